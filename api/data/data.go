@@ -47,6 +47,7 @@ var (
 		TopicMeasureSeriesIndexUpdate.String():  TopicMeasureSeriesIndexUpdate,
 		TopicMeasureSeriesSync.String():         TopicMeasureSeriesSync,
 		TopicPropertyRepair.String():            TopicPropertyRepair,
+		TopicPropertyWatch.String():             TopicPropertyWatch,
 		TopicStreamSeriesIndexWrite.String():    TopicStreamSeriesIndexWrite,
 		TopicStreamLocalIndexWrite.String():     TopicStreamLocalIndexWrite,
 		TopicStreamSeriesSync.String():          TopicStreamSeriesSync,
@@ -65,6 +66,12 @@ var (
 		TopicMeasureDropGroup.String():          TopicMeasureDropGroup,
 		TopicStreamDropGroup.String():           TopicStreamDropGroup,
 		TopicTraceDropGroup.String():            TopicTraceDropGroup,
+		TopicStreamDeleteData.String():          TopicStreamDeleteData,
+		TopicMeasureDeleteData.String():         TopicMeasureDeleteData,
+		TopicTraceDeleteData.String():           TopicTraceDeleteData,
+		TopicMeasureIndexBackfill.String():      TopicMeasureIndexBackfill,
+		TopicStreamIndexBackfill.String():       TopicStreamIndexBackfill,
+		TopicTraceIndexBackfill.String():        TopicTraceIndexBackfill,
 	}
 
 	// TopicRequestMap is the map of topic name to request message.
@@ -115,6 +122,9 @@ var (
 		TopicPropertyRepair: func() proto.Message {
 			return &propertyv1.InternalRepairRequest{}
 		},
+		TopicPropertyWatch: func() proto.Message {
+			return &propertyv1.InternalWatchRequest{}
+		},
 		TopicStreamSeriesIndexWrite: func() proto.Message {
 			return nil
 		},
@@ -169,6 +179,24 @@ var (
 		TopicTraceDropGroup: func() proto.Message {
 			return &databasev1.GroupRegistryServiceDeleteRequest{}
 		},
+		TopicStreamDeleteData: func() proto.Message {
+			return &streamv1.DeleteDataRequest{}
+		},
+		TopicMeasureDeleteData: func() proto.Message {
+			return &measurev1.DeleteDataRequest{}
+		},
+		TopicTraceDeleteData: func() proto.Message {
+			return &tracev1.DeleteDataRequest{}
+		},
+		TopicMeasureIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{}
+		},
+		TopicStreamIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{}
+		},
+		TopicTraceIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{}
+		},
 	}
 
 	// TopicResponseMap is the map of topic name to response message.
@@ -193,11 +221,14 @@ var (
 			return &propertyv1.DeleteResponse{}
 		},
 		TopicPropertyUpdate: func() proto.Message {
-			return &propertyv1.ApplyResponse{}
+			return &propertyv1.InternalUpdateResponse{}
 		},
 		TopicPropertyRepair: func() proto.Message {
 			return &propertyv1.InternalRepairResponse{}
 		},
+		TopicPropertyWatch: func() proto.Message {
+			return &propertyv1.InternalWatchResponse{}
+		},
 		TopicTraceQuery: func() proto.Message {
 			return &tracev1.InternalQueryResponse{}
 		},
@@ -228,6 +259,24 @@ var (
 		TopicTraceDropGroup: func() proto.Message {
 			return &databasev1.GroupRegistryServiceDeleteRequest{}
 		},
+		TopicStreamDeleteData: func() proto.Message {
+			return &streamv1.InternalDeleteDataResponse{}
+		},
+		TopicMeasureDeleteData: func() proto.Message {
+			return &measurev1.InternalDeleteDataResponse{}
+		},
+		TopicTraceDeleteData: func() proto.Message {
+			return &tracev1.InternalDeleteDataResponse{}
+		},
+		TopicMeasureIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{}
+		},
+		TopicStreamIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{}
+		},
+		TopicTraceIndexBackfill: func() proto.Message {
+			return &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{}
+		},
 	}
 
 	// TopicCommon is the common topic for data transmission.
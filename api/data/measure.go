@@ -68,6 +68,15 @@ var MeasureDeleteExpiredSegmentsKindVersion = common.KindVersion{
 // TopicMeasureDeleteExpiredSegments is the measure delete topic.
 var TopicMeasureDeleteExpiredSegments = bus.BiTopic(MeasureDeleteExpiredSegmentsKindVersion.String())
 
+// MeasureDeleteDataKindVersion is the version tag of measure delete data kind.
+var MeasureDeleteDataKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-delete-data",
+}
+
+// TopicMeasureDeleteData is the measure delete data topic.
+var TopicMeasureDeleteData = bus.BiTopic(MeasureDeleteDataKindVersion.String())
+
 // MeasurePartSyncKindVersion is the version tag of measure part sync kind.
 var MeasurePartSyncKindVersion = common.KindVersion{
 	Version: "v1",
@@ -112,3 +121,6 @@ var TopicMeasureCollectLiaisonInfo = bus.BiTopic("measure-collect-liaison-info")
 
 // TopicMeasureDropGroup is the topic for dropping group data files.
 var TopicMeasureDropGroup = bus.BiTopic("measure-drop-group")
+
+// TopicMeasureIndexBackfill is the topic for scheduling index backfill jobs.
+var TopicMeasureIndexBackfill = bus.BiTopic("measure-index-backfill")
@@ -57,3 +57,12 @@ var PropertyRepairKindVersion = common.KindVersion{
 
 // TopicPropertyRepair is the property repair topic.
 var TopicPropertyRepair = bus.BiTopic(PropertyRepairKindVersion.String())
+
+// PropertyWatchKindVersion is the version tag of property watch kind.
+var PropertyWatchKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "property-watch",
+}
+
+// TopicPropertyWatch is the property watch topic.
+var TopicPropertyWatch = bus.BiTopic(PropertyWatchKindVersion.String())
@@ -49,6 +49,15 @@ var StreamDeleteExpiredSegmentsKindVersion = common.KindVersion{
 // TopicDeleteExpiredStreamSegments is the delete stream segments topic.
 var TopicDeleteExpiredStreamSegments = bus.BiTopic(StreamDeleteExpiredSegmentsKindVersion.String())
 
+// StreamDeleteDataKindVersion is the version tag of stream delete data kind.
+var StreamDeleteDataKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-delete-data",
+}
+
+// TopicStreamDeleteData is the stream delete data topic.
+var TopicStreamDeleteData = bus.BiTopic(StreamDeleteDataKindVersion.String())
+
 // StreamPartSyncKindVersion is the version tag of part sync kind.
 var StreamPartSyncKindVersion = common.KindVersion{
 	Version: "v1",
@@ -102,3 +111,6 @@ var TopicStreamCollectLiaisonInfo = bus.BiTopic("stream-collect-liaison-info")
 
 // TopicStreamDropGroup is the topic for dropping group data files.
 var TopicStreamDropGroup = bus.BiTopic("stream-drop-group")
+
+// TopicStreamIndexBackfill is the topic for scheduling index backfill jobs.
+var TopicStreamIndexBackfill = bus.BiTopic("stream-index-backfill")
@@ -49,6 +49,15 @@ var TraceDeleteExpiredSegmentsKindVersion = common.KindVersion{
 // TopicDeleteExpiredTraceSegments is the delete trace segments topic.
 var TopicDeleteExpiredTraceSegments = bus.BiTopic(TraceDeleteExpiredSegmentsKindVersion.String())
 
+// TraceDeleteDataKindVersion is the version tag of trace delete data kind.
+var TraceDeleteDataKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "trace-delete-data",
+}
+
+// TopicTraceDeleteData is the trace delete data topic.
+var TopicTraceDeleteData = bus.BiTopic(TraceDeleteDataKindVersion.String())
+
 // TracePartSyncKindVersion is the version tag of part sync kind.
 var TracePartSyncKindVersion = common.KindVersion{
 	Version: "v1",
@@ -84,3 +93,6 @@ var TopicTraceCollectLiaisonInfo = bus.BiTopic("trace-collect-liaison-info")
 
 // TopicTraceDropGroup is the topic for dropping group data files.
 var TopicTraceDropGroup = bus.BiTopic("trace-drop-group")
+
+// TopicTraceIndexBackfill is the topic for scheduling index backfill jobs.
+var TopicTraceIndexBackfill = bus.BiTopic("trace-index-backfill")
@@ -25,6 +25,7 @@ import (
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 )
 
 const reservedTagSeparator = "#"
@@ -57,8 +58,13 @@ func Group(group *commonv1.Group) error {
 		if group.ResourceOpts.SegmentInterval != nil {
 			return errors.New("segmentInterval should be nil")
 		}
-		if group.ResourceOpts.Ttl != nil {
-			return errors.New("ttl should be nil")
+		if ttl := group.ResourceOpts.Ttl; ttl != nil {
+			if ttl.Num <= 0 {
+				return errors.New("ttl num is invalid")
+			}
+			if ttl.Unit == commonv1.IntervalRule_UNIT_UNSPECIFIED {
+				return errors.New("ttl unit is unspecified")
+			}
 		}
 		return nil
 	}
@@ -131,7 +137,55 @@ func Stream(stream *databasev1.Stream) error {
 	if len(stream.Entity.TagNames) == 0 {
 		return errors.New("stream entity tag names is empty")
 	}
-	return tagFamily(stream.TagFamilies)
+	if err := tagFamily(stream.TagFamilies); err != nil {
+		return err
+	}
+	return streamProjections(stream)
+}
+
+func streamProjections(stream *databasev1.Stream) error {
+	tagTypes := make(map[string]databasev1.TagType)
+	for _, tf := range stream.TagFamilies {
+		for _, t := range tf.Tags {
+			tagTypes[t.Name] = t.Type
+		}
+	}
+	entitySet := make(map[string]struct{}, len(stream.Entity.TagNames))
+	for _, name := range stream.Entity.TagNames {
+		entitySet[name] = struct{}{}
+	}
+	names := make(map[string]struct{}, len(stream.Projections))
+	for _, p := range stream.Projections {
+		if p.Name == "" {
+			return errors.New("stream projection name is empty")
+		}
+		if strings.ContainsAny(p.Name, "/\\") {
+			return fmt.Errorf("stream projection name %q must not contain a path separator", p.Name)
+		}
+		if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("stream projection %q is duplicated", p.Name)
+		}
+		names[p.Name] = struct{}{}
+		typ, ok := tagTypes[p.SortTagName]
+		if !ok {
+			return fmt.Errorf("sort tag %q of stream projection %q is not found", p.SortTagName, p.Name)
+		}
+		if typ != databasev1.TagType_TAG_TYPE_INT {
+			return fmt.Errorf("sort tag %q of stream projection %q is not an int tag", p.SortTagName, p.Name)
+		}
+		if _, isEntity := entitySet[p.SortTagName]; isEntity {
+			return fmt.Errorf("sort tag %q of stream projection %q is an entity tag", p.SortTagName, p.Name)
+		}
+		for _, name := range p.TagNames {
+			if _, ok := tagTypes[name]; !ok {
+				return fmt.Errorf("tag %q of stream projection %q is not found", name, p.Name)
+			}
+			if _, isEntity := entitySet[name]; isEntity {
+				return fmt.Errorf("tag %q of stream projection %q is an entity tag", name, p.Name)
+			}
+		}
+	}
+	return nil
 }
 
 // Measure validates the provided Measure object.
@@ -297,6 +351,40 @@ func IndexRule(indexRule *databasev1.IndexRule) error {
 	return nil
 }
 
+// Analyzer validates the provided Analyzer object.
+// It checks for nil values, empty strings, and unspecified enum values.
+func Analyzer(analyzer *databasev1.Analyzer) error {
+	if analyzer == nil {
+		return errors.New("analyzer is nil")
+	}
+	if analyzer.Metadata == nil {
+		return errors.New("analyzer metadata is nil")
+	}
+	if analyzer.Metadata.Name == "" {
+		return errors.New("analyzer name is empty")
+	}
+	if analyzer.Metadata.Group == "" {
+		return errors.New("analyzer group is empty")
+	}
+	if analyzer.Tokenizer == nil {
+		return errors.New("analyzer tokenizer is nil")
+	}
+	if analyzer.Tokenizer.Type == databasev1.Tokenizer_TYPE_UNSPECIFIED {
+		return errors.New("analyzer tokenizer type is unspecified")
+	}
+	for _, cf := range analyzer.CharFilters {
+		if cf.GetType() == databasev1.CharFilter_TYPE_UNSPECIFIED {
+			return errors.New("analyzer char filter type is unspecified")
+		}
+	}
+	for _, tf := range analyzer.TokenFilters {
+		if tf.GetType() == databasev1.TokenFilter_TYPE_UNSPECIFIED {
+			return errors.New("analyzer token filter type is unspecified")
+		}
+	}
+	return nil
+}
+
 // IndexRuleBinding validates the provided IndexRuleBinding object.
 // It checks for nil values, empty strings, and unspecified enum values.
 func IndexRuleBinding(indexRuleBinding *databasev1.IndexRuleBinding) error {
@@ -357,5 +445,82 @@ func TopNAggregation(topNAggregation *databasev1.TopNAggregation) error {
 	if topNAggregation.FieldName == "" {
 		return errors.New("topNAggregation fieldName is empty")
 	}
+	switch window := topNAggregation.GetWindow(); window.GetType() {
+	case databasev1.TopNWindow_TYPE_TUMBLING:
+		// tumbling windows follow the source measure's interval
+	case databasev1.TopNWindow_TYPE_SLIDING:
+		if window.Size == "" || window.Slide == "" {
+			return errors.New("topNAggregation sliding window requires size and slide")
+		}
+	case databasev1.TopNWindow_TYPE_SESSION:
+		if window.Gap == "" {
+			return errors.New("topNAggregation session window requires gap")
+		}
+	}
+	return nil
+}
+
+// ContinuousAggregation validates the provided ContinuousAggregation object.
+func ContinuousAggregation(ca *databasev1.ContinuousAggregation) error {
+	if ca == nil {
+		return errors.New("continuousAggregation is nil")
+	}
+	if ca.Metadata == nil {
+		return errors.New("continuousAggregation metadata is nil")
+	}
+	if ca.Metadata.Name == "" {
+		return errors.New("continuousAggregation name is empty")
+	}
+	if ca.Metadata.Group == "" {
+		return errors.New("continuousAggregation group is empty")
+	}
+	if ca.Source.GetName() == "" || ca.Source.GetGroup() == "" {
+		return errors.New("continuousAggregation source is invalid")
+	}
+	if ca.SourceCatalog != commonv1.Catalog_CATALOG_MEASURE && ca.SourceCatalog != commonv1.Catalog_CATALOG_STREAM {
+		return fmt.Errorf("continuousAggregation source catalog %s is not supported", ca.SourceCatalog)
+	}
+	if ca.Target.GetName() == "" || ca.Target.GetGroup() == "" {
+		return errors.New("continuousAggregation target is invalid")
+	}
+	if ca.SourceCatalog == commonv1.Catalog_CATALOG_MEASURE &&
+		ca.Source.GetGroup() == ca.Target.GetGroup() && ca.Source.GetName() == ca.Target.GetName() {
+		return errors.New("continuousAggregation target must not be its source")
+	}
+	if len(ca.Aggregations) == 0 {
+		return errors.New("continuousAggregation aggregations are empty")
+	}
+	targetFields := make(map[string]struct{}, len(ca.Aggregations))
+	for _, a := range ca.Aggregations {
+		switch a.GetFunction() {
+		case modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT:
+		case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN, modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+			modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN, modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM:
+			if a.GetSourceName() == "" {
+				return fmt.Errorf("continuousAggregation %s requires a source name", a.GetFunction())
+			}
+		default:
+			return fmt.Errorf("continuousAggregation function %s is not supported", a.GetFunction())
+		}
+		if a.GetTargetField() == "" {
+			return errors.New("continuousAggregation target field is empty")
+		}
+		if _, ok := targetFields[a.GetTargetField()]; ok {
+			return fmt.Errorf("continuousAggregation target field %s is duplicated", a.GetTargetField())
+		}
+		targetFields[a.GetTargetField()] = struct{}{}
+	}
+	switch window := ca.GetWindow(); window.GetType() {
+	case databasev1.TopNWindow_TYPE_TUMBLING:
+		// tumbling windows follow the target measure's interval
+	case databasev1.TopNWindow_TYPE_SLIDING:
+		if window.Size == "" || window.Slide == "" {
+			return errors.New("continuousAggregation sliding window requires size and slide")
+		}
+	case databasev1.TopNWindow_TYPE_SESSION:
+		if window.Gap == "" {
+			return errors.New("continuousAggregation session window requires gap")
+		}
+	}
 	return nil
 }
@@ -195,6 +195,8 @@ type measureColumnFamilyMetadata struct {
 	columns []measureColumnMetadata
 }
 
+const measureColumnStatsFlag = 0x80
+
 type measureColumnMetadata struct {
 	name      string
 	dataBlock measureDataBlock
@@ -801,10 +803,19 @@ func (cm *measureColumnMetadata) unmarshal(src []byte) ([]byte, error) {
 	if len(src) < 1 {
 		return nil, fmt.Errorf("cannot unmarshal columnMetadata.valueType: src is too short")
 	}
-	cm.valueType = pbv1.ValueType(src[0])
+	// The high bit marks the column which carries the stats: count, sum, min and max.
+	hasStats := src[0]&measureColumnStatsFlag != 0
+	cm.valueType = pbv1.ValueType(src[0] &^ measureColumnStatsFlag)
 	src = src[1:]
 	src = cm.dataBlock.unmarshal(src)
-	return src, nil
+	if !hasStats {
+		return src, nil
+	}
+	src, _ = encoding.BytesToVarUint64(src)
+	if len(src) < 24 {
+		return nil, fmt.Errorf("cannot unmarshal columnMetadata.stats: src is too short")
+	}
+	return src[24:], nil
 }
 
 func readMeasureTimestamps(tm measureTimestampsMetadata, count int, reader fs.Reader) ([]int64, []int64, error) {
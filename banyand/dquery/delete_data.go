@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// defaultDeleteDataTimeout is longer than the query timeout since data nodes
+// have to scan every row matching the criteria.
+const defaultDeleteDataTimeout = time.Minute
+
+type keyedResponse interface {
+	GetKeys() []uint64
+}
+
+// deleteDataProcessor sends a DeleteData request to all data nodes and merges the matched keys.
+// Data in every stage is deleted, so the request is not routed by node selectors.
+type deleteDataProcessor struct {
+	broadcaster bus.Broadcaster
+	*queryService
+	*bus.UnImplementedHealthyListener
+	newResponse func(keys []uint64) proto.Message
+	topic       bus.Topic
+}
+
+func newStreamDeleteDataProcessor(svc *queryService, broadcaster bus.Broadcaster, topic bus.Topic) *deleteDataProcessor {
+	return &deleteDataProcessor{
+		queryService: svc,
+		broadcaster:  broadcaster,
+		topic:        topic,
+		newResponse: func(keys []uint64) proto.Message {
+			return &streamv1.InternalDeleteDataResponse{Keys: keys}
+		},
+	}
+}
+
+func newMeasureDeleteDataProcessor(svc *queryService, broadcaster bus.Broadcaster, topic bus.Topic) *deleteDataProcessor {
+	return &deleteDataProcessor{
+		queryService: svc,
+		broadcaster:  broadcaster,
+		topic:        topic,
+		newResponse: func(keys []uint64) proto.Message {
+			return &measurev1.InternalDeleteDataResponse{Keys: keys}
+		},
+	}
+}
+
+func newTraceDeleteDataProcessor(svc *queryService, broadcaster bus.Broadcaster, topic bus.Topic) *deleteDataProcessor {
+	return &deleteDataProcessor{
+		queryService: svc,
+		broadcaster:  broadcaster,
+		topic:        topic,
+		newResponse: func(keys []uint64) proto.Message {
+			return &tracev1.InternalDeleteDataResponse{Keys: keys}
+		},
+	}
+}
+
+func (p *deleteDataProcessor) Rev(_ context.Context, message bus.Message) (resp bus.Message) {
+	now := bus.MessageID(time.Now().UnixNano())
+	if p.log.Debug().Enabled() {
+		if req, ok := message.Data().(proto.Message); ok {
+			p.log.Debug().Str("topic", p.topic.String()).RawJSON("req", logger.Proto(req)).Msg("received a delete data request")
+		}
+	}
+	ff, err := p.broadcaster.Broadcast(defaultDeleteDataTimeout, p.topic, bus.NewMessage(now, message.Data()))
+	if err != nil {
+		return bus.NewMessage(now, common.NewError("fail to broadcast the delete data request: %v", err))
+	}
+	var keys []uint64
+	var errs error
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			errs = multierr.Append(errs, getErr)
+			continue
+		}
+		switch d := m.Data().(type) {
+		case keyedResponse:
+			keys = append(keys, d.GetKeys()...)
+		case *common.Error:
+			errs = multierr.Append(errs, errors.New(d.Error()))
+		}
+	}
+	if errs != nil {
+		return bus.NewMessage(now, common.NewError("fail to delete data: %v", errs))
+	}
+	return bus.NewMessage(now, p.newResponse(keys))
+}
@@ -65,6 +65,9 @@ type queryService struct {
 	mqp                  *measureQueryProcessor
 	nqp                  *topNQueryProcessor
 	tqp                  *traceQueryProcessor
+	sdp                  *deleteDataProcessor
+	mdp                  *deleteDataProcessor
+	tdp                  *deleteDataProcessor
 	closer               *run.Closer
 	nodeID               string
 	hotStageNodeSelector string
@@ -100,6 +103,9 @@ func NewService(metaService metadata.Repo, pipeline queue.Server, broadcaster bu
 		traceService: traceSchemaSVC,
 		broadcaster:  broadcaster,
 	}
+	svc.sdp = newStreamDeleteDataProcessor(svc, broadcaster, data.TopicStreamDeleteData)
+	svc.mdp = newMeasureDeleteDataProcessor(svc, broadcaster, data.TopicMeasureDeleteData)
+	svc.tdp = newTraceDeleteDataProcessor(svc, broadcaster, data.TopicTraceDeleteData)
 	return svc, nil
 }
 
@@ -136,6 +142,9 @@ func (q *queryService) PreRun(ctx context.Context) error {
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.nqp),
 		q.pipeline.Subscribe(data.TopicTraceQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicStreamDeleteData, q.sdp),
+		q.pipeline.Subscribe(data.TopicMeasureDeleteData, q.mdp),
+		q.pipeline.Subscribe(data.TopicTraceDeleteData, q.tdp),
 	)
 }
 
@@ -0,0 +1,452 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	// IndexBackfillFilename is the name of the file persisting the index backfill progress of a shard.
+	IndexBackfillFilename = "index-backfill.json"
+	// DefaultIndexBackfillRateLimit is the number of rows indexed per second in a shard
+	// when the backfill request doesn't set a rate limit.
+	DefaultIndexBackfillRateLimit = 10000
+
+	indexBackfillCheckInterval = time.Minute
+)
+
+var (
+	// ErrIndexBackfillTaskReplaced indicates the task was rescheduled or removed while it was running.
+	ErrIndexBackfillTaskReplaced = errors.New("index backfill task is replaced")
+	// ErrIndexBackfillNotReady indicates the subject's schema doesn't contain the bound index rules yet.
+	ErrIndexBackfillNotReady = errors.New("index rules are not applied to the subject yet")
+)
+
+// indexBackfillMu serializes the read-modify-write cycles of the progress files.
+var indexBackfillMu sync.Mutex
+
+// IndexBackfillTask is the progress of backfilling an index rule binding in a shard.
+type IndexBackfillTask struct {
+	ScheduledAt    time.Time                          `json:"scheduled_at"`
+	UpdatedAt      time.Time                          `json:"updated_at"`
+	Binding        string                             `json:"binding"`
+	Subject        string                             `json:"subject"`
+	Message        string                             `json:"message,omitempty"`
+	Rules          []string                           `json:"rules"`
+	ProcessedParts []uint64                           `json:"processed_parts,omitempty"`
+	TotalParts     int64                              `json:"total_parts"`
+	IndexedCount   int64                              `json:"indexed_count"`
+	RateLimit      uint32                             `json:"rate_limit"`
+	Phase          databasev1.IndexBackfillInfo_Phase `json:"phase"`
+}
+
+// IsDone reports whether the task has nothing left to do.
+func (t *IndexBackfillTask) IsDone() bool {
+	return t.Phase == databasev1.IndexBackfillInfo_PHASE_COMPLETED || t.Phase == databasev1.IndexBackfillInfo_PHASE_FAILED
+}
+
+// IsProcessed reports whether the part has been indexed.
+func (t *IndexBackfillTask) IsProcessed(partID uint64) bool {
+	for _, id := range t.ProcessedParts {
+		if id == partID {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkProcessed records that the part has been indexed.
+func (t *IndexBackfillTask) MarkProcessed(partID uint64, indexed int) {
+	if !t.IsProcessed(partID) {
+		t.ProcessedParts = append(t.ProcessedParts, partID)
+	}
+	t.IndexedCount += int64(indexed)
+}
+
+// Limiter returns a limiter throttling the rows indexed by the task.
+func (t *IndexBackfillTask) Limiter() *IndexBackfillLimiter {
+	r := t.RateLimit
+	if r == 0 {
+		r = DefaultIndexBackfillRateLimit
+	}
+	return &IndexBackfillLimiter{l: rate.NewLimiter(rate.Limit(r), int(r))}
+}
+
+// IndexBackfillLimiter throttles the rows indexed by a backfill task.
+type IndexBackfillLimiter struct {
+	l *rate.Limiter
+}
+
+// Wait blocks until n rows are allowed to be indexed.
+func (l *IndexBackfillLimiter) Wait(ctx context.Context, n int) error {
+	burst := l.l.Burst()
+	for n > 0 {
+		c := n
+		if c > burst {
+			c = burst
+		}
+		if err := l.l.WaitN(ctx, c); err != nil {
+			return err
+		}
+		n -= c
+	}
+	return nil
+}
+
+// Info converts the task to the inspection message.
+func (t *IndexBackfillTask) Info() *databasev1.IndexBackfillInfo {
+	return &databasev1.IndexBackfillInfo{
+		Binding:        t.Binding,
+		Subject:        t.Subject,
+		CurrentPhase:   t.Phase,
+		TotalParts:     t.TotalParts,
+		ProcessedParts: int64(len(t.ProcessedParts)),
+		IndexedCount:   t.IndexedCount,
+		Message:        t.Message,
+		UpdatedAt:      timestamppb.New(t.UpdatedAt),
+	}
+}
+
+// IndexBackfillProgress is the index backfill progress of a shard.
+// It's persisted in the shard directory so that the jobs resume after a restart,
+// and it's dropped along with the segment by the retention.
+type IndexBackfillProgress struct {
+	Tasks []*IndexBackfillTask `json:"tasks"`
+}
+
+// Schedule adds a task for the binding, replacing the existing one.
+func (p *IndexBackfillProgress) Schedule(binding, subject string, rules []string, rateLimit uint32) *IndexBackfillTask {
+	now := time.Now()
+	task := &IndexBackfillTask{
+		ScheduledAt: now,
+		UpdatedAt:   now,
+		Binding:     binding,
+		Subject:     subject,
+		Rules:       rules,
+		RateLimit:   rateLimit,
+		Phase:       databasev1.IndexBackfillInfo_PHASE_PENDING,
+	}
+	for i := range p.Tasks {
+		if p.Tasks[i].Binding == binding {
+			p.Tasks[i] = task
+			return task
+		}
+	}
+	p.Tasks = append(p.Tasks, task)
+	return task
+}
+
+// Pending returns the tasks which are not done yet.
+func (p *IndexBackfillProgress) Pending() []*IndexBackfillTask {
+	var tt []*IndexBackfillTask
+	for _, t := range p.Tasks {
+		if !t.IsDone() {
+			tt = append(tt, t)
+		}
+	}
+	return tt
+}
+
+// Infos converts the tasks to the inspection messages.
+func (p *IndexBackfillProgress) Infos() []*databasev1.IndexBackfillInfo {
+	if p == nil || len(p.Tasks) == 0 {
+		return nil
+	}
+	infos := make([]*databasev1.IndexBackfillInfo, 0, len(p.Tasks))
+	for _, t := range p.Tasks {
+		infos = append(infos, t.Info())
+	}
+	return infos
+}
+
+func (p *IndexBackfillProgress) replace(task *IndexBackfillTask) error {
+	for i := range p.Tasks {
+		if p.Tasks[i].Binding == task.Binding && p.Tasks[i].ScheduledAt.Equal(task.ScheduledAt) {
+			task.UpdatedAt = time.Now()
+			p.Tasks[i] = task
+			return nil
+		}
+	}
+	return ErrIndexBackfillTaskReplaced
+}
+
+// LoadIndexBackfillProgress reads the index backfill progress of the shard located at root.
+// A shard without any backfill job gets an empty progress.
+func LoadIndexBackfillProgress(fileSystem fs.FileSystem, root string) (*IndexBackfillProgress, error) {
+	indexBackfillMu.Lock()
+	defer indexBackfillMu.Unlock()
+	return loadIndexBackfillProgress(fileSystem, root)
+}
+
+// UpdateIndexBackfillProgress applies fn to the progress of the shard located at root and persists the result.
+func UpdateIndexBackfillProgress(fileSystem fs.FileSystem, root string, fn func(p *IndexBackfillProgress) error) error {
+	indexBackfillMu.Lock()
+	defer indexBackfillMu.Unlock()
+	p, err := loadIndexBackfillProgress(fileSystem, root)
+	if err != nil {
+		return err
+	}
+	if err = fn(p); err != nil {
+		return err
+	}
+	return saveIndexBackfillProgress(fileSystem, root, p)
+}
+
+// SaveIndexBackfillTask persists the task. It fails with ErrIndexBackfillTaskReplaced
+// if the task has been rescheduled since it was loaded.
+func SaveIndexBackfillTask(fileSystem fs.FileSystem, root string, task *IndexBackfillTask) error {
+	return UpdateIndexBackfillProgress(fileSystem, root, func(p *IndexBackfillProgress) error {
+		return p.replace(task)
+	})
+}
+
+// ScheduleIndexBackfill schedules the backfill job of the binding in every shard of the database.
+// It returns the number of segment shards scheduled.
+func ScheduleIndexBackfill[T TSTable, O any](db TSDB[T, O], binding *databasev1.IndexRuleBinding, rateLimit uint32,
+	shardRoot func(table T) (fs.FileSystem, string),
+) (int64, error) {
+	segments, err := db.SelectSegments(timestamp.TimeRange{
+		Start: time.Unix(0, 0),
+		End:   time.Unix(0, timestamp.MaxNanoTime),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		for _, s := range segments {
+			s.DecRef()
+		}
+	}()
+	var count int64
+	for _, s := range segments {
+		tables, _ := s.Tables()
+		for _, t := range tables {
+			fileSystem, root := shardRoot(t)
+			if err = UpdateIndexBackfillProgress(fileSystem, root, func(p *IndexBackfillProgress) error {
+				p.Schedule(binding.GetMetadata().GetName(), binding.GetSubject().GetName(), binding.GetRules(), rateLimit)
+				return nil
+			}); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RunIndexBackfill runs the unfinished backfill tasks of every shard of the database.
+func RunIndexBackfill[T TSTable, O any](ctx context.Context, l *logger.Logger, db TSDB[T, O],
+	shardRoot func(table T) (fs.FileSystem, string),
+	runTask func(ctx context.Context, segment Segment[T, O], table T, task *IndexBackfillTask) error,
+) {
+	segments, err := db.SelectSegments(timestamp.TimeRange{
+		Start: time.Unix(0, 0),
+		End:   time.Unix(0, timestamp.MaxNanoTime),
+	})
+	if err != nil {
+		l.Warn().Err(err).Msg("cannot select segments to backfill the index")
+		return
+	}
+	defer func() {
+		for _, s := range segments {
+			s.DecRef()
+		}
+	}()
+	for _, s := range segments {
+		tables, _ := s.Tables()
+		for _, t := range tables {
+			if ctx.Err() != nil {
+				return
+			}
+			fileSystem, root := shardRoot(t)
+			RunIndexBackfillTasks(ctx, l, fileSystem, root, func(ctx context.Context, task *IndexBackfillTask) error {
+				return runTask(ctx, s, t, task)
+			})
+		}
+	}
+}
+
+// RunIndexBackfillTasks runs the unfinished tasks of the shard located at root one by one.
+// A task waiting for its index rules stays pending, and a task failing with any other error
+// is marked as failed.
+func RunIndexBackfillTasks(ctx context.Context, l *logger.Logger, fileSystem fs.FileSystem, root string,
+	runTask func(ctx context.Context, task *IndexBackfillTask) error,
+) {
+	p, err := LoadIndexBackfillProgress(fileSystem, root)
+	if err != nil {
+		l.Warn().Err(err).Str("shard", root).Msg("cannot load the index backfill progress")
+		return
+	}
+	for _, task := range p.Pending() {
+		err = runTask(ctx, task)
+		switch {
+		case err == nil, errors.Is(err, ErrIndexBackfillTaskReplaced):
+			continue
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, ErrIndexBackfillNotReady):
+			task.Message = err.Error()
+		default:
+			l.Error().Err(err).Str("shard", root).Str("binding", task.Binding).Msg("index backfill failed")
+			task.Phase = databasev1.IndexBackfillInfo_PHASE_FAILED
+			task.Message = err.Error()
+		}
+		if err = SaveIndexBackfillTask(fileSystem, root, task); err != nil && !errors.Is(err, ErrIndexBackfillTaskReplaced) {
+			l.Warn().Err(err).Str("shard", root).Msg("cannot save the index backfill progress")
+		}
+	}
+}
+
+// FindIndexBackfillGroups returns the groups under the data root which have unfinished backfill jobs.
+func FindIndexBackfillGroups(fileSystem fs.FileSystem, root string) []string {
+	matches, err := filepath.Glob(filepath.Join(root, "*", segPathPrefix+"-*", shardPathPrefix+"-*", IndexBackfillFilename))
+	if err != nil {
+		return nil
+	}
+	groups := make(map[string]struct{})
+	for _, m := range matches {
+		shardRoot := filepath.Dir(m)
+		group := filepath.Base(filepath.Dir(filepath.Dir(shardRoot)))
+		if _, ok := groups[group]; ok {
+			continue
+		}
+		p, loadErr := LoadIndexBackfillProgress(fileSystem, shardRoot)
+		if loadErr != nil || len(p.Pending()) == 0 {
+			continue
+		}
+		groups[group] = struct{}{}
+	}
+	result := make([]string, 0, len(groups))
+	for g := range groups {
+		result = append(result, g)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func loadIndexBackfillProgress(fileSystem fs.FileSystem, root string) (*IndexBackfillProgress, error) {
+	p := &IndexBackfillProgress{}
+	name := filepath.Join(root, IndexBackfillFilename)
+	if !fileSystem.IsExist(name) {
+		return p, nil
+	}
+	data, err := fileSystem.Read(name)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot read %s", name)
+	}
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, errors.WithMessagef(err, "cannot parse %s", name)
+	}
+	return p, nil
+}
+
+func saveIndexBackfillProgress(fileSystem fs.FileSystem, root string, p *IndexBackfillProgress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return errors.WithMessage(err, "cannot marshal index backfill progress")
+	}
+	name := filepath.Join(root, IndexBackfillFilename)
+	tmp := name + ".tmp"
+	if _, err = fileSystem.Write(data, tmp, FilePerm); err != nil {
+		return errors.WithMessagef(err, "cannot write %s", tmp)
+	}
+	if err = fileSystem.Rename(tmp, name); err != nil {
+		return errors.WithMessagef(err, "cannot rename %s", tmp)
+	}
+	return nil
+}
+
+// IndexBackfillRunner runs the unfinished index backfill jobs found under a data root in the background.
+// The groups are checked when the runner starts, when it's triggered, and periodically
+// to pick up the jobs whose schema changes arrived late.
+type IndexBackfillRunner struct {
+	fileSystem fs.FileSystem
+	l          *logger.Logger
+	closer     *run.Closer
+	trigger    chan struct{}
+	runGroup   func(ctx context.Context, group string)
+	root       string
+}
+
+// NewIndexBackfillRunner returns a runner calling runGroup for each group having unfinished jobs.
+func NewIndexBackfillRunner(l *logger.Logger, fileSystem fs.FileSystem, root string,
+	runGroup func(ctx context.Context, group string),
+) *IndexBackfillRunner {
+	return &IndexBackfillRunner{
+		fileSystem: fileSystem,
+		l:          l,
+		closer:     run.NewCloser(1),
+		trigger:    make(chan struct{}, 1),
+		runGroup:   runGroup,
+		root:       root,
+	}
+}
+
+// Start launches the background loop.
+func (r *IndexBackfillRunner) Start() {
+	go r.loop()
+}
+
+// Trigger wakes the loop up to run the newly scheduled jobs.
+func (r *IndexBackfillRunner) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Stop terminates the loop and waits for the running job to stop.
+func (r *IndexBackfillRunner) Stop() {
+	r.closer.CloseThenWait()
+}
+
+func (r *IndexBackfillRunner) loop() {
+	defer r.closer.Done()
+	ticker := time.NewTicker(indexBackfillCheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, g := range FindIndexBackfillGroups(r.fileSystem, r.root) {
+			if r.closer.Closed() {
+				return
+			}
+			r.runGroup(r.closer.Ctx(), g)
+		}
+		select {
+		case <-r.closer.CloseNotify():
+			return
+		case <-r.trigger:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func scheduleTestIndexBackfill(t *testing.T, fileSystem fs.FileSystem, root, binding string) *IndexBackfillTask {
+	var task *IndexBackfillTask
+	require.NoError(t, UpdateIndexBackfillProgress(fileSystem, root, func(p *IndexBackfillProgress) error {
+		task = p.Schedule(binding, "sw", []string{"rule"}, 100)
+		return nil
+	}))
+	return task
+}
+
+func TestIndexBackfillProgress_SaveAndLoad(t *testing.T) {
+	root := t.TempDir()
+	fileSystem := fs.NewLocalFileSystem()
+
+	p, err := LoadIndexBackfillProgress(fileSystem, root)
+	require.NoError(t, err)
+	assert.Empty(t, p.Tasks)
+	assert.Nil(t, p.Infos())
+
+	task := scheduleTestIndexBackfill(t, fileSystem, root, "binding")
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_IN_PROGRESS
+	task.TotalParts = 2
+	task.MarkProcessed(1, 10)
+	task.MarkProcessed(1, 5)
+	require.NoError(t, SaveIndexBackfillTask(fileSystem, root, task))
+
+	p, err = LoadIndexBackfillProgress(fileSystem, root)
+	require.NoError(t, err)
+	require.Len(t, p.Pending(), 1)
+	loaded := p.Pending()[0]
+	assert.Equal(t, []uint64{1}, loaded.ProcessedParts)
+	assert.True(t, loaded.IsProcessed(1))
+	assert.False(t, loaded.IsProcessed(2))
+	assert.Equal(t, int64(15), loaded.IndexedCount)
+
+	infos := p.Infos()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "binding", infos[0].Binding)
+	assert.Equal(t, databasev1.IndexBackfillInfo_PHASE_IN_PROGRESS, infos[0].CurrentPhase)
+	assert.Equal(t, int64(2), infos[0].TotalParts)
+	assert.Equal(t, int64(1), infos[0].ProcessedParts)
+
+	loaded.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+	require.NoError(t, SaveIndexBackfillTask(fileSystem, root, loaded))
+	p, err = LoadIndexBackfillProgress(fileSystem, root)
+	require.NoError(t, err)
+	assert.Empty(t, p.Pending())
+	assert.Len(t, p.Tasks, 1)
+}
+
+func TestIndexBackfillProgress_Reschedule(t *testing.T) {
+	root := t.TempDir()
+	fileSystem := fs.NewLocalFileSystem()
+
+	stale := scheduleTestIndexBackfill(t, fileSystem, root, "binding")
+	// Make sure the rescheduled task gets a different schedule time.
+	time.Sleep(time.Millisecond)
+	scheduleTestIndexBackfill(t, fileSystem, root, "binding")
+	scheduleTestIndexBackfill(t, fileSystem, root, "another")
+
+	stale.MarkProcessed(1, 1)
+	err := SaveIndexBackfillTask(fileSystem, root, stale)
+	require.ErrorIs(t, err, ErrIndexBackfillTaskReplaced)
+
+	p, err := LoadIndexBackfillProgress(fileSystem, root)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 2)
+	assert.Empty(t, p.Tasks[0].ProcessedParts)
+	assert.Equal(t, databasev1.IndexBackfillInfo_PHASE_PENDING, p.Tasks[0].Phase)
+}
+
+func TestRunIndexBackfillTasks(t *testing.T) {
+	root := t.TempDir()
+	fileSystem := fs.NewLocalFileSystem()
+	l := logger.GetLogger("test")
+
+	scheduleTestIndexBackfill(t, fileSystem, root, "done")
+	scheduleTestIndexBackfill(t, fileSystem, root, "not-ready")
+	scheduleTestIndexBackfill(t, fileSystem, root, "failed")
+
+	RunIndexBackfillTasks(context.Background(), l, fileSystem, root, func(_ context.Context, task *IndexBackfillTask) error {
+		switch task.Binding {
+		case "done":
+			task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+			return SaveIndexBackfillTask(fileSystem, root, task)
+		case "not-ready":
+			return ErrIndexBackfillNotReady
+		default:
+			return errors.New("broken part")
+		}
+	})
+
+	p, err := LoadIndexBackfillProgress(fileSystem, root)
+	require.NoError(t, err)
+	require.Len(t, p.Tasks, 3)
+	assert.Equal(t, databasev1.IndexBackfillInfo_PHASE_COMPLETED, p.Tasks[0].Phase)
+	assert.Equal(t, databasev1.IndexBackfillInfo_PHASE_PENDING, p.Tasks[1].Phase)
+	assert.Equal(t, ErrIndexBackfillNotReady.Error(), p.Tasks[1].Message)
+	assert.Equal(t, databasev1.IndexBackfillInfo_PHASE_FAILED, p.Tasks[2].Phase)
+	assert.Equal(t, "broken part", p.Tasks[2].Message)
+}
+
+func TestFindIndexBackfillGroups(t *testing.T) {
+	root := t.TempDir()
+	fileSystem := fs.NewLocalFileSystem()
+
+	shardRoot := func(group string) string {
+		return filepath.Join(root, group, "seg-20240101", "shard-0")
+	}
+	fileSystem.MkdirIfNotExist(shardRoot("pending"), 0o755)
+	fileSystem.MkdirIfNotExist(shardRoot("done"), 0o755)
+	scheduleTestIndexBackfill(t, fileSystem, shardRoot("pending"), "binding")
+	done := scheduleTestIndexBackfill(t, fileSystem, shardRoot("done"), "binding")
+	done.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+	require.NoError(t, SaveIndexBackfillTask(fileSystem, shardRoot("done"), done))
+
+	assert.Equal(t, []string{"pending"}, FindIndexBackfillGroups(fileSystem, root))
+}
+
+func TestIndexBackfillLimiter(t *testing.T) {
+	task := &IndexBackfillTask{RateLimit: 10}
+	limiter := task.Limiter()
+	// The burst allows the first 10 rows immediately, the next 10 rows take about a second.
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), 20))
+	assert.GreaterOrEqual(t, time.Since(start), 800*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, limiter.Wait(ctx, 1))
+}
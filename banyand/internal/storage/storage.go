@@ -212,6 +212,23 @@ func (ir IntervalRule) estimatedDuration() time.Duration {
 	panic("invalid interval unit")
 }
 
+// ToIntervalRule converts a commonv1.IntervalRule to IntervalRule, or returns an error if it's invalid.
+func ToIntervalRule(ir *commonv1.IntervalRule) (result IntervalRule, err error) {
+	switch ir.GetUnit() {
+	case commonv1.IntervalRule_UNIT_DAY:
+		result.Unit = DAY
+	case commonv1.IntervalRule_UNIT_HOUR:
+		result.Unit = HOUR
+	default:
+		return result, errors.Errorf("unknown interval rule: %v", ir)
+	}
+	if ir.GetNum() <= 0 {
+		return result, errors.Errorf("invalid interval rule num: %v", ir)
+	}
+	result.Num = int(ir.GetNum())
+	return result, nil
+}
+
 // MustToIntervalRule converts a commonv1.IntervalRule to IntervalRule.
 func MustToIntervalRule(ir *commonv1.IntervalRule) (result IntervalRule) {
 	switch ir.Unit {
@@ -238,6 +238,8 @@ func protoKindToString(protoKind string) string {
 		return schema.KindIndexRuleBinding.String()
 	case "top_n_aggregation":
 		return schema.KindTopNAggregation.String()
+	case "continuous_aggregation":
+		return schema.KindContinuousAggregation.String()
 	default:
 		// For "stream", "measure", "trace", "group", "property", "node" the
 		// proto kind string already matches kind.String().
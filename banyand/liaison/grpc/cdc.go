@@ -0,0 +1,553 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/cdc"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	// cdcReadBatch is the maximum number of the changes read from the log at once.
+	cdcReadBatch = 1000
+	// cdcPollInterval is how often a subscription reads the changes settled since the last read.
+	cdcPollInterval = time.Second
+	// cdcRetryInterval is how long the recording of a schema change or a group deletion waits before a retry.
+	cdcRetryInterval = time.Second
+	// cdcExpireInterval is how often the changes beyond the retention are dropped.
+	cdcExpireInterval = time.Minute
+	// cdcSchemaKinds are the kinds of the recorded schema changes.
+	cdcSchemaKinds = schema.KindMask &^ schema.KindNode
+)
+
+var errCDCStopped = status.Error(codes.Unavailable, "the server is stopping")
+
+// cdcStore stores the change log as the properties of the internal group.
+type cdcStore interface {
+	propertyApplier
+	Delete(ctx context.Context, req *propertyv1.DeleteRequest) (*propertyv1.DeleteResponse, error)
+}
+
+// cdcService records the changes into the change log shared by the liaisons, and serves the subscriptions to the log.
+// The recording methods do nothing if the change data capture is disabled.
+type cdcService struct {
+	cdcv1.UnimplementedChangeDataCaptureServiceServer
+	schema.UnimplementedOnInitHandler
+	ctx     context.Context
+	store   cdcStore
+	metrics *metrics
+	l       *logger.Logger
+	cancel  context.CancelFunc
+	notify  chan struct{}
+	// pending are the changes recorded in the background, which no request waits for.
+	pending     []*cdcv1.ChangeEvent
+	retention   time.Duration
+	settleDelay time.Duration
+	mu          sync.Mutex
+}
+
+func newCDCService() *cdcService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cdcService{ctx: ctx, cancel: cancel, notify: make(chan struct{}, 1)}
+}
+
+func (c *cdcService) enabled() bool {
+	return c != nil && c.store != nil
+}
+
+// internalGroup reports whether the group stores the internal data, such as the schemas and the deletion tasks.
+func internalGroup(group string) bool {
+	return strings.HasPrefix(group, "_")
+}
+
+// initStorage creates the internal group and the property schemas storing the change log.
+func (c *cdcService) initStorage(ctx context.Context, schemaRegistry metadata.Repo, replicas uint32) error {
+	if _, err := schemaRegistry.GroupRegistry().GetGroup(ctx, cdc.Group); err != nil {
+		if !errors.Is(err, schema.ErrGRPCResourceNotFound) {
+			return fmt.Errorf("failed to get the group of the change log: %w", err)
+		}
+		if _, err = schemaRegistry.GroupRegistry().CreateGroup(ctx, cdc.NewGroup(replicas)); err != nil {
+			return fmt.Errorf("failed to create the group of the change log: %w", err)
+		}
+	}
+	for _, propSchema := range cdc.NewPropertySchemas() {
+		if _, err := schemaRegistry.PropertyRegistry().GetProperty(ctx, propSchema.Metadata); err != nil {
+			if !errors.Is(err, schema.ErrGRPCResourceNotFound) {
+				return fmt.Errorf("failed to get the property schema %s of the change log: %w", propSchema.Metadata.Name, err)
+			}
+			if err = schemaRegistry.PropertyRegistry().CreateProperty(ctx, propSchema); err != nil {
+				return fmt.Errorf("failed to create the property schema %s of the change log: %w", propSchema.Metadata.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// record stores the change unless another liaison has stored it.
+func (c *cdcService) record(ctx context.Context, event *cdcv1.ChangeEvent) error {
+	event.Id = cdc.ID(event)
+	if event.Time == nil {
+		event.Time = timestamppb.Now()
+	}
+	p, err := cdc.ToProperty(event)
+	if err != nil {
+		return err
+	}
+	_, err = c.store.Apply(ctx, &propertyv1.ApplyRequest{
+		Property:     p,
+		Strategy:     propertyv1.ApplyRequest_STRATEGY_REPLACE,
+		Precondition: &propertyv1.ApplyRequest_MustNotExist{MustNotExist: true},
+	})
+	if status.Code(err) == codes.FailedPrecondition {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if late := time.Since(time.Unix(0, event.GetRevision())); late > c.settleDelay {
+		c.l.Warn().Str("id", event.GetId()).Dur("late", late).
+			Msg("the change is recorded after the settle delay, the subscriptions having passed its revision miss it")
+	}
+	return nil
+}
+
+// recordSync records a change a request waits for. The request fails if the change isn't recorded,
+// so that the client retries it instead of leaving the subscribers behind.
+func (c *cdcService) recordSync(ctx context.Context, event *cdcv1.ChangeEvent) error {
+	if err := c.record(ctx, event); err != nil {
+		c.l.Error().Err(err).Stringer("type", event.GetType()).Str("group", event.GetGroup()).Str("name", event.GetName()).
+			Msg("failed to record the change")
+		return status.Errorf(codes.Unavailable, "the change is made, but failed to be recorded in the change log: %v", err)
+	}
+	return nil
+}
+
+// enqueue records the change in the background.
+func (c *cdcService) enqueue(event *cdcv1.ChangeEvent) {
+	if event.Time == nil {
+		event.Time = timestamppb.Now()
+	}
+	c.mu.Lock()
+	c.pending = append(c.pending, event)
+	c.mu.Unlock()
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// recordLoop records the pending changes in order until the service stops. A failed change is retried.
+func (c *cdcService) recordLoop() {
+	failed := false
+	for {
+		var event *cdcv1.ChangeEvent
+		c.mu.Lock()
+		if len(c.pending) > 0 {
+			event = c.pending[0]
+		}
+		c.mu.Unlock()
+		if event == nil {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-c.notify:
+			}
+			continue
+		}
+		if err := c.record(c.ctx, event); err != nil {
+			if !failed && c.ctx.Err() == nil {
+				c.l.Warn().Err(err).Stringer("type", event.GetType()).Str("group", event.GetGroup()).Str("name", event.GetName()).
+					Msg("failed to record the change, retrying")
+				failed = true
+			}
+			select {
+			case <-c.ctx.Done():
+				c.mu.Lock()
+				c.l.Warn().Int("changes", len(c.pending)).Msg("the server stops before the changes are recorded")
+				c.mu.Unlock()
+				return
+			case <-time.After(cdcRetryInterval):
+			}
+			continue
+		}
+		failed = false
+		c.mu.Lock()
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+		c.mu.Unlock()
+	}
+}
+
+// OnAddOrUpdate records a created or updated schema.
+func (c *cdcService) OnAddOrUpdate(m schema.Metadata) {
+	c.recordSchema(m, false)
+}
+
+// OnDelete records a deleted schema.
+func (c *cdcService) OnDelete(m schema.Metadata) {
+	c.recordSchema(m, true)
+}
+
+// recordSchema records the schema change received from the schema registry. Every liaison receives it,
+// and the first one recording it wins.
+func (c *cdcService) recordSchema(m schema.Metadata, deleted bool) {
+	if !c.enabled() || internalGroup(m.Group) {
+		return
+	}
+	spec, ok := m.Spec.(proto.Message)
+	if !ok {
+		return
+	}
+	event, ok := cdc.NewSchemaEvent(proto.Clone(spec), deleted)
+	if !ok || internalGroup(event.GetGroup()) {
+		return
+	}
+	now := time.Now()
+	if deleted {
+		// the registry doesn't tell when the schema is deleted
+		event.Revision = now.UnixNano()
+	} else if c.retention > 0 && event.GetRevision() <= now.Add(-c.retention).UnixNano() {
+		// the registry replays all the schemas on start, while the old changes have been dropped
+		return
+	}
+	c.enqueue(event)
+}
+
+func (c *cdcService) recordPropertyApply(ctx context.Context, property *propertyv1.Property) error {
+	if !c.enabled() || internalGroup(property.GetMetadata().GetGroup()) {
+		return nil
+	}
+	return c.recordSync(ctx, &cdcv1.ChangeEvent{
+		Revision: property.GetMetadata().GetModRevision(),
+		Type:     cdcv1.ChangeType_CHANGE_TYPE_PROPERTY_APPLY,
+		Group:    property.GetMetadata().GetGroup(),
+		Name:     property.GetMetadata().GetName(),
+		Change:   &cdcv1.ChangeEvent_Property{Property: &cdcv1.PropertyChange{Property: proto.Clone(property).(*propertyv1.Property)}},
+	})
+}
+
+func (c *cdcService) recordPropertyDelete(ctx context.Context, group, name, id string, deleteTime time.Time) error {
+	if !c.enabled() || internalGroup(group) {
+		return nil
+	}
+	return c.recordSync(ctx, &cdcv1.ChangeEvent{
+		Revision: deleteTime.UnixNano(),
+		Type:     cdcv1.ChangeType_CHANGE_TYPE_PROPERTY_DELETE,
+		Group:    group,
+		Name:     name,
+		Change: &cdcv1.ChangeEvent_Property{Property: &cdcv1.PropertyChange{Property: &propertyv1.Property{
+			Metadata: &commonv1.Metadata{Group: group, Name: name},
+			Id:       id,
+		}}},
+	})
+}
+
+// recordGroupDeletion records a completed group deletion task in the background, since the task runs after the request.
+func (c *cdcService) recordGroupDeletion(group string, catalog commonv1.Catalog, dataOnly bool) {
+	if !c.enabled() || internalGroup(group) {
+		return
+	}
+	c.enqueue(&cdcv1.ChangeEvent{
+		Revision: time.Now().UnixNano(),
+		Type:     cdcv1.ChangeType_CHANGE_TYPE_GROUP_DELETE,
+		Group:    group,
+		Change:   &cdcv1.ChangeEvent_GroupDeletion{GroupDeletion: &cdcv1.GroupDeletion{Catalog: catalog, DataOnly: dataOnly}},
+	})
+}
+
+// recordDataDeletion records a change for each group of the request.
+func (c *cdcService) recordDataDeletion(ctx context.Context, catalog commonv1.Catalog, req deleteDataRequest, deleted int64) error {
+	if !c.enabled() || req.GetDryRun() {
+		return nil
+	}
+	now := time.Now().UnixNano()
+	for i, g := range req.GetGroups() {
+		if err := c.recordSync(ctx, &cdcv1.ChangeEvent{
+			// the groups get their own revisions
+			Revision: now + int64(i),
+			Type:     cdcv1.ChangeType_CHANGE_TYPE_DATA_DELETE,
+			Group:    g,
+			Name:     req.GetName(),
+			Change: &cdcv1.ChangeEvent_DataDeletion{DataDeletion: &cdcv1.DataDeletion{
+				Catalog:   catalog,
+				TimeRange: req.GetTimeRange(),
+				Criteria:  req.GetCriteria(),
+				Deleted:   deleted,
+			}},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireLoop drops the changes beyond the retention until the service stops.
+// Every liaison runs it, and the drops of the same changes are harmless.
+func (c *cdcService) expireLoop() {
+	if c.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cdcExpireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := c.expire(c.ctx, now); err != nil && c.ctx.Err() == nil {
+				c.l.Error().Err(err).Msg("failed to expire the changes")
+			}
+		}
+	}
+}
+
+// expire drops the changes older than the retention. It raises the compacted revision before dropping them,
+// so that a subscription which hasn't read them fails instead of missing them.
+func (c *cdcService) expire(ctx context.Context, now time.Time) error {
+	deadline := now.Add(-c.retention).UnixNano()
+	for {
+		resp, err := c.store.Query(ctx, &propertyv1.QueryRequest{
+			Groups:        []string{cdc.Group},
+			Name:          cdc.EventName,
+			Criteria:      revisionCondition(modelv1.Condition_BINARY_OP_LE, deadline),
+			TagProjection: []string{cdc.RevisionTag},
+			OrderBy:       &propertyv1.QueryOrder{TagName: cdc.RevisionTag, Sort: modelv1.Sort_SORT_ASC},
+			Limit:         cdcReadBatch,
+		})
+		if err != nil {
+			return err
+		}
+		expired := resp.GetProperties()
+		if len(expired) == 0 {
+			return nil
+		}
+		if err = c.compact(ctx, cdc.Revision(expired[len(expired)-1])); err != nil {
+			return err
+		}
+		for _, p := range expired {
+			if _, err = c.store.Delete(ctx, &propertyv1.DeleteRequest{Group: cdc.Group, Name: cdc.EventName, Id: p.GetId()}); err != nil {
+				return err
+			}
+		}
+		if len(expired) < cdcReadBatch {
+			return nil
+		}
+	}
+}
+
+// compact raises the compacted revision to the revision. The liaisons race to raise it,
+// so the update is conditional on the version it's raised from.
+func (c *cdcService) compact(ctx context.Context, revision int64) error {
+	var previous int64 = -1
+	for {
+		compacted, modRevision, err := c.compacted(ctx)
+		if err != nil {
+			return err
+		}
+		if compacted >= revision {
+			return nil
+		}
+		if modRevision == previous {
+			return errors.Errorf("failed to raise the compacted revision %d with the mod revision %d", compacted, modRevision)
+		}
+		previous = modRevision
+		req := &propertyv1.ApplyRequest{
+			Property: &propertyv1.Property{
+				Metadata: &commonv1.Metadata{Group: cdc.Group, Name: cdc.CompactedName},
+				Id:       cdc.CompactedID,
+				Tags:     []*modelv1.Tag{{Key: cdc.RevisionTag, Value: cdc.IntValue(revision)}},
+			},
+			Strategy: propertyv1.ApplyRequest_STRATEGY_REPLACE,
+		}
+		if modRevision == 0 {
+			req.Precondition = &propertyv1.ApplyRequest_MustNotExist{MustNotExist: true}
+		} else {
+			req.Precondition = &propertyv1.ApplyRequest_ExpectedModRevision{ExpectedModRevision: modRevision}
+		}
+		if _, err = c.store.Apply(ctx, req); status.Code(err) != codes.FailedPrecondition {
+			return err
+		}
+	}
+}
+
+// compacted returns the revision of the last change dropped by the retention, and the mod revision of the property holding it.
+func (c *cdcService) compacted(ctx context.Context) (int64, int64, error) {
+	resp, err := c.store.Query(ctx, &propertyv1.QueryRequest{
+		Groups: []string{cdc.Group},
+		Name:   cdc.CompactedName,
+		Ids:    []string{cdc.CompactedID},
+		Limit:  1,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(resp.GetProperties()) == 0 {
+		return 0, 0, nil
+	}
+	p := resp.GetProperties()[0]
+	return cdc.Revision(p), p.GetMetadata().GetModRevision(), nil
+}
+
+// watermark returns the revision up to which the changes are sent at the time. The changes made in the settle delay
+// are held back, since the changes of other liaisons with earlier revisions might still be on their way.
+func (c *cdcService) watermark(now time.Time) int64 {
+	return now.Add(-c.settleDelay).UnixNano()
+}
+
+// read returns the changes after the revision up to the watermark in the order of their revisions,
+// and whether there are more of them.
+func (c *cdcService) read(ctx context.Context, after, watermark int64) ([]*cdcv1.ChangeEvent, bool, error) {
+	if after >= watermark {
+		return nil, false, nil
+	}
+	resp, err := c.store.Query(ctx, &propertyv1.QueryRequest{
+		Groups: []string{cdc.Group},
+		Name:   cdc.EventName,
+		Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+			Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+			Left:  revisionCondition(modelv1.Condition_BINARY_OP_GT, after),
+			Right: revisionCondition(modelv1.Condition_BINARY_OP_LE, watermark),
+		}}},
+		OrderBy: &propertyv1.QueryOrder{TagName: cdc.RevisionTag, Sort: modelv1.Sort_SORT_ASC},
+		Limit:   cdcReadBatch,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	properties := resp.GetProperties()
+	more := len(properties) == cdcReadBatch
+	if more {
+		// the changes sharing the last revision are left to the next read, which starts after a revision
+		last, n := cdc.Revision(properties[len(properties)-1]), len(properties)
+		for n > 0 && cdc.Revision(properties[n-1]) == last {
+			n--
+		}
+		if n > 0 {
+			properties = properties[:n]
+		}
+	}
+	events := make([]*cdcv1.ChangeEvent, 0, len(properties))
+	for _, p := range properties {
+		event, decodeErr := cdc.FromProperty(p)
+		if decodeErr != nil {
+			return nil, false, decodeErr
+		}
+		events = append(events, event)
+	}
+	return events, more, nil
+}
+
+func revisionCondition(op modelv1.Condition_BinaryOp, revision int64) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  cdc.RevisionTag,
+		Op:    op,
+		Value: cdc.IntValue(revision),
+	}}}
+}
+
+// stop ends the recording and the subscriptions, which never end by themselves.
+func (c *cdcService) stop() {
+	c.cancel()
+}
+
+func (c *cdcService) Subscribe(req *cdcv1.SubscribeRequest, stream cdcv1.ChangeDataCaptureService_SubscribeServer) (err error) {
+	c.metrics.totalStreamStarted.Inc(1, "cdc", "subscribe")
+	start := time.Now()
+	defer func() {
+		c.metrics.totalStreamFinished.Inc(1, "cdc", "subscribe")
+		c.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "cdc", "subscribe")
+		if err != nil && status.Code(err) != codes.Canceled {
+			c.metrics.totalStreamErr.Inc(1, "cdc", "subscribe")
+		}
+	}()
+	ctx := stream.Context()
+	after := req.GetStartRevision()
+	switch {
+	case after < 0:
+		after = c.watermark(start)
+	case after == 0:
+		if after, _, err = c.compacted(ctx); err != nil {
+			return err
+		}
+	}
+	types := make(map[cdcv1.ChangeType]struct{}, len(req.GetTypes()))
+	for _, t := range req.GetTypes() {
+		types[t] = struct{}{}
+	}
+	groups := make(map[string]struct{}, len(req.GetGroups()))
+	for _, g := range req.GetGroups() {
+		groups[g] = struct{}{}
+	}
+	for {
+		events, more, readErr := c.read(ctx, after, c.watermark(time.Now()))
+		if readErr != nil {
+			return readErr
+		}
+		// the compacted revision is raised before the changes are dropped, so it tells whether the read missed some
+		compacted, _, compactedErr := c.compacted(ctx)
+		if compactedErr != nil {
+			return compactedErr
+		}
+		if compacted > after {
+			return status.Errorf(codes.OutOfRange, "the changes after revision %d have been dropped by the retention up to revision %d",
+				after, compacted)
+		}
+		for _, e := range events {
+			after = e.GetRevision()
+			if _, ok := types[e.GetType()]; len(types) > 0 && !ok {
+				continue
+			}
+			if _, ok := groups[e.GetGroup()]; len(groups) > 0 && !ok {
+				continue
+			}
+			if err = stream.Send(&cdcv1.SubscribeResponse{Event: e}); err != nil {
+				c.metrics.totalStreamMsgSentErr.Inc(1, e.GetGroup(), "cdc", "subscribe")
+				return err
+			}
+			c.metrics.totalStreamMsgSent.Inc(1, e.GetGroup(), "cdc", "subscribe")
+		}
+		if more {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return errCDCStopped
+		case <-time.After(cdcPollInterval):
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/cdc"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// memoryCDCStore keeps the properties of the change log in memory, the way the data nodes apply the preconditions.
+type memoryCDCStore struct {
+	properties map[string]*propertyv1.Property
+	modRev     int64
+	mu         sync.Mutex
+}
+
+func (s *memoryCDCStore) Apply(_ context.Context, req *propertyv1.ApplyRequest) (*propertyv1.ApplyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := req.GetProperty().GetMetadata().GetName() + "/" + req.GetProperty().GetId()
+	prev, exists := s.properties[key]
+	if req.GetMustNotExist() && exists {
+		return nil, status.Error(codes.FailedPrecondition, "the property exists")
+	}
+	if expected := req.GetExpectedModRevision(); expected > 0 && (!exists || prev.GetMetadata().GetModRevision() != expected) {
+		return nil, status.Error(codes.FailedPrecondition, "the property is modified")
+	}
+	s.modRev++
+	p := proto.Clone(req.GetProperty()).(*propertyv1.Property)
+	p.Metadata.ModRevision = s.modRev
+	s.properties[key] = p
+	return &propertyv1.ApplyResponse{Created: !exists, ModRevision: s.modRev}, nil
+}
+
+func (s *memoryCDCStore) Query(_ context.Context, req *propertyv1.QueryRequest) (*propertyv1.QueryResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var properties []*propertyv1.Property
+	for _, p := range s.properties {
+		if p.GetMetadata().GetName() != req.GetName() {
+			continue
+		}
+		if len(req.GetIds()) > 0 && p.GetId() != req.GetIds()[0] {
+			continue
+		}
+		if req.GetCriteria() != nil && !matchRevision(req.GetCriteria(), cdc.Revision(p)) {
+			continue
+		}
+		properties = append(properties, proto.Clone(p).(*propertyv1.Property))
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		return cdc.Revision(properties[i]) < cdc.Revision(properties[j])
+	})
+	if req.GetLimit() > 0 && len(properties) > int(req.GetLimit()) {
+		properties = properties[:req.GetLimit()]
+	}
+	return &propertyv1.QueryResponse{Properties: properties}, nil
+}
+
+func (s *memoryCDCStore) Delete(_ context.Context, req *propertyv1.DeleteRequest) (*propertyv1.DeleteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := req.GetName() + "/" + req.GetId()
+	_, exists := s.properties[key]
+	delete(s.properties, key)
+	return &propertyv1.DeleteResponse{Deleted: exists}, nil
+}
+
+func (s *memoryCDCStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, p := range s.properties {
+		if p.GetMetadata().GetName() == cdc.EventName {
+			n++
+		}
+	}
+	return n
+}
+
+// matchRevision evaluates the criteria on the revisions built by the service.
+func matchRevision(criteria *modelv1.Criteria, revision int64) bool {
+	if le := criteria.GetLe(); le != nil {
+		return matchRevision(le.GetLeft(), revision) && matchRevision(le.GetRight(), revision)
+	}
+	v := criteria.GetCondition().GetValue().GetInt().GetValue()
+	switch criteria.GetCondition().GetOp() {
+	case modelv1.Condition_BINARY_OP_GT:
+		return revision > v
+	case modelv1.Condition_BINARY_OP_LE:
+		return revision <= v
+	default:
+		panic("unexpected operator")
+	}
+}
+
+func newTestCDCService(t *testing.T, retention time.Duration) (*cdcService, *memoryCDCStore) {
+	store := &memoryCDCStore{properties: make(map[string]*propertyv1.Property)}
+	c := newCDCService()
+	c.store, c.metrics, c.l = store, newBypassMetrics(), logger.GetLogger("cdc-test")
+	c.retention, c.settleDelay = retention, 100*time.Millisecond
+	go c.recordLoop()
+	t.Cleanup(c.stop)
+	return c, store
+}
+
+func TestCDCSubscribe_FiltersChanges(t *testing.T) {
+	c, _ := newTestCDCService(t, 0)
+	ctx := context.Background()
+	c.recordGroupDeletion("g1", commonv1.Catalog_CATALOG_STREAM, true)
+	c.recordGroupDeletion("g2", commonv1.Catalog_CATALOG_STREAM, false)
+	require.NoError(t, c.recordPropertyDelete(ctx, "g1", "p", "1", time.Now()))
+	c.recordGroupDeletion("_internal", commonv1.Catalog_CATALOG_PROPERTY, false)
+
+	ctx, cancel := context.WithCancel(ctx)
+	srv := newMockTailServer[cdcv1.SubscribeResponse](ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(&cdcv1.SubscribeRequest{
+			StartRevision: 1,
+			Types:         []cdcv1.ChangeType{cdcv1.ChangeType_CHANGE_TYPE_GROUP_DELETE},
+			Groups:        []string{"g1"},
+		}, srv)
+	}()
+	var resp *cdcv1.SubscribeResponse
+	select {
+	case resp = <-srv.sent:
+		assert.True(t, resp.GetEvent().GetGroupDeletion().GetDataOnly())
+		assert.NotEmpty(t, resp.GetEvent().GetId())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the recorded change is not sent")
+	}
+	first := resp.GetEvent().GetRevision()
+
+	// the changes recorded while subscribed are sent as well
+	c.recordGroupDeletion("g1", commonv1.Catalog_CATALOG_MEASURE, false)
+	select {
+	case resp = <-srv.sent:
+		assert.Greater(t, resp.GetEvent().GetRevision(), first)
+		assert.Equal(t, commonv1.Catalog_CATALOG_MEASURE, resp.GetEvent().GetGroupDeletion().GetCatalog())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new change is not sent")
+	}
+	assert.Empty(t, srv.sent)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestCDCRecordSchema_RecordedOnce(t *testing.T) {
+	c, store := newTestCDCService(t, time.Hour)
+	stream := &databasev1.Stream{Metadata: &commonv1.Metadata{Group: "g1", Name: "s", ModRevision: time.Now().UnixNano()}}
+	m := schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindStream, Group: "g1", Name: "s"}, Spec: stream}
+	// every liaison receives the change from the registry
+	other := &cdcService{store: store, l: c.l}
+	c.OnAddOrUpdate(m)
+	require.NoError(t, other.record(context.Background(), func() *cdcv1.ChangeEvent {
+		event, ok := cdc.NewSchemaEvent(stream, false)
+		require.True(t, ok)
+		return event
+	}()))
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, store.count())
+
+	// the schemas replayed by the registry are skipped once their changes are dropped
+	stream = proto.Clone(stream).(*databasev1.Stream)
+	stream.Metadata.Name, stream.Metadata.ModRevision = "old", time.Now().Add(-2*time.Hour).UnixNano()
+	c.OnAddOrUpdate(schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindStream, Group: "g1", Name: "old"}, Spec: stream})
+	c.mu.Lock()
+	assert.Empty(t, c.pending)
+	c.mu.Unlock()
+}
+
+func TestCDCSubscribe_CompactedRevision_ReturnsOutOfRange(t *testing.T) {
+	c, store := newTestCDCService(t, time.Hour)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, c.recordPropertyDelete(ctx, "g1", "p", "1", now.Add(-3*time.Hour)))
+	require.NoError(t, c.recordPropertyDelete(ctx, "g1", "p", "2", now.Add(-2*time.Hour)))
+	require.NoError(t, c.recordPropertyDelete(ctx, "g1", "p", "3", now.Add(-time.Minute)))
+	require.NoError(t, c.expire(ctx, now))
+	// expiring again is harmless
+	require.NoError(t, c.expire(ctx, now))
+	assert.Equal(t, 1, store.count())
+	compacted, _, err := c.compacted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-2*time.Hour).UnixNano(), compacted)
+
+	srv := newMockTailServer[cdcv1.SubscribeResponse](ctx)
+	err = c.Subscribe(&cdcv1.SubscribeRequest{StartRevision: 1}, srv)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// a subscription from the earliest retained change starts after the compacted revision
+	ctx, cancel := context.WithCancel(ctx)
+	srv = newMockTailServer[cdcv1.SubscribeResponse](ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(&cdcv1.SubscribeRequest{}, srv)
+	}()
+	select {
+	case resp := <-srv.sent:
+		assert.Equal(t, "3", resp.GetEvent().GetProperty().GetProperty().GetId())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the retained change is not sent")
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestCDCSubscribe_Stop_EndsSubscriptions(t *testing.T) {
+	c, _ := newTestCDCService(t, 0)
+	srv := newMockTailServer[cdcv1.SubscribeResponse](context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(&cdcv1.SubscribeRequest{StartRevision: -1}, srv)
+	}()
+	c.stop()
+	assert.Equal(t, codes.Unavailable, status.Code(<-done))
+}
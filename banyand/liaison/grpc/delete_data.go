@@ -0,0 +1,146 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var errDeleteDataMsg = errors.New("invalid delete data message")
+
+// deleteDataRequest is the common part of the stream, measure and trace DeleteDataRequest.
+type deleteDataRequest interface {
+	proto.Message
+	GetGroups() []string
+	GetName() string
+	GetTimeRange() *modelv1.TimeRange
+	GetCriteria() *modelv1.Criteria
+	GetDryRun() bool
+}
+
+// keyedResponse is the common part of the stream, measure and trace InternalDeleteDataResponse.
+type keyedResponse interface {
+	GetKeys() []uint64
+}
+
+func (s *streamService) DeleteData(ctx context.Context, req *streamv1.DeleteDataRequest) (*streamv1.DeleteDataResponse, error) {
+	deleted, err := deleteData(ctx, s.discoveryService, s.broadcaster, s.metrics, "stream", data.TopicStreamDeleteData, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.cdc.recordDataDeletion(ctx, commonv1.Catalog_CATALOG_STREAM, req, deleted); err != nil {
+		return nil, err
+	}
+	return &streamv1.DeleteDataResponse{Deleted: deleted, DryRun: req.GetDryRun()}, nil
+}
+
+func (ms *measureService) DeleteData(ctx context.Context, req *measurev1.DeleteDataRequest) (*measurev1.DeleteDataResponse, error) {
+	deleted, err := deleteData(ctx, ms.discoveryService, ms.broadcaster, ms.metrics, "measure", data.TopicMeasureDeleteData, req)
+	if !req.GetDryRun() {
+		ms.queryCache.invalidateGroups(req.GetGroups())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = ms.cdc.recordDataDeletion(ctx, commonv1.Catalog_CATALOG_MEASURE, req, deleted); err != nil {
+		return nil, err
+	}
+	return &measurev1.DeleteDataResponse{Deleted: deleted, DryRun: req.GetDryRun()}, nil
+}
+
+func (s *traceService) DeleteData(ctx context.Context, req *tracev1.DeleteDataRequest) (*tracev1.DeleteDataResponse, error) {
+	deleted, err := deleteData(ctx, s.discoveryService, s.broadcaster, s.metrics, "trace", data.TopicTraceDeleteData, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.cdc.recordDataDeletion(ctx, commonv1.Catalog_CATALOG_TRACE, req, deleted); err != nil {
+		return nil, err
+	}
+	return &tracev1.DeleteDataResponse{Deleted: deleted, DryRun: req.GetDryRun()}, nil
+}
+
+// deleteData sends the request to the query processors and counts the distinct keys they matched.
+// Replicas of a shard report the same keys, so counting distinct keys avoids counting a row twice.
+func deleteData(ctx context.Context, ds *discoveryService, broadcaster queue.Client, m *metrics,
+	catalog string, topic bus.Topic, req deleteDataRequest,
+) (deleted int64, err error) {
+	groups := req.GetGroups()
+	for _, g := range groups {
+		if acquireErr := ds.groupRepo.acquireRequest(g); acquireErr != nil {
+			return 0, status.Errorf(codes.FailedPrecondition, "group %s is pending deletion", g)
+		}
+	}
+	defer func() {
+		for _, g := range groups {
+			ds.groupRepo.releaseRequest(g)
+		}
+	}()
+	for _, g := range groups {
+		m.totalStarted.Inc(1, g, catalog, "delete_data")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range groups {
+			m.totalFinished.Inc(1, g, catalog, "delete_data")
+			if err != nil {
+				m.totalErr.Inc(1, g, catalog, "delete_data")
+			}
+			m.totalLatency.Inc(time.Since(start).Seconds(), g, catalog, "delete_data")
+		}
+	}()
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	message := bus.NewMessage(bus.MessageID(start.UnixNano()), req)
+	feat, err := broadcaster.Publish(ctx, topic, message)
+	if err != nil {
+		return 0, err
+	}
+	msg, err := feat.Get()
+	if err != nil {
+		return 0, err
+	}
+	switch d := msg.Data().(type) {
+	case keyedResponse:
+		keys := make(map[uint64]struct{}, len(d.GetKeys()))
+		for _, k := range d.GetKeys() {
+			keys[k] = struct{}{}
+		}
+		return int64(len(keys)), nil
+	case *common.Error:
+		return 0, errors.WithMessage(errDeleteDataMsg, d.Error())
+	}
+	return 0, errDeleteDataMsg
+}
@@ -53,6 +53,7 @@ type groupDeletionTaskManager struct {
 	propServer     propertyApplier
 	log            *logger.Logger
 	groupRepo      *groupRepo
+	cdc            *cdcService
 	tasks          sync.Map
 }
 
@@ -203,6 +204,7 @@ func (m *groupDeletionTaskManager) executeDeletion(ctx context.Context, group st
 		task.CurrentPhase = databasev1.GroupDeletionTask_PHASE_COMPLETED
 		task.Message = "data files deleted successfully"
 		m.saveProgress(ctx, group, task)
+		m.cdc.recordGroupDeletion(group, groupMeta.GetCatalog(), true)
 		return
 	}
 
@@ -214,6 +216,8 @@ func (m *groupDeletionTaskManager) executeDeletion(ctx context.Context, group st
 	steps := []deletionStep{
 		{func() error { return m.deleteIndexRuleBindings(ctx, opt, task) }, "deleting index rule bindings"},
 		{func() error { return m.deleteIndexRules(ctx, opt, task) }, "deleting index rules"},
+		{func() error { return m.deleteAnalyzers(ctx, opt, task) }, "deleting analyzers"},
+		{func() error { return m.deleteContinuousAggregations(ctx, opt, task) }, "deleting continuous aggregations"},
 		{func() error { return m.deleteProperties(ctx, opt, task) }, "deleting properties"},
 		{func() error { return m.deleteStreams(ctx, opt, task) }, "deleting streams"},
 		{func() error { return m.deleteMeasures(ctx, opt, task) }, "deleting measures"},
@@ -230,6 +234,7 @@ func (m *groupDeletionTaskManager) executeDeletion(ctx context.Context, group st
 	task.CurrentPhase = databasev1.GroupDeletionTask_PHASE_COMPLETED
 	task.Message = "group deleted successfully"
 	m.saveProgress(ctx, group, task)
+	m.cdc.recordGroupDeletion(group, groupMeta.GetCatalog(), false)
 }
 
 func (m *groupDeletionTaskManager) deleteIndexRuleBindings(
@@ -266,6 +271,40 @@ func (m *groupDeletionTaskManager) deleteIndexRules(
 	return nil
 }
 
+func (m *groupDeletionTaskManager) deleteAnalyzers(
+	ctx context.Context, opt schema.ListOpt, task *databasev1.GroupDeletionTask,
+) error {
+	analyzers, listErr := m.schemaRegistry.AnalyzerRegistry().ListAnalyzer(ctx, opt)
+	if listErr != nil {
+		return listErr
+	}
+	task.TotalCounts["analyzer"] = int32(len(analyzers))
+	for _, a := range analyzers {
+		if _, _, deleteErr := m.schemaRegistry.AnalyzerRegistry().DeleteAnalyzer(ctx, a.GetMetadata()); deleteErr != nil {
+			return fmt.Errorf("analyzer %s: %w", a.GetMetadata().GetName(), deleteErr)
+		}
+	}
+	task.DeletedCounts["analyzer"] = task.TotalCounts["analyzer"]
+	return nil
+}
+
+func (m *groupDeletionTaskManager) deleteContinuousAggregations(
+	ctx context.Context, opt schema.ListOpt, task *databasev1.GroupDeletionTask,
+) error {
+	cas, listErr := m.schemaRegistry.ContinuousAggregationRegistry().ListContinuousAggregation(ctx, opt)
+	if listErr != nil {
+		return listErr
+	}
+	task.TotalCounts["continuous_aggregation"] = int32(len(cas))
+	for _, ca := range cas {
+		if _, _, deleteErr := m.schemaRegistry.ContinuousAggregationRegistry().DeleteContinuousAggregation(ctx, ca.GetMetadata()); deleteErr != nil {
+			return fmt.Errorf("continuous aggregation %s: %w", ca.GetMetadata().GetName(), deleteErr)
+		}
+	}
+	task.DeletedCounts["continuous_aggregation"] = task.TotalCounts["continuous_aggregation"]
+	return nil
+}
+
 func (m *groupDeletionTaskManager) deleteProperties(
 	ctx context.Context, opt schema.ListOpt, task *databasev1.GroupDeletionTask,
 ) error {
@@ -98,6 +98,52 @@ func (s *stubIndexRule) DeleteIndexRule(_ context.Context, _ *commonv1.Metadata)
 	return true, 0, nil
 }
 
+// stubAnalyzer implements schema.Analyzer returning empty results.
+type stubAnalyzer struct{}
+
+func (s *stubAnalyzer) GetAnalyzer(_ context.Context, _ *commonv1.Metadata) (*databasev1.Analyzer, error) {
+	return nil, nil
+}
+
+func (s *stubAnalyzer) ListAnalyzer(_ context.Context, _ schema.ListOpt) ([]*databasev1.Analyzer, error) {
+	return nil, nil
+}
+
+func (s *stubAnalyzer) CreateAnalyzer(_ context.Context, _ *databasev1.Analyzer) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubAnalyzer) UpdateAnalyzer(_ context.Context, _ *databasev1.Analyzer) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubAnalyzer) DeleteAnalyzer(_ context.Context, _ *commonv1.Metadata) (bool, int64, error) {
+	return true, 0, nil
+}
+
+// stubContinuousAggregation implements schema.ContinuousAggregation returning empty results.
+type stubContinuousAggregation struct{}
+
+func (s *stubContinuousAggregation) GetContinuousAggregation(_ context.Context, _ *commonv1.Metadata) (*databasev1.ContinuousAggregation, error) {
+	return nil, nil
+}
+
+func (s *stubContinuousAggregation) ListContinuousAggregation(_ context.Context, _ schema.ListOpt) ([]*databasev1.ContinuousAggregation, error) {
+	return nil, nil
+}
+
+func (s *stubContinuousAggregation) CreateContinuousAggregation(_ context.Context, _ *databasev1.ContinuousAggregation) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubContinuousAggregation) UpdateContinuousAggregation(_ context.Context, _ *databasev1.ContinuousAggregation) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubContinuousAggregation) DeleteContinuousAggregation(_ context.Context, _ *commonv1.Metadata) (bool, int64, error) {
+	return true, 0, nil
+}
+
 func TestHasNonEmptyResources(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -195,6 +241,8 @@ func TestDeletion(t *testing.T) {
 		mockRepo.EXPECT().CollectDataInfo(gomock.Any(), group).Return([]*databasev1.DataInfo{{DataSizeBytes: 512}}, nil)
 		mockRepo.EXPECT().IndexRuleBindingRegistry().Return(&stubIndexRuleBinding{})
 		mockRepo.EXPECT().IndexRuleRegistry().Return(&stubIndexRule{})
+		mockRepo.EXPECT().AnalyzerRegistry().Return(&stubAnalyzer{})
+		mockRepo.EXPECT().ContinuousAggregationRegistry().Return(&stubContinuousAggregation{})
 
 		mockProperty := schema.NewMockProperty(ctrl)
 		mockProperty.EXPECT().ListProperty(gomock.Any(), schema.ListOpt{Group: group}).Return(nil, nil)
@@ -63,6 +63,8 @@ type measureService struct {
 	*discoveryService
 	l               *logger.Logger
 	metrics         *metrics
+	cdc             *cdcService
+	queryCache      *queryCache
 	writeTimeout    time.Duration
 	maxWaitDuration time.Duration
 }
@@ -283,6 +285,8 @@ func (ms *measureService) processAndPublishRequest(ctx context.Context, writeReq
 		return err
 	}
 
+	ms.queryCache.onWrite(metadata.Group, writeRequest.DataPoint.Timestamp.AsTime(), time.Now())
+
 	*succeedSent = append(*succeedSent, succeedSentMessage{
 		metadata:  metadata,
 		messageID: writeRequest.GetMessageId(),
@@ -474,6 +478,26 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 			req.TimeRange.Begin = timestamppb.New(newBegin)
 		}
 	}
+	fetch := func(r *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+		return ms.publishQuery(ctx, r, now)
+	}
+	var d *measurev1.QueryResponse
+	if ms.queryCache.enabled() {
+		d, err = ms.queryCache.queryMeasure(req, now, fetch)
+	} else {
+		d, err = fetch(req)
+	}
+	if err != nil || d == nil || d == emptyMeasureQueryResponse {
+		return d, err
+	}
+	responseDataPointCount = len(d.DataPoints)
+	if len(gatedStatuses) > 0 {
+		d.GroupStatuses = gatedStatuses
+	}
+	return d, nil
+}
+
+func (ms *measureService) publishQuery(ctx context.Context, req *measurev1.QueryRequest, now time.Time) (*measurev1.QueryResponse, error) {
 	feat, err := ms.broadcaster.Publish(ctx, data.TopicMeasureQuery, bus.NewMessage(bus.MessageID(now.UnixNano()), req))
 	if err != nil {
 		return nil, err
@@ -485,13 +509,8 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 		}
 		return nil, err
 	}
-	data := msg.Data()
-	switch d := data.(type) {
+	switch d := msg.Data().(type) {
 	case *measurev1.QueryResponse:
-		responseDataPointCount = len(d.DataPoints)
-		if len(gatedStatuses) > 0 {
-			d.GroupStatuses = gatedStatuses
-		}
 		return d, nil
 	case *common.Error:
 		return nil, errors.WithMessage(errQueryMsg, d.Error())
@@ -542,6 +561,22 @@ func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNR
 			topNSpan.Stop()
 		}()
 	}
+	fetch := func(r *measurev1.TopNRequest) (*measurev1.TopNResponse, error) {
+		return ms.publishTopN(ctx, r, now)
+	}
+	if ms.queryCache.enabled() {
+		resp, err = ms.queryCache.queryTopN(topNRequest, now, fetch)
+	} else {
+		resp, err = fetch(topNRequest)
+	}
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	responseListCount = len(resp.Lists)
+	return resp, nil
+}
+
+func (ms *measureService) publishTopN(ctx context.Context, topNRequest *measurev1.TopNRequest, now time.Time) (*measurev1.TopNResponse, error) {
 	message := bus.NewMessage(bus.MessageID(now.UnixNano()), topNRequest)
 	feat, errQuery := ms.broadcaster.Publish(ctx, data.TopicTopNQuery, message)
 	if errQuery != nil {
@@ -551,10 +586,8 @@ func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNR
 	if errFeat != nil {
 		return nil, errFeat
 	}
-	data := msg.Data()
-	switch d := data.(type) {
+	switch d := msg.Data().(type) {
 	case *measurev1.TopNResponse:
-		responseListCount = len(d.Lists)
 		return d, nil
 	case *common.Error:
 		return nil, errors.WithMessage(errQueryMsg, d.Error())
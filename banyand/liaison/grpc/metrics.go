@@ -47,6 +47,10 @@ type metrics struct {
 	memoryLoadSheddingRejections meter.Counter
 	grpcBufferSize               meter.Gauge // Shared gauge for both conn and stream buffer sizes
 	memoryState                  meter.Gauge
+
+	queryCacheHit  meter.Counter
+	queryCacheMiss meter.Counter
+	queryCacheSize meter.Gauge
 }
 
 func newMetrics(factory observability.Factory) *metrics {
@@ -71,6 +75,9 @@ func newMetrics(factory observability.Factory) *metrics {
 		memoryLoadSheddingRejections: factory.NewCounter("memory_load_shedding_rejections_total", "service"),
 		grpcBufferSize:               factory.NewGauge("grpc_buffer_size_bytes", "type"),
 		memoryState:                  factory.NewGauge("memory_state"),
+		queryCacheHit:                factory.NewCounter("query_cache_hit", "service"),
+		queryCacheMiss:               factory.NewCounter("query_cache_miss", "service"),
+		queryCacheSize:               factory.NewGauge("query_cache_size_bytes"),
 	}
 }
 
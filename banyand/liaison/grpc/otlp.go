@@ -0,0 +1,223 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/otlp"
+)
+
+const (
+	otlpTraceExportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	otlpLogsExportMethod  = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+)
+
+// otlpTraceService receives the OTLP spans, and writes them into a trace through the trace write path,
+// so that they share the batching and the back-pressure of the other clients.
+type otlpTraceService struct {
+	collectortracev1.UnimplementedTraceServiceServer
+	traceSVC    *traceService
+	shedLoad    func(fullMethod string) error
+	converter   *otlp.TraceConverter
+	mapping     otlp.TagMapping
+	group       string
+	name        string
+	modRevision int64
+	mu          sync.Mutex
+}
+
+func (o *otlpTraceService) traceConverter() (*otlp.TraceConverter, error) {
+	trace, ok := o.traceSVC.entityRepo.getTrace(identity{group: o.group, name: o.name})
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "trace %s/%s doesn't exist", o.group, o.name)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.converter == nil || o.modRevision != trace.GetMetadata().GetModRevision() {
+		o.converter = otlp.NewTraceConverter(trace, o.mapping)
+		o.modRevision = trace.GetMetadata().GetModRevision()
+	}
+	return o.converter, nil
+}
+
+func (o *otlpTraceService) Export(ctx context.Context, req *collectortracev1.ExportTraceServiceRequest) (*collectortracev1.ExportTraceServiceResponse, error) {
+	if err := o.shedLoad(otlpTraceExportMethod); err != nil {
+		return nil, err
+	}
+	converter, err := o.traceConverter()
+	if err != nil {
+		return nil, err
+	}
+	result := converter.Convert(req)
+	rejected, reason := result.Rejected, result.RejectReason
+	if len(result.Requests) > 0 {
+		stream := &localTraceWriteStream{ctx: ctx, requests: result.Requests}
+		if err = o.traceSVC.Write(stream); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to write the spans: %v", err)
+		}
+		succeeded := 0
+		for _, resp := range stream.responses {
+			switch resp.GetStatus() {
+			case modelv1.Status_STATUS_SUCCEED.String():
+				succeeded++
+			case modelv1.Status_STATUS_INVALID_TIMESTAMP.String():
+				rejected++
+				if reason == "" {
+					reason = "the timestamp of the span is out of range"
+				}
+			}
+		}
+		if failed := len(result.Requests) - succeeded - int(rejected-result.Rejected); failed > 0 {
+			return nil, status.Errorf(codes.Unavailable, "failed to write %d spans", failed)
+		}
+	}
+	resp := &collectortracev1.ExportTraceServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &collectortracev1.ExportTracePartialSuccess{RejectedSpans: rejected, ErrorMessage: reason}
+	}
+	return resp, nil
+}
+
+// localTraceWriteStream feeds the write requests to the trace write path in process, and collects the responses.
+type localTraceWriteStream struct {
+	grpclib.ServerStream
+	ctx       context.Context
+	requests  []*tracev1.WriteRequest
+	responses []*tracev1.WriteResponse
+}
+
+func (s *localTraceWriteStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *localTraceWriteStream) Recv() (*tracev1.WriteRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *localTraceWriteStream) Send(resp *tracev1.WriteResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+// otlpLogsService receives the OTLP log records, and writes them into a stream through the stream write path.
+type otlpLogsService struct {
+	collectorlogsv1.UnimplementedLogsServiceServer
+	streamSVC   *streamService
+	shedLoad    func(fullMethod string) error
+	converter   *otlp.LogConverter
+	mapping     otlp.TagMapping
+	group       string
+	name        string
+	modRevision int64
+	mu          sync.Mutex
+}
+
+func (o *otlpLogsService) logConverter() (*otlp.LogConverter, error) {
+	stream, ok := o.streamSVC.entityRepo.getStream(identity{group: o.group, name: o.name})
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "stream %s/%s doesn't exist", o.group, o.name)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.converter == nil || o.modRevision != stream.GetMetadata().GetModRevision() {
+		o.converter = otlp.NewLogConverter(stream, o.mapping)
+		o.modRevision = stream.GetMetadata().GetModRevision()
+	}
+	return o.converter, nil
+}
+
+func (o *otlpLogsService) Export(ctx context.Context, req *collectorlogsv1.ExportLogsServiceRequest) (*collectorlogsv1.ExportLogsServiceResponse, error) {
+	if err := o.shedLoad(otlpLogsExportMethod); err != nil {
+		return nil, err
+	}
+	converter, err := o.logConverter()
+	if err != nil {
+		return nil, err
+	}
+	result := converter.Convert(req)
+	rejected, reason := result.Rejected, result.RejectReason
+	if len(result.Requests) > 0 {
+		stream := &localStreamWriteStream{ctx: ctx, requests: result.Requests}
+		if err = o.streamSVC.Write(stream); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to write the log records: %v", err)
+		}
+		succeeded := 0
+		for _, resp := range stream.responses {
+			switch resp.GetStatus() {
+			case modelv1.Status_STATUS_SUCCEED.String():
+				succeeded++
+			case modelv1.Status_STATUS_INVALID_TIMESTAMP.String():
+				rejected++
+				if reason == "" {
+					reason = "the time of the log record is out of range"
+				}
+			}
+		}
+		if failed := len(result.Requests) - succeeded - int(rejected-result.Rejected); failed > 0 {
+			return nil, status.Errorf(codes.Unavailable, "failed to write %d log records", failed)
+		}
+	}
+	resp := &collectorlogsv1.ExportLogsServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &collectorlogsv1.ExportLogsPartialSuccess{RejectedLogRecords: rejected, ErrorMessage: reason}
+	}
+	return resp, nil
+}
+
+// localStreamWriteStream feeds the write requests to the stream write path in process, and collects the responses.
+type localStreamWriteStream struct {
+	grpclib.ServerStream
+	ctx       context.Context
+	requests  []*streamv1.WriteRequest
+	responses []*streamv1.WriteResponse
+}
+
+func (s *localStreamWriteStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *localStreamWriteStream) Recv() (*streamv1.WriteRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *localStreamWriteStream) Send(resp *streamv1.WriteResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
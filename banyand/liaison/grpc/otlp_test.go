@@ -0,0 +1,126 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func newTestOTLPTraceService(er *entityRepo, shedErr error) *otlpTraceService {
+	return &otlpTraceService{
+		traceSVC: newTestTraceService(er, time.Millisecond),
+		shedLoad: func(string) error { return shedErr },
+		group:    "g",
+		name:     "t",
+	}
+}
+
+func otlpRequest(spans ...*otlptracev1.Span) *collectortracev1.ExportTraceServiceRequest {
+	return &collectortracev1.ExportTraceServiceRequest{ResourceSpans: []*otlptracev1.ResourceSpans{{
+		ScopeSpans: []*otlptracev1.ScopeSpans{{Spans: spans}},
+	}}}
+}
+
+func TestOTLPTraceExport_AbsentTrace_ReturnsFailedPrecondition(t *testing.T) {
+	svc := newTestOTLPTraceService(newEmptyEntityRepo(), nil)
+	_, err := svc.Export(context.Background(), otlpRequest())
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestOTLPTraceExport_HighMemoryPressure_ReturnsResourceExhausted(t *testing.T) {
+	id := identity{group: "g", name: "t"}
+	svc := newTestOTLPTraceService(seededTraceRepo(id, 1), status.Error(codes.ResourceExhausted, "pressure"))
+	_, err := svc.Export(context.Background(), otlpRequest())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestOTLPTraceExport_InvalidSpans_ReturnsPartialSuccess(t *testing.T) {
+	id := identity{group: "g", name: "t"}
+	svc := newTestOTLPTraceService(seededTraceRepo(id, 1), nil)
+	resp, err := svc.Export(context.Background(), otlpRequest(
+		&otlptracev1.Span{SpanId: []byte{1, 2, 3, 4, 5, 6, 7, 8}, StartTimeUnixNano: 1},
+		&otlptracev1.Span{TraceId: make([]byte, 16), SpanId: []byte{1, 2, 3, 4, 5, 6, 7, 8}, StartTimeUnixNano: 1},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.GetPartialSuccess().GetRejectedSpans())
+	assert.Contains(t, resp.GetPartialSuccess().GetErrorMessage(), "invalid trace ID")
+}
+
+func newTestOTLPLogsService(er *entityRepo, shedErr error) *otlpLogsService {
+	return &otlpLogsService{
+		streamSVC: newTestStreamService(er, time.Millisecond),
+		shedLoad:  func(string) error { return shedErr },
+		group:     "g",
+		name:      "s",
+	}
+}
+
+func seededLogsStreamRepo(id identity) *entityRepo {
+	er := newEmptyEntityRepo()
+	er.streamMap[id] = &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: id.group, Name: id.name, ModRevision: 1},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+			{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+		}}},
+		Entity: &databasev1.Entity{TagNames: []string{"service_name"}},
+	}
+	return er
+}
+
+func otlpLogsRequest(records ...*otlplogsv1.LogRecord) *collectorlogsv1.ExportLogsServiceRequest {
+	return &collectorlogsv1.ExportLogsServiceRequest{ResourceLogs: []*otlplogsv1.ResourceLogs{{
+		ScopeLogs: []*otlplogsv1.ScopeLogs{{LogRecords: records}},
+	}}}
+}
+
+func TestOTLPLogsExport_AbsentStream_ReturnsFailedPrecondition(t *testing.T) {
+	svc := newTestOTLPLogsService(newEmptyEntityRepo(), nil)
+	_, err := svc.Export(context.Background(), otlpLogsRequest())
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestOTLPLogsExport_HighMemoryPressure_ReturnsResourceExhausted(t *testing.T) {
+	svc := newTestOTLPLogsService(seededLogsStreamRepo(identity{group: "g", name: "s"}), status.Error(codes.ResourceExhausted, "pressure"))
+	_, err := svc.Export(context.Background(), otlpLogsRequest())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestOTLPLogsExport_InvalidRecords_ReturnsPartialSuccess(t *testing.T) {
+	svc := newTestOTLPLogsService(seededLogsStreamRepo(identity{group: "g", name: "s"}), nil)
+	resp, err := svc.Export(context.Background(), otlpLogsRequest(
+		&otlplogsv1.LogRecord{SeverityText: "INFO"},
+		&otlplogsv1.LogRecord{TimeUnixNano: 1},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.GetPartialSuccess().GetRejectedLogRecords())
+	assert.Contains(t, resp.GetPartialSuccess().GetErrorMessage(), "absent time")
+}
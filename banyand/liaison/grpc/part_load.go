@@ -0,0 +1,139 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"errors"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/archive"
+	fslib "github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+// PartSyncer hands the parts under the data directory root of a group, which are built offline,
+// to the data nodes located by the registry, and returns the number of the synced parts.
+type PartSyncer func(root string, group *commonv1.Group, registry NodeRegistry, chunkSize uint32) (int, error)
+
+// partLoadService receives the parts built offline, and hands them to the data nodes owning their shards.
+type partLoadService struct {
+	databasev1.UnimplementedPartLoadServiceServer
+	schemaRegistry metadata.Repo
+	lfs            fslib.FileSystem
+	syncer         PartSyncer
+	registries     map[commonv1.Catalog]NodeRegistry
+	l              *logger.Logger
+	rootPath       string
+	chunkSize      run.Bytes
+	maxSize        run.Bytes
+	maxFiles       int
+}
+
+// enabled reports whether the parts are synced to the data nodes, which a standalone server doesn't have.
+func (p *partLoadService) enabled() bool {
+	if p.syncer == nil {
+		return false
+	}
+	for _, r := range p.registries {
+		if r == nil {
+			return false
+		}
+	}
+	return len(p.registries) > 0
+}
+
+func (p *partLoadService) Load(stream databasev1.PartLoadService_LoadServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if req.GetGroup() == "" {
+		return status.Error(codes.InvalidArgument, "group is absent in the first request")
+	}
+	group, err := p.schemaRegistry.GroupRegistry().GetGroup(stream.Context(), req.GetGroup())
+	if errors.Is(err, schema.ErrGRPCResourceNotFound) {
+		return status.Errorf(codes.NotFound, "group %s is not found", req.GetGroup())
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot get group %s: %v", req.GetGroup(), err)
+	}
+	registry, ok := p.registries[group.GetCatalog()]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "the parts of the %s group %s can't be loaded", group.GetCatalog(), req.GetGroup())
+	}
+	// The archive without a size may fill up to the maximum size.
+	size := int64(p.maxSize)
+	if req.GetSize() > 0 {
+		if req.GetSize() > uint64(p.maxSize) {
+			return status.Errorf(codes.ResourceExhausted, "the parts of %d bytes exceed the maximum size %s", req.GetSize(), p.maxSize.String())
+		}
+		size = int64(req.GetSize())
+	}
+	if free := p.lfs.MustGetFreeSpace(p.rootPath); uint64(size) > free {
+		return status.Errorf(codes.ResourceExhausted, "the parts of %d bytes exceed the free space %d bytes of %s", size, free, p.rootPath)
+	}
+	dir, err := os.MkdirTemp(p.rootPath, "part-load-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(dir); removeErr != nil {
+			p.l.Warn().Err(removeErr).Str("dir", dir).Msg("failed to remove the loaded parts")
+		}
+	}()
+	limits := archive.Limits{MaxBytes: size, MaxFiles: p.maxFiles}
+	if err = archive.Untar(&partLoadReader{stream: stream, chunk: req.GetChunk()}, dir, limits); err != nil {
+		if errors.Is(err, archive.ErrLimitExceeded) {
+			return status.Errorf(codes.ResourceExhausted, "cannot extract the parts: %v", err)
+		}
+		return status.Errorf(codes.InvalidArgument, "cannot extract the parts: %v", err)
+	}
+	synced, err := p.syncer(dir, group, registry, uint32(p.chunkSize))
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot load the parts after loading %d of them: %v", synced, err)
+	}
+	p.l.Info().Str("group", req.GetGroup()).Int("parts", synced).Msg("loaded the parts built offline")
+	return stream.SendAndClose(&databasev1.PartLoadResponse{Parts: uint32(synced)})
+}
+
+// partLoadReader reads the archive from the chunks of the requests.
+type partLoadReader struct {
+	stream databasev1.PartLoadService_LoadServer
+	chunk  []byte
+}
+
+func (r *partLoadReader) Read(b []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.chunk = req.GetChunk()
+	}
+	n := copy(b, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
@@ -0,0 +1,205 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/archive"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+type mockPartLoadServer struct {
+	*mockBidiServer[databasev1.PartLoadRequest, databasev1.PartLoadResponse]
+	resp     *databasev1.PartLoadResponse
+	requests []*databasev1.PartLoadRequest
+}
+
+func (s *mockPartLoadServer) Recv() (*databasev1.PartLoadRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *mockPartLoadServer) SendAndClose(resp *databasev1.PartLoadResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// newMockPartLoadServer sends the archive of the directory in the chunks of the size.
+func newMockPartLoadServer(t *testing.T, group, dir string, chunkSize int) *mockPartLoadServer {
+	var buf bytes.Buffer
+	require.NoError(t, archive.Tar(&buf, dir))
+	size, err := archive.Size(dir)
+	require.NoError(t, err)
+	s := &mockPartLoadServer{mockBidiServer: &mockBidiServer[databasev1.PartLoadRequest, databasev1.PartLoadResponse]{}}
+	for b := buf.Bytes(); len(b) > 0; b = b[min(chunkSize, len(b)):] {
+		s.requests = append(s.requests, &databasev1.PartLoadRequest{Chunk: b[:min(chunkSize, len(b))]})
+	}
+	s.requests[0].Group, s.requests[0].Size = group, uint64(size)
+	return s
+}
+
+func newTestPartLoadService(t *testing.T, syncer PartSyncer) *partLoadService {
+	ctrl := gomock.NewController(t)
+	groups := schema.NewMockGroup(ctrl)
+	groups.EXPECT().GetGroup(gomock.Any(), "sw").Return(&commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "sw"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+	}, nil).AnyTimes()
+	groups.EXPECT().GetGroup(gomock.Any(), "absent").Return(nil, errors.WithMessage(schema.ErrGRPCResourceNotFound, "absent")).AnyTimes()
+	repo := metadata.NewMockRepo(ctrl)
+	repo.EXPECT().GroupRegistry().Return(groups).AnyTimes()
+	return &partLoadService{
+		schemaRegistry: repo,
+		syncer:         syncer,
+		registries: map[commonv1.Catalog]NodeRegistry{
+			commonv1.Catalog_CATALOG_STREAM: NewLocalNodeRegistry(),
+		},
+		lfs:       fs.NewLocalFileSystem(),
+		l:         logger.GetLogger("part-load-test"),
+		rootPath:  t.TempDir(),
+		chunkSize: 1024,
+		maxSize:   1 << 20,
+		maxFiles:  10,
+	}
+}
+
+func TestPartLoadService_Load(t *testing.T) {
+	src := t.TempDir()
+	partPath := filepath.Join("seg-20250101", "shard-0", "0000000000000001")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, partPath), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, partPath, "primary.bin"), bytes.Repeat([]byte("p"), 100), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "seg-20250101", "sidx"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "seg-20250101", "sidx", "0000000000000002.seg"), nil, 0o600))
+
+	var loaded map[string][]byte
+	p := newTestPartLoadService(t, func(root string, group *commonv1.Group, registry NodeRegistry, chunkSize uint32) (int, error) {
+		assert.Equal(t, "sw", group.GetMetadata().GetName())
+		assert.Equal(t, "local", registry.String())
+		assert.Equal(t, uint32(1024), chunkSize)
+		loaded = make(map[string][]byte)
+		return 1, filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			name, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			loaded[name], err = os.ReadFile(path)
+			return err
+		})
+	})
+	s := newMockPartLoadServer(t, "sw", src, 7)
+	require.NoError(t, p.Load(s))
+	assert.Equal(t, uint32(1), s.resp.GetParts())
+	assert.Equal(t, map[string][]byte{
+		filepath.Join(partPath, "primary.bin"):                        bytes.Repeat([]byte("p"), 100),
+		filepath.Join("seg-20250101", "sidx", "0000000000000002.seg"): {},
+	}, loaded)
+	entries, err := os.ReadDir(p.rootPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the loaded parts are removed")
+}
+
+func TestPartLoadService_LoadErrors(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "part"), []byte("part"), 0o600))
+	p := newTestPartLoadService(t, func(string, *commonv1.Group, NodeRegistry, uint32) (int, error) {
+		return 1, errors.New("node is down")
+	})
+
+	err := p.Load(newMockPartLoadServer(t, "", src, 1024))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = p.Load(newMockPartLoadServer(t, "sw", src, 1024))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.ErrorContains(t, err, "after loading 1 of them: node is down")
+
+	s := newMockPartLoadServer(t, "sw", src, 1024)
+	s.requests = s.requests[:1]
+	s.requests[0].Chunk = s.requests[0].Chunk[:100]
+	err = p.Load(s)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = p.Load(newMockPartLoadServer(t, "absent", src, 1024))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPartLoadService_LoadLimits(t *testing.T) {
+	src := t.TempDir()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(src, strconv.Itoa(i)), bytes.Repeat([]byte("p"), 10), 0o600))
+	}
+	p := newTestPartLoadService(t, func(string, *commonv1.Group, NodeRegistry, uint32) (int, error) {
+		return 1, nil
+	})
+
+	p.maxSize = 20
+	err := p.Load(newMockPartLoadServer(t, "sw", src, 1024))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the declared size exceeds the maximum size")
+
+	p.maxSize = 1 << 20
+	s := newMockPartLoadServer(t, "sw", src, 1024)
+	s.requests[0].Size = 20
+	err = p.Load(s)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the files exceed the declared size")
+
+	p.maxFiles = 2
+	err = p.Load(newMockPartLoadServer(t, "sw", src, 1024))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the files exceed the maximum number")
+
+	p.maxFiles = 10
+	s = newMockPartLoadServer(t, "sw", src, 1024)
+	s.requests[0].Size = 1 << 62
+	p.maxSize = 1 << 62
+	err = p.Load(s)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the declared size exceeds the free space")
+
+	require.NoError(t, p.Load(newMockPartLoadServer(t, "sw", src, 1024)))
+}
+
+func TestPartLoadService_Enabled(t *testing.T) {
+	p := newTestPartLoadService(t, nil)
+	assert.False(t, p.enabled())
+	p.syncer = func(string, *commonv1.Group, NodeRegistry, uint32) (int, error) { return 0, nil }
+	assert.True(t, p.enabled())
+	p.registries[commonv1.Catalog_CATALOG_MEASURE] = nil
+	assert.False(t, p.enabled())
+}
@@ -31,6 +31,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/apache/skywalking-banyandb/api/common"
@@ -38,6 +39,7 @@ import (
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	propertydb "github.com/apache/skywalking-banyandb/banyand/property/db"
@@ -63,6 +65,7 @@ type propertyServer struct {
 	pipeline           queue.Client
 	nodeRegistry       NodeRegistry
 	metrics            *metrics
+	cdc                *cdcService
 	repairQueue        *repairQueue
 	repairQueueCount   int
 }
@@ -114,6 +117,9 @@ func (ps *propertyServer) validatePropertyRequest(property *propertyv1.Property)
 	if len(property.Tags) == 0 {
 		return schema.BadRequest("tags", "tags should not be empty")
 	}
+	if property.Ttl != nil && property.Ttl.AsDuration() <= 0 {
+		return schema.BadRequest("ttl", "ttl should be positive")
+	}
 	return nil
 }
 
@@ -169,18 +175,18 @@ func (ps *propertyServer) locateNodeSetForProperty(property *propertyv1.Property
 		return nil, errors.New("failed to get group copies")
 	}
 
+	// the nodes keep the order of the replicas, so the first one holds the primary replica
 	nodeSet := make(map[string]struct{}, copies)
-	var nodeID string
-	var err error
+	nodes := make([]string, 0, copies)
 	for i := range copies {
-		nodeID, err = ps.nodeRegistry.Locate(property.GetMetadata().GetGroup(), property.GetMetadata().GetName(), shardID, i)
+		nodeID, err := ps.nodeRegistry.Locate(property.GetMetadata().GetGroup(), property.GetMetadata().GetName(), shardID, i)
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := nodeSet[nodeID]; ok {
+			continue
+		}
 		nodeSet[nodeID] = struct{}{}
-	}
-	nodes := make([]string, 0, len(nodeSet))
-	for nodeID := range nodeSet {
 		nodes = append(nodes, nodeID)
 	}
 	return nodes, nil
@@ -217,6 +223,9 @@ func (ps *propertyServer) Apply(ctx context.Context, req *propertyv1.ApplyReques
 	if err = ps.validatePropertyTags(ctx, property); err != nil {
 		return nil, err
 	}
+	if property.ExpireAt, err = expireAt(property, group, start); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid ttl of group %s: %v", g, err)
+	}
 	nodeProperties, _, _, err := ps.queryProperties(ctx, &propertyv1.QueryRequest{
 		Groups: []string{g},
 		Name:   property.Metadata.Name,
@@ -239,6 +248,10 @@ func (ps *propertyServer) Apply(ctx context.Context, req *propertyv1.ApplyReques
 	if prevPropertyWithMetadata != nil && prevPropertyWithMetadata.deletedTime <= 0 {
 		prev = prevPropertyWithMetadata.Property
 	}
+	if err = checkPrecondition(req, prev); err != nil {
+		return nil, err
+	}
+	conditional := req.Precondition != nil
 	defer func() {
 		// if their no older properties or have error when apply the new property
 		// then ignore cleanup the older properties
@@ -249,12 +262,49 @@ func (ps *propertyServer) Apply(ctx context.Context, req *propertyv1.ApplyReques
 		for _, p := range olderProperties {
 			ids = append(ids, propertydb.GetPropertyID(p.Property))
 		}
-		_ = ps.remove(ids)
+		_ = ps.remove(ids, true)
 	}()
 	if req.Strategy == propertyv1.ApplyRequest_STRATEGY_REPLACE {
-		return ps.replaceProperty(ctx, start, uint64(shardID), nodes, prev, property)
+		return ps.replaceProperty(ctx, start, uint64(shardID), nodes, prev, property, conditional)
 	}
-	return ps.mergeProperty(ctx, start, uint64(shardID), nodes, prev, property)
+	return ps.mergeProperty(ctx, start, uint64(shardID), nodes, prev, property, conditional)
+}
+
+// checkPrecondition verifies the precondition of the apply request against the current property.
+func checkPrecondition(req *propertyv1.ApplyRequest, cur *propertyv1.Property) error {
+	switch req.Precondition.(type) {
+	case *propertyv1.ApplyRequest_MustNotExist:
+		if req.GetMustNotExist() && cur != nil {
+			return status.Errorf(codes.FailedPrecondition, "property %s already exists with mod_revision %d",
+				req.Property.Id, cur.Metadata.ModRevision)
+		}
+	case *propertyv1.ApplyRequest_ExpectedModRevision:
+		if cur == nil {
+			return status.Errorf(codes.FailedPrecondition, "property %s does not exist", req.Property.Id)
+		}
+		if cur.Metadata.ModRevision != req.GetExpectedModRevision() {
+			return status.Errorf(codes.FailedPrecondition, "property %s has mod_revision %d, expected %d",
+				req.Property.Id, cur.Metadata.ModRevision, req.GetExpectedModRevision())
+		}
+	}
+	return nil
+}
+
+// expireAt returns when the property applied at now expires, or nil if it never expires.
+// The property's ttl takes precedence over the group's.
+func expireAt(property *propertyv1.Property, group *commonv1.Group, now time.Time) (*timestamppb.Timestamp, error) {
+	if property.Ttl != nil {
+		return timestamppb.New(now.Add(property.Ttl.AsDuration())), nil
+	}
+	ttl := group.GetResourceOpts().GetTtl()
+	if ttl == nil {
+		return nil, nil
+	}
+	ir, err := storage.ToIntervalRule(ttl)
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(ir.NextTime(now)), nil
 }
 
 func (ps *propertyServer) findPrevAndOlderProperties(nodeProperties map[string][]*propertyWithMetadata) (*propertyWithMetadata, []*propertyWithMetadata) {
@@ -276,10 +326,10 @@ func (ps *propertyServer) findPrevAndOlderProperties(nodeProperties map[string][
 }
 
 func (ps *propertyServer) mergeProperty(ctx context.Context, now time.Time, shardID uint64, nodes []string,
-	prev, cur *propertyv1.Property,
+	prev, cur *propertyv1.Property, conditional bool,
 ) (*propertyv1.ApplyResponse, error) {
 	if prev == nil {
-		return ps.replaceProperty(ctx, now, shardID, nodes, prev, cur)
+		return ps.replaceProperty(ctx, now, shardID, nodes, prev, cur, conditional)
 	}
 	tagCount, err := tagLen(prev)
 	if err != nil {
@@ -300,7 +350,7 @@ func (ps *propertyServer) mergeProperty(ctx context.Context, now time.Time, shar
 		}
 	}
 	cur.Tags = append(cur.Tags, tags...)
-	return ps.replaceProperty(ctx, now, shardID, nodes, prev, cur)
+	return ps.replaceProperty(ctx, now, shardID, nodes, prev, cur, conditional)
 }
 
 func tagLen(property *propertyv1.Property) (uint32, error) {
@@ -313,22 +363,80 @@ func tagLen(property *propertyv1.Property) (uint32, error) {
 }
 
 func (ps *propertyServer) replaceProperty(ctx context.Context, now time.Time, shardID uint64, nodes []string,
-	prev, cur *propertyv1.Property,
+	prev, cur *propertyv1.Property, conditional bool,
 ) (*propertyv1.ApplyResponse, error) {
 	ns := now.UnixNano()
+	var expectedModRevision int64
 	if prev != nil {
 		cur.Metadata.CreateRevision = prev.Metadata.CreateRevision
+		expectedModRevision = prev.Metadata.ModRevision
 	} else {
 		cur.Metadata.CreateRevision = ns
 	}
 	cur.Metadata.ModRevision = ns
 	cur.UpdatedAt = timestamppb.New(now)
 	req := &propertyv1.InternalUpdateRequest{
-		ShardId:  shardID,
-		Id:       propertydb.GetPropertyID(cur),
-		Property: cur,
+		ShardId:             shardID,
+		Id:                  propertydb.GetPropertyID(cur),
+		Property:            cur,
+		Conditional:         conditional,
+		ExpectedModRevision: expectedModRevision,
+	}
+	if conditional {
+		// The primary replica decides the precondition alone, so a rejected apply changes no replica.
+		// The other replicas follow the accepted apply, and the repair fixes the ones missing it.
+		if err := ps.applyOnPrimary(ctx, nodes[0], req); err != nil {
+			return nil, err
+		}
+		if len(nodes) > 1 {
+			follower := proto.Clone(req).(*propertyv1.InternalUpdateRequest)
+			follower.Conditional, follower.ExpectedModRevision = false, 0
+			if _, err := ps.publishUpdate(ctx, nodes[1:], follower); err != nil {
+				ps.log.Warn().Err(err).Str("group", cur.Metadata.Group).Str("name", cur.Metadata.Name).Str("id", cur.Id).
+					Msg("failed to apply the property on the replicas, they are left to the repair")
+			}
+		}
+	} else if haveSuccess, err := ps.publishUpdate(ctx, nodes, req); !haveSuccess {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("failed to apply property, no replicas success")
+	}
+	if err := ps.cdc.recordPropertyApply(ctx, cur); err != nil {
+		return nil, err
+	}
+
+	return &propertyv1.ApplyResponse{
+		Created:     prev == nil,
+		TagsNum:     uint32(len(cur.Tags)),
+		ModRevision: ns,
+	}, nil
+}
+
+// applyOnPrimary applies a conditional update on the primary replica.
+func (ps *propertyServer) applyOnPrimary(ctx context.Context, node string, req *propertyv1.InternalUpdateRequest) error {
+	f, err := ps.pipeline.Publish(ctx, data.TopicPropertyUpdate, bus.NewMessageWithNode(bus.MessageID(time.Now().Unix()), node, req))
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to publish the property update to the primary replica %s: %v", node, err)
+	}
+	m, err := f.Get()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to apply the property on the primary replica %s: %v", node, err)
+	}
+	switch d := m.Data().(type) {
+	case *propertyv1.InternalUpdateResponse:
+		if d.GetModRevisionConflict() {
+			return status.Errorf(codes.FailedPrecondition, "property %s was modified concurrently", req.Property.Id)
+		}
+	case *common.Error:
+		// the standalone node replies errors as the message data
+		return d
 	}
-	var successCount int
+	return nil
+}
+
+// publishUpdate applies an update on the nodes, and reports whether any of them succeeds.
+func (ps *propertyServer) publishUpdate(ctx context.Context, nodes []string, req *propertyv1.InternalUpdateRequest) (bool, error) {
 	futures := make([]bus.Future, 0, len(nodes))
 	for _, node := range nodes {
 		f, err := ps.pipeline.Publish(ctx, data.TopicPropertyUpdate,
@@ -337,34 +445,22 @@ func (ps *propertyServer) replaceProperty(ctx context.Context, now time.Time, sh
 			ps.log.Debug().Err(err).Str("node", node).Msg("failed to publish property update")
 			continue
 		}
-		successCount++
 		futures = append(futures, f)
 	}
-	if successCount == 0 {
-		return nil, fmt.Errorf("failed to publish property update to any node")
+	if len(futures) == 0 {
+		return false, fmt.Errorf("failed to publish property update to any node")
 	}
 	// Wait for all futures to complete, and which should last have one success
 	haveSuccess := false
 	var lastestError error
 	for _, f := range futures {
-		_, err := f.Get()
-		if err == nil {
-			haveSuccess = true
-		} else {
+		if _, err := f.Get(); err != nil {
 			lastestError = multierr.Append(lastestError, err)
+			continue
 		}
+		haveSuccess = true
 	}
-	if !haveSuccess {
-		if lastestError != nil {
-			return nil, lastestError
-		}
-		return nil, errors.New("failed to apply property, no replicas success")
-	}
-
-	return &propertyv1.ApplyResponse{
-		Created: prev == nil,
-		TagsNum: uint32(len(cur.Tags)),
-	}, nil
+	return haveSuccess, lastestError
 }
 
 func (ps *propertyServer) Delete(ctx context.Context, req *propertyv1.DeleteRequest) (resp *propertyv1.DeleteResponse, err error) {
@@ -417,7 +513,10 @@ func (ps *propertyServer) Delete(ctx context.Context, req *propertyv1.DeleteRequ
 			ids = append(ids, propertydb.GetPropertyID(p.Property))
 		}
 	}
-	if err := ps.remove(ids); err != nil {
+	if err = ps.remove(ids, false); err != nil {
+		return nil, err
+	}
+	if err = ps.cdc.recordPropertyDelete(ctx, g, req.Name, req.Id, start); err != nil {
 		return nil, err
 	}
 	return &propertyv1.DeleteResponse{Deleted: true}, nil
@@ -828,9 +927,14 @@ func (ps *propertyServer) queryProperties(
 	return res, groups, trace, nil
 }
 
-func (ps *propertyServer) remove(ids [][]byte) error {
-	ff, err := ps.pipeline.Broadcast(defaultQueryTimeout, data.TopicPropertyDelete, bus.NewMessage(bus.MessageID(time.Now().Unix()), &propertyv1.InternalDeleteRequest{
-		Ids: ids,
+// remove marks the properties as deleted on all nodes.
+// The superseded properties are replaced by newer revisions, so the watches are not notified of their removal.
+func (ps *propertyServer) remove(ids [][]byte, superseded bool) error {
+	now := time.Now()
+	ff, err := ps.pipeline.Broadcast(defaultQueryTimeout, data.TopicPropertyDelete, bus.NewMessage(bus.MessageID(now.Unix()), &propertyv1.InternalDeleteRequest{
+		Ids:        ids,
+		Superseded: superseded,
+		DeleteTime: now.UnixNano(),
 	}))
 	if err != nil {
 		return err
@@ -0,0 +1,150 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestExpireAt(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	groupWithTTL := func(ttl *commonv1.IntervalRule) *commonv1.Group {
+		return &commonv1.Group{ResourceOpts: &commonv1.ResourceOpts{Ttl: ttl}}
+	}
+	tests := []struct {
+		want     time.Time
+		property *propertyv1.Property
+		group    *commonv1.Group
+		name     string
+		wantErr  bool
+		wantNil  bool
+	}{
+		{name: "no ttl", property: &propertyv1.Property{}, group: groupWithTTL(nil), wantNil: true},
+		{
+			name:     "property ttl",
+			property: &propertyv1.Property{Ttl: durationpb.New(time.Minute)},
+			group:    groupWithTTL(&commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1}),
+			want:     now.Add(time.Minute),
+		},
+		{
+			name:     "group ttl",
+			property: &propertyv1.Property{},
+			group:    groupWithTTL(&commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_HOUR, Num: 2}),
+			want:     now.Add(2 * time.Hour),
+		},
+		{
+			name:     "unspecified unit",
+			property: &propertyv1.Property{},
+			group:    groupWithTTL(&commonv1.IntervalRule{Num: 2}),
+			wantErr:  true,
+		},
+		{
+			name:     "non-positive num",
+			property: &propertyv1.Property{},
+			group:    groupWithTTL(&commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY}),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expireAt(tt.property, tt.group, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got.AsTime())
+		})
+	}
+}
+
+// repliedFuture replies a message of a node.
+type repliedFuture struct {
+	data any
+	node string
+}
+
+func (f repliedFuture) Get() (bus.Message, error) {
+	return bus.NewMessageWithNode(1, f.node, f.data), nil
+}
+
+func (f repliedFuture) GetAll() ([]bus.Message, error) {
+	m, err := f.Get()
+	return []bus.Message{m}, err
+}
+
+func TestReplaceProperty_Conditional_PrimaryDecides(t *testing.T) {
+	newProperty := func() *propertyv1.Property {
+		return &propertyv1.Property{
+			Metadata: &commonv1.Metadata{Group: "g", Name: "p"},
+			Id:       "1",
+			Tags:     []*modelv1.Tag{{Key: "k", Value: strTagValue("v")}},
+		}
+	}
+	t.Run("a conflict on the primary changes no replica", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		pipeline := queue.NewMockClient(ctrl)
+		pipeline.EXPECT().Publish(gomock.Any(), data.TopicPropertyUpdate, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ bus.Topic, m ...bus.Message) (bus.Future, error) {
+				assert.Equal(t, "primary", m[0].Node())
+				assert.True(t, m[0].Data().(*propertyv1.InternalUpdateRequest).Conditional)
+				return repliedFuture{node: "primary", data: &propertyv1.InternalUpdateResponse{ModRevisionConflict: true}}, nil
+			})
+		ps := &propertyServer{discoveryService: &discoveryService{log: logger.GetLogger("test")}, pipeline: pipeline}
+		_, err := ps.replaceProperty(context.Background(), time.Now(), 0, []string{"primary", "replica"}, nil, newProperty(), true)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+	t.Run("the replicas follow the primary", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		pipeline := queue.NewMockClient(ctrl)
+		var published []*propertyv1.InternalUpdateRequest
+		pipeline.EXPECT().Publish(gomock.Any(), data.TopicPropertyUpdate, gomock.Any()).Times(2).
+			DoAndReturn(func(_ context.Context, _ bus.Topic, m ...bus.Message) (bus.Future, error) {
+				published = append(published, m[0].Data().(*propertyv1.InternalUpdateRequest))
+				return repliedFuture{node: m[0].Node(), data: &propertyv1.InternalUpdateResponse{}}, nil
+			})
+		ps := &propertyServer{discoveryService: &discoveryService{log: logger.GetLogger("test")}, pipeline: pipeline}
+		resp, err := ps.replaceProperty(context.Background(), time.Now(), 0, []string{"primary", "replica"}, nil, newProperty(), true)
+		require.NoError(t, err)
+		assert.True(t, resp.Created)
+		require.Len(t, published, 2)
+		assert.True(t, published[0].Conditional)
+		assert.False(t, published[1].Conditional)
+	})
+}
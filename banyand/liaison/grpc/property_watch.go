@@ -0,0 +1,347 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	propertydb "github.com/apache/skywalking-banyandb/banyand/property/db"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+const (
+	// propertyWatchWait is how long a data node holds a watch request if there are no changes.
+	// It stays below the timeout of publishing a message to a data node.
+	propertyWatchWait = 10 * time.Second
+	// propertyWatchRetryInterval is how long a watch waits before reading the changes from a failed data node again.
+	propertyWatchRetryInterval = time.Second
+	// propertyWatchRefreshInterval is how often a watch looks for the data nodes joining the watched groups.
+	propertyWatchRefreshInterval = 30 * time.Second
+	// propertyWatchDedupSize is the number of properties a watch remembers to drop the changes reported by several replicas.
+	propertyWatchDedupSize = 10000
+)
+
+// nodeChanges are the changes read from the change log of a data node.
+type nodeChanges struct {
+	resp *propertyv1.InternalWatchResponse
+	node string
+}
+
+// watchedProperty is the latest change of a property sent to the watch.
+type watchedProperty struct {
+	modRevision int64
+	deleted     bool
+}
+
+// propertyWatch merges the changes read from the data nodes into the events of a watch.
+type propertyWatch struct {
+	seen           *lru.Cache
+	request        *propertyv1.WatchRequest
+	positions      map[string]uint64
+	nodeLogs       map[string]string
+	startPositions []*propertyv1.WatchLogPosition
+	// startRevision is where the data nodes absent in the start cursor replay the changes from.
+	startRevision int64
+	// fromNow indicates the data nodes found when the watch starts only report the changes from now on.
+	fromNow bool
+}
+
+func newPropertyWatch(req *propertyv1.WatchRequest, now time.Time) (*propertyWatch, error) {
+	seen, err := lru.New(propertyWatchDedupSize)
+	if err != nil {
+		return nil, err
+	}
+	w := &propertyWatch{
+		seen:          seen,
+		request:       req,
+		positions:     make(map[string]uint64),
+		nodeLogs:      make(map[string]string),
+		startRevision: req.StartRevision,
+	}
+	if len(req.StartCursor) > 0 {
+		var cursor propertyv1.WatchCursor
+		if err = proto.Unmarshal(req.StartCursor, &cursor); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid start cursor: %v", err)
+		}
+		w.startPositions = cursor.Positions
+		w.startRevision = cursor.Revision
+		for _, p := range cursor.Positions {
+			w.positions[p.Log] = p.Seq
+		}
+	}
+	if w.startRevision <= 0 {
+		// the data nodes joining later replay the changes made since the watch starts
+		w.startRevision = now.UnixNano()
+		w.fromNow = true
+	}
+	return w, nil
+}
+
+// internalRequest returns the first request to read the changes from a data node.
+func (w *propertyWatch) internalRequest(initial bool) *propertyv1.InternalWatchRequest {
+	req := proto.Clone(w.request).(*propertyv1.WatchRequest)
+	req.StartCursor = nil
+	req.StartRevision = w.startRevision
+	if initial && w.fromNow {
+		req.StartRevision = 0
+	}
+	return &propertyv1.InternalWatchRequest{
+		Request:   req,
+		Positions: w.startPositions,
+		Wait:      durationpb.New(propertyWatchWait),
+	}
+}
+
+// merge returns the events of the changes read from a data node, dropping the ones already sent.
+// Each event carries a cursor holding the positions of all change logs read so far.
+func (w *propertyWatch) merge(c nodeChanges) ([]*propertyv1.WatchResponse, error) {
+	log := c.resp.GetPosition().GetLog()
+	if previous, ok := w.nodeLogs[c.node]; ok && previous != log {
+		delete(w.positions, previous)
+	}
+	w.nodeLogs[c.node] = log
+	var events []*propertyv1.WatchResponse
+	for i, event := range c.resp.Events {
+		if i < len(c.resp.Seqs) {
+			w.positions[log] = c.resp.Seqs[i]
+		}
+		if w.sent(event) {
+			continue
+		}
+		cursor, err := w.cursor(event.Revision)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &propertyv1.WatchResponse{
+			EventType: event.EventType,
+			Property:  event.Property,
+			Revision:  event.Revision,
+			Cursor:    cursor,
+		})
+	}
+	w.positions[log] = c.resp.GetPosition().GetSeq()
+	return events, nil
+}
+
+// sent tells whether the event has been sent, since every replica of a property reports its changes.
+func (w *propertyWatch) sent(event *propertyv1.WatchResponse) bool {
+	entity := propertydb.GetEntity(event.Property)
+	current := watchedProperty{
+		modRevision: event.Property.Metadata.ModRevision,
+		deleted:     event.EventType == propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE,
+	}
+	if v, ok := w.seen.Get(entity); ok {
+		previous := v.(watchedProperty)
+		if previous.modRevision > current.modRevision ||
+			previous.modRevision == current.modRevision && (previous.deleted || !current.deleted) {
+			return true
+		}
+	}
+	w.seen.Add(entity, current)
+	return false
+}
+
+func (w *propertyWatch) cursor(revision int64) ([]byte, error) {
+	cursor := &propertyv1.WatchCursor{
+		Positions: make([]*propertyv1.WatchLogPosition, 0, len(w.positions)),
+		Revision:  revision,
+	}
+	for log, seq := range w.positions {
+		cursor.Positions = append(cursor.Positions, &propertyv1.WatchLogPosition{Log: log, Seq: seq})
+	}
+	return proto.Marshal(cursor)
+}
+
+func (ps *propertyServer) Watch(req *propertyv1.WatchRequest, stream propertyv1.PropertyService_WatchServer) (err error) {
+	if len(req.Groups) == 0 {
+		return schema.BadRequest("groups", "groups should not be empty")
+	}
+	ps.metrics.totalStreamStarted.Inc(1, "property", "watch")
+	start := time.Now()
+	defer func() {
+		ps.metrics.totalStreamFinished.Inc(1, "property", "watch")
+		ps.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "property", "watch")
+		if err != nil && status.Code(err) != codes.Canceled {
+			ps.metrics.totalStreamErr.Inc(1, "property", "watch")
+		}
+	}()
+	for _, g := range req.Groups {
+		if _, validateErr := ps.validateGroupForProperty(stream.Context(), g); validateErr != nil {
+			return status.Error(codes.InvalidArgument, validateErr.Error())
+		}
+	}
+	w, err := newPropertyWatch(req, start)
+	if err != nil {
+		return err
+	}
+	nodes, err := ps.watchNodes(req.Groups)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	changes := make(chan nodeChanges)
+	pollers := make(map[string]context.CancelFunc, len(nodes))
+	startPollers := func(nodes []string, initial bool) {
+		alive := make(map[string]struct{}, len(nodes))
+		for _, n := range nodes {
+			alive[n] = struct{}{}
+			if _, ok := pollers[n]; ok {
+				continue
+			}
+			pollerCtx, pollerCancel := context.WithCancel(ctx)
+			pollers[n] = pollerCancel
+			go ps.pollWatchedChanges(pollerCtx, n, w.internalRequest(initial), w.startRevision, changes)
+		}
+		for n, pollerCancel := range pollers {
+			if _, ok := alive[n]; !ok {
+				pollerCancel()
+				delete(pollers, n)
+			}
+		}
+	}
+	startPollers(nodes, true)
+	ticker := time.NewTicker(propertyWatchRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if nodes, err = ps.watchNodes(req.Groups); err != nil {
+				ps.log.Warn().Err(err).Strs("groups", req.Groups).Msg("fail to refresh the nodes of the property watch")
+				continue
+			}
+			startPollers(nodes, false)
+		case c := <-changes:
+			if c.resp.Compacted {
+				return status.Errorf(codes.OutOfRange,
+					"the changes to resume the watch from have been dropped by node %s, query the properties and watch the changes from now on", c.node)
+			}
+			events, mergeErr := w.merge(c)
+			if mergeErr != nil {
+				return mergeErr
+			}
+			for _, event := range events {
+				if err = stream.Send(event); err != nil {
+					ps.metrics.totalStreamMsgSentErr.Inc(1, event.Property.Metadata.Group, "property", "watch")
+					return err
+				}
+				ps.metrics.totalStreamMsgSent.Inc(1, event.Property.Metadata.Group, "property", "watch")
+			}
+		}
+	}
+}
+
+// watchNodes returns the data nodes holding the shards of the groups.
+func (ps *propertyServer) watchNodes(groups []string) ([]string, error) {
+	nodeSet := make(map[string]struct{})
+	nodes := make([]string, 0)
+	for _, g := range groups {
+		shardNum, ok := ps.groupRepo.shardNum(g)
+		if !ok {
+			return nil, errors.Errorf("group %s not found", g)
+		}
+		copies, _ := ps.groupRepo.copies(g)
+		for shardID := uint32(0); shardID < shardNum; shardID++ {
+			located, err := ps.nodeRegistry.LocateAll(g, shardID, int(copies))
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range located {
+				if _, ok := nodeSet[n]; ok {
+					continue
+				}
+				nodeSet[n] = struct{}{}
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// pollWatchedChanges keeps reading the changes from the change log of a data node until the log is compacted or the context is done.
+// startRevision is where the node replays the changes from if it loses the position of the watch, for example after a restart.
+func (ps *propertyServer) pollWatchedChanges(ctx context.Context, node string, req *propertyv1.InternalWatchRequest,
+	startRevision int64, changes chan<- nodeChanges,
+) {
+	failed := false
+	for {
+		resp, err := ps.readWatchedChanges(ctx, node, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if !failed {
+				ps.log.Warn().Err(err).Str("node", node).Msg("fail to read the property changes, retrying")
+				failed = true
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(propertyWatchRetryInterval):
+			}
+			continue
+		}
+		failed = false
+		select {
+		case <-ctx.Done():
+			return
+		case changes <- nodeChanges{node: node, resp: resp}:
+		}
+		if resp.Compacted {
+			return
+		}
+		req.Positions = []*propertyv1.WatchLogPosition{resp.Position}
+		req.Request.StartRevision = startRevision
+	}
+}
+
+func (ps *propertyServer) readWatchedChanges(ctx context.Context, node string,
+	req *propertyv1.InternalWatchRequest,
+) (*propertyv1.InternalWatchResponse, error) {
+	f, err := ps.pipeline.Publish(ctx, data.TopicPropertyWatch, bus.NewMessageWithNode(bus.MessageID(time.Now().Unix()), node, req))
+	if err != nil {
+		return nil, err
+	}
+	m, err := f.Get()
+	if err != nil {
+		return nil, err
+	}
+	switch d := m.Data().(type) {
+	case *propertyv1.InternalWatchResponse:
+		return d, nil
+	case *common.Error:
+		return nil, errors.New(d.Error())
+	default:
+		return nil, errors.Errorf("unexpected response %T", d)
+	}
+}
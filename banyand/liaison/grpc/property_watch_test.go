@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+)
+
+func loggedChange(id string, eventType propertyv1.WatchEventType, modRevision, revision int64) *propertyv1.WatchResponse {
+	return &propertyv1.WatchResponse{
+		EventType: eventType,
+		Property: &propertyv1.Property{
+			Metadata: &commonv1.Metadata{Group: "g", Name: "p", ModRevision: modRevision},
+			Id:       id,
+		},
+		Revision: revision,
+	}
+}
+
+type watchEvent struct {
+	id        string
+	eventType propertyv1.WatchEventType
+	revision  int64
+}
+
+func toWatchEvents(events []*propertyv1.WatchResponse) []watchEvent {
+	result := make([]watchEvent, 0, len(events))
+	for _, e := range events {
+		result = append(result, watchEvent{id: e.Property.Id, eventType: e.EventType, revision: e.Revision})
+	}
+	return result
+}
+
+func TestPropertyWatch_Merge(t *testing.T) {
+	apply, del := propertyv1.WatchEventType_WATCH_EVENT_TYPE_APPLY, propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE
+	w, err := newPropertyWatch(&propertyv1.WatchRequest{Groups: []string{"g"}}, time.Now())
+	require.NoError(t, err)
+
+	events, err := w.merge(nodeChanges{node: "n1", resp: &propertyv1.InternalWatchResponse{
+		Events:   []*propertyv1.WatchResponse{loggedChange("a", apply, 10, 10), loggedChange("a", del, 10, 20)},
+		Seqs:     []uint64{1, 2},
+		Position: &propertyv1.WatchLogPosition{Log: "l1", Seq: 2},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []watchEvent{{id: "a", eventType: apply, revision: 10}, {id: "a", eventType: del, revision: 20}}, toWatchEvents(events))
+
+	events, err = w.merge(nodeChanges{node: "n2", resp: &propertyv1.InternalWatchResponse{
+		Events: []*propertyv1.WatchResponse{
+			loggedChange("a", apply, 10, 10), loggedChange("a", del, 10, 20), loggedChange("b", apply, 15, 15),
+		},
+		Seqs:     []uint64{5, 6, 7},
+		Position: &propertyv1.WatchLogPosition{Log: "l2", Seq: 8},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []watchEvent{{id: "b", eventType: apply, revision: 15}}, toWatchEvents(events),
+		"the changes reported by the other replicas are dropped")
+
+	var cursor propertyv1.WatchCursor
+	require.NoError(t, proto.Unmarshal(events[0].Cursor, &cursor))
+	assert.Equal(t, int64(15), cursor.Revision)
+	positions := make(map[string]uint64)
+	for _, p := range cursor.Positions {
+		positions[p.Log] = p.Seq
+	}
+	assert.Equal(t, map[string]uint64{"l1": 2, "l2": 7}, positions)
+	assert.Equal(t, map[string]uint64{"l1": 2, "l2": 8}, w.positions, "the positions skip the changes filtered out")
+}
+
+func TestPropertyWatch_StartCursor(t *testing.T) {
+	start := time.Now()
+	w, err := newPropertyWatch(&propertyv1.WatchRequest{Groups: []string{"g"}}, start)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), w.internalRequest(true).Request.StartRevision, "the nodes found at start only report the changes from now on")
+	assert.Equal(t, start.UnixNano(), w.internalRequest(false).Request.StartRevision, "the nodes joining later replay the changes since the start")
+
+	cursor, err := proto.Marshal(&propertyv1.WatchCursor{
+		Positions: []*propertyv1.WatchLogPosition{{Log: "l1", Seq: 3}},
+		Revision:  42,
+	})
+	require.NoError(t, err)
+	w, err = newPropertyWatch(&propertyv1.WatchRequest{Groups: []string{"g"}, StartRevision: 7, StartCursor: cursor}, start)
+	require.NoError(t, err)
+	req := w.internalRequest(true)
+	assert.Equal(t, int64(42), req.Request.StartRevision, "the cursor takes precedence over the start revision")
+	assert.Nil(t, req.Request.StartCursor)
+	require.Len(t, req.Positions, 1)
+	assert.Equal(t, uint64(3), req.Positions[0].Seq)
+
+	_, err = newPropertyWatch(&propertyv1.WatchRequest{Groups: []string{"g"}, StartCursor: []byte("invalid")}, start)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
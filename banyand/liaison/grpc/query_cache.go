@@ -0,0 +1,439 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+)
+
+const (
+	// maxQueryCacheSlices bounds the number of slices a time range is split into.
+	// Longer ranges use a multiple of the slice duration.
+	maxQueryCacheSlices = 64
+	// defaultQueryLimit is the limit applied by the query engine when the request sets none.
+	defaultQueryLimit uint32 = 100
+)
+
+// queryCache caches the query results at the liaison for repeated dashboard queries.
+//
+// A raw measure query is split into time slices. The slices ending before now minus the
+// closed grace are immutable, so their results are cached and reused, while the open tail
+// is always queried again. An aggregation or a TopN query caches the partial aggregates of
+// its slices instead, and combines them with the aggregates of the tail. The other queries
+// are cached as a whole once their time range is closed. Entries are dropped when the schema
+// or the group changes, or the data of the group is deleted through this liaison. The cache
+// takes at most memoryRatio of the protector's memory limit.
+//
+// A data point older than the closed grace written through this liaison drops the entries
+// of its group, and the group isn't cached for another grace while the point is synced to
+// the data nodes. The late data, the loaded parts and the deletions coming through other
+// liaisons are only seen once the entries expire after the TTL.
+type queryCache struct {
+	schema.UnimplementedOnInitHandler
+	protector     protector.Memory
+	metrics       *metrics
+	entries       map[string]*list.Element
+	lru           *list.List
+	revisions     map[cacheSubject]int64
+	generations   map[string]int64
+	settling      map[string]time.Time
+	memoryRatio   float64
+	sliceDuration time.Duration
+	closedGrace   time.Duration
+	ttl           time.Duration
+	size          int64
+	mu            sync.Mutex
+}
+
+type cacheSubject struct {
+	group string
+	name  string
+	kind  schema.Kind
+}
+
+// cacheStamp records the schema revision and the group generation of every queried group.
+// An entry is valid as long as its stamp equals the current one.
+type cacheStamp []int64
+
+type queryCacheEntry struct {
+	expireAt time.Time
+	value    proto.Message
+	key      string
+	stamp    cacheStamp
+	size     int64
+}
+
+// newQueryCache creates a cache whose entries expire after the ttl, or after the closed grace if the ttl is zero.
+func newQueryCache(memory protector.Memory, memoryRatio float64, sliceDuration, closedGrace, ttl time.Duration) *queryCache {
+	if ttl <= 0 {
+		ttl = closedGrace
+	}
+	return &queryCache{
+		protector:     memory,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+		revisions:     make(map[cacheSubject]int64),
+		generations:   make(map[string]int64),
+		settling:      make(map[string]time.Time),
+		memoryRatio:   memoryRatio,
+		sliceDuration: sliceDuration,
+		closedGrace:   closedGrace,
+		ttl:           ttl,
+	}
+}
+
+func (c *queryCache) enabled() bool {
+	return c != nil && c.protector != nil && c.memoryRatio > 0
+}
+
+// OnAddOrUpdate implements schema.EventHandler.
+func (c *queryCache) OnAddOrUpdate(schemaMetadata schema.Metadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch schemaMetadata.Kind {
+	case schema.KindGroup:
+		c.generations[schemaMetadata.Spec.(*commonv1.Group).GetMetadata().GetName()]++
+	case schema.KindMeasure:
+		md := schemaMetadata.Spec.(*databasev1.Measure).GetMetadata()
+		c.revisions[cacheSubject{kind: schema.KindMeasure, group: md.GetGroup(), name: md.GetName()}] = md.GetModRevision()
+	case schema.KindTopNAggregation:
+		md := schemaMetadata.Spec.(*databasev1.TopNAggregation).GetMetadata()
+		c.revisions[cacheSubject{kind: schema.KindTopNAggregation, group: md.GetGroup(), name: md.GetName()}] = md.GetModRevision()
+	default:
+	}
+}
+
+// OnDelete implements schema.EventHandler.
+func (c *queryCache) OnDelete(schemaMetadata schema.Metadata) {
+	var md *commonv1.Metadata
+	switch schemaMetadata.Kind {
+	case schema.KindGroup:
+		md = schemaMetadata.Spec.(*commonv1.Group).GetMetadata()
+		c.invalidateGroups([]string{md.GetName()})
+		return
+	case schema.KindMeasure:
+		md = schemaMetadata.Spec.(*databasev1.Measure).GetMetadata()
+	case schema.KindTopNAggregation:
+		md = schemaMetadata.Spec.(*databasev1.TopNAggregation).GetMetadata()
+	default:
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.revisions, cacheSubject{kind: schemaMetadata.Kind, group: md.GetGroup(), name: md.GetName()})
+	// A resource created again under the same name might report a revision seen before.
+	c.generations[md.GetGroup()]++
+}
+
+// invalidateGroups drops the entries of the groups, for example after their data is deleted.
+func (c *queryCache) invalidateGroups(groups []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, g := range groups {
+		c.generations[g]++
+	}
+}
+
+// onWrite drops the entries of the group if the data point falls into the closed slices,
+// and stops caching the group until the data point settles.
+func (c *queryCache) onWrite(group string, ts, now time.Time) {
+	if !c.enabled() || ts.After(now.Add(-c.closedGrace)) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[group]++
+	c.settling[group] = now.Add(c.closedGrace)
+}
+
+// stamp returns the stamp of the queried groups, or false if any of them has late data to settle.
+func (c *queryCache) stamp(kind schema.Kind, name string, groups []string, now time.Time) (cacheStamp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := make(cacheStamp, 0, 2*len(groups))
+	for _, g := range groups {
+		if until, ok := c.settling[g]; ok {
+			if now.Before(until) {
+				return nil, false
+			}
+			delete(c.settling, g)
+		}
+		s = append(s, c.revisions[cacheSubject{kind: kind, group: g, name: name}], c.generations[g])
+	}
+	return s, true
+}
+
+func (c *queryCache) get(key string, stamp cacheStamp, now time.Time) (proto.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*queryCacheEntry)
+	if !slices.Equal(e.stamp, stamp) || !now.Before(e.expireAt) {
+		c.removeElement(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return proto.Clone(e.value), true
+}
+
+func (c *queryCache) put(key string, stamp cacheStamp, value proto.Message, now time.Time) {
+	size := int64(proto.Size(value))
+	maxSize := int64(c.memoryRatio * float64(c.protector.GetLimit()))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.protector.State() == protector.StateHigh {
+		// Give the memory back under pressure instead of holding on to the results.
+		c.clear()
+		return
+	}
+	if size > maxSize || size > c.protector.AvailableBytes() {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+	for c.size+size > maxSize {
+		c.removeElement(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&queryCacheEntry{
+		key:      key,
+		stamp:    stamp,
+		value:    proto.Clone(value),
+		size:     size,
+		expireAt: now.Add(c.ttl),
+	})
+	c.size += size
+	c.updateSizeMetric()
+}
+
+func (c *queryCache) removeElement(el *list.Element) {
+	e := c.lru.Remove(el).(*queryCacheEntry)
+	delete(c.entries, e.key)
+	c.size -= e.size
+	c.updateSizeMetric()
+}
+
+func (c *queryCache) clear() {
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+	c.updateSizeMetric()
+}
+
+func (c *queryCache) updateSizeMetric() {
+	if c.metrics != nil {
+		c.metrics.queryCacheSize.Set(float64(c.size))
+	}
+}
+
+func (c *queryCache) record(service string, hit bool) {
+	if c.metrics == nil {
+		return
+	}
+	if hit {
+		c.metrics.queryCacheHit.Inc(1, service)
+		return
+	}
+	c.metrics.queryCacheMiss.Inc(1, service)
+}
+
+// cachedQuery returns the cached result of the key, or fetches and caches it.
+func cachedQuery[T proto.Message](c *queryCache, service, key string, stamp cacheStamp, now time.Time, fetch func() (T, error)) (T, error) {
+	if v, ok := c.get(key, stamp, now); ok {
+		c.record(service, true)
+		return v.(T), nil
+	}
+	c.record(service, false)
+	v, err := fetch()
+	if err != nil || !v.ProtoReflect().IsValid() {
+		return v, err
+	}
+	if m, ok := any(v).(*measurev1.QueryResponse); ok && m == emptyMeasureQueryResponse {
+		// No data node answered, so the result might not be complete.
+		return v, nil
+	}
+	c.put(key, stamp, v, now)
+	return v, nil
+}
+
+// cacheKey builds the key of the normalized request.
+func cacheKey(service string, req proto.Message) string {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	return service + ":" + string(b)
+}
+
+// queryMeasure answers the measure query from the cache and the data nodes.
+func (c *queryCache) queryMeasure(req *measurev1.QueryRequest, now time.Time,
+	fetch func(*measurev1.QueryRequest) (*measurev1.QueryResponse, error),
+) (*measurev1.QueryResponse, error) {
+	if req.Trace || req.TimeRange == nil {
+		return fetch(req)
+	}
+	begin, end := req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()
+	closedEnd := now.Add(-c.closedGrace)
+	stamp, ok := c.stamp(schema.KindMeasure, req.Name, req.Groups, now)
+	if !ok {
+		return fetch(req)
+	}
+	if !isSliceable(req) {
+		if isCombinable(req) {
+			if resp, combined, err := c.queryAggregation(req, stamp, now, fetch); combined || err != nil {
+				return resp, err
+			}
+		}
+		if end.After(closedEnd) {
+			return fetch(req)
+		}
+		normalized := proto.Clone(req).(*measurev1.QueryRequest)
+		normalized.GroupModRevisions = nil
+		return cachedQuery(c, "measure", cacheKey("measure", normalized), stamp, now, func() (*measurev1.QueryResponse, error) {
+			return fetch(req)
+		})
+	}
+	closed, tailBegin := c.split(begin, end, closedEnd)
+	if len(closed) == 0 {
+		return fetch(req)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultQueryLimit
+	}
+	wanted := int(req.Offset + limit)
+	sliceRequest := func(sliceBegin, sliceEnd time.Time) *measurev1.QueryRequest {
+		r := proto.Clone(req).(*measurev1.QueryRequest)
+		r.GroupModRevisions = nil
+		r.TimeRange = &modelv1.TimeRange{Begin: timestamppb.New(sliceBegin), End: timestamppb.New(sliceEnd)}
+		r.Offset = 0
+		r.Limit = uint32(wanted)
+		return r
+	}
+	fetchTail := func() (*measurev1.QueryResponse, error) {
+		if tailBegin.After(end) {
+			return emptyMeasureQueryResponse, nil
+		}
+		return fetch(sliceRequest(tailBegin, end))
+	}
+	// The slices don't overlap, so concatenating them in the query order keeps the data points sorted by time.
+	// Each slice returns the first offset+limit data points, which is enough to cut the page.
+	var steps []func() (*measurev1.QueryResponse, error)
+	for _, s := range closed {
+		sr := sliceRequest(s[0], s[1])
+		steps = append(steps, func() (*measurev1.QueryResponse, error) {
+			return cachedQuery(c, "measure", cacheKey("measure", sr), stamp, now, func() (*measurev1.QueryResponse, error) {
+				return fetch(sr)
+			})
+		})
+	}
+	steps = append(steps, fetchTail)
+	if req.OrderBy.GetSort() == modelv1.Sort_SORT_DESC {
+		slices.Reverse(steps)
+	}
+	result := &measurev1.QueryResponse{}
+	for _, step := range steps {
+		if len(result.DataPoints) >= wanted {
+			break
+		}
+		resp, err := step()
+		if err != nil {
+			return nil, err
+		}
+		result.DataPoints = append(result.DataPoints, resp.GetDataPoints()...)
+	}
+	if int(req.Offset) >= len(result.DataPoints) {
+		result.DataPoints = make([]*measurev1.DataPoint, 0)
+		return result, nil
+	}
+	result.DataPoints = result.DataPoints[req.Offset:min(len(result.DataPoints), wanted)]
+	return result, nil
+}
+
+// queryTopN answers the TopN query from the partial aggregates of the slices,
+// or from the cache as a whole once its time range is closed.
+func (c *queryCache) queryTopN(req *measurev1.TopNRequest, now time.Time,
+	fetch func(*measurev1.TopNRequest) (*measurev1.TopNResponse, error),
+) (*measurev1.TopNResponse, error) {
+	if req.Trace || req.TimeRange == nil {
+		return fetch(req)
+	}
+	stamp, ok := c.stamp(schema.KindTopNAggregation, req.Name, req.Groups, now)
+	if !ok {
+		return fetch(req)
+	}
+	if req.Agg != modelv1.AggregationFunction_AGGREGATION_FUNCTION_UNSPECIFIED {
+		if resp, combined, err := c.queryTopNAggregation(req, stamp, now, fetch); combined || err != nil {
+			return resp, err
+		}
+	}
+	if req.TimeRange.End.AsTime().After(now.Add(-c.closedGrace)) {
+		return fetch(req)
+	}
+	return cachedQuery(c, "topn", cacheKey("topn", req), stamp, now, func() (*measurev1.TopNResponse, error) {
+		return fetch(req)
+	})
+}
+
+// split splits the time range into the closed slices and the beginning of the open tail.
+// The slices are aligned to the slice duration, so that the sliding ranges of a dashboard share them.
+// Both ends of the time range and of the slices are inclusive.
+func (c *queryCache) split(begin, end, closedEnd time.Time) (closed [][2]time.Time, tailBegin time.Time) {
+	d := c.sliceDuration
+	if d <= 0 {
+		return nil, begin
+	}
+	if n := end.Sub(begin) / (d * maxQueryCacheSlices); n > 0 {
+		d *= n + 1
+	}
+	tailBegin = begin
+	for {
+		next := tailBegin.Truncate(d).Add(d)
+		if next.After(closedEnd) || next.Add(-time.Nanosecond).After(end) {
+			return closed, tailBegin
+		}
+		closed = append(closed, [2]time.Time{tailBegin, next.Add(-time.Nanosecond)})
+		tailBegin = next
+	}
+}
+
+// isSliceable reports whether the result of the query is the concatenation of the results of its time slices.
+// It holds for the raw data points sorted by time.
+func isSliceable(req *measurev1.QueryRequest) bool {
+	return req.Agg == nil && req.GroupBy == nil && req.Top == nil &&
+		req.OrderBy.GetIndexRuleName() == "" && !req.RewriteAggTopNResult
+}
@@ -0,0 +1,364 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+)
+
+// maxQueryCachePartials bounds the groups of an aggregation and the items of a TopN query returned by a slice.
+// A slice reaching it might miss some of them, so the query isn't answered from the partial aggregates.
+const maxQueryCachePartials = 10000
+
+// isCombinable reports whether the result of the query could be combined from the partial aggregates of its time slices.
+func isCombinable(req *measurev1.QueryRequest) bool {
+	return req.Agg != nil && req.OrderBy.GetIndexRuleName() == "" && !req.RewriteAggTopNResult
+}
+
+// partialFunctions returns the functions whose results of a slice make up the partial aggregate of the function.
+// A mean is combined from the sums and the counts of the slices.
+func partialFunctions(af modelv1.AggregationFunction) []modelv1.AggregationFunction {
+	if af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN {
+		return []modelv1.AggregationFunction{
+			modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+			modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+		}
+	}
+	return []modelv1.AggregationFunction{af}
+}
+
+// sliceRanges returns the closed slices of the time range followed by its open tail, if any.
+func (c *queryCache) sliceRanges(timeRange *modelv1.TimeRange, now time.Time) (ranges [][2]time.Time, closedNum int) {
+	begin, end := timeRange.Begin.AsTime(), timeRange.End.AsTime()
+	closed, tailBegin := c.split(begin, end, now.Add(-c.closedGrace))
+	ranges = closed
+	if !tailBegin.After(end) {
+		ranges = append(ranges, [2]time.Time{tailBegin, end})
+	}
+	return ranges, len(closed)
+}
+
+// queryAggregation combines the aggregation from the cached partial aggregates of the closed slices and the ones of the open tail.
+// It reports false if the query isn't split, or a slice returns too many groups to be combined.
+func (c *queryCache) queryAggregation(req *measurev1.QueryRequest, stamp cacheStamp, now time.Time,
+	fetch func(*measurev1.QueryRequest) (*measurev1.QueryResponse, error),
+) (*measurev1.QueryResponse, bool, error) {
+	ranges, closedNum := c.sliceRanges(req.TimeRange, now)
+	if closedNum == 0 {
+		return nil, false, nil
+	}
+	functions := partialFunctions(req.Agg.Function)
+	merged := newPartialSet(req.Agg.Function)
+	dataPoints := make(map[string]*measurev1.DataPoint)
+	for i, r := range ranges {
+		values := make(map[string][]*modelv1.FieldValue)
+		var keys []string
+		for j, af := range functions {
+			sr := proto.Clone(req).(*measurev1.QueryRequest)
+			sr.GroupModRevisions = nil
+			sr.TimeRange = &modelv1.TimeRange{Begin: timestamppb.New(r[0]), End: timestamppb.New(r[1])}
+			sr.Agg = &measurev1.QueryRequest_Aggregation{Function: af, FieldName: req.Agg.FieldName}
+			sr.Top = nil
+			sr.Offset = 0
+			sr.Limit = maxQueryCachePartials
+			var resp *measurev1.QueryResponse
+			var err error
+			if i < closedNum {
+				resp, err = cachedQuery(c, "measure", cacheKey("measure", sr), stamp, now, func() (*measurev1.QueryResponse, error) {
+					return fetch(sr)
+				})
+			} else {
+				resp, err = fetch(sr)
+			}
+			if err != nil {
+				return nil, true, err
+			}
+			if len(resp.GetDataPoints()) >= maxQueryCachePartials {
+				return nil, false, nil
+			}
+			for _, dp := range resp.GetDataPoints() {
+				key, keyErr := groupKey(dp, req.GroupBy)
+				if keyErr != nil {
+					return nil, true, keyErr
+				}
+				if _, ok := dataPoints[key]; !ok {
+					dataPoints[key] = dp
+				}
+				if _, ok := values[key]; !ok {
+					values[key] = make([]*modelv1.FieldValue, len(functions))
+					keys = append(keys, key)
+				}
+				values[key][j] = aggregatedValue(dp, req.Agg.FieldName)
+			}
+		}
+		for _, key := range keys {
+			if err := merged.combine(key, values[key]); err != nil {
+				return nil, true, err
+			}
+		}
+	}
+
+	result := &measurev1.QueryResponse{DataPoints: make([]*measurev1.DataPoint, 0, len(merged.keys))}
+	for _, key := range merged.keys {
+		fv, err := merged.value(key)
+		if err != nil {
+			return nil, true, err
+		}
+		dp := proto.Clone(dataPoints[key]).(*measurev1.DataPoint)
+		dp.Fields = []*measurev1.DataPoint_Field{{Name: req.Agg.FieldName, Value: fv}}
+		result.DataPoints = append(result.DataPoints, dp)
+	}
+	if req.Top != nil {
+		slices.SortStableFunc(result.DataPoints, func(a, b *measurev1.DataPoint) int {
+			return compareFieldValues(aggregatedValue(a, req.Top.FieldName), aggregatedValue(b, req.Top.FieldName), req.Top.FieldValueSort)
+		})
+		result.DataPoints = result.DataPoints[:min(len(result.DataPoints), int(req.Top.Number))]
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultQueryLimit
+	}
+	offset := min(len(result.DataPoints), int(req.Offset))
+	result.DataPoints = result.DataPoints[offset:min(len(result.DataPoints), offset+int(limit))]
+	return result, true, nil
+}
+
+// queryTopNAggregation combines the TopN query from the cached partial aggregates of the closed slices and the ones of the open tail.
+// Each slice returns every item it holds rather than the top n, so that the items out of the top n of a slice still count.
+// It reports false if the query isn't split, or a slice returns too many items to be combined.
+func (c *queryCache) queryTopNAggregation(req *measurev1.TopNRequest, stamp cacheStamp, now time.Time,
+	fetch func(*measurev1.TopNRequest) (*measurev1.TopNResponse, error),
+) (*measurev1.TopNResponse, bool, error) {
+	ranges, closedNum := c.sliceRanges(req.TimeRange, now)
+	if closedNum == 0 {
+		return nil, false, nil
+	}
+	functions := partialFunctions(req.Agg)
+	merged := newPartialSet(req.Agg)
+	entities := make(map[string][]*modelv1.Tag)
+	for i, r := range ranges {
+		values := make(map[string][]*modelv1.FieldValue)
+		var keys []string
+		for j, af := range functions {
+			sr := proto.Clone(req).(*measurev1.TopNRequest)
+			sr.TimeRange = &modelv1.TimeRange{Begin: timestamppb.New(r[0]), End: timestamppb.New(r[1])}
+			sr.Agg = af
+			sr.TopN = maxQueryCachePartials
+			var resp *measurev1.TopNResponse
+			var err error
+			if i < closedNum {
+				resp, err = cachedQuery(c, "topn", cacheKey("topn", sr), stamp, now, func() (*measurev1.TopNResponse, error) {
+					return fetch(sr)
+				})
+			} else {
+				resp, err = fetch(sr)
+			}
+			if err != nil {
+				return nil, true, err
+			}
+			for _, l := range resp.GetLists() {
+				if len(l.Items) >= maxQueryCachePartials {
+					return nil, false, nil
+				}
+				for _, item := range l.Items {
+					key, keyErr := entityKey(item.Entity)
+					if keyErr != nil {
+						return nil, true, keyErr
+					}
+					if _, ok := entities[key]; !ok {
+						entities[key] = item.Entity
+					}
+					if _, ok := values[key]; !ok {
+						values[key] = make([]*modelv1.FieldValue, len(functions))
+						keys = append(keys, key)
+					}
+					values[key][j] = item.Value
+				}
+			}
+		}
+		for _, key := range keys {
+			if err := merged.combine(key, values[key]); err != nil {
+				return nil, true, err
+			}
+		}
+	}
+
+	items := make([]*measurev1.TopNList_Item, 0, len(merged.keys))
+	for _, key := range merged.keys {
+		fv, err := merged.value(key)
+		if err != nil {
+			return nil, true, err
+		}
+		items = append(items, &measurev1.TopNList_Item{Entity: entities[key], Value: fv})
+	}
+	slices.SortStableFunc(items, func(a, b *measurev1.TopNList_Item) int {
+		return compareFieldValues(a.Value, b.Value, req.FieldValueSort)
+	})
+	items = items[:min(len(items), int(req.TopN))]
+	return &measurev1.TopNResponse{Lists: []*measurev1.TopNList{{Timestamp: timestamppb.New(now), Items: items}}}, true, nil
+}
+
+// partialSet combines the partial aggregates of the groups over the slices in the order the groups first appear.
+type partialSet struct {
+	reduces  map[string]*partialReduce
+	keys     []string
+	function modelv1.AggregationFunction
+}
+
+type partialReduce struct {
+	ints   aggregation.Reduce[int64]
+	floats aggregation.Reduce[float64]
+}
+
+func newPartialSet(af modelv1.AggregationFunction) *partialSet {
+	return &partialSet{function: af, reduces: make(map[string]*partialReduce)}
+}
+
+// combine merges the partial aggregate of a slice, which is a value or, for a mean, a sum and a count.
+// The group might be absent in the sum or the count of the open tail, if it's written between the two queries.
+func (ps *partialSet) combine(key string, fvs []*modelv1.FieldValue) error {
+	if fvs[0] == nil {
+		return nil
+	}
+	r, ok := ps.reduces[key]
+	if !ok {
+		r = &partialReduce{}
+		ps.reduces[key] = r
+		ps.keys = append(ps.keys, key)
+	}
+	_, isFloat := fvs[0].GetValue().(*modelv1.FieldValue_Float)
+	for i := range fvs {
+		if fvs[i] != nil {
+			continue
+		}
+		fvs[i] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{}}}
+		if isFloat {
+			fvs[i] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{}}}
+		}
+	}
+	if isFloat {
+		return combinePartial(&r.floats, ps.function, fvs)
+	}
+	return combinePartial(&r.ints, ps.function, fvs)
+}
+
+func (ps *partialSet) value(key string) (*modelv1.FieldValue, error) {
+	r := ps.reduces[key]
+	if r.floats != nil {
+		return aggregation.ToFieldValue(r.floats.Val())
+	}
+	return aggregation.ToFieldValue(r.ints.Val())
+}
+
+func combinePartial[N aggregation.Number](reduce *aggregation.Reduce[N], af modelv1.AggregationFunction, fvs []*modelv1.FieldValue) error {
+	if *reduce == nil {
+		r, err := aggregation.NewReduce[N](af)
+		if err != nil {
+			return err
+		}
+		*reduce = r
+	}
+	p, err := aggregation.FieldValuesToPartial[N](af, fvs)
+	if err != nil {
+		return err
+	}
+	(*reduce).Combine(p)
+	return nil
+}
+
+// groupKey identifies the group of an aggregated data point by the values of the group-by tags.
+func groupKey(dp *measurev1.DataPoint, groupBy *measurev1.QueryRequest_GroupBy) (string, error) {
+	if groupBy == nil {
+		return "", nil
+	}
+	var sb strings.Builder
+	for _, family := range groupBy.GetTagProjection().GetTagFamilies() {
+		for _, name := range family.GetTags() {
+			var value *modelv1.TagValue
+			for _, tf := range dp.GetTagFamilies() {
+				if tf.GetName() != family.GetName() {
+					continue
+				}
+				for _, t := range tf.GetTags() {
+					if t.GetKey() == name {
+						value = t.GetValue()
+					}
+				}
+			}
+			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			sb.Write(b)
+			sb.WriteByte(0)
+		}
+	}
+	return sb.String(), nil
+}
+
+func entityKey(entity []*modelv1.Tag) (string, error) {
+	var sb strings.Builder
+	for _, t := range entity {
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(t)
+		if err != nil {
+			return "", err
+		}
+		sb.Write(b)
+		sb.WriteByte(0)
+	}
+	return sb.String(), nil
+}
+
+// aggregatedValue returns the value of the field, or the first field if it's absent.
+func aggregatedValue(dp *measurev1.DataPoint, fieldName string) *modelv1.FieldValue {
+	for _, f := range dp.GetFields() {
+		if f.GetName() == fieldName {
+			return f.GetValue()
+		}
+	}
+	if len(dp.GetFields()) > 0 {
+		return dp.GetFields()[0].GetValue()
+	}
+	return nil
+}
+
+// compareFieldValues orders the values ascending for SORT_ASC, and descending otherwise.
+func compareFieldValues(a, b *modelv1.FieldValue, sort modelv1.Sort) int {
+	va, vb := numericValue(a), numericValue(b)
+	if sort == modelv1.Sort_SORT_ASC {
+		return cmp.Compare(va, vb)
+	}
+	return cmp.Compare(vb, va)
+}
+
+func numericValue(v *modelv1.FieldValue) float64 {
+	if f, ok := v.GetValue().(*modelv1.FieldValue_Float); ok {
+		return f.Float.GetValue()
+	}
+	return float64(v.GetInt().GetValue())
+}
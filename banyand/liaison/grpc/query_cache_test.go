@@ -0,0 +1,436 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+)
+
+type cacheTestMemory struct {
+	protector.Nop
+	limit uint64
+}
+
+func (m cacheTestMemory) GetLimit() uint64 { return m.limit }
+
+func (m cacheTestMemory) AvailableBytes() int64 { return int64(m.limit) }
+
+func newTestQueryCache(limit uint64) *queryCache {
+	return newQueryCache(cacheTestMemory{limit: limit}, 0.5, 5*time.Minute, 5*time.Minute, time.Hour)
+}
+
+// minuteFetcher answers a measure query with a data point per minute in the time range.
+type minuteFetcher struct {
+	ranges [][2]time.Time
+}
+
+func (f *minuteFetcher) fetch(req *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+	begin, end := req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()
+	f.ranges = append(f.ranges, [2]time.Time{begin, end})
+	var timestamps []time.Time
+	for ts := begin.Truncate(time.Minute); !ts.After(end); ts = ts.Add(time.Minute) {
+		if !ts.Before(begin) {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	if req.OrderBy.GetSort() == modelv1.Sort_SORT_DESC {
+		for i, j := 0, len(timestamps)-1; i < j; i, j = i+1, j-1 {
+			timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
+		}
+	}
+	timestamps = timestamps[min(len(timestamps), int(req.Offset)):]
+	timestamps = timestamps[:min(len(timestamps), int(req.Limit))]
+	resp := &measurev1.QueryResponse{}
+	for _, ts := range timestamps {
+		resp.DataPoints = append(resp.DataPoints, &measurev1.DataPoint{Timestamp: timestamppb.New(ts)})
+	}
+	return resp, nil
+}
+
+func dataPointTimes(resp *measurev1.QueryResponse) []time.Time {
+	var tt []time.Time
+	for _, dp := range resp.DataPoints {
+		tt = append(tt, dp.Timestamp.AsTime())
+	}
+	return tt
+}
+
+func testMeasureRequest(begin, end time.Time, limit uint32) *measurev1.QueryRequest {
+	return &measurev1.QueryRequest{
+		Groups:    []string{"sw_metric"},
+		Name:      "service_cpm_minute",
+		TimeRange: &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)},
+		Limit:     limit,
+	}
+}
+
+func TestQueryCache_ReusesClosedSlices(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	req := testMeasureRequest(base, now, 100)
+	f := &minuteFetcher{}
+	expected, err := f.fetch(req)
+	require.NoError(t, err)
+	f.ranges = nil
+
+	resp, err := c.queryMeasure(req, now, f.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, dataPointTimes(expected), dataPointTimes(resp))
+	// 11 closed slices and the open tail.
+	require.Len(t, f.ranges, 12)
+	assert.Equal(t, [2]time.Time{base, base.Add(5*time.Minute - time.Nanosecond)}, f.ranges[0])
+	assert.Equal(t, [2]time.Time{base.Add(55 * time.Minute), now}, f.ranges[11])
+
+	// The dashboard refreshes a minute later.
+	f.ranges = nil
+	later := now.Add(time.Minute)
+	req = testMeasureRequest(base.Add(time.Minute), later, 100)
+	expected, err = (&minuteFetcher{}).fetch(req)
+	require.NoError(t, err)
+	resp, err = c.queryMeasure(req, later, f.fetch)
+	require.NoError(t, err)
+	assert.Equal(t, dataPointTimes(expected), dataPointTimes(resp))
+	// The first slice starts at a new time, the others except the tail are cached.
+	assert.Equal(t, [][2]time.Time{
+		{base.Add(time.Minute), base.Add(5*time.Minute - time.Nanosecond)},
+		{base.Add(55 * time.Minute), later},
+	}, f.ranges)
+}
+
+func TestQueryCache_OffsetLimitAndOrder(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	for _, sort := range []modelv1.Sort{modelv1.Sort_SORT_ASC, modelv1.Sort_SORT_DESC} {
+		req := testMeasureRequest(base, now, 7)
+		req.Offset = 3
+		req.OrderBy = &modelv1.QueryOrder{Sort: sort}
+		f := &minuteFetcher{}
+		expected, err := f.fetch(req)
+		require.NoError(t, err)
+		f.ranges = nil
+		for i := 0; i < 2; i++ {
+			resp, err := c.queryMeasure(req, now, f.fetch)
+			require.NoError(t, err)
+			assert.Equal(t, dataPointTimes(expected), dataPointTimes(resp), sort.String())
+		}
+		if sort == modelv1.Sort_SORT_DESC {
+			// The page is cut from the tail and the newest slice, the older slices are never queried.
+			assert.Len(t, f.ranges, 3)
+		}
+	}
+}
+
+func TestQueryCache_Invalidation(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	req := testMeasureRequest(base, base.Add(30*time.Minute-time.Nanosecond), 100)
+	f := &minuteFetcher{}
+	query := func() int {
+		f.ranges = nil
+		_, err := c.queryMeasure(req, now, f.fetch)
+		require.NoError(t, err)
+		return len(f.ranges)
+	}
+	assert.Equal(t, 6, query())
+	assert.Equal(t, 0, query())
+
+	c.OnAddOrUpdate(schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindMeasure}, Spec: &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_minute", ModRevision: 10},
+	}})
+	assert.Equal(t, 6, query())
+	assert.Equal(t, 0, query())
+
+	c.invalidateGroups([]string{"sw_metric"})
+	assert.Equal(t, 6, query())
+
+	c.OnAddOrUpdate(schema.Metadata{TypeMeta: schema.TypeMeta{Kind: schema.KindGroup}, Spec: &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "sw_metric"},
+	}})
+	assert.Equal(t, 6, query())
+	assert.Equal(t, 0, query())
+}
+
+func TestQueryCache_LateWrite(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	req := testMeasureRequest(base, base.Add(30*time.Minute-time.Nanosecond), 100)
+	f := &minuteFetcher{}
+	query := func(at time.Time) int {
+		f.ranges = nil
+		_, err := c.queryMeasure(req, at, f.fetch)
+		require.NoError(t, err)
+		return len(f.ranges)
+	}
+	assert.Equal(t, 6, query(now))
+	assert.Equal(t, 0, query(now))
+
+	// A data point in the open tail doesn't touch the closed slices.
+	c.onWrite("sw_metric", now.Add(-time.Minute), now)
+	assert.Equal(t, 0, query(now))
+	c.onWrite("other", base, now)
+	assert.Equal(t, 0, query(now))
+
+	// A late data point drops the slices, and the group is queried as a whole until the point settles.
+	c.onWrite("sw_metric", base.Add(time.Minute), now)
+	assert.Equal(t, 1, query(now))
+	assert.Equal(t, 1, query(now.Add(time.Minute)))
+	settled := now.Add(5 * time.Minute)
+	assert.Equal(t, 6, query(settled))
+	assert.Equal(t, 0, query(settled))
+}
+
+func TestQueryCache_WholeResponse(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	calls := 0
+	fetchTopN := func(*measurev1.TopNRequest) (*measurev1.TopNResponse, error) {
+		calls++
+		return &measurev1.TopNResponse{Lists: []*measurev1.TopNList{{Timestamp: timestamppb.New(base)}}}, nil
+	}
+	open := &measurev1.TopNRequest{
+		Groups:    []string{"sw_metric"},
+		Name:      "endpoint_cpm_minute_top_bottom",
+		TimeRange: &modelv1.TimeRange{Begin: timestamppb.New(base), End: timestamppb.New(now)},
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.queryTopN(open, now, fetchTopN)
+		require.NoError(t, err)
+		require.Len(t, resp.Lists, 1)
+	}
+	assert.Equal(t, 2, calls, "the open time range is never cached")
+
+	closed := &measurev1.TopNRequest{
+		Groups:    []string{"sw_metric"},
+		Name:      "endpoint_cpm_minute_top_bottom",
+		TimeRange: &modelv1.TimeRange{Begin: timestamppb.New(base), End: timestamppb.New(now.Add(-10 * time.Minute))},
+	}
+	calls = 0
+	for i := 0; i < 2; i++ {
+		resp, err := c.queryTopN(closed, now, fetchTopN)
+		require.NoError(t, err)
+		require.Len(t, resp.Lists, 1)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+var testServices = []string{"a", "b", "c"}
+
+// serviceValue is the value of the service at the time, the fetchers below aggregate them by minute.
+func serviceValue(i int, ts time.Time) int64 {
+	return int64(ts.Minute()+1) * int64(i+1)
+}
+
+func minutesOf(tr *modelv1.TimeRange) []time.Time {
+	begin, end := tr.Begin.AsTime(), tr.End.AsTime()
+	var tt []time.Time
+	for ts := begin.Truncate(time.Minute); !ts.After(end); ts = ts.Add(time.Minute) {
+		if !ts.Before(begin) {
+			tt = append(tt, ts)
+		}
+	}
+	return tt
+}
+
+// aggregationFetcher answers an aggregation grouped by the service.
+type aggregationFetcher struct {
+	ranges [][2]time.Time
+}
+
+func (f *aggregationFetcher) fetch(req *measurev1.QueryRequest) (*measurev1.QueryResponse, error) {
+	f.ranges = append(f.ranges, [2]time.Time{req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()})
+	minutes := minutesOf(req.TimeRange)
+	resp := &measurev1.QueryResponse{}
+	if len(minutes) == 0 {
+		return resp, nil
+	}
+	for i, svc := range testServices {
+		m, err := aggregation.NewMap[int64](req.Agg.Function)
+		if err != nil {
+			return nil, err
+		}
+		for _, ts := range minutes {
+			m.In(serviceValue(i, ts))
+		}
+		v, err := aggregation.ToFieldValue(m.Val())
+		if err != nil {
+			return nil, err
+		}
+		resp.DataPoints = append(resp.DataPoints, &measurev1.DataPoint{
+			TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{
+				{Key: "service", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: svc}}}},
+			}}},
+			Fields: []*measurev1.DataPoint_Field{{Name: req.Agg.FieldName, Value: v}},
+		})
+	}
+	if req.Top != nil {
+		slices.SortStableFunc(resp.DataPoints, func(a, b *measurev1.DataPoint) int {
+			return compareFieldValues(a.Fields[0].Value, b.Fields[0].Value, req.Top.FieldValueSort)
+		})
+		resp.DataPoints = resp.DataPoints[:min(len(resp.DataPoints), int(req.Top.Number))]
+	}
+	resp.DataPoints = resp.DataPoints[min(len(resp.DataPoints), int(req.Offset)):]
+	resp.DataPoints = resp.DataPoints[:min(len(resp.DataPoints), int(req.Limit))]
+	return resp, nil
+}
+
+func TestQueryCache_PartialAggregation(t *testing.T) {
+	now := base.Add(time.Hour)
+	for _, af := range []modelv1.AggregationFunction{
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	} {
+		c := newTestQueryCache(1 << 30)
+		req := testMeasureRequest(base, now, 2)
+		req.Offset = 1
+		req.Agg = &measurev1.QueryRequest_Aggregation{Function: af, FieldName: "total"}
+		req.GroupBy = &measurev1.QueryRequest_GroupBy{
+			TagProjection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"service"}}}},
+			FieldName:     "total",
+		}
+		req.Top = &measurev1.QueryRequest_Top{Number: 2, FieldName: "total", FieldValueSort: modelv1.Sort_SORT_ASC}
+		f := &aggregationFetcher{}
+		expected, err := f.fetch(req)
+		require.NoError(t, err)
+		f.ranges = nil
+
+		resp, err := c.queryMeasure(req, now, f.fetch)
+		require.NoError(t, err)
+		assert.Empty(t, cmp.Diff(expected, resp, protocmp.Transform()), af.String())
+		calls := len(f.ranges)
+
+		f.ranges = nil
+		resp, err = c.queryMeasure(req, now, f.fetch)
+		require.NoError(t, err)
+		assert.Empty(t, cmp.Diff(expected, resp, protocmp.Transform()), af.String())
+		// the closed slices are cached, only the open tail is queried again
+		assert.Len(t, f.ranges, calls/12, af.String())
+		for _, r := range f.ranges {
+			assert.Equal(t, [2]time.Time{base.Add(55 * time.Minute), now}, r)
+		}
+	}
+}
+
+// topNFetcher answers a TopN query aggregating the values of the services.
+type topNFetcher struct {
+	ranges [][2]time.Time
+}
+
+func (f *topNFetcher) fetch(req *measurev1.TopNRequest) (*measurev1.TopNResponse, error) {
+	f.ranges = append(f.ranges, [2]time.Time{req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()})
+	list := &measurev1.TopNList{Timestamp: timestamppb.New(base)}
+	for i, svc := range testServices {
+		m, err := aggregation.NewMap[int64](req.Agg)
+		if err != nil {
+			return nil, err
+		}
+		minutes := minutesOf(req.TimeRange)
+		if len(minutes) == 0 {
+			continue
+		}
+		for _, ts := range minutes {
+			m.In(serviceValue(i, ts))
+		}
+		list.Items = append(list.Items, &measurev1.TopNList_Item{
+			Entity: []*modelv1.Tag{{Key: "service", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: svc}}}}},
+			Value:  &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: m.Val()}}},
+		})
+	}
+	slices.SortStableFunc(list.Items, func(a, b *measurev1.TopNList_Item) int {
+		return compareFieldValues(a.Value, b.Value, req.FieldValueSort)
+	})
+	list.Items = list.Items[:min(len(list.Items), int(req.TopN))]
+	return &measurev1.TopNResponse{Lists: []*measurev1.TopNList{list}}, nil
+}
+
+func TestQueryCache_PartialTopN(t *testing.T) {
+	c := newTestQueryCache(1 << 30)
+	now := base.Add(time.Hour)
+	req := &measurev1.TopNRequest{
+		Groups:         []string{"sw_metric"},
+		Name:           "endpoint_cpm_minute_top_bottom",
+		TimeRange:      &modelv1.TimeRange{Begin: timestamppb.New(base), End: timestamppb.New(now)},
+		TopN:           2,
+		Agg:            modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+		FieldValueSort: modelv1.Sort_SORT_DESC,
+	}
+	f := &topNFetcher{}
+	expected, err := f.fetch(req)
+	require.NoError(t, err)
+	f.ranges = nil
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.queryTopN(req, now, f.fetch)
+		require.NoError(t, err)
+		require.Len(t, resp.Lists, 1)
+		assert.Empty(t, cmp.Diff(expected.Lists[0].Items, resp.Lists[0].Items, protocmp.Transform()))
+	}
+	// the sums and the counts of 11 closed slices and the open tail, then the ones of the open tail
+	assert.Len(t, f.ranges, 2*12+2)
+}
+
+func TestQueryCache_TTL(t *testing.T) {
+	c := newQueryCache(cacheTestMemory{limit: 1 << 30}, 0.5, 5*time.Minute, 5*time.Minute, 0)
+	now := base.Add(time.Hour)
+	req := testMeasureRequest(base, base.Add(30*time.Minute-time.Nanosecond), 100)
+	f := &minuteFetcher{}
+	query := func(at time.Time) int {
+		f.ranges = nil
+		_, err := c.queryMeasure(req, at, f.fetch)
+		require.NoError(t, err)
+		return len(f.ranges)
+	}
+	assert.Equal(t, 6, query(now))
+	assert.Equal(t, 0, query(now.Add(4*time.Minute)))
+	assert.Equal(t, 6, query(now.Add(5*time.Minute)), "the entries expire after the closed grace by default")
+}
+
+func TestQueryCache_MemoryBound(t *testing.T) {
+	now := base.Add(time.Hour)
+	req := testMeasureRequest(base, base.Add(30*time.Minute-time.Nanosecond), 100)
+	f := &minuteFetcher{}
+	slice, err := f.fetch(testMeasureRequest(base, base.Add(5*time.Minute-time.Nanosecond), 100))
+	require.NoError(t, err)
+	size := proto.Size(slice)
+	// Two slices fit in the cache.
+	c := newTestQueryCache(uint64(5 * size))
+	_, err = c.queryMeasure(req, now, f.fetch)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, c.size, int64(c.memoryRatio*float64(5*size)))
+	assert.Equal(t, 2, c.lru.Len())
+	assert.Len(t, c.entries, 2)
+}
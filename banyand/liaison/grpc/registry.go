@@ -312,6 +312,29 @@ func (rs *indexRuleBindingRegistryServer) Exist(ctx context.Context, req *databa
 	return &databasev1.IndexRuleBindingRegistryServiceExistResponse{HasGroup: exist, HasIndexRuleBinding: false}, nil
 }
 
+func (rs *indexRuleBindingRegistryServer) Backfill(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (
+	*databasev1.IndexRuleBindingRegistryServiceBackfillResponse, error,
+) {
+	g := req.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "indexRuleBinding", "backfill")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "indexRuleBinding", "backfill")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "indexRuleBinding", "backfill")
+	}()
+	binding, err := rs.schemaRegistry.IndexRuleBindingRegistry().GetIndexRuleBinding(ctx, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRuleBinding", "backfill")
+		return nil, err
+	}
+	shardCount, err := rs.schemaRegistry.BackfillIndex(ctx, binding.GetSubject().GetCatalog(), req)
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "indexRuleBinding", "backfill")
+		return nil, err
+	}
+	return &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{ShardCount: shardCount}, nil
+}
+
 type indexRuleRegistryServer struct {
 	databasev1.UnimplementedIndexRuleRegistryServiceServer
 	schemaRegistry metadata.Repo
@@ -445,6 +468,222 @@ func (rs *indexRuleRegistryServer) Exist(ctx context.Context, req *databasev1.In
 	return &databasev1.IndexRuleRegistryServiceExistResponse{HasGroup: exist, HasIndexRule: false}, nil
 }
 
+type analyzerRegistryServer struct {
+	databasev1.UnimplementedAnalyzerRegistryServiceServer
+	schemaRegistry metadata.Repo
+	metrics        *metrics
+}
+
+func (rs *analyzerRegistryServer) Create(ctx context.Context,
+	req *databasev1.AnalyzerRegistryServiceCreateRequest) (
+	*databasev1.AnalyzerRegistryServiceCreateResponse, error,
+) {
+	g := req.Analyzer.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "analyzer", "create")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "analyzer", "create")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "analyzer", "create")
+	}()
+	modRevision, err := rs.schemaRegistry.AnalyzerRegistry().CreateAnalyzer(ctx, req.GetAnalyzer())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "analyzer", "create")
+		return nil, err
+	}
+	return &databasev1.AnalyzerRegistryServiceCreateResponse{ModRevision: modRevision}, nil
+}
+
+func (rs *analyzerRegistryServer) Update(ctx context.Context,
+	req *databasev1.AnalyzerRegistryServiceUpdateRequest) (
+	*databasev1.AnalyzerRegistryServiceUpdateResponse, error,
+) {
+	g := req.Analyzer.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "analyzer", "update")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "analyzer", "update")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "analyzer", "update")
+	}()
+	modRevision, err := rs.schemaRegistry.AnalyzerRegistry().UpdateAnalyzer(ctx, req.GetAnalyzer())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "analyzer", "update")
+		return nil, err
+	}
+	return &databasev1.AnalyzerRegistryServiceUpdateResponse{ModRevision: modRevision}, nil
+}
+
+func (rs *analyzerRegistryServer) Delete(ctx context.Context,
+	req *databasev1.AnalyzerRegistryServiceDeleteRequest) (
+	*databasev1.AnalyzerRegistryServiceDeleteResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "analyzer", "delete")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "analyzer", "delete")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "analyzer", "delete")
+	}()
+	ok, deleteTime, err := rs.schemaRegistry.AnalyzerRegistry().DeleteAnalyzer(ctx, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "analyzer", "delete")
+		return nil, err
+	}
+	return &databasev1.AnalyzerRegistryServiceDeleteResponse{
+		Deleted:    ok,
+		DeleteTime: deleteTime,
+	}, nil
+}
+
+func (rs *analyzerRegistryServer) Get(ctx context.Context,
+	req *databasev1.AnalyzerRegistryServiceGetRequest) (
+	*databasev1.AnalyzerRegistryServiceGetResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "analyzer", "get")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "analyzer", "get")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "analyzer", "get")
+	}()
+	entity, err := rs.schemaRegistry.AnalyzerRegistry().GetAnalyzer(ctx, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "analyzer", "get")
+		return nil, err
+	}
+	return &databasev1.AnalyzerRegistryServiceGetResponse{
+		Analyzer: entity,
+	}, nil
+}
+
+func (rs *analyzerRegistryServer) List(ctx context.Context,
+	req *databasev1.AnalyzerRegistryServiceListRequest) (
+	*databasev1.AnalyzerRegistryServiceListResponse, error,
+) {
+	g := req.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "analyzer", "list")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "analyzer", "list")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "analyzer", "list")
+	}()
+	entities, err := rs.schemaRegistry.AnalyzerRegistry().ListAnalyzer(ctx, schema.ListOpt{Group: req.GetGroup()})
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "analyzer", "list")
+		return nil, err
+	}
+	return &databasev1.AnalyzerRegistryServiceListResponse{
+		Analyzer: entities,
+	}, nil
+}
+
+type continuousAggregationRegistryServer struct {
+	databasev1.UnimplementedContinuousAggregationRegistryServiceServer
+	schemaRegistry metadata.Repo
+	metrics        *metrics
+}
+
+func (rs *continuousAggregationRegistryServer) Create(ctx context.Context,
+	req *databasev1.ContinuousAggregationRegistryServiceCreateRequest) (
+	*databasev1.ContinuousAggregationRegistryServiceCreateResponse, error,
+) {
+	g := req.ContinuousAggregation.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "continuous_aggregation", "create")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "continuous_aggregation", "create")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "continuous_aggregation", "create")
+	}()
+	modRevision, err := rs.schemaRegistry.ContinuousAggregationRegistry().CreateContinuousAggregation(ctx, req.GetContinuousAggregation())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "continuous_aggregation", "create")
+		return nil, err
+	}
+	return &databasev1.ContinuousAggregationRegistryServiceCreateResponse{ModRevision: modRevision}, nil
+}
+
+func (rs *continuousAggregationRegistryServer) Update(ctx context.Context,
+	req *databasev1.ContinuousAggregationRegistryServiceUpdateRequest) (
+	*databasev1.ContinuousAggregationRegistryServiceUpdateResponse, error,
+) {
+	g := req.ContinuousAggregation.GetMetadata().GetGroup()
+	rs.metrics.totalRegistryStarted.Inc(1, g, "continuous_aggregation", "update")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "continuous_aggregation", "update")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "continuous_aggregation", "update")
+	}()
+	modRevision, err := rs.schemaRegistry.ContinuousAggregationRegistry().UpdateContinuousAggregation(ctx, req.GetContinuousAggregation())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "continuous_aggregation", "update")
+		return nil, err
+	}
+	return &databasev1.ContinuousAggregationRegistryServiceUpdateResponse{ModRevision: modRevision}, nil
+}
+
+func (rs *continuousAggregationRegistryServer) Delete(ctx context.Context,
+	req *databasev1.ContinuousAggregationRegistryServiceDeleteRequest) (
+	*databasev1.ContinuousAggregationRegistryServiceDeleteResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "continuous_aggregation", "delete")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "continuous_aggregation", "delete")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "continuous_aggregation", "delete")
+	}()
+	ok, deleteTime, err := rs.schemaRegistry.ContinuousAggregationRegistry().DeleteContinuousAggregation(ctx, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "continuous_aggregation", "delete")
+		return nil, err
+	}
+	return &databasev1.ContinuousAggregationRegistryServiceDeleteResponse{
+		Deleted:    ok,
+		DeleteTime: deleteTime,
+	}, nil
+}
+
+func (rs *continuousAggregationRegistryServer) Get(ctx context.Context,
+	req *databasev1.ContinuousAggregationRegistryServiceGetRequest) (
+	*databasev1.ContinuousAggregationRegistryServiceGetResponse, error,
+) {
+	g := req.Metadata.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "continuous_aggregation", "get")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "continuous_aggregation", "get")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "continuous_aggregation", "get")
+	}()
+	entity, err := rs.schemaRegistry.ContinuousAggregationRegistry().GetContinuousAggregation(ctx, req.GetMetadata())
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "continuous_aggregation", "get")
+		return nil, err
+	}
+	return &databasev1.ContinuousAggregationRegistryServiceGetResponse{
+		ContinuousAggregation: entity,
+	}, nil
+}
+
+func (rs *continuousAggregationRegistryServer) List(ctx context.Context,
+	req *databasev1.ContinuousAggregationRegistryServiceListRequest) (
+	*databasev1.ContinuousAggregationRegistryServiceListResponse, error,
+) {
+	g := req.Group
+	rs.metrics.totalRegistryStarted.Inc(1, g, "continuous_aggregation", "list")
+	start := time.Now()
+	defer func() {
+		rs.metrics.totalRegistryFinished.Inc(1, g, "continuous_aggregation", "list")
+		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "continuous_aggregation", "list")
+	}()
+	entities, err := rs.schemaRegistry.ContinuousAggregationRegistry().ListContinuousAggregation(ctx, schema.ListOpt{Group: req.GetGroup()})
+	if err != nil {
+		rs.metrics.totalRegistryErr.Inc(1, g, "continuous_aggregation", "list")
+		return nil, err
+	}
+	return &databasev1.ContinuousAggregationRegistryServiceListResponse{
+		ContinuousAggregation: entities,
+	}, nil
+}
+
 type measureRegistryServer struct {
 	databasev1.UnimplementedMeasureRegistryServiceServer
 	schemaRegistry metadata.Repo
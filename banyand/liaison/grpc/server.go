@@ -30,6 +30,8 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/pkg/errors"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -39,6 +41,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
 	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -50,6 +53,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/grpc/route"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/otlp"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema/property"
@@ -76,6 +80,8 @@ var (
 	errNoAddr            = errors.New("no address")
 	errQueryMsg          = errors.New("invalid query message")
 	errAccessLogRootPath = errors.New("access log root path is required")
+	errNoOTLPTrace       = errors.New("the group and the name of the OTLP trace are required")
+	errNoOTLPLogs        = errors.New("the group and the name of the OTLP logs stream are required")
 
 	liaisonGrpcScope = observability.RootScope.SubScope("liaison_grpc")
 )
@@ -85,6 +91,8 @@ type Server interface {
 	run.Unit
 	GetAuthReloader() *auth.Reloader
 	GetPort() *uint32
+	// SetPartSyncer serves the loads of the parts built offline through the syncer.
+	SetPartSyncer(syncer PartSyncer)
 }
 
 // NodeRegistries contains the node registries.
@@ -93,19 +101,29 @@ type NodeRegistries struct {
 	MeasureLiaisonNodeRegistry NodeRegistry
 	PropertyNodeRegistry       NodeRegistry
 	TraceLiaisonNodeRegistry   NodeRegistry
+	// The data node registries locate the data nodes receiving the parts built offline. A standalone server leaves them nil.
+	MeasureDataNodeRegistry NodeRegistry
+	StreamDataNodeRegistry  NodeRegistry
+	TraceDataNodeRegistry   NodeRegistry
 }
 
 type server struct {
 	databasev1.UnimplementedSnapshotServiceServer
 	databasev1.UnimplementedClusterStateServiceServer
 	databasev1.UnimplementedNodeQueryServiceServer
-	omr        observability.MetricsRegistry
-	schemaRepo metadata.Repo
-	curNode    *databasev1.Node
-	protector  protector.Memory
-	traceSVC   *traceService
-	stopCh     chan struct{}
+	omr          observability.MetricsRegistry
+	schemaRepo   metadata.Repo
+	curNode      *databasev1.Node
+	protector    protector.Memory
+	traceSVC     *traceService
+	otlpTraceSVC *otlpTraceService
+	otlpLogsSVC  *otlpLogsService
+	cdcSVC       *cdcService
+	partLoadSVC  *partLoadService
+	stopCh       chan struct{}
 	*indexRuleRegistryServer
+	*analyzerRegistryServer
+	*continuousAggregationRegistryServer
 	*measureRegistryServer
 	streamSVC     *streamService
 	barrierSVC    *barrierService
@@ -132,16 +150,34 @@ type server struct {
 	accessLogRootPath        string
 	certFile                 string
 	host                     string
+	otlpTraceGroup           string
+	otlpTraceName            string
+	otlpTraceMappings        []string
+	otlpLogsGroup            string
+	otlpLogsName             string
+	otlpLogsMappings         []string
 	accessLogRecorders       []accessLogRecorder
 	queryAccessLogRecorders  []queryAccessLogRecorder
 	maxRecvMsgSize           run.Bytes
 	grpcBufferMemoryRatio    float64
+	queryCacheMemoryRatio    float64
+	queryCacheSliceDuration  time.Duration
+	queryCacheClosedGrace    time.Duration
+	queryCacheTTL            time.Duration
+	cdcRetentionPeriod       time.Duration
+	cdcSettleDelay           time.Duration
+	tailBufferSize           int
+	tailMaxSubscribers       int
 	port                     uint32
+	cdcReplicas              uint32
 	tls                      bool
 	enableIngestionAccessLog bool
 	enableQueryAccessLog     bool
 	accessLogSampled         bool
 	healthAuthEnabled        bool
+	otlpTraceEnabled         bool
+	otlpLogsEnabled          bool
+	cdcEnabled               bool
 }
 
 // NewServer returns a new gRPC server.
@@ -232,6 +268,12 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 		indexRuleRegistryServer: &indexRuleRegistryServer{
 			schemaRegistry: schemaRegistry,
 		},
+		analyzerRegistryServer: &analyzerRegistryServer{
+			schemaRegistry: schemaRegistry,
+		},
+		continuousAggregationRegistryServer: &continuousAggregationRegistryServer{
+			schemaRegistry: schemaRegistry,
+		},
 		measureRegistryServer: &measureRegistryServer{
 			schemaRegistry: schemaRegistry,
 		},
@@ -253,6 +295,20 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 		protector:           protectorService,
 		routeTableProviders: routeProviders,
 	}
+	s.otlpTraceSVC = &otlpTraceService{traceSVC: traceSVC, shedLoad: s.shedLoad}
+	s.otlpLogsSVC = &otlpLogsService{streamSVC: streamSVC, shedLoad: s.shedLoad}
+	s.cdcSVC = newCDCService()
+	s.partLoadSVC = &partLoadService{
+		schemaRegistry: schemaRegistry,
+		registries: map[commonv1.Catalog]NodeRegistry{
+			commonv1.Catalog_CATALOG_MEASURE: nr.MeasureDataNodeRegistry,
+			commonv1.Catalog_CATALOG_STREAM:  nr.StreamDataNodeRegistry,
+			commonv1.Catalog_CATALOG_TRACE:   nr.TraceDataNodeRegistry,
+		},
+		chunkSize: run.Bytes(1024 * 1024),
+		maxSize:   run.Bytes(10 * 1024 * 1024 * 1024),
+	}
+	streamSVC.cdc, measureSVC.cdc, traceSVC.cdc, propertyService.cdc = s.cdcSVC, s.cdcSVC, s.cdcSVC, s.cdcSVC
 	s.accessLogRecorders = []accessLogRecorder{streamSVC, measureSVC, traceSVC, s.propertyServer}
 	s.queryAccessLogRecorders = []queryAccessLogRecorder{streamSVC, measureSVC, traceSVC, s.propertyServer}
 
@@ -287,6 +343,12 @@ func (s *server) PreRun(ctx context.Context) error {
 			return err
 		}
 	}
+	if s.partLoadSVC.rootPath, err = banyandbpath.Get(s.partLoadSVC.rootPath); err != nil {
+		return err
+	}
+	s.partLoadSVC.l = s.log.Named("part-load")
+	s.partLoadSVC.lfs = fslib.NewLocalFileSystemWithLogger(s.partLoadSVC.l)
+	s.partLoadSVC.lfs.MkdirIfNotExist(s.partLoadSVC.rootPath, fslib.Mode(storage.DirPerm))
 
 	s.streamSVC.setLogger(s.log.Named("stream-t1"))
 	s.measureSVC.setLogger(s.log)
@@ -296,9 +358,15 @@ func (s *server) PreRun(ctx context.Context) error {
 	s.groupRegistryServer.deletionTaskManager = newGroupDeletionTaskManager(
 		s.groupRegistryServer.schemaRegistry, s.propertyServer, s.groupRepo, s.log.Named("group-deletion"),
 	)
+	s.groupRegistryServer.deletionTaskManager.cdc = s.cdcSVC
 	if initErr := s.groupRegistryServer.deletionTaskManager.initPropertyStorage(ctx); initErr != nil {
 		return initErr
 	}
+	if s.cdcEnabled {
+		if initErr := s.initCDC(ctx); initErr != nil {
+			return initErr
+		}
+	}
 	components := []*discoveryService{
 		s.streamSVC.discoveryService,
 		s.measureSVC.discoveryService,
@@ -306,6 +374,12 @@ func (s *server) PreRun(ctx context.Context) error {
 		s.propertyServer.discoveryService,
 	}
 	s.schemaRepo.RegisterHandler("liaison", schema.KindGroup, s.groupRepo)
+	if s.queryCacheMemoryRatio > 0 && s.protector != nil {
+		s.measureSVC.queryCache = newQueryCache(s.protector, s.queryCacheMemoryRatio, s.queryCacheSliceDuration,
+			s.queryCacheClosedGrace, s.queryCacheTTL)
+		s.schemaRepo.RegisterHandler("liaison-query-cache",
+			schema.KindGroup|schema.KindMeasure|schema.KindTopNAggregation, s.measureSVC.queryCache)
+	}
 	for _, c := range components {
 		c.SetLogger(s.log)
 		if err := c.initialize(); err != nil {
@@ -337,12 +411,18 @@ func (s *server) PreRun(ctx context.Context) error {
 	s.metrics = metrics
 	s.streamSVC.metrics = metrics
 	s.measureSVC.metrics = metrics
+	if s.measureSVC.queryCache != nil {
+		s.measureSVC.queryCache.metrics = metrics
+	}
 	s.traceSVC.metrics = metrics
+	s.cdcSVC.metrics = metrics
 	s.bydbQLSVC.metrics = metrics
 	s.propertyServer.metrics = metrics
 	s.streamRegistryServer.metrics = metrics
 	s.indexRuleBindingRegistryServer.metrics = metrics
 	s.indexRuleRegistryServer.metrics = metrics
+	s.analyzerRegistryServer.metrics = metrics
+	s.continuousAggregationRegistryServer.metrics = metrics
 	s.measureRegistryServer.metrics = metrics
 	s.groupRegistryServer.metrics = metrics
 	s.topNAggregationRegistryServer.metrics = metrics
@@ -360,6 +440,19 @@ func (s *server) PreRun(ctx context.Context) error {
 	return nil
 }
 
+// initCDC creates the storage of the change log on the data nodes, and records the schema changes into it.
+func (s *server) initCDC(ctx context.Context) error {
+	if err := s.cdcSVC.initStorage(ctx, s.groupRegistryServer.schemaRegistry, s.cdcReplicas); err != nil {
+		return errors.WithMessage(err, "failed to initialize the change log")
+	}
+	s.cdcSVC.store = s.propertyServer
+	s.cdcSVC.retention = s.cdcRetentionPeriod
+	s.cdcSVC.settleDelay = s.cdcSettleDelay
+	s.cdcSVC.l = s.log.Named("cdc")
+	s.schemaRepo.RegisterHandler("liaison-cdc", cdcSchemaKinds, s.cdcSVC)
+	return nil
+}
+
 func (s *server) initCurrentNode(ctx context.Context) {
 	nodeVal := ctx.Value(common.ContextNodeKey)
 	roleVal := ctx.Value(common.ContextNodeRolesKey)
@@ -391,6 +484,10 @@ func (s *server) GetPort() *uint32 {
 }
 
 // GetAuthReloader returns auth reloader (for httpserver).
+func (s *server) SetPartSyncer(syncer PartSyncer) {
+	s.partLoadSVC.syncer = syncer
+}
+
 func (s *server) GetAuthReloader() *auth.Reloader {
 	return s.authReloader
 }
@@ -420,6 +517,38 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.DurationVar(&s.traceSVC.maxWaitDuration, "trace-metadata-cache-wait-duration", 0,
 		"the maximum duration to wait for metadata cache to load (for testing purposes)")
 	fs.IntVar(&s.propertyServer.repairQueueCount, "property-repair-queue-count", 128, "the number of queues for property repair")
+	fs.Float64Var(&s.queryCacheMemoryRatio, "query-cache-memory-ratio", 0,
+		"ratio of the memory limit used by the measure query result cache, 0 disables the cache")
+	fs.DurationVar(&s.queryCacheSliceDuration, "query-cache-slice-duration", 5*time.Minute,
+		"the duration of the time slices whose results are cached separately")
+	fs.DurationVar(&s.queryCacheClosedGrace, "query-cache-closed-grace", 5*time.Minute,
+		"the data older than now minus the grace is treated as immutable and its results are cached, it should exceed the delay of late data")
+	fs.DurationVar(&s.queryCacheTTL, "query-cache-ttl", 0,
+		"how long the cached results live, which bounds how long the changes through other liaisons stay invisible, 0 means the closed grace")
+	fs.BoolVar(&s.otlpTraceEnabled, "otlp-trace-enabled", false, "enable the OTLP trace receiver, the TraceService/Export of OpenTelemetry")
+	fs.StringVar(&s.otlpTraceGroup, "otlp-trace-group", "otlp", "the group of the trace storing the OTLP spans")
+	fs.StringVar(&s.otlpTraceName, "otlp-trace-name", "otlp_spans", "the trace storing the OTLP spans")
+	fs.StringSliceVar(&s.otlpTraceMappings, "otlp-trace-tag-mappings", nil,
+		"the rules in the form of source=tag filling the trace tags by the span fields or the resource and span attributes")
+	fs.BoolVar(&s.otlpLogsEnabled, "otlp-logs-enabled", false, "enable the OTLP logs receiver, the LogsService/Export of OpenTelemetry")
+	fs.StringVar(&s.otlpLogsGroup, "otlp-logs-group", "otlp_logs", "the group of the stream storing the OTLP log records")
+	fs.StringVar(&s.otlpLogsName, "otlp-logs-name", "otlp_logs", "the stream storing the OTLP log records")
+	fs.StringSliceVar(&s.otlpLogsMappings, "otlp-logs-tag-mappings", nil,
+		"the rules in the form of source=tag filling the stream tags by the log record fields or the resource and log record attributes")
+	fs.IntVar(&s.tailBufferSize, "tail-buffer-size", defaultTailBufferSize,
+		"the number of elements or spans buffered for a stream or trace tail if the request doesn't set it")
+	fs.IntVar(&s.tailMaxSubscribers, "tail-max-subscribers", 100, "the maximum number of the stream and trace tails served at the same time, 0 means no limit")
+	fs.BoolVar(&s.cdcEnabled, "cdc-enabled", false,
+		"enable the change data capture, which records the schema changes, the property applies and deletes, and the data deletions")
+	fs.DurationVar(&s.cdcRetentionPeriod, "cdc-retention-period", 24*time.Hour, "how long the changes are retained, 0 means no limit")
+	fs.Uint32Var(&s.cdcReplicas, "cdc-replicas", 1, "the number of the replicas of the change log on the data nodes, which applies when the log is created")
+	fs.DurationVar(&s.cdcSettleDelay, "cdc-settle-delay", 5*time.Second,
+		"how long a change is held back from the subscriptions, which covers the clock skew and the recording latency of the liaisons")
+	fs.StringVar(&s.partLoadSVC.rootPath, "part-load-root-path", "/tmp",
+		"the root path holding the parts built offline until they are loaded into the data nodes, which needs room for the loaded parts")
+	fs.VarP(&s.partLoadSVC.chunkSize, "part-load-chunk-size", "", "the size of the chunks sending the loaded parts to the data nodes")
+	fs.VarP(&s.partLoadSVC.maxSize, "part-load-max-size", "", "the maximum total size of the files in a load of the parts")
+	fs.IntVar(&s.partLoadSVC.maxFiles, "part-load-max-files", 100000, "the maximum number of the files and the directories in a load of the parts")
 	s.grpcBufferMemoryRatio = 0.1
 	fs.Float64Var(&s.grpcBufferMemoryRatio, "grpc-buffer-memory-ratio", 0.1,
 		"ratio of memory limit to use for gRPC buffer size calculation (0.0 < ratio <= 1.0)")
@@ -437,6 +566,48 @@ func (s *server) Validate() error {
 	if s.grpcBufferMemoryRatio <= 0.0 || s.grpcBufferMemoryRatio > 1.0 {
 		return errors.Errorf("grpc-buffer-memory-ratio must be in range (0.0, 1.0], got %f", s.grpcBufferMemoryRatio)
 	}
+	if s.queryCacheMemoryRatio < 0.0 || s.queryCacheMemoryRatio >= 1.0 {
+		return errors.Errorf("query-cache-memory-ratio must be in range [0.0, 1.0), got %f", s.queryCacheMemoryRatio)
+	}
+	if s.queryCacheTTL < 0 {
+		return errors.Errorf("query-cache-ttl must not be negative, got %s", s.queryCacheTTL)
+	}
+	if s.tailBufferSize < 0 || s.tailBufferSize > maxTailBufferSize {
+		return errors.Errorf("tail-buffer-size must be in range [0, %d], got %d", maxTailBufferSize, s.tailBufferSize)
+	}
+	if s.tailMaxSubscribers < 0 {
+		return errors.Errorf("tail-max-subscribers must not be negative, got %d", s.tailMaxSubscribers)
+	}
+	if s.cdcEnabled {
+		if s.cdcRetentionPeriod < 0 || s.cdcSettleDelay < 0 {
+			return errors.Errorf("cdc-retention-period and cdc-settle-delay must not be negative, got %s and %s",
+				s.cdcRetentionPeriod, s.cdcSettleDelay)
+		}
+	}
+	if s.partLoadSVC.maxSize <= 0 || s.partLoadSVC.maxFiles <= 0 {
+		return errors.Errorf("part-load-max-size and part-load-max-files must be positive, got %s and %d",
+			s.partLoadSVC.maxSize.String(), s.partLoadSVC.maxFiles)
+	}
+	if s.otlpTraceEnabled {
+		if s.otlpTraceGroup == "" || s.otlpTraceName == "" {
+			return errNoOTLPTrace
+		}
+		mapping, err := otlp.ParseTagMapping(s.otlpTraceMappings)
+		if err != nil {
+			return err
+		}
+		s.otlpTraceSVC.group, s.otlpTraceSVC.name, s.otlpTraceSVC.mapping = s.otlpTraceGroup, s.otlpTraceName, mapping
+	}
+	if s.otlpLogsEnabled {
+		if s.otlpLogsGroup == "" || s.otlpLogsName == "" {
+			return errNoOTLPLogs
+		}
+		mapping, err := otlp.ParseLogTagMapping(s.otlpLogsMappings)
+		if err != nil {
+			return err
+		}
+		s.otlpLogsSVC.group, s.otlpLogsSVC.name, s.otlpLogsSVC.mapping = s.otlpLogsGroup, s.otlpLogsName, mapping
+	}
 	if !s.tls {
 		return nil
 	}
@@ -510,6 +681,8 @@ func (s *server) Serve() run.StopNotify {
 	s.ser = grpclib.NewServer(opts...)
 
 	commonv1.RegisterServiceServer(s.ser, &apiVersionService{})
+	s.streamSVC.tails.bufferSize, s.streamSVC.tails.maxSubscribers = s.tailBufferSize, s.tailMaxSubscribers
+	s.traceSVC.tails.bufferSize, s.traceSVC.tails.maxSubscribers = s.tailBufferSize, s.tailMaxSubscribers
 	streamv1.RegisterStreamServiceServer(s.ser, s.streamSVC)
 	measurev1.RegisterMeasureServiceServer(s.ser, s.measureSVC)
 	tracev1.RegisterTraceServiceServer(s.ser, s.traceSVC)
@@ -517,10 +690,12 @@ func (s *server) Serve() run.StopNotify {
 	databasev1.RegisterGroupRegistryServiceServer(s.ser, s.groupRegistryServer)
 	databasev1.RegisterIndexRuleBindingRegistryServiceServer(s.ser, s.indexRuleBindingRegistryServer)
 	databasev1.RegisterIndexRuleRegistryServiceServer(s.ser, s.indexRuleRegistryServer)
+	databasev1.RegisterAnalyzerRegistryServiceServer(s.ser, s.analyzerRegistryServer)
 	databasev1.RegisterStreamRegistryServiceServer(s.ser, s.streamRegistryServer)
 	databasev1.RegisterMeasureRegistryServiceServer(s.ser, s.measureRegistryServer)
 	propertyv1.RegisterPropertyServiceServer(s.ser, s.propertyServer)
 	databasev1.RegisterTopNAggregationRegistryServiceServer(s.ser, s.topNAggregationRegistryServer)
+	databasev1.RegisterContinuousAggregationRegistryServiceServer(s.ser, s.continuousAggregationRegistryServer)
 	databasev1.RegisterSnapshotServiceServer(s.ser, s)
 	databasev1.RegisterPropertyRegistryServiceServer(s.ser, s.propertyRegistryServer)
 	databasev1.RegisterTraceRegistryServiceServer(s.ser, s.traceRegistryServer)
@@ -532,6 +707,20 @@ func (s *server) Serve() run.StopNotify {
 	if s.nodeStatusSVC != nil {
 		clusterv1.RegisterNodeSchemaStatusServiceServer(s.ser, s.nodeStatusSVC)
 	}
+	if s.otlpTraceEnabled {
+		collectortracev1.RegisterTraceServiceServer(s.ser, s.otlpTraceSVC)
+	}
+	if s.otlpLogsEnabled {
+		collectorlogsv1.RegisterLogsServiceServer(s.ser, s.otlpLogsSVC)
+	}
+	if s.partLoadSVC.enabled() {
+		databasev1.RegisterPartLoadServiceServer(s.ser, s.partLoadSVC)
+	}
+	if s.cdcEnabled {
+		cdcv1.RegisterChangeDataCaptureServiceServer(s.ser, s.cdcSVC)
+		go s.cdcSVC.recordLoop()
+		go s.cdcSVC.expireLoop()
+	}
 	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
 
 	s.stopCh = make(chan struct{})
@@ -556,9 +745,21 @@ func (s *server) Serve() run.StopNotify {
 
 // protectorLoadSheddingInterceptor rejects streams when memory pressure is high.
 func (s *server) protectorLoadSheddingInterceptor(srv interface{}, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, handler grpclib.StreamHandler) error {
+	fullMethod := ""
+	if info != nil {
+		fullMethod = info.FullMethod
+	}
+	if err := s.shedLoad(fullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// shedLoad returns a ResourceExhausted error if the memory pressure is high. The fullMethod is the rejected gRPC method.
+func (s *server) shedLoad(fullMethod string) error {
 	// Fail open if protector is not available
 	if s.protector == nil {
-		return handler(srv, ss)
+		return nil
 	}
 
 	// Get current memory state and update metric
@@ -574,9 +775,9 @@ func (s *server) protectorLoadSheddingInterceptor(srv interface{}, ss grpclib.Se
 	if state == protector.StateHigh {
 		// Extract service name from FullMethod (e.g., "/banyandb.stream.v1.StreamService/Write")
 		serviceName := "unknown"
-		if info != nil && info.FullMethod != "" {
+		if fullMethod != "" {
 			// Extract service name from FullMethod
-			parts := strings.Split(info.FullMethod, "/")
+			parts := strings.Split(fullMethod, "/")
 			if len(parts) >= 2 {
 				serviceName = parts[1]
 			}
@@ -585,7 +786,7 @@ func (s *server) protectorLoadSheddingInterceptor(srv interface{}, ss grpclib.Se
 		// Log rejection with metrics
 		if s.log != nil {
 			s.log.Warn().
-				Str("service", info.FullMethod).
+				Str("service", fullMethod).
 				Msg("rejecting new stream due to high memory pressure")
 		}
 		if s.metrics != nil {
@@ -595,7 +796,7 @@ func (s *server) protectorLoadSheddingInterceptor(srv interface{}, ss grpclib.Se
 		return status.Errorf(codes.ResourceExhausted, "server is under memory pressure, please retry later")
 	}
 
-	return handler(srv, ss)
+	return nil
 }
 
 // calculateGrpcBufferSizes calculates the gRPC buffer sizes based on available system memory.
@@ -678,6 +879,10 @@ func (s *server) GracefulStop() {
 	if s.authConfigFile != "" && s.authReloader != nil {
 		s.authReloader.Stop()
 	}
+	// the tails never end by themselves, which would hold the graceful stop
+	s.streamSVC.tails.stop()
+	s.traceSVC.tails.stop()
+	s.cdcSVC.stop()
 	stopped := make(chan struct{})
 	go func() {
 		s.ser.GracefulStop()
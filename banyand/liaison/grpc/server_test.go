@@ -51,6 +51,7 @@ func TestGrpcBufferMemoryRatioFlagDefault(t *testing.T) {
 		measureSVC:     &measureService{},
 		traceSVC:       &traceService{},
 		propertyServer: &propertyServer{},
+		partLoadSVC:    &partLoadService{},
 	}
 	fs := s.FlagSet()
 	flag := fs.Lookup("grpc-buffer-memory-ratio")
@@ -79,6 +80,7 @@ func TestGrpcBufferMemoryRatioValidation(t *testing.T) {
 				grpcBufferMemoryRatio: tt.ratio,
 				host:                  "localhost",
 				port:                  17912,
+				partLoadSVC:           &partLoadService{maxSize: 1, maxFiles: 1},
 			}
 			err := s.Validate()
 			if tt.expectErr {
@@ -63,6 +63,8 @@ type streamService struct {
 	*discoveryService
 	l               *logger.Logger
 	metrics         *metrics
+	cdc             *cdcService
+	tails           tailHub[*streamTailItem]
 	writeTimeout    time.Duration
 	maxWaitDuration time.Duration
 }
@@ -334,6 +336,9 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 			continue
 		}
 		s.groupRepo.releaseRequest(metadata.Group)
+		if s.tails.active() {
+			s.publishTail(metadata, writeEntity.GetElement(), spec)
+		}
 
 		succeedSent = append(succeedSent, succeedSentMessage{
 			metadata:  metadata,
@@ -0,0 +1,550 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const (
+	// defaultTailBufferSize is the number of items buffered for a tail if neither the flag nor the request sets it.
+	defaultTailBufferSize = 1024
+	// maxTailBufferSize is the maximum number of items buffered for a tail.
+	maxTailBufferSize = 65536
+)
+
+var (
+	errTailSlowConsumer = status.Error(codes.ResourceExhausted, "the tail is disconnected since it can't keep up with the writes")
+	errTailStopped      = status.Error(codes.Unavailable, "the server is stopping")
+)
+
+// tailSubscriber buffers the written items pushed to a tail.
+type tailSubscriber[T any] struct {
+	err     error
+	match   func(T) bool
+	items   chan T
+	done    chan struct{}
+	dropped atomic.Uint64
+	once    sync.Once
+	policy  modelv1.SlowConsumerPolicy
+}
+
+// offer buffers the item if it matches the tail. It never blocks the write path,
+// and applies the slow consumer policy of the tail if the buffer is full.
+func (ts *tailSubscriber[T]) offer(item T) {
+	select {
+	case <-ts.done:
+		return
+	default:
+	}
+	if !ts.match(item) {
+		return
+	}
+	for {
+		select {
+		case ts.items <- item:
+			return
+		default:
+		}
+		switch ts.policy {
+		case modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_DROP_NEWEST:
+			ts.dropped.Add(1)
+			return
+		case modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_DISCONNECT:
+			ts.stop(errTailSlowConsumer)
+			return
+		default:
+			select {
+			case <-ts.items:
+				ts.dropped.Add(1)
+			default:
+			}
+		}
+	}
+}
+
+func (ts *tailSubscriber[T]) stop(err error) {
+	ts.once.Do(func() {
+		ts.err = err
+		close(ts.done)
+	})
+}
+
+// serve sends the buffered items along with the number of the items dropped before them,
+// until the client goes away or the tail is stopped.
+func (ts *tailSubscriber[T]) serve(ctx context.Context, send func(item T, dropped uint64) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ts.done:
+			return ts.err
+		case item := <-ts.items:
+			if err := send(item, ts.dropped.Swap(0)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tailHub pushes the items accepted by the write path to the tails subscribing to their streams or traces.
+type tailHub[T any] struct {
+	subscribers    map[identity]map[uint64]*tailSubscriber[T]
+	mu             sync.RWMutex
+	nextID         uint64
+	count          atomic.Int64
+	bufferSize     int
+	maxSubscribers int
+	stopped        bool
+}
+
+func (th *tailHub[T]) newSubscriber(bufferSize uint32, policy modelv1.SlowConsumerPolicy, match func(T) bool) *tailSubscriber[T] {
+	size := th.bufferSize
+	if size <= 0 {
+		size = defaultTailBufferSize
+	}
+	if bufferSize > 0 {
+		size = int(min(bufferSize, maxTailBufferSize))
+	}
+	return &tailSubscriber[T]{
+		match:  match,
+		items:  make(chan T, size),
+		done:   make(chan struct{}),
+		policy: policy,
+	}
+}
+
+func (th *tailHub[T]) subscribe(ids []identity, sub *tailSubscriber[T]) (uint64, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	if th.stopped {
+		return 0, errTailStopped
+	}
+	if th.maxSubscribers > 0 && th.count.Load() >= int64(th.maxSubscribers) {
+		return 0, status.Errorf(codes.ResourceExhausted, "the number of tails reaches the limit %d", th.maxSubscribers)
+	}
+	if th.subscribers == nil {
+		th.subscribers = make(map[identity]map[uint64]*tailSubscriber[T])
+	}
+	th.nextID++
+	for _, id := range ids {
+		subs, ok := th.subscribers[id]
+		if !ok {
+			subs = make(map[uint64]*tailSubscriber[T])
+			th.subscribers[id] = subs
+		}
+		subs[th.nextID] = sub
+	}
+	th.count.Add(1)
+	return th.nextID, nil
+}
+
+func (th *tailHub[T]) unsubscribe(ids []identity, subID uint64) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	for _, id := range ids {
+		subs := th.subscribers[id]
+		delete(subs, subID)
+		if len(subs) == 0 {
+			delete(th.subscribers, id)
+		}
+	}
+	th.count.Add(-1)
+}
+
+// active reports whether there are any tails, so that the write path skips the lookups otherwise.
+func (th *tailHub[T]) active() bool {
+	return th.count.Load() > 0
+}
+
+// publish offers the item built by newItem to the tails of the stream or trace, if there are any.
+func (th *tailHub[T]) publish(id identity, newItem func() T) {
+	th.mu.RLock()
+	defer th.mu.RUnlock()
+	subs := th.subscribers[id]
+	if len(subs) == 0 {
+		return
+	}
+	item := newItem()
+	for _, sub := range subs {
+		sub.offer(item)
+	}
+}
+
+// stop ends all tails and rejects the new ones.
+func (th *tailHub[T]) stop() {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	th.stopped = true
+	for _, subs := range th.subscribers {
+		for _, sub := range subs {
+			sub.stop(errTailStopped)
+		}
+	}
+}
+
+// tailIdentities validates the groups and the name of a tail request.
+func tailIdentities(groups []string, name string) ([]identity, error) {
+	if len(groups) == 0 {
+		return nil, schema.BadRequest("groups", "groups should not be empty")
+	}
+	if name == "" {
+		return nil, schema.BadRequest("name", "name should not be empty")
+	}
+	ids := make([]identity, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, identity{group: g, name: name})
+	}
+	return ids, nil
+}
+
+// checkTailTags makes sure the tags referred by the criteria and the projection exist.
+func checkTailTags(id identity, criteria *modelv1.Criteria, projection []string, defined func(tagName string) bool) error {
+	tags := make(map[string]struct{}, len(projection))
+	logical.CollectCriteriaTagNames(criteria, tags)
+	for _, tagName := range projection {
+		tags[tagName] = struct{}{}
+	}
+	for tagName := range tags {
+		if !defined(tagName) {
+			return status.Errorf(codes.InvalidArgument, "tag %s isn't defined in %s", tagName, id)
+		}
+	}
+	return nil
+}
+
+func tailTagValue(tagFamilies logical.TagFamiliesForWrite, tagSpec logical.TagSpecMap, tagName string) *modelv1.TagValue {
+	spec := tagSpec.FindTagSpecByName(tagName)
+	if spec == nil {
+		return pbv1.NullTagValue
+	}
+	if v := tagFamilies.GetTagValue(spec.TagFamilyIdx, spec.TagIdx); v != nil {
+		return v
+	}
+	return pbv1.NullTagValue
+}
+
+// streamTailItem is an element accepted by the write path of a stream.
+type streamTailItem struct {
+	stream  *databasev1.Stream
+	element *streamv1.ElementValue
+	tagSpec logical.TagSpecMap
+	group   string
+	spec    []*streamv1.TagFamilySpec
+}
+
+func newStreamTailItem(stream *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) *streamTailItem {
+	tagSpec := logical.TagSpecMap{}
+	if spec != nil {
+		for i, family := range spec {
+			for j, tagName := range family.GetTagNames() {
+				tagSpec.RegisterTag(i, j, &databasev1.TagSpec{Name: tagName})
+			}
+		}
+	} else {
+		tagSpec.RegisterTagFamilies(stream.GetTagFamilies())
+	}
+	return &streamTailItem{
+		stream:  stream,
+		element: element,
+		tagSpec: tagSpec,
+		spec:    spec,
+		group:   stream.GetMetadata().GetGroup(),
+	}
+}
+
+func (it *streamTailItem) match(filter logical.TagFilter) bool {
+	ok, err := filter.Match(logical.TagFamiliesForWrite(it.element.GetTagFamilies()), it.tagSpec)
+	return err == nil && ok
+}
+
+// toElement renders the element with the tags of the projection, or all the written tags if the projection is absent.
+func (it *streamTailItem) toElement(projection *modelv1.TagProjection) *streamv1.Element {
+	element := &streamv1.Element{
+		ElementId: it.element.GetElementId(),
+		Timestamp: it.element.GetTimestamp(),
+	}
+	if projection != nil {
+		tagFamilies := logical.TagFamiliesForWrite(it.element.GetTagFamilies())
+		for _, pf := range projection.GetTagFamilies() {
+			family := &modelv1.TagFamily{Name: pf.GetName()}
+			for _, tagName := range pf.GetTags() {
+				family.Tags = append(family.Tags, &modelv1.Tag{Key: tagName, Value: tailTagValue(tagFamilies, it.tagSpec, tagName)})
+			}
+			element.TagFamilies = append(element.TagFamilies, family)
+		}
+		return element
+	}
+	for i, written := range it.element.GetTagFamilies() {
+		var familyName string
+		var tagNames []string
+		if it.spec != nil {
+			if i >= len(it.spec) {
+				break
+			}
+			familyName, tagNames = it.spec[i].GetName(), it.spec[i].GetTagNames()
+		} else {
+			if i >= len(it.stream.GetTagFamilies()) {
+				break
+			}
+			familySpec := it.stream.GetTagFamilies()[i]
+			familyName = familySpec.GetName()
+			for _, tagSpec := range familySpec.GetTags() {
+				tagNames = append(tagNames, tagSpec.GetName())
+			}
+		}
+		family := &modelv1.TagFamily{Name: familyName}
+		for j, v := range written.GetTags() {
+			if j >= len(tagNames) {
+				break
+			}
+			family.Tags = append(family.Tags, &modelv1.Tag{Key: tagNames[j], Value: v})
+		}
+		element.TagFamilies = append(element.TagFamilies, family)
+	}
+	return element
+}
+
+// publishTail pushes the accepted element to the tails of its stream.
+func (s *streamService) publishTail(metadata *commonv1.Metadata, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) {
+	id := getID(metadata)
+	s.tails.publish(id, func() *streamTailItem {
+		stream, ok := s.entityRepo.getStream(id)
+		if !ok {
+			return nil
+		}
+		return newStreamTailItem(stream, element, spec)
+	})
+}
+
+func (s *streamService) Tail(req *streamv1.TailRequest, stream streamv1.StreamService_TailServer) (err error) {
+	ids, err := tailIdentities(req.GetGroups(), req.GetName())
+	if err != nil {
+		return err
+	}
+	var projection []string
+	for _, pf := range req.GetProjection().GetTagFamilies() {
+		projection = append(projection, pf.GetTags()...)
+	}
+	for _, id := range ids {
+		streamSchema, ok := s.entityRepo.getStream(id)
+		if !ok {
+			return status.Errorf(codes.NotFound, "stream %s doesn't exist", id)
+		}
+		if err = checkTailTags(id, req.GetCriteria(), projection, func(tagName string) bool {
+			for _, family := range streamSchema.GetTagFamilies() {
+				for _, tagSpec := range family.GetTags() {
+					if tagSpec.GetName() == tagName {
+						return true
+					}
+				}
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+	}
+	filter, err := logical.BuildSimpleTagFilter(req.GetCriteria())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid criteria: %v", err)
+	}
+	s.metrics.totalStreamStarted.Inc(1, "stream", "tail")
+	start := time.Now()
+	defer func() {
+		s.metrics.totalStreamFinished.Inc(1, "stream", "tail")
+		s.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "stream", "tail")
+		if err != nil && status.Code(err) != codes.Canceled {
+			s.metrics.totalStreamErr.Inc(1, "stream", "tail")
+		}
+	}()
+	sub := s.tails.newSubscriber(req.GetBufferSize(), req.GetSlowConsumerPolicy(), func(item *streamTailItem) bool {
+		return item != nil && item.match(filter)
+	})
+	subID, err := s.tails.subscribe(ids, sub)
+	if err != nil {
+		return err
+	}
+	defer s.tails.unsubscribe(ids, subID)
+	// the header tells the client that the tail starts
+	if err = stream.SendHeader(grpcmd.MD{}); err != nil {
+		return err
+	}
+	return sub.serve(stream.Context(), func(item *streamTailItem, dropped uint64) error {
+		if sendErr := stream.Send(&streamv1.TailResponse{
+			Element: item.toElement(req.GetProjection()),
+			Group:   item.group,
+			Dropped: dropped,
+		}); sendErr != nil {
+			s.metrics.totalStreamMsgSentErr.Inc(1, item.group, "stream", "tail")
+			return sendErr
+		}
+		s.metrics.totalStreamMsgSent.Inc(1, item.group, "stream", "tail")
+		return nil
+	})
+}
+
+// traceTailItem is a span accepted by the write path of a trace.
+type traceTailItem struct {
+	trace   *databasev1.Trace
+	request *tracev1.WriteRequest
+	tagSpec logical.TagSpecMap
+	spec    *tracev1.TagSpec
+	group   string
+}
+
+func newTraceTailItem(trace *databasev1.Trace, request *tracev1.WriteRequest, spec *tracev1.TagSpec) *traceTailItem {
+	tagSpec := logical.TagSpecMap{}
+	if spec != nil {
+		for i, tagName := range spec.GetTagNames() {
+			tagSpec.RegisterTag(0, i, &databasev1.TagSpec{Name: tagName})
+		}
+	} else {
+		for i, ts := range trace.GetTags() {
+			tagSpec.RegisterTag(0, i, &databasev1.TagSpec{Name: ts.GetName(), Type: ts.GetType()})
+		}
+	}
+	return &traceTailItem{
+		trace:   trace,
+		request: request,
+		tagSpec: tagSpec,
+		spec:    spec,
+		group:   trace.GetMetadata().GetGroup(),
+	}
+}
+
+func (it *traceTailItem) tagFamilies() logical.TagFamiliesForWrite {
+	return logical.TagFamiliesForWrite{{Tags: it.request.GetTags()}}
+}
+
+func (it *traceTailItem) match(filter logical.TagFilter) bool {
+	ok, err := filter.Match(it.tagFamilies(), it.tagSpec)
+	return err == nil && ok
+}
+
+// toResponse renders the span with the tags of the projection, or all the written tags if the projection is empty.
+func (it *traceTailItem) toResponse(projection []string, dropped uint64) *tracev1.TailResponse {
+	tagFamilies := it.tagFamilies()
+	tagNames := projection
+	if len(tagNames) == 0 {
+		if it.spec != nil {
+			tagNames = it.spec.GetTagNames()
+		} else {
+			for _, ts := range it.trace.GetTags() {
+				tagNames = append(tagNames, ts.GetName())
+			}
+		}
+		tagNames = tagNames[:min(len(tagNames), len(it.request.GetTags()))]
+	}
+	span := &tracev1.Span{
+		Span:   it.request.GetSpan(),
+		SpanId: tailTagValue(tagFamilies, it.tagSpec, it.trace.GetSpanIdTagName()).GetStr().GetValue(),
+	}
+	for _, tagName := range tagNames {
+		span.Tags = append(span.Tags, &modelv1.Tag{Key: tagName, Value: tailTagValue(tagFamilies, it.tagSpec, tagName)})
+	}
+	return &tracev1.TailResponse{
+		Span:    span,
+		TraceId: tailTagValue(tagFamilies, it.tagSpec, it.trace.GetTraceIdTagName()).GetStr().GetValue(),
+		Group:   it.group,
+		Dropped: dropped,
+	}
+}
+
+// publishTail pushes the accepted span to the tails of its trace.
+func (s *traceService) publishTail(metadata *commonv1.Metadata, request *tracev1.WriteRequest, spec *tracev1.TagSpec) {
+	id := getID(metadata)
+	s.tails.publish(id, func() *traceTailItem {
+		trace, ok := s.entityRepo.getTrace(id)
+		if !ok {
+			return nil
+		}
+		return newTraceTailItem(trace, request, spec)
+	})
+}
+
+func (s *traceService) Tail(req *tracev1.TailRequest, stream tracev1.TraceService_TailServer) (err error) {
+	ids, err := tailIdentities(req.GetGroups(), req.GetName())
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		traceSchema, ok := s.entityRepo.getTrace(id)
+		if !ok {
+			return status.Errorf(codes.NotFound, "trace %s doesn't exist", id)
+		}
+		if err = checkTailTags(id, req.GetCriteria(), req.GetTagProjection(), func(tagName string) bool {
+			for _, tagSpec := range traceSchema.GetTags() {
+				if tagSpec.GetName() == tagName {
+					return true
+				}
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+	}
+	filter, err := logical.BuildSimpleTagFilter(req.GetCriteria())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid criteria: %v", err)
+	}
+	s.metrics.totalStreamStarted.Inc(1, "trace", "tail")
+	start := time.Now()
+	defer func() {
+		s.metrics.totalStreamFinished.Inc(1, "trace", "tail")
+		s.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "trace", "tail")
+		if err != nil && status.Code(err) != codes.Canceled {
+			s.metrics.totalStreamErr.Inc(1, "trace", "tail")
+		}
+	}()
+	sub := s.tails.newSubscriber(req.GetBufferSize(), req.GetSlowConsumerPolicy(), func(item *traceTailItem) bool {
+		return item != nil && item.match(filter)
+	})
+	subID, err := s.tails.subscribe(ids, sub)
+	if err != nil {
+		return err
+	}
+	defer s.tails.unsubscribe(ids, subID)
+	// the header tells the client that the tail starts
+	if err = stream.SendHeader(grpcmd.MD{}); err != nil {
+		return err
+	}
+	return sub.serve(stream.Context(), func(item *traceTailItem, dropped uint64) error {
+		if sendErr := stream.Send(item.toResponse(req.GetTagProjection(), dropped)); sendErr != nil {
+			s.metrics.totalStreamMsgSentErr.Inc(1, item.group, "trace", "tail")
+			return sendErr
+		}
+		s.metrics.totalStreamMsgSent.Inc(1, item.group, "trace", "tail")
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+)
+
+// mockTailServer collects the responses of a tail until its context is canceled.
+type mockTailServer[Res any] struct {
+	*mockBidiServer[struct{}, Res]
+	ctx  context.Context
+	sent chan *Res
+}
+
+func newMockTailServer[Res any](ctx context.Context) *mockTailServer[Res] {
+	return &mockTailServer[Res]{
+		mockBidiServer: &mockBidiServer[struct{}, Res]{},
+		ctx:            ctx,
+		sent:           make(chan *Res, 16),
+	}
+}
+
+func (s *mockTailServer[Res]) Send(resp *Res) error {
+	s.sent <- resp
+	return nil
+}
+
+func (s *mockTailServer[Res]) Context() context.Context { return s.ctx }
+
+func strTagValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func serviceNameCriteria(v string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name: "service_name", Op: modelv1.Condition_BINARY_OP_EQ, Value: strTagValue(v),
+	}}}
+}
+
+func TestTailSubscriber_SlowConsumerPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		want        []int
+		wantDropped uint64
+		policy      modelv1.SlowConsumerPolicy
+		wantStopped bool
+	}{
+		{name: "unspecified drops the oldest", policy: modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_UNSPECIFIED, want: []int{2, 3}, wantDropped: 1},
+		{name: "drop the oldest", policy: modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_DROP_OLDEST, want: []int{2, 3}, wantDropped: 1},
+		{name: "drop the newest", policy: modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_DROP_NEWEST, want: []int{1, 2}, wantDropped: 1},
+		{name: "disconnect", policy: modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_DISCONNECT, want: []int{1, 2}, wantStopped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hub tailHub[int]
+			sub := hub.newSubscriber(2, tt.policy, func(int) bool { return true })
+			for i := 1; i <= 3; i++ {
+				sub.offer(i)
+			}
+			var got []int
+			for len(sub.items) > 0 {
+				got = append(got, <-sub.items)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDropped, sub.dropped.Load())
+			select {
+			case <-sub.done:
+				assert.True(t, tt.wantStopped)
+				assert.Equal(t, codes.ResourceExhausted, status.Code(sub.err))
+			default:
+				assert.False(t, tt.wantStopped)
+			}
+		})
+	}
+}
+
+func TestTailHub_MaxSubscribers_ReturnsResourceExhausted(t *testing.T) {
+	hub := tailHub[int]{maxSubscribers: 1}
+	ids := []identity{{group: "g", name: "s"}}
+	newSub := func() *tailSubscriber[int] {
+		return hub.newSubscriber(0, modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_UNSPECIFIED, func(int) bool { return true })
+	}
+	subID, err := hub.subscribe(ids, newSub())
+	require.NoError(t, err)
+	_, err = hub.subscribe(ids, newSub())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	hub.unsubscribe(ids, subID)
+	assert.False(t, hub.active())
+	_, err = hub.subscribe(ids, newSub())
+	assert.NoError(t, err)
+}
+
+func TestTailHub_Stop_EndsTails(t *testing.T) {
+	var hub tailHub[int]
+	ids := []identity{{group: "g", name: "s"}}
+	sub := hub.newSubscriber(0, modelv1.SlowConsumerPolicy_SLOW_CONSUMER_POLICY_UNSPECIFIED, func(int) bool { return true })
+	_, err := hub.subscribe(ids, sub)
+	require.NoError(t, err)
+
+	hub.stop()
+	err = sub.serve(context.Background(), func(int, uint64) error { return nil })
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = hub.subscribe(ids, sub)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestStreamTail_InvalidRequest(t *testing.T) {
+	id := identity{group: "g", name: "s"}
+	svc := newTestStreamService(seededLogsStreamRepo(id), time.Millisecond)
+	server := newMockTailServer[streamv1.TailResponse](context.Background())
+
+	err := svc.Tail(&streamv1.TailRequest{Name: "s"}, server)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = svc.Tail(&streamv1.TailRequest{Groups: []string{"absent"}, Name: "s"}, server)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	err = svc.Tail(&streamv1.TailRequest{Groups: []string{"g"}, Name: "s", Criteria: &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: "absent", Op: modelv1.Condition_BINARY_OP_EQ, Value: strTagValue("a")}},
+	}}, server)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestStreamTail_PushesMatchingElements(t *testing.T) {
+	id := identity{group: "g", name: "s"}
+	svc := newTestStreamService(seededLogsStreamRepo(id), time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	server := newMockTailServer[streamv1.TailResponse](ctx)
+	tailErr := make(chan error, 1)
+	go func() {
+		tailErr <- svc.Tail(&streamv1.TailRequest{Groups: []string{"g"}, Name: "s", Criteria: serviceNameCriteria("a")}, server)
+	}()
+	require.Eventually(t, svc.tails.active, time.Second, time.Millisecond)
+
+	metadata := &commonv1.Metadata{Group: "g", Name: "s"}
+	for _, serviceName := range []string{"b", "a"} {
+		svc.publishTail(metadata, &streamv1.ElementValue{
+			ElementId:   serviceName,
+			Timestamp:   timestamppb.Now(),
+			TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{strTagValue(serviceName)}}},
+		}, nil)
+	}
+
+	resp := <-server.sent
+	assert.Equal(t, "g", resp.GetGroup())
+	assert.Equal(t, "a", resp.GetElement().GetElementId())
+	require.Len(t, resp.GetElement().GetTagFamilies(), 1)
+	assert.Equal(t, "default", resp.GetElement().GetTagFamilies()[0].GetName())
+	assert.Equal(t, []*modelv1.Tag{{Key: "service_name", Value: strTagValue("a")}}, resp.GetElement().GetTagFamilies()[0].GetTags())
+	cancel()
+	assert.ErrorIs(t, <-tailErr, context.Canceled)
+	assert.Empty(t, server.sent)
+	assert.False(t, svc.tails.active())
+}
+
+func TestTraceTail_PushesProjectedSpans(t *testing.T) {
+	id := identity{group: "g", name: "t"}
+	er := newEmptyEntityRepo()
+	er.traceMap[id] = &databasev1.Trace{
+		Metadata: &commonv1.Metadata{Group: id.group, Name: id.name},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+		},
+		TraceIdTagName: "trace_id",
+		SpanIdTagName:  "span_id",
+	}
+	svc := newTestTraceService(er, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := newMockTailServer[tracev1.TailResponse](ctx)
+	go func() {
+		_ = svc.Tail(&tracev1.TailRequest{
+			Groups: []string{"g"}, Name: "t", Criteria: serviceNameCriteria("a"), TagProjection: []string{"service_name"},
+		}, server)
+	}()
+	require.Eventually(t, svc.tails.active, time.Second, time.Millisecond)
+
+	metadata := &commonv1.Metadata{Group: "g", Name: "t"}
+	spec := &tracev1.TagSpec{TagNames: []string{"service_name", "span_id", "trace_id"}}
+	for _, serviceName := range []string{"b", "a"} {
+		svc.publishTail(metadata, &tracev1.WriteRequest{
+			Tags: []*modelv1.TagValue{strTagValue(serviceName), strTagValue("span-" + serviceName), strTagValue("trace-" + serviceName)},
+			Span: []byte(serviceName),
+		}, spec)
+	}
+
+	resp := <-server.sent
+	assert.Equal(t, "trace-a", resp.GetTraceId())
+	assert.Equal(t, "span-a", resp.GetSpan().GetSpanId())
+	assert.Equal(t, []byte("a"), resp.GetSpan().GetSpan())
+	assert.Equal(t, []*modelv1.Tag{{Key: "service_name", Value: strTagValue("a")}}, resp.GetSpan().GetTags())
+}
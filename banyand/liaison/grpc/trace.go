@@ -74,6 +74,8 @@ type traceService struct {
 	*discoveryService
 	l               *logger.Logger
 	metrics         *metrics
+	cdc             *cdcService
+	tails           tailHub[*traceTailItem]
 	writeTimeout    time.Duration
 	maxWaitDuration time.Duration
 }
@@ -422,6 +424,9 @@ func (s *traceService) Write(stream tracev1.TraceService_WriteServer) error {
 			continue
 		}
 		s.groupRepo.releaseRequest(metadata.Group)
+		if s.tails.active() {
+			s.publishTail(metadata, writeEntity, spec)
+		}
 
 		succeedSent = append(succeedSent, succeedSentMessage{
 			metadata:  metadata,
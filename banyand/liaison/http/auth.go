@@ -126,3 +126,17 @@ func buildGRPCContextForHealthCheck(authReloader *auth.Reloader, r *http.Request
 
 	return ctx, nil
 }
+
+// buildGRPCContext forwards the credentials verified by the auth middleware to the gRPC server.
+func buildGRPCContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	username := r.Header.Get("Grpc-Metadata-Username")
+	password := r.Header.Get("Grpc-Metadata-Password")
+	if username == "" || password == "" {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, metadata.New(map[string]string{
+		"username": username,
+		"password": password,
+	}))
+}
@@ -0,0 +1,227 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/jaeger"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	jaegerTracesPath     = "/api/traces"
+	jaegerTracePath      = "/api/traces/{traceID}"
+	jaegerServicesPath   = "/api/services"
+	jaegerOperationsPath = "/api/services/{service}/operations"
+)
+
+type jaegerResponse struct {
+	Data   any           `json:"data"`
+	Errors []jaegerError `json:"errors"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+type jaegerError struct {
+	Msg  string `json:"msg"`
+	Code int    `json:"code,omitempty"`
+}
+
+// jaegerQueryAPI serves the HTTP API of the Jaeger query service over a trace, so that the Jaeger UI, Grafana and
+// the other Jaeger clients can browse the spans.
+type jaegerQueryAPI struct {
+	querier *jaeger.Querier
+	l       *logger.Logger
+}
+
+func (api *jaegerQueryAPI) register(mux interface {
+	Handle(pattern string, handler http.Handler)
+},
+) {
+	mux.Handle(jaegerTracesPath, api.handler(api.findTraces))
+	mux.Handle(jaegerTracePath, api.handler(api.getTrace))
+	mux.Handle(jaegerServicesPath, api.handler(api.services))
+	mux.Handle(jaegerOperationsPath, api.handler(api.operations))
+}
+
+// handler wraps fn, which returns the data and the number of its elements.
+func (api *jaegerQueryAPI) handler(fn func(ctx context.Context, r *http.Request) (any, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, total, err := fn(buildGRPCContext(r), r)
+		if err != nil {
+			code := jaegerQueryStatus(err)
+			if code >= http.StatusInternalServerError {
+				api.l.Error().Err(err).Str("path", r.URL.Path).Msg("failed to query jaeger traces")
+			}
+			writeJaegerResponse(w, code, jaegerResponse{Errors: []jaegerError{{Code: code, Msg: err.Error()}}})
+			return
+		}
+		writeJaegerResponse(w, http.StatusOK, jaegerResponse{Data: data, Total: total})
+	}
+}
+
+func writeJaegerResponse(w http.ResponseWriter, code int, resp jaegerResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Errorf("Failed to write the jaeger response: %v", err)
+	}
+}
+
+// jaegerQueryStatus maps a query error to the status code.
+func jaegerQueryStatus(err error) int {
+	switch {
+	case errors.Is(err, jaeger.ErrBadQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, jaeger.ErrTraceNotFound):
+		return http.StatusNotFound
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (api *jaegerQueryAPI) getTrace(ctx context.Context, r *http.Request) (any, int, error) {
+	start, err := parseJaegerTime(r, "start")
+	if err != nil {
+		return nil, 0, err
+	}
+	end, err := parseJaegerTime(r, "end")
+	if err != nil {
+		return nil, 0, err
+	}
+	trace, err := api.querier.GetTrace(ctx, chi.URLParam(r, "traceID"), start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []*jaeger.Trace{trace}, 1, nil
+}
+
+func (api *jaegerQueryAPI) findTraces(ctx context.Context, r *http.Request) (any, int, error) {
+	query := r.URL.Query()
+	tq := &jaeger.TraceQuery{
+		Service:   query.Get("service"),
+		Operation: query.Get("operation"),
+		Tags:      make(map[string]string),
+	}
+	var err error
+	if tq.Start, err = parseJaegerTime(r, "start"); err != nil {
+		return nil, 0, err
+	}
+	if tq.End, err = parseJaegerTime(r, "end"); err != nil {
+		return nil, 0, err
+	}
+	if tq.MinDuration, err = parseJaegerDuration(r, "minDuration"); err != nil {
+		return nil, 0, err
+	}
+	if tq.MaxDuration, err = parseJaegerDuration(r, "maxDuration"); err != nil {
+		return nil, 0, err
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, parseErr := strconv.ParseUint(s, 10, 32)
+		if parseErr != nil {
+			return nil, 0, errors.Wrapf(jaeger.ErrBadQuery, "invalid limit %q", s)
+		}
+		tq.Limit = uint32(limit)
+	}
+	if s := query.Get("tags"); s != "" {
+		if err = json.Unmarshal([]byte(s), &tq.Tags); err != nil {
+			return nil, 0, errors.Wrapf(jaeger.ErrBadQuery, "invalid tags %q: %v", s, err)
+		}
+	}
+	for _, tag := range query["tag"] {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok {
+			return nil, 0, errors.Wrapf(jaeger.ErrBadQuery, "invalid tag %q, it should be in the form of key:value", tag)
+		}
+		tq.Tags[k] = v
+	}
+	traces, err := api.querier.FindTraces(ctx, tq)
+	if err != nil {
+		return nil, 0, err
+	}
+	return traces, len(traces), nil
+}
+
+func (api *jaegerQueryAPI) services(ctx context.Context, _ *http.Request) (any, int, error) {
+	services, err := api.querier.Services(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return services, len(services), nil
+}
+
+func (api *jaegerQueryAPI) operations(ctx context.Context, r *http.Request) (any, int, error) {
+	operations, err := api.querier.Operations(ctx, chi.URLParam(r, "service"))
+	if err != nil {
+		return nil, 0, err
+	}
+	return operations, len(operations), nil
+}
+
+// parseJaegerTime parses a time in microseconds since the epoch. An absent time is zero.
+func parseJaegerTime(r *http.Request, name string) (time.Time, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	us, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(jaeger.ErrBadQuery, "invalid %s %q, it should be in microseconds", name, s)
+	}
+	return time.UnixMicro(us), nil
+}
+
+func parseJaegerDuration(r *http.Request, name string) (time.Duration, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Wrapf(jaeger.ErrBadQuery, "invalid %s %q", name, s)
+	}
+	return d, nil
+}
@@ -0,0 +1,227 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/pkg/errors"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	otlpTracesPath    = "/v1/traces"
+	otlpLogsPath      = "/v1/logs"
+	otlpMaxBodySize   = 16 << 20
+	otlpMaxDecodeSize = 64 << 20
+
+	otlpContentTypeProtobuf = "application/x-protobuf"
+	otlpContentTypeJSON     = "application/json"
+)
+
+// otlpIDFields are the fields encoded in hexadecimal by the OTLP JSON encoding instead of base64.
+var otlpIDFields = map[string]struct{}{"traceId": {}, "spanId": {}, "parentSpanId": {}}
+
+// otlpTracesHandler serves the OTLP/HTTP trace export by forwarding it to the OTLP trace receiver of the liaison.
+func otlpTracesHandler(client collectortracev1.TraceServiceClient, l *logger.Logger) http.HandlerFunc {
+	return otlpExportHandler(func() proto.Message { return &collectortracev1.ExportTraceServiceRequest{} },
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return client.Export(ctx, req.(*collectortracev1.ExportTraceServiceRequest))
+		}, "spans", l)
+}
+
+// otlpLogsHandler serves the OTLP/HTTP logs export by forwarding it to the OTLP logs receiver of the liaison.
+func otlpLogsHandler(client collectorlogsv1.LogsServiceClient, l *logger.Logger) http.HandlerFunc {
+	return otlpExportHandler(func() proto.Message { return &collectorlogsv1.ExportLogsServiceRequest{} },
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return client.Export(ctx, req.(*collectorlogsv1.ExportLogsServiceRequest))
+		}, "log records", l)
+}
+
+func otlpExportHandler(newRequest func() proto.Message, export func(context.Context, proto.Message) (proto.Message, error),
+	signal string, l *logger.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || (contentType != otlpContentTypeProtobuf && contentType != otlpContentTypeJSON) {
+			http.Error(w, "unsupported content type: "+r.Header.Get("Content-Type"), http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := readOTLPBody(w, r)
+		if err != nil {
+			writeOTLPError(w, contentType, status.New(codes.InvalidArgument, err.Error()))
+			return
+		}
+		req := newRequest()
+		if contentType == otlpContentTypeJSON {
+			err = unmarshalOTLPJSON(body, req)
+		} else {
+			err = proto.Unmarshal(body, req)
+		}
+		if err != nil {
+			writeOTLPError(w, contentType, status.New(codes.InvalidArgument, err.Error()))
+			return
+		}
+		resp, err := export(buildGRPCContext(r), req)
+		if err != nil {
+			st := status.Convert(err)
+			if otlpHTTPStatus(st.Code()) >= http.StatusInternalServerError {
+				l.Error().Err(err).Msgf("failed to export OTLP %s", signal)
+			}
+			writeOTLPError(w, contentType, st)
+			return
+		}
+		writeOTLPResponse(w, contentType, http.StatusOK, resp)
+	}
+}
+
+func readOTLPBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	var reader io.Reader = http.MaxBytesReader(w, r.Body, otlpMaxBodySize)
+	switch enc := r.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, otlpMaxDecodeSize+1)
+	default:
+		return nil, errors.Errorf("unsupported content encoding: %s", enc)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > otlpMaxDecodeSize {
+		return nil, errors.Errorf("the decompressed body exceeds %d bytes", otlpMaxDecodeSize)
+	}
+	return body, nil
+}
+
+// unmarshalOTLPJSON decodes the OTLP JSON encoding, whose trace and span IDs are in hexadecimal.
+func unmarshalOTLPJSON(body []byte, msg proto.Message) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+	if err := hexToBase64IDs(v); err != nil {
+		return err
+	}
+	converted, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(converted, msg)
+}
+
+func hexToBase64IDs(v any) error {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, field := range val {
+			if s, ok := field.(string); ok {
+				if _, isID := otlpIDFields[k]; isID {
+					id, err := hex.DecodeString(s)
+					if err != nil {
+						return errors.Wrapf(err, "invalid %s %q", k, s)
+					}
+					val[k] = base64.StdEncoding.EncodeToString(id)
+				}
+				continue
+			}
+			if err := hexToBase64IDs(field); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, el := range val {
+			if err := hexToBase64IDs(el); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// otlpHTTPStatus maps a gRPC code to the status code of OTLP/HTTP. The clients retry on 429, 502, 503 and 504.
+func otlpHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unimplemented:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeOTLPError(w http.ResponseWriter, contentType string, st *status.Status) {
+	writeOTLPResponse(w, contentType, otlpHTTPStatus(st.Code()), st.Proto())
+}
+
+func writeOTLPResponse(w http.ResponseWriter, contentType string, code int, msg proto.Message) {
+	var body []byte
+	var err error
+	if contentType == otlpContentTypeJSON {
+		body, err = protojson.Marshal(msg)
+	} else {
+		body, err = proto.Marshal(msg)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	if _, err = w.Write(body); err != nil {
+		logger.Errorf("Failed to write the OTLP response: %v", err)
+	}
+}
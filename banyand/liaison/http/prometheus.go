@@ -0,0 +1,370 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/prometheus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	prometheusWritePath          = "/api/v1/prometheus/write"
+	prometheusWriteMaxBodySize   = 16 << 20
+	prometheusWriteMaxDecodeSize = 64 << 20
+
+	prometheusQueryPath      = "/api/v1/query"
+	prometheusQueryRangePath = "/api/v1/query_range"
+	prometheusSeriesPath     = "/api/v1/series"
+	prometheusLabelsPath     = "/api/v1/labels"
+	// prometheusDefaultRange is the range of the series and labels lookups without the start.
+	prometheusDefaultRange = time.Hour
+)
+
+func prometheusWriteHandler(writer *prometheus.Writer, l *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "snappy" {
+			http.Error(w, "unsupported content encoding: "+enc, http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, prometheusWriteMaxBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		series, err := prometheus.DecodeWriteRequest(body, prometheusWriteMaxDecodeSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = writer.Write(buildGRPCContext(r), series); err != nil {
+			code := prometheusWriteStatus(err)
+			if code >= http.StatusInternalServerError {
+				l.Error().Err(err).Msg("failed to write prometheus samples")
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// prometheusWriteStatus maps a write error to the status code. Prometheus retries on 5xx and 429, and drops the samples on the other 4xx.
+func prometheusWriteStatus(err error) int {
+	if errors.Is(err, prometheus.ErrInvalidRequest) {
+		return http.StatusBadRequest
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type prometheusErrorType string
+
+const (
+	prometheusErrorBadData   prometheusErrorType = "bad_data"
+	prometheusErrorExecution prometheusErrorType = "execution"
+	prometheusErrorTimeout   prometheusErrorType = "timeout"
+	prometheusErrorInternal  prometheusErrorType = "internal"
+)
+
+type prometheusResponse struct {
+	Data      any                 `json:"data,omitempty"`
+	Status    string              `json:"status"`
+	ErrorType prometheusErrorType `json:"errorType,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+type prometheusQueryData struct {
+	Result     any                  `json:"result"`
+	ResultType prometheus.ValueType `json:"resultType"`
+}
+
+type prometheusSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]any          `json:"values"`
+}
+
+type prometheusSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]any            `json:"value"`
+}
+
+// prometheusQueryAPI serves the Prometheus HTTP query API over the measures, so that Grafana and the other
+// Prometheus clients can query them with PromQL.
+type prometheusQueryAPI struct {
+	engine  *prometheus.Engine
+	querier *prometheus.MeasureQuerier
+	l       *logger.Logger
+}
+
+func (api *prometheusQueryAPI) register(mux interface {
+	Handle(pattern string, handler http.Handler)
+},
+) {
+	mux.Handle(prometheusQueryPath, api.handler(api.query))
+	mux.Handle(prometheusQueryRangePath, api.handler(api.queryRange))
+	mux.Handle(prometheusSeriesPath, api.handler(api.series))
+	mux.Handle(prometheusLabelsPath, api.handler(api.labels))
+}
+
+func (api *prometheusQueryAPI) handler(fn func(ctx context.Context, r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writePrometheusResponse(w, http.StatusBadRequest, prometheusResponse{Status: "error", ErrorType: prometheusErrorBadData, Error: err.Error()})
+			return
+		}
+		data, err := fn(buildGRPCContext(r), r)
+		if err != nil {
+			code, errType := prometheusQueryStatus(err)
+			if code >= http.StatusInternalServerError {
+				api.l.Error().Err(err).Str("path", r.URL.Path).Msg("failed to query prometheus metrics")
+			}
+			writePrometheusResponse(w, code, prometheusResponse{Status: "error", ErrorType: errType, Error: err.Error()})
+			return
+		}
+		writePrometheusResponse(w, http.StatusOK, prometheusResponse{Status: "success", Data: data})
+	}
+}
+
+func writePrometheusResponse(w http.ResponseWriter, code int, resp prometheusResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Errorf("Failed to write the prometheus response: %v", err)
+	}
+}
+
+// prometheusQueryStatus maps a query error to the status code and the error type of the Prometheus HTTP API.
+func prometheusQueryStatus(err error) (int, prometheusErrorType) {
+	switch {
+	case errors.Is(err, prometheus.ErrBadQuery):
+		return http.StatusBadRequest, prometheusErrorBadData
+	case errors.Is(err, prometheus.ErrTooManySamples):
+		return http.StatusUnprocessableEntity, prometheusErrorExecution
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest, prometheusErrorBadData
+	case codes.ResourceExhausted:
+		return http.StatusUnprocessableEntity, prometheusErrorExecution
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusServiceUnavailable, prometheusErrorTimeout
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, prometheusErrorExecution
+	case codes.PermissionDenied:
+		return http.StatusForbidden, prometheusErrorExecution
+	default:
+		return http.StatusInternalServerError, prometheusErrorInternal
+	}
+}
+
+func (api *prometheusQueryAPI) query(ctx context.Context, r *http.Request) (any, error) {
+	expr, err := prometheus.ParseExpr(r.FormValue("query"))
+	if err != nil {
+		return nil, err
+	}
+	ts, err := parsePrometheusTime(r.FormValue("time"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.engine.InstantQuery(ctx, expr, ts)
+	if err != nil {
+		return nil, err
+	}
+	return encodePrometheusResult(result), nil
+}
+
+func (api *prometheusQueryAPI) queryRange(ctx context.Context, r *http.Request) (any, error) {
+	expr, err := prometheus.ParseExpr(r.FormValue("query"))
+	if err != nil {
+		return nil, err
+	}
+	start, err := parsePrometheusTime(r.FormValue("start"), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	end, err := parsePrometheusTime(r.FormValue("end"), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() || end.IsZero() {
+		return nil, errors.Wrap(prometheus.ErrBadQuery, "the start and the end are required")
+	}
+	step, err := prometheus.ParseDuration(r.FormValue("step"))
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.engine.RangeQuery(ctx, expr, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	return encodePrometheusResult(result), nil
+}
+
+func (api *prometheusQueryAPI) series(ctx context.Context, r *http.Request) (any, error) {
+	if len(r.Form["match[]"]) == 0 {
+		return nil, errors.Wrap(prometheus.ErrBadQuery, "no match[] parameter")
+	}
+	seriesSet, err := api.selectSeries(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]string, 0, len(seriesSet))
+	for _, s := range seriesSet {
+		result = append(result, s.Labels.Map())
+	}
+	return result, nil
+}
+
+func (api *prometheusQueryAPI) labels(ctx context.Context, r *http.Request) (any, error) {
+	if len(r.Form["match[]"]) == 0 {
+		return api.querier.LabelNames(ctx)
+	}
+	seriesSet, err := api.selectSeries(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{})
+	for _, s := range seriesSet {
+		for _, l := range s.Labels {
+			names[l.Name] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for n := range names {
+		result = append(result, n)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// selectSeries returns the distinct series of the match[] selectors between the start and the end.
+func (api *prometheusQueryAPI) selectSeries(ctx context.Context, r *http.Request) ([]prometheus.Series, error) {
+	end, err := parsePrometheusTime(r.FormValue("end"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	start, err := parsePrometheusTime(r.FormValue("start"), end.Add(-prometheusDefaultRange))
+	if err != nil {
+		return nil, err
+	}
+	var result []prometheus.Series
+	seen := make(map[string]struct{})
+	for _, m := range r.Form["match[]"] {
+		expr, parseErr := prometheus.ParseExpr(m)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		selector, ok := expr.(*prometheus.VectorSelector)
+		if !ok {
+			return nil, errors.Wrapf(prometheus.ErrBadQuery, "match[] %q isn't a series selector", m)
+		}
+		seriesSet, selectErr := api.engine.Series(ctx, selector, start, end)
+		if selectErr != nil {
+			return nil, selectErr
+		}
+		for _, s := range seriesSet {
+			key := prometheus.SeriesID(s.Labels) + s.Labels.Get(prometheus.MetricNameLabel)
+			if _, ok = seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func encodePrometheusResult(result *prometheus.Result) prometheusQueryData {
+	data := prometheusQueryData{ResultType: result.Type}
+	switch result.Type {
+	case prometheus.ValueTypeScalar:
+		data.Result = encodePrometheusSample(result.Scalar)
+	case prometheus.ValueTypeVector:
+		vector := make([]prometheusSample, 0, len(result.Vector))
+		for _, el := range result.Vector {
+			vector = append(vector, prometheusSample{Metric: el.Labels.Map(), Value: encodePrometheusSample(el.Sample)})
+		}
+		data.Result = vector
+	default:
+		matrix := make([]prometheusSeries, 0, len(result.Matrix))
+		for _, s := range result.Matrix {
+			values := make([][2]any, 0, len(s.Samples))
+			for _, sample := range s.Samples {
+				values = append(values, encodePrometheusSample(sample))
+			}
+			matrix = append(matrix, prometheusSeries{Metric: s.Labels.Map(), Values: values})
+		}
+		data.Result = matrix
+	}
+	return data
+}
+
+// encodePrometheusSample encodes a sample as [<unix seconds>, "<value>"], the same as Prometheus.
+func encodePrometheusSample(s prometheus.Sample) [2]any {
+	return [2]any{float64(s.Timestamp) / 1000, strconv.FormatFloat(s.Value, 'f', -1, 64)}
+}
+
+// parsePrometheusTime parses a RFC3339 time or a unix timestamp in seconds. It returns the defaultValue if s is empty.
+func parsePrometheusTime(s string, defaultValue time.Time) (time.Time, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return time.Time{}, errors.Wrapf(prometheus.ErrBadQuery, "invalid time %q", s)
+		}
+		whole, frac := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(math.Round(frac*1000))*int64(time.Millisecond)), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(prometheus.ErrBadQuery, "invalid time %q", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,368 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/ql"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	qlQueryPath     = "/api/v1/ql"
+	qlGroupsPath    = "/api/v1/ql/groups"
+	qlResourcesPath = "/api/v1/ql/resources"
+	qlTagsPath      = "/api/v1/ql/tags"
+	qlTagValuesPath = "/api/v1/ql/tag-values"
+
+	qlMaxBodySize = 1 << 20
+	// qlDefaultRange is the time range of the queries and the tag values lookups without from.
+	qlDefaultRange = time.Hour
+	// qlDefaultMaxDataPoints divides the time range into the interval of the queries without the interval.
+	qlDefaultMaxDataPoints = 1000
+	// qlVarPrefix prefixes the names of the query parameters holding the values of the variables, as Grafana does in its URLs.
+	qlVarPrefix = "var-"
+)
+
+type qlResponse struct {
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// qlQueryBody is the JSON body of a query. The from and the to are either the milliseconds since the epoch, or the strings
+// accepted by parseQLTime. A variable has a single value or an array of values.
+type qlQueryBody struct {
+	Variables     map[string]json.RawMessage `json:"variables"`
+	Query         string                     `json:"query"`
+	Interval      string                     `json:"interval"`
+	RefID         string                     `json:"refId"`
+	Format        string                     `json:"format"`
+	From          json.RawMessage            `json:"from"`
+	To            json.RawMessage            `json:"to"`
+	IntervalMs    int64                      `json:"intervalMs"`
+	MaxDataPoints int64                      `json:"maxDataPoints"`
+}
+
+// qlQueryAPI serves the BydbQL queries of the dashboards, such as Grafana, with the macros and the variables,
+// and returns the results as data frames. It also serves the metadata filling the template variables.
+type qlQueryAPI struct {
+	querier *ql.Querier
+	l       *logger.Logger
+}
+
+func (api *qlQueryAPI) register(mux interface {
+	Handle(pattern string, handler http.Handler)
+},
+) {
+	mux.Handle(qlQueryPath, api.handler(api.query, http.MethodGet, http.MethodPost))
+	mux.Handle(qlGroupsPath, api.handler(api.groups, http.MethodGet))
+	mux.Handle(qlResourcesPath, api.handler(api.resources, http.MethodGet))
+	mux.Handle(qlTagsPath, api.handler(api.tags, http.MethodGet))
+	mux.Handle(qlTagValuesPath, api.handler(api.tagValues, http.MethodGet))
+}
+
+func (api *qlQueryAPI) handler(fn func(ctx context.Context, r *http.Request) (any, error), methods ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := false
+		for _, m := range methods {
+			allowed = allowed || r.Method == m
+		}
+		if !allowed {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := fn(buildGRPCContext(r), r)
+		if err != nil {
+			code := qlQueryStatus(err)
+			if code >= http.StatusInternalServerError {
+				api.l.Error().Err(err).Str("path", r.URL.Path).Msg("failed to serve the bydbql query")
+			}
+			writeQLResponse(w, code, qlResponse{Error: err.Error()})
+			return
+		}
+		if result, ok := data.(*ql.Result); ok {
+			writeQLResponse(w, http.StatusOK, result)
+			return
+		}
+		writeQLResponse(w, http.StatusOK, qlResponse{Data: data})
+	}
+}
+
+func writeQLResponse(w http.ResponseWriter, code int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Errorf("Failed to write the bydbql response: %v", err)
+	}
+}
+
+// qlQueryStatus maps a query error to the status code.
+func qlQueryStatus(err error) int {
+	if errors.Is(err, ql.ErrBadQuery) {
+		return http.StatusBadRequest
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// query reads the query from the JSON body of a POST request, or from the parameters of the URL or the form.
+func (api *qlQueryAPI) query(ctx context.Context, r *http.Request) (any, error) {
+	var (
+		req ql.Request
+		err error
+	)
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); r.Method == http.MethodPost && ct == "application/json" {
+		req, err = qlRequestFromBody(r)
+	} else {
+		req, err = qlRequestFromForm(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, errors.Wrap(ql.ErrBadQuery, "query is required")
+	}
+	return api.querier.Query(ctx, req)
+}
+
+func qlRequestFromBody(r *http.Request) (ql.Request, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, qlMaxBodySize))
+	if err != nil {
+		return ql.Request{}, errors.Wrap(ql.ErrBadQuery, err.Error())
+	}
+	var body qlQueryBody
+	if err = json.Unmarshal(data, &body); err != nil {
+		return ql.Request{}, errors.Wrapf(ql.ErrBadQuery, "invalid body: %v", err)
+	}
+	from, err := rawQLTime(body.From)
+	if err != nil {
+		return ql.Request{}, err
+	}
+	to, err := rawQLTime(body.To)
+	if err != nil {
+		return ql.Request{}, err
+	}
+	interval := body.Interval
+	if interval == "" && body.IntervalMs > 0 {
+		interval = strconv.FormatInt(body.IntervalMs, 10) + "ms"
+	}
+	tr, err := parseQLTimeRange(from, to, interval, body.MaxDataPoints)
+	if err != nil {
+		return ql.Request{}, err
+	}
+	vars := make(map[string][]string, len(body.Variables))
+	for name, raw := range body.Variables {
+		if vars[name], err = rawQLVariable(raw); err != nil {
+			return ql.Request{}, errors.Wrapf(ql.ErrBadQuery, "invalid variable %s: %v", name, err)
+		}
+	}
+	return ql.Request{TimeRange: tr, Vars: vars, Query: body.Query, RefID: body.RefID, Format: ql.Format(body.Format)}, nil
+}
+
+func qlRequestFromForm(r *http.Request) (ql.Request, error) {
+	if err := r.ParseForm(); err != nil {
+		return ql.Request{}, errors.Wrap(ql.ErrBadQuery, err.Error())
+	}
+	var maxDataPoints int64
+	if s := r.Form.Get("maxDataPoints"); s != "" {
+		var err error
+		if maxDataPoints, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return ql.Request{}, errors.Wrapf(ql.ErrBadQuery, "invalid maxDataPoints %q", s)
+		}
+	}
+	tr, err := parseQLTimeRange(r.Form.Get("from"), r.Form.Get("to"), r.Form.Get("interval"), maxDataPoints)
+	if err != nil {
+		return ql.Request{}, err
+	}
+	return ql.Request{
+		TimeRange: tr,
+		Vars:      qlVariables(r),
+		Query:     r.Form.Get("query"),
+		RefID:     r.Form.Get("refId"),
+		Format:    ql.Format(r.Form.Get("format")),
+	}, nil
+}
+
+// qlVariables returns the variables of the parameters named var-<name>. A repeated parameter has multiple values.
+func qlVariables(r *http.Request) map[string][]string {
+	vars := make(map[string][]string)
+	for k, values := range r.Form {
+		if name, ok := strings.CutPrefix(k, qlVarPrefix); ok && name != "" {
+			vars[name] = values
+		}
+	}
+	return vars
+}
+
+func rawQLTime(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var ms json.Number
+	if err := json.Unmarshal(raw, &ms); err != nil {
+		return "", errors.Wrapf(ql.ErrBadQuery, "invalid time %s", raw)
+	}
+	return ms.String(), nil
+}
+
+func rawQLVariable(raw json.RawMessage) ([]string, error) {
+	var values []any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var v any
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		values = []any{v}
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		switch x := v.(type) {
+		case string:
+			result = append(result, x)
+		case float64, bool:
+			result = append(result, fmt.Sprint(x))
+		default:
+			return nil, errors.Errorf("unsupported value %v", v)
+		}
+	}
+	return result, nil
+}
+
+// parseQLTimeRange parses the time range, which defaults to the last hour. The interval defaults to the range divided by the
+// maximum data points.
+func parseQLTimeRange(from, to, interval string, maxDataPoints int64) (ql.TimeRange, error) {
+	now := time.Now()
+	var (
+		tr  ql.TimeRange
+		err error
+	)
+	if tr.To, err = parseQLTime(to, now, now); err != nil {
+		return tr, err
+	}
+	if tr.From, err = parseQLTime(from, now, tr.To.Add(-qlDefaultRange)); err != nil {
+		return tr, err
+	}
+	if !tr.From.Before(tr.To) {
+		return tr, errors.Wrap(ql.ErrBadQuery, "from should be before to")
+	}
+	if interval != "" {
+		if tr.Interval, err = ql.ParseInterval(interval); err != nil {
+			return tr, err
+		}
+		return tr, nil
+	}
+	if maxDataPoints <= 0 {
+		maxDataPoints = qlDefaultMaxDataPoints
+	}
+	tr.Interval = max(tr.To.Sub(tr.From)/time.Duration(maxDataPoints), time.Millisecond).Truncate(time.Millisecond)
+	return tr, nil
+}
+
+// parseQLTime parses a time in milliseconds since the epoch, in RFC3339, or relative to now as Grafana does, such as
+// now and now-1h. An absent time is the default.
+func parseQLTime(s string, now, def time.Time) (time.Time, error) {
+	switch {
+	case s == "":
+		return def, nil
+	case s == "now":
+		return now, nil
+	case strings.HasPrefix(s, "now-"):
+		d, err := ql.ParseInterval(strings.TrimPrefix(s, "now-"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(ql.ErrBadQuery, "invalid time %q", s)
+	}
+	return t, nil
+}
+
+func (api *qlQueryAPI) groups(ctx context.Context, _ *http.Request) (any, error) {
+	return api.querier.Groups(ctx)
+}
+
+func (api *qlQueryAPI) resources(ctx context.Context, r *http.Request) (any, error) {
+	return api.querier.Resources(ctx, r.URL.Query().Get("group"))
+}
+
+func (api *qlQueryAPI) tags(ctx context.Context, r *http.Request) (any, error) {
+	query := r.URL.Query()
+	return api.querier.Tags(ctx, query.Get("group"), query.Get("kind"), query.Get("name"))
+}
+
+func (api *qlQueryAPI) tagValues(ctx context.Context, r *http.Request) (any, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.Wrap(ql.ErrBadQuery, err.Error())
+	}
+	tr, err := parseQLTimeRange(r.Form.Get("from"), r.Form.Get("to"), "", 0)
+	if err != nil {
+		return nil, err
+	}
+	req := ql.TagValuesRequest{
+		TimeRange: tr,
+		Vars:      qlVariables(r),
+		Group:     r.Form.Get("group"),
+		Kind:      r.Form.Get("kind"),
+		Name:      r.Form.Get("name"),
+		Tag:       r.Form.Get("tag"),
+		Filter:    r.Form.Get("filter"),
+	}
+	if s := r.Form.Get("limit"); s != "" {
+		limit, parseErr := strconv.ParseUint(s, 10, 32)
+		if parseErr != nil {
+			return nil, errors.Wrapf(ql.ErrBadQuery, "invalid limit %q", s)
+		}
+		req.Limit = uint32(limit)
+	}
+	return api.querier.TagValues(ctx, req)
+}
@@ -32,6 +32,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/pkg/errors"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"go.uber.org/multierr"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -46,6 +48,9 @@ import (
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/jaeger"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/prometheus"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/ql"
 	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -56,9 +61,13 @@ var (
 	_ run.Config  = (*server)(nil)
 	_ run.Service = (*server)(nil)
 
-	errServerCert = errors.New("http: invalid server cert file")
-	errServerKey  = errors.New("http: invalid server key file")
-	errNoAddr     = errors.New("http: no address")
+	errServerCert  = errors.New("http: invalid server cert file")
+	errServerKey   = errors.New("http: invalid server key file")
+	errNoAddr      = errors.New("http: no address")
+	errNoPromGroup = errors.New("http: no group for the Prometheus metrics")
+
+	errNoJaegerTrace      = errors.New("http: the group, the name and the order index rule of the Jaeger trace are required")
+	errJaegerDurationUnit = errors.New("http: the duration unit of the Jaeger trace should be positive")
 )
 
 // NewServer return a http service.
@@ -94,9 +103,27 @@ type server struct {
 	certFile        string
 	authReloader    *auth.Reloader
 	grpcCert        string
+	promGroup       string
+	promPrefix      string
+	promRules       []string
+	promNaming      prometheus.Naming
+	jaegerGroup     string
+	jaegerName      string
+	jaegerOrderRule string
+	jaegerMapping   jaeger.TagMapping
+	jaegerRules     []string
 	grpcMu          sync.Mutex
+	promLookback    time.Duration
+	jaegerDurUnit   time.Duration
+	jaegerLookback  time.Duration
 	port            uint32
+	promMaxSamples  uint32
 	tls             bool
+	promEnabled     bool
+	promAutoCreate  bool
+	promQuery       bool
+	otlpEnabled     bool
+	jaegerEnabled   bool
 }
 
 func (p *server) FlagSet() *run.FlagSet {
@@ -108,6 +135,30 @@ func (p *server) FlagSet() *run.FlagSet {
 	flagSet.StringVar(&p.keyFile, "http-key-file", "", "the TLS key file of http server")
 	flagSet.StringVar(&p.grpcCert, "http-grpc-cert-file", "", "the grpc TLS cert file if grpc server enables tls")
 	flagSet.BoolVar(&p.tls, "http-tls", false, "connection uses TLS if true, else plain HTTP")
+	flagSet.BoolVar(&p.promEnabled, "prometheus-remote-write-enabled", false, "enable the Prometheus remote-write receiver at "+prometheusWritePath)
+	flagSet.BoolVar(&p.promAutoCreate, "prometheus-remote-write-auto-create", false,
+		"create the absent measures and add the absent label tags of the Prometheus metrics")
+	flagSet.BoolVar(&p.promQuery, "prometheus-query-enabled", false,
+		"enable the PromQL-compatible query API at /api/v1/query, /api/v1/query_range, /api/v1/series and /api/v1/labels")
+	flagSet.DurationVar(&p.promLookback, "prometheus-query-lookback-delta", 5*time.Minute, "the maximum lookback of the PromQL instant vector selectors")
+	flagSet.Uint32Var(&p.promMaxSamples, "prometheus-query-max-samples", 1_000_000, "the maximum samples a PromQL selector selects")
+	flagSet.BoolVar(&p.otlpEnabled, "otlp-http-enabled", false,
+		"enable the OTLP/HTTP receivers at "+otlpTracesPath+" and "+otlpLogsPath+", which forward the spans and the log records to the OTLP receivers of the gRPC server")
+	flagSet.StringVar(&p.promGroup, "prometheus-group", "prometheus", "the group of the measures storing the Prometheus metrics")
+	flagSet.StringVar(&p.promPrefix, "prometheus-measure-prefix", "", "the prefix prepended to the measure names of the Prometheus metrics")
+	flagSet.StringSliceVar(&p.promRules, "prometheus-naming-rules", nil,
+		"the rules in the form of pattern=replacement renaming the Prometheus metrics to measures, the first rule matching a metric name applies")
+	flagSet.BoolVar(&p.jaegerEnabled, "jaeger-query-enabled", false,
+		"enable the Jaeger-compatible trace query API at /api/traces, /api/traces/{id}, /api/services and /api/services/{service}/operations")
+	flagSet.StringVar(&p.jaegerGroup, "jaeger-trace-group", "otlp", "the group of the trace served by the Jaeger query API")
+	flagSet.StringVar(&p.jaegerName, "jaeger-trace-name", "otlp_spans", "the trace served by the Jaeger query API")
+	flagSet.StringVar(&p.jaegerOrderRule, "jaeger-order-index-rule", "timestamp",
+		"the TREE index rule ordering the spans of the Jaeger trace by their timestamps, which the searches require")
+	flagSet.StringSliceVar(&p.jaegerRules, "jaeger-tag-mappings", nil,
+		"the rules in the form of field=tag naming the tags filling the service, operation, duration and parent_span_id of the Jaeger spans")
+	flagSet.DurationVar(&p.jaegerDurUnit, "jaeger-duration-unit", time.Millisecond, "the unit of the duration tag of the Jaeger trace")
+	flagSet.DurationVar(&p.jaegerLookback, "jaeger-query-lookback", time.Hour,
+		"the range of the Jaeger searches without start, and of the services and operations lookups")
 	return flagSet
 }
 
@@ -116,6 +167,29 @@ func (p *server) Validate() error {
 	if p.listenAddr == ":" {
 		return errNoAddr
 	}
+	if p.promEnabled || p.promQuery {
+		if p.promGroup == "" {
+			return errNoPromGroup
+		}
+		rules, err := prometheus.ParseNamingRules(p.promRules)
+		if err != nil {
+			return err
+		}
+		p.promNaming = prometheus.Naming{Prefix: p.promPrefix, Rules: rules}
+	}
+	if p.jaegerEnabled {
+		if p.jaegerGroup == "" || p.jaegerName == "" || p.jaegerOrderRule == "" {
+			return errNoJaegerTrace
+		}
+		if p.jaegerDurUnit <= 0 {
+			return errJaegerDurationUnit
+		}
+		mapping, err := jaeger.ParseTagMapping(p.jaegerRules)
+		if err != nil {
+			return err
+		}
+		p.jaegerMapping = mapping
+	}
 	if !p.tls {
 		return nil
 	}
@@ -352,9 +426,11 @@ func (p *server) initGRPCClient() error {
 		databasev1.RegisterStreamRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterMeasureRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterIndexRuleRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterAnalyzerRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterIndexRuleBindingRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterGroupRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterTopNAggregationRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterContinuousAggregationRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterSnapshotServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterPropertyRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterClusterStateServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
@@ -394,6 +470,65 @@ func (p *server) initGRPCClient() error {
 		}
 	}))
 
+	conn, connErr := grpc.NewClient(p.grpcAddr, opts...)
+	if connErr != nil {
+		return errors.Wrap(connErr, "failed to create the gRPC connection of the HTTP APIs")
+	}
+	go func() {
+		<-ctx.Done()
+		if cerr := conn.Close(); cerr != nil {
+			p.l.Info().Err(cerr).Msg("Failed to close the gRPC connection of the HTTP APIs")
+		}
+	}()
+	newMux.Handle(streamTailPath, streamTailHandler(streamv1.NewStreamServiceClient(conn), p.l))
+	newMux.Handle(traceTailPath, traceTailHandler(tracev1.NewTraceServiceClient(conn), p.l))
+	qlAPI := &qlQueryAPI{querier: ql.NewQuerier(ql.Clients{
+		QL:               bydbqlv1.NewBydbQLServiceClient(conn),
+		Groups:           databasev1.NewGroupRegistryServiceClient(conn),
+		Measures:         databasev1.NewMeasureRegistryServiceClient(conn),
+		TopNAggregations: databasev1.NewTopNAggregationRegistryServiceClient(conn),
+		Streams:          databasev1.NewStreamRegistryServiceClient(conn),
+		Traces:           databasev1.NewTraceRegistryServiceClient(conn),
+		Properties:       databasev1.NewPropertyRegistryServiceClient(conn),
+	}), l: p.l}
+	qlAPI.register(newMux)
+	if p.jaegerEnabled {
+		querier := jaeger.NewQuerier(databasev1.NewGroupRegistryServiceClient(conn), databasev1.NewTraceRegistryServiceClient(conn),
+			tracev1.NewTraceServiceClient(conn), jaeger.Config{
+				Group:          p.jaegerGroup,
+				Name:           p.jaegerName,
+				OrderIndexRule: p.jaegerOrderRule,
+				Mapping:        p.jaegerMapping,
+				DurationUnit:   p.jaegerDurUnit,
+				Lookback:       p.jaegerLookback,
+			})
+		api := &jaegerQueryAPI{querier: querier, l: p.l}
+		api.register(newMux)
+	}
+	if p.otlpEnabled {
+		newMux.Handle(otlpTracesPath, otlpTracesHandler(collectortracev1.NewTraceServiceClient(conn), p.l))
+		newMux.Handle(otlpLogsPath, otlpLogsHandler(collectorlogsv1.NewLogsServiceClient(conn), p.l))
+	}
+	registries := prometheus.Registries{
+		Measure:          databasev1.NewMeasureRegistryServiceClient(conn),
+		IndexRule:        databasev1.NewIndexRuleRegistryServiceClient(conn),
+		IndexRuleBinding: databasev1.NewIndexRuleBindingRegistryServiceClient(conn),
+	}
+	measureClient := measurev1.NewMeasureServiceClient(conn)
+	if p.promEnabled {
+		writer := prometheus.NewWriter(registries, measureClient, prometheus.Config{
+			Group:      p.promGroup,
+			Naming:     p.promNaming,
+			AutoCreate: p.promAutoCreate,
+		})
+		newMux.Handle(prometheusWritePath, prometheusWriteHandler(writer, p.l))
+	}
+	if p.promQuery {
+		querier := prometheus.NewMeasureQuerier(registries, measureClient, p.promGroup, p.promNaming, p.promMaxSamples)
+		api := &prometheusQueryAPI{engine: prometheus.NewEngine(querier, p.promLookback), querier: querier, l: p.l}
+		api.register(newMux)
+	}
+
 	// Mount the gateway mux to the HTTP server
 	newMux.Mount("/api", http.StripPrefix("/api", p.gwMux))
 
@@ -0,0 +1,201 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	streamTailPath  = "/api/v1/stream/tail"
+	traceTailPath   = "/api/v1/trace/tail"
+	tailMaxBodySize = 1 << 20
+	// tailKeepAliveInterval is how often an idle tail sends a comment, so that the proxies keep the connection.
+	tailKeepAliveInterval = 15 * time.Second
+)
+
+// tailStream is the client side of a Tail RPC.
+type tailStream interface {
+	Header() (metadata.MD, error)
+	RecvMsg(m any) error
+}
+
+// streamTailHandler serves the stream Tail RPC as server-sent events.
+func streamTailHandler(client streamv1.StreamServiceClient, l *logger.Logger) http.HandlerFunc {
+	return tailHandler(func() proto.Message { return &streamv1.TailRequest{} },
+		func() proto.Message { return &streamv1.TailResponse{} },
+		func(ctx context.Context, req proto.Message) (tailStream, error) {
+			return client.Tail(ctx, req.(*streamv1.TailRequest))
+		}, "stream", l)
+}
+
+// traceTailHandler serves the trace Tail RPC as server-sent events.
+func traceTailHandler(client tracev1.TraceServiceClient, l *logger.Logger) http.HandlerFunc {
+	return tailHandler(func() proto.Message { return &tracev1.TailRequest{} },
+		func() proto.Message { return &tracev1.TailResponse{} },
+		func(ctx context.Context, req proto.Message) (tailStream, error) {
+			return client.Tail(ctx, req.(*tracev1.TailRequest))
+		}, "trace", l)
+}
+
+// tailHandler takes the tail request from the JSON body of a POST, or the query parameters of a GET,
+// and sends each response of the tail as the JSON data of an event. The errors before the tail starts
+// are in the HTTP status, and the ones after are sent as the "error" events ending the tail.
+func tailHandler(newRequest, newResponse func() proto.Message, open func(context.Context, proto.Message) (tailStream, error),
+	catalog string, l *logger.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := newRequest()
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			err = parseTailQuery(r, req)
+		case http.MethodPost:
+			var body []byte
+			if body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, tailMaxBodySize)); err == nil {
+				err = protojson.Unmarshal(body, req)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, "invalid tail request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithCancel(buildGRPCContext(r))
+		defer cancel()
+		stream, err := open(ctx, req)
+		if err == nil {
+			// the server sends the header once the tail starts
+			var md metadata.MD
+			if md, err = stream.Header(); err == nil && md == nil {
+				err = stream.RecvMsg(newResponse())
+			}
+		}
+		if err != nil {
+			st := status.Convert(err)
+			httpStatus := runtime.HTTPStatusFromCode(st.Code())
+			if httpStatus >= http.StatusInternalServerError {
+				l.Error().Err(err).Msgf("failed to tail the %s", catalog)
+			}
+			http.Error(w, st.Message(), httpStatus)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// the tail lasts longer than the write timeout of the server
+		_ = rc.SetWriteDeadline(time.Time{})
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err = rc.Flush(); err != nil {
+			l.Error().Err(err).Msg("the response doesn't support the server-sent events")
+			return
+		}
+
+		received := make(chan proto.Message)
+		failed := make(chan error, 1)
+		go func() {
+			for {
+				resp := newResponse()
+				if recvErr := stream.RecvMsg(resp); recvErr != nil {
+					failed <- recvErr
+					return
+				}
+				select {
+				case received <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		ticker := time.NewTicker(tailKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			var event []byte
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				event = []byte(": keep-alive\n\n")
+			case resp := <-received:
+				data, marshalErr := protojson.Marshal(resp)
+				if marshalErr != nil {
+					l.Error().Err(marshalErr).Msgf("failed to marshal the %s tail response", catalog)
+					return
+				}
+				event = append(append([]byte("data: "), data...), '\n', '\n')
+			case recvErr := <-failed:
+				if errors.Is(recvErr, io.EOF) || status.Code(recvErr) == codes.Canceled {
+					return
+				}
+				st := status.Convert(recvErr)
+				data, _ := json.Marshal(map[string]any{"code": st.Code().String(), "message": st.Message()})
+				event = append(append([]byte("event: error\ndata: "), data...), '\n', '\n')
+				_, _ = w.Write(event)
+				_ = rc.Flush()
+				return
+			}
+			if _, err = w.Write(event); err != nil {
+				return
+			}
+			if err = rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseTailQuery converts the query parameters into the tail request. "groups" and "tag_projection" are repeatable,
+// "criteria" and "projection" are in JSON, and the others are the plain fields of the request.
+func parseTailQuery(r *http.Request, req proto.Message) error {
+	fields := make(map[string]any)
+	for k, values := range r.URL.Query() {
+		switch k {
+		case "groups", "tag_projection", "tagProjection":
+			fields[k] = values
+		case "criteria", "projection":
+			fields[k] = json.RawMessage(values[0])
+		default:
+			fields[k] = values[0]
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(data, req)
+}
@@ -0,0 +1,145 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package cdc encodes the changes of the change data capture log. The log is stored as the properties of an internal group
+// on the data nodes, so that all the liaisons of a cluster record the changes into, and read them from, the same log.
+package cdc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+)
+
+const (
+	// Group is the internal group storing the log.
+	Group = "_cdc"
+	// EventName is the name of the properties holding the changes, whose ids are the ids of the changes.
+	EventName = "_cdc"
+	// CompactedName is the name of the property holding the revision of the last change dropped by the retention.
+	CompactedName = "_cdc_compacted"
+	// CompactedID is the id of the property holding the compacted revision.
+	CompactedID = "compacted"
+	// RevisionTag is the tag holding the revision of a change, or the compacted revision.
+	RevisionTag = "revision"
+	// EventTag is the tag holding the encoded change.
+	EventTag = "event"
+)
+
+// NewGroup returns the internal group storing the log. The replicas keep the log when a data node is lost.
+func NewGroup(replicas uint32) *commonv1.Group {
+	return &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: Group},
+		Catalog:  commonv1.Catalog_CATALOG_PROPERTY,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum: 1,
+			Replicas: replicas,
+		},
+	}
+}
+
+// NewPropertySchemas returns the schemas of the properties holding the changes and the compacted revision.
+func NewPropertySchemas() []*databasev1.Property {
+	return []*databasev1.Property{
+		{
+			Metadata: &commonv1.Metadata{Group: Group, Name: EventName},
+			Tags: []*databasev1.TagSpec{
+				{Name: RevisionTag, Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: EventTag, Type: databasev1.TagType_TAG_TYPE_DATA_BINARY},
+			},
+		},
+		{
+			Metadata: &commonv1.Metadata{Group: Group, Name: CompactedName},
+			Tags: []*databasev1.TagSpec{
+				{Name: RevisionTag, Type: databasev1.TagType_TAG_TYPE_INT},
+			},
+		},
+	}
+}
+
+// ID returns the identity of the change, which is the same on all the liaisons recording it.
+func ID(event *cdcv1.ChangeEvent) string {
+	switch event.GetType() {
+	case cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_APPLY:
+		return fmt.Sprintf("%s/%d", schemaKey(event), modRevisionOf(event.GetSchema()))
+	case cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_DELETE:
+		// the deleted version of the schema tells the deletions of a schema created again apart
+		return fmt.Sprintf("%s/%d/delete", schemaKey(event), modRevisionOf(event.GetSchema()))
+	case cdcv1.ChangeType_CHANGE_TYPE_PROPERTY_APPLY:
+		p := event.GetProperty().GetProperty()
+		return fmt.Sprintf("property/%s/%s/%s/%d", event.GetGroup(), event.GetName(), p.GetId(), p.GetMetadata().GetModRevision())
+	case cdcv1.ChangeType_CHANGE_TYPE_PROPERTY_DELETE:
+		return fmt.Sprintf("property/%s/%s/%s/%d/delete", event.GetGroup(), event.GetName(),
+			event.GetProperty().GetProperty().GetId(), event.GetRevision())
+	}
+	kind := strings.ToLower(strings.TrimPrefix(event.GetType().String(), "CHANGE_TYPE_"))
+	return fmt.Sprintf("%s/%s/%s/%d", kind, event.GetGroup(), event.GetName(), event.GetRevision())
+}
+
+// ToProperty returns the property holding the change.
+func ToProperty(event *cdcv1.ChangeEvent) (*propertyv1.Property, error) {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the change")
+	}
+	return &propertyv1.Property{
+		Metadata: &commonv1.Metadata{Group: Group, Name: EventName},
+		Id:       event.GetId(),
+		Tags: []*modelv1.Tag{
+			{Key: RevisionTag, Value: IntValue(event.GetRevision())},
+			{Key: EventTag, Value: &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: data}}},
+		},
+	}, nil
+}
+
+// FromProperty returns the change held by the property.
+func FromProperty(p *propertyv1.Property) (*cdcv1.ChangeEvent, error) {
+	for _, t := range p.GetTags() {
+		if t.GetKey() != EventTag {
+			continue
+		}
+		event := &cdcv1.ChangeEvent{}
+		if err := proto.Unmarshal(t.GetValue().GetBinaryData(), event); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the change %s", p.GetId())
+		}
+		return event, nil
+	}
+	return nil, errors.Errorf("the change %s has no %s tag", p.GetId(), EventTag)
+}
+
+// Revision returns the revision held by the property.
+func Revision(p *propertyv1.Property) int64 {
+	for _, t := range p.GetTags() {
+		if t.GetKey() == RevisionTag {
+			return t.GetValue().GetInt().GetValue()
+		}
+	}
+	return 0
+}
+
+// IntValue returns the tag value of an integer.
+func IntValue(v int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+}
@@ -0,0 +1,97 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cdc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+)
+
+func streamEvent(t *testing.T, modRevision int64, deleted bool) *cdcv1.ChangeEvent {
+	event, ok := NewSchemaEvent(&databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: "default", Name: "sw", ModRevision: modRevision},
+	}, deleted)
+	require.True(t, ok)
+	return event
+}
+
+func TestNewSchemaEvent(t *testing.T) {
+	event := streamEvent(t, 10, false)
+	assert.Equal(t, cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_APPLY, event.GetType())
+	assert.Equal(t, "default", event.GetGroup())
+	assert.Equal(t, "sw", event.GetName())
+	assert.Equal(t, int64(10), event.GetRevision())
+	assert.Equal(t, cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_DELETE, streamEvent(t, 10, true).GetType())
+
+	event, _ = NewSchemaEvent(&commonv1.Group{Metadata: &commonv1.Metadata{Name: "default"}}, false)
+	assert.Equal(t, "default", event.GetGroup())
+	assert.Empty(t, event.GetName())
+	_, ok := NewSchemaEvent(&databasev1.Node{}, false)
+	assert.False(t, ok)
+}
+
+func TestID(t *testing.T) {
+	// the liaisons receiving the same schema change record it once
+	assert.Equal(t, ID(streamEvent(t, 10, false)), ID(streamEvent(t, 10, false)))
+	assert.NotEqual(t, ID(streamEvent(t, 10, false)), ID(streamEvent(t, 11, false)))
+	// the deletion revision is picked by each liaison, while the deleted version is the same
+	deleted := streamEvent(t, 10, true)
+	deleted.Revision = 20
+	again := streamEvent(t, 10, true)
+	again.Revision = 21
+	assert.Equal(t, ID(deleted), ID(again))
+	assert.NotEqual(t, ID(streamEvent(t, 10, false)), ID(deleted))
+
+	apply := &cdcv1.ChangeEvent{
+		Type:  cdcv1.ChangeType_CHANGE_TYPE_PROPERTY_APPLY,
+		Group: "g",
+		Name:  "p",
+		Change: &cdcv1.ChangeEvent_Property{Property: &cdcv1.PropertyChange{Property: &propertyv1.Property{
+			Metadata: &commonv1.Metadata{Group: "g", Name: "p", ModRevision: 5},
+			Id:       "1",
+		}}},
+	}
+	assert.Equal(t, "property/g/p/1/5", ID(apply))
+	assert.Equal(t, "data_delete/g/sw/7", ID(&cdcv1.ChangeEvent{Type: cdcv1.ChangeType_CHANGE_TYPE_DATA_DELETE, Group: "g", Name: "sw", Revision: 7}))
+}
+
+func TestProperty(t *testing.T) {
+	event := streamEvent(t, 10, false)
+	event.Id = ID(event)
+	p, err := ToProperty(event)
+	require.NoError(t, err)
+	assert.Equal(t, Group, p.GetMetadata().GetGroup())
+	assert.Equal(t, EventName, p.GetMetadata().GetName())
+	assert.Equal(t, event.GetId(), p.GetId())
+	assert.Equal(t, int64(10), Revision(p))
+	decoded, err := FromProperty(p)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(event, decoded))
+
+	_, err = FromProperty(&propertyv1.Property{Id: "1"})
+	assert.ErrorContains(t, err, "no event tag")
+}
+
@@ -0,0 +1,111 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cdc
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	cdcv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cdc/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// NewSchemaEvent returns the event of a schema change, whose revision is the mod revision of the schema.
+// It returns false if the schema isn't supported.
+func NewSchemaEvent(spec proto.Message, deleted bool) (*cdcv1.ChangeEvent, bool) {
+	change := &cdcv1.SchemaChange{}
+	switch s := spec.(type) {
+	case *commonv1.Group:
+		change.Resource = &cdcv1.SchemaChange_Group{Group: s}
+	case *databasev1.Stream:
+		change.Resource = &cdcv1.SchemaChange_Stream{Stream: s}
+	case *databasev1.Measure:
+		change.Resource = &cdcv1.SchemaChange_Measure{Measure: s}
+	case *databasev1.Trace:
+		change.Resource = &cdcv1.SchemaChange_Trace{Trace: s}
+	case *databasev1.IndexRule:
+		change.Resource = &cdcv1.SchemaChange_IndexRule{IndexRule: s}
+	case *databasev1.IndexRuleBinding:
+		change.Resource = &cdcv1.SchemaChange_IndexRuleBinding{IndexRuleBinding: s}
+	case *databasev1.TopNAggregation:
+		change.Resource = &cdcv1.SchemaChange_TopNAggregation{TopNAggregation: s}
+	case *databasev1.Property:
+		change.Resource = &cdcv1.SchemaChange_Property{Property: s}
+	case *databasev1.Analyzer:
+		change.Resource = &cdcv1.SchemaChange_Analyzer{Analyzer: s}
+	case *databasev1.ContinuousAggregation:
+		change.Resource = &cdcv1.SchemaChange_ContinuousAggregation{ContinuousAggregation: s}
+	default:
+		return nil, false
+	}
+	event := &cdcv1.ChangeEvent{
+		Type:   cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_APPLY,
+		Change: &cdcv1.ChangeEvent_Schema{Schema: change},
+	}
+	if deleted {
+		event.Type = cdcv1.ChangeType_CHANGE_TYPE_SCHEMA_DELETE
+	}
+	md := metadataOf(change)
+	if _, ok := change.Resource.(*cdcv1.SchemaChange_Group); ok {
+		event.Group = md.GetName()
+	} else {
+		event.Group, event.Name = md.GetGroup(), md.GetName()
+	}
+	event.Revision = md.GetModRevision()
+	return event, true
+}
+
+func metadataOf(change *cdcv1.SchemaChange) *commonv1.Metadata {
+	switch r := change.GetResource().(type) {
+	case *cdcv1.SchemaChange_Group:
+		return r.Group.GetMetadata()
+	case *cdcv1.SchemaChange_Stream:
+		return r.Stream.GetMetadata()
+	case *cdcv1.SchemaChange_Measure:
+		return r.Measure.GetMetadata()
+	case *cdcv1.SchemaChange_Trace:
+		return r.Trace.GetMetadata()
+	case *cdcv1.SchemaChange_IndexRule:
+		return r.IndexRule.GetMetadata()
+	case *cdcv1.SchemaChange_IndexRuleBinding:
+		return r.IndexRuleBinding.GetMetadata()
+	case *cdcv1.SchemaChange_TopNAggregation:
+		return r.TopNAggregation.GetMetadata()
+	case *cdcv1.SchemaChange_Property:
+		return r.Property.GetMetadata()
+	case *cdcv1.SchemaChange_Analyzer:
+		return r.Analyzer.GetMetadata()
+	case *cdcv1.SchemaChange_ContinuousAggregation:
+		return r.ContinuousAggregation.GetMetadata()
+	}
+	return nil
+}
+
+func modRevisionOf(change *cdcv1.SchemaChange) int64 {
+	return metadataOf(change).GetModRevision()
+}
+
+// schemaKey identifies the schema of a change by its kind, group and name.
+func schemaKey(event *cdcv1.ChangeEvent) string {
+	m := event.GetSchema().ProtoReflect()
+	kind := ""
+	if fd := m.WhichOneof(m.Descriptor().Oneofs().ByName("resource")); fd != nil {
+		kind = string(fd.Name())
+	}
+	return kind + "/" + event.GetGroup() + "/" + event.GetName()
+}
@@ -0,0 +1,297 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package jaeger
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/otlp"
+)
+
+const (
+	serviceNameAttribute = "service.name"
+	unknownService       = "unknown_service"
+)
+
+// convert converts a trace of the query response. The spans sharing the same process refer to the same process ID.
+func (q *Querier) convert(s *traceSchema, t *tracev1.Trace) *Trace {
+	result := &Trace{
+		TraceID:   t.GetTraceId(),
+		Spans:     make([]Span, 0, len(t.GetSpans())),
+		Processes: make(map[string]Process),
+	}
+	processIDs := make(map[string]string)
+	for _, span := range t.GetSpans() {
+		js, process := q.convertSpan(s, t.GetTraceId(), span)
+		key := processKey(process)
+		id, ok := processIDs[key]
+		if !ok {
+			id = "p" + strconv.Itoa(len(processIDs)+1)
+			processIDs[key] = id
+			result.Processes[id] = process
+		}
+		js.ProcessID = id
+		result.Spans = append(result.Spans, js)
+	}
+	return result
+}
+
+func (q *Querier) convertSpan(s *traceSchema, traceID string, span *tracev1.Span) (Span, Process) {
+	tags := make(map[string]*modelv1.TagValue, len(span.GetTags()))
+	for _, t := range span.GetTags() {
+		tags[t.GetKey()] = t.GetValue()
+	}
+	if rs := decodeOTLPSpan(span); rs != nil {
+		return fromOTLP(traceID, rs, tagString(tags[q.cfg.Mapping.Service]))
+	}
+	return q.fromTags(s, traceID, span.GetSpanId(), tags)
+}
+
+// decodeOTLPSpan returns the OTLP span of the span bytes written by the OTLP receiver, or nil if they are not.
+func decodeOTLPSpan(span *tracev1.Span) *otlptracev1.ResourceSpans {
+	rs, err := otlp.DecodeSpan(span.GetSpan())
+	if err != nil || len(rs.GetScopeSpans()) != 1 || len(rs.GetScopeSpans()[0].GetSpans()) != 1 {
+		return nil
+	}
+	if otlp.SpanID(rs.GetScopeSpans()[0].GetSpans()[0].GetSpanId()) != span.GetSpanId() {
+		return nil
+	}
+	return rs
+}
+
+// fromOTLP converts an OTLP span the same as the OTLP receiver of Jaeger.
+func fromOTLP(traceID string, rs *otlptracev1.ResourceSpans, service string) (Span, Process) {
+	scope := rs.GetScopeSpans()[0].GetScope()
+	span := rs.GetScopeSpans()[0].GetSpans()[0]
+	js := Span{
+		TraceID:       traceID,
+		SpanID:        otlp.SpanID(span.GetSpanId()),
+		OperationName: span.GetName(),
+		StartTime:     int64(span.GetStartTimeUnixNano() / uint64(time.Microsecond)),
+		References:    []Reference{},
+		Tags:          []KeyValue{},
+		Logs:          []Log{},
+	}
+	if span.GetEndTimeUnixNano() > span.GetStartTimeUnixNano() {
+		js.Duration = int64((span.GetEndTimeUnixNano() - span.GetStartTimeUnixNano()) / uint64(time.Microsecond))
+	}
+	if len(span.GetParentSpanId()) > 0 {
+		js.References = append(js.References, Reference{RefType: ChildOf, TraceID: traceID, SpanID: otlp.SpanID(span.GetParentSpanId())})
+	}
+	for _, link := range span.GetLinks() {
+		js.References = append(js.References, Reference{RefType: FollowsFrom, TraceID: otlp.SpanID(link.GetTraceId()), SpanID: otlp.SpanID(link.GetSpanId())})
+	}
+	for _, kv := range span.GetAttributes() {
+		js.Tags = append(js.Tags, attributeKeyValue(kv.GetKey(), kv.GetValue()))
+	}
+	if kind := span.GetKind(); kind != otlptracev1.Span_SPAN_KIND_UNSPECIFIED {
+		js.Tags = append(js.Tags, stringKeyValue("span.kind", strings.ToLower(strings.TrimPrefix(kind.String(), "SPAN_KIND_"))))
+	}
+	if code := span.GetStatus().GetCode(); code != otlptracev1.Status_STATUS_CODE_UNSET {
+		js.Tags = append(js.Tags, stringKeyValue("otel.status_code", strings.TrimPrefix(code.String(), "STATUS_CODE_")))
+		if code == otlptracev1.Status_STATUS_CODE_ERROR {
+			js.Tags = append(js.Tags, KeyValue{Key: "error", Type: BoolType, Value: true})
+		}
+	}
+	if msg := span.GetStatus().GetMessage(); msg != "" {
+		js.Tags = append(js.Tags, stringKeyValue("otel.status_description", msg))
+	}
+	if name := scope.GetName(); name != "" {
+		js.Tags = append(js.Tags, stringKeyValue("otel.scope.name", name))
+	}
+	if version := scope.GetVersion(); version != "" {
+		js.Tags = append(js.Tags, stringKeyValue("otel.scope.version", version))
+	}
+	for _, event := range span.GetEvents() {
+		log := Log{
+			Timestamp: int64(event.GetTimeUnixNano() / uint64(time.Microsecond)),
+			Fields:    []KeyValue{stringKeyValue("event", event.GetName())},
+		}
+		for _, kv := range event.GetAttributes() {
+			log.Fields = append(log.Fields, attributeKeyValue(kv.GetKey(), kv.GetValue()))
+		}
+		js.Logs = append(js.Logs, log)
+	}
+	process := Process{ServiceName: service, Tags: []KeyValue{}}
+	for _, kv := range rs.GetResource().GetAttributes() {
+		if kv.GetKey() == serviceNameAttribute && kv.GetValue().GetStringValue() != "" {
+			process.ServiceName = kv.GetValue().GetStringValue()
+			continue
+		}
+		process.Tags = append(process.Tags, attributeKeyValue(kv.GetKey(), kv.GetValue()))
+	}
+	if process.ServiceName == "" {
+		process.ServiceName = unknownService
+	}
+	return js, process
+}
+
+// fromTags converts a span by its tags. The mapped and the identifying tags fill the fields of the span,
+// and the others are the tags of the span.
+func (q *Querier) fromTags(s *traceSchema, traceID, spanID string, tags map[string]*modelv1.TagValue) (Span, Process) {
+	m := q.cfg.Mapping
+	js := Span{
+		TraceID:       traceID,
+		SpanID:        spanID,
+		OperationName: tagString(tags[m.Operation]),
+		References:    []Reference{},
+		Tags:          []KeyValue{},
+		Logs:          []Log{},
+	}
+	if ts := tags[s.trace.GetTimestampTagName()].GetTimestamp(); ts != nil {
+		js.StartTime = ts.AsTime().UnixMicro()
+	}
+	if d := tags[m.Duration].GetInt(); d != nil {
+		js.Duration = (time.Duration(d.GetValue()) * q.cfg.DurationUnit).Microseconds()
+	}
+	if parent := tagString(tags[m.ParentSpanID]); parent != "" {
+		js.References = append(js.References, Reference{RefType: ChildOf, TraceID: traceID, SpanID: parent})
+	}
+	mapped := map[string]struct{}{
+		s.trace.GetTraceIdTagName():   {},
+		s.trace.GetSpanIdTagName():    {},
+		s.trace.GetTimestampTagName(): {},
+		m.Service:                     {},
+		m.Operation:                   {},
+		m.Duration:                    {},
+		m.ParentSpanID:                {},
+	}
+	for _, name := range s.projection {
+		if _, ok := mapped[name]; ok {
+			continue
+		}
+		if kv, ok := tagKeyValue(name, tags[name]); ok {
+			js.Tags = append(js.Tags, kv)
+		}
+	}
+	service := tagString(tags[m.Service])
+	if service == "" {
+		service = unknownService
+	}
+	return js, Process{ServiceName: service, Tags: []KeyValue{}}
+}
+
+func processKey(p Process) string {
+	var sb strings.Builder
+	sb.WriteString(p.ServiceName)
+	for _, kv := range p.Tags {
+		sb.WriteByte(0)
+		sb.WriteString(kv.Key)
+		sb.WriteByte('=')
+		b, _ := json.Marshal(kv.Value)
+		sb.Write(b)
+	}
+	return sb.String()
+}
+
+func tagString(v *modelv1.TagValue) string {
+	switch val := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return val.Str.GetValue()
+	case *modelv1.TagValue_Int:
+		return strconv.FormatInt(val.Int.GetValue(), 10)
+	default:
+		return ""
+	}
+}
+
+func tagKeyValue(name string, v *modelv1.TagValue) (KeyValue, bool) {
+	switch val := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return stringKeyValue(name, val.Str.GetValue()), true
+	case *modelv1.TagValue_Int:
+		return KeyValue{Key: name, Type: Int64Type, Value: val.Int.GetValue()}, true
+	case *modelv1.TagValue_StrArray:
+		return jsonKeyValue(name, val.StrArray.GetValue()), true
+	case *modelv1.TagValue_IntArray:
+		return jsonKeyValue(name, val.IntArray.GetValue()), true
+	case *modelv1.TagValue_BinaryData:
+		return KeyValue{Key: name, Type: BinaryType, Value: val.BinaryData}, true
+	case *modelv1.TagValue_Timestamp:
+		return stringKeyValue(name, val.Timestamp.AsTime().Format(time.RFC3339Nano)), true
+	default:
+		return KeyValue{}, false
+	}
+}
+
+// attributeKeyValue converts an OTLP attribute. The arrays and the maps are encoded in JSON strings.
+func attributeKeyValue(key string, v *otlpcommonv1.AnyValue) KeyValue {
+	switch val := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return KeyValue{Key: key, Type: BoolType, Value: val.BoolValue}
+	case *otlpcommonv1.AnyValue_IntValue:
+		return KeyValue{Key: key, Type: Int64Type, Value: val.IntValue}
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return KeyValue{Key: key, Type: Float64Type, Value: val.DoubleValue}
+	case *otlpcommonv1.AnyValue_BytesValue:
+		return KeyValue{Key: key, Type: BinaryType, Value: val.BytesValue}
+	case *otlpcommonv1.AnyValue_ArrayValue, *otlpcommonv1.AnyValue_KvlistValue:
+		return jsonKeyValue(key, plainValue(v))
+	default:
+		return stringKeyValue(key, v.GetStringValue())
+	}
+}
+
+func plainValue(v *otlpcommonv1.AnyValue) any {
+	switch val := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_StringValue:
+		return val.StringValue
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return val.BoolValue
+	case *otlpcommonv1.AnyValue_IntValue:
+		return val.IntValue
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return val.DoubleValue
+	case *otlpcommonv1.AnyValue_BytesValue:
+		return val.BytesValue
+	case *otlpcommonv1.AnyValue_ArrayValue:
+		values := make([]any, 0, len(val.ArrayValue.GetValues()))
+		for _, el := range val.ArrayValue.GetValues() {
+			values = append(values, plainValue(el))
+		}
+		return values
+	case *otlpcommonv1.AnyValue_KvlistValue:
+		values := make(map[string]any, len(val.KvlistValue.GetValues()))
+		for _, kv := range val.KvlistValue.GetValues() {
+			values[kv.GetKey()] = plainValue(kv.GetValue())
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func stringKeyValue(key, value string) KeyValue {
+	return KeyValue{Key: key, Type: StringType, Value: value}
+}
+
+func jsonKeyValue(key string, value any) KeyValue {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return stringKeyValue(key, "")
+	}
+	return stringKeyValue(key, string(b))
+}
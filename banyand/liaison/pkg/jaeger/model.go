@@ -0,0 +1,88 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package jaeger serves the spans of a trace in the data model of the Jaeger query service.
+package jaeger
+
+// ReferenceType is the type of the reference between two spans.
+type ReferenceType string
+
+// The reference types.
+const (
+	ChildOf     ReferenceType = "CHILD_OF"
+	FollowsFrom ReferenceType = "FOLLOWS_FROM"
+)
+
+// ValueType is the type of the value of a KeyValue.
+type ValueType string
+
+// The value types.
+const (
+	StringType  ValueType = "string"
+	BoolType    ValueType = "bool"
+	Int64Type   ValueType = "int64"
+	Float64Type ValueType = "float64"
+	BinaryType  ValueType = "binary"
+)
+
+// Trace is a trace of the Jaeger JSON model.
+type Trace struct {
+	Processes map[string]Process `json:"processes"`
+	TraceID   string             `json:"traceID"`
+	Spans     []Span             `json:"spans"`
+	Warnings  []string           `json:"warnings"`
+}
+
+// Span is a span of the Jaeger JSON model. The times are in microseconds.
+type Span struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	OperationName string      `json:"operationName"`
+	ProcessID     string      `json:"processID"`
+	References    []Reference `json:"references"`
+	Tags          []KeyValue  `json:"tags"`
+	Logs          []Log       `json:"logs"`
+	Warnings      []string    `json:"warnings"`
+	StartTime     int64       `json:"startTime"`
+	Duration      int64       `json:"duration"`
+}
+
+// Reference refers to another span.
+type Reference struct {
+	RefType ReferenceType `json:"refType"`
+	TraceID string        `json:"traceID"`
+	SpanID  string        `json:"spanID"`
+}
+
+// KeyValue is a tag of a span or a process, or a field of a log.
+type KeyValue struct {
+	Value any       `json:"value"`
+	Key   string    `json:"key"`
+	Type  ValueType `json:"type"`
+}
+
+// Log is an event of a span.
+type Log struct {
+	Fields    []KeyValue `json:"fields"`
+	Timestamp int64      `json:"timestamp"`
+}
+
+// Process is the service emitting the spans.
+type Process struct {
+	ServiceName string     `json:"serviceName"`
+	Tags        []KeyValue `json:"tags"`
+}
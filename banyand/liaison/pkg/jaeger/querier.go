@@ -0,0 +1,472 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package jaeger
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+)
+
+// The fields of the Jaeger spans filled by the trace tags.
+const (
+	FieldService      = "service"
+	FieldOperation    = "operation"
+	FieldDuration     = "duration"
+	FieldParentSpanID = "parent_span_id"
+)
+
+const (
+	schemaTTL = time.Minute
+	// DefaultLimit is the number of the traces a search returns without limit.
+	DefaultLimit = 20
+	// MaxLimit is the maximum number of the traces a search returns.
+	MaxLimit = 1000
+	// lookupMaxTraces is the maximum number of the traces scanned by the services and operations lookups.
+	lookupMaxTraces = 1000
+)
+
+var (
+	// ErrBadQuery means the query is malformed, or the trace can't serve it.
+	ErrBadQuery = errors.New("bad query")
+	// ErrTraceNotFound means no span has the trace ID.
+	ErrTraceNotFound = errors.New("trace not found")
+)
+
+// TagMapping names the trace tags filling the fields of the Jaeger spans. An empty name leaves the field unfilled.
+type TagMapping struct {
+	Service      string
+	Operation    string
+	Duration     string
+	ParentSpanID string
+}
+
+// DefaultTagMapping is the mapping of the fields absent from the rules of ParseTagMapping.
+var DefaultTagMapping = TagMapping{
+	Service:      "service_name",
+	Operation:    "operation",
+	Duration:     "duration",
+	ParentSpanID: "parent_span_id",
+}
+
+// ParseTagMapping parses the rules in the form of "field=tag" on top of the DefaultTagMapping.
+// The field is one of "service", "operation", "duration" and "parent_span_id". An empty tag unmaps the field.
+func ParseTagMapping(rules []string) (TagMapping, error) {
+	mapping := DefaultTagMapping
+	for _, r := range rules {
+		field, tag, ok := strings.Cut(r, "=")
+		if !ok || field == "" {
+			return TagMapping{}, errors.Errorf("invalid tag mapping %q, it should be in the form of field=tag", r)
+		}
+		switch field {
+		case FieldService:
+			mapping.Service = tag
+		case FieldOperation:
+			mapping.Operation = tag
+		case FieldDuration:
+			mapping.Duration = tag
+		case FieldParentSpanID:
+			mapping.ParentSpanID = tag
+		default:
+			return TagMapping{}, errors.Errorf("unknown field %q of the tag mapping %q", field, r)
+		}
+	}
+	return mapping, nil
+}
+
+// Config configures a Querier.
+type Config struct {
+	// Group and Name identify the trace holding the spans.
+	Group string
+	Name  string
+	// OrderIndexRule is the TREE index rule ordering the spans by their timestamps, which the searches require.
+	OrderIndexRule string
+	Mapping        TagMapping
+	// DurationUnit is the unit of the duration tag.
+	DurationUnit time.Duration
+	// Lookback is the range of the searches without start, and of the services and operations lookups.
+	Lookback time.Duration
+}
+
+// TraceQuery holds the parameters of a search. The traces hold a span of the service, and of the operation if set,
+// whose tags equal the Tags and whose duration is between MinDuration and MaxDuration if set.
+type TraceQuery struct {
+	Start       time.Time
+	End         time.Time
+	Tags        map[string]string
+	Service     string
+	Operation   string
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	Limit       uint32
+}
+
+type traceSchema struct {
+	fetchedAt  time.Time
+	trace      *databasev1.Trace
+	tagTypes   map[string]databasev1.TagType
+	projection []string
+	ttl        time.Duration
+}
+
+// Querier reads the spans of a trace through the trace service, and converts them into the Jaeger data model.
+// The spans written by the OTLP receiver are converted from their OTLP span bytes, and the others from their tags.
+type Querier struct {
+	groups   databasev1.GroupRegistryServiceClient
+	registry databasev1.TraceRegistryServiceClient
+	client   tracev1.TraceServiceClient
+	schema   *traceSchema
+	cfg      Config
+	mu       sync.Mutex
+}
+
+// NewQuerier returns a Querier.
+func NewQuerier(groups databasev1.GroupRegistryServiceClient, registry databasev1.TraceRegistryServiceClient,
+	client tracev1.TraceServiceClient, cfg Config,
+) *Querier {
+	return &Querier{groups: groups, registry: registry, client: client, cfg: cfg}
+}
+
+func (q *Querier) traceSchema(ctx context.Context) (*traceSchema, error) {
+	q.mu.Lock()
+	s := q.schema
+	q.mu.Unlock()
+	if s != nil && time.Since(s.fetchedAt) < schemaTTL {
+		return s, nil
+	}
+	resp, err := q.registry.Get(ctx, &databasev1.TraceRegistryServiceGetRequest{Metadata: &commonv1.Metadata{Group: q.cfg.Group, Name: q.cfg.Name}})
+	if err != nil {
+		return nil, err
+	}
+	group, err := q.groups.Get(ctx, &databasev1.GroupRegistryServiceGetRequest{Group: q.cfg.Group})
+	if err != nil {
+		return nil, err
+	}
+	s = &traceSchema{
+		fetchedAt: time.Now(),
+		trace:     resp.GetTrace(),
+		tagTypes:  make(map[string]databasev1.TagType, len(resp.GetTrace().GetTags())),
+		ttl:       intervalDuration(group.GetGroup().GetResourceOpts().GetTtl()),
+	}
+	for _, t := range s.trace.GetTags() {
+		s.tagTypes[t.GetName()] = t.GetType()
+		s.projection = append(s.projection, t.GetName())
+	}
+	q.mu.Lock()
+	q.schema = s
+	q.mu.Unlock()
+	return s, nil
+}
+
+func intervalDuration(rule *commonv1.IntervalRule) time.Duration {
+	switch rule.GetUnit() {
+	case commonv1.IntervalRule_UNIT_HOUR:
+		return time.Hour * time.Duration(rule.GetNum())
+	case commonv1.IntervalRule_UNIT_DAY:
+		return 24 * time.Hour * time.Duration(rule.GetNum())
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// mappedTag returns the tag mapped to the field, or an ErrBadQuery if the trace doesn't have it.
+func (s *traceSchema) mappedTag(field, tag string) (string, error) {
+	if _, ok := s.tagTypes[tag]; !ok {
+		return "", errors.Wrapf(ErrBadQuery, "the trace has no tag mapped to the %s", field)
+	}
+	return tag, nil
+}
+
+// GetTrace returns the trace of the ID. The spans are looked up between the start and the end,
+// or within the TTL of the group if they are zero.
+func (q *Querier) GetTrace(ctx context.Context, traceID string, start, end time.Time) (*Trace, error) {
+	s, err := q.traceSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-s.ttl)
+	}
+	traces, err := q.query(ctx, &tracev1.QueryRequest{
+		TimeRange:     timeRange(start, end),
+		Criteria:      condition(s.trace.GetTraceIdTagName(), modelv1.Condition_BINARY_OP_EQ, strTagValue(traceID)),
+		TagProjection: s.projection,
+		Limit:         1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(traces) == 0 || len(traces[0].GetSpans()) == 0 {
+		return nil, errors.Wrapf(ErrTraceNotFound, "trace %s", traceID)
+	}
+	return q.convert(s, traces[0]), nil
+}
+
+// FindTraces returns the traces matching the query, the latest first.
+func (q *Querier) FindTraces(ctx context.Context, tq *TraceQuery) ([]*Trace, error) {
+	s, err := q.traceSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tq.Service == "" {
+		return nil, errors.Wrap(ErrBadQuery, "the service is required")
+	}
+	serviceTag, err := s.mappedTag(FieldService, q.cfg.Mapping.Service)
+	if err != nil {
+		return nil, err
+	}
+	criteria := condition(serviceTag, modelv1.Condition_BINARY_OP_EQ, strTagValue(tq.Service))
+	if tq.Operation != "" {
+		operationTag, opErr := s.mappedTag(FieldOperation, q.cfg.Mapping.Operation)
+		if opErr != nil {
+			return nil, opErr
+		}
+		criteria = and(criteria, condition(operationTag, modelv1.Condition_BINARY_OP_EQ, strTagValue(tq.Operation)))
+	}
+	tags := make([]string, 0, len(tq.Tags))
+	for k := range tq.Tags {
+		tags = append(tags, k)
+	}
+	sort.Strings(tags)
+	for _, k := range tags {
+		v, tagErr := s.tagValue(k, tq.Tags[k])
+		if tagErr != nil {
+			return nil, tagErr
+		}
+		criteria = and(criteria, condition(k, modelv1.Condition_BINARY_OP_EQ, v))
+	}
+	if tq.MinDuration > 0 || tq.MaxDuration > 0 {
+		durationTag, durErr := s.mappedTag(FieldDuration, q.cfg.Mapping.Duration)
+		if durErr != nil {
+			return nil, durErr
+		}
+		if tq.MinDuration > 0 {
+			criteria = and(criteria, condition(durationTag, modelv1.Condition_BINARY_OP_GE, intTagValue(int64(tq.MinDuration/q.cfg.DurationUnit))))
+		}
+		if tq.MaxDuration > 0 {
+			criteria = and(criteria, condition(durationTag, modelv1.Condition_BINARY_OP_LE, intTagValue(int64(tq.MaxDuration/q.cfg.DurationUnit))))
+		}
+	}
+	end := tq.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := tq.Start
+	if start.IsZero() {
+		start = end.Add(-q.cfg.Lookback)
+	}
+	limit := tq.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	traces, err := q.query(ctx, &tracev1.QueryRequest{
+		TimeRange:     timeRange(start, end),
+		Criteria:      criteria,
+		TagProjection: projectionOf(criteria, s.projection),
+		OrderBy:       &modelv1.QueryOrder{IndexRuleName: q.cfg.OrderIndexRule, Sort: modelv1.Sort_SORT_DESC},
+		Limit:         min(limit, MaxLimit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Trace, 0, len(traces))
+	for _, t := range traces {
+		if len(t.GetSpans()) > 0 {
+			result = append(result, q.convert(s, t))
+		}
+	}
+	return result, nil
+}
+
+// Services returns the services of the spans within the lookback.
+func (q *Querier) Services(ctx context.Context) ([]string, error) {
+	s, err := q.traceSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceTag, err := s.mappedTag(FieldService, q.cfg.Mapping.Service)
+	if err != nil {
+		return nil, err
+	}
+	return q.distinct(ctx, nil, serviceTag, "", serviceTag)
+}
+
+// Operations returns the operations of the spans of the service within the lookback.
+func (q *Querier) Operations(ctx context.Context, service string) ([]string, error) {
+	s, err := q.traceSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceTag, err := s.mappedTag(FieldService, q.cfg.Mapping.Service)
+	if err != nil {
+		return nil, err
+	}
+	operationTag, err := s.mappedTag(FieldOperation, q.cfg.Mapping.Operation)
+	if err != nil {
+		return nil, err
+	}
+	return q.distinct(ctx, condition(serviceTag, modelv1.Condition_BINARY_OP_EQ, strTagValue(service)), serviceTag, service, operationTag)
+}
+
+// distinct returns the distinct values of the tag of the spans within the lookback. The traces are selected by the criteria,
+// and only the spans whose filterTag equals the filterValue count if the filterValue is set.
+func (q *Querier) distinct(ctx context.Context, criteria *modelv1.Criteria, filterTag, filterValue, tag string) ([]string, error) {
+	projection := []string{tag}
+	if filterTag != tag {
+		projection = append(projection, filterTag)
+	}
+	end := time.Now()
+	traces, err := q.query(ctx, &tracev1.QueryRequest{
+		TimeRange:     timeRange(end.Add(-q.cfg.Lookback), end),
+		Criteria:      criteria,
+		TagProjection: projectionOf(criteria, projection),
+		OrderBy:       &modelv1.QueryOrder{IndexRuleName: q.cfg.OrderIndexRule, Sort: modelv1.Sort_SORT_DESC},
+		Limit:         lookupMaxTraces,
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]struct{})
+	for _, t := range traces {
+		for _, span := range t.GetSpans() {
+			var filter, value string
+			for _, tv := range span.GetTags() {
+				switch tv.GetKey() {
+				case filterTag:
+					filter = tagString(tv.GetValue())
+				case tag:
+					value = tagString(tv.GetValue())
+				}
+			}
+			if tag == filterTag {
+				value = filter
+			}
+			if value == "" || filterValue != "" && filter != filterValue {
+				continue
+			}
+			values[value] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(values))
+	for v := range values {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// projectionOf returns the tags led by the tags of the criteria in the order they appear. The tag filters of the trace
+// queries locate the values of the conditions by their positions in the projection.
+func projectionOf(criteria *modelv1.Criteria, tags []string) []string {
+	var names []string
+	var walk func(c *modelv1.Criteria)
+	walk = func(c *modelv1.Criteria) {
+		switch exp := c.GetExp().(type) {
+		case *modelv1.Criteria_Condition:
+			if !slices.Contains(names, exp.Condition.GetName()) {
+				names = append(names, exp.Condition.GetName())
+			}
+		case *modelv1.Criteria_Le:
+			walk(exp.Le.GetLeft())
+			walk(exp.Le.GetRight())
+		}
+	}
+	walk(criteria)
+	for _, t := range tags {
+		if !slices.Contains(names, t) {
+			names = append(names, t)
+		}
+	}
+	return names
+}
+
+func (q *Querier) query(ctx context.Context, req *tracev1.QueryRequest) ([]*tracev1.Trace, error) {
+	req.Groups = []string{q.cfg.Group}
+	req.Name = q.cfg.Name
+	resp, err := q.client.Query(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetTraces(), nil
+}
+
+// tagValue parses the value of a searched tag by the type of the tag.
+func (s *traceSchema) tagValue(name, value string) (*modelv1.TagValue, error) {
+	typ, ok := s.tagTypes[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrBadQuery, "the trace has no tag %s", name)
+	}
+	switch typ {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		return strTagValue(value), nil
+	case databasev1.TagType_TAG_TYPE_INT:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrBadQuery, "invalid integer %q of the tag %s", value, name)
+		}
+		return intTagValue(i), nil
+	default:
+		return nil, errors.Wrapf(ErrBadQuery, "the tag %s of the type %s can't be searched", name, typ)
+	}
+}
+
+// timeRange returns the range covering the start and the end in the millisecond precision, which the trace queries require.
+func timeRange(start, end time.Time) *modelv1.TimeRange {
+	if truncated := end.Truncate(time.Millisecond); truncated.Before(end) {
+		end = truncated.Add(time.Millisecond)
+	}
+	return &modelv1.TimeRange{Begin: timestamppb.New(start.Truncate(time.Millisecond)), End: timestamppb.New(end)}
+}
+
+func condition(name string, op modelv1.Condition_BinaryOp, value *modelv1.TagValue) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: name, Op: op, Value: value}}}
+}
+
+func and(left, right *modelv1.Criteria) *modelv1.Criteria {
+	if left == nil {
+		return right
+	}
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+		Left:  left,
+		Right: right,
+	}}}
+}
+
+func strTagValue(s string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: s}}}
+}
+
+func intTagValue(i int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: i}}}
+}
@@ -0,0 +1,312 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package jaeger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+)
+
+type fakeGroupRegistry struct {
+	databasev1.GroupRegistryServiceClient
+}
+
+func (fakeGroupRegistry) Get(_ context.Context, _ *databasev1.GroupRegistryServiceGetRequest, _ ...grpc.CallOption,
+) (*databasev1.GroupRegistryServiceGetResponse, error) {
+	return &databasev1.GroupRegistryServiceGetResponse{Group: &commonv1.Group{
+		Metadata:     &commonv1.Metadata{Name: "otlp"},
+		ResourceOpts: &commonv1.ResourceOpts{Ttl: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 3}},
+	}}, nil
+}
+
+type fakeTraceRegistry struct {
+	databasev1.TraceRegistryServiceClient
+}
+
+func (fakeTraceRegistry) Get(_ context.Context, _ *databasev1.TraceRegistryServiceGetRequest, _ ...grpc.CallOption,
+) (*databasev1.TraceRegistryServiceGetResponse, error) {
+	return &databasev1.TraceRegistryServiceGetResponse{Trace: &databasev1.Trace{
+		Metadata: &commonv1.Metadata{Group: "otlp", Name: "otlp_spans"},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "operation", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "parent_span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "status", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "labels", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+		},
+		TraceIdTagName:   "trace_id",
+		SpanIdTagName:    "span_id",
+		TimestampTagName: "timestamp",
+	}}, nil
+}
+
+type fakeTraceClient struct {
+	tracev1.TraceServiceClient
+	request *tracev1.QueryRequest
+	traces  []*tracev1.Trace
+}
+
+func (f *fakeTraceClient) Query(_ context.Context, in *tracev1.QueryRequest, _ ...grpc.CallOption) (*tracev1.QueryResponse, error) {
+	f.request = in
+	return &tracev1.QueryResponse{Traces: f.traces}, nil
+}
+
+func newTestQuerier(client *fakeTraceClient) *Querier {
+	return NewQuerier(fakeGroupRegistry{}, fakeTraceRegistry{}, client, Config{
+		Group:          "otlp",
+		Name:           "otlp_spans",
+		OrderIndexRule: "timestamp",
+		Mapping:        DefaultTagMapping,
+		DurationUnit:   time.Millisecond,
+		Lookback:       time.Hour,
+	})
+}
+
+func tag(key string, value *modelv1.TagValue) *modelv1.Tag {
+	return &modelv1.Tag{Key: key, Value: value}
+}
+
+func tagSpan(spanID, service, operation, parent string, start time.Time, durationMillis int64) *tracev1.Span {
+	return &tracev1.Span{
+		SpanId: spanID,
+		Span:   []byte("raw"),
+		Tags: []*modelv1.Tag{
+			tag("trace_id", strTagValue("t1")),
+			tag("span_id", strTagValue(spanID)),
+			tag("timestamp", &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(start)}}),
+			tag("service_name", strTagValue(service)),
+			tag("operation", strTagValue(operation)),
+			tag("duration", intTagValue(durationMillis)),
+			tag("parent_span_id", strTagValue(parent)),
+			tag("status", intTagValue(500)),
+			tag("labels", &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{"a", "b"}}}}),
+		},
+	}
+}
+
+func TestParseTagMapping(t *testing.T) {
+	mapping, err := ParseTagMapping(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTagMapping, mapping)
+
+	mapping, err = ParseTagMapping([]string{"service=local_endpoint_service_name", "operation=operation_name", "parent_span_id="})
+	require.NoError(t, err)
+	assert.Equal(t, TagMapping{Service: "local_endpoint_service_name", Operation: "operation_name", Duration: "duration"}, mapping)
+
+	for _, rule := range []string{"service", "=tag", "kind=span_kind"} {
+		_, err = ParseTagMapping([]string{rule})
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestQuerierFindTraces(t *testing.T) {
+	start := time.UnixMilli(1_700_000_000_123)
+	client := &fakeTraceClient{traces: []*tracev1.Trace{{TraceId: "t1", Spans: []*tracev1.Span{
+		tagSpan("s1", "frontend", "GET /", "", start, 30),
+		tagSpan("s2", "backend", "query", "s1", start.Add(time.Millisecond), 20),
+		tagSpan("s3", "frontend", "render", "s1", start.Add(25*time.Millisecond), 5),
+	}}}}
+	q := newTestQuerier(client)
+	traces, err := q.FindTraces(context.Background(), &TraceQuery{
+		Service:     "frontend",
+		Operation:   "GET /",
+		Tags:        map[string]string{"status": "500"},
+		MinDuration: 10 * time.Millisecond,
+		End:         start.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	req := client.request
+	assert.Equal(t, []string{"otlp"}, req.GetGroups())
+	assert.Equal(t, "otlp_spans", req.GetName())
+	assert.Equal(t, "timestamp", req.GetOrderBy().GetIndexRuleName())
+	assert.Equal(t, modelv1.Sort_SORT_DESC, req.GetOrderBy().GetSort())
+	assert.Equal(t, uint32(DefaultLimit), req.GetLimit())
+	assert.Equal(t, start.Add(time.Minute-time.Hour).UTC(), req.GetTimeRange().GetBegin().AsTime())
+	var conditions []*modelv1.Condition
+	var collect func(c *modelv1.Criteria)
+	collect = func(c *modelv1.Criteria) {
+		if cond := c.GetCondition(); cond != nil {
+			conditions = append(conditions, cond)
+			return
+		}
+		collect(c.GetLe().GetLeft())
+		collect(c.GetLe().GetRight())
+	}
+	collect(req.GetCriteria())
+	require.Len(t, conditions, 4)
+	assert.Equal(t, "service_name", conditions[0].GetName())
+	assert.Equal(t, "frontend", conditions[0].GetValue().GetStr().GetValue())
+	assert.Equal(t, "operation", conditions[1].GetName())
+	assert.Equal(t, "status", conditions[2].GetName())
+	assert.Equal(t, int64(500), conditions[2].GetValue().GetInt().GetValue())
+	assert.Equal(t, "duration", conditions[3].GetName())
+	assert.Equal(t, modelv1.Condition_BINARY_OP_GE, conditions[3].GetOp())
+	assert.Equal(t, int64(10), conditions[3].GetValue().GetInt().GetValue())
+	assert.Equal(t, []string{"service_name", "operation", "status", "duration", "trace_id", "span_id", "timestamp", "parent_span_id", "labels"},
+		req.GetTagProjection())
+
+	require.Len(t, traces, 1)
+	trace := traces[0]
+	assert.Equal(t, "t1", trace.TraceID)
+	require.Len(t, trace.Spans, 3)
+	assert.Len(t, trace.Processes, 2)
+	root := trace.Spans[0]
+	assert.Equal(t, "s1", root.SpanID)
+	assert.Equal(t, "GET /", root.OperationName)
+	assert.Equal(t, start.UnixMicro(), root.StartTime)
+	assert.Equal(t, int64(30_000), root.Duration)
+	assert.Empty(t, root.References)
+	assert.Equal(t, []KeyValue{
+		{Key: "status", Type: Int64Type, Value: int64(500)},
+		{Key: "labels", Type: StringType, Value: `["a","b"]`},
+	}, root.Tags)
+	assert.Equal(t, "frontend", trace.Processes[root.ProcessID].ServiceName)
+	assert.Equal(t, []Reference{{RefType: ChildOf, TraceID: "t1", SpanID: "s1"}}, trace.Spans[1].References)
+	assert.Equal(t, "backend", trace.Processes[trace.Spans[1].ProcessID].ServiceName)
+	assert.Equal(t, root.ProcessID, trace.Spans[2].ProcessID)
+}
+
+func TestQuerierBadQueries(t *testing.T) {
+	q := newTestQuerier(&fakeTraceClient{})
+	for _, tq := range []*TraceQuery{
+		{},
+		{Service: "svc", Tags: map[string]string{"absent": "v"}},
+		{Service: "svc", Tags: map[string]string{"status": "ok"}},
+		{Service: "svc", Tags: map[string]string{"labels": "a"}},
+	} {
+		_, err := q.FindTraces(context.Background(), tq)
+		assert.ErrorIs(t, err, ErrBadQuery)
+	}
+	q.cfg.Mapping.Duration = ""
+	_, err := q.FindTraces(context.Background(), &TraceQuery{Service: "svc", MaxDuration: time.Second})
+	assert.ErrorIs(t, err, ErrBadQuery)
+}
+
+func TestQuerierGetTrace(t *testing.T) {
+	traceID, spanID, parentID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, []byte{1, 1, 1, 1, 1, 1, 1, 1}, []byte{2, 2, 2, 2, 2, 2, 2, 2}
+	start := uint64(time.UnixMilli(1_700_000_000_000).UnixNano())
+	spanBytes, err := proto.Marshal(&otlptracev1.ResourceSpans{
+		Resource: &otlpresourcev1.Resource{Attributes: []*otlpcommonv1.KeyValue{
+			{Key: "service.name", Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: "checkout"}}},
+			{Key: "host.name", Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: "node-1"}}},
+		}},
+		ScopeSpans: []*otlptracev1.ScopeSpans{{
+			Scope: &otlpcommonv1.InstrumentationScope{Name: "net/http"},
+			Spans: []*otlptracev1.Span{{
+				TraceId:           traceID,
+				SpanId:            spanID,
+				ParentSpanId:      parentID,
+				Name:              "POST /pay",
+				Kind:              otlptracev1.Span_SPAN_KIND_SERVER,
+				StartTimeUnixNano: start,
+				EndTimeUnixNano:   start + uint64(1500*time.Microsecond),
+				Attributes: []*otlpcommonv1.KeyValue{
+					{Key: "http.status_code", Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: 500}}},
+				},
+				Events: []*otlptracev1.Span_Event{{Name: "retry", TimeUnixNano: start + uint64(time.Millisecond)}},
+				Status: &otlptracev1.Status{Code: otlptracev1.Status_STATUS_CODE_ERROR, Message: "declined"},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	client := &fakeTraceClient{traces: []*tracev1.Trace{{
+		TraceId: "0102030405060708090a0b0c0d0e0f10",
+		Spans:   []*tracev1.Span{{SpanId: "0101010101010101", Span: spanBytes}},
+	}}}
+	q := newTestQuerier(client)
+	end := time.UnixMilli(1_700_000_100_000)
+	trace, err := q.GetTrace(context.Background(), "0102030405060708090a0b0c0d0e0f10", time.Time{}, end)
+	require.NoError(t, err)
+
+	cond := client.request.GetCriteria().GetCondition()
+	assert.Equal(t, "trace_id", cond.GetName())
+	assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", cond.GetValue().GetStr().GetValue())
+	assert.Equal(t, end.Add(-72*time.Hour).UTC(), client.request.GetTimeRange().GetBegin().AsTime())
+	assert.Nil(t, client.request.GetOrderBy())
+
+	require.Len(t, trace.Spans, 1)
+	span := trace.Spans[0]
+	assert.Equal(t, "0101010101010101", span.SpanID)
+	assert.Equal(t, "POST /pay", span.OperationName)
+	assert.Equal(t, int64(start/1000), span.StartTime)
+	assert.Equal(t, int64(1500), span.Duration)
+	assert.Equal(t, []Reference{{RefType: ChildOf, TraceID: "0102030405060708090a0b0c0d0e0f10", SpanID: "0202020202020202"}}, span.References)
+	assert.Equal(t, []KeyValue{
+		{Key: "http.status_code", Type: Int64Type, Value: int64(500)},
+		{Key: "span.kind", Type: StringType, Value: "server"},
+		{Key: "otel.status_code", Type: StringType, Value: "ERROR"},
+		{Key: "error", Type: BoolType, Value: true},
+		{Key: "otel.status_description", Type: StringType, Value: "declined"},
+		{Key: "otel.scope.name", Type: StringType, Value: "net/http"},
+	}, span.Tags)
+	require.Len(t, span.Logs, 1)
+	assert.Equal(t, int64(start/1000)+1000, span.Logs[0].Timestamp)
+	assert.Equal(t, Process{ServiceName: "checkout", Tags: []KeyValue{{Key: "host.name", Type: StringType, Value: "node-1"}}}, trace.Processes[span.ProcessID])
+
+	client.traces = nil
+	_, err = q.GetTrace(context.Background(), "absent", time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, ErrTraceNotFound)
+}
+
+func TestQuerierServicesAndOperations(t *testing.T) {
+	start := time.Now()
+	client := &fakeTraceClient{traces: []*tracev1.Trace{
+		{TraceId: "t1", Spans: []*tracev1.Span{
+			tagSpan("s1", "frontend", "GET /", "", start, 30),
+			tagSpan("s2", "backend", "query", "s1", start, 20),
+		}},
+		{TraceId: "t2", Spans: []*tracev1.Span{
+			tagSpan("s3", "frontend", "GET /cart", "", start, 30),
+			tagSpan("s4", "frontend", "GET /", "", start, 30),
+		}},
+	}}
+	q := newTestQuerier(client)
+	services, err := q.Services(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend", "frontend"}, services)
+	assert.Equal(t, []string{"service_name"}, client.request.GetTagProjection())
+	assert.Zero(t, client.request.GetTimeRange().GetBegin().GetNanos()%int32(time.Millisecond))
+	assert.Zero(t, client.request.GetTimeRange().GetEnd().GetNanos()%int32(time.Millisecond))
+	assert.Nil(t, client.request.GetCriteria())
+
+	operations, err := q.Operations(context.Background(), "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /", "GET /cart"}, operations)
+	assert.Equal(t, "frontend", client.request.GetCriteria().GetCondition().GetValue().GetStr().GetValue())
+	assert.Equal(t, []string{"service_name", "operation"}, client.request.GetTagProjection())
+}
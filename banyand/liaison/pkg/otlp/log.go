@@ -0,0 +1,268 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const logAttributesPrefix = "log.attributes."
+
+// The sources of the tag values of the log records besides the attributes.
+const (
+	SourceLogBody           = "log.body"
+	SourceLogSeverityText   = "log.severity_text"
+	SourceLogSeverityNumber = "log.severity_number"
+	SourceLogEventName      = "log.event_name"
+	SourceLogTraceID        = "log.trace_id"
+	SourceLogSpanID         = "log.span_id"
+	SourceLogFlags          = "log.flags"
+	SourceLogTime           = "log.time"
+	SourceLogObservedTime   = "log.observed_time"
+)
+
+var logFieldSources = map[string]struct{}{
+	SourceLogBody:           {},
+	SourceLogSeverityText:   {},
+	SourceLogSeverityNumber: {},
+	SourceLogEventName:      {},
+	SourceLogTraceID:        {},
+	SourceLogSpanID:         {},
+	SourceLogFlags:          {},
+	SourceLogTime:           {},
+	SourceLogObservedTime:   {},
+	SourceScopeName:         {},
+	SourceScopeVersion:      {},
+}
+
+// ParseLogTagMapping parses the mapping rules of the log records in the form of "source=tag". A source is a log record field,
+// such as "log.body", a resource attribute, such as "resource.attributes.service.name", or a log record attribute,
+// such as "log.attributes.http.method".
+func ParseLogTagMapping(rules []string) (TagMapping, error) {
+	return parseTagMapping(rules, logFieldSources, resourceAttributesPrefix, logAttributesPrefix)
+}
+
+// LogConvertResult holds the write requests converted from the log records, and the log records rejected.
+type LogConvertResult struct {
+	RejectReason string
+	Requests     []*streamv1.WriteRequest
+	Rejected     int64
+}
+
+type logTag struct {
+	name   string
+	source string
+	typ    databasev1.TagType
+	entity bool
+}
+
+// LogConverter converts the OTLP log records into the writes of a stream.
+type LogConverter struct {
+	stream   *databasev1.Stream
+	families [][]logTag
+}
+
+// NewLogConverter returns a LogConverter of the stream. The mapped tags are filled by their sources,
+// and the other tags by the log record or resource attributes of the same name.
+func NewLogConverter(stream *databasev1.Stream, mapping TagMapping) *LogConverter {
+	entity := make(map[string]struct{}, len(stream.GetEntity().GetTagNames()))
+	for _, name := range stream.GetEntity().GetTagNames() {
+		entity[name] = struct{}{}
+	}
+	c := &LogConverter{stream: stream, families: make([][]logTag, len(stream.GetTagFamilies()))}
+	for i, f := range stream.GetTagFamilies() {
+		tags := make([]logTag, len(f.GetTags()))
+		for j, t := range f.GetTags() {
+			_, isEntity := entity[t.GetName()]
+			tags[j] = logTag{name: t.GetName(), source: mapping[t.GetName()], typ: t.GetType(), entity: isEntity}
+		}
+		c.families[i] = tags
+	}
+	return c
+}
+
+// Convert converts the log records of the request. A log record is rejected if it has neither time nor observed time,
+// or any entity tag of the stream is absent.
+//
+// The element ID is the hash of the log record with its scope and resource, so that the retried exports write the same elements.
+func (c *LogConverter) Convert(req *collectorlogsv1.ExportLogsServiceRequest) LogConvertResult {
+	var result LogConvertResult
+	reject := func(reason string) {
+		result.Rejected++
+		if result.RejectReason == "" {
+			result.RejectReason = reason
+		}
+	}
+	metadata := proto.Clone(c.stream.GetMetadata()).(*commonv1.Metadata)
+	var messageID uint64
+	for _, rl := range req.GetResourceLogs() {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, record := range sl.GetLogRecords() {
+				ts := record.GetTimeUnixNano()
+				if ts == 0 {
+					ts = record.GetObservedTimeUnixNano()
+				}
+				if ts == 0 {
+					reject("absent time of the log record")
+					continue
+				}
+				families, absent := c.tagFamilies(rl.GetResource().GetAttributes(), sl.GetScope(), record)
+				if absent != "" {
+					reject("absent entity tag " + strconv.Quote(absent) + " of the log record")
+					continue
+				}
+				id, err := elementID(rl, sl, record)
+				if err != nil {
+					reject(err.Error())
+					continue
+				}
+				messageID++
+				result.Requests = append(result.Requests, &streamv1.WriteRequest{
+					Metadata: metadata,
+					Element: &streamv1.ElementValue{
+						ElementId:   id,
+						Timestamp:   timestamppb.New(time.Unix(0, int64(ts)).Truncate(time.Millisecond)),
+						TagFamilies: families,
+					},
+					MessageId: messageID,
+				})
+				metadata = nil
+			}
+		}
+	}
+	return result
+}
+
+// elementID returns the hexadecimal SHA-256 prefix of the deterministic encoding of the log record, its scope and its resource.
+func elementID(rl *otlplogsv1.ResourceLogs, sl *otlplogsv1.ScopeLogs, record *otlplogsv1.LogRecord) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&otlplogsv1.ResourceLogs{
+		Resource: rl.GetResource(),
+		ScopeLogs: []*otlplogsv1.ScopeLogs{{
+			Scope:      sl.GetScope(),
+			LogRecords: []*otlplogsv1.LogRecord{record},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// tagFamilies returns the tag values of the log record, or the name of the first absent entity tag.
+func (c *LogConverter) tagFamilies(resourceAttrs []*otlpcommonv1.KeyValue, scope *otlpcommonv1.InstrumentationScope,
+	record *otlplogsv1.LogRecord,
+) ([]*modelv1.TagFamilyForWrite, string) {
+	families := make([]*modelv1.TagFamilyForWrite, len(c.families))
+	for i, tags := range c.families {
+		values := make([]*modelv1.TagValue, len(tags))
+		for j, t := range tags {
+			var v *otlpcommonv1.AnyValue
+			if t.source != "" {
+				v = logSourceValue(t.source, resourceAttrs, scope, record)
+			} else if v = attribute(record.GetAttributes(), t.name); v == nil {
+				v = attribute(resourceAttrs, t.name)
+			}
+			values[j] = anyToTagValue(v, t.typ)
+			if t.entity && values[j] == pbv1.NullTagValue {
+				return nil, t.name
+			}
+		}
+		families[i] = &modelv1.TagFamilyForWrite{Tags: values}
+	}
+	return families, ""
+}
+
+func logSourceValue(source string, resourceAttrs []*otlpcommonv1.KeyValue, scope *otlpcommonv1.InstrumentationScope,
+	record *otlplogsv1.LogRecord,
+) *otlpcommonv1.AnyValue {
+	switch source {
+	case SourceLogBody:
+		return bodyValue(record.GetBody())
+	case SourceLogSeverityText:
+		return stringValue(record.GetSeverityText())
+	case SourceLogSeverityNumber:
+		if record.GetSeverityNumber() == otlplogsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+			return nil
+		}
+		return intValue(int64(record.GetSeverityNumber()))
+	case SourceLogEventName:
+		return stringValue(record.GetEventName())
+	case SourceLogTraceID:
+		return bytesValue(record.GetTraceId())
+	case SourceLogSpanID:
+		return bytesValue(record.GetSpanId())
+	case SourceLogFlags:
+		return intValue(int64(record.GetFlags()))
+	case SourceLogTime:
+		if record.GetTimeUnixNano() == 0 {
+			return nil
+		}
+		return intValue(int64(record.GetTimeUnixNano()))
+	case SourceLogObservedTime:
+		if record.GetObservedTimeUnixNano() == 0 {
+			return nil
+		}
+		return intValue(int64(record.GetObservedTimeUnixNano()))
+	case SourceScopeName:
+		return stringValue(scope.GetName())
+	case SourceScopeVersion:
+		return stringValue(scope.GetVersion())
+	}
+	if key, ok := strings.CutPrefix(source, resourceAttributesPrefix); ok {
+		return attribute(resourceAttrs, key)
+	}
+	return attribute(record.GetAttributes(), strings.TrimPrefix(source, logAttributesPrefix))
+}
+
+// bodyValue returns the body of a log record. The structured bodies, the maps and the arrays, are in the OTLP JSON encoding.
+func bodyValue(body *otlpcommonv1.AnyValue) *otlpcommonv1.AnyValue {
+	switch body.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_KvlistValue, *otlpcommonv1.AnyValue_ArrayValue:
+		b, err := protojson.Marshal(body)
+		if err != nil {
+			return nil
+		}
+		return stringValue(string(b))
+	}
+	return body
+}
+
+func bytesValue(b []byte) *otlpcommonv1.AnyValue {
+	if len(b) == 0 {
+		return nil
+	}
+	return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_BytesValue{BytesValue: b}}
+}
@@ -0,0 +1,130 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func testStream() *databasev1.Stream {
+	return &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: "otlp", Name: "logs"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "searchable", Tags: []*databasev1.TagSpec{
+				{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "severity", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "http.method", Type: databasev1.TagType_TAG_TYPE_STRING},
+			}},
+			{Name: "data", Tags: []*databasev1.TagSpec{
+				{Name: "body", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY},
+			}},
+		},
+		Entity: &databasev1.Entity{TagNames: []string{"service_name"}},
+	}
+}
+
+func TestParseLogTagMapping(t *testing.T) {
+	mapping, err := ParseLogTagMapping([]string{"resource.attributes.service.name=service_name", "log.body=body", "log.attributes.http.method=method"})
+	require.NoError(t, err)
+	assert.Equal(t, TagMapping{"service_name": "resource.attributes.service.name", "body": "log.body", "method": "log.attributes.http.method"}, mapping)
+
+	for _, invalid := range []string{"log.body", "span.name=operation", "log.unknown=tag", "log.attributes.=tag"} {
+		_, err = ParseLogTagMapping([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLogConverter(t *testing.T) {
+	mapping, err := ParseLogTagMapping([]string{
+		"resource.attributes.service.name=service_name",
+		"log.severity_text=severity",
+		"log.trace_id=trace_id",
+		"log.body=body",
+	})
+	require.NoError(t, err)
+	ts := time.Unix(1_700_000_000, 123_456_789)
+	record := &otlplogsv1.LogRecord{
+		TimeUnixNano: uint64(ts.UnixNano()),
+		SeverityText: "ERROR",
+		TraceId:      []byte{0x5b, 0x8e, 0xfb, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		Body:         &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: "connection refused"}},
+		Attributes:   []*otlpcommonv1.KeyValue{strAttr("http.method", "GET")},
+	}
+	structured := &otlplogsv1.LogRecord{
+		ObservedTimeUnixNano: uint64(ts.UnixNano()),
+		Body: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_KvlistValue{KvlistValue: &otlpcommonv1.KeyValueList{
+			Values: []*otlpcommonv1.KeyValue{intAttr("code", 7)},
+		}}},
+	}
+	newRequest := func() *collectorlogsv1.ExportLogsServiceRequest {
+		return &collectorlogsv1.ExportLogsServiceRequest{ResourceLogs: []*otlplogsv1.ResourceLogs{
+			{
+				Resource: &otlpresourcev1.Resource{Attributes: []*otlpcommonv1.KeyValue{strAttr("service.name", "users")}},
+				ScopeLogs: []*otlplogsv1.ScopeLogs{{LogRecords: []*otlplogsv1.LogRecord{
+					proto.Clone(record).(*otlplogsv1.LogRecord), proto.Clone(structured).(*otlplogsv1.LogRecord), {SeverityText: "INFO"},
+				}}},
+			},
+			{ScopeLogs: []*otlplogsv1.ScopeLogs{{LogRecords: []*otlplogsv1.LogRecord{{TimeUnixNano: 1}}}}},
+		}}
+	}
+
+	converter := NewLogConverter(testStream(), mapping)
+	result := converter.Convert(newRequest())
+	assert.Equal(t, int64(2), result.Rejected)
+	assert.Contains(t, result.RejectReason, "absent time")
+	require.Len(t, result.Requests, 2)
+
+	w := result.Requests[0]
+	assert.Equal(t, "logs", w.GetMetadata().GetName())
+	assert.Equal(t, uint64(1), w.GetMessageId())
+	assert.True(t, w.GetElement().GetTimestamp().AsTime().Equal(ts.Truncate(time.Millisecond)))
+	families := w.GetElement().GetTagFamilies()
+	require.Len(t, families, 2)
+	searchable := families[0].GetTags()
+	assert.Equal(t, "users", searchable[0].GetStr().GetValue())
+	assert.Equal(t, "ERROR", searchable[1].GetStr().GetValue())
+	assert.Equal(t, "5b8efbf0000000000000000000000001", searchable[2].GetStr().GetValue())
+	assert.Equal(t, "GET", searchable[3].GetStr().GetValue())
+	assert.Equal(t, []byte("connection refused"), families[1].GetTags()[0].GetBinaryData())
+
+	second := result.Requests[1]
+	assert.Nil(t, second.GetMetadata())
+	assert.Equal(t, pbv1.NullTagValue, second.GetElement().GetTagFamilies()[0].GetTags()[1])
+	assert.JSONEq(t, `{"kvlistValue":{"values":[{"key":"code","value":{"intValue":"7"}}]}}`,
+		string(second.GetElement().GetTagFamilies()[1].GetTags()[0].GetBinaryData()))
+	assert.NotEqual(t, w.GetElement().GetElementId(), second.GetElement().GetElementId())
+
+	retried := converter.Convert(newRequest())
+	require.Len(t, retried.Requests, 2)
+	assert.Equal(t, w.GetElement().GetElementId(), retried.Requests[0].GetElement().GetElementId())
+	assert.Equal(t, second.GetElement().GetElementId(), retried.Requests[1].GetElement().GetElementId())
+}
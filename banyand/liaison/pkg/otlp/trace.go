@@ -0,0 +1,413 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package otlp converts the OpenTelemetry Protocol (OTLP) payloads into BanyanDB writes.
+package otlp
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	resourceAttributesPrefix = "resource.attributes."
+	spanAttributesPrefix     = "span.attributes."
+)
+
+// The sources of the tag values besides the attributes.
+const (
+	SourceSpanName         = "span.name"
+	SourceSpanKind         = "span.kind"
+	SourceSpanStatusCode   = "span.status_code"
+	SourceSpanStatusMsg    = "span.status_message"
+	SourceSpanParentSpanID = "span.parent_span_id"
+	SourceSpanDuration     = "span.duration"
+	SourceSpanEndTime      = "span.end_time"
+	SourceScopeName        = "scope.name"
+	SourceScopeVersion     = "scope.version"
+)
+
+var fieldSources = map[string]struct{}{
+	SourceSpanName:         {},
+	SourceSpanKind:         {},
+	SourceSpanStatusCode:   {},
+	SourceSpanStatusMsg:    {},
+	SourceSpanParentSpanID: {},
+	SourceSpanDuration:     {},
+	SourceSpanEndTime:      {},
+	SourceScopeName:        {},
+	SourceScopeVersion:     {},
+}
+
+// TagMapping maps the tag names to the sources of their values in the spans.
+type TagMapping map[string]string
+
+// ParseTagMapping parses the mapping rules in the form of "source=tag". A source is a span field, such as "span.name",
+// a resource attribute, such as "resource.attributes.service.name", or a span attribute, such as "span.attributes.http.method".
+func ParseTagMapping(rules []string) (TagMapping, error) {
+	return parseTagMapping(rules, fieldSources, resourceAttributesPrefix, spanAttributesPrefix)
+}
+
+func parseTagMapping(rules []string, fields map[string]struct{}, attributePrefixes ...string) (TagMapping, error) {
+	mapping := make(TagMapping, len(rules))
+	for _, r := range rules {
+		i := strings.LastIndex(r, "=")
+		if i <= 0 || i == len(r)-1 {
+			return nil, errors.Errorf("invalid tag mapping %q, it should be in the form of source=tag", r)
+		}
+		source, tag := r[:i], r[i+1:]
+		_, valid := fields[source]
+		for _, prefix := range attributePrefixes {
+			valid = valid || strings.HasPrefix(source, prefix) && len(source) > len(prefix)
+		}
+		if !valid {
+			return nil, errors.Errorf("unknown source %q of the tag mapping %q", source, r)
+		}
+		mapping[tag] = source
+	}
+	return mapping, nil
+}
+
+// ConvertResult holds the write requests converted from the spans, and the spans rejected.
+type ConvertResult struct {
+	RejectReason string
+	Requests     []*tracev1.WriteRequest
+	Rejected     int64
+}
+
+type tagKind int
+
+const (
+	tagKindSource tagKind = iota
+	tagKindTraceID
+	tagKindSpanID
+	tagKindTimestamp
+	tagKindSameName
+)
+
+type tagSource struct {
+	source string
+	kind   tagKind
+	typ    databasev1.TagType
+}
+
+// TraceConverter converts the OTLP spans into the writes of a trace.
+type TraceConverter struct {
+	trace *databasev1.Trace
+	tags  []tagSource
+}
+
+// NewTraceConverter returns a TraceConverter of the trace. The trace ID, span ID and timestamp tags are filled by the spans,
+// the mapped tags by their sources, and the other tags by the span or resource attributes of the same name.
+func NewTraceConverter(trace *databasev1.Trace, mapping TagMapping) *TraceConverter {
+	c := &TraceConverter{trace: trace, tags: make([]tagSource, len(trace.GetTags()))}
+	for i, t := range trace.GetTags() {
+		ts := tagSource{typ: t.GetType(), kind: tagKindSameName}
+		switch t.GetName() {
+		case trace.GetTraceIdTagName():
+			ts.kind = tagKindTraceID
+		case trace.GetSpanIdTagName():
+			ts.kind = tagKindSpanID
+		case trace.GetTimestampTagName():
+			ts.kind = tagKindTimestamp
+		default:
+			if source, ok := mapping[t.GetName()]; ok {
+				ts.kind, ts.source = tagKindSource, source
+			}
+		}
+		c.tags[i] = ts
+	}
+	return c
+}
+
+// Convert converts the spans of the request. A span is rejected if its trace ID, span ID or start time is absent.
+func (c *TraceConverter) Convert(req *collectortracev1.ExportTraceServiceRequest) ConvertResult {
+	var result ConvertResult
+	metadata := proto.Clone(c.trace.GetMetadata()).(*commonv1.Metadata)
+	var version uint64
+	for _, rs := range req.GetResourceSpans() {
+		resourceAttrs := rs.GetResource().GetAttributes()
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				if reason := validateSpan(span); reason != "" {
+					result.Rejected++
+					if result.RejectReason == "" {
+						result.RejectReason = reason
+					}
+					continue
+				}
+				spanBytes, err := proto.Marshal(&otlptracev1.ResourceSpans{
+					Resource:  rs.GetResource(),
+					SchemaUrl: rs.GetSchemaUrl(),
+					ScopeSpans: []*otlptracev1.ScopeSpans{{
+						Scope:     ss.GetScope(),
+						SchemaUrl: ss.GetSchemaUrl(),
+						Spans:     []*otlptracev1.Span{span},
+					}},
+				})
+				if err != nil {
+					result.Rejected++
+					if result.RejectReason == "" {
+						result.RejectReason = err.Error()
+					}
+					continue
+				}
+				version++
+				result.Requests = append(result.Requests, &tracev1.WriteRequest{
+					Metadata: metadata,
+					Tags:     c.tagValues(resourceAttrs, ss.GetScope(), span),
+					Span:     spanBytes,
+					Version:  version,
+				})
+				metadata = nil
+			}
+		}
+	}
+	return result
+}
+
+func validateSpan(span *otlptracev1.Span) string {
+	switch {
+	case !validID(span.GetTraceId(), 16):
+		return "invalid trace ID " + strconv.Quote(SpanID(span.GetTraceId()))
+	case !validID(span.GetSpanId(), 8):
+		return "invalid span ID " + strconv.Quote(SpanID(span.GetSpanId()))
+	case span.GetStartTimeUnixNano() == 0:
+		return "absent start time of the span " + strconv.Quote(SpanID(span.GetSpanId()))
+	}
+	return ""
+}
+
+func validID(id []byte, size int) bool {
+	if len(id) != size {
+		return false
+	}
+	for _, b := range id {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SpanID returns the hexadecimal form of a trace or span ID, the same as the OTLP JSON encoding.
+func SpanID(id []byte) string {
+	return hex.EncodeToString(id)
+}
+
+// DecodeSpan decodes the span bytes written by the TraceConverter. They hold the span, its scope and its resource.
+func DecodeSpan(b []byte) (*otlptracev1.ResourceSpans, error) {
+	rs := &otlptracev1.ResourceSpans{}
+	if err := proto.Unmarshal(b, rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (c *TraceConverter) tagValues(resourceAttrs []*otlpcommonv1.KeyValue, scope *otlpcommonv1.InstrumentationScope,
+	span *otlptracev1.Span,
+) []*modelv1.TagValue {
+	values := make([]*modelv1.TagValue, len(c.tags))
+	for i, ts := range c.tags {
+		switch ts.kind {
+		case tagKindTraceID:
+			values[i] = idTagValue(span.GetTraceId(), ts.typ)
+		case tagKindSpanID:
+			values[i] = idTagValue(span.GetSpanId(), ts.typ)
+		case tagKindTimestamp:
+			values[i] = anyToTagValue(intValue(int64(span.GetStartTimeUnixNano())), ts.typ)
+		case tagKindSource:
+			values[i] = anyToTagValue(sourceValue(ts.source, resourceAttrs, scope, span), ts.typ)
+		default:
+			name := c.trace.GetTags()[i].GetName()
+			v := attribute(span.GetAttributes(), name)
+			if v == nil {
+				v = attribute(resourceAttrs, name)
+			}
+			values[i] = anyToTagValue(v, ts.typ)
+		}
+	}
+	return values
+}
+
+func sourceValue(source string, resourceAttrs []*otlpcommonv1.KeyValue, scope *otlpcommonv1.InstrumentationScope,
+	span *otlptracev1.Span,
+) *otlpcommonv1.AnyValue {
+	switch source {
+	case SourceSpanName:
+		return stringValue(span.GetName())
+	case SourceSpanKind:
+		return stringValue(span.GetKind().String())
+	case SourceSpanStatusCode:
+		return stringValue(span.GetStatus().GetCode().String())
+	case SourceSpanStatusMsg:
+		return stringValue(span.GetStatus().GetMessage())
+	case SourceSpanParentSpanID:
+		if len(span.GetParentSpanId()) == 0 {
+			return nil
+		}
+		return stringValue(SpanID(span.GetParentSpanId()))
+	case SourceSpanDuration:
+		return intValue(int64(span.GetEndTimeUnixNano()) - int64(span.GetStartTimeUnixNano()))
+	case SourceSpanEndTime:
+		if span.GetEndTimeUnixNano() == 0 {
+			return nil
+		}
+		return intValue(int64(span.GetEndTimeUnixNano()))
+	case SourceScopeName:
+		return stringValue(scope.GetName())
+	case SourceScopeVersion:
+		return stringValue(scope.GetVersion())
+	}
+	if key, ok := strings.CutPrefix(source, resourceAttributesPrefix); ok {
+		return attribute(resourceAttrs, key)
+	}
+	return attribute(span.GetAttributes(), strings.TrimPrefix(source, spanAttributesPrefix))
+}
+
+func attribute(attrs []*otlpcommonv1.KeyValue, key string) *otlpcommonv1.AnyValue {
+	for _, kv := range attrs {
+		if kv.GetKey() == key {
+			return kv.GetValue()
+		}
+	}
+	return nil
+}
+
+func stringValue(s string) *otlpcommonv1.AnyValue {
+	if s == "" {
+		return nil
+	}
+	return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: s}}
+}
+
+func intValue(i int64) *otlpcommonv1.AnyValue {
+	return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: i}}
+}
+
+func idTagValue(id []byte, t databasev1.TagType) *modelv1.TagValue {
+	if t == databasev1.TagType_TAG_TYPE_DATA_BINARY {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: id}}
+	}
+	return anyToTagValue(stringValue(SpanID(id)), t)
+}
+
+// anyToTagValue converts an attribute value to the type of the tag. The values which can't be converted are null.
+func anyToTagValue(v *otlpcommonv1.AnyValue, t databasev1.TagType) *modelv1.TagValue {
+	if v == nil || v.GetValue() == nil {
+		return pbv1.NullTagValue
+	}
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		if s, ok := anyToString(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: s}}}
+		}
+	case databasev1.TagType_TAG_TYPE_INT:
+		if i, ok := anyToInt(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: i}}}
+		}
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		var strs []string
+		for _, el := range anyToArray(v) {
+			if s, ok := anyToString(el); ok {
+				strs = append(strs, s)
+			}
+		}
+		if len(strs) > 0 {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: strs}}}
+		}
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		var ints []int64
+		for _, el := range anyToArray(v) {
+			if i, ok := anyToInt(el); ok {
+				ints = append(ints, i)
+			}
+		}
+		if len(ints) > 0 {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: ints}}}
+		}
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		if b := v.GetBytesValue(); b != nil {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: b}}
+		}
+		if s, ok := anyToString(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte(s)}}
+		}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		// The timestamps are in nanoseconds, and BanyanDB stores them in milliseconds.
+		if i, ok := anyToInt(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Unix(0, i).Truncate(time.Millisecond))}}
+		}
+	}
+	return pbv1.NullTagValue
+}
+
+func anyToString(v *otlpcommonv1.AnyValue) (string, bool) {
+	switch val := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_StringValue:
+		return val.StringValue, true
+	case *otlpcommonv1.AnyValue_IntValue:
+		return strconv.FormatInt(val.IntValue, 10), true
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(val.DoubleValue, 'f', -1, 64), true
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(val.BoolValue), true
+	case *otlpcommonv1.AnyValue_BytesValue:
+		return hex.EncodeToString(val.BytesValue), true
+	}
+	return "", false
+}
+
+func anyToInt(v *otlpcommonv1.AnyValue) (int64, bool) {
+	switch val := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_IntValue:
+		return val.IntValue, true
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return int64(val.DoubleValue), true
+	case *otlpcommonv1.AnyValue_BoolValue:
+		if val.BoolValue {
+			return 1, true
+		}
+		return 0, true
+	case *otlpcommonv1.AnyValue_StringValue:
+		i, err := strconv.ParseInt(val.StringValue, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func anyToArray(v *otlpcommonv1.AnyValue) []*otlpcommonv1.AnyValue {
+	if arr := v.GetArrayValue(); arr != nil {
+		return arr.GetValues()
+	}
+	return []*otlpcommonv1.AnyValue{v}
+}
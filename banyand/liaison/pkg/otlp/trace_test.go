@@ -0,0 +1,137 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func strAttr(key, value string) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: key, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: value}}}
+}
+
+func intAttr(key string, value int64) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: key, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: value}}}
+}
+
+func testTrace() *databasev1.Trace {
+	return &databasev1.Trace{
+		Metadata: &commonv1.Metadata{Group: "otlp", Name: "spans"},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			{Name: "service_name", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "operation", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "http.status_code", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "absent", Type: databasev1.TagType_TAG_TYPE_STRING},
+		},
+		TraceIdTagName:   "trace_id",
+		SpanIdTagName:    "span_id",
+		TimestampTagName: "timestamp",
+	}
+}
+
+func TestParseTagMapping(t *testing.T) {
+	mapping, err := ParseTagMapping([]string{"resource.attributes.service.name=service_name", "span.name=operation"})
+	require.NoError(t, err)
+	assert.Equal(t, TagMapping{"service_name": "resource.attributes.service.name", "operation": "span.name"}, mapping)
+
+	for _, invalid := range []string{"span.name", "=tag", "span.name=", "span.unknown=tag", "resource.attributes.=tag"} {
+		_, err = ParseTagMapping([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTraceConverter(t *testing.T) {
+	mapping, err := ParseTagMapping([]string{
+		"resource.attributes.service.name=service_name",
+		"span.name=operation",
+		"span.duration=duration",
+	})
+	require.NoError(t, err)
+	start := time.Unix(1_700_000_000, 123_456_789)
+	span := &otlptracev1.Span{
+		TraceId:           []byte{0x5b, 0x8e, 0xfb, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		SpanId:            []byte{0xeb, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02},
+		Name:              "GET /users",
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(start.Add(15 * time.Millisecond).UnixNano()),
+		Attributes:        []*otlpcommonv1.KeyValue{intAttr("http.status_code", 200)},
+	}
+	req := &collectortracev1.ExportTraceServiceRequest{ResourceSpans: []*otlptracev1.ResourceSpans{{
+		Resource: &otlpresourcev1.Resource{Attributes: []*otlpcommonv1.KeyValue{strAttr("service.name", "users")}},
+		ScopeSpans: []*otlptracev1.ScopeSpans{{
+			Scope: &otlpcommonv1.InstrumentationScope{Name: "otelhttp"},
+			Spans: []*otlptracev1.Span{span, {SpanId: span.SpanId, StartTimeUnixNano: 1}},
+		}},
+	}}}
+
+	result := NewTraceConverter(testTrace(), mapping).Convert(req)
+	assert.Equal(t, int64(1), result.Rejected)
+	assert.Contains(t, result.RejectReason, "invalid trace ID")
+	require.Len(t, result.Requests, 1)
+	w := result.Requests[0]
+	assert.Equal(t, "spans", w.GetMetadata().GetName())
+	assert.Equal(t, uint64(1), w.GetVersion())
+	tags := w.GetTags()
+	require.Len(t, tags, 8)
+	assert.Equal(t, "5b8efbf0000000000000000000000001", tags[0].GetStr().GetValue())
+	assert.Equal(t, "eb00000000000002", tags[1].GetStr().GetValue())
+	assert.True(t, tags[2].GetTimestamp().AsTime().Equal(start.Truncate(time.Millisecond)))
+	assert.Equal(t, "users", tags[3].GetStr().GetValue())
+	assert.Equal(t, "GET /users", tags[4].GetStr().GetValue())
+	assert.Equal(t, (15 * time.Millisecond).Nanoseconds(), tags[5].GetInt().GetValue())
+	assert.Equal(t, int64(200), tags[6].GetInt().GetValue())
+	assert.Equal(t, pbv1.NullTagValue, tags[7])
+
+	rs, err := DecodeSpan(w.GetSpan())
+	require.NoError(t, err)
+	assert.Equal(t, "users", rs.GetResource().GetAttributes()[0].GetValue().GetStringValue())
+	assert.Equal(t, "otelhttp", rs.GetScopeSpans()[0].GetScope().GetName())
+	assert.True(t, proto.Equal(span, rs.GetScopeSpans()[0].GetSpans()[0]))
+}
+
+func TestAnyToTagValue(t *testing.T) {
+	str := &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: "42"}}
+	assert.Equal(t, int64(42), anyToTagValue(str, databasev1.TagType_TAG_TYPE_INT).GetInt().GetValue())
+	assert.Equal(t, []string{"42"}, anyToTagValue(str, databasev1.TagType_TAG_TYPE_STRING_ARRAY).GetStrArray().GetValue())
+	double := &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_DoubleValue{DoubleValue: 1.5}}
+	assert.Equal(t, "1.5", anyToTagValue(double, databasev1.TagType_TAG_TYPE_STRING).GetStr().GetValue())
+	array := &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_ArrayValue{ArrayValue: &otlpcommonv1.ArrayValue{
+		Values: []*otlpcommonv1.AnyValue{str, double},
+	}}}
+	assert.Equal(t, []int64{42, 1}, anyToTagValue(array, databasev1.TagType_TAG_TYPE_INT_ARRAY).GetIntArray().GetValue())
+	text := &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: "text"}}
+	assert.Equal(t, pbv1.NullTagValue, anyToTagValue(text, databasev1.TagType_TAG_TYPE_INT))
+	assert.Equal(t, pbv1.NullTagValue, anyToTagValue(nil, databasev1.TagType_TAG_TYPE_STRING))
+}
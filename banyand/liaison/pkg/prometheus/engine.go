@@ -0,0 +1,595 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxPointsPerSeries bounds the steps of a range query, the same as Prometheus.
+const maxPointsPerSeries = 11000
+
+// Labels are the labels of a series sorted by their names.
+type Labels []Label
+
+// NewLabels returns the labels of the map sorted by their names.
+func NewLabels(m map[string]string) Labels {
+	ls := make(Labels, 0, len(m))
+	for name, value := range m {
+		ls = append(ls, Label{Name: name, Value: value})
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].Name < ls[j].Name })
+	return ls
+}
+
+// Get returns the value of the label, or empty if it's absent.
+func (ls Labels) Get(name string) string {
+	for _, l := range ls {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// Map returns the labels as a map.
+func (ls Labels) Map() map[string]string {
+	m := make(map[string]string, len(ls))
+	for _, l := range ls {
+		m[l.Name] = l.Value
+	}
+	return m
+}
+
+func (ls Labels) signature() string {
+	var sb strings.Builder
+	for _, l := range ls {
+		sb.WriteString(l.Name)
+		sb.WriteByte(0xff)
+		sb.WriteString(l.Value)
+		sb.WriteByte(0xff)
+	}
+	return sb.String()
+}
+
+func (ls Labels) without(names ...string) Labels {
+	result := make(Labels, 0, len(ls))
+	for _, l := range ls {
+		drop := false
+		for _, n := range names {
+			if l.Name == n {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func (ls Labels) only(names []string) Labels {
+	result := make(Labels, 0, len(names))
+	for _, l := range ls {
+		for _, n := range names {
+			if l.Name == n {
+				result = append(result, l)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Series is a series of samples sorted by their timestamps.
+type Series struct {
+	Labels  Labels
+	Samples []Sample
+}
+
+// VectorElement is the value of a series at the evaluation time.
+type VectorElement struct {
+	Labels Labels
+	Sample Sample
+}
+
+// Querier selects the series of a selector between start and end, in milliseconds and inclusive.
+type Querier interface {
+	Select(ctx context.Context, selector *VectorSelector, start, end int64) ([]Series, error)
+}
+
+// Result is the result of a query. It's a scalar, a vector or a matrix by its Type.
+type Result struct {
+	Type   ValueType
+	Vector []VectorElement
+	Matrix []Series
+	Scalar Sample
+}
+
+// Engine evaluates the PromQL expressions over the series selected by the Querier.
+type Engine struct {
+	querier       Querier
+	lookbackDelta time.Duration
+}
+
+// NewEngine returns an Engine. An instant vector selector picks the latest sample within the lookbackDelta.
+func NewEngine(querier Querier, lookbackDelta time.Duration) *Engine {
+	return &Engine{querier: querier, lookbackDelta: lookbackDelta}
+}
+
+// InstantQuery evaluates the expression at ts.
+func (e *Engine) InstantQuery(ctx context.Context, expr Expr, ts time.Time) (*Result, error) {
+	t := ts.UnixMilli()
+	ev, err := e.newEvaluator(ctx, expr, t, t)
+	if err != nil {
+		return nil, err
+	}
+	v, err := ev.eval(expr, t)
+	if err != nil {
+		return nil, err
+	}
+	switch val := v.(type) {
+	case float64:
+		return &Result{Type: ValueTypeScalar, Scalar: Sample{Timestamp: t, Value: val}}, nil
+	case []VectorElement:
+		return &Result{Type: ValueTypeVector, Vector: val}, nil
+	default:
+		return &Result{Type: ValueTypeMatrix, Matrix: val.([]Series)}, nil
+	}
+}
+
+// RangeQuery evaluates the expression at every step from start to end, and returns a matrix.
+func (e *Engine) RangeQuery(ctx context.Context, expr Expr, start, end time.Time, step time.Duration) (*Result, error) {
+	if step <= 0 {
+		return nil, errors.Wrap(ErrBadQuery, "the step should be positive")
+	}
+	if end.Before(start) {
+		return nil, errors.Wrap(ErrBadQuery, "the end is before the start")
+	}
+	if end.Sub(start)/step >= maxPointsPerSeries {
+		return nil, errors.Wrapf(ErrBadQuery, "exceeded the maximum resolution of %d points per series", maxPointsPerSeries)
+	}
+	if expr.Type() == ValueTypeMatrix {
+		return nil, errors.Wrap(ErrBadQuery, "a range query requires a scalar or vector expression")
+	}
+	startMs, endMs, stepMs := start.UnixMilli(), end.UnixMilli(), step.Milliseconds()
+	ev, err := e.newEvaluator(ctx, expr, startMs, endMs)
+	if err != nil {
+		return nil, err
+	}
+	var matrix []Series
+	index := make(map[string]int)
+	for t := startMs; t <= endMs; t += stepMs {
+		v, evalErr := ev.eval(expr, t)
+		if evalErr != nil {
+			return nil, evalErr
+		}
+		var vector []VectorElement
+		switch val := v.(type) {
+		case float64:
+			vector = []VectorElement{{Sample: Sample{Timestamp: t, Value: val}}}
+		case []VectorElement:
+			vector = val
+		}
+		for _, el := range vector {
+			sig := el.Labels.signature()
+			i, ok := index[sig]
+			if !ok {
+				i = len(matrix)
+				index[sig] = i
+				matrix = append(matrix, Series{Labels: el.Labels})
+			}
+			matrix[i].Samples = append(matrix[i].Samples, Sample{Timestamp: t, Value: el.Sample.Value})
+		}
+	}
+	sort.Slice(matrix, func(i, j int) bool { return matrix[i].Labels.signature() < matrix[j].Labels.signature() })
+	return &Result{Type: ValueTypeMatrix, Matrix: matrix}, nil
+}
+
+// Series returns the series of the selector between start and end.
+func (e *Engine) Series(ctx context.Context, selector *VectorSelector, start, end time.Time) ([]Series, error) {
+	return e.querier.Select(ctx, selector, start.UnixMilli(), end.UnixMilli())
+}
+
+type evaluator struct {
+	series        map[*VectorSelector][]Series
+	lookbackDelta int64
+}
+
+// newEvaluator selects the series of all the selectors in the expression for the evaluations from start to end.
+func (e *Engine) newEvaluator(ctx context.Context, expr Expr, start, end int64) (*evaluator, error) {
+	ev := &evaluator{series: make(map[*VectorSelector][]Series), lookbackDelta: e.lookbackDelta.Milliseconds()}
+	var walk func(Expr) error
+	walk = func(expr Expr) error {
+		switch n := expr.(type) {
+		case *VectorSelector:
+			series, err := e.querier.Select(ctx, n, start-ev.lookbackDelta+1, end)
+			ev.series[n] = series
+			return err
+		case *MatrixSelector:
+			series, err := e.querier.Select(ctx, n.VectorSelector, start-n.Range.Milliseconds()+1, end)
+			ev.series[n.VectorSelector] = series
+			return err
+		case *Call:
+			for _, arg := range n.Args {
+				if err := walk(arg); err != nil {
+					return err
+				}
+			}
+		case *AggregateExpr:
+			if n.Param != nil {
+				if err := walk(n.Param); err != nil {
+					return err
+				}
+			}
+			return walk(n.Expr)
+		case *BinaryExpr:
+			if err := walk(n.LHS); err != nil {
+				return err
+			}
+			return walk(n.RHS)
+		}
+		return nil
+	}
+	if err := walk(expr); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// eval evaluates the expression at t. The result is a float64, a []VectorElement or a []Series.
+func (ev *evaluator) eval(expr Expr, t int64) (any, error) {
+	switch n := expr.(type) {
+	case *NumberLiteral:
+		return n.Val, nil
+	case *VectorSelector:
+		return ev.evalVectorSelector(n, t), nil
+	case *MatrixSelector:
+		return ev.evalMatrixSelector(n, t), nil
+	case *Call:
+		return ev.evalCall(n, t)
+	case *AggregateExpr:
+		return ev.evalAggregate(n, t)
+	case *BinaryExpr:
+		return ev.evalBinary(n, t)
+	}
+	return nil, errors.Wrapf(ErrBadQuery, "unsupported expression %T", expr)
+}
+
+func (ev *evaluator) evalVectorSelector(vs *VectorSelector, t int64) []VectorElement {
+	var vector []VectorElement
+	for _, s := range ev.series[vs] {
+		i := sort.Search(len(s.Samples), func(i int) bool { return s.Samples[i].Timestamp > t }) - 1
+		if i < 0 || s.Samples[i].Timestamp <= t-ev.lookbackDelta {
+			continue
+		}
+		vector = append(vector, VectorElement{Labels: s.Labels, Sample: Sample{Timestamp: t, Value: s.Samples[i].Value}})
+	}
+	return vector
+}
+
+func (ev *evaluator) evalMatrixSelector(ms *MatrixSelector, t int64) []Series {
+	var matrix []Series
+	from := t - ms.Range.Milliseconds()
+	for _, s := range ev.series[ms.VectorSelector] {
+		lo := sort.Search(len(s.Samples), func(i int) bool { return s.Samples[i].Timestamp > from })
+		hi := sort.Search(len(s.Samples), func(i int) bool { return s.Samples[i].Timestamp > t })
+		if lo >= hi {
+			continue
+		}
+		matrix = append(matrix, Series{Labels: s.Labels, Samples: s.Samples[lo:hi]})
+	}
+	return matrix
+}
+
+func (ev *evaluator) evalCall(call *Call, t int64) (any, error) {
+	switch call.Func {
+	case "rate", "increase":
+		ms := call.Args[0].(*MatrixSelector)
+		v, err := ev.eval(ms, t)
+		if err != nil {
+			return nil, err
+		}
+		var vector []VectorElement
+		for _, s := range v.([]Series) {
+			value, ok := extrapolatedRate(s.Samples, t-ms.Range.Milliseconds(), t, ms.Range, call.Func == "rate")
+			if !ok {
+				continue
+			}
+			vector = append(vector, VectorElement{Labels: s.Labels.without(MetricNameLabel), Sample: Sample{Timestamp: t, Value: value}})
+		}
+		return vector, nil
+	default:
+		phi, err := ev.eval(call.Args[0], t)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ev.eval(call.Args[1], t)
+		if err != nil {
+			return nil, err
+		}
+		return histogramQuantile(phi.(float64), v.([]VectorElement), t), nil
+	}
+}
+
+// extrapolatedRate calculates the increase, or the per-second rate, of a counter over the range,
+// extrapolating the samples to the boundaries of the range the same as Prometheus.
+func extrapolatedRate(samples []Sample, rangeStart, rangeEnd int64, rng time.Duration, isRate bool) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	result := last.Value - first.Value
+	prev := first.Value
+	for _, s := range samples[1:] {
+		if s.Value < prev {
+			result += prev
+		}
+		prev = s.Value
+	}
+	durationToStart := float64(first.Timestamp-rangeStart) / 1000
+	durationToEnd := float64(rangeEnd-last.Timestamp) / 1000
+	sampledInterval := float64(last.Timestamp-first.Timestamp) / 1000
+	averageDurationBetweenSamples := sampledInterval / float64(len(samples)-1)
+	extrapolationThreshold := averageDurationBetweenSamples * 1.1
+	if durationToStart >= extrapolationThreshold {
+		durationToStart = averageDurationBetweenSamples / 2
+	}
+	if result > 0 && first.Value >= 0 {
+		// The counter can't be extrapolated below zero.
+		if durationToZero := sampledInterval * (first.Value / result); durationToZero < durationToStart {
+			durationToStart = durationToZero
+		}
+	}
+	if durationToEnd >= extrapolationThreshold {
+		durationToEnd = averageDurationBetweenSamples / 2
+	}
+	factor := (sampledInterval + durationToStart + durationToEnd) / sampledInterval
+	if isRate {
+		factor /= rng.Seconds()
+	}
+	return result * factor, true
+}
+
+type bucket struct {
+	upperBound float64
+	count      float64
+}
+
+// histogramQuantile calculates the phi-quantile of the cumulative "le" buckets of each histogram, the same as Prometheus.
+func histogramQuantile(phi float64, vector []VectorElement, t int64) []VectorElement {
+	type histogram struct {
+		labels  Labels
+		buckets []bucket
+	}
+	var histograms []*histogram
+	index := make(map[string]*histogram)
+	for _, el := range vector {
+		upperBound, err := strconv.ParseFloat(el.Labels.Get("le"), 64)
+		if err != nil {
+			continue
+		}
+		labels := el.Labels.without(MetricNameLabel, "le")
+		sig := labels.signature()
+		h, ok := index[sig]
+		if !ok {
+			h = &histogram{labels: labels}
+			index[sig] = h
+			histograms = append(histograms, h)
+		}
+		h.buckets = append(h.buckets, bucket{upperBound: upperBound, count: el.Sample.Value})
+	}
+	result := make([]VectorElement, 0, len(histograms))
+	for _, h := range histograms {
+		result = append(result, VectorElement{Labels: h.labels, Sample: Sample{Timestamp: t, Value: bucketQuantile(phi, h.buckets)}})
+	}
+	return result
+}
+
+func bucketQuantile(phi float64, buckets []bucket) float64 {
+	if math.IsNaN(phi) {
+		return math.NaN()
+	}
+	if phi < 0 {
+		return math.Inf(-1)
+	}
+	if phi > 1 {
+		return math.Inf(1)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upperBound < buckets[j].upperBound })
+	if len(buckets) < 2 || !math.IsInf(buckets[len(buckets)-1].upperBound, 1) {
+		return math.NaN()
+	}
+	// The counts of the buckets are scraped at different times, so they might not be monotonic.
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].count < buckets[i-1].count {
+			buckets[i].count = buckets[i-1].count
+		}
+	}
+	observations := buckets[len(buckets)-1].count
+	if observations == 0 {
+		return math.NaN()
+	}
+	rank := phi * observations
+	b := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].count >= rank })
+	if b == len(buckets)-1 {
+		return buckets[len(buckets)-2].upperBound
+	}
+	if b == 0 && buckets[0].upperBound <= 0 {
+		return buckets[0].upperBound
+	}
+	bucketStart, bucketEnd, count := 0.0, buckets[b].upperBound, buckets[b].count
+	if b > 0 {
+		bucketStart = buckets[b-1].upperBound
+		count -= buckets[b-1].count
+		rank -= buckets[b-1].count
+	}
+	return bucketStart + (bucketEnd-bucketStart)*(rank/count)
+}
+
+func (ev *evaluator) evalAggregate(agg *AggregateExpr, t int64) (any, error) {
+	v, err := ev.eval(agg.Expr, t)
+	if err != nil {
+		return nil, err
+	}
+	vector := v.([]VectorElement)
+	k := 0
+	if agg.Param != nil {
+		param, paramErr := ev.eval(agg.Param, t)
+		if paramErr != nil {
+			return nil, paramErr
+		}
+		k = int(param.(float64))
+	}
+	type group struct {
+		labels   Labels
+		elements []VectorElement
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	for _, el := range vector {
+		var labels Labels
+		if agg.Without {
+			labels = el.Labels.without(append([]string{MetricNameLabel}, agg.Grouping...)...)
+		} else {
+			labels = el.Labels.only(agg.Grouping)
+		}
+		sig := labels.signature()
+		g, ok := index[sig]
+		if !ok {
+			g = &group{labels: labels}
+			index[sig] = g
+			groups = append(groups, g)
+		}
+		g.elements = append(g.elements, el)
+	}
+	var result []VectorElement
+	for _, g := range groups {
+		switch agg.Op {
+		case "topk", "bottomk":
+			elements := g.elements
+			sort.SliceStable(elements, func(i, j int) bool {
+				a, b := elements[i].Sample.Value, elements[j].Sample.Value
+				if math.IsNaN(a) || math.IsNaN(b) {
+					return !math.IsNaN(a)
+				}
+				if agg.Op == "topk" {
+					return a > b
+				}
+				return a < b
+			})
+			if k < len(elements) {
+				elements = elements[:max(k, 0)]
+			}
+			result = append(result, elements...)
+		default:
+			result = append(result, VectorElement{Labels: g.labels, Sample: Sample{Timestamp: t, Value: aggregate(agg.Op, g.elements)}})
+		}
+	}
+	return result, nil
+}
+
+func aggregate(op string, elements []VectorElement) float64 {
+	switch op {
+	case "count":
+		return float64(len(elements))
+	case "max", "min":
+		result := math.NaN()
+		for _, el := range elements {
+			v := el.Sample.Value
+			if math.IsNaN(result) || op == "max" && v > result || op == "min" && v < result {
+				result = v
+			}
+		}
+		return result
+	default:
+		sum := 0.0
+		for _, el := range elements {
+			sum += el.Sample.Value
+		}
+		if op == "avg" {
+			return sum / float64(len(elements))
+		}
+		return sum
+	}
+}
+
+func (ev *evaluator) evalBinary(be *BinaryExpr, t int64) (any, error) {
+	lhs, err := ev.eval(be.LHS, t)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := ev.eval(be.RHS, t)
+	if err != nil {
+		return nil, err
+	}
+	lScalar, lIsScalar := lhs.(float64)
+	rScalar, rIsScalar := rhs.(float64)
+	switch {
+	case lIsScalar && rIsScalar:
+		return arithmetic(be.Op, lScalar, rScalar), nil
+	case lIsScalar:
+		return mapVector(rhs.([]VectorElement), func(v float64) float64 { return arithmetic(be.Op, lScalar, v) }), nil
+	case rIsScalar:
+		return mapVector(lhs.([]VectorElement), func(v float64) float64 { return arithmetic(be.Op, v, rScalar) }), nil
+	}
+	// The elements of the vectors with the same labels, except the metric name, are matched one-to-one.
+	rIndex := make(map[string]VectorElement)
+	for _, el := range rhs.([]VectorElement) {
+		rIndex[el.Labels.without(MetricNameLabel).signature()] = el
+	}
+	var result []VectorElement
+	for _, el := range lhs.([]VectorElement) {
+		labels := el.Labels.without(MetricNameLabel)
+		r, ok := rIndex[labels.signature()]
+		if !ok {
+			continue
+		}
+		result = append(result, VectorElement{Labels: labels, Sample: Sample{Timestamp: t, Value: arithmetic(be.Op, el.Sample.Value, r.Sample.Value)}})
+	}
+	return result, nil
+}
+
+func mapVector(vector []VectorElement, fn func(float64) float64) []VectorElement {
+	result := make([]VectorElement, len(vector))
+	for i, el := range vector {
+		result[i] = VectorElement{Labels: el.Labels.without(MetricNameLabel), Sample: Sample{Timestamp: el.Sample.Timestamp, Value: fn(el.Sample.Value)}}
+	}
+	return result
+}
+
+func arithmetic(op string, a, b float64) float64 {
+	switch op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	default:
+		return a / b
+	}
+}
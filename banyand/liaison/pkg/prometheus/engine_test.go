@@ -0,0 +1,176 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQuerier struct {
+	series map[string][]Series
+}
+
+func (f *fakeQuerier) Select(_ context.Context, selector *VectorSelector, start, end int64) ([]Series, error) {
+	var result []Series
+	for _, s := range f.series[selector.Name] {
+		if !matchAll(selector.Matchers, s.Labels) {
+			continue
+		}
+		var samples []Sample
+		for _, sample := range s.Samples {
+			if sample.Timestamp >= start && sample.Timestamp <= end {
+				samples = append(samples, sample)
+			}
+		}
+		if len(samples) > 0 {
+			result = append(result, Series{Labels: s.Labels, Samples: samples})
+		}
+	}
+	return result, nil
+}
+
+var testStart = time.Unix(1_700_000_000, 0)
+
+// counter returns a series scraped every 15 seconds for 10 minutes, increasing by perSecond.
+func counter(labels map[string]string, perSecond float64) Series {
+	s := Series{Labels: NewLabels(labels)}
+	for i := 0; i <= 40; i++ {
+		s.Samples = append(s.Samples, Sample{
+			Timestamp: testStart.Add(time.Duration(i) * 15 * time.Second).UnixMilli(),
+			Value:     float64(i) * 15 * perSecond,
+		})
+	}
+	return s
+}
+
+func newTestEngine() *Engine {
+	gauge := func(le string, v float64) Series {
+		return Series{
+			Labels:  NewLabels(map[string]string{MetricNameLabel: "latency_bucket", "le": le}),
+			Samples: []Sample{{Timestamp: testStart.Add(10 * time.Minute).UnixMilli(), Value: v}},
+		}
+	}
+	return NewEngine(&fakeQuerier{series: map[string][]Series{
+		"http_requests_total": {
+			counter(map[string]string{MetricNameLabel: "http_requests_total", "job": "api", "instance": "a"}, 1),
+			counter(map[string]string{MetricNameLabel: "http_requests_total", "job": "api", "instance": "b"}, 2),
+			counter(map[string]string{MetricNameLabel: "http_requests_total", "job": "db", "instance": "c"}, 1),
+		},
+		"latency_bucket": {gauge("0.1", 10), gauge("0.5", 30), gauge("+Inf", 40)},
+	}}, 5*time.Minute)
+}
+
+func instantQuery(t *testing.T, e *Engine, query string, ts time.Time) *Result {
+	expr, err := ParseExpr(query)
+	require.NoError(t, err)
+	result, err := e.InstantQuery(context.Background(), expr, ts)
+	require.NoError(t, err)
+	return result
+}
+
+func vectorValues(result *Result, label string) map[string]float64 {
+	values := make(map[string]float64, len(result.Vector))
+	for _, el := range result.Vector {
+		values[el.Labels.Get(label)] = el.Sample.Value
+	}
+	return values
+}
+
+func TestEngineInstantQuery(t *testing.T) {
+	e := newTestEngine()
+	end := testStart.Add(10 * time.Minute)
+
+	result := instantQuery(t, e, `http_requests_total{job="api"}`, end)
+	assert.Equal(t, ValueTypeVector, result.Type)
+	assert.Equal(t, map[string]float64{"a": 600, "b": 1200}, vectorValues(result, "instance"))
+	assert.Equal(t, "http_requests_total", result.Vector[0].Labels.Get(MetricNameLabel))
+
+	result = instantQuery(t, e, `rate(http_requests_total[1m])`, end)
+	values := vectorValues(result, "instance")
+	assert.InDelta(t, 1, values["a"], 1e-9)
+	assert.InDelta(t, 2, values["b"], 1e-9)
+	assert.Empty(t, result.Vector[0].Labels.Get(MetricNameLabel))
+
+	result = instantQuery(t, e, `increase(http_requests_total{instance="a"}[5m])`, end)
+	require.Len(t, result.Vector, 1)
+	assert.InDelta(t, 300, result.Vector[0].Sample.Value, 1e-9)
+
+	result = instantQuery(t, e, `sum by (job) (rate(http_requests_total[1m]))`, end)
+	values = vectorValues(result, "job")
+	assert.InDelta(t, 3, values["api"], 1e-9)
+	assert.InDelta(t, 1, values["db"], 1e-9)
+
+	result = instantQuery(t, e, `max without (instance) (http_requests_total) * 2`, end)
+	assert.Equal(t, map[string]float64{"api": 2400, "db": 1200}, vectorValues(result, "job"))
+
+	result = instantQuery(t, e, `topk(1, rate(http_requests_total[1m]))`, end)
+	require.Len(t, result.Vector, 1)
+	assert.Equal(t, "b", result.Vector[0].Labels.Get("instance"))
+
+	result = instantQuery(t, e, `histogram_quantile(0.5, latency_bucket)`, end)
+	require.Len(t, result.Vector, 1)
+	assert.InDelta(t, 0.3, result.Vector[0].Sample.Value, 1e-9)
+	assert.Empty(t, result.Vector[0].Labels)
+
+	result = instantQuery(t, e, `1 + 2 * 3`, end)
+	assert.Equal(t, ValueTypeScalar, result.Type)
+	assert.InDelta(t, 7, result.Scalar.Value, 1e-9)
+
+	// The samples older than the lookback delta are stale.
+	result = instantQuery(t, e, `http_requests_total`, end.Add(6*time.Minute))
+	assert.Empty(t, result.Vector)
+}
+
+func TestEngineCounterReset(t *testing.T) {
+	s := counter(map[string]string{MetricNameLabel: "restarts_total"}, 1)
+	for i := 20; i < len(s.Samples); i++ {
+		s.Samples[i].Value -= 285
+	}
+	e := NewEngine(&fakeQuerier{series: map[string][]Series{"restarts_total": {s}}}, 5*time.Minute)
+	result := instantQuery(t, e, `increase(restarts_total[10m])`, testStart.Add(10*time.Minute))
+	require.Len(t, result.Vector, 1)
+	assert.InDelta(t, 600, result.Vector[0].Sample.Value, 1e-9)
+}
+
+func TestEngineRangeQuery(t *testing.T) {
+	e := newTestEngine()
+	expr, err := ParseExpr(`sum(rate(http_requests_total[1m]))`)
+	require.NoError(t, err)
+	result, err := e.RangeQuery(context.Background(), expr, testStart.Add(5*time.Minute), testStart.Add(10*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ValueTypeMatrix, result.Type)
+	require.Len(t, result.Matrix, 1)
+	require.Len(t, result.Matrix[0].Samples, 6)
+	for _, s := range result.Matrix[0].Samples {
+		assert.InDelta(t, 4, s.Value, 1e-9)
+	}
+
+	_, err = e.RangeQuery(context.Background(), expr, testStart, testStart.Add(time.Hour), 0)
+	assert.ErrorIs(t, err, ErrBadQuery)
+	_, err = e.RangeQuery(context.Background(), expr, testStart, testStart.Add(24*time.Hour), time.Second)
+	assert.ErrorIs(t, err, ErrBadQuery)
+	matrixExpr, err := ParseExpr(`http_requests_total[1m]`)
+	require.NoError(t, err)
+	_, err = e.RangeQuery(context.Background(), matrixExpr, testStart, testStart.Add(time.Hour), time.Minute)
+	assert.ErrorIs(t, err, ErrBadQuery)
+}
@@ -0,0 +1,80 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NamingRule rewrites the metric names matching Pattern with Replacement.
+// The Replacement can refer to the submatches of the Pattern, for example "$1".
+type NamingRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// ParseNamingRules parses the rules in the form of "pattern=replacement".
+func ParseNamingRules(rules []string) ([]NamingRule, error) {
+	result := make([]NamingRule, 0, len(rules))
+	for _, r := range rules {
+		idx := strings.LastIndex(r, "=")
+		if idx <= 0 {
+			return nil, errors.Errorf("naming rule %q is not in the form of pattern=replacement", r)
+		}
+		pattern, err := regexp.Compile("^(?:" + r[:idx] + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "naming rule %q has an invalid pattern", r)
+		}
+		result = append(result, NamingRule{Pattern: pattern, Replacement: r[idx+1:]})
+	}
+	return result, nil
+}
+
+// Naming maps metric names to measure names.
+type Naming struct {
+	Prefix string
+	Rules  []NamingRule
+}
+
+// MeasureName returns the measure storing the metric.
+// The first rule matching the whole metric name rewrites it, then the prefix is prepended.
+// The characters other than letters, digits and underscores are replaced with underscores.
+func (n Naming) MeasureName(metric string) string {
+	name := metric
+	for _, r := range n.Rules {
+		if r.Pattern.MatchString(name) {
+			name = r.Pattern.ReplaceAllString(name, r.Replacement)
+			break
+		}
+	}
+	return sanitizeName(n.Prefix + name)
+}
+
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
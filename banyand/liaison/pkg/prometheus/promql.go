@@ -0,0 +1,647 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadQuery indicates the PromQL expression is malformed or out of the supported subset.
+var ErrBadQuery = errors.New("bad PromQL query")
+
+// ValueType is the type of the value a PromQL expression evaluates to.
+type ValueType string
+
+// The value types of PromQL.
+const (
+	ValueTypeScalar ValueType = "scalar"
+	ValueTypeVector ValueType = "vector"
+	ValueTypeMatrix ValueType = "matrix"
+)
+
+// Expr is a node of a PromQL expression.
+type Expr interface {
+	Type() ValueType
+}
+
+// NumberLiteral is a float literal.
+type NumberLiteral struct {
+	Val float64
+}
+
+// Type implements Expr.
+func (*NumberLiteral) Type() ValueType { return ValueTypeScalar }
+
+// MatchType is the operator of a label matcher.
+type MatchType string
+
+// The label matcher operators.
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// LabelMatcher matches the value of a label. An absent label has the empty value.
+type LabelMatcher struct {
+	re    *regexp.Regexp
+	Name  string
+	Type  MatchType
+	Value string
+}
+
+// NewLabelMatcher returns a matcher, compiling the regular expression which must match the whole value.
+func NewLabelMatcher(t MatchType, name, value string) (*LabelMatcher, error) {
+	m := &LabelMatcher{Name: name, Type: t, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(ErrBadQuery, "invalid regular expression %q: %v", value, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches reports whether the value matches.
+func (m *LabelMatcher) Matches(v string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return v == m.Value
+	case MatchNotEqual:
+		return v != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(v)
+	default:
+		return !m.re.MatchString(v)
+	}
+}
+
+// VectorSelector selects the series of a metric by the label matchers.
+type VectorSelector struct {
+	Name     string
+	Matchers []*LabelMatcher
+}
+
+// Type implements Expr.
+func (*VectorSelector) Type() ValueType { return ValueTypeVector }
+
+// MatrixSelector selects the samples of the series in a range before the evaluation time.
+type MatrixSelector struct {
+	VectorSelector *VectorSelector
+	Range          time.Duration
+}
+
+// Type implements Expr.
+func (*MatrixSelector) Type() ValueType { return ValueTypeMatrix }
+
+// Call is a function call.
+type Call struct {
+	Func string
+	Args []Expr
+}
+
+// Type implements Expr.
+func (*Call) Type() ValueType { return ValueTypeVector }
+
+// AggregateExpr aggregates the elements of a vector by the grouping labels.
+type AggregateExpr struct {
+	Expr     Expr
+	Param    Expr
+	Op       string
+	Grouping []string
+	Without  bool
+}
+
+// Type implements Expr.
+func (*AggregateExpr) Type() ValueType { return ValueTypeVector }
+
+// BinaryExpr is an arithmetic operation.
+type BinaryExpr struct {
+	LHS Expr
+	RHS Expr
+	Op  string
+}
+
+// Type implements Expr.
+func (e *BinaryExpr) Type() ValueType {
+	if e.LHS.Type() == ValueTypeScalar && e.RHS.Type() == ValueTypeScalar {
+		return ValueTypeScalar
+	}
+	return ValueTypeVector
+}
+
+var (
+	aggregateOps = map[string]bool{
+		"sum": true, "avg": true, "max": true, "min": true, "count": true, "topk": true, "bottomk": true,
+	}
+	functions = map[string][]ValueType{
+		"rate":               {ValueTypeMatrix},
+		"increase":           {ValueTypeMatrix},
+		"histogram_quantile": {ValueTypeScalar, ValueTypeVector},
+	}
+)
+
+// ParseExpr parses an expression of the supported PromQL subset:
+// selectors, rate, increase, histogram_quantile, the sum, avg, max, min, count, topk and bottomk aggregations,
+// and the arithmetic operators.
+func ParseExpr(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenNumber
+	tokenString
+	tokenDuration
+	tokenPunct
+)
+
+type token struct {
+	text string
+	kind tokenKind
+	pos  int
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	inBrackets := false
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case inBrackets && c != ']':
+			j := i
+			for j < len(input) && input[j] != ']' {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenDuration, text: strings.TrimSpace(input[i:j]), pos: i})
+			i = j
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(input) && rune(input[j]) != c {
+				if input[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(input) {
+				return nil, errors.Wrapf(ErrBadQuery, "unterminated string at %d", i)
+			}
+			s, err := unquote(input[i : j+1])
+			if err != nil {
+				return nil, errors.Wrapf(ErrBadQuery, "invalid string at %d: %v", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(input) && input[i+1] >= '0' && input[i+1] <= '9':
+			j := i
+			for j < len(input) && (isIdentifierChar(rune(input[j])) || input[j] == '.' ||
+				(input[j] == '+' || input[j] == '-') && (input[j-1] == 'e' || input[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[i:j], pos: i})
+			i = j
+		case isIdentifierChar(c) || c == ':':
+			j := i
+			for j < len(input) && (isIdentifierChar(rune(input[j])) || input[j] == ':') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: input[i:j], pos: i})
+			i = j
+		default:
+			text := string(c)
+			if i+1 < len(input) && (c == '!' || c == '=') && (input[i+1] == '=' || input[i+1] == '~') {
+				text = input[i : i+2]
+			}
+			if !strings.Contains("(){}[],=+-*/", text) && text != "!=" && text != "=~" && text != "!~" {
+				return nil, errors.Wrapf(ErrBadQuery, "unexpected character %q at %d", text, i)
+			}
+			inBrackets = text == "["
+			tokens = append(tokens, token{kind: tokenPunct, text: text, pos: i})
+			i += len(text)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+func isIdentifierChar(c rune) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func unquote(s string) (string, error) {
+	switch s[0] {
+	case '`':
+		return s[1 : len(s)-1], nil
+	case '\'':
+		inner := strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`)
+		return strconv.Unquote(`"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`)
+	default:
+		return strconv.Unquote(s)
+	}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return errors.Wrapf(ErrBadQuery, "%s at position %d", fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *parser) isPunct(text string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.text == text
+}
+
+func (p *parser) expect(text string) error {
+	t := p.next()
+	if t.kind != tokenPunct || t.text != text {
+		return p.errorf(t, "expected %q but got %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseBinary(0)
+}
+
+var binaryPrecedence = map[string]int{"+": 1, "-": 1, "*": 2, "/": 2}
+
+func (p *parser) parseBinary(minPrecedence int) (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		precedence, ok := binaryPrecedence[t.text]
+		if t.kind != tokenPunct || !ok || precedence <= minPrecedence {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := p.parseBinary(precedence)
+		if err != nil {
+			return nil, err
+		}
+		for _, operand := range []Expr{lhs, rhs} {
+			if operand.Type() == ValueTypeMatrix {
+				return nil, p.errorf(t, "binary operator %q requires scalar or vector operands", t.text)
+			}
+		}
+		lhs = &BinaryExpr{Op: t.text, LHS: lhs, RHS: rhs}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	switch {
+	case p.isPunct("-"):
+		t := p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := expr.(*NumberLiteral); ok {
+			return &NumberLiteral{Val: -n.Val}, nil
+		}
+		if expr.Type() == ValueTypeMatrix {
+			return nil, p.errorf(t, "unary minus requires a scalar or vector operand")
+		}
+		return &BinaryExpr{Op: "*", LHS: &NumberLiteral{Val: -1}, RHS: expr}, nil
+	case p.isPunct("+"):
+		p.next()
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next()
+		v, err := parseNumber(t.text)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %q", t.text)
+		}
+		return &NumberLiteral{Val: v}, nil
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			p.next()
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		case "{":
+			return p.parseSelector("")
+		}
+	case tokenIdentifier:
+		lower := strings.ToLower(t.text)
+		if p.pos+1 < len(p.tokens) {
+			nextTok := p.tokens[p.pos+1]
+			isCall := nextTok.kind == tokenPunct && nextTok.text == "("
+			isGrouping := nextTok.kind == tokenIdentifier && (nextTok.text == "by" || nextTok.text == "without")
+			if aggregateOps[lower] && (isCall || isGrouping) {
+				return p.parseAggregate()
+			}
+			if isCall {
+				return p.parseCall()
+			}
+		}
+		switch lower {
+		case "inf", "nan":
+			p.next()
+			v, _ := parseNumber(lower)
+			return &NumberLiteral{Val: v}, nil
+		}
+		p.next()
+		return p.parseSelector(t.text)
+	}
+	return nil, p.errorf(t, "unexpected %q", t.text)
+}
+
+func parseNumber(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "inf":
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(v), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func (p *parser) parseSelector(name string) (Expr, error) {
+	vs := &VectorSelector{Name: name}
+	if p.isPunct("{") {
+		p.next()
+		for !p.isPunct("}") {
+			nameTok := p.next()
+			if nameTok.kind != tokenIdentifier {
+				return nil, p.errorf(nameTok, "expected a label name but got %q", nameTok.text)
+			}
+			opTok := p.next()
+			op := MatchType(opTok.text)
+			if opTok.kind != tokenPunct || (op != MatchEqual && op != MatchNotEqual && op != MatchRegexp && op != MatchNotRegexp) {
+				return nil, p.errorf(opTok, "expected a label matching operator but got %q", opTok.text)
+			}
+			valueTok := p.next()
+			if valueTok.kind != tokenString {
+				return nil, p.errorf(valueTok, "expected a string but got %q", valueTok.text)
+			}
+			m, err := NewLabelMatcher(op, nameTok.text, valueTok.text)
+			if err != nil {
+				return nil, err
+			}
+			if m.Name == MetricNameLabel {
+				if op != MatchEqual || (vs.Name != "" && vs.Name != m.Value) {
+					return nil, p.errorf(nameTok, "the metric name can only be matched by one equality")
+				}
+				vs.Name = m.Value
+			} else {
+				vs.Matchers = append(vs.Matchers, m)
+			}
+			if p.isPunct(",") {
+				p.next()
+				continue
+			}
+			if !p.isPunct("}") {
+				t := p.peek()
+				return nil, p.errorf(t, "expected \",\" or \"}\" but got %q", t.text)
+			}
+		}
+		p.next()
+	}
+	if vs.Name == "" {
+		return nil, p.errorf(p.peek(), "the selector requires a metric name")
+	}
+	if !p.isPunct("[") {
+		return vs, nil
+	}
+	p.next()
+	t := p.next()
+	if t.kind != tokenDuration {
+		return nil, p.errorf(t, "expected a range duration")
+	}
+	d, err := ParseDuration(t.text)
+	if err != nil {
+		return nil, p.errorf(t, "invalid range duration %q", t.text)
+	}
+	if err = p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &MatrixSelector{VectorSelector: vs, Range: d}, nil
+}
+
+func (p *parser) parseCall() (Expr, error) {
+	nameTok := p.next()
+	argTypes, ok := functions[nameTok.text]
+	if !ok {
+		return nil, p.errorf(nameTok, "unsupported function %q", nameTok.text)
+	}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(argTypes) {
+		return nil, p.errorf(nameTok, "%s expects %d arguments but got %d", nameTok.text, len(argTypes), len(args))
+	}
+	for i, arg := range args {
+		if arg.Type() != argTypes[i] {
+			return nil, p.errorf(nameTok, "the argument %d of %s should be a %s but got a %s", i+1, nameTok.text, argTypes[i], arg.Type())
+		}
+	}
+	return &Call{Func: nameTok.text, Args: args}, nil
+}
+
+func (p *parser) parseArgs() ([]Expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []Expr
+	for !p.isPunct(")") {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.isPunct(",") {
+			p.next()
+		} else if !p.isPunct(")") {
+			t := p.peek()
+			return nil, p.errorf(t, "expected \",\" or \")\" but got %q", t.text)
+		}
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *parser) parseAggregate() (Expr, error) {
+	opTok := p.next()
+	agg := &AggregateExpr{Op: strings.ToLower(opTok.text)}
+	grouped := false
+	if err := p.parseGrouping(agg, &grouped); err != nil {
+		return nil, err
+	}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.parseGrouping(agg, &grouped); err != nil {
+		return nil, err
+	}
+	wantArgs := 1
+	if agg.Op == "topk" || agg.Op == "bottomk" {
+		wantArgs = 2
+	}
+	if len(args) != wantArgs {
+		return nil, p.errorf(opTok, "%s expects %d arguments but got %d", agg.Op, wantArgs, len(args))
+	}
+	if wantArgs == 2 {
+		agg.Param = args[0]
+		if agg.Param.Type() != ValueTypeScalar {
+			return nil, p.errorf(opTok, "the parameter of %s should be a scalar", agg.Op)
+		}
+	}
+	agg.Expr = args[len(args)-1]
+	if agg.Expr.Type() != ValueTypeVector {
+		return nil, p.errorf(opTok, "%s expects a vector but got a %s", agg.Op, agg.Expr.Type())
+	}
+	return agg, nil
+}
+
+func (p *parser) parseGrouping(agg *AggregateExpr, grouped *bool) error {
+	t := p.peek()
+	if t.kind != tokenIdentifier || (t.text != "by" && t.text != "without") {
+		return nil
+	}
+	if *grouped {
+		return p.errorf(t, "duplicated grouping")
+	}
+	*grouped = true
+	p.next()
+	agg.Without = t.text == "without"
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.isPunct(")") {
+		l := p.next()
+		if l.kind != tokenIdentifier {
+			return p.errorf(l, "expected a label name but got %q", l.text)
+		}
+		agg.Grouping = append(agg.Grouping, l.text)
+		if p.isPunct(",") {
+			p.next()
+		}
+	}
+	p.next()
+	return nil
+}
+
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// ParseDuration parses a PromQL duration such as "5m" or "1h30m". A plain number is in seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.Wrap(ErrBadQuery, "empty duration")
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v <= 0 {
+			return 0, errors.Wrapf(ErrBadQuery, "invalid duration %q", s)
+		}
+		return time.Duration(v * float64(time.Second)), nil
+	}
+	var d time.Duration
+	for rest := s; rest != ""; {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		unit, ok := durationUnits[rest[i:j]]
+		if i == 0 || !ok {
+			return 0, errors.Wrapf(ErrBadQuery, "invalid duration %q", s)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(ErrBadQuery, "invalid duration %q", s)
+		}
+		d += time.Duration(n) * unit
+		rest = rest[j:]
+	}
+	if d <= 0 {
+		return 0, errors.Wrapf(ErrBadQuery, "duration %q should be positive", s)
+	}
+	return d, nil
+}
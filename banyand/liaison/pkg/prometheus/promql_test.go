@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpr(t *testing.T) {
+	expr, err := ParseExpr(`sum by (job) (rate(http_requests_total{code=~"5..", method!="GET"}[5m])) / 2`)
+	require.NoError(t, err)
+	div, ok := expr.(*BinaryExpr)
+	require.True(t, ok)
+	assert.Equal(t, "/", div.Op)
+	assert.Equal(t, ValueTypeVector, div.Type())
+	agg, ok := div.LHS.(*AggregateExpr)
+	require.True(t, ok)
+	assert.Equal(t, "sum", agg.Op)
+	assert.Equal(t, []string{"job"}, agg.Grouping)
+	call, ok := agg.Expr.(*Call)
+	require.True(t, ok)
+	assert.Equal(t, "rate", call.Func)
+	ms, ok := call.Args[0].(*MatrixSelector)
+	require.True(t, ok)
+	assert.Equal(t, "http_requests_total", ms.VectorSelector.Name)
+	assert.Equal(t, 5*time.Minute, ms.Range)
+	require.Len(t, ms.VectorSelector.Matchers, 2)
+	assert.True(t, ms.VectorSelector.Matchers[0].Matches("503"))
+	assert.False(t, ms.VectorSelector.Matchers[0].Matches("1503"))
+	assert.False(t, ms.VectorSelector.Matchers[1].Matches("GET"))
+
+	expr, err = ParseExpr(`topk(3, up) without (instance)`)
+	require.NoError(t, err)
+	agg, ok = expr.(*AggregateExpr)
+	require.True(t, ok)
+	assert.Equal(t, "topk", agg.Op)
+	assert.True(t, agg.Without)
+	assert.Equal(t, []string{"instance"}, agg.Grouping)
+
+	expr, err = ParseExpr(`histogram_quantile(0.9, sum by (le) (rate(latency_bucket[1h30m])))`)
+	require.NoError(t, err)
+	assert.Equal(t, ValueTypeVector, expr.Type())
+
+	expr, err = ParseExpr(`-1 + 2 * 3`)
+	require.NoError(t, err)
+	assert.Equal(t, ValueTypeScalar, expr.Type())
+
+	for _, invalid := range []string{
+		``,
+		`up{`,
+		`rate(up)`,
+		`up[5x]`,
+		`unknown(up)`,
+		`{job="node"}`,
+		`sum(up) by (`,
+		`up{job=~"("}`,
+	} {
+		_, err = ParseExpr(invalid)
+		assert.Truef(t, errors.Is(err, ErrBadQuery), "%q: %v", invalid, err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"5m":    5 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"250ms": 250 * time.Millisecond,
+		"1d":    24 * time.Hour,
+		"15":    15 * time.Second,
+		"0.5":   500 * time.Millisecond,
+	} {
+		d, err := ParseDuration(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, d, s)
+	}
+	for _, invalid := range []string{"", "m5", "5x", "-1", "0"} {
+		_, err := ParseDuration(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,403 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// FieldLabel is the pseudo label selecting the field holding the sample values, for example `{__field__="total"}`.
+const FieldLabel = "__field__"
+
+// ErrTooManySamples means a selector selects more samples than the limit of the querier.
+var ErrTooManySamples = errors.New("too many samples")
+
+// MeasureQuerier selects the series from the measures of a group through the measure service.
+// The string tags of a data point are the labels of its series, and the selected field holds the sample values.
+type MeasureQuerier struct {
+	registries Registries
+	client     measurev1.MeasureServiceClient
+	schemas    map[string]*querySchema
+	group      string
+	naming     Naming
+	maxSamples uint32
+	mu         sync.Mutex
+}
+
+// NewMeasureQuerier returns a MeasureQuerier. A selector selecting more than maxSamples samples fails.
+func NewMeasureQuerier(registries Registries, client measurev1.MeasureServiceClient,
+	group string, naming Naming, maxSamples uint32,
+) *MeasureQuerier {
+	return &MeasureQuerier{
+		registries: registries,
+		client:     client,
+		group:      group,
+		naming:     naming,
+		maxSamples: maxSamples,
+		schemas:    make(map[string]*querySchema),
+	}
+}
+
+type querySchema struct {
+	fetchedAt time.Time
+	measure   *databasev1.Measure
+	entity    map[string]struct{}
+	indexed   map[string]struct{}
+	tagTypes  map[string]databasev1.TagType
+	fields    map[string]databasev1.FieldType
+}
+
+// valueField returns the field holding the sample values: the requested one, the field "value",
+// or the only numeric field of the measure.
+func (s *querySchema) valueField(requested string) (string, bool) {
+	isNumeric := func(t databasev1.FieldType) bool {
+		return t == databasev1.FieldType_FIELD_TYPE_FLOAT || t == databasev1.FieldType_FIELD_TYPE_INT
+	}
+	if requested != "" {
+		t, ok := s.fields[requested]
+		return requested, ok && isNumeric(t)
+	}
+	if isNumeric(s.fields[ValueFieldName]) {
+		return ValueFieldName, true
+	}
+	var numeric []string
+	for _, f := range s.measure.GetFields() {
+		if isNumeric(f.GetFieldType()) {
+			numeric = append(numeric, f.GetName())
+		}
+	}
+	if len(numeric) == 1 {
+		return numeric[0], true
+	}
+	return "", false
+}
+
+// condition returns the condition the matcher is pushed down as, or nil if the matcher is applied to the returned data points.
+// The equality matchers on the string entity tags, and the equality and regular expression matchers on the string tags
+// indexed as keywords are pushed down. The matchers matching the empty value aren't, since they select the series
+// without the label as well.
+func (s *querySchema) condition(m *LabelMatcher) *modelv1.Condition {
+	if s.tagTypes[m.Name] != databasev1.TagType_TAG_TYPE_STRING || m.Matches("") {
+		return nil
+	}
+	_, isEntity := s.entity[m.Name]
+	_, isIndexed := s.indexed[m.Name]
+	switch {
+	case m.Type == MatchEqual && (isEntity || isIndexed):
+		return &modelv1.Condition{Name: m.Name, Op: modelv1.Condition_BINARY_OP_EQ, Value: strTagValue(m.Value)}
+	case m.Type == MatchRegexp && isIndexed && isIndexableRegexp(m.Value):
+		return &modelv1.Condition{Name: m.Name, Op: modelv1.Condition_BINARY_OP_REGEXP, Value: strTagValue(m.Value)}
+	}
+	return nil
+}
+
+// isIndexableRegexp reports whether the inverted index is able to run the regular expression.
+// It rejects the empty-width assertions, such as the anchors and the word boundaries.
+func isIndexableRegexp(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var walk func(re *syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+			syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			return false
+		}
+		for _, sub := range re.Sub {
+			if !walk(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(re)
+}
+
+// schema returns the schema of the measure, or nil if the measure doesn't exist.
+func (q *MeasureQuerier) schema(ctx context.Context, name string) (*querySchema, error) {
+	q.mu.Lock()
+	s, ok := q.schemas[name]
+	q.mu.Unlock()
+	if ok && time.Since(s.fetchedAt) < schemaTTL {
+		return s, nil
+	}
+	resp, err := q.registries.Measure.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: &commonv1.Metadata{Group: q.group, Name: name}})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := resp.GetMeasure()
+	indexed, err := q.indexedTags(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s = &querySchema{
+		measure:   m,
+		entity:    make(map[string]struct{}),
+		indexed:   indexed,
+		tagTypes:  make(map[string]databasev1.TagType),
+		fields:    make(map[string]databasev1.FieldType),
+		fetchedAt: time.Now(),
+	}
+	for _, t := range m.GetEntity().GetTagNames() {
+		s.entity[t] = struct{}{}
+	}
+	for _, tf := range m.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			s.tagTypes[t.GetName()] = t.GetType()
+		}
+	}
+	for _, f := range m.GetFields() {
+		s.fields[f.GetName()] = f.GetFieldType()
+	}
+	q.mu.Lock()
+	q.schemas[name] = s
+	q.mu.Unlock()
+	return s, nil
+}
+
+// indexedTags returns the tags indexed by the inverted index rules bound to the measure.
+// The rules indexing several tags, or tokenizing the values, aren't able to match the whole values, so they are skipped.
+func (q *MeasureQuerier) indexedTags(ctx context.Context, name string) (map[string]struct{}, error) {
+	bindings, err := q.registries.IndexRuleBinding.List(ctx, &databasev1.IndexRuleBindingRegistryServiceListRequest{Group: q.group})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ruleNames := make(map[string]struct{})
+	for _, b := range bindings.GetIndexRuleBinding() {
+		if b.GetSubject().GetCatalog() != commonv1.Catalog_CATALOG_MEASURE || b.GetSubject().GetName() != name ||
+			b.GetBeginAt().AsTime().After(now) || b.GetExpireAt().AsTime().Before(now) {
+			continue
+		}
+		for _, r := range b.GetRules() {
+			ruleNames[r] = struct{}{}
+		}
+	}
+	indexed := make(map[string]struct{})
+	if len(ruleNames) == 0 {
+		return indexed, nil
+	}
+	rules, err := q.registries.IndexRule.List(ctx, &databasev1.IndexRuleRegistryServiceListRequest{Group: q.group})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules.GetIndexRule() {
+		if _, ok := ruleNames[r.GetMetadata().GetName()]; !ok || r.GetType() != databasev1.IndexRule_TYPE_INVERTED || len(r.GetTags()) != 1 {
+			continue
+		}
+		if r.GetAnalyzer() != "" && r.GetAnalyzer() != index.AnalyzerKeyword {
+			continue
+		}
+		indexed[r.GetTags()[0]] = struct{}{}
+	}
+	return indexed, nil
+}
+
+// Select implements Querier. The matchers on the entity and indexed tags are pushed down as the criteria,
+// and the other matchers are applied to the returned data points.
+func (q *MeasureQuerier) Select(ctx context.Context, selector *VectorSelector, start, end int64) ([]Series, error) {
+	s, err := q.schema(ctx, q.naming.MeasureName(selector.Name))
+	if err != nil || s == nil {
+		return nil, err
+	}
+	var requestedField string
+	var matchers []*LabelMatcher
+	var criteria *modelv1.Criteria
+	for _, m := range selector.Matchers {
+		if m.Name == FieldLabel {
+			if m.Type != MatchEqual {
+				return nil, errors.Wrapf(ErrBadQuery, "the %s matcher should be an equality", FieldLabel)
+			}
+			requestedField = m.Value
+			continue
+		}
+		if cond := s.condition(m); cond != nil {
+			criteria = and(criteria, &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: cond}})
+			continue
+		}
+		matchers = append(matchers, m)
+	}
+	field, ok := s.valueField(requestedField)
+	if !ok {
+		return nil, errors.Wrapf(ErrBadQuery, "measure %s has no numeric field to select as the sample values", s.measure.GetMetadata().GetName())
+	}
+	projection := &modelv1.TagProjection{}
+	for _, tf := range s.measure.GetTagFamilies() {
+		family := &modelv1.TagProjection_TagFamily{Name: tf.GetName()}
+		for _, t := range tf.GetTags() {
+			family.Tags = append(family.Tags, t.GetName())
+		}
+		projection.TagFamilies = append(projection.TagFamilies, family)
+	}
+	resp, err := q.client.Query(ctx, &measurev1.QueryRequest{
+		Groups: []string{q.group},
+		Name:   s.measure.GetMetadata().GetName(),
+		TimeRange: &modelv1.TimeRange{
+			Begin: timestamppb.New(time.UnixMilli(start)),
+			End:   timestamppb.New(time.UnixMilli(end)),
+		},
+		Criteria:        criteria,
+		TagProjection:   projection,
+		FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{field}},
+		Limit:           q.maxSamples,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(resp.GetDataPoints())) >= q.maxSamples {
+		return nil, errors.Wrapf(ErrTooManySamples, "selector %s selects more than %d samples", selector.Name, q.maxSamples)
+	}
+	var result []Series
+	index := make(map[string]int)
+	for _, dp := range resp.GetDataPoints() {
+		labels := dataPointLabels(selector.Name, dp)
+		if !matchAll(matchers, labels) {
+			continue
+		}
+		value, ok := fieldValue(dp, field)
+		if !ok {
+			continue
+		}
+		sig := labels.signature()
+		i, ok := index[sig]
+		if !ok {
+			i = len(result)
+			index[sig] = i
+			result = append(result, Series{Labels: labels})
+		}
+		result[i].Samples = append(result[i].Samples, Sample{Timestamp: dp.GetTimestamp().AsTime().UnixMilli(), Value: value})
+	}
+	for i := range result {
+		result[i].Samples = sortSamples(result[i].Samples)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Labels.signature() < result[j].Labels.signature() })
+	return result, nil
+}
+
+// LabelNames returns the names of the string tags of the measures in the group, and the metric name label.
+func (q *MeasureQuerier) LabelNames(ctx context.Context) ([]string, error) {
+	resp, err := q.registries.Measure.List(ctx, &databasev1.MeasureRegistryServiceListRequest{Group: q.group})
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]struct{}{MetricNameLabel: {}}
+	for _, m := range resp.GetMeasure() {
+		// The measures prefixed with an underscore, such as the TopN results, are internal.
+		if strings.HasPrefix(m.GetMetadata().GetName(), "_") {
+			continue
+		}
+		for _, tf := range m.GetTagFamilies() {
+			for _, t := range tf.GetTags() {
+				if t.GetName() != SeriesTagName && t.GetType() == databasev1.TagType_TAG_TYPE_STRING {
+					names[t.GetName()] = struct{}{}
+				}
+			}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for n := range names {
+		result = append(result, n)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func and(left, right *modelv1.Criteria) *modelv1.Criteria {
+	if left == nil {
+		return right
+	}
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+		Left:  left,
+		Right: right,
+	}}}
+}
+
+func dataPointLabels(metric string, dp *measurev1.DataPoint) Labels {
+	m := map[string]string{MetricNameLabel: metric}
+	for _, tf := range dp.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			if t.GetKey() == SeriesTagName {
+				continue
+			}
+			if v := t.GetValue().GetStr(); v != nil && v.GetValue() != "" {
+				m[t.GetKey()] = v.GetValue()
+			}
+		}
+	}
+	return NewLabels(m)
+}
+
+func matchAll(matchers []*LabelMatcher, labels Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(labels.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldValue(dp *measurev1.DataPoint, name string) (float64, bool) {
+	for _, f := range dp.GetFields() {
+		if f.GetName() != name {
+			continue
+		}
+		switch v := f.GetValue().GetValue().(type) {
+		case *modelv1.FieldValue_Float:
+			return v.Float.GetValue(), true
+		case *modelv1.FieldValue_Int:
+			return float64(v.Int.GetValue()), true
+		}
+	}
+	return 0, false
+}
+
+// sortSamples sorts the samples by their timestamps, and keeps the last one of the samples sharing a timestamp.
+func sortSamples(samples []Sample) []Sample {
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Timestamp < samples[j].Timestamp })
+	result := samples[:0]
+	for _, s := range samples {
+		if len(result) > 0 && result[len(result)-1].Timestamp == s.Timestamp {
+			result[len(result)-1] = s
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+type fakeQueryClient struct {
+	measurev1.MeasureServiceClient
+	request    *measurev1.QueryRequest
+	dataPoints []*measurev1.DataPoint
+}
+
+func (f *fakeQueryClient) Query(_ context.Context, in *measurev1.QueryRequest, _ ...grpc.CallOption) (*measurev1.QueryResponse, error) {
+	f.request = in
+	return &measurev1.QueryResponse{DataPoints: f.dataPoints}, nil
+}
+
+func dataPoint(ts int64, service, instance string, value float64) *measurev1.DataPoint {
+	return &measurev1.DataPoint{
+		Timestamp: timestamppb.New(time.UnixMilli(ts)),
+		TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{
+			{Key: "service", Value: strTagValue(service)},
+			{Key: "instance", Value: strTagValue(instance)},
+		}}},
+		Fields: []*measurev1.DataPoint_Field{{
+			Name:  ValueFieldName,
+			Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: value}}},
+		}},
+	}
+}
+
+func newTestRegistry() *fakeRegistry {
+	return newFakeRegistry(map[string]*databasev1.Measure{
+		"up": {
+			Metadata: &commonv1.Metadata{Group: "prometheus", Name: "up"},
+			Entity:   &databasev1.Entity{TagNames: []string{"service"}},
+			TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "instance", Type: databasev1.TagType_TAG_TYPE_STRING},
+			}}},
+			Fields: []*databasev1.FieldSpec{{Name: ValueFieldName, FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT}},
+		},
+	})
+}
+
+func newTestQuerier(client *fakeQueryClient, maxSamples uint32) *MeasureQuerier {
+	return NewMeasureQuerier(newTestRegistry().registries(), client, "prometheus", Naming{}, maxSamples)
+}
+
+func TestMeasureQuerierSelect(t *testing.T) {
+	client := &fakeQueryClient{dataPoints: []*measurev1.DataPoint{
+		dataPoint(2000, "svc", "a", 2),
+		dataPoint(1000, "svc", "a", 1),
+		dataPoint(1000, "svc", "b", 10),
+		dataPoint(1000, "svc", "c", 100),
+	}}
+	q := newTestQuerier(client, 100)
+	expr, err := ParseExpr(`up{service="svc", instance=~"a|b"}`)
+	require.NoError(t, err)
+	series, err := q.Select(context.Background(), expr.(*VectorSelector), 0, 3000)
+	require.NoError(t, err)
+
+	condition := client.request.GetCriteria().GetCondition()
+	assert.Equal(t, "service", condition.GetName())
+	assert.Equal(t, "svc", condition.GetValue().GetStr().GetValue())
+	assert.Equal(t, []string{ValueFieldName}, client.request.GetFieldProjection().GetNames())
+	assert.Equal(t, uint32(100), client.request.GetLimit())
+
+	require.Len(t, series, 2)
+	assert.Equal(t, NewLabels(map[string]string{MetricNameLabel: "up", "service": "svc", "instance": "a"}), series[0].Labels)
+	assert.Equal(t, []Sample{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 2}}, series[0].Samples)
+	assert.Equal(t, "b", series[1].Labels.Get("instance"))
+}
+
+func TestMeasureQuerierIndexedMatchers(t *testing.T) {
+	registry := newTestRegistry()
+	registry.rules["instance"] = &databasev1.IndexRule{
+		Metadata: &commonv1.Metadata{Group: "prometheus", Name: "instance"},
+		Tags:     []string{"instance"},
+		Type:     databasev1.IndexRule_TYPE_INVERTED,
+	}
+	registry.bindings["up"] = &databasev1.IndexRuleBinding{
+		Metadata: &commonv1.Metadata{Group: "prometheus", Name: "up"},
+		Rules:    []string{"instance"},
+		Subject:  &databasev1.Subject{Catalog: commonv1.Catalog_CATALOG_MEASURE, Name: "up"},
+		BeginAt:  timestamppb.New(bindingBeginAt),
+		ExpireAt: timestamppb.New(bindingExpireAt),
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		value string
+		op    modelv1.Condition_BinaryOp
+	}{
+		{name: "equal", expr: `up{instance="a"}`, op: modelv1.Condition_BINARY_OP_EQ, value: "a"},
+		{name: "regexp", expr: `up{instance=~"a|b"}`, op: modelv1.Condition_BINARY_OP_REGEXP, value: "a|b"},
+		{name: "anchored regexp", expr: `up{instance=~"^a$"}`},
+		{name: "regexp matching empty", expr: `up{instance=~"a|"}`},
+		{name: "not equal", expr: `up{instance!="a"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeQueryClient{dataPoints: []*measurev1.DataPoint{dataPoint(1000, "svc", "a", 1)}}
+			q := NewMeasureQuerier(registry.registries(), client, "prometheus", Naming{}, 100)
+			expr, err := ParseExpr(tt.expr)
+			require.NoError(t, err)
+			_, err = q.Select(context.Background(), expr.(*VectorSelector), 0, 3000)
+			require.NoError(t, err)
+			condition := client.request.GetCriteria().GetCondition()
+			if tt.op == modelv1.Condition_BINARY_OP_UNSPECIFIED {
+				assert.Nil(t, condition)
+				return
+			}
+			assert.Equal(t, "instance", condition.GetName())
+			assert.Equal(t, tt.op, condition.GetOp())
+			assert.Equal(t, tt.value, condition.GetValue().GetStr().GetValue())
+		})
+	}
+}
+
+func TestMeasureQuerierLimits(t *testing.T) {
+	client := &fakeQueryClient{dataPoints: []*measurev1.DataPoint{dataPoint(1000, "svc", "a", 1), dataPoint(2000, "svc", "a", 2)}}
+	q := newTestQuerier(client, 2)
+	_, err := q.Select(context.Background(), &VectorSelector{Name: "up"}, 0, 3000)
+	assert.ErrorIs(t, err, ErrTooManySamples)
+
+	series, err := q.Select(context.Background(), &VectorSelector{Name: "absent"}, 0, 3000)
+	require.NoError(t, err)
+	assert.Empty(t, series)
+
+	fieldMatcher, err := NewLabelMatcher(MatchEqual, FieldLabel, "absent")
+	require.NoError(t, err)
+	_, err = q.Select(context.Background(), &VectorSelector{Name: "up", Matchers: []*LabelMatcher{fieldMatcher}}, 0, 3000)
+	assert.ErrorIs(t, err, ErrBadQuery)
+}
@@ -0,0 +1,206 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prometheus implements the Prometheus remote-write receiver which ingests samples into measures.
+package prometheus
+
+import (
+	"math"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricNameLabel is the label holding the metric name.
+const MetricNameLabel = "__name__"
+
+// staleNaN is the bit pattern Prometheus uses to mark a series as stale.
+const staleNaN uint64 = 0x7ff0000000000002
+
+// ErrInvalidRequest indicates the remote-write request can't be accepted, and shouldn't be retried.
+var ErrInvalidRequest = errors.New("invalid remote-write request")
+
+// Label is a name-value pair of a time series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of a time series at a timestamp in milliseconds.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a series of samples identified by labels.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// MetricName returns the value of the __name__ label.
+func (ts TimeSeries) MetricName() string {
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// IsStale reports whether the sample is a staleness marker.
+func (s Sample) IsStale() bool {
+	return math.Float64bits(s.Value) == staleNaN
+}
+
+// DecodeWriteRequest decompresses a snappy-encoded remote-write v1 request and decodes its time series.
+// Decoded payloads larger than maxSize are rejected.
+func DecodeWriteRequest(compressed []byte, maxSize int) ([]TimeSeries, error) {
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "malformed snappy payload: %v", err)
+	}
+	if maxSize > 0 && size > maxSize {
+		return nil, errors.Wrapf(ErrInvalidRequest, "decoded payload is %d bytes, exceeding the limit %d", size, maxSize)
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "malformed snappy payload: %v", err)
+	}
+	var series []TimeSeries
+	err = walkFields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		ts, decodeErr := decodeTimeSeries(value)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		series = append(series, ts)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "malformed protobuf payload: %v", err)
+	}
+	return series, nil
+}
+
+func decodeTimeSeries(data []byte) (TimeSeries, error) {
+	var ts TimeSeries
+	err := walkFields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l, err := decodeLabel(value)
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			s, err := decodeSample(value)
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+func decodeLabel(data []byte) (Label, error) {
+	var l Label
+	err := walkFields(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l.Name = string(value)
+		case 2:
+			l.Value = string(value)
+		}
+		return nil
+	})
+	return l, err
+}
+
+func decodeSample(data []byte) (Sample, error) {
+	var s Sample
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return s, protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			v, m := protowire.ConsumeFixed64(data)
+			if m < 0 {
+				return s, protowire.ParseError(m)
+			}
+			s.Value = math.Float64frombits(v)
+			n = m
+		case num == 2 && typ == protowire.VarintType:
+			v, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return s, protowire.ParseError(m)
+			}
+			s.Timestamp = int64(v)
+			n = m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return s, protowire.ParseError(n)
+			}
+		}
+		data = data[n:]
+	}
+	return s, nil
+}
+
+// walkFields calls fn with every field of a message. The value of a length-delimited field is its payload,
+// and the values of the other fields are their raw encodings.
+func walkFields(data []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var value []byte
+		if typ == protowire.BytesType {
+			value, n = protowire.ConsumeBytes(data)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				value = data[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func encodeWriteRequest(series []TimeSeries) []byte {
+	var req []byte
+	for _, ts := range series {
+		var tsBuf []byte
+		for _, l := range ts.Labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Value)
+			tsBuf = protowire.AppendTag(tsBuf, 1, protowire.BytesType)
+			tsBuf = protowire.AppendBytes(tsBuf, lb)
+		}
+		for _, s := range ts.Samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(s.Value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(s.Timestamp))
+			tsBuf = protowire.AppendTag(tsBuf, 2, protowire.BytesType)
+			tsBuf = protowire.AppendBytes(tsBuf, sb)
+		}
+		// An exemplar, which is ignored.
+		tsBuf = protowire.AppendTag(tsBuf, 3, protowire.BytesType)
+		tsBuf = protowire.AppendBytes(tsBuf, []byte{0x08, 0x01})
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, tsBuf)
+	}
+	// The metadata, which is ignored.
+	req = protowire.AppendTag(req, 3, protowire.BytesType)
+	req = protowire.AppendBytes(req, nil)
+	return snappy.Encode(nil, req)
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	series := []TimeSeries{
+		{
+			Labels:  []Label{{Name: MetricNameLabel, Value: "up"}, {Name: "job", Value: "node"}},
+			Samples: []Sample{{Value: 1, Timestamp: 1700000000000}, {Value: 0, Timestamp: 1700000015000}},
+		},
+		{
+			Labels:  []Label{{Name: MetricNameLabel, Value: "http_requests_total"}, {Name: "code", Value: "200"}},
+			Samples: []Sample{{Value: math.Float64frombits(staleNaN), Timestamp: 1700000000000}},
+		},
+	}
+	got, err := DecodeWriteRequest(encodeWriteRequest(series), 0)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, series[0], got[0])
+	assert.Equal(t, "http_requests_total", got[1].MetricName())
+	assert.True(t, got[1].Samples[0].IsStale())
+	assert.False(t, got[0].Samples[0].IsStale())
+}
+
+func TestDecodeWriteRequestRejectsMalformedPayload(t *testing.T) {
+	_, err := DecodeWriteRequest([]byte("not snappy"), 0)
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+	_, err = DecodeWriteRequest(snappy.Encode(nil, []byte{0x0a, 0x05, 0x01}), 0)
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+	payload := encodeWriteRequest([]TimeSeries{{Labels: []Label{{Name: MetricNameLabel, Value: "up"}}}})
+	_, err = DecodeWriteRequest(payload, 4)
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+}
+
+func TestNaming(t *testing.T) {
+	rules, err := ParseNamingRules([]string{`node_(.+)=infra_$1`, `.*:.*=recorded`})
+	require.NoError(t, err)
+	n := Naming{Prefix: "prom_", Rules: rules}
+	assert.Equal(t, "prom_infra_cpu_seconds_total", n.MeasureName("node_cpu_seconds_total"))
+	assert.Equal(t, "prom_recorded", n.MeasureName("job:http_requests:rate5m"))
+	assert.Equal(t, "prom_up", n.MeasureName("up"))
+	assert.Equal(t, "a_b_c", Naming{}.MeasureName("a:b.c"))
+
+	_, err = ParseNamingRules([]string{"no-replacement"})
+	assert.Error(t, err)
+	_, err = ParseNamingRules([]string{"(=x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,513 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	// SeriesTagName is the entity tag of the auto-created measures. It holds the labels identifying a series.
+	SeriesTagName = "__series"
+	// ValueFieldName is the float field holding the sample values.
+	ValueFieldName = "value"
+	// TagFamilyName is the tag family of the auto-created measures.
+	TagFamilyName = "default"
+
+	schemaTTL = time.Minute
+)
+
+var (
+	// The bindings of the auto-created measures are always active.
+	bindingBeginAt  = time.Unix(0, 0)
+	bindingExpireAt = time.Date(2200, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Config configures how the samples are written.
+type Config struct {
+	Group      string
+	Naming     Naming
+	AutoCreate bool
+}
+
+// Registries are the schema registries the measures and their index rules are managed through.
+type Registries struct {
+	Measure          databasev1.MeasureRegistryServiceClient
+	IndexRule        databasev1.IndexRuleRegistryServiceClient
+	IndexRuleBinding databasev1.IndexRuleBindingRegistryServiceClient
+}
+
+// Writer writes the remote-write samples into measures through the measure service,
+// so that they share the write path, including the load shedding, of the other clients.
+type Writer struct {
+	registries Registries
+	client     measurev1.MeasureServiceClient
+	schemas    map[string]*measureSchema
+	cfg        Config
+	mu         sync.Mutex
+}
+
+// NewWriter returns a Writer sending requests through the registries and measure service clients.
+func NewWriter(registries Registries, client measurev1.MeasureServiceClient, cfg Config) *Writer {
+	return &Writer{
+		registries: registries,
+		client:     client,
+		cfg:        cfg,
+		schemas:    make(map[string]*measureSchema),
+	}
+}
+
+type tagLocation struct {
+	family int
+	index  int
+}
+
+type measureSchema struct {
+	fetchedAt  time.Time
+	measure    *databasev1.Measure
+	tags       map[string]tagLocation
+	valueIndex int
+}
+
+func newMeasureSchema(m *databasev1.Measure) (*measureSchema, error) {
+	s := &measureSchema{
+		measure:    m,
+		tags:       make(map[string]tagLocation),
+		valueIndex: -1,
+		fetchedAt:  time.Now(),
+	}
+	for i, tf := range m.GetTagFamilies() {
+		for j, t := range tf.GetTags() {
+			s.tags[t.GetName()] = tagLocation{family: i, index: j}
+		}
+	}
+	for i, f := range m.GetFields() {
+		if f.GetName() == ValueFieldName && f.GetFieldType() == databasev1.FieldType_FIELD_TYPE_FLOAT {
+			s.valueIndex = i
+			break
+		}
+	}
+	if s.valueIndex < 0 {
+		return nil, errors.Wrapf(ErrInvalidRequest, "measure %s/%s has no float field %q",
+			m.GetMetadata().GetGroup(), m.GetMetadata().GetName(), ValueFieldName)
+	}
+	return s, nil
+}
+
+func (s *measureSchema) missingTags(labelNames []string) []string {
+	var missing []string
+	for _, n := range labelNames {
+		if _, ok := s.tags[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// Write writes the samples of the time series. The errors wrapping ErrInvalidRequest
+// mean the series can't be written as they are, and the others are transient.
+func (w *Writer) Write(ctx context.Context, series []TimeSeries) error {
+	var names []string
+	batches := make(map[string][]TimeSeries)
+	rejected := 0
+	for _, ts := range series {
+		metric := ts.MetricName()
+		if metric == "" {
+			rejected += len(ts.Samples)
+			continue
+		}
+		name := w.cfg.Naming.MeasureName(metric)
+		if _, ok := batches[name]; !ok {
+			names = append(names, name)
+		}
+		batches[name] = append(batches[name], ts)
+	}
+	var invalidErr error
+	schemas := make(map[string]*measureSchema, len(names))
+	for _, name := range names {
+		s, err := w.schema(ctx, name, labelNames(batches[name]))
+		if errors.Is(err, ErrInvalidRequest) {
+			invalidErr = err
+			for _, ts := range batches[name] {
+				rejected += len(ts.Samples)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		schemas[name] = s
+	}
+	failed, invalid, err := w.send(ctx, names, batches, schemas)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to write %d samples", failed)
+	}
+	rejected += invalid
+	if rejected > 0 {
+		if invalidErr != nil {
+			return errors.Wrapf(invalidErr, "rejected %d samples", rejected)
+		}
+		return errors.Wrapf(ErrInvalidRequest, "rejected %d samples", rejected)
+	}
+	return nil
+}
+
+func (w *Writer) send(ctx context.Context, names []string, batches map[string][]TimeSeries,
+	schemas map[string]*measureSchema,
+) (failed, invalid int, err error) {
+	if len(schemas) == 0 {
+		return 0, 0, nil
+	}
+	stream, err := w.client.Write(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	type result struct {
+		err     error
+		failed  int
+		invalid int
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		var r result
+		for {
+			resp, recvErr := stream.Recv()
+			if errors.Is(recvErr, io.EOF) {
+				break
+			}
+			if recvErr != nil {
+				r.err = recvErr
+				break
+			}
+			switch resp.GetStatus() {
+			case modelv1.Status_STATUS_SUCCEED.String():
+			case modelv1.Status_STATUS_INVALID_TIMESTAMP.String():
+				r.invalid++
+			default:
+				r.failed++
+				w.invalidate(resp.GetMetadata().GetName())
+			}
+		}
+		resultCh <- r
+	}()
+	var messageID uint64
+	var sendErr error
+	for _, name := range names {
+		s, ok := schemas[name]
+		if !ok {
+			continue
+		}
+		metadata := proto.Clone(s.measure.GetMetadata()).(*commonv1.Metadata)
+		for _, ts := range batches[name] {
+			tagFamilies := s.tagFamilies(ts)
+			for _, sample := range ts.Samples {
+				if sample.IsStale() {
+					continue
+				}
+				messageID++
+				req := &measurev1.WriteRequest{
+					Metadata:  metadata,
+					MessageId: messageID,
+					DataPoint: &measurev1.DataPointValue{
+						Timestamp:   timestamppb.New(time.UnixMilli(sample.Timestamp)),
+						TagFamilies: tagFamilies,
+						Fields:      s.fields(sample.Value),
+					},
+				}
+				metadata = nil
+				if sendErr = stream.Send(req); sendErr != nil {
+					break
+				}
+			}
+			if sendErr != nil {
+				break
+			}
+		}
+		if sendErr != nil {
+			break
+		}
+	}
+	if closeErr := stream.CloseSend(); closeErr != nil && sendErr == nil {
+		sendErr = closeErr
+	}
+	r := <-resultCh
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	if sendErr != nil && !errors.Is(sendErr, io.EOF) {
+		return 0, 0, sendErr
+	}
+	return r.failed, r.invalid, nil
+}
+
+func (s *measureSchema) tagFamilies(ts TimeSeries) []*modelv1.TagFamilyForWrite {
+	values := make(map[string]string, len(ts.Labels))
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			continue
+		}
+		values[l.Name] = l.Value
+	}
+	result := make([]*modelv1.TagFamilyForWrite, len(s.measure.GetTagFamilies()))
+	for i, tf := range s.measure.GetTagFamilies() {
+		tags := make([]*modelv1.TagValue, len(tf.GetTags()))
+		for j, t := range tf.GetTags() {
+			if t.GetName() == SeriesTagName {
+				tags[j] = strTagValue(SeriesID(ts.Labels))
+				continue
+			}
+			v, ok := values[t.GetName()]
+			if !ok || t.GetType() != databasev1.TagType_TAG_TYPE_STRING {
+				tags[j] = pbv1.NullTagValue
+				continue
+			}
+			tags[j] = strTagValue(v)
+		}
+		result[i] = &modelv1.TagFamilyForWrite{Tags: tags}
+	}
+	return result
+}
+
+func (s *measureSchema) fields(value float64) []*modelv1.FieldValue {
+	fields := make([]*modelv1.FieldValue, len(s.measure.GetFields()))
+	for i := range fields {
+		fields[i] = pbv1.NullFieldValue
+	}
+	fields[s.valueIndex] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: value}}}
+	return fields
+}
+
+func strTagValue(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+// SeriesID returns the labels identifying a series of a metric, sorted by their names,
+// in the form of `name="value",...`. The metric name is excluded.
+func SeriesID(labels []Label) string {
+	sorted := make([]Label, 0, len(labels))
+	for _, l := range labels {
+		if l.Name != MetricNameLabel {
+			sorted = append(sorted, l)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	var sb strings.Builder
+	for i, l := range sorted {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l.Name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l.Value))
+	}
+	return sb.String()
+}
+
+func labelNames(series []TimeSeries) []string {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, ts := range series {
+		for _, l := range ts.Labels {
+			if l.Name == MetricNameLabel {
+				continue
+			}
+			if _, ok := seen[l.Name]; ok {
+				continue
+			}
+			seen[l.Name] = struct{}{}
+			names = append(names, l.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (w *Writer) invalidate(name string) {
+	w.mu.Lock()
+	delete(w.schemas, name)
+	w.mu.Unlock()
+}
+
+// schema returns the schema of the measure. With the auto-creation, the measure is created if it doesn't exist,
+// and the labels absent in the measure are added as its tags. The labels are indexed as well.
+func (w *Writer) schema(ctx context.Context, name string, labels []string) (*measureSchema, error) {
+	w.mu.Lock()
+	s := w.schemas[name]
+	w.mu.Unlock()
+	if s != nil && time.Since(s.fetchedAt) < schemaTTL && (!w.cfg.AutoCreate || len(s.missingTags(labels)) == 0) {
+		return s, nil
+	}
+	metadata := &commonv1.Metadata{Group: w.cfg.Group, Name: name}
+	resp, err := w.registries.Measure.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: metadata})
+	var m *databasev1.Measure
+	switch {
+	case status.Code(err) == codes.NotFound:
+		if !w.cfg.AutoCreate {
+			return nil, errors.Wrapf(ErrInvalidRequest, "measure %s/%s doesn't exist", w.cfg.Group, name)
+		}
+		if err = w.index(ctx, name, labels); err != nil {
+			return nil, err
+		}
+		m = newMeasure(metadata, labels)
+		createResp, createErr := w.registries.Measure.Create(ctx, &databasev1.MeasureRegistryServiceCreateRequest{Measure: m})
+		if createErr != nil {
+			return nil, createErr
+		}
+		m.Metadata.ModRevision = createResp.GetModRevision()
+	case err != nil:
+		return nil, err
+	default:
+		m = resp.GetMeasure()
+		if w.cfg.AutoCreate {
+			if m, err = w.addTags(ctx, m, labels); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if s, err = newMeasureSchema(m); err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	w.schemas[name] = s
+	w.mu.Unlock()
+	return s, nil
+}
+
+func (w *Writer) addTags(ctx context.Context, m *databasev1.Measure, labels []string) (*databasev1.Measure, error) {
+	existing := make(map[string]struct{})
+	family := 0
+	for i, tf := range m.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			existing[t.GetName()] = struct{}{}
+			if t.GetName() == SeriesTagName {
+				family = i
+			}
+		}
+	}
+	var missing []*databasev1.TagSpec
+	var missingNames []string
+	for _, l := range labels {
+		if _, ok := existing[l]; !ok {
+			missing = append(missing, &databasev1.TagSpec{Name: l, Type: databasev1.TagType_TAG_TYPE_STRING})
+			missingNames = append(missingNames, l)
+		}
+	}
+	if len(missing) == 0 {
+		return m, nil
+	}
+	if err := w.index(ctx, m.GetMetadata().GetName(), missingNames); err != nil {
+		return nil, err
+	}
+	updated := proto.Clone(m).(*databasev1.Measure)
+	updated.TagFamilies[family].Tags = append(updated.TagFamilies[family].Tags, missing...)
+	resp, err := w.registries.Measure.Update(ctx, &databasev1.MeasureRegistryServiceUpdateRequest{Measure: updated})
+	if err != nil {
+		return nil, err
+	}
+	updated.Metadata.ModRevision = resp.GetModRevision()
+	return updated, nil
+}
+
+// index creates the inverted index rules of the labels, named after them, and binds them to the measure.
+// It's called before the labels become the tags of the measure, so that the nodes load the rules along with the tags,
+// and the MeasureQuerier is able to push the matchers on the labels down to the index.
+func (w *Writer) index(ctx context.Context, measure string, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	for _, l := range labels {
+		_, err := w.registries.IndexRule.Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{IndexRule: &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Group: w.cfg.Group, Name: l},
+			Tags:     []string{l},
+			Type:     databasev1.IndexRule_TYPE_INVERTED,
+		}})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return err
+		}
+	}
+	metadata := &commonv1.Metadata{Group: w.cfg.Group, Name: measure}
+	resp, err := w.registries.IndexRuleBinding.Get(ctx, &databasev1.IndexRuleBindingRegistryServiceGetRequest{Metadata: metadata})
+	switch {
+	case status.Code(err) == codes.NotFound:
+		_, err = w.registries.IndexRuleBinding.Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{
+			IndexRuleBinding: &databasev1.IndexRuleBinding{
+				Metadata: metadata,
+				Rules:    labels,
+				Subject:  &databasev1.Subject{Catalog: commonv1.Catalog_CATALOG_MEASURE, Name: measure},
+				BeginAt:  timestamppb.New(bindingBeginAt),
+				ExpireAt: timestamppb.New(bindingExpireAt),
+			},
+		})
+		return err
+	case err != nil:
+		return err
+	}
+	binding := proto.Clone(resp.GetIndexRuleBinding()).(*databasev1.IndexRuleBinding)
+	bound := len(binding.Rules)
+	for _, l := range labels {
+		if !slices.Contains(binding.Rules, l) {
+			binding.Rules = append(binding.Rules, l)
+		}
+	}
+	if len(binding.Rules) == bound {
+		return nil
+	}
+	_, err = w.registries.IndexRuleBinding.Update(ctx, &databasev1.IndexRuleBindingRegistryServiceUpdateRequest{IndexRuleBinding: binding})
+	return err
+}
+
+func newMeasure(metadata *commonv1.Metadata, labels []string) *databasev1.Measure {
+	tags := make([]*databasev1.TagSpec, 0, len(labels)+1)
+	tags = append(tags, &databasev1.TagSpec{Name: SeriesTagName, Type: databasev1.TagType_TAG_TYPE_STRING})
+	for _, l := range labels {
+		tags = append(tags, &databasev1.TagSpec{Name: l, Type: databasev1.TagType_TAG_TYPE_STRING})
+	}
+	return &databasev1.Measure{
+		Metadata:    proto.Clone(metadata).(*commonv1.Metadata),
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: TagFamilyName, Tags: tags}},
+		Fields: []*databasev1.FieldSpec{{
+			Name:              ValueFieldName,
+			FieldType:         databasev1.FieldType_FIELD_TYPE_FLOAT,
+			EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+			CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{SeriesTagName}},
+	}
+}
@@ -0,0 +1,287 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+type fakeRegistry struct {
+	databasev1.MeasureRegistryServiceClient
+	measures map[string]*databasev1.Measure
+	rules    map[string]*databasev1.IndexRule
+	bindings map[string]*databasev1.IndexRuleBinding
+	revision int64
+}
+
+func newFakeRegistry(measures map[string]*databasev1.Measure) *fakeRegistry {
+	return &fakeRegistry{
+		measures: measures,
+		rules:    make(map[string]*databasev1.IndexRule),
+		bindings: make(map[string]*databasev1.IndexRuleBinding),
+	}
+}
+
+func (f *fakeRegistry) registries() Registries {
+	return Registries{Measure: f, IndexRule: &fakeIndexRuleRegistry{f: f}, IndexRuleBinding: &fakeBindingRegistry{f: f}}
+}
+
+func (f *fakeRegistry) Get(_ context.Context, in *databasev1.MeasureRegistryServiceGetRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceGetResponse, error) {
+	m, ok := f.measures[in.GetMetadata().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &databasev1.MeasureRegistryServiceGetResponse{Measure: proto.Clone(m).(*databasev1.Measure)}, nil
+}
+
+func (f *fakeRegistry) Create(_ context.Context, in *databasev1.MeasureRegistryServiceCreateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceCreateResponse, error) {
+	f.revision++
+	f.measures[in.GetMeasure().GetMetadata().GetName()] = proto.Clone(in.GetMeasure()).(*databasev1.Measure)
+	return &databasev1.MeasureRegistryServiceCreateResponse{ModRevision: f.revision}, nil
+}
+
+func (f *fakeRegistry) Update(_ context.Context, in *databasev1.MeasureRegistryServiceUpdateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceUpdateResponse, error) {
+	f.revision++
+	f.measures[in.GetMeasure().GetMetadata().GetName()] = proto.Clone(in.GetMeasure()).(*databasev1.Measure)
+	return &databasev1.MeasureRegistryServiceUpdateResponse{ModRevision: f.revision}, nil
+}
+
+type fakeIndexRuleRegistry struct {
+	databasev1.IndexRuleRegistryServiceClient
+	f *fakeRegistry
+}
+
+func (r *fakeIndexRuleRegistry) Create(_ context.Context, in *databasev1.IndexRuleRegistryServiceCreateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleRegistryServiceCreateResponse, error) {
+	name := in.GetIndexRule().GetMetadata().GetName()
+	if _, ok := r.f.rules[name]; ok {
+		return nil, status.Error(codes.AlreadyExists, "already exists")
+	}
+	r.f.rules[name] = proto.Clone(in.GetIndexRule()).(*databasev1.IndexRule)
+	return &databasev1.IndexRuleRegistryServiceCreateResponse{}, nil
+}
+
+func (r *fakeIndexRuleRegistry) List(_ context.Context, _ *databasev1.IndexRuleRegistryServiceListRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleRegistryServiceListResponse, error) {
+	resp := &databasev1.IndexRuleRegistryServiceListResponse{}
+	for _, rule := range r.f.rules {
+		resp.IndexRule = append(resp.IndexRule, proto.Clone(rule).(*databasev1.IndexRule))
+	}
+	return resp, nil
+}
+
+type fakeBindingRegistry struct {
+	databasev1.IndexRuleBindingRegistryServiceClient
+	f *fakeRegistry
+}
+
+func (r *fakeBindingRegistry) Get(_ context.Context, in *databasev1.IndexRuleBindingRegistryServiceGetRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleBindingRegistryServiceGetResponse, error) {
+	b, ok := r.f.bindings[in.GetMetadata().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &databasev1.IndexRuleBindingRegistryServiceGetResponse{IndexRuleBinding: proto.Clone(b).(*databasev1.IndexRuleBinding)}, nil
+}
+
+func (r *fakeBindingRegistry) Create(_ context.Context, in *databasev1.IndexRuleBindingRegistryServiceCreateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleBindingRegistryServiceCreateResponse, error) {
+	r.f.bindings[in.GetIndexRuleBinding().GetMetadata().GetName()] = proto.Clone(in.GetIndexRuleBinding()).(*databasev1.IndexRuleBinding)
+	return &databasev1.IndexRuleBindingRegistryServiceCreateResponse{}, nil
+}
+
+func (r *fakeBindingRegistry) Update(_ context.Context, in *databasev1.IndexRuleBindingRegistryServiceUpdateRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleBindingRegistryServiceUpdateResponse, error) {
+	r.f.bindings[in.GetIndexRuleBinding().GetMetadata().GetName()] = proto.Clone(in.GetIndexRuleBinding()).(*databasev1.IndexRuleBinding)
+	return &databasev1.IndexRuleBindingRegistryServiceUpdateResponse{}, nil
+}
+
+func (r *fakeBindingRegistry) List(_ context.Context, _ *databasev1.IndexRuleBindingRegistryServiceListRequest,
+	_ ...grpc.CallOption,
+) (*databasev1.IndexRuleBindingRegistryServiceListResponse, error) {
+	resp := &databasev1.IndexRuleBindingRegistryServiceListResponse{}
+	for _, b := range r.f.bindings {
+		resp.IndexRuleBinding = append(resp.IndexRuleBinding, proto.Clone(b).(*databasev1.IndexRuleBinding))
+	}
+	return resp, nil
+}
+
+type fakeMeasureClient struct {
+	measurev1.MeasureServiceClient
+	openErr  error
+	requests []*measurev1.WriteRequest
+	status   modelv1.Status
+}
+
+func (f *fakeMeasureClient) Write(_ context.Context, _ ...grpc.CallOption) (grpc.BidiStreamingClient[measurev1.WriteRequest, measurev1.WriteResponse], error) {
+	if f.openErr != nil {
+		return nil, f.openErr
+	}
+	return &fakeWriteStream{client: f, responses: make(chan *measurev1.WriteResponse, 16)}, nil
+}
+
+type fakeWriteStream struct {
+	grpc.ClientStream
+	client    *fakeMeasureClient
+	responses chan *measurev1.WriteResponse
+	metadata  *measurev1.WriteRequest
+}
+
+func (s *fakeWriteStream) Send(req *measurev1.WriteRequest) error {
+	if req.GetMetadata() != nil {
+		s.metadata = req
+	}
+	s.client.requests = append(s.client.requests, req)
+	s.responses <- &measurev1.WriteResponse{
+		MessageId: req.GetMessageId(),
+		Status:    s.client.status.String(),
+		Metadata:  s.metadata.GetMetadata(),
+	}
+	return nil
+}
+
+func (s *fakeWriteStream) Recv() (*measurev1.WriteResponse, error) {
+	resp, ok := <-s.responses
+	if !ok {
+		return nil, io.EOF
+	}
+	return resp, nil
+}
+
+func (s *fakeWriteStream) CloseSend() error {
+	close(s.responses)
+	return nil
+}
+
+func upSeries(labels ...Label) TimeSeries {
+	return TimeSeries{
+		Labels:  append([]Label{{Name: MetricNameLabel, Value: "up"}}, labels...),
+		Samples: []Sample{{Value: 1, Timestamp: 1700000000000}},
+	}
+}
+
+func TestWriterAutoCreate(t *testing.T) {
+	registry := newFakeRegistry(make(map[string]*databasev1.Measure))
+	client := &fakeMeasureClient{status: modelv1.Status_STATUS_SUCCEED}
+	w := NewWriter(registry.registries(), client, Config{Group: "prom", AutoCreate: true})
+
+	require.NoError(t, w.Write(context.Background(), []TimeSeries{upSeries(Label{Name: "job", Value: "node"})}))
+	m := registry.measures["up"]
+	require.NotNil(t, m)
+	assert.Equal(t, []string{SeriesTagName}, m.GetEntity().GetTagNames())
+	assert.Len(t, m.GetTagFamilies()[0].GetTags(), 2)
+	require.Len(t, client.requests, 1)
+	req := client.requests[0]
+	assert.Equal(t, int64(1), req.GetMetadata().GetModRevision())
+	tags := req.GetDataPoint().GetTagFamilies()[0].GetTags()
+	assert.Equal(t, `job="node"`, tags[0].GetStr().GetValue())
+	assert.Equal(t, "node", tags[1].GetStr().GetValue())
+	assert.Equal(t, 1.0, req.GetDataPoint().GetFields()[0].GetFloat().GetValue())
+	require.Contains(t, registry.rules, "job")
+	assert.Equal(t, []string{"job"}, registry.rules["job"].GetTags())
+	assert.Equal(t, []string{"job"}, registry.bindings["up"].GetRules())
+	assert.Equal(t, "up", registry.bindings["up"].GetSubject().GetName())
+
+	// A new label is added to the measure as a tag.
+	require.NoError(t, w.Write(context.Background(), []TimeSeries{
+		upSeries(Label{Name: "job", Value: "node"}, Label{Name: "instance", Value: "host:9100"}),
+	}))
+	assert.Len(t, registry.measures["up"].GetTagFamilies()[0].GetTags(), 3)
+	req = client.requests[1]
+	assert.Equal(t, int64(2), req.GetMetadata().GetModRevision())
+	tags = req.GetDataPoint().GetTagFamilies()[0].GetTags()
+	assert.Equal(t, `instance="host:9100",job="node"`, tags[0].GetStr().GetValue())
+	assert.Equal(t, "host:9100", tags[2].GetStr().GetValue())
+	assert.Equal(t, []string{"job", "instance"}, registry.bindings["up"].GetRules())
+}
+
+func TestWriterWithoutAutoCreate(t *testing.T) {
+	registry := newFakeRegistry(make(map[string]*databasev1.Measure))
+	client := &fakeMeasureClient{status: modelv1.Status_STATUS_SUCCEED}
+	w := NewWriter(registry.registries(), client, Config{Group: "prom"})
+
+	err := w.Write(context.Background(), []TimeSeries{upSeries()})
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+	assert.Empty(t, registry.measures)
+
+	registry.measures["up"] = &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "prom", Name: "up"},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+			{Name: "job", Type: databasev1.TagType_TAG_TYPE_STRING},
+		}}},
+		Fields: []*databasev1.FieldSpec{
+			{Name: "other", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: ValueFieldName, FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+		Entity: &databasev1.Entity{TagNames: []string{"job"}},
+	}
+	require.NoError(t, w.Write(context.Background(), []TimeSeries{upSeries(Label{Name: "instance", Value: "a"})}))
+	require.Len(t, client.requests, 1)
+	dp := client.requests[0].GetDataPoint()
+	assert.NotNil(t, dp.GetTagFamilies()[0].GetTags()[0].GetNull())
+	assert.NotNil(t, dp.GetFields()[0].GetNull())
+	assert.Equal(t, 1.0, dp.GetFields()[1].GetFloat().GetValue())
+	assert.Len(t, registry.measures["up"].GetTagFamilies()[0].GetTags(), 1)
+}
+
+func TestWriterErrors(t *testing.T) {
+	registry := newFakeRegistry(make(map[string]*databasev1.Measure))
+	client := &fakeMeasureClient{status: modelv1.Status_STATUS_INTERNAL_ERROR}
+	w := NewWriter(registry.registries(), client, Config{Group: "prom", AutoCreate: true})
+
+	err := w.Write(context.Background(), []TimeSeries{upSeries()})
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidRequest))
+
+	client.status = modelv1.Status_STATUS_INVALID_TIMESTAMP
+	assert.True(t, errors.Is(w.Write(context.Background(), []TimeSeries{upSeries()}), ErrInvalidRequest))
+
+	client.openErr = status.Error(codes.ResourceExhausted, "server is under memory pressure")
+	err = w.Write(context.Background(), []TimeSeries{upSeries()})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	series := upSeries()
+	series.Labels = nil
+	assert.True(t, errors.Is(w.Write(context.Background(), []TimeSeries{series}), ErrInvalidRequest))
+}
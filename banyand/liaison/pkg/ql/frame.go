@@ -0,0 +1,436 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ql
+
+import (
+	"encoding/base64"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+)
+
+// FieldType is the type of the values of a field of a data frame.
+type FieldType string
+
+// The types of the fields.
+const (
+	FieldTypeTime    FieldType = "time"
+	FieldTypeNumber  FieldType = "number"
+	FieldTypeString  FieldType = "string"
+	FieldTypeBoolean FieldType = "boolean"
+	FieldTypeOther   FieldType = "other"
+)
+
+// Format is the shape of the data frames of a measure or a TopN result.
+type Format string
+
+// The formats of the results.
+const (
+	// FormatTimeSeries splits the data points into a frame of each series, whose tags are the labels of the fields.
+	FormatTimeSeries Format = "time_series"
+	// FormatTable returns all the rows in a single frame.
+	FormatTable Format = "table"
+)
+
+// The names of the fields besides the tags and the fields of the resource.
+const (
+	timeField      = "time"
+	elementIDField = "element_id"
+	traceIDField   = "trace_id"
+	spanIDField    = "span_id"
+	idField        = "id"
+	groupField     = "group"
+	nameField      = "name"
+	valueField     = "value"
+)
+
+// Frame is a data frame in the JSON form of the Grafana data frames, whose values are stored by columns.
+type Frame struct {
+	Schema FrameSchema `json:"schema"`
+	Data   FrameData   `json:"data"`
+}
+
+// FrameSchema describes the fields of a data frame.
+type FrameSchema struct {
+	Meta   *FrameMeta `json:"meta,omitempty"`
+	Name   string     `json:"name,omitempty"`
+	RefID  string     `json:"refId,omitempty"`
+	Fields []Field    `json:"fields"`
+}
+
+// FrameMeta holds the query producing a data frame.
+type FrameMeta struct {
+	ExecutedQueryString string `json:"executedQueryString,omitempty"`
+}
+
+// Field is a column of a data frame. The labels identify the series of a time series frame.
+type Field struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Name   string            `json:"name"`
+	Type   FieldType         `json:"type"`
+}
+
+// FrameData holds a slice of the values of each field. A time value is in milliseconds since the epoch.
+type FrameData struct {
+	Values [][]any `json:"values"`
+}
+
+// table builds a frame by rows. The columns are added as the rows bring them, and the absent values are null.
+type table struct {
+	index   map[string]int
+	fields  []Field
+	columns [][]any
+	rows    int
+}
+
+func newTable() *table {
+	return &table{index: make(map[string]int)}
+}
+
+func (t *table) addRow() {
+	t.rows++
+	for i := range t.columns {
+		t.columns[i] = append(t.columns[i], nil)
+	}
+}
+
+// set sets the value of the column in the current row.
+func (t *table) set(name string, ft FieldType, v any) {
+	i, ok := t.index[name]
+	if !ok {
+		i = len(t.fields)
+		t.index[name] = i
+		t.fields = append(t.fields, Field{Name: name, Type: ft})
+		t.columns = append(t.columns, make([]any, t.rows))
+	}
+	if v == nil {
+		return
+	}
+	if t.fields[i].Type == FieldTypeOther && ft != FieldTypeOther {
+		t.fields[i].Type = ft
+	}
+	t.columns[i][t.rows-1] = v
+}
+
+func (t *table) frame(name string) *Frame {
+	values := t.columns
+	if values == nil {
+		values = [][]any{}
+	}
+	fields := t.fields
+	if fields == nil {
+		fields = []Field{}
+	}
+	return &Frame{Schema: FrameSchema{Name: name, Fields: fields}, Data: FrameData{Values: values}}
+}
+
+// Frames converts the result of a query to data frames. The measure data points are split into a series of each
+// combination of the groupBy tags, or of all the tags without the groupBy tags, if the format is FormatTimeSeries.
+func Frames(resp *bydbqlv1.QueryResponse, name string, format Format, groupBy []string) []*Frame {
+	switch r := resp.GetResult().(type) {
+	case *bydbqlv1.QueryResponse_MeasureResult:
+		if format == FormatTable {
+			return []*Frame{measureTable(r.MeasureResult.GetDataPoints(), name)}
+		}
+		return measureSeries(r.MeasureResult.GetDataPoints(), name, groupBy)
+	case *bydbqlv1.QueryResponse_TopnResult:
+		if format == FormatTable {
+			return []*Frame{topNTable(r.TopnResult.GetLists(), name)}
+		}
+		return topNSeries(r.TopnResult.GetLists(), name)
+	case *bydbqlv1.QueryResponse_StreamResult:
+		return []*Frame{streamTable(r.StreamResult.GetElements(), name)}
+	case *bydbqlv1.QueryResponse_TraceResult:
+		return []*Frame{traceTable(r.TraceResult.GetTraces(), name)}
+	case *bydbqlv1.QueryResponse_PropertyResult:
+		return []*Frame{propertyTable(r.PropertyResult.GetProperties(), name)}
+	}
+	return []*Frame{}
+}
+
+func measureTable(dataPoints []*measurev1.DataPoint, name string) *Frame {
+	t := newTable()
+	for _, dp := range dataPoints {
+		t.addRow()
+		t.set(timeField, FieldTypeTime, timeValue(dp.GetTimestamp()))
+		for _, tf := range dp.GetTagFamilies() {
+			setTags(t, tf.GetTags())
+		}
+		for _, f := range dp.GetFields() {
+			ft, v := fieldValue(f.GetValue())
+			t.set(f.GetName(), ft, v)
+		}
+	}
+	return t.frame(name)
+}
+
+type series struct {
+	labels map[string]string
+	points []*measurev1.DataPoint
+}
+
+func measureSeries(dataPoints []*measurev1.DataPoint, name string, groupBy []string) []*Frame {
+	var keys []string
+	all := make(map[string]*series)
+	for _, dp := range dataPoints {
+		labels := make(map[string]string)
+		for _, tf := range dp.GetTagFamilies() {
+			for _, tag := range tf.GetTags() {
+				if len(groupBy) > 0 && !slices.Contains(groupBy, tag.GetKey()) {
+					continue
+				}
+				if v, ok := labelValue(tag.GetValue()); ok {
+					labels[tag.GetKey()] = v
+				}
+			}
+		}
+		key := labelsKey(labels)
+		s, ok := all[key]
+		if !ok {
+			s = &series{labels: labels}
+			all[key] = s
+			keys = append(keys, key)
+		}
+		s.points = append(s.points, dp)
+	}
+	frames := make([]*Frame, 0, len(keys))
+	for _, key := range keys {
+		s := all[key]
+		sort.SliceStable(s.points, func(i, j int) bool {
+			return s.points[i].GetTimestamp().AsTime().Before(s.points[j].GetTimestamp().AsTime())
+		})
+		t := newTable()
+		for _, dp := range s.points {
+			t.addRow()
+			t.set(timeField, FieldTypeTime, timeValue(dp.GetTimestamp()))
+			for _, f := range dp.GetFields() {
+				ft, v := fieldValue(f.GetValue())
+				t.set(f.GetName(), ft, v)
+			}
+		}
+		for i := range t.fields {
+			if t.fields[i].Name != timeField && len(s.labels) > 0 {
+				t.fields[i].Labels = s.labels
+			}
+		}
+		frames = append(frames, t.frame(name))
+	}
+	return frames
+}
+
+func topNTable(lists []*measurev1.TopNList, name string) *Frame {
+	t := newTable()
+	for _, l := range lists {
+		for _, item := range l.GetItems() {
+			t.addRow()
+			ts := item.GetTimestamp()
+			if ts == nil {
+				ts = l.GetTimestamp()
+			}
+			t.set(timeField, FieldTypeTime, timeValue(ts))
+			setTags(t, item.GetEntity())
+			ft, v := fieldValue(item.GetValue())
+			t.set(valueField, ft, v)
+		}
+	}
+	return t.frame(name)
+}
+
+func topNSeries(lists []*measurev1.TopNList, name string) []*Frame {
+	var keys []string
+	all := make(map[string]*table)
+	labelsOf := make(map[string]map[string]string)
+	sorted := make([]*measurev1.TopNList, len(lists))
+	copy(sorted, lists)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetTimestamp().AsTime().Before(sorted[j].GetTimestamp().AsTime())
+	})
+	for _, l := range sorted {
+		for _, item := range l.GetItems() {
+			labels := make(map[string]string)
+			for _, tag := range item.GetEntity() {
+				if v, ok := labelValue(tag.GetValue()); ok {
+					labels[tag.GetKey()] = v
+				}
+			}
+			key := labelsKey(labels)
+			t, ok := all[key]
+			if !ok {
+				t = newTable()
+				all[key] = t
+				labelsOf[key] = labels
+				keys = append(keys, key)
+			}
+			t.addRow()
+			t.set(timeField, FieldTypeTime, timeValue(l.GetTimestamp()))
+			ft, v := fieldValue(item.GetValue())
+			t.set(valueField, ft, v)
+		}
+	}
+	frames := make([]*Frame, 0, len(keys))
+	for _, key := range keys {
+		t := all[key]
+		for i := range t.fields {
+			if t.fields[i].Name == valueField && len(labelsOf[key]) > 0 {
+				t.fields[i].Labels = labelsOf[key]
+			}
+		}
+		frames = append(frames, t.frame(name))
+	}
+	return frames
+}
+
+func streamTable(elements []*streamv1.Element, name string) *Frame {
+	t := newTable()
+	for _, e := range elements {
+		t.addRow()
+		t.set(timeField, FieldTypeTime, timeValue(e.GetTimestamp()))
+		t.set(elementIDField, FieldTypeString, e.GetElementId())
+		for _, tf := range e.GetTagFamilies() {
+			setTags(t, tf.GetTags())
+		}
+	}
+	return t.frame(name)
+}
+
+// traceTable returns a row of each span. The raw span data is left out.
+func traceTable(traces []*tracev1.Trace, name string) *Frame {
+	t := newTable()
+	for _, tr := range traces {
+		for _, span := range tr.GetSpans() {
+			t.addRow()
+			t.set(traceIDField, FieldTypeString, tr.GetTraceId())
+			t.set(spanIDField, FieldTypeString, span.GetSpanId())
+			setTags(t, span.GetTags())
+		}
+	}
+	return t.frame(name)
+}
+
+func propertyTable(properties []*propertyv1.Property, name string) *Frame {
+	t := newTable()
+	for _, p := range properties {
+		t.addRow()
+		t.set(groupField, FieldTypeString, p.GetMetadata().GetGroup())
+		t.set(nameField, FieldTypeString, p.GetMetadata().GetName())
+		t.set(idField, FieldTypeString, p.GetId())
+		if p.GetUpdatedAt() != nil {
+			t.set(timeField, FieldTypeTime, timeValue(p.GetUpdatedAt()))
+		}
+		setTags(t, p.GetTags())
+	}
+	return t.frame(name)
+}
+
+func setTags(t *table, tags []*modelv1.Tag) {
+	for _, tag := range tags {
+		ft, v := tagValue(tag.GetValue())
+		t.set(tag.GetKey(), ft, v)
+	}
+}
+
+func timeValue(ts *timestamppb.Timestamp) any {
+	if ts == nil {
+		return nil
+	}
+	return ts.AsTime().UnixMilli()
+}
+
+// tagValue returns the type and the value of a tag in a data frame. The binary data is in base64.
+func tagValue(v *modelv1.TagValue) (FieldType, any) {
+	switch x := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return FieldTypeString, x.Str.GetValue()
+	case *modelv1.TagValue_Int:
+		return FieldTypeNumber, x.Int.GetValue()
+	case *modelv1.TagValue_StrArray:
+		return FieldTypeOther, x.StrArray.GetValue()
+	case *modelv1.TagValue_IntArray:
+		return FieldTypeOther, x.IntArray.GetValue()
+	case *modelv1.TagValue_BinaryData:
+		return FieldTypeString, base64.StdEncoding.EncodeToString(x.BinaryData)
+	case *modelv1.TagValue_Timestamp:
+		return FieldTypeTime, timeValue(x.Timestamp)
+	}
+	return FieldTypeOther, nil
+}
+
+// fieldValue returns the type and the value of a field in a data frame. The binary data is in base64.
+func fieldValue(v *modelv1.FieldValue) (FieldType, any) {
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Int:
+		return FieldTypeNumber, x.Int.GetValue()
+	case *modelv1.FieldValue_Float:
+		return FieldTypeNumber, x.Float.GetValue()
+	case *modelv1.FieldValue_Str:
+		return FieldTypeString, x.Str.GetValue()
+	case *modelv1.FieldValue_BinaryData:
+		return FieldTypeString, base64.StdEncoding.EncodeToString(x.BinaryData)
+	}
+	return FieldTypeOther, nil
+}
+
+// labelValue returns the text of a tag used as a label, or false if the tag is null.
+func labelValue(v *modelv1.TagValue) (string, bool) {
+	switch x := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return x.Str.GetValue(), true
+	case *modelv1.TagValue_Int:
+		return strconv.FormatInt(x.Int.GetValue(), 10), true
+	case *modelv1.TagValue_StrArray:
+		return strings.Join(x.StrArray.GetValue(), ","), true
+	case *modelv1.TagValue_IntArray:
+		values := make([]string, 0, len(x.IntArray.GetValue()))
+		for _, i := range x.IntArray.GetValue() {
+			values = append(values, strconv.FormatInt(i, 10))
+		}
+		return strings.Join(values, ","), true
+	case *modelv1.TagValue_BinaryData:
+		return base64.StdEncoding.EncodeToString(x.BinaryData), true
+	case *modelv1.TagValue_Timestamp:
+		return x.Timestamp.AsTime().UTC().Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+func labelsKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for n := range labels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, n := range names {
+		sb.WriteString(strconv.Quote(n))
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[n]))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
@@ -0,0 +1,432 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bydbql"
+)
+
+// The kinds of the resources.
+const (
+	KindMeasure  = "measure"
+	KindTopN     = "topn"
+	KindStream   = "stream"
+	KindTrace    = "trace"
+	KindProperty = "property"
+)
+
+const (
+	// DefaultValuesLimit is the number of the rows scanned by a tag values lookup without limit.
+	DefaultValuesLimit = 1000
+	// MaxValuesLimit is the maximum number of the rows scanned by a tag values lookup.
+	MaxValuesLimit = 10000
+)
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// Clients are the gRPC clients of the services the Querier calls.
+type Clients struct {
+	QL               bydbqlv1.BydbQLServiceClient
+	Groups           databasev1.GroupRegistryServiceClient
+	Measures         databasev1.MeasureRegistryServiceClient
+	TopNAggregations databasev1.TopNAggregationRegistryServiceClient
+	Streams          databasev1.StreamRegistryServiceClient
+	Traces           databasev1.TraceRegistryServiceClient
+	Properties       databasev1.PropertyRegistryServiceClient
+}
+
+// Request is a query of a panel.
+type Request struct {
+	TimeRange
+	Vars   map[string][]string
+	Query  string
+	RefID  string
+	Format Format
+}
+
+// Result holds the data frames of a query.
+type Result struct {
+	RefID  string   `json:"refId,omitempty"`
+	Frames []*Frame `json:"frames"`
+}
+
+// Group is a group listed by Groups.
+type Group struct {
+	Name    string `json:"name"`
+	Catalog string `json:"catalog"`
+}
+
+// Resource is a measure, a TopN aggregation, a stream, a trace or a property listed by Resources.
+type Resource struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Tag is a tag listed by Tags. The family is empty for the tags of the traces and the properties.
+type Tag struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Family string `json:"family,omitempty"`
+}
+
+// TagValuesRequest looks up the distinct values of a tag in the time range. The filter is a BydbQL
+// condition selecting the rows, which may refer to the variables.
+type TagValuesRequest struct {
+	TimeRange
+	Vars   map[string][]string
+	Group  string
+	Kind   string
+	Name   string
+	Tag    string
+	Filter string
+	Limit  uint32
+}
+
+// Querier runs the BydbQL queries through the BydbQL service, and reads the metadata through the registry services.
+type Querier struct {
+	c Clients
+}
+
+// NewQuerier returns a Querier.
+func NewQuerier(c Clients) *Querier {
+	return &Querier{c: c}
+}
+
+// Query expands the macros and the variables of the query, runs it, and converts the result to data frames.
+// The format defaults to FormatTimeSeries.
+func (q *Querier) Query(ctx context.Context, req Request) (*Result, error) {
+	expanded, err := Expand(req.Query, req.TimeRange, req.Vars)
+	if err != nil {
+		return nil, err
+	}
+	grammar, err := bydbql.ParseQuery(expanded)
+	if err != nil {
+		return nil, errors.Wrap(ErrBadQuery, err.Error())
+	}
+	format := req.Format
+	switch format {
+	case "":
+		format = FormatTimeSeries
+	case FormatTimeSeries, FormatTable:
+	default:
+		return nil, errors.Wrapf(ErrBadQuery, "unknown format %q", format)
+	}
+	var (
+		name    string
+		groupBy []string
+	)
+	if s := grammar.Select; s != nil {
+		name = s.From.ResourceName
+		if s.GroupBy != nil {
+			for _, c := range s.GroupBy.Columns {
+				tag, pathErr := c.Identifier.ToString(c.TypeSpec != nil)
+				if pathErr != nil {
+					return nil, errors.Wrap(ErrBadQuery, pathErr.Error())
+				}
+				groupBy = append(groupBy, tag)
+			}
+		}
+	} else {
+		name = grammar.TopN.From.ResourceName
+	}
+	resp, err := q.c.QL.Query(ctx, &bydbqlv1.QueryRequest{Query: expanded})
+	if err != nil {
+		return nil, err
+	}
+	frames := Frames(resp, name, format, groupBy)
+	for _, f := range frames {
+		f.Schema.RefID = req.RefID
+		f.Schema.Meta = &FrameMeta{ExecutedQueryString: expanded}
+	}
+	return &Result{RefID: req.RefID, Frames: frames}, nil
+}
+
+// Groups returns the groups sorted by their names.
+func (q *Querier) Groups(ctx context.Context) ([]Group, error) {
+	resp, err := q.c.Groups.List(ctx, &databasev1.GroupRegistryServiceListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]Group, 0, len(resp.GetGroup()))
+	for _, g := range resp.GetGroup() {
+		groups = append(groups, Group{Name: g.GetMetadata().GetName(), Catalog: catalogName(g.GetCatalog())})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+func catalogName(c commonv1.Catalog) string {
+	return strings.ToLower(strings.TrimPrefix(c.String(), "CATALOG_"))
+}
+
+// Resources returns the resources of the group sorted by their kinds and names. The measure groups hold
+// the measures and the TopN aggregations.
+func (q *Querier) Resources(ctx context.Context, group string) ([]Resource, error) {
+	if !identifierPattern.MatchString(group) {
+		return nil, errors.Wrapf(ErrBadQuery, "invalid group %q", group)
+	}
+	g, err := q.c.Groups.Get(ctx, &databasev1.GroupRegistryServiceGetRequest{Group: group})
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	add := func(kind string, metadata ...*commonv1.Metadata) {
+		for _, m := range metadata {
+			resources = append(resources, Resource{Name: m.GetName(), Kind: kind})
+		}
+	}
+	switch g.GetGroup().GetCatalog() {
+	case commonv1.Catalog_CATALOG_MEASURE:
+		measures, listErr := q.c.Measures.List(ctx, &databasev1.MeasureRegistryServiceListRequest{Group: group})
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, m := range measures.GetMeasure() {
+			add(KindMeasure, m.GetMetadata())
+		}
+		topNs, listErr := q.c.TopNAggregations.List(ctx, &databasev1.TopNAggregationRegistryServiceListRequest{Group: group})
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, t := range topNs.GetTopNAggregation() {
+			add(KindTopN, t.GetMetadata())
+		}
+	case commonv1.Catalog_CATALOG_STREAM:
+		streams, listErr := q.c.Streams.List(ctx, &databasev1.StreamRegistryServiceListRequest{Group: group})
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, s := range streams.GetStream() {
+			add(KindStream, s.GetMetadata())
+		}
+	case commonv1.Catalog_CATALOG_TRACE:
+		traces, listErr := q.c.Traces.List(ctx, &databasev1.TraceRegistryServiceListRequest{Group: group})
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, t := range traces.GetTrace() {
+			add(KindTrace, t.GetMetadata())
+		}
+	case commonv1.Catalog_CATALOG_PROPERTY:
+		properties, listErr := q.c.Properties.List(ctx, &databasev1.PropertyRegistryServiceListRequest{Group: group})
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, p := range properties.GetProperties() {
+			add(KindProperty, p.GetMetadata())
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind < resources[j].Kind
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// Tags returns the tags of a resource in the order of its schema. The tags of a TopN aggregation are
+// its group by tags, whose types are those of the source measure.
+func (q *Querier) Tags(ctx context.Context, group, kind, name string) ([]Tag, error) {
+	if !identifierPattern.MatchString(group) || !identifierPattern.MatchString(name) {
+		return nil, errors.Wrapf(ErrBadQuery, "invalid group %q or name %q", group, name)
+	}
+	metadata := &commonv1.Metadata{Group: group, Name: name}
+	switch kind {
+	case KindMeasure:
+		resp, err := q.c.Measures.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: metadata})
+		if err != nil {
+			return nil, err
+		}
+		return familyTags(resp.GetMeasure().GetTagFamilies()), nil
+	case KindTopN:
+		resp, err := q.c.TopNAggregations.Get(ctx, &databasev1.TopNAggregationRegistryServiceGetRequest{Metadata: metadata})
+		if err != nil {
+			return nil, err
+		}
+		topN := resp.GetTopNAggregation()
+		source, err := q.c.Measures.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: topN.GetSourceMeasure()})
+		if err != nil {
+			return nil, err
+		}
+		measureTags := familyTags(source.GetMeasure().GetTagFamilies())
+		tags := make([]Tag, 0, len(topN.GetGroupByTagNames()))
+		for _, n := range topN.GetGroupByTagNames() {
+			for _, t := range measureTags {
+				if t.Name == n {
+					tags = append(tags, t)
+					break
+				}
+			}
+		}
+		return tags, nil
+	case KindStream:
+		resp, err := q.c.Streams.Get(ctx, &databasev1.StreamRegistryServiceGetRequest{Metadata: metadata})
+		if err != nil {
+			return nil, err
+		}
+		return familyTags(resp.GetStream().GetTagFamilies()), nil
+	case KindTrace:
+		resp, err := q.c.Traces.Get(ctx, &databasev1.TraceRegistryServiceGetRequest{Metadata: metadata})
+		if err != nil {
+			return nil, err
+		}
+		tags := make([]Tag, 0, len(resp.GetTrace().GetTags()))
+		for _, t := range resp.GetTrace().GetTags() {
+			tags = append(tags, Tag{Name: t.GetName(), Type: tagTypeName(t.GetType())})
+		}
+		return tags, nil
+	case KindProperty:
+		resp, err := q.c.Properties.Get(ctx, &databasev1.PropertyRegistryServiceGetRequest{Metadata: metadata})
+		if err != nil {
+			return nil, err
+		}
+		tags := make([]Tag, 0, len(resp.GetProperty().GetTags()))
+		for _, t := range resp.GetProperty().GetTags() {
+			tags = append(tags, Tag{Name: t.GetName(), Type: tagTypeName(t.GetType())})
+		}
+		return tags, nil
+	}
+	return nil, errors.Wrapf(ErrBadQuery, "unknown kind %q", kind)
+}
+
+func familyTags(families []*databasev1.TagFamilySpec) []Tag {
+	var tags []Tag
+	for _, f := range families {
+		for _, t := range f.GetTags() {
+			tags = append(tags, Tag{Name: t.GetName(), Type: tagTypeName(t.GetType()), Family: f.GetName()})
+		}
+	}
+	return tags
+}
+
+func tagTypeName(t databasev1.TagType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "TAG_TYPE_"))
+}
+
+// TagValues returns the distinct values of the tag in the rows selected by the filter, sorted in ascending order.
+// It scans at most the limit of the rows, or of the TopN items.
+func (q *Querier) TagValues(ctx context.Context, req TagValuesRequest) ([]string, error) {
+	for _, id := range []string{req.Group, req.Name, req.Tag} {
+		if !identifierPattern.MatchString(id) {
+			return nil, errors.Wrapf(ErrBadQuery, "invalid identifier %q", id)
+		}
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultValuesLimit
+	}
+	if limit > MaxValuesLimit {
+		return nil, errors.Wrapf(ErrBadQuery, "limit should be at most %d", MaxValuesLimit)
+	}
+	timeFilter := fmt.Sprintf("TIME BETWEEN %s AND %s", quote(req.From.UTC().Format(time.RFC3339Nano)), quote(req.To.UTC().Format(time.RFC3339Nano)))
+	where := ""
+	if strings.TrimSpace(req.Filter) != "" {
+		filter, err := Expand(req.Filter, req.TimeRange, req.Vars)
+		if err != nil {
+			return nil, err
+		}
+		where = " WHERE " + filter
+	}
+	var query string
+	switch req.Kind {
+	case KindMeasure:
+		query = fmt.Sprintf("SELECT %s::tag FROM MEASURE %s IN %s %s%s LIMIT %d", req.Tag, req.Name, req.Group, timeFilter, where, limit)
+	case KindTopN:
+		query = fmt.Sprintf("SHOW TOP %d FROM MEASURE %s IN %s %s%s", limit, req.Name, req.Group, timeFilter, where)
+	case KindStream:
+		query = fmt.Sprintf("SELECT %s FROM STREAM %s IN %s %s%s LIMIT %d", req.Tag, req.Name, req.Group, timeFilter, where, limit)
+	case KindTrace:
+		query = fmt.Sprintf("SELECT %s FROM TRACE %s IN %s %s%s LIMIT %d", req.Tag, req.Name, req.Group, timeFilter, where, limit)
+	case KindProperty:
+		query = fmt.Sprintf("SELECT %s FROM PROPERTY %s IN %s%s LIMIT %d", req.Tag, req.Name, req.Group, where, limit)
+	default:
+		return nil, errors.Wrapf(ErrBadQuery, "unknown kind %q", req.Kind)
+	}
+	if _, err := bydbql.ParseQuery(query); err != nil {
+		return nil, errors.Wrap(ErrBadQuery, err.Error())
+	}
+	resp, err := q.c.QL.Query(ctx, &bydbqlv1.QueryRequest{Query: query})
+	if err != nil {
+		return nil, err
+	}
+	return distinctValues(resp, req.Tag), nil
+}
+
+func distinctValues(resp *bydbqlv1.QueryResponse, tag string) []string {
+	values := make(map[string]struct{})
+	collect := func(tags []*modelv1.Tag) {
+		for _, t := range tags {
+			if t.GetKey() != tag {
+				continue
+			}
+			if v, ok := labelValue(t.GetValue()); ok {
+				values[v] = struct{}{}
+			}
+		}
+	}
+	switch r := resp.GetResult().(type) {
+	case *bydbqlv1.QueryResponse_MeasureResult:
+		for _, dp := range r.MeasureResult.GetDataPoints() {
+			for _, tf := range dp.GetTagFamilies() {
+				collect(tf.GetTags())
+			}
+		}
+	case *bydbqlv1.QueryResponse_TopnResult:
+		for _, l := range r.TopnResult.GetLists() {
+			for _, item := range l.GetItems() {
+				collect(item.GetEntity())
+			}
+		}
+	case *bydbqlv1.QueryResponse_StreamResult:
+		for _, e := range r.StreamResult.GetElements() {
+			for _, tf := range e.GetTagFamilies() {
+				collect(tf.GetTags())
+			}
+		}
+	case *bydbqlv1.QueryResponse_TraceResult:
+		for _, t := range r.TraceResult.GetTraces() {
+			for _, span := range t.GetSpans() {
+				collect(span.GetTags())
+			}
+		}
+	case *bydbqlv1.QueryResponse_PropertyResult:
+		for _, p := range r.PropertyResult.GetProperties() {
+			collect(p.GetTags())
+		}
+	}
+	result := make([]string, 0, len(values))
+	for v := range values {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
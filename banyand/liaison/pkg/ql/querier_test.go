@@ -0,0 +1,295 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+type fakeQLClient struct {
+	bydbqlv1.BydbQLServiceClient
+	resp    *bydbqlv1.QueryResponse
+	queries []string
+}
+
+func (f *fakeQLClient) Query(_ context.Context, in *bydbqlv1.QueryRequest, _ ...grpc.CallOption) (*bydbqlv1.QueryResponse, error) {
+	f.queries = append(f.queries, in.GetQuery())
+	return f.resp, nil
+}
+
+type fakeGroupRegistry struct {
+	databasev1.GroupRegistryServiceClient
+}
+
+func (fakeGroupRegistry) List(_ context.Context, _ *databasev1.GroupRegistryServiceListRequest, _ ...grpc.CallOption,
+) (*databasev1.GroupRegistryServiceListResponse, error) {
+	return &databasev1.GroupRegistryServiceListResponse{Group: []*commonv1.Group{
+		{Metadata: &commonv1.Metadata{Name: "sw_stream"}, Catalog: commonv1.Catalog_CATALOG_STREAM},
+		{Metadata: &commonv1.Metadata{Name: "sw_metric"}, Catalog: commonv1.Catalog_CATALOG_MEASURE},
+	}}, nil
+}
+
+func (fakeGroupRegistry) Get(_ context.Context, in *databasev1.GroupRegistryServiceGetRequest, _ ...grpc.CallOption,
+) (*databasev1.GroupRegistryServiceGetResponse, error) {
+	return &databasev1.GroupRegistryServiceGetResponse{Group: &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: in.GetGroup()},
+		Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+	}}, nil
+}
+
+type fakeMeasureRegistry struct {
+	databasev1.MeasureRegistryServiceClient
+}
+
+func (fakeMeasureRegistry) List(_ context.Context, _ *databasev1.MeasureRegistryServiceListRequest, _ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceListResponse, error) {
+	return &databasev1.MeasureRegistryServiceListResponse{Measure: []*databasev1.Measure{
+		{Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_minute"}},
+		{Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "endpoint_cpm_minute"}},
+	}}, nil
+}
+
+func (fakeMeasureRegistry) Get(_ context.Context, in *databasev1.MeasureRegistryServiceGetRequest, _ ...grpc.CallOption,
+) (*databasev1.MeasureRegistryServiceGetResponse, error) {
+	return &databasev1.MeasureRegistryServiceGetResponse{Measure: &databasev1.Measure{
+		Metadata: in.GetMetadata(),
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "layer", Type: databasev1.TagType_TAG_TYPE_INT},
+			},
+		}},
+	}}, nil
+}
+
+type fakeTopNRegistry struct {
+	databasev1.TopNAggregationRegistryServiceClient
+}
+
+func (fakeTopNRegistry) List(_ context.Context, _ *databasev1.TopNAggregationRegistryServiceListRequest, _ ...grpc.CallOption,
+) (*databasev1.TopNAggregationRegistryServiceListResponse, error) {
+	return &databasev1.TopNAggregationRegistryServiceListResponse{TopNAggregation: []*databasev1.TopNAggregation{
+		{Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_minute_top"}},
+	}}, nil
+}
+
+func (fakeTopNRegistry) Get(_ context.Context, in *databasev1.TopNAggregationRegistryServiceGetRequest, _ ...grpc.CallOption,
+) (*databasev1.TopNAggregationRegistryServiceGetResponse, error) {
+	return &databasev1.TopNAggregationRegistryServiceGetResponse{TopNAggregation: &databasev1.TopNAggregation{
+		Metadata:        in.GetMetadata(),
+		SourceMeasure:   &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_minute"},
+		GroupByTagNames: []string{"service_id"},
+	}}, nil
+}
+
+func testClients(qlClient *fakeQLClient) Clients {
+	return Clients{
+		QL:               qlClient,
+		Groups:           fakeGroupRegistry{},
+		Measures:         fakeMeasureRegistry{},
+		TopNAggregations: fakeTopNRegistry{},
+	}
+}
+
+func strTag(key, value string) *modelv1.Tag {
+	return &modelv1.Tag{Key: key, Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}}}
+}
+
+func dataPoint(ts time.Time, svc, instance string, value int64) *measurev1.DataPoint {
+	return &measurev1.DataPoint{
+		Timestamp:   timestamppb.New(ts),
+		TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{strTag("service_id", svc), strTag("instance_id", instance)}}},
+		Fields: []*measurev1.DataPoint_Field{{
+			Name:  "total",
+			Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: value}}},
+		}},
+	}
+}
+
+func TestQueryTimeSeries(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	qlClient := &fakeQLClient{resp: &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_MeasureResult{
+		MeasureResult: &measurev1.QueryResponse{DataPoints: []*measurev1.DataPoint{
+			dataPoint(now.Add(time.Minute), "a", "1", 2),
+			dataPoint(now.Add(time.Minute), "b", "1", 5),
+			dataPoint(now, "a", "2", 1),
+		}},
+	}}}
+	q := NewQuerier(testClients(qlClient))
+	result, err := q.Query(context.Background(), Request{
+		TimeRange: TimeRange{From: now, To: now.Add(time.Hour), Interval: time.Minute},
+		Vars:      map[string][]string{"svc": {"a", "b"}},
+		Query:     "SELECT service_id, SUM(total) FROM MEASURE service_cpm_minute IN sw_metric $__timeFilter WHERE service_id IN ($svc) GROUP BY service_id",
+		RefID:     "A",
+	})
+	require.NoError(t, err)
+	require.Len(t, qlClient.queries, 1)
+	assert.Contains(t, qlClient.queries[0], "TIME BETWEEN '2024-01-02T03:00:00Z' AND '2024-01-02T04:00:00Z'")
+	assert.Contains(t, qlClient.queries[0], "service_id IN ('a', 'b')")
+
+	assert.Equal(t, "A", result.RefID)
+	require.Len(t, result.Frames, 2)
+	a := result.Frames[0]
+	assert.Equal(t, "service_cpm_minute", a.Schema.Name)
+	assert.Equal(t, "A", a.Schema.RefID)
+	assert.Equal(t, qlClient.queries[0], a.Schema.Meta.ExecutedQueryString)
+	assert.Equal(t, []Field{
+		{Name: "time", Type: FieldTypeTime},
+		{Name: "total", Type: FieldTypeNumber, Labels: map[string]string{"service_id": "a"}},
+	}, a.Schema.Fields)
+	assert.Equal(t, [][]any{
+		{now.UnixMilli(), now.Add(time.Minute).UnixMilli()},
+		{int64(1), int64(2)},
+	}, a.Data.Values)
+	assert.Equal(t, map[string]string{"service_id": "b"}, result.Frames[1].Schema.Fields[1].Labels)
+}
+
+func TestQueryTable(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	qlClient := &fakeQLClient{resp: &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_StreamResult{
+		StreamResult: &streamv1.QueryResponse{Elements: []*streamv1.Element{
+			{
+				ElementId:   "e1",
+				Timestamp:   timestamppb.New(now),
+				TagFamilies: []*modelv1.TagFamily{{Name: "searchable", Tags: []*modelv1.Tag{strTag("trace_id", "t1")}}},
+			},
+			{ElementId: "e2", Timestamp: timestamppb.New(now)},
+		}},
+	}}}
+	q := NewQuerier(testClients(qlClient))
+	result, err := q.Query(context.Background(), Request{
+		TimeRange: TimeRange{From: now, To: now.Add(time.Hour)},
+		Query:     "SELECT trace_id FROM STREAM sw IN sw_stream $__timeFilter",
+		Format:    FormatTable,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Frames, 1)
+	assert.Equal(t, []Field{
+		{Name: "time", Type: FieldTypeTime},
+		{Name: "element_id", Type: FieldTypeString},
+		{Name: "trace_id", Type: FieldTypeString},
+	}, result.Frames[0].Schema.Fields)
+	assert.Equal(t, []any{"t1", nil}, result.Frames[0].Data.Values[2])
+
+	_, err = q.Query(context.Background(), Request{Query: "SELECT * FROM", Format: FormatTable})
+	assert.ErrorIs(t, err, ErrBadQuery)
+	_, err = q.Query(context.Background(), Request{Query: "SELECT * FROM STREAM sw IN g", Format: "graph"})
+	assert.ErrorIs(t, err, ErrBadQuery)
+}
+
+func TestTopNSeries(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	item := func(svc string, v int64) *measurev1.TopNList_Item {
+		return &measurev1.TopNList_Item{
+			Entity: []*modelv1.Tag{strTag("service_id", svc)},
+			Value:  &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}},
+		}
+	}
+	frames := Frames(&bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_TopnResult{TopnResult: &measurev1.TopNResponse{
+		Lists: []*measurev1.TopNList{
+			{Timestamp: timestamppb.New(now.Add(time.Minute)), Items: []*measurev1.TopNList_Item{item("a", 3)}},
+			{Timestamp: timestamppb.New(now), Items: []*measurev1.TopNList_Item{item("a", 1), item("b", 2)}},
+		},
+	}}}, "top", FormatTimeSeries, nil)
+	require.Len(t, frames, 2)
+	assert.Equal(t, map[string]string{"service_id": "a"}, frames[0].Schema.Fields[1].Labels)
+	assert.Equal(t, [][]any{{now.UnixMilli(), now.Add(time.Minute).UnixMilli()}, {int64(1), int64(3)}}, frames[0].Data.Values)
+	assert.Equal(t, [][]any{{now.UnixMilli()}, {int64(2)}}, frames[1].Data.Values)
+}
+
+func TestTagValues(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	qlClient := &fakeQLClient{resp: &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_MeasureResult{
+		MeasureResult: &measurev1.QueryResponse{DataPoints: []*measurev1.DataPoint{
+			dataPoint(now, "b", "1", 1),
+			dataPoint(now, "a", "1", 1),
+			dataPoint(now, "b", "2", 1),
+		}},
+	}}}
+	q := NewQuerier(testClients(qlClient))
+	values, err := q.TagValues(context.Background(), TagValuesRequest{
+		TimeRange: TimeRange{From: now, To: now.Add(time.Hour)},
+		Vars:      map[string][]string{"layer": {"1"}},
+		Group:     "sw_metric",
+		Kind:      KindMeasure,
+		Name:      "service_cpm_minute",
+		Tag:       "service_id",
+		Filter:    "layer = ${layer:raw}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, values)
+	assert.Equal(t, []string{
+		"SELECT service_id::tag FROM MEASURE service_cpm_minute IN sw_metric " +
+			"TIME BETWEEN '2024-01-02T03:00:00Z' AND '2024-01-02T04:00:00Z' WHERE layer = 1 LIMIT 1000",
+	}, qlClient.queries)
+
+	for _, req := range []TagValuesRequest{
+		{Group: "sw_metric", Kind: KindMeasure, Name: "m", Tag: "t; DROP"},
+		{Group: "sw_metric", Kind: "index", Name: "m", Tag: "t"},
+		{Group: "sw_metric", Kind: KindMeasure, Name: "m", Tag: "t", Limit: MaxValuesLimit + 1},
+	} {
+		_, err = q.TagValues(context.Background(), req)
+		assert.ErrorIs(t, err, ErrBadQuery)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	q := NewQuerier(testClients(&fakeQLClient{}))
+	ctx := context.Background()
+
+	groups, err := q.Groups(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Group{{Name: "sw_metric", Catalog: "measure"}, {Name: "sw_stream", Catalog: "stream"}}, groups)
+
+	resources, err := q.Resources(ctx, "sw_metric")
+	require.NoError(t, err)
+	assert.Equal(t, []Resource{
+		{Name: "endpoint_cpm_minute", Kind: KindMeasure},
+		{Name: "service_cpm_minute", Kind: KindMeasure},
+		{Name: "service_cpm_minute_top", Kind: KindTopN},
+	}, resources)
+
+	tags, err := q.Tags(ctx, "sw_metric", KindMeasure, "service_cpm_minute")
+	require.NoError(t, err)
+	assert.Equal(t, []Tag{
+		{Name: "service_id", Type: "string", Family: "default"},
+		{Name: "layer", Type: "int", Family: "default"},
+	}, tags)
+
+	tags, err = q.Tags(ctx, "sw_metric", KindTopN, "service_cpm_minute_top")
+	require.NoError(t, err)
+	assert.Equal(t, []Tag{{Name: "service_id", Type: "string", Family: "default"}}, tags)
+
+	_, err = q.Resources(ctx, "")
+	assert.ErrorIs(t, err, ErrBadQuery)
+}
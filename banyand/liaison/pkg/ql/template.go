@@ -0,0 +1,169 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package ql serves the BydbQL queries of the dashboards, such as Grafana. It expands the macros and the variables
+// of the queries, returns the results as data frames, and lists the metadata filling the template variables.
+package ql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadQuery means the query or its parameters are malformed.
+var ErrBadQuery = errors.New("bad query")
+
+// The macros expanded by Expand.
+const (
+	// MacroFrom is the begin of the time range, as a quoted RFC3339 timestamp.
+	MacroFrom = "__from"
+	// MacroTo is the end of the time range, as a quoted RFC3339 timestamp.
+	MacroTo = "__to"
+	// MacroTimeFilter is the TIME clause selecting the time range.
+	MacroTimeFilter = "__timeFilter"
+	// MacroInterval is the interval between the points of the panel, such as 30s, 5m or 1h.
+	MacroInterval = "__interval"
+	// MacroIntervalMs is the interval in milliseconds.
+	MacroIntervalMs = "__interval_ms"
+)
+
+// The formats of the variable values, given by ${name:format}.
+const (
+	// FormatSQLString quotes each value as a string literal and joins them with commas. It's the default format.
+	FormatSQLString = "sqlstring"
+	// FormatRaw joins the values with commas without quoting them, which suits the integers.
+	FormatRaw = "raw"
+	// FormatCSV is the same as FormatRaw.
+	FormatCSV = "csv"
+)
+
+// TimeRange is the time range and the interval of a panel.
+type TimeRange struct {
+	From     time.Time
+	To       time.Time
+	Interval time.Duration
+}
+
+// variablePattern matches $name, ${name} and ${name:format}.
+var variablePattern = regexp.MustCompile(`\$(?:([a-zA-Z_][a-zA-Z0-9_]*)|\{([a-zA-Z_][a-zA-Z0-9_]*)(?::([a-zA-Z]+))?\})`)
+
+// Expand replaces the macros and the variables of the query. A variable may have multiple values.
+// The names starting with "__" are reserved for the macros.
+func Expand(query string, tr TimeRange, vars map[string][]string) (string, error) {
+	var err error
+	expanded := variablePattern.ReplaceAllStringFunc(query, func(s string) string {
+		if err != nil {
+			return s
+		}
+		m := variablePattern.FindStringSubmatch(s)
+		name, format := m[1], m[3]
+		if name == "" {
+			name = m[2]
+		}
+		if strings.HasPrefix(name, "__") {
+			var v string
+			v, err = expandMacro(name, tr)
+			return v
+		}
+		values, ok := vars[name]
+		if !ok {
+			err = errors.Wrapf(ErrBadQuery, "variable %q is undefined", name)
+			return s
+		}
+		var v string
+		v, err = formatValues(values, format)
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func expandMacro(name string, tr TimeRange) (string, error) {
+	switch name {
+	case MacroFrom:
+		return quote(tr.From.UTC().Format(time.RFC3339Nano)), nil
+	case MacroTo:
+		return quote(tr.To.UTC().Format(time.RFC3339Nano)), nil
+	case MacroTimeFilter:
+		return "TIME BETWEEN " + quote(tr.From.UTC().Format(time.RFC3339Nano)) + " AND " + quote(tr.To.UTC().Format(time.RFC3339Nano)), nil
+	case MacroInterval:
+		return FormatInterval(tr.Interval), nil
+	case MacroIntervalMs:
+		return strconv.FormatInt(tr.Interval.Milliseconds(), 10), nil
+	}
+	return "", errors.Wrapf(ErrBadQuery, "unknown macro $%s", name)
+}
+
+func formatValues(values []string, format string) (string, error) {
+	switch format {
+	case "", FormatSQLString:
+		quoted := make([]string, 0, len(values))
+		for _, v := range values {
+			quoted = append(quoted, quote(v))
+		}
+		return strings.Join(quoted, ", "), nil
+	case FormatRaw, FormatCSV:
+		return strings.Join(values, ","), nil
+	}
+	return "", errors.Wrapf(ErrBadQuery, "unknown format %q of the variable", format)
+}
+
+// quote returns the string literal of s, escaping the quotes and the backslashes.
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// FormatInterval formats the interval in the largest unit dividing it, such as 500ms, 30s, 5m, 1h or 1d.
+func FormatInterval(d time.Duration) string {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	for _, u := range units {
+		if d >= u.unit && d%u.unit == 0 {
+			return strconv.FormatInt(int64(d/u.unit), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// ParseInterval parses an interval formatted by FormatInterval, or a Go duration.
+func ParseInterval(s string) (time.Duration, error) {
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.ParseInt(n, 10, 64)
+		if err != nil || days <= 0 {
+			return 0, errors.Wrapf(ErrBadQuery, "invalid interval %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, errors.Wrapf(ErrBadQuery, "invalid interval %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,108 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	tr := TimeRange{
+		From:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		To:       time.Date(2024, 1, 2, 4, 4, 5, 0, time.UTC),
+		Interval: 30 * time.Second,
+	}
+	vars := map[string][]string{
+		"svc":   {"a", "o'b"},
+		"limit": {"10"},
+		"ids":   {"1", "2"},
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "time filter",
+			query: "SELECT * FROM MEASURE m IN g $__timeFilter",
+			want:  "SELECT * FROM MEASURE m IN g TIME BETWEEN '2024-01-02T03:04:05Z' AND '2024-01-02T04:04:05Z'",
+		},
+		{
+			name:  "from and to",
+			query: "TIME > ${__from} AND TIME < $__to",
+			want:  "TIME > '2024-01-02T03:04:05Z' AND TIME < '2024-01-02T04:04:05Z'",
+		},
+		{
+			name:  "interval",
+			query: "$__interval $__interval_ms",
+			want:  "30s 30000",
+		},
+		{
+			name:  "quoted values",
+			query: "WHERE svc IN ($svc)",
+			want:  `WHERE svc IN ('a', 'o\'b')`,
+		},
+		{
+			name:  "raw values",
+			query: "LIMIT ${limit:raw} WHERE id IN (${ids:csv})",
+			want:  "LIMIT 10 WHERE id IN (1,2)",
+		},
+		{
+			name:  "braces delimit the name",
+			query: "${limit}0",
+			want:  "'10'0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.query, tr, vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	for _, query := range []string{"$missing", "${svc:json}", "$__unknown"} {
+		_, err := Expand(query, TimeRange{}, map[string][]string{"svc": {"a"}})
+		assert.ErrorIs(t, err, ErrBadQuery, query)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	for s, d := range map[string]time.Duration{
+		"500ms": 500 * time.Millisecond,
+		"90s":   90 * time.Second,
+		"5m":    5 * time.Minute,
+		"2h":    2 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+	} {
+		assert.Equal(t, s, FormatInterval(d))
+		got, err := ParseInterval(s)
+		require.NoError(t, err)
+		assert.Equal(t, d, got)
+	}
+	for _, s := range []string{"", "0s", "-1d", "xd", "1w"} {
+		_, err := ParseInterval(s)
+		assert.ErrorIs(t, err, ErrBadQuery, s)
+	}
+}
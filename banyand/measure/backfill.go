@@ -0,0 +1,325 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// BackfillIndex schedules the index backfill job of a binding in all shards of the group.
+func (s *standalone) BackfillIndex(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	n, err := s.schemaRepo.scheduleIndexBackfill(ctx, req)
+	if err != nil {
+		return n, err
+	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Trigger()
+	}
+	return n, nil
+}
+
+// BackfillIndex schedules the index backfill job of a binding in all shards of the group.
+func (s *dataSVC) BackfillIndex(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	n, err := s.schemaRepo.scheduleIndexBackfill(ctx, req)
+	if err != nil {
+		return n, err
+	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Trigger()
+	}
+	return n, nil
+}
+
+func (sr *schemaRepo) scheduleIndexBackfill(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	binding, err := sr.metadata.IndexRuleBindingRegistry().GetIndexRuleBinding(ctx, req.GetMetadata())
+	if err != nil {
+		return 0, err
+	}
+	if binding.GetSubject().GetCatalog() != commonv1.Catalog_CATALOG_MEASURE {
+		return 0, fmt.Errorf("index rule binding %s is not bound to a measure", req.GetMetadata().GetName())
+	}
+	m, err := sr.metadata.MeasureRegistry().GetMeasure(ctx, &commonv1.Metadata{
+		Group: req.GetMetadata().GetGroup(),
+		Name:  binding.GetSubject().GetName(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if m.GetIndexMode() {
+		return 0, fmt.Errorf("measure %s is in the index mode which indexes all tags on write", m.GetMetadata().GetName())
+	}
+	tsdb, err := sr.loadTSDB(req.GetMetadata().GetGroup())
+	if err != nil {
+		return 0, err
+	}
+	return storage.ScheduleIndexBackfill(tsdb, binding, req.GetRateLimit(), backfillShardRoot)
+}
+
+func (sr *schemaRepo) runIndexBackfill(ctx context.Context, group string) {
+	tsdb, err := sr.loadTSDB(group)
+	if err != nil {
+		// The group isn't loaded yet, retry later.
+		return
+	}
+	storage.RunIndexBackfill(ctx, sr.l, tsdb, backfillShardRoot,
+		func(ctx context.Context, segment storage.Segment[*tsTable, option], tst *tsTable, task *storage.IndexBackfillTask) error {
+			return sr.backfillTable(ctx, group, segment, tst, task)
+		})
+}
+
+func backfillShardRoot(tst *tsTable) (fs.FileSystem, string) {
+	return tst.fileSystem, tst.root
+}
+
+// backfillTable rebuilds the series index documents of the measure in the segment.
+// The indexed tags of a series take the values stored in the series index if there are any,
+// otherwise the latest values stored in the parts. Since the latest values can only be determined
+// after scanning all parts, an interrupted task rescans the parts from the beginning.
+func (sr *schemaRepo) backfillTable(ctx context.Context, group string, segment storage.Segment[*tsTable, option], tst *tsTable,
+	task *storage.IndexBackfillTask,
+) error {
+	m, ok := sr.loadMeasure(&commonv1.Metadata{Group: group, Name: task.Subject})
+	if !ok {
+		return fmt.Errorf("measure %s: %w", task.Subject, storage.ErrIndexBackfillNotReady)
+	}
+	is := m.indexSchema.Load().(indexSchema)
+	if !containsIndexRules(is.indexRules, task.Rules) {
+		return storage.ErrIndexBackfillNotReady
+	}
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+		return storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task)
+	}
+	defer snp.decRef()
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_IN_PROGRESS
+	task.Message = ""
+	task.ProcessedParts = nil
+	task.IndexedCount = 0
+	task.TotalParts = int64(len(snp.parts))
+	if err := storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task); err != nil {
+		return err
+	}
+	limiter := task.Limiter()
+	latest := make(map[common.SeriesID]map[string]*backfillValue)
+	for _, pw := range snp.parts {
+		if err := scanLatestTagValues(ctx, pw.p, limiter, latest); err != nil {
+			return err
+		}
+		task.MarkProcessed(pw.ID(), 0)
+		if err := storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task); err != nil {
+			return err
+		}
+	}
+	n, err := rebuildSeriesDocs(ctx, segment, m, is, latest)
+	if err != nil {
+		return err
+	}
+	task.IndexedCount = int64(n)
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+	return storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task)
+}
+
+func containsIndexRules(indexRules []*databasev1.IndexRule, names []string) bool {
+	for _, n := range names {
+		found := false
+		for _, r := range indexRules {
+			if r.GetMetadata().GetName() == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type backfillValue struct {
+	value     *modelv1.TagValue
+	timestamp int64
+	version   int64
+}
+
+func (v *backfillValue) olderThan(timestamp, version int64) bool {
+	return v.timestamp < timestamp || (v.timestamp == timestamp && v.version < version)
+}
+
+// scanLatestTagValues collects the latest stored value of each tag of each series in the part.
+// The tags written after they were indexed aren't stored in parts, so the values are tracked per tag.
+func scanLatestTagValues(ctx context.Context, p *part, limiter *storage.IndexBackfillLimiter,
+	latest map[common.SeriesID]map[string]*backfillValue,
+) error {
+	pmi := generatePartMergeIter()
+	defer releasePartMergeIter(pmi)
+	pmi.mustInitFromPart(p)
+	pmi.tombstone = p.tombstone.Load()
+	br := generateBlockReader()
+	defer releaseBlockReader(br)
+	br.init([]*partMergeIter{pmi})
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	for br.nextBlockMetadata() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		br.loadBlockData(decoder)
+		b := br.block
+		if len(b.timestamps) == 0 {
+			continue
+		}
+		if err := limiter.Wait(ctx, len(b.timestamps)); err != nil {
+			return err
+		}
+		values, ok := latest[b.bm.seriesID]
+		if !ok {
+			values = make(map[string]*backfillValue)
+			latest[b.bm.seriesID] = values
+		}
+		for _, tf := range b.tagFamilies {
+			for _, c := range tf.columns {
+				for i := range c.values {
+					if c.values[i] == nil || i >= len(b.timestamps) {
+						continue
+					}
+					v, found := values[c.name]
+					if found && !v.olderThan(b.timestamps[i], b.versions[i]) {
+						continue
+					}
+					values[c.name] = &backfillValue{
+						value:     mustDecodeTagValue(c.valueType, c.values[i]),
+						timestamp: b.timestamps[i],
+						version:   b.versions[i],
+					}
+				}
+			}
+		}
+	}
+	return br.error()
+}
+
+func rebuildSeriesDocs(ctx context.Context, segment storage.Segment[*tsTable, option], m *measure, is indexSchema,
+	latest map[common.SeriesID]map[string]*backfillValue,
+) (int, error) {
+	var projection []index.FieldKey
+	for _, fields := range is.fieldIndexLocation {
+		for _, f := range fields {
+			projection = append(projection, f.Key)
+		}
+	}
+	entityValues := make([]*modelv1.TagValue, len(m.schema.GetEntity().GetTagNames()))
+	for i := range entityValues {
+		entityValues[i] = pbv1.AnyTagValue
+	}
+	sd, _, err := segment.IndexDB().Search(ctx, []*pbv1.Series{{Subject: m.name, EntityValues: entityValues}}, storage.IndexSearchOpts{
+		Projection: projection,
+	})
+	if err != nil {
+		return 0, err
+	}
+	entityIndex := make(map[string]int, len(m.schema.GetEntity().GetTagNames()))
+	for i, name := range m.schema.GetEntity().GetTagNames() {
+		entityIndex[name] = i
+	}
+	docs := make(index.Documents, 0, len(sd.SeriesList))
+	for i, s := range sd.SeriesList {
+		var stored map[string][]byte
+		if i < len(sd.Fields) {
+			stored = sd.Fields[i]
+		}
+		fields := backfillFields(m.schema, is, s.EntityValues, entityIndex, stored, latest[s.ID])
+		series := &pbv1.Series{Subject: m.name, EntityValues: s.EntityValues}
+		if err = series.Marshal(); err != nil {
+			return 0, err
+		}
+		docs = append(docs, index.Document{
+			DocID:        uint64(series.ID),
+			EntityValues: series.Buffer,
+			Fields:       fields,
+		})
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	if err = segment.IndexDB().Update(docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+func backfillFields(schema *databasev1.Measure, is indexSchema, entityValues []*modelv1.TagValue, entityIndex map[string]int,
+	stored map[string][]byte, values map[string]*backfillValue,
+) []index.Field {
+	var fields []index.Field
+	newField := func(fieldKey index.FieldKey, value []byte, noSort bool) index.Field {
+		f := index.NewBytesField(fieldKey, value)
+		f.Store = true
+		f.Index = true
+		f.NoSort = noSort
+		return f
+	}
+	for i, tagFamilySpec := range schema.GetTagFamilies() {
+		tfr := is.indexRuleLocators.TagFamilyTRule[i]
+		for _, t := range tagFamilySpec.GetTags() {
+			r, ok := tfr[t.GetName()]
+			if !ok {
+				continue
+			}
+			fieldKey := index.FieldKey{
+				IndexRuleID: r.GetMetadata().GetId(),
+				Analyzer:    r.Analyzer,
+			}
+			if v, found := stored[fieldKey.Marshal()]; found && v != nil {
+				fields = append(fields, newField(fieldKey, v, r.GetNoSort()))
+				continue
+			}
+			var tagValue *modelv1.TagValue
+			if ei, isEntity := entityIndex[t.GetName()]; isEntity {
+				if ei < len(entityValues) {
+					tagValue = entityValues[ei]
+				}
+			} else if v, found := values[t.GetName()]; found {
+				tagValue = v.value
+			}
+			if tagValue == nil || tagValue == pbv1.NullTagValue {
+				continue
+			}
+			nv := encodeTagValue(t.GetName(), t.GetType(), tagValue)
+			if nv.value != nil {
+				fields = append(fields, newField(fieldKey, nv.value, r.GetNoSort()))
+			} else {
+				for _, val := range nv.valueArr {
+					fields = append(fields, newField(fieldKey, val, r.GetNoSort()))
+				}
+			}
+			releaseNameValue(nv)
+		}
+	}
+	return fields
+}
@@ -154,6 +154,7 @@ func (b *block) mustWriteTo(sid common.SeriesID, bm *blockMetadata, ww *writers)
 	cmm := bm.field.resizeColumnMetadata(len(cc))
 	for i := range cc {
 		cc[i].mustWriteTo(&cmm[i], &ww.fieldValuesWriter)
+		cmm[i].collectStats(&cc[i])
 	}
 }
 
@@ -848,8 +849,12 @@ NEXT_FIELD:
 	if !ok {
 		return false
 	}
-	bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[start:end+1]...)
-	bc.versions = append(bc.versions, tmpBlock.versions[start:end+1]...)
+	kept := bc.p.tombstone.Load().keptRows(bc.bm.seriesID, tmpBlock.timestamps, start, end)
+	if kept != nil && len(kept) == 0 {
+		return false
+	}
+	bc.timestamps = appendRows(bc.timestamps, tmpBlock.timestamps, start, end, kept)
+	bc.versions = appendRows(bc.versions, tmpBlock.versions, start, end, kept)
 
 	for _, cf := range tmpBlock.tagFamilies {
 		tf := columnFamily{
@@ -866,7 +871,7 @@ NEXT_FIELD:
 			if len(cf.columns[i].values) != len(tmpBlock.timestamps) {
 				logger.Panicf("unexpected number of values for tags %q: got %d; want %d", cf.columns[i].name, len(cf.columns[i].values), len(tmpBlock.timestamps))
 			}
-			column.values = append(column.values, cf.columns[i].values[start:end+1]...)
+			column.values = appendRows(column.values, cf.columns[i].values, start, end, kept)
 			tf.columns = append(tf.columns, column)
 		}
 		bc.tagFamilies = append(bc.tagFamilies, tf)
@@ -885,12 +890,23 @@ NEXT_FIELD:
 			valueType: tmpBlock.field.columns[i].valueType,
 		}
 
-		c.values = append(c.values, tmpBlock.field.columns[i].values[start:end+1]...)
+		c.values = appendRows(c.values, tmpBlock.field.columns[i].values, start, end, kept)
 		bc.fields.columns = append(bc.fields.columns, c)
 	}
 	return true
 }
 
+// appendRows appends src[start:end+1] to dst, or only the kept rows if kept isn't nil.
+func appendRows[T any](dst, src []T, start, end int, kept []int) []T {
+	if kept == nil {
+		return append(dst, src[start:end+1]...)
+	}
+	for _, i := range kept {
+		dst = append(dst, src[i])
+	}
+	return dst
+}
+
 var blockCursorPool = pool.Register[*blockCursor]("measure-blockCursor")
 
 func generateBlockCursor() *blockCursor {
@@ -144,6 +144,7 @@ func (sw *writers) getColumnMetadataWriterAndColumnWriter(columnName string) (*w
 
 type blockWriter struct {
 	writers                    writers
+	fieldStats                 map[string]*columnStats
 	metaData                   []byte
 	primaryBlockData           []byte
 	primaryBlockMetadata       primaryBlockMetadata
@@ -173,6 +174,7 @@ func (bw *blockWriter) reset() {
 	bw.totalBlocksCount = 0
 	bw.totalMinTimestamp = 0
 	bw.totalMaxTimestamp = 0
+	bw.fieldStats = nil
 	bw.primaryBlockData = bw.primaryBlockData[:0]
 	bw.metaData = bw.metaData[:0]
 	bw.primaryBlockMetadata.reset()
@@ -241,6 +243,21 @@ func (bw *blockWriter) mustWriteBlock(sid common.SeriesID, b *block) {
 	bw.totalUncompressedSizeBytes += bm.uncompressedSizeBytes
 	bw.totalCount += bm.count
 	bw.totalBlocksCount++
+	for i := range bm.field.columnMetadata {
+		cm := &bm.field.columnMetadata[i]
+		if !cm.hasStats {
+			continue
+		}
+		if bw.fieldStats == nil {
+			bw.fieldStats = make(map[string]*columnStats)
+		}
+		cs, ok := bw.fieldStats[cm.name]
+		if !ok {
+			cs = &columnStats{}
+			bw.fieldStats[cm.name] = cs
+		}
+		cs.merge(&cm.stats)
+	}
 
 	bw.primaryBlockData = bm.marshal(bw.primaryBlockData)
 	releaseBlockMetadata(bm)
@@ -267,6 +284,7 @@ func (bw *blockWriter) Flush(pm *partMetadata) {
 	pm.BlocksCount = bw.totalBlocksCount
 	pm.MinTimestamp = bw.totalMinTimestamp
 	pm.MaxTimestamp = bw.totalMaxTimestamp
+	pm.FieldStats = bw.fieldStats
 
 	bw.mustFlushPrimaryBlock(bw.primaryBlockData)
 
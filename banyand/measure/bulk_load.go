@@ -0,0 +1,229 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const bulkLoadBatchSize = 100_000
+
+// BulkLoader writes the data points of a measure into the data directory of a stopped data node.
+// The data points are encoded into parts and the series index in the same way as the write path,
+// and the parts are flushed to the disk directly instead of staying in memory.
+type BulkLoader struct {
+	tsdb               storage.TSDB[*tsTable, option]
+	measure            *measure
+	shardingKeyLocator *partition.Locator
+	l                  *logger.Logger
+	dpg                *dataPointsInGroup
+	entityLocator      partition.Locator
+	pending            int
+	shardNum           uint32
+}
+
+// NewBulkLoader opens the storage of the group under the data directory root for loading the data points of the measure.
+func NewBulkLoader(root string, group *commonv1.Group, schema *databasev1.Measure, indexRules []*databasev1.IndexRule) (*BulkLoader, error) {
+	if group.GetResourceOpts() == nil {
+		return nil, fmt.Errorf("no resource opts in group %s", group.GetMetadata().GetName())
+	}
+	if schema.GetMetadata().GetGroup() != group.GetMetadata().GetName() {
+		return nil, fmt.Errorf("measure %s doesn't belong to group %s", schema.GetMetadata().GetName(), group.GetMetadata().GetName())
+	}
+	l := logger.GetLogger("measure-bulk-load")
+	omr := observability.NewBypassRegistry()
+	pm := protector.Nop{}
+	s := &supplier{
+		path: root,
+		c:    storage.NewBypassCache(),
+		// The zero flush timeout persists the batches of the series index before it's closed.
+		option: option{
+			protector:          pm,
+			mergePolicy:        newDefaultMergePolicy(),
+			seriesCacheMaxSize: run.Bytes(32 << 20),
+		},
+		omr: omr,
+		pm:  pm,
+		l:   l,
+	}
+	m, err := openMeasure(measureSpec{schema: schema}, l, s.c, pm, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.OnIndexUpdate(indexRules)
+	db, err := s.OpenDB(group)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the storage of group %s: %w", group.GetMetadata().GetName(), err)
+	}
+	bl := &BulkLoader{
+		tsdb:          db.(storage.TSDB[*tsTable, option]),
+		measure:       m,
+		l:             l,
+		entityLocator: partition.NewEntityLocator(schema.GetTagFamilies(), schema.GetEntity(), 0),
+		shardNum:      group.GetResourceOpts().GetShardNum(),
+	}
+	if len(schema.GetShardingKey().GetTagNames()) > 0 {
+		locator := partition.NewShardingKeyLocator(schema.GetTagFamilies(), schema.GetShardingKey())
+		bl.shardingKeyLocator = &locator
+	}
+	return bl, nil
+}
+
+// Write writes a data point, whose tag families and fields are in the order of the measure schema.
+// A data point without version gets the current time as the version, the same as the liaison does.
+func (bl *BulkLoader) Write(dp *measurev1.DataPointValue) error {
+	t := dp.GetTimestamp().AsTime().Local()
+	if err := timestamp.Check(t); err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	if len(dp.GetTagFamilies()) < 1 {
+		return fmt.Errorf("%s has no tag family", bl.measure.schema.GetMetadata())
+	}
+	metadata := bl.measure.schema.GetMetadata()
+	entityValues, shardID, err := bl.entityLocator.Locate(metadata.GetName(), dp.GetTagFamilies(), bl.shardNum)
+	if err != nil {
+		return fmt.Errorf("cannot locate the series: %w", err)
+	}
+	if bl.shardingKeyLocator != nil {
+		if _, shardID, err = bl.shardingKeyLocator.Locate(metadata.GetName(), dp.GetTagFamilies(), bl.shardNum); err != nil {
+			return fmt.Errorf("cannot locate the shard: %w", err)
+		}
+	}
+	if dp.Version == 0 {
+		dp.Version = time.Now().UnixNano()
+	}
+	req := &measurev1.WriteRequest{Metadata: metadata, DataPoint: dp}
+	writeEvent := &measurev1.InternalWriteRequest{
+		Request:      req,
+		ShardId:      uint32(shardID),
+		EntityValues: entityValues[1:].Encode(),
+	}
+	ts := t.UnixNano()
+	dpt, err := bl.table(t, shardID)
+	if err != nil {
+		return err
+	}
+	if bl.dpg.latestTS < ts {
+		bl.dpg.latestTS = ts
+	}
+	is := bl.measure.indexSchema.Load().(indexSchema)
+	if _, err = processDataPoint(dpt, req, writeEvent, bl.measure, is, ts, metadata, nil); err != nil {
+		return err
+	}
+	bl.pending++
+	if bl.pending >= bulkLoadBatchSize {
+		return bl.Flush()
+	}
+	return nil
+}
+
+func (bl *BulkLoader) table(t time.Time, shardID common.ShardID) (*dataPointsInTable, error) {
+	if bl.dpg == nil {
+		bl.dpg = &dataPointsInGroup{tsdb: bl.tsdb}
+	}
+	ts := t.UnixNano()
+	for _, dpt := range bl.dpg.tables {
+		if dpt.timeRange.Contains(ts) && dpt.shardID == shardID {
+			return dpt, nil
+		}
+	}
+	var segment storage.Segment[*tsTable, option]
+	for _, seg := range bl.dpg.segments {
+		if seg.GetTimeRange().Contains(ts) {
+			segment = seg
+		}
+	}
+	if segment == nil {
+		var err error
+		if segment, err = bl.tsdb.CreateSegmentIfNotExist(t); err != nil {
+			return nil, fmt.Errorf("cannot create segment: %w", err)
+		}
+		bl.dpg.segments = append(bl.dpg.segments, segment)
+	}
+	tst, err := segment.CreateTSTableIfNotExist(shardID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create ts table: %w", err)
+	}
+	dpt := newDpt(segment, segment.GetTimeRange(), bl.measure.schema.IndexMode, tst, shardID)
+	bl.dpg.tables = append(bl.dpg.tables, dpt)
+	return dpt, nil
+}
+
+// Flush writes the buffered data points into parts on the disk, and their series into the series index.
+func (bl *BulkLoader) Flush() error {
+	if bl.dpg == nil {
+		return nil
+	}
+	dpg := bl.dpg
+	bl.dpg, bl.pending = nil, 0
+	defer func() {
+		for _, segment := range dpg.segments {
+			segment.DecRef()
+		}
+	}()
+	for _, dpt := range dpg.tables {
+		if dpt.dataPoints != nil {
+			mp := generateMemPart()
+			mp.mustInitFromDataPoints(dpt.dataPoints)
+			if mp.partMetadata.TotalCount > 0 {
+				tst := dpt.tsTable
+				partID := atomic.AddUint64(&tst.curPartID, 1)
+				mp.mustFlush(tst.fileSystem, partPath(tst.root, partID))
+				tst.mustAddFilePart(partID)
+			}
+			releaseMemPart(mp)
+			releaseDataPoints(dpt.dataPoints)
+			dpt.dataPoints = nil
+		}
+		if len(dpt.metadataDocs) > 0 {
+			if err := dpt.segment.IndexDB().Insert(dpt.metadataDocs); err != nil {
+				return fmt.Errorf("cannot write the series: %w", err)
+			}
+		}
+		if len(dpt.indexModeDocs) > 0 {
+			if err := dpt.segment.IndexDB().Update(dpt.indexModeDocs); err != nil {
+				return fmt.Errorf("cannot write the index: %w", err)
+			}
+		}
+	}
+	bl.l.Debug().Int("tables", len(dpg.tables)).Int64("latest", dpg.latestTS).Msg("flushed data points")
+	return nil
+}
+
+// Close flushes the buffered data points and closes the storage.
+func (bl *BulkLoader) Close() error {
+	err := bl.Flush()
+	if closeErr := bl.tsdb.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
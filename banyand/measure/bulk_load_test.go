@@ -0,0 +1,110 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestBulkLoader(t *testing.T) {
+	root := t.TempDir()
+	group := &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "bulk"},
+		Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        2,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		},
+	}
+	schema := &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm", Group: "bulk"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Fields: []*databasev1.FieldSpec{{
+			Name:              "total",
+			FieldType:         databasev1.FieldType_FIELD_TYPE_INT,
+			EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+			CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"id"}},
+	}
+
+	bl, err := NewBulkLoader(root, group, schema, nil)
+	require.NoError(t, err)
+	now := time.Now().Truncate(time.Millisecond)
+	const total = 1000
+	for i := 0; i < total; i++ {
+		dp := &measurev1.DataPointValue{
+			Timestamp: timestamppb.New(now.Add(-time.Duration(i) * time.Second)),
+			TagFamilies: []*modelv1.TagFamilyForWrite{{
+				Tags: []*modelv1.TagValue{{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: fmt.Sprintf("svc-%d", i%3)}}}},
+			}},
+			Fields: []*modelv1.FieldValue{{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: int64(i)}}}},
+		}
+		require.NoError(t, bl.Write(dp))
+		require.NotZero(t, dp.Version)
+	}
+	require.NoError(t, bl.Close())
+
+	_, err = NewBulkLoader(root, group, &databasev1.Measure{Metadata: &commonv1.Metadata{Name: "m", Group: "other"}}, nil)
+	require.Error(t, err)
+
+	// The loaded parts are persisted in the snapshots of the tables, and the series in the series index.
+	bl, err = NewBulkLoader(root, group, schema, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, bl.Close())
+	}()
+	segments, err := bl.tsdb.SelectSegments(timestamp.NewInclusiveTimeRange(now.Add(-time.Hour), now))
+	require.NoError(t, err)
+	var count uint64
+	var seriesCount int64
+	for _, segment := range segments {
+		n, _ := segment.IndexDB().Stats()
+		seriesCount += n
+		tables, _ := segment.Tables()
+		for _, tst := range tables {
+			s := tst.currentSnapshot()
+			if s == nil {
+				continue
+			}
+			for _, pw := range s.parts {
+				require.Nil(t, pw.mp)
+				count += pw.p.partMetadata.TotalCount
+			}
+			s.decRef()
+		}
+		segment.DecRef()
+	}
+	require.Equal(t, uint64(total), count)
+	require.Equal(t, int64(3), seriesCount)
+}
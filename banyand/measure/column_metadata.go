@@ -19,6 +19,7 @@ package measure
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
@@ -26,29 +27,141 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 )
 
+// columnStatsFlag marks the value type of a column whose metadata carries the column stats.
+// The metadata written before the stats were introduced never sets it.
+const columnStatsFlag = 0x80
+
+// columnStats is the statistics of the non-null values of a numeric column.
+// The Int fields are set for int64 columns and the Float fields for float64 columns.
+type columnStats struct {
+	Count    uint64  `json:"count"`
+	IntSum   int64   `json:"intSum,omitempty"`
+	IntMin   int64   `json:"intMin,omitempty"`
+	IntMax   int64   `json:"intMax,omitempty"`
+	FloatSum float64 `json:"floatSum,omitempty"`
+	FloatMin float64 `json:"floatMin,omitempty"`
+	FloatMax float64 `json:"floatMax,omitempty"`
+}
+
+func (cs *columnStats) update(valueType pbv1.ValueType, value []byte) {
+	if value == nil || string(value) == "null" {
+		return
+	}
+	switch valueType {
+	case pbv1.ValueTypeInt64:
+		v := convert.BytesToInt64(value)
+		if cs.Count == 0 || v < cs.IntMin {
+			cs.IntMin = v
+		}
+		if cs.Count == 0 || v > cs.IntMax {
+			cs.IntMax = v
+		}
+		cs.IntSum += v
+	case pbv1.ValueTypeFloat64:
+		v := convert.BytesToFloat64(value)
+		if cs.Count == 0 || v < cs.FloatMin {
+			cs.FloatMin = v
+		}
+		if cs.Count == 0 || v > cs.FloatMax {
+			cs.FloatMax = v
+		}
+		cs.FloatSum += v
+	default:
+		return
+	}
+	cs.Count++
+}
+
+func (cs *columnStats) merge(other *columnStats) {
+	if other.Count == 0 {
+		return
+	}
+	if cs.Count == 0 {
+		*cs = *other
+		return
+	}
+	cs.Count += other.Count
+	cs.IntSum += other.IntSum
+	cs.IntMin = min(cs.IntMin, other.IntMin)
+	cs.IntMax = max(cs.IntMax, other.IntMax)
+	cs.FloatSum += other.FloatSum
+	cs.FloatMin = math.Min(cs.FloatMin, other.FloatMin)
+	cs.FloatMax = math.Max(cs.FloatMax, other.FloatMax)
+}
+
+func (cs *columnStats) marshal(dst []byte, valueType pbv1.ValueType) []byte {
+	dst = encoding.VarUint64ToBytes(dst, cs.Count)
+	if valueType == pbv1.ValueTypeInt64 {
+		dst = encoding.Int64ToBytes(dst, cs.IntSum)
+		dst = encoding.Int64ToBytes(dst, cs.IntMin)
+		return encoding.Int64ToBytes(dst, cs.IntMax)
+	}
+	dst = encoding.Uint64ToBytes(dst, math.Float64bits(cs.FloatSum))
+	dst = encoding.Uint64ToBytes(dst, math.Float64bits(cs.FloatMin))
+	return encoding.Uint64ToBytes(dst, math.Float64bits(cs.FloatMax))
+}
+
+func (cs *columnStats) unmarshal(src []byte, valueType pbv1.ValueType) ([]byte, error) {
+	src, cs.Count = encoding.BytesToVarUint64(src)
+	if len(src) < 24 {
+		return nil, fmt.Errorf("cannot unmarshal columnStats: src is too short")
+	}
+	if valueType == pbv1.ValueTypeInt64 {
+		cs.IntSum = encoding.BytesToInt64(src)
+		cs.IntMin = encoding.BytesToInt64(src[8:])
+		cs.IntMax = encoding.BytesToInt64(src[16:])
+		return src[24:], nil
+	}
+	cs.FloatSum = math.Float64frombits(encoding.BytesToUint64(src))
+	cs.FloatMin = math.Float64frombits(encoding.BytesToUint64(src[8:]))
+	cs.FloatMax = math.Float64frombits(encoding.BytesToUint64(src[16:]))
+	return src[24:], nil
+}
+
 type columnMetadata struct {
 	name string
 	dataBlock
+	stats     columnStats
 	valueType pbv1.ValueType
+	hasStats  bool
 }
 
 func (cm *columnMetadata) reset() {
 	cm.name = ""
 	cm.valueType = 0
 	cm.dataBlock.reset()
+	cm.stats = columnStats{}
+	cm.hasStats = false
 }
 
 func (cm *columnMetadata) copyFrom(src *columnMetadata) {
 	cm.name = src.name
 	cm.valueType = src.valueType
 	cm.dataBlock.copyFrom(&src.dataBlock)
+	cm.stats = src.stats
+	cm.hasStats = src.hasStats
+}
+
+// collectStats records the stats of a numeric column.
+func (cm *columnMetadata) collectStats(c *column) {
+	if c.valueType != pbv1.ValueTypeInt64 && c.valueType != pbv1.ValueTypeFloat64 {
+		return
+	}
+	for _, v := range c.values {
+		cm.stats.update(c.valueType, v)
+	}
+	cm.hasStats = true
 }
 
 func (cm *columnMetadata) marshal(dst []byte) []byte {
 	dst = encoding.EncodeBytes(dst, convert.StringToBytes(cm.name))
-	dst = append(dst, byte(cm.valueType))
+	if !cm.hasStats {
+		dst = append(dst, byte(cm.valueType))
+		return cm.dataBlock.marshal(dst)
+	}
+	dst = append(dst, byte(cm.valueType)|columnStatsFlag)
 	dst = cm.dataBlock.marshal(dst)
-	return dst
+	return cm.stats.marshal(dst, cm.valueType)
 }
 
 func (cm *columnMetadata) unmarshal(src []byte) ([]byte, error) {
@@ -60,9 +173,17 @@ func (cm *columnMetadata) unmarshal(src []byte) ([]byte, error) {
 	if len(src) < 1 {
 		return nil, fmt.Errorf("cannot unmarshal columnMetadata.valueType: src is too short")
 	}
-	cm.valueType = pbv1.ValueType(src[0])
+	cm.hasStats = src[0]&columnStatsFlag != 0
+	cm.valueType = pbv1.ValueType(src[0] &^ columnStatsFlag)
 	src = src[1:]
 	src = cm.dataBlock.unmarshal(src)
+	if !cm.hasStats {
+		return src, nil
+	}
+	src, err = cm.stats.unmarshal(src, cm.valueType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal columnMetadata.stats: %w", err)
+	}
 	return src, nil
 }
 
@@ -22,6 +22,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -70,6 +72,44 @@ func Test_columnMetadata_marshal(t *testing.T) {
 	assert.Equal(t, original, unmarshaled)
 }
 
+func Test_columnMetadata_marshalStats(t *testing.T) {
+	for _, c := range []column{
+		{name: "int", valueType: pbv1.ValueTypeInt64, values: [][]byte{convert.Int64ToBytes(3), nil, convert.Int64ToBytes(-1)}},
+		{name: "float", valueType: pbv1.ValueTypeFloat64, values: [][]byte{convert.Float64ToBytes(1.5), convert.Float64ToBytes(2.5)}},
+	} {
+		original := &columnMetadata{
+			name:      c.name,
+			valueType: c.valueType,
+			dataBlock: dataBlock{offset: 1, size: 10},
+		}
+		original.collectStats(&c)
+		assert.True(t, original.hasStats)
+
+		unmarshaled := &columnMetadata{}
+		tail, err := unmarshaled.unmarshal(original.marshal(nil))
+		assert.NoError(t, err)
+		assert.Empty(t, tail)
+		assert.Equal(t, original, unmarshaled)
+	}
+	cm := &columnMetadata{name: "int", valueType: pbv1.ValueTypeInt64}
+	cm.collectStats(&column{name: "int", valueType: pbv1.ValueTypeInt64, values: [][]byte{convert.Int64ToBytes(3), nil, convert.Int64ToBytes(-1)}})
+	assert.Equal(t, columnStats{Count: 2, IntSum: 2, IntMin: -1, IntMax: 3}, cm.stats)
+
+	// The string column doesn't have stats, and its metadata keeps the format without stats.
+	cm = &columnMetadata{name: "str", valueType: pbv1.ValueTypeStr}
+	cm.collectStats(&column{name: "str", valueType: pbv1.ValueTypeStr, values: [][]byte{[]byte("a")}})
+	assert.False(t, cm.hasStats)
+	assert.Equal(t, byte(pbv1.ValueTypeStr), cm.marshal(nil)[len(encoding.EncodeBytes(nil, []byte("str")))])
+}
+
+func Test_columnStats_merge(t *testing.T) {
+	cs := columnStats{}
+	cs.merge(&columnStats{Count: 2, FloatSum: 3, FloatMin: 1, FloatMax: 2})
+	cs.merge(&columnStats{})
+	cs.merge(&columnStats{Count: 1, FloatSum: -4, FloatMin: -4, FloatMax: -4})
+	assert.Equal(t, columnStats{Count: 3, FloatSum: -1, FloatMin: -4, FloatMax: 2}, cs)
+}
+
 func Test_columnFamilyMetadata_reset(t *testing.T) {
 	cfm := &columnFamilyMetadata{
 		columnMetadata: []columnMetadata{
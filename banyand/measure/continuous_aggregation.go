@@ -0,0 +1,988 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiData "github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/api/validate"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/flow"
+	"github.com/apache/skywalking-banyandb/pkg/flow/streaming"
+	"github.com/apache/skywalking-banyandb/pkg/flow/streaming/sources"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	continuousAggregationDir                = "continuous-aggregation"
+	continuousAggregationCheckpointInterval = 30 * time.Second
+	continuousAggregationBufferSize         = 1024
+)
+
+var (
+	_ flow.Sink                   = (*continuousAggregationProcessor)(nil)
+	_ flow.MergeableAggregationOp = (*continuousAggregationOp)(nil)
+
+	continuousAggregationScope = measureScope.SubScope("continuous_aggregation")
+)
+
+type continuousAggregationMetrics struct {
+	lag     meter.Gauge
+	in      meter.Counter
+	written meter.Counter
+	errors  meter.Counter
+}
+
+func newContinuousAggregationMetrics(omr observability.MetricsRegistry) *continuousAggregationMetrics {
+	factory := omr.With(continuousAggregationScope)
+	return &continuousAggregationMetrics{
+		lag:     factory.NewGauge("lag_seconds", "group", "name"),
+		in:      factory.NewCounter("in_total", "group", "name"),
+		written: factory.NewCounter("written_total", "group", "name"),
+		errors:  factory.NewCounter("errors_total", "group", "name"),
+	}
+}
+
+// continuousAggregationRegistry runs the continuous aggregations defined in the schema.
+// The open windows are checkpointed periodically so that the aggregations survive restarts.
+type continuousAggregationRegistry struct {
+	lfs        fs.FileSystem
+	pipeline   queue.Client
+	metadata   continuousAggregationSchemaGetter
+	l          *logger.Logger
+	metrics    *continuousAggregationMetrics
+	processors map[string]*continuousAggregationProcessor
+	sources    map[string][]*continuousAggregationProcessor
+	stopCh     chan struct{}
+	root       string
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
+	closed     bool
+}
+
+// continuousAggregationSchemaGetter fetches the schemas the results are written into.
+type continuousAggregationSchemaGetter interface {
+	GetGroup(ctx context.Context, group string) (*commonv1.Group, error)
+	GetMeasure(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Measure, error)
+}
+
+type repoSchemaGetter struct {
+	repo metadata.Repo
+}
+
+func (g repoSchemaGetter) GetGroup(ctx context.Context, group string) (*commonv1.Group, error) {
+	return g.repo.GroupRegistry().GetGroup(ctx, group)
+}
+
+func (g repoSchemaGetter) GetMeasure(ctx context.Context, md *commonv1.Metadata) (*databasev1.Measure, error) {
+	return g.repo.MeasureRegistry().GetMeasure(ctx, md)
+}
+
+func newContinuousAggregationRegistry(root string, lfs fs.FileSystem, pipeline queue.Client, metadata continuousAggregationSchemaGetter,
+	l *logger.Logger, omr observability.MetricsRegistry,
+) *continuousAggregationRegistry {
+	r := &continuousAggregationRegistry{
+		root:       filepath.Join(root, continuousAggregationDir),
+		lfs:        lfs,
+		pipeline:   pipeline,
+		metadata:   metadata,
+		l:          l,
+		metrics:    newContinuousAggregationMetrics(omr),
+		processors: make(map[string]*continuousAggregationProcessor),
+		sources:    make(map[string][]*continuousAggregationProcessor),
+		stopCh:     make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.checkpointLoop()
+	return r
+}
+
+func continuousAggregationSourceKey(catalog commonv1.Catalog, md *commonv1.Metadata) string {
+	return catalog.String() + "/" + getKey(md)
+}
+
+func (r *continuousAggregationRegistry) register(ca *databasev1.ContinuousAggregation) {
+	if r == nil {
+		return
+	}
+	if err := validate.ContinuousAggregation(ca); err != nil {
+		r.l.Warn().Err(err).Msg("continuous aggregation is ignored")
+		return
+	}
+	key := getKey(ca.GetMetadata())
+	r.mu.RLock()
+	prev, closed := r.processors[key], r.closed
+	r.mu.RUnlock()
+	if closed || (prev != nil && prev.ca.GetMetadata().GetModRevision() >= ca.GetMetadata().GetModRevision()) {
+		return
+	}
+	p, err := r.newProcessor(ca)
+	if err != nil {
+		r.l.Err(err).Str("continuousAggregation", key).Msg("fail to start continuous aggregation")
+		return
+	}
+	p.restore()
+	p.start()
+	// the processors are closed out of the lock, since their results might be written into the sources of others.
+	r.mu.Lock()
+	prev = r.processors[key]
+	if r.closed || (prev != nil && prev.ca.GetMetadata().GetModRevision() >= ca.GetMetadata().GetModRevision()) {
+		r.mu.Unlock()
+		r.closeProcessor(p)
+		return
+	}
+	if prev != nil {
+		r.removeLocked(key)
+	}
+	r.processors[key] = p
+	sourceKey := continuousAggregationSourceKey(ca.GetSourceCatalog(), ca.GetSource())
+	r.sources[sourceKey] = append(r.sources[sourceKey], p)
+	r.mu.Unlock()
+	if prev != nil {
+		r.closeProcessor(prev)
+	}
+}
+
+func (r *continuousAggregationRegistry) closeProcessor(p *continuousAggregationProcessor) {
+	if err := p.Close(); err != nil {
+		r.l.Err(err).Str("continuousAggregation", getKey(p.ca.GetMetadata())).Msg("fail to close the processor")
+	}
+}
+
+func (r *continuousAggregationRegistry) unregister(md *commonv1.Metadata) {
+	if r == nil {
+		return
+	}
+	key := getKey(md)
+	r.mu.Lock()
+	p, ok := r.processors[key]
+	if ok {
+		r.removeLocked(key)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	r.closeProcessor(p)
+	if err := p.removeCheckpoint(); err != nil {
+		r.l.Warn().Err(err).Str("continuousAggregation", key).Msg("fail to remove the checkpoint")
+	}
+}
+
+// unregisterGroup stops the aggregations reading from, writing into or belonging to the group.
+// Their checkpoints are kept, since the group might be recreated with the same schemas.
+func (r *continuousAggregationRegistry) unregisterGroup(group string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	var stopped []*continuousAggregationProcessor
+	for key, p := range r.processors {
+		if p.ca.GetMetadata().GetGroup() == group || p.ca.GetSource().GetGroup() == group || p.ca.GetTarget().GetGroup() == group {
+			r.removeLocked(key)
+			stopped = append(stopped, p)
+		}
+	}
+	r.mu.Unlock()
+	for _, p := range stopped {
+		if err := p.checkpoint(); err != nil {
+			r.l.Warn().Err(err).Str("continuousAggregation", getKey(p.ca.GetMetadata())).Msg("fail to checkpoint")
+		}
+		r.closeProcessor(p)
+	}
+}
+
+func (r *continuousAggregationRegistry) removeLocked(key string) {
+	p := r.processors[key]
+	delete(r.processors, key)
+	sourceKey := continuousAggregationSourceKey(p.ca.GetSourceCatalog(), p.ca.GetSource())
+	list := r.sources[sourceKey]
+	for i := range list {
+		if list[i] == p {
+			// copy on write, the feeders might be iterating the previous list.
+			newList := make([]*continuousAggregationProcessor, 0, len(list)-1)
+			newList = append(newList, list[:i]...)
+			newList = append(newList, list[i+1:]...)
+			list = newList
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(r.sources, sourceKey)
+	} else {
+		r.sources[sourceKey] = list
+	}
+}
+
+func (r *continuousAggregationRegistry) feed(sourceKey string, newRecord func() flow.StreamRecord) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	processors := r.sources[sourceKey]
+	r.mu.RUnlock()
+	if len(processors) == 0 {
+		return
+	}
+	record := newRecord()
+	for _, p := range processors {
+		p.feed(record)
+	}
+}
+
+func (r *continuousAggregationRegistry) onMeasureWrite(m *databasev1.Measure, dp *measurev1.DataPointValue, spec *measurev1.DataPointSpec) {
+	r.feed(continuousAggregationSourceKey(commonv1.Catalog_CATALOG_MEASURE, m.GetMetadata()), func() flow.StreamRecord {
+		return flow.NewStreamRecordWithTimestampPb(&continuousAggregationRecord{
+			tagSpec:     buildTagSpecRegistryFromSpec(spec, m),
+			tagFamilies: dp.GetTagFamilies(),
+			fieldIndex:  buildFieldIndex(spec, m),
+			fields:      dp.GetFields(),
+		}, dp.GetTimestamp())
+	})
+}
+
+func (r *continuousAggregationRegistry) onStreamWrite(s *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) {
+	r.feed(continuousAggregationSourceKey(commonv1.Catalog_CATALOG_STREAM, s.GetMetadata()), func() flow.StreamRecord {
+		tagSpec := logical.TagSpecMap{}
+		if spec != nil {
+			for i, family := range spec {
+				for j, tagName := range family.GetTagNames() {
+					tagSpec.RegisterTag(i, j, &databasev1.TagSpec{Name: tagName})
+				}
+			}
+		} else {
+			tagSpec.RegisterTagFamilies(s.GetTagFamilies())
+		}
+		return flow.NewStreamRecordWithTimestampPb(&continuousAggregationRecord{
+			tagSpec:     tagSpec,
+			tagFamilies: element.GetTagFamilies(),
+		}, element.GetTimestamp())
+	})
+}
+
+func (r *continuousAggregationRegistry) checkpointLoop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(continuousAggregationCheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.RLock()
+			processors := make([]*continuousAggregationProcessor, 0, len(r.processors))
+			for _, p := range r.processors {
+				processors = append(processors, p)
+			}
+			r.mu.RUnlock()
+			for _, p := range processors {
+				if err := p.checkpoint(); err != nil {
+					r.l.Warn().Err(err).Str("continuousAggregation", getKey(p.ca.GetMetadata())).Msg("fail to checkpoint")
+				}
+			}
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// Close checkpoints and stops all aggregations.
+func (r *continuousAggregationRegistry) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	processors := r.processors
+	r.processors = make(map[string]*continuousAggregationProcessor)
+	r.sources = make(map[string][]*continuousAggregationProcessor)
+	r.mu.Unlock()
+	close(r.stopCh)
+	r.wg.Wait()
+	var err error
+	for _, p := range processors {
+		err = multierr.Append(err, p.checkpoint())
+		err = multierr.Append(err, p.Close())
+	}
+	return err
+}
+
+func (r *continuousAggregationRegistry) newProcessor(ca *databasev1.ContinuousAggregation) (*continuousAggregationProcessor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	target, err := r.metadata.GetMeasure(ctx, ca.GetTarget())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "fail to get the target measure %s", getKey(ca.GetTarget()))
+	}
+	group, err := r.metadata.GetGroup(ctx, ca.GetTarget().GetGroup())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "fail to get the group of the target measure %s", getKey(ca.GetTarget()))
+	}
+	interval, err := timestamp.ParseDuration(target.GetInterval())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid interval %s of the target measure %s", target.GetInterval(), getKey(ca.GetTarget()))
+	}
+	window, err := parseTopNWindow(ca.GetWindow(), interval)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid window")
+	}
+	flushInterval := interval
+	if flushInterval > maxFlushInterval {
+		flushInterval = maxFlushInterval
+	}
+	assigner := window.assigner(flushInterval)
+	p := &continuousAggregationProcessor{
+		assigner:         assigner,
+		windows:          assigner.(flow.CheckpointableWindows),
+		registry:         r,
+		ca:               ca,
+		target:           target,
+		shardNum:         group.GetResourceOpts().GetShardNum(),
+		interval:         interval,
+		allowedLateness:  window.allowedLateness,
+		entityLocator:    partition.NewEntityLocator(target.GetTagFamilies(), target.GetEntity(), target.GetMetadata().GetModRevision()),
+		groupByIndex:     make(map[string]int, len(ca.GetGroupByTagNames())),
+		fieldAggregation: make([]int, len(target.GetFields())),
+		labelValues:      []string{ca.GetMetadata().GetGroup(), ca.GetMetadata().GetName()},
+		in:               make(chan flow.StreamRecord, continuousAggregationBufferSize),
+		src:              make(chan any),
+		sinkIn:           make(chan flow.StreamRecord),
+		done:             make(chan struct{}),
+		stopCh:           make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	if target.GetShardingKey() != nil && len(target.GetShardingKey().GetTagNames()) > 0 {
+		l := partition.NewShardingKeyLocator(target.GetTagFamilies(), target.GetShardingKey())
+		p.shardingKeyLocator = &l
+	}
+	for i, tagName := range ca.GetGroupByTagNames() {
+		p.groupByIndex[tagName] = i
+	}
+	for i, fieldSpec := range target.GetFields() {
+		p.fieldAggregation[i] = -1
+		for j, a := range ca.GetAggregations() {
+			if a.GetTargetField() == fieldSpec.GetName() {
+				p.fieldAggregation[i] = j
+				break
+			}
+		}
+	}
+	if p.filter, err = buildContinuousAggregationFilter(ca.GetCriteria()); err != nil {
+		return nil, errors.WithMessage(err, "invalid criteria")
+	}
+	return p, nil
+}
+
+// continuousAggregationRecord is a data point or an element written into the source.
+type continuousAggregationRecord struct {
+	tagSpec     logical.TagSpecRegistry
+	fieldIndex  map[string]int
+	tagFamilies []*modelv1.TagFamilyForWrite
+	fields      []*modelv1.FieldValue
+}
+
+func (r *continuousAggregationRecord) tagValue(tagName string) *modelv1.TagValue {
+	tagSpec := r.tagSpec.FindTagSpecByName(tagName)
+	if tagSpec == nil || tagSpec.TagFamilyIdx >= len(r.tagFamilies) {
+		return pbv1.NullTagValue
+	}
+	tags := r.tagFamilies[tagSpec.TagFamilyIdx].GetTags()
+	if tagSpec.TagIdx >= len(tags) {
+		return pbv1.NullTagValue
+	}
+	return tags[tagSpec.TagIdx]
+}
+
+// numericValue returns the value of a field, or of an int tag if there is no such field.
+func (r *continuousAggregationRecord) numericValue(name string) (float64, bool) {
+	if idx, ok := r.fieldIndex[name]; ok {
+		if idx >= len(r.fields) {
+			return 0, false
+		}
+		switch v := r.fields[idx].GetValue().(type) {
+		case *modelv1.FieldValue_Int:
+			return float64(v.Int.GetValue()), true
+		case *modelv1.FieldValue_Float:
+			return v.Float.GetValue(), true
+		default:
+			return 0, false
+		}
+	}
+	if v := r.tagValue(name).GetInt(); v != nil {
+		return float64(v.GetValue()), true
+	}
+	return 0, false
+}
+
+func buildContinuousAggregationFilter(criteria *modelv1.Criteria) (flow.UnaryFunc[bool], error) {
+	if criteria == nil {
+		return func(_ context.Context, _ any) bool {
+			return true
+		}, nil
+	}
+	f, err := logical.BuildSimpleTagFilter(criteria)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, data any) bool {
+		record := data.(*continuousAggregationRecord)
+		ok, matchErr := f.Match(logical.TagFamiliesForWrite(record.tagFamilies), record.tagSpec)
+		return matchErr == nil && ok
+	}, nil
+}
+
+// continuousAggregationInput is a record mapped to the group it belongs to and the inputs of the aggregation functions.
+type continuousAggregationInput struct {
+	key    string
+	tags   []*modelv1.TagValue
+	values []float64
+	// present marks the values found in the record.
+	present []bool
+}
+
+func newContinuousAggregationMapper(ca *databasev1.ContinuousAggregation) flow.UnaryFunc[any] {
+	groupBy := ca.GetGroupByTagNames()
+	aggregations := ca.GetAggregations()
+	return func(_ context.Context, data any) any {
+		record := data.(*continuousAggregationRecord)
+		in := continuousAggregationInput{
+			tags:    make([]*modelv1.TagValue, len(groupBy)),
+			values:  make([]float64, len(aggregations)),
+			present: make([]bool, len(aggregations)),
+		}
+		groupValues := make([]string, len(groupBy))
+		for i, tagName := range groupBy {
+			in.tags[i] = record.tagValue(tagName)
+			groupValues[i] = Stringify(in.tags[i])
+		}
+		in.key = GroupName(groupValues)
+		for i, a := range aggregations {
+			if a.GetSourceName() == "" {
+				// COUNT without a source counts the records.
+				in.values[i], in.present[i] = 1, true
+				continue
+			}
+			in.values[i], in.present[i] = record.numericValue(a.GetSourceName())
+		}
+		return in
+	}
+}
+
+// continuousAggregationAccumulator keeps the partial results of an aggregation function.
+type continuousAggregationAccumulator struct {
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+func (a *continuousAggregationAccumulator) add(v float64) {
+	if a.Count == 0 || v < a.Min {
+		a.Min = v
+	}
+	if a.Count == 0 || v > a.Max {
+		a.Max = v
+	}
+	a.Count++
+	a.Sum += v
+}
+
+func (a *continuousAggregationAccumulator) merge(other continuousAggregationAccumulator) {
+	if other.Count == 0 {
+		return
+	}
+	if a.Count == 0 {
+		*a = other
+		return
+	}
+	a.Min = min(a.Min, other.Min)
+	a.Max = max(a.Max, other.Max)
+	a.Count += other.Count
+	a.Sum += other.Sum
+}
+
+// result returns the value of the function. It returns false if no value is aggregated.
+func (a continuousAggregationAccumulator) result(fn modelv1.AggregationFunction) (float64, bool) {
+	switch fn {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT:
+		return float64(a.Count), true
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM:
+		return a.Sum, true
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN:
+		return a.Min, a.Count > 0
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX:
+		return a.Max, a.Count > 0
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN:
+		if a.Count == 0 {
+			return 0, false
+		}
+		return a.Sum / float64(a.Count), true
+	default:
+		return 0, false
+	}
+}
+
+type continuousAggregationGroup struct {
+	tags         []*modelv1.TagValue
+	accumulators []continuousAggregationAccumulator
+	dirty        bool
+}
+
+// continuousAggregationOp aggregates the records of a window by groups.
+// Only the groups updated since the last snapshot are emitted.
+type continuousAggregationOp struct {
+	groups map[string]*continuousAggregationGroup
+	size   int
+	dirty  bool
+}
+
+func newContinuousAggregationOp(size int) *continuousAggregationOp {
+	return &continuousAggregationOp{
+		groups: make(map[string]*continuousAggregationGroup),
+		size:   size,
+	}
+}
+
+func (op *continuousAggregationOp) group(key string, tags []*modelv1.TagValue) *continuousAggregationGroup {
+	g, ok := op.groups[key]
+	if !ok {
+		g = &continuousAggregationGroup{
+			tags:         tags,
+			accumulators: make([]continuousAggregationAccumulator, op.size),
+		}
+		op.groups[key] = g
+	}
+	return g
+}
+
+func (op *continuousAggregationOp) Add(records []flow.StreamRecord) {
+	for _, record := range records {
+		in := record.Data().(continuousAggregationInput)
+		g := op.group(in.key, in.tags)
+		for i := range in.values {
+			if in.present[i] {
+				g.accumulators[i].add(in.values[i])
+			}
+		}
+		g.dirty = true
+		op.dirty = true
+	}
+}
+
+func (op *continuousAggregationOp) Merge(other flow.AggregationOp) {
+	o := other.(*continuousAggregationOp)
+	for key, og := range o.groups {
+		g := op.group(key, og.tags)
+		for i := range og.accumulators {
+			g.accumulators[i].merge(og.accumulators[i])
+		}
+		g.dirty = true
+		op.dirty = true
+	}
+}
+
+// Snapshot returns the copies of the dirty groups.
+func (op *continuousAggregationOp) Snapshot() interface{} {
+	snapshot := make([]continuousAggregationGroup, 0, len(op.groups))
+	for _, g := range op.groups {
+		if !g.dirty {
+			continue
+		}
+		g.dirty = false
+		snapshot = append(snapshot, continuousAggregationGroup{
+			tags:         g.tags,
+			accumulators: append([]continuousAggregationAccumulator(nil), g.accumulators...),
+		})
+	}
+	op.dirty = false
+	return snapshot
+}
+
+func (op *continuousAggregationOp) Dirty() bool {
+	return op.dirty
+}
+
+type continuousAggregationCheckpoint struct {
+	Windows     []continuousAggregationWindowState `json:"windows"`
+	ModRevision int64                              `json:"mod_revision"`
+}
+
+type continuousAggregationWindowState struct {
+	Groups []continuousAggregationGroupState `json:"groups"`
+	Start  int64                             `json:"start"`
+	End    int64                             `json:"end"`
+}
+
+type continuousAggregationGroupState struct {
+	Key          string                             `json:"key"`
+	Tags         [][]byte                           `json:"tags"`
+	Accumulators []continuousAggregationAccumulator `json:"accumulators"`
+	Dirty        bool                               `json:"dirty"`
+}
+
+func (op *continuousAggregationOp) save() ([]continuousAggregationGroupState, error) {
+	states := make([]continuousAggregationGroupState, 0, len(op.groups))
+	for key, g := range op.groups {
+		state := continuousAggregationGroupState{
+			Key:          key,
+			Tags:         make([][]byte, len(g.tags)),
+			Accumulators: g.accumulators,
+			Dirty:        g.dirty,
+		}
+		for i, tag := range g.tags {
+			data, err := proto.Marshal(tag)
+			if err != nil {
+				return nil, err
+			}
+			state.Tags[i] = data
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (op *continuousAggregationOp) load(states []continuousAggregationGroupState) error {
+	for _, state := range states {
+		if len(state.Accumulators) != op.size {
+			return errors.Errorf("group %s has %d accumulators, want %d", state.Key, len(state.Accumulators), op.size)
+		}
+		tags := make([]*modelv1.TagValue, len(state.Tags))
+		for i, data := range state.Tags {
+			tags[i] = &modelv1.TagValue{}
+			if err := proto.Unmarshal(data, tags[i]); err != nil {
+				return err
+			}
+		}
+		op.groups[state.Key] = &continuousAggregationGroup{
+			tags:         tags,
+			accumulators: state.Accumulators,
+			dirty:        state.Dirty,
+		}
+		op.dirty = op.dirty || state.Dirty
+	}
+	return nil
+}
+
+// continuousAggregationProcessor runs the flow of a continuous aggregation,
+// and writes the results of the windows into the target measure.
+type continuousAggregationProcessor struct {
+	fieldAggregation   []int
+	labelValues        []string
+	registry           *continuousAggregationRegistry
+	assigner           flow.WindowAssigner
+	windows            flow.CheckpointableWindows
+	streamingFlow      flow.Flow
+	shardingKeyLocator *partition.Locator
+	filter             flow.UnaryFunc[bool]
+	ca                 *databasev1.ContinuousAggregation
+	target             *databasev1.Measure
+	ctx                context.Context
+	cancel             context.CancelFunc
+	errCh              <-chan error
+	in                 chan flow.StreamRecord
+	src                chan any
+	sinkIn             chan flow.StreamRecord
+	done               chan struct{}
+	stopCh             chan struct{}
+	groupByIndex       map[string]int
+	entityLocator      partition.Locator
+	flow.ComponentState
+	forwarder       sync.WaitGroup
+	interval        time.Duration
+	allowedLateness time.Duration
+	shardNum        uint32
+}
+
+func (p *continuousAggregationProcessor) checkpointPath() string {
+	return filepath.Join(p.registry.root, p.ca.GetMetadata().GetGroup(), p.ca.GetMetadata().GetName()+".json")
+}
+
+func (p *continuousAggregationProcessor) start() {
+	src, _ := sources.NewChannel(p.src)
+	name := p.ca.GetMetadata().GetGroup() + "-" + p.ca.GetMetadata().GetName()
+	size := len(p.ca.GetAggregations())
+	p.streamingFlow = streaming.New(name, src).
+		Filter(p.filter).
+		Map(newContinuousAggregationMapper(p.ca))
+	p.errCh = p.streamingFlow.Window(p.assigner).
+		AllowedLateness(p.allowedLateness).
+		Aggregate(func() flow.AggregationOp {
+			return newContinuousAggregationOp(size)
+		}).
+		To(p).Open()
+	go p.handleError()
+	p.forwarder.Add(1)
+	go p.forward()
+}
+
+// restore resumes the windows checkpointed by the same revision of the schema.
+func (p *continuousAggregationProcessor) restore() {
+	cpPath := p.checkpointPath()
+	lfs := p.registry.lfs
+	if !lfs.IsExist(cpPath) {
+		return
+	}
+	l := p.registry.l.Warn().Str("continuousAggregation", getKey(p.ca.GetMetadata()))
+	data, err := lfs.Read(cpPath)
+	if err != nil {
+		l.Err(err).Msg("fail to read the checkpoint")
+		return
+	}
+	var state continuousAggregationCheckpoint
+	if err = json.Unmarshal(data, &state); err != nil {
+		l.Err(err).Msg("fail to parse the checkpoint")
+		return
+	}
+	if state.ModRevision != p.ca.GetMetadata().GetModRevision() {
+		l.Int64("checkpointRevision", state.ModRevision).Msg("ignore the checkpoint of another schema revision")
+		return
+	}
+	checkpoints := make([]flow.WindowCheckpoint, 0, len(state.Windows))
+	for _, w := range state.Windows {
+		op := newContinuousAggregationOp(len(p.ca.GetAggregations()))
+		if err = op.load(w.Groups); err != nil {
+			l.Err(err).Msg("fail to load the checkpoint")
+			return
+		}
+		checkpoints = append(checkpoints, flow.WindowCheckpoint{Aggregation: op, Start: w.Start, End: w.End})
+	}
+	p.windows.Restore(checkpoints)
+}
+
+func (p *continuousAggregationProcessor) checkpoint() error {
+	var state continuousAggregationCheckpoint
+	var saveErr error
+	err := p.windows.Checkpoint(func(checkpoints []flow.WindowCheckpoint) {
+		state.Windows = make([]continuousAggregationWindowState, 0, len(checkpoints))
+		for _, cp := range checkpoints {
+			groups, innerErr := cp.Aggregation.(*continuousAggregationOp).save()
+			if innerErr != nil {
+				saveErr = innerErr
+				return
+			}
+			state.Windows = append(state.Windows, continuousAggregationWindowState{Start: cp.Start, End: cp.End, Groups: groups})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if saveErr != nil {
+		return saveErr
+	}
+	state.ModRevision = p.ca.GetMetadata().GetModRevision()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.WithMessage(err, "cannot marshal the checkpoint")
+	}
+	lfs := p.registry.lfs
+	name := p.checkpointPath()
+	lfs.MkdirIfNotExist(filepath.Dir(name), storage.DirPerm)
+	tmp := name + ".tmp"
+	if _, err = lfs.Write(data, tmp, storage.FilePerm); err != nil {
+		return errors.WithMessagef(err, "cannot write %s", tmp)
+	}
+	if err = lfs.Rename(tmp, name); err != nil {
+		return errors.WithMessagef(err, "cannot rename %s", tmp)
+	}
+	return nil
+}
+
+func (p *continuousAggregationProcessor) removeCheckpoint() error {
+	name := p.checkpointPath()
+	if !p.registry.lfs.IsExist(name) {
+		return nil
+	}
+	return p.registry.lfs.DeleteFile(name)
+}
+
+// feed hands the record to the flow. It blocks if the buffer is full, and gives up if the processor is closed.
+func (p *continuousAggregationProcessor) feed(record flow.StreamRecord) {
+	select {
+	case p.in <- record:
+		p.registry.metrics.in.Inc(1, p.labelValues...)
+	case <-p.done:
+	}
+}
+
+func (p *continuousAggregationProcessor) forward() {
+	defer p.forwarder.Done()
+	for {
+		select {
+		case record := <-p.in:
+			select {
+			case p.src <- record:
+			case <-p.done:
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *continuousAggregationProcessor) In() chan<- flow.StreamRecord {
+	return p.sinkIn
+}
+
+// Setup runs the writer until the processor is closed, since the flow's context is never canceled.
+func (p *continuousAggregationProcessor) Setup(_ context.Context) error {
+	p.Add(1)
+	go p.run(p.ctx)
+	return nil
+}
+
+func (p *continuousAggregationProcessor) run(ctx context.Context) {
+	defer p.Done()
+	// keep draining the results until the flow closes its output, or the windows would block the closing.
+	for record := range p.sinkIn {
+		if ctx.Err() != nil {
+			continue
+		}
+		if err := p.write(ctx, record); err != nil {
+			p.registry.metrics.errors.Inc(1, p.labelValues...)
+			p.registry.l.Err(err).Str("continuousAggregation", getKey(p.ca.GetMetadata())).Msg("fail to write the results")
+		}
+	}
+}
+
+// Teardown is called by the Flow as a lifecycle hook.
+func (p *continuousAggregationProcessor) Teardown(_ context.Context) error {
+	p.Wait()
+	return nil
+}
+
+// Close stops the flow. The open windows are dropped, so checkpoint them in advance to keep them.
+// The results being written are abandoned as well, so that an unreachable node doesn't block the closing.
+func (p *continuousAggregationProcessor) Close() error {
+	close(p.done)
+	p.cancel()
+	p.forwarder.Wait()
+	close(p.src)
+	err := p.streamingFlow.Close()
+	<-p.stopCh
+	return err
+}
+
+func (p *continuousAggregationProcessor) handleError() {
+	for err := range p.errCh {
+		p.registry.l.Err(err).Str("continuousAggregation", getKey(p.ca.GetMetadata())).
+			Msg("error occurred during flow setup or process")
+	}
+	close(p.stopCh)
+}
+
+func (p *continuousAggregationProcessor) write(ctx context.Context, record flow.StreamRecord) error {
+	groups, ok := record.Data().([]continuousAggregationGroup)
+	if !ok {
+		return errors.New("invalid data type")
+	}
+	eventTime := time.UnixMilli(record.TimestampMillis() - record.TimestampMillis()%p.interval.Milliseconds())
+	publisher := p.registry.pipeline.NewBatchPublisher(resultPersistencyTimeout)
+	defer publisher.Close()
+	var err error
+	for _, g := range groups {
+		tagFamilies := make([]*modelv1.TagFamilyForWrite, len(p.target.GetTagFamilies()))
+		for i, tf := range p.target.GetTagFamilies() {
+			tags := make([]*modelv1.TagValue, len(tf.GetTags()))
+			for j, t := range tf.GetTags() {
+				if idx, found := p.groupByIndex[t.GetName()]; found && idx < len(g.tags) {
+					tags[j] = g.tags[idx]
+				} else {
+					tags[j] = pbv1.NullTagValue
+				}
+			}
+			tagFamilies[i] = &modelv1.TagFamilyForWrite{Tags: tags}
+		}
+		fields := make([]*modelv1.FieldValue, len(p.target.GetFields()))
+		for i, fieldSpec := range p.target.GetFields() {
+			fields[i] = pbv1.NullFieldValue
+			aggIdx := p.fieldAggregation[i]
+			if aggIdx < 0 {
+				continue
+			}
+			v, hasValue := g.accumulators[aggIdx].result(p.ca.GetAggregations()[aggIdx].GetFunction())
+			if !hasValue {
+				continue
+			}
+			if fieldSpec.GetFieldType() == databasev1.FieldType_FIELD_TYPE_FLOAT {
+				fields[i] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v}}}
+			} else {
+				fields[i] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: int64(v)}}}
+			}
+		}
+		entityValues, shardID, locateErr := p.entityLocator.Locate(p.target.GetMetadata().GetName(), tagFamilies, p.shardNum)
+		if locateErr != nil {
+			err = multierr.Append(err, locateErr)
+			continue
+		}
+		if p.shardingKeyLocator != nil {
+			if _, shardID, locateErr = p.shardingKeyLocator.Locate(p.target.GetMetadata().GetName(), tagFamilies, p.shardNum); locateErr != nil {
+				err = multierr.Append(err, locateErr)
+				continue
+			}
+		}
+		iwr := &measurev1.InternalWriteRequest{
+			Request: &measurev1.WriteRequest{
+				MessageId: uint64(time.Now().UnixNano()),
+				Metadata:  p.target.GetMetadata(),
+				DataPoint: &measurev1.DataPointValue{
+					Timestamp:   timestamppb.New(eventTime),
+					TagFamilies: tagFamilies,
+					Fields:      fields,
+					Version:     time.Now().UnixNano(),
+				},
+			},
+			EntityValues: entityValues,
+			ShardId:      uint32(shardID),
+		}
+		message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), "local", iwr)
+		if _, publishErr := publisher.Publish(ctx, apiData.TopicMeasureWrite, message); publishErr != nil {
+			return multierr.Append(err, publishErr)
+		}
+		p.registry.metrics.written.Inc(1, p.labelValues...)
+	}
+	p.registry.metrics.lag.Set(time.Since(time.UnixMilli(record.TimestampMillis())).Seconds(), p.labelValues...)
+	return err
+}
@@ -0,0 +1,215 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/flow"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+var (
+	caSourceMeasure = &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "layer", Type: databasev1.TagType_TAG_TYPE_STRING},
+			},
+		}},
+		Fields: []*databasev1.FieldSpec{{Name: "value", FieldType: databasev1.FieldType_FIELD_TYPE_INT}},
+		Entity: &databasev1.Entity{TagNames: []string{"service"}},
+	}
+	caTargetMeasure = &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_hour"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "default",
+			Tags: []*databasev1.TagSpec{{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING}},
+		}},
+		Fields: []*databasev1.FieldSpec{
+			{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+			{Name: "avg", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		},
+		Entity:   &databasev1.Entity{TagNames: []string{"service"}},
+		Interval: "1h",
+	}
+)
+
+func newTestContinuousAggregation() *databasev1.ContinuousAggregation {
+	return &databasev1.ContinuousAggregation{
+		Metadata:        &commonv1.Metadata{Group: "sw_metric", Name: "service_cpm_hourly", ModRevision: 1},
+		Source:          caSourceMeasure.GetMetadata(),
+		SourceCatalog:   commonv1.Catalog_CATALOG_MEASURE,
+		GroupByTagNames: []string{"service"},
+		Aggregations: []*databasev1.ContinuousAggregationFunction{
+			{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, SourceName: "value", TargetField: "total"},
+			{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN, SourceName: "value", TargetField: "avg"},
+			{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT, TargetField: "count"},
+		},
+		Target: caTargetMeasure.GetMetadata(),
+	}
+}
+
+func newTestDataPoint(service string, value int64, ts time.Time) *measurev1.DataPointValue {
+	return &measurev1.DataPointValue{
+		Timestamp: timestamppb.New(ts),
+		TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+			{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: service}}},
+			{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "GENERAL"}}},
+		}}},
+		Fields: []*modelv1.FieldValue{{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: value}}}},
+	}
+}
+
+func mapTestDataPoint(ca *databasev1.ContinuousAggregation, dp *measurev1.DataPointValue) flow.StreamRecord {
+	record := &continuousAggregationRecord{
+		tagSpec:     buildTagSpecRegistryFromSpec(nil, caSourceMeasure),
+		tagFamilies: dp.GetTagFamilies(),
+		fieldIndex:  buildFieldIndex(nil, caSourceMeasure),
+		fields:      dp.GetFields(),
+	}
+	return flow.NewStreamRecordWithTimestampPb(newContinuousAggregationMapper(ca)(context.TODO(), record), dp.GetTimestamp())
+}
+
+func TestContinuousAggregationOp(t *testing.T) {
+	ca := newTestContinuousAggregation()
+	now := time.Now()
+	op := newContinuousAggregationOp(len(ca.GetAggregations()))
+	op.Add([]flow.StreamRecord{
+		mapTestDataPoint(ca, newTestDataPoint("svc1", 1, now)),
+		mapTestDataPoint(ca, newTestDataPoint("svc1", 4, now)),
+		mapTestDataPoint(ca, newTestDataPoint("svc2", 10, now)),
+	})
+	require.True(t, op.Dirty())
+	snapshot := op.Snapshot().([]continuousAggregationGroup)
+	require.Len(t, snapshot, 2)
+	require.False(t, op.Dirty())
+	results := make(map[string][]float64)
+	for _, g := range snapshot {
+		values := make([]float64, 0, len(ca.GetAggregations()))
+		for i, a := range ca.GetAggregations() {
+			v, ok := g.accumulators[i].result(a.GetFunction())
+			require.True(t, ok)
+			values = append(values, v)
+		}
+		results[g.tags[0].GetStr().GetValue()] = values
+	}
+	require.Equal(t, []float64{5, 2.5, 2}, results["svc1"])
+	require.Equal(t, []float64{10, 10, 1}, results["svc2"])
+
+	// only the updated groups are emitted
+	other := newContinuousAggregationOp(len(ca.GetAggregations()))
+	other.Add([]flow.StreamRecord{mapTestDataPoint(ca, newTestDataPoint("svc2", 20, now))})
+	op.Merge(other)
+	snapshot = op.Snapshot().([]continuousAggregationGroup)
+	require.Len(t, snapshot, 1)
+	v, _ := snapshot[0].accumulators[0].result(modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM)
+	require.Equal(t, float64(30), v)
+
+	states, err := op.save()
+	require.NoError(t, err)
+	loaded := newContinuousAggregationOp(len(ca.GetAggregations()))
+	require.NoError(t, loaded.load(states))
+	require.Len(t, loaded.groups, 2)
+	require.False(t, loaded.Dirty())
+	require.Equal(t, op.groups["svc1"].accumulators, loaded.groups["svc1"].accumulators)
+	require.Equal(t, "svc1", loaded.groups["svc1"].tags[0].GetStr().GetValue())
+	require.Error(t, newContinuousAggregationOp(1).load(states))
+}
+
+type fakeContinuousAggregationSchema struct{}
+
+func (fakeContinuousAggregationSchema) GetGroup(_ context.Context, group string) (*commonv1.Group, error) {
+	return &commonv1.Group{
+		Metadata:     &commonv1.Metadata{Name: group},
+		Catalog:      commonv1.Catalog_CATALOG_MEASURE,
+		ResourceOpts: &commonv1.ResourceOpts{ShardNum: 2},
+	}, nil
+}
+
+func (fakeContinuousAggregationSchema) GetMeasure(_ context.Context, _ *commonv1.Metadata) (*databasev1.Measure, error) {
+	return caTargetMeasure, nil
+}
+
+func TestContinuousAggregationCheckpoint(t *testing.T) {
+	root := t.TempDir()
+	lfs := fs.NewLocalFileSystem()
+	l := logger.GetLogger("test")
+	newRegistry := func() *continuousAggregationRegistry {
+		return newContinuousAggregationRegistry(root, lfs, nil, fakeContinuousAggregationSchema{}, l, observability.BypassRegistry)
+	}
+	sumOfWindows := func(p *continuousAggregationProcessor) float64 {
+		var sum float64
+		require.NoError(t, p.windows.Checkpoint(func(cps []flow.WindowCheckpoint) {
+			for _, cp := range cps {
+				for _, g := range cp.Aggregation.(*continuousAggregationOp).groups {
+					sum += g.accumulators[0].Sum
+				}
+			}
+		}))
+		return sum
+	}
+	ca := newTestContinuousAggregation()
+	now := time.Now()
+
+	r := newRegistry()
+	r.register(ca)
+	p := r.processors[getKey(ca.GetMetadata())]
+	require.NotNil(t, p)
+	r.onMeasureWrite(caSourceMeasure, newTestDataPoint("svc1", 1, now), nil)
+	r.onMeasureWrite(caSourceMeasure, newTestDataPoint("svc2", 2, now), nil)
+	require.Eventually(t, func() bool {
+		return sumOfWindows(p) == 3
+	}, 10*time.Second, 10*time.Millisecond)
+	// the windows are checkpointed on close
+	require.NoError(t, r.Close())
+
+	r = newRegistry()
+	defer func() {
+		require.NoError(t, r.Close())
+	}()
+	r.register(ca)
+	p = r.processors[getKey(ca.GetMetadata())]
+	require.Equal(t, float64(3), sumOfWindows(p))
+
+	// the checkpoint of another revision is ignored
+	updated := newTestContinuousAggregation()
+	updated.Metadata.ModRevision = 2
+	r.register(updated)
+	p = r.processors[getKey(ca.GetMetadata())]
+	require.Equal(t, updated, p.ca)
+	require.Zero(t, sumOfWindows(p))
+
+	r.unregister(updated.GetMetadata())
+	require.Empty(t, r.processors)
+	require.Empty(t, r.sources)
+	require.False(t, lfs.IsExist(p.checkpointPath()))
+}
@@ -108,6 +108,7 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	reservedSpace := tst.reserveSpace(parts)
 	defer releaseDiskSpace(reservedSpace)
 	start := time.Now()
+	tombstones := captureTombstones(parts)
 	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, atomic.AddUint64(&tst.curPartID, 1), tst.root)
 	if err != nil {
 		return nil, err
@@ -157,6 +158,7 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	mi.creator = creator
 	mi.newPart = newPart
 	mi.merged = merged
+	mi.tombstones = tombstones
 	mi.applied = make(chan struct{})
 	select {
 	case merges <- mi:
@@ -241,6 +243,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	for i := range parts {
 		pmi := generatePartMergeIter()
 		pmi.mustInitFromPart(parts[i].p)
+		pmi.tombstone = parts[i].p.tombstone.Load()
 		pii = append(pii, pmi)
 		totalSize += int64(parts[i].p.partMetadata.CompressedSizeBytes)
 	}
@@ -295,6 +298,10 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 
 		if pendingBlockIsEmpty {
 			br.loadBlockData(getDecoder())
+			if len(b.timestamps) == 0 {
+				// all data points in the block are deleted.
+				continue
+			}
 			pendingBlock.copyFrom(b)
 			pendingBlockIsEmpty = false
 			continue
@@ -305,6 +312,11 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 			bw.mustWriteBlock(pendingBlock.bm.seriesID, &pendingBlock.block)
 			releaseDecoder()
 			br.loadBlockData(getDecoder())
+			if len(b.timestamps) == 0 {
+				pendingBlock.reset()
+				pendingBlockIsEmpty = true
+				continue
+			}
 			pendingBlock.copyFrom(b)
 			continue
 		}
@@ -316,6 +328,9 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		tmpBlock.reset()
 		tmpBlock.bm.seriesID = b.bm.seriesID
 		br.loadBlockData(getDecoder())
+		if len(b.timestamps) == 0 {
+			continue
+		}
 		mergeTwoBlocks(tmpBlock, pendingBlock, b)
 		if len(tmpBlock.timestamps) <= maxBlockLength && tmpBlock.uncompressedSizeBytes() <= maxUncompressedBlockSize {
 			if len(tmpBlock.timestamps) == 0 {
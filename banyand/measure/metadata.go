@@ -79,17 +79,19 @@ type SchemaService interface {
 }
 type schemaRepo struct {
 	resourceSchema.Repository
-	metadata         metadata.Repo
-	pipeline         queue.Client
-	l                *logger.Logger
-	ctx              context.Context
-	cancel           context.CancelFunc
-	closingGroups    map[string]struct{}
-	topNProcessorMap sync.Map
-	nodeID           string
-	path             string
-	closingGroupsMu  sync.RWMutex
-	role             databasev1.Role
+	metadata metadata.Repo
+	pipeline queue.Client
+	// continuousAggregations is nil if the node doesn't write the results of the aggregations.
+	continuousAggregations *continuousAggregationRegistry
+	l                      *logger.Logger
+	ctx                    context.Context
+	cancel                 context.CancelFunc
+	closingGroups          map[string]struct{}
+	topNProcessorMap       sync.Map
+	nodeID                 string
+	path                   string
+	closingGroupsMu        sync.RWMutex
+	role                   databasev1.Role
 }
 
 func newSchemaRepo(path string, svc *standalone, nodeLabels map[string]string, nodeID string) *schemaRepo {
@@ -111,6 +113,9 @@ func newSchemaRepo(path string, svc *standalone, nodeLabels map[string]string, n
 		newSupplier(path, svc, sr, nodeLabels),
 		resourceSchema.NewMetrics(svc.omr.With(metadataScope)),
 	)
+	if sr.pipeline != nil {
+		sr.continuousAggregations = newContinuousAggregationRegistry(path, svc.lfs, sr.pipeline, repoSchemaGetter{repo: svc.metadata}, svc.l, svc.omr)
+	}
 	sr.start()
 	return sr
 }
@@ -133,6 +138,9 @@ func newLiaisonSchemaRepo(path string, svc *liaison, measureDataNodeRegistry grp
 		newQueueSupplier(path, svc, measureDataNodeRegistry),
 		resourceSchema.NewMetrics(svc.omr.With(metadataScope)),
 	)
+	if sr.pipeline != nil {
+		sr.continuousAggregations = newContinuousAggregationRegistry(path, svc.lfs, sr.pipeline, repoSchemaGetter{repo: svc.metadata}, svc.l, svc.omr)
+	}
 	sr.start()
 	return sr
 }
@@ -165,8 +173,8 @@ func (sr *schemaRepo) isGroupClosing(group string) bool {
 func (sr *schemaRepo) start() {
 	sr.Watcher()
 	sr.metadata.
-		RegisterHandler("measure", schema.KindGroup|schema.KindMeasure|schema.KindIndexRuleBinding|schema.KindIndexRule|schema.KindTopNAggregation,
-			sr)
+		RegisterHandler("measure", schema.KindGroup|schema.KindMeasure|schema.KindIndexRuleBinding|schema.KindIndexRule|schema.KindTopNAggregation|
+			schema.KindContinuousAggregation, sr)
 }
 
 func (sr *schemaRepo) Measure(metadata *commonv1.Metadata) (Measure, error) {
@@ -190,7 +198,7 @@ func (sr *schemaRepo) GetRemovalSegmentsTimeRange(group string) *timestamp.TimeR
 }
 
 func (sr *schemaRepo) OnInit(kinds []schema.Kind) (bool, []int64) {
-	if len(kinds) != 5 {
+	if len(kinds) != 6 {
 		logger.Panicf("unexpected kinds: %v", kinds)
 		return false, nil
 	}
@@ -277,6 +285,12 @@ func (sr *schemaRepo) OnAddOrUpdate(metadata schema.Metadata) {
 			return
 		}
 		manager.register(topNSchema)
+	case schema.KindContinuousAggregation:
+		ca := metadata.Spec.(*databasev1.ContinuousAggregation)
+		if sr.isGroupClosing(ca.GetMetadata().GetGroup()) || sr.isGroupClosing(ca.GetTarget().GetGroup()) {
+			return
+		}
+		sr.continuousAggregations.register(ca)
 	default:
 	}
 }
@@ -296,6 +310,7 @@ func (sr *schemaRepo) OnDelete(metadata schema.Metadata) {
 			Metadata: g,
 		})
 		sr.stopAllProcessorsWithGroupPrefix(g.Metadata.Name)
+		sr.continuousAggregations.unregisterGroup(g.Metadata.Name)
 		// Deletion completed; allow future re-creation
 		sr.unmarkGroupClosing(g.Metadata.Name)
 	case schema.KindMeasure:
@@ -331,6 +346,8 @@ func (sr *schemaRepo) OnDelete(metadata schema.Metadata) {
 			topNAggregation := metadata.Spec.(*databasev1.TopNAggregation)
 			sr.stopSteamingManager(topNAggregation.SourceMeasure)
 		}
+	case schema.KindContinuousAggregation:
+		sr.continuousAggregations.unregister(metadata.Spec.(*databasev1.ContinuousAggregation).GetMetadata())
 	default:
 	}
 }
@@ -346,6 +363,7 @@ func (sr *schemaRepo) Close() {
 		err = multierr.Append(err, manager.Close())
 		return true
 	})
+	err = multierr.Append(err, sr.continuousAggregations.Close())
 	if err != nil {
 		sr.l.Error().Err(err).Msg("faced error when closing schema repository")
 	}
@@ -487,7 +505,17 @@ func (sr *schemaRepo) collectShardInfo(table any, shardID uint32) *databasev1.Sh
 		PartCount:         int64(partCount),
 		InvertedIndexInfo: &databasev1.InvertedIndexInfo{},
 		SidxInfo:          &databasev1.SIDXInfo{},
+		IndexBackfillInfo: sr.collectIndexBackfillInfo(tst),
+	}
+}
+
+func (sr *schemaRepo) collectIndexBackfillInfo(tst *tsTable) []*databasev1.IndexBackfillInfo {
+	progress, err := storage.LoadIndexBackfillProgress(tst.fileSystem, tst.root)
+	if err != nil {
+		sr.l.Warn().Err(err).Str("shard", tst.root).Msg("cannot load the index backfill progress")
+		return nil
 	}
+	return progress.Infos()
 }
 
 func (sr *schemaRepo) collectPendingWriteInfo(groupName string) (int64, error) {
@@ -63,6 +63,7 @@ type part struct {
 	tagFamilies          map[string]fs.Reader
 	seriesMetadata       fs.Reader // Optional: series metadata reader
 	cache                storage.Cache
+	tombstone            atomic.Pointer[tombstone]
 	path                 string
 	primaryBlockMetadata []primaryBlockMetadata
 	partMetadata         partMetadata
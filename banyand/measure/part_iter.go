@@ -252,6 +252,7 @@ func (pi *partIter) findBlock() bool {
 type partMergeIter struct {
 	seqReaders           seqReaders
 	err                  error
+	tombstone            *tombstone
 	primaryBlockMetadata []primaryBlockMetadata
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
@@ -266,6 +267,7 @@ func (pmi *partMergeIter) reset() {
 	pmi.primaryBlockMetadata = nil
 	pmi.primaryMetadataIdx = 0
 	pmi.partID = 0
+	pmi.tombstone = nil
 	pmi.primaryBuf = pmi.primaryBuf[:0]
 	pmi.compressedPrimaryBuf = pmi.compressedPrimaryBuf[:0]
 	pmi.block.reset()
@@ -332,6 +334,9 @@ func (pmi *partMergeIter) loadBlockMetadata() error {
 
 func (pmi *partMergeIter) mustLoadBlockData(decoder *encoding.BytesBlockDecoder, block *blockPointer) {
 	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm)
+	if pmi.tombstone.removeFrom(block.bm.seriesID, &block.block) {
+		block.updateMetadata()
+	}
 }
 
 func generatePartMergeIter() *partMergeIter {
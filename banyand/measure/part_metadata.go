@@ -30,13 +30,16 @@ import (
 )
 
 type partMetadata struct {
-	CompressedSizeBytes   uint64 `json:"compressedSizeBytes"`
-	UncompressedSizeBytes uint64 `json:"uncompressedSizeBytes"`
-	TotalCount            uint64 `json:"totalCount"`
-	BlocksCount           uint64 `json:"blocksCount"`
-	MinTimestamp          int64  `json:"minTimestamp"`
-	MaxTimestamp          int64  `json:"maxTimestamp"`
-	ID                    uint64 `json:"-"`
+	// FieldStats is the stats of the numeric fields in all blocks.
+	// It's absent from the parts written by the older versions or received by the chunked sync.
+	FieldStats            map[string]*columnStats `json:"fieldStats,omitempty"`
+	CompressedSizeBytes   uint64                  `json:"compressedSizeBytes"`
+	UncompressedSizeBytes uint64                  `json:"uncompressedSizeBytes"`
+	TotalCount            uint64                  `json:"totalCount"`
+	BlocksCount           uint64                  `json:"blocksCount"`
+	MinTimestamp          int64                   `json:"minTimestamp"`
+	MaxTimestamp          int64                   `json:"maxTimestamp"`
+	ID                    uint64                  `json:"-"`
 }
 
 func (pm *partMetadata) reset() {
@@ -47,6 +50,7 @@ func (pm *partMetadata) reset() {
 	pm.MinTimestamp = 0
 	pm.MaxTimestamp = 0
 	pm.ID = 0
+	pm.FieldStats = nil
 }
 
 func (pm *partMetadata) fillFromSyncContext(ctx *queue.ChunkedSyncPartContext) {
@@ -64,6 +64,7 @@ type Measure interface {
 	Query(ctx context.Context, opts model.MeasureQueryOptions) (model.MeasureQueryResult, error)
 	GetSchema() *databasev1.Measure
 	GetIndexRules() []*databasev1.IndexRule
+	Delete(tr timestamp.TimeRange, keys []DataPointKey) error
 }
 
 var _ Measure = (*measure)(nil)
@@ -550,6 +551,7 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 	}
 	var hit int
 	var totalBlockBytes uint64
+	var stats map[*blockCursor]*columnStats
 	quota := m.pm.AvailableBytes()
 	for tstIter.nextBlock() {
 		if hit%checkDoneEvery == 0 {
@@ -565,6 +567,13 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 		bc.init(p.p, p.curBlock, qo)
 		bc.shardID = p.p.shardID
 		result.data = append(result.data, bc)
+		if cs := aggregatedStats(p.p, &bc.bm, qo); cs != nil {
+			if stats == nil {
+				stats = make(map[*blockCursor]*columnStats)
+			}
+			stats[bc] = cs
+			continue
+		}
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
 		if quota >= 0 && totalBlockBytes > uint64(quota) {
 			return fmt.Errorf("block scan quota exceeded: used %d bytes, quota is %d bytes", totalBlockBytes, quota)
@@ -573,14 +582,17 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 	if tstIter.Error() != nil {
 		return fmt.Errorf("cannot iterate tstIter: %w", tstIter.Error())
 	}
-	if err := m.pm.AcquireResource(ctx, totalBlockBytes); err != nil {
-		return err
-	}
 	result.sidToIndex = make(map[common.SeriesID]int)
 	for i, si := range originalSids {
 		result.sidToIndex[si] = i
 	}
-	return nil
+	if stats != nil {
+		totalBlockBytes = result.answerFromStats(stats, qo)
+		if quota >= 0 && totalBlockBytes > uint64(quota) {
+			return fmt.Errorf("block scan quota exceeded: used %d bytes, quota is %d bytes", totalBlockBytes, quota)
+		}
+	}
+	return m.pm.AcquireResource(ctx, totalBlockBytes)
 }
 
 func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValue {
@@ -742,6 +754,7 @@ type queryResult struct {
 	storedIndexValue map[common.SeriesID]map[string]*modelv1.TagValue
 	tagProjection    []model.TagProjection
 	data             []*blockCursor
+	aggregated       []*model.MeasureResult
 	snapshots        []*snapshot
 	segments         []storage.Segment[*tsTable, option]
 	hit              int
@@ -760,7 +773,7 @@ func (qr *queryResult) Pull() *model.MeasureResult {
 	}
 	if !qr.loaded {
 		if len(qr.data) == 0 {
-			return nil
+			return qr.pullAggregated()
 		}
 
 		cursorChan := make(chan int, len(qr.data))
@@ -809,7 +822,7 @@ func (qr *queryResult) Pull() *model.MeasureResult {
 		heap.Init(qr)
 	}
 	if len(qr.data) == 0 {
-		return nil
+		return qr.pullAggregated()
 	}
 	if len(qr.data) == 1 {
 		r := &model.MeasureResult{}
@@ -832,6 +845,7 @@ func (qr *queryResult) Release() {
 		qr.data[i] = nil
 	}
 	qr.data = qr.data[:0]
+	qr.aggregated = nil
 	for i := range qr.snapshots {
 		qr.snapshots[i].decRef()
 	}
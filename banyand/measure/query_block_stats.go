@@ -0,0 +1,209 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"sort"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// aggregatedStats returns the stats of the aggregated field if the block can be answered from them.
+// The block must lie in the time range, has no deleted data point and no null value of the field.
+func aggregatedStats(p *part, bm *blockMetadata, qo queryOptions) *columnStats {
+	agg := qo.Aggregation
+	if agg == nil || bm.timestamps.min < qo.minTimestamp || bm.timestamps.max > qo.maxTimestamp {
+		return nil
+	}
+	if p.tombstone.Load().hasSeries(bm.seriesID) {
+		return nil
+	}
+	for i := range bm.field.columnMetadata {
+		cm := &bm.field.columnMetadata[i]
+		if cm.name != agg.FieldName {
+			continue
+		}
+		if !cm.hasStats || cm.stats.Count != bm.count {
+			return nil
+		}
+		return &cm.stats
+	}
+	return nil
+}
+
+// overlappedBlocks returns the blocks whose time range overlaps another block of the same series.
+// Their data points might be duplicated and have to be deduplicated by the version.
+func overlappedBlocks(cursors []*blockCursor) map[*blockCursor]struct{} {
+	sorted := make([]*blockCursor, len(cursors))
+	copy(sorted, cursors)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bm.seriesID != sorted[j].bm.seriesID {
+			return sorted[i].bm.seriesID < sorted[j].bm.seriesID
+		}
+		return sorted[i].bm.timestamps.min < sorted[j].bm.timestamps.min
+	})
+	overlapped := make(map[*blockCursor]struct{})
+	var last *blockCursor
+	for _, bc := range sorted {
+		if last != nil && last.bm.seriesID == bc.bm.seriesID && bc.bm.timestamps.min <= last.bm.timestamps.max {
+			overlapped[last] = struct{}{}
+			overlapped[bc] = struct{}{}
+			if bc.bm.timestamps.max <= last.bm.timestamps.max {
+				continue
+			}
+		}
+		last = bc
+	}
+	return overlapped
+}
+
+// leadingBlock returns the block whose first data point leads the result in the query order.
+func leadingBlock(stats map[*blockCursor]*columnStats, qo queryOptions, sidToIndex map[common.SeriesID]int) *blockCursor {
+	orderByTS := qo.Order == nil || qo.Order.Type == index.OrderByTypeTime
+	desc := qo.Order != nil && qo.Order.Sort == modelv1.Sort_SORT_DESC
+	var leading *blockCursor
+	for bc := range stats {
+		if leading == nil {
+			leading = bc
+			continue
+		}
+		l, r := &leading.bm, &bc.bm
+		switch {
+		case !orderByTS && sidToIndex[r.seriesID] != sidToIndex[l.seriesID]:
+			if sidToIndex[r.seriesID] < sidToIndex[l.seriesID] {
+				leading = bc
+			}
+		case orderByTS && desc && r.timestamps.max != l.timestamps.max:
+			if r.timestamps.max > l.timestamps.max {
+				leading = bc
+			}
+		case (!orderByTS || !desc) && r.timestamps.min != l.timestamps.min:
+			if r.timestamps.min < l.timestamps.min {
+				leading = bc
+			}
+		case r.seriesID < l.seriesID:
+			leading = bc
+		}
+	}
+	return leading
+}
+
+// answerFromStats replaces the cursors of the blocks answered from their stats with the aggregated results.
+// The leading block is still loaded, so that the aggregation takes the tags from the same data point as before.
+// It returns the uncompressed size of the blocks which still need to be loaded.
+func (qr *queryResult) answerFromStats(stats map[*blockCursor]*columnStats, qo queryOptions) uint64 {
+	delete(stats, leadingBlock(stats, qo, qr.sidToIndex))
+	overlapped := overlappedBlocks(qr.data)
+	var totalBlockBytes uint64
+	data := qr.data[:0]
+	for _, bc := range qr.data {
+		cs, ok := stats[bc]
+		if _, found := overlapped[bc]; !ok || found {
+			data = append(data, bc)
+			totalBlockBytes += bc.bm.uncompressedSizeBytes
+			continue
+		}
+		qr.aggregated = append(qr.aggregated, bc.statsResult(cs, qo, qr.storedIndexValue, qr.tagProjection))
+		releaseBlockCursor(bc)
+	}
+	qr.data = data
+	return totalBlockBytes
+}
+
+// pullAggregated returns the results answered from the block stats after all loaded data points.
+func (qr *queryResult) pullAggregated() *model.MeasureResult {
+	if len(qr.aggregated) == 0 {
+		return nil
+	}
+	r := qr.aggregated[0]
+	qr.aggregated = qr.aggregated[1:]
+	return r
+}
+
+// statsResult builds a data point holding the partial aggregation of the block.
+func (bc *blockCursor) statsResult(cs *columnStats, qo queryOptions,
+	storedIndexValue map[common.SeriesID]map[string]*modelv1.TagValue, tagProjection []model.TagProjection,
+) *model.MeasureResult {
+	r := &model.MeasureResult{
+		SID:              bc.bm.seriesID,
+		Timestamps:       []int64{bc.bm.timestamps.min},
+		Versions:         []int64{bc.bm.timestamps.versionFirst},
+		ShardIDs:         []common.ShardID{bc.shardID},
+		AggregatedCounts: []int64{int64(cs.Count)},
+	}
+	var indexValue map[string]*modelv1.TagValue
+	if storedIndexValue != nil {
+		indexValue = storedIndexValue[r.SID]
+	}
+	for _, tp := range tagProjection {
+		tf := model.TagFamily{Name: tp.Family}
+		for _, n := range tp.Names {
+			v := pbv1.NullTagValue
+			if indexValue != nil && indexValue[n] != nil {
+				v = indexValue[n]
+			}
+			tf.Tags = append(tf.Tags, model.Tag{Name: n, Values: []*modelv1.TagValue{v}})
+		}
+		r.TagFamilies = append(r.TagFamilies, tf)
+	}
+	valueType := pbv1.ValueTypeFloat64
+	for _, cm := range bc.bm.field.columnMetadata {
+		if cm.name == qo.Aggregation.FieldName {
+			valueType = cm.valueType
+			break
+		}
+	}
+	for _, n := range qo.FieldProjection {
+		v := pbv1.NullFieldValue
+		if n == qo.Aggregation.FieldName {
+			v = cs.partialValue(valueType, qo.Aggregation.Function)
+		}
+		r.Fields = append(r.Fields, model.Field{Name: n, Values: []*modelv1.FieldValue{v}})
+	}
+	return r
+}
+
+// partialValue returns the partial aggregation of the stats.
+// MEAN returns the sum, and the count is carried along with it.
+func (cs *columnStats) partialValue(valueType pbv1.ValueType, function modelv1.AggregationFunction) *modelv1.FieldValue {
+	if function == modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT {
+		return int64FieldValue(int64(cs.Count))
+	}
+	if valueType == pbv1.ValueTypeInt64 {
+		switch function {
+		case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX:
+			return int64FieldValue(cs.IntMax)
+		case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN:
+			return int64FieldValue(cs.IntMin)
+		default:
+			return int64FieldValue(cs.IntSum)
+		}
+	}
+	switch function {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX:
+		return float64FieldValue(cs.FloatMax)
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN:
+		return float64FieldValue(cs.FloatMin)
+	default:
+		return float64FieldValue(cs.FloatSum)
+	}
+}
@@ -0,0 +1,150 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	itest "github.com/apache/skywalking-banyandb/banyand/internal/test"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func statsTestPart(t *testing.T, id uint64, sids []common.SeriesID, timestamps, values []int64) *part {
+	dps := &dataPoints{}
+	for i := range sids {
+		dps.seriesIDs = append(dps.seriesIDs, sids[i])
+		dps.timestamps = append(dps.timestamps, timestamps[i])
+		dps.versions = append(dps.versions, 1)
+		dps.tagFamilies = append(dps.tagFamilies, nil)
+		dps.fields = append(dps.fields, nameValues{
+			name: "fields", values: []*nameValue{
+				{name: "intField", valueType: pbv1.ValueTypeInt64, value: convert.Int64ToBytes(values[i])},
+			},
+		})
+	}
+	mp := generateMemPart()
+	t.Cleanup(func() { releaseMemPart(mp) })
+	mp.mustInitFromDataPoints(dps)
+	p := openMemPart(mp)
+	p.partMetadata.ID = id
+	p.cache = storage.NewShardCache("test-group", 0, 0)
+	return p
+}
+
+func TestQueryResult_AggregatedFromBlockStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		wantAggregated []int64
+		function       modelv1.AggregationFunction
+	}{
+		{
+			name:           "sum",
+			function:       modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+			wantAggregated: []int64{80},
+		},
+		{
+			name:           "max",
+			function:       modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+			wantAggregated: []int64{80},
+		},
+		{
+			name:           "min",
+			function:       modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN,
+			wantAggregated: []int64{-50},
+		},
+		{
+			name:           "count",
+			function:       modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+			wantAggregated: []int64{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The leading block of series 1, the overlapped blocks of series 2 and
+			// the block of series 3 which isn't in the time range are loaded.
+			parts := []*part{
+				statsTestPart(t, 1, []common.SeriesID{1, 1, 2, 2, 3, 3}, []int64{1, 2, 1, 2, 5, 20}, []int64{10, 20, 1, 2, 5, 20}),
+				statsTestPart(t, 2, []common.SeriesID{1, 1, 1, 2}, []int64{5, 6, 7, 2}, []int64{50, 80, -50, 3}),
+			}
+			m := &measure{
+				pm: &itest.MockMemoryProtector{},
+			}
+			qo := queryOptions{
+				minTimestamp: 1,
+				maxTimestamp: 10,
+			}
+			qo.FieldProjection = []string{"intField"}
+			qo.Aggregation = &model.MeasureAggregation{FieldName: "intField", Function: tt.function}
+			var result queryResult
+			result.ctx = context.TODO()
+			result.orderByTS = true
+			result.ascTS = true
+			require.NoError(t, m.searchBlocks(context.TODO(), &result, []common.SeriesID{1, 2, 3}, parts, qo))
+			defer result.Release()
+
+			var aggregated []int64
+			var loaded []int64
+			for {
+				r := result.Pull()
+				if r == nil {
+					break
+				}
+				require.NoError(t, r.Error)
+				require.Len(t, r.Fields, 1)
+				if len(r.AggregatedCounts) == 0 {
+					require.Empty(t, aggregated, "the loaded data points come first")
+					loaded = append(loaded, r.Timestamps...)
+					continue
+				}
+				require.Equal(t, common.SeriesID(1), r.SID)
+				aggregated = append(aggregated, r.Fields[0].Values[0].GetInt().GetValue())
+			}
+			require.Equal(t, tt.wantAggregated, aggregated)
+			require.Equal(t, []int64{1, 1, 2, 2, 5}, loaded)
+		})
+	}
+}
+
+func Test_overlappedBlocks(t *testing.T) {
+	newCursor := func(sid common.SeriesID, minTS, maxTS int64) *blockCursor {
+		bc := &blockCursor{}
+		bc.bm.seriesID = sid
+		bc.bm.timestamps.min = minTS
+		bc.bm.timestamps.max = maxTS
+		return bc
+	}
+	a := newCursor(1, 0, 10)
+	b := newCursor(1, 2, 5)
+	c := newCursor(1, 11, 12)
+	d := newCursor(2, 4, 6)
+	e := newCursor(2, 6, 8)
+	f := newCursor(3, 1, 2)
+	overlapped := overlappedBlocks([]*blockCursor{f, e, d, c, b, a})
+	require.Len(t, overlapped, 4)
+	for _, bc := range []*blockCursor{a, b, d, e} {
+		require.Contains(t, overlapped, bc)
+	}
+}
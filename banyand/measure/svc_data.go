@@ -34,6 +34,7 @@ import (
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
@@ -69,6 +70,7 @@ type dataSVC struct {
 	schemaRepo         *schemaRepo
 	cm                 *cacheMetrics
 	diskMonitor        *storage.DiskMonitor
+	backfillRunner     *storage.IndexBackfillRunner
 	root               string
 	dataPath           string
 	snapshotDir        string
@@ -282,6 +284,9 @@ func (s *dataSVC) PreRun(ctx context.Context) error {
 	if dropGroupErr := s.pipeline.Subscribe(data.TopicMeasureDropGroup, &dropGroupDataListener{s: s}); dropGroupErr != nil {
 		return fmt.Errorf("failed to subscribe to drop group topic: %w", dropGroupErr)
 	}
+	if backfillErr := s.pipeline.Subscribe(data.TopicMeasureIndexBackfill, &indexBackfillListener{s: s}); backfillErr != nil {
+		return fmt.Errorf("failed to subscribe to index backfill topic: %w", backfillErr)
+	}
 
 	if err = s.createDataNativeObservabilityGroup(ctx); err != nil {
 		return err
@@ -310,6 +315,9 @@ func (s *dataSVC) PreRun(ctx context.Context) error {
 	s.diskMonitor = storage.NewDiskMonitor(s, s.retentionConfig, s.omr)
 	s.diskMonitor.Start()
 
+	s.backfillRunner = storage.NewIndexBackfillRunner(s.l, s.lfs, s.dataPath, s.schemaRepo.runIndexBackfill)
+	s.backfillRunner.Start()
+
 	// For now, keep the original write throttling behavior based on high watermark
 	// TODO: Replace this with a newer write callback that doesn't duplicate disk monitoring
 	writeListener := setUpWriteCallback(s.l, s.schemaRepo, int(s.retentionConfig.HighWatermark))
@@ -336,6 +344,9 @@ func (s *dataSVC) GracefulStop() {
 	if s.diskMonitor != nil {
 		s.diskMonitor.Stop()
 	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Stop()
+	}
 
 	obsservice.MetricsCollector.Unregister("measure_cache")
 	s.schemaRepo.Close()
@@ -579,6 +590,10 @@ func (s *dataSVC) CollectDataInfo(ctx context.Context, group string) (*databasev
 	return s.schemaRepo.CollectDataInfo(ctx, group)
 }
 
+// OnStreamWrite does nothing, since the continuous aggregations run on liaison nodes.
+func (s *dataSVC) OnStreamWrite(_ *databasev1.Stream, _ *streamv1.ElementValue, _ []*streamv1.TagFamilySpec) {
+}
+
 func (s *dataSVC) CollectLiaisonInfo(_ context.Context, _ string) (*databasev1.LiaisonInfo, error) {
 	return nil, errors.New("collect liaison info is not supported on data node")
 }
@@ -615,3 +630,20 @@ func (l *dropGroupDataListener) Rev(ctx context.Context, message bus.Message) bu
 	}
 	return bus.NewMessage(message.ID(), req)
 }
+
+type indexBackfillListener struct {
+	*bus.UnImplementedHealthyListener
+	s *dataSVC
+}
+
+func (l *indexBackfillListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	req, ok := message.Data().(*databasev1.IndexRuleBindingRegistryServiceBackfillRequest)
+	if !ok {
+		return bus.NewMessage(message.ID(), common.NewError("invalid data type for index backfill request"))
+	}
+	n, err := l.s.BackfillIndex(ctx, req)
+	if err != nil {
+		return bus.NewMessage(message.ID(), common.NewError("failed to schedule index backfill: %v", err))
+	}
+	return bus.NewMessage(message.ID(), &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{ShardCount: n})
+}
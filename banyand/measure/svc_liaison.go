@@ -30,6 +30,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/grpc"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
@@ -88,6 +89,10 @@ func (s *liaison) CollectDataInfo(_ context.Context, _ string) (*databasev1.Data
 }
 
 // CollectLiaisonInfo collects liaison node statistics.
+func (s *liaison) OnStreamWrite(stm *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) {
+	s.schemaRepo.continuousAggregations.onStreamWrite(stm, element, spec)
+}
+
 func (s *liaison) CollectLiaisonInfo(_ context.Context, group string) (*databasev1.LiaisonInfo, error) {
 	info := &databasev1.LiaisonInfo{}
 	pendingWriteCount, writeErr := s.schemaRepo.collectPendingWriteInfo(group)
@@ -31,6 +31,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
@@ -59,6 +60,8 @@ type Service interface {
 	Query
 	CollectDataInfo(context.Context, string) (*databasev1.DataInfo, error)
 	CollectLiaisonInfo(context.Context, string) (*databasev1.LiaisonInfo, error)
+	// OnStreamWrite feeds the written elements to the continuous aggregations reading streams.
+	OnStreamWrite(stm *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec)
 }
 
 var _ Service = (*standalone)(nil)
@@ -76,6 +79,7 @@ type standalone struct {
 	l                  *logger.Logger
 	schemaRepo         *schemaRepo
 	diskMonitor        *storage.DiskMonitor
+	backfillRunner     *storage.IndexBackfillRunner
 	root               string
 	snapshotDir        string
 	dataPath           string
@@ -279,6 +283,7 @@ func (s *standalone) PreRun(ctx context.Context) error {
 		metaSvc.RegisterDataCollector(commonv1.Catalog_CATALOG_MEASURE, s.schemaRepo)
 		metaSvc.RegisterLiaisonCollector(commonv1.Catalog_CATALOG_MEASURE, s)
 		metaSvc.RegisterGroupDropHandler(commonv1.Catalog_CATALOG_MEASURE, s)
+		metaSvc.RegisterIndexBackfillHandler(commonv1.Catalog_CATALOG_MEASURE, s)
 	}
 
 	s.cm = newCacheMetrics(s.omr)
@@ -300,6 +305,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	s.diskMonitor = storage.NewDiskMonitor(s, s.retentionConfig, s.omr)
 	s.diskMonitor.Start()
 
+	s.backfillRunner = storage.NewIndexBackfillRunner(s.l, s.lfs, s.dataPath, s.schemaRepo.runIndexBackfill)
+	s.backfillRunner.Start()
+
 	// For now, keep the original write throttling behavior based on high watermark
 	writeListener := setUpWriteCallback(s.l, s.schemaRepo, int(s.retentionConfig.HighWatermark))
 	// only subscribe metricPipeline for data node
@@ -327,6 +335,9 @@ func (s *standalone) GracefulStop() {
 	if s.diskMonitor != nil {
 		s.diskMonitor.Stop()
 	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Stop()
+	}
 
 	obsservice.MetricsCollector.Unregister("measure_cache")
 	// Stop local pipeline first to prevent new metadata/write events creating processors during shutdown
@@ -373,6 +384,10 @@ func (s *standalone) CollectDataInfo(ctx context.Context, group string) (*databa
 	return s.schemaRepo.CollectDataInfo(ctx, group)
 }
 
+func (s *standalone) OnStreamWrite(stm *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) {
+	s.schemaRepo.continuousAggregations.onStreamWrite(stm, element, spec)
+}
+
 func (s *standalone) CollectLiaisonInfo(_ context.Context, group string) (*databasev1.LiaisonInfo, error) {
 	info := &databasev1.LiaisonInfo{}
 	pendingWriteCount, writeErr := s.schemaRepo.collectPendingWriteInfo(group)
@@ -0,0 +1,330 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const tombstoneFilename = "tombstone.json"
+
+// DataPointKey identifies all versions of a data point.
+type DataPointKey struct {
+	SeriesID  common.SeriesID
+	Timestamp int64
+}
+
+// tombstone is the set of data points deleted from a part but not yet removed from its files.
+// It's never modified after creation. Deleting more data points replaces the part's tombstone.
+type tombstone struct {
+	dataPoints map[common.SeriesID]map[int64]struct{}
+}
+
+func (t *tombstone) hasSeries(sid common.SeriesID) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.dataPoints[sid]
+	return ok
+}
+
+func (t *tombstone) contains(sid common.SeriesID, ts int64) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.dataPoints[sid][ts]
+	return ok
+}
+
+func (t *tombstone) union(dataPoints map[common.SeriesID]map[int64]struct{}) *tombstone {
+	if t == nil {
+		return &tombstone{dataPoints: dataPoints}
+	}
+	merged := make(map[common.SeriesID]map[int64]struct{}, len(t.dataPoints)+len(dataPoints))
+	for _, src := range []map[common.SeriesID]map[int64]struct{}{t.dataPoints, dataPoints} {
+		for sid, tss := range src {
+			m, ok := merged[sid]
+			if !ok {
+				m = make(map[int64]struct{}, len(tss))
+				merged[sid] = m
+			}
+			for ts := range tss {
+				m[ts] = struct{}{}
+			}
+		}
+	}
+	return &tombstone{dataPoints: merged}
+}
+
+// keptRows returns the rows in [start, end] which are not deleted.
+// It returns nil if no row of the block is deleted.
+func (t *tombstone) keptRows(sid common.SeriesID, timestamps []int64, start, end int) []int {
+	if !t.hasSeries(sid) {
+		return nil
+	}
+	kept := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		if !t.contains(sid, timestamps[i]) {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// removeFrom drops the deleted data points from the block and reports whether any was dropped.
+func (t *tombstone) removeFrom(sid common.SeriesID, b *block) bool {
+	if len(b.timestamps) == 0 {
+		return false
+	}
+	kept := t.keptRows(sid, b.timestamps, 0, len(b.timestamps)-1)
+	if kept == nil || len(kept) == len(b.timestamps) {
+		return false
+	}
+	for i, k := range kept {
+		b.timestamps[i] = b.timestamps[k]
+		b.versions[i] = b.versions[k]
+	}
+	b.timestamps = b.timestamps[:len(kept)]
+	b.versions = b.versions[:len(kept)]
+	keepValues := func(cc []column) {
+		for j := range cc {
+			values := cc[j].values
+			if len(values) == 0 {
+				continue
+			}
+			for n, k := range kept {
+				values[n] = values[k]
+			}
+			cc[j].values = values[:len(kept)]
+		}
+	}
+	for i := range b.tagFamilies {
+		keepValues(b.tagFamilies[i].columns)
+	}
+	keepValues(b.field.columns)
+	return true
+}
+
+// deleteDataPoints hides the data points from the parts overlapping the time range.
+// The data points are removed from disk when these parts are merged.
+func (tst *tsTable) deleteDataPoints(minTimestamp, maxTimestamp int64, dataPoints map[common.SeriesID]map[int64]struct{}) {
+	if len(dataPoints) == 0 {
+		return
+	}
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		pm := pw.p.partMetadata
+		if maxTimestamp < pm.MinTimestamp || minTimestamp > pm.MaxTimestamp {
+			continue
+		}
+		pw.p.tombstone.Store(pw.p.tombstone.Load().union(dataPoints))
+	}
+	tst.mustPersistTombstones(snp)
+}
+
+// carryTombstones copies the tombstones of the replaced parts to the new ones.
+// The merger captured the tombstones of its source parts in applied before reading them.
+// Data points deleted after that are still in the merged part, so they have to be hidden there.
+func carryTombstones(cur *snapshot, newPart *partWrapper, merged map[uint64]struct{}, applied map[uint64]*tombstone) {
+	for _, pw := range cur.parts {
+		if _, ok := merged[pw.ID()]; !ok {
+			continue
+		}
+		t := pw.p.tombstone.Load()
+		if t == nil || t == applied[pw.ID()] {
+			continue
+		}
+		newPart.p.tombstone.Store(newPart.p.tombstone.Load().union(t.dataPoints))
+	}
+}
+
+func captureTombstones(parts []*partWrapper) map[uint64]*tombstone {
+	var applied map[uint64]*tombstone
+	for _, pw := range parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		if applied == nil {
+			applied = make(map[uint64]*tombstone)
+		}
+		applied[pw.ID()] = t
+	}
+	return applied
+}
+
+// mustPersistTombstones writes the tombstones of the snapshot's parts to the table's root.
+// The file is removed once merges have dropped all the deleted data points.
+func (tst *tsTable) mustPersistTombstones(snp *snapshot) {
+	entries := make(map[string]map[uint64][]int64)
+	for _, pw := range snp.parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		series := make(map[uint64][]int64, len(t.dataPoints))
+		for sid, tss := range t.dataPoints {
+			timestamps := make([]int64, 0, len(tss))
+			for ts := range tss {
+				timestamps = append(timestamps, ts)
+			}
+			series[uint64(sid)] = timestamps
+		}
+		entries[partName(pw.ID())] = series
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if len(entries) == 0 {
+		if tst.hasTombstones {
+			if err := tst.fileSystem.DeleteFile(tombstonePath); err != nil {
+				logger.Panicf("cannot delete tombstone file %s: %s", tombstonePath, err)
+			}
+			tst.fileSystem.SyncPath(tst.root)
+			tst.hasTombstones = false
+		}
+		return
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		logger.Panicf("cannot marshal tombstones to JSON: %s", err)
+	}
+	tombstoneTempPath := tombstonePath + ".tmp"
+	lf, err := tst.fileSystem.CreateLockFile(tombstoneTempPath, storage.FilePerm)
+	if err != nil {
+		logger.Panicf("cannot create lock file %s: %s", tombstoneTempPath, err)
+	}
+	n, err := lf.Write(data)
+	if err != nil {
+		_ = lf.Close()
+		logger.Panicf("cannot write tombstones %s: %s", tombstoneTempPath, err)
+	}
+	if n != len(data) {
+		_ = lf.Close()
+		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", tombstoneTempPath, n, len(data))
+	}
+	if closeErr := lf.Close(); closeErr != nil {
+		logger.Panicf("cannot close tombstone temp file %s: %s", tombstoneTempPath, closeErr)
+	}
+	if renameErr := tst.fileSystem.Rename(tombstoneTempPath, tombstonePath); renameErr != nil {
+		logger.Panicf("cannot rename tombstones %s to %s: %s", tombstoneTempPath, tombstonePath, renameErr)
+	}
+	tst.fileSystem.SyncPath(tst.root)
+	tst.hasTombstones = true
+}
+
+// openTombstones loads the tombstones of a table opened from disk.
+// The table is closed if they can't be loaded, since serving its parts without them brings the deleted data back.
+func (tst *tsTable) openTombstones() error {
+	if err := tst.loadTombstones(tst.snapshot); err != nil {
+		_ = tst.Close()
+		return fmt.Errorf("cannot open the table %s: %w", tst.root, err)
+	}
+	return nil
+}
+
+// loadTombstones attaches the persisted tombstones to the loaded parts.
+// Tombstones of parts that no longer exist are dropped.
+func (tst *tsTable) loadTombstones(snp *snapshot) error {
+	if snp == nil {
+		snp = &snapshot{}
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if !tst.fileSystem.IsExist(tombstonePath) {
+		return nil
+	}
+	tst.hasTombstones = true
+	data, err := tst.fileSystem.Read(tombstonePath)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", tombstonePath, err)
+	}
+	var entries map[string]map[uint64][]int64
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", tombstonePath, err)
+	}
+	for _, pw := range snp.parts {
+		series, ok := entries[partName(pw.ID())]
+		if !ok {
+			continue
+		}
+		dataPoints := make(map[common.SeriesID]map[int64]struct{}, len(series))
+		for sid, timestamps := range series {
+			tss := make(map[int64]struct{}, len(timestamps))
+			for _, ts := range timestamps {
+				tss[ts] = struct{}{}
+			}
+			dataPoints[common.SeriesID(sid)] = tss
+		}
+		pw.p.tombstone.Store(&tombstone{dataPoints: dataPoints})
+	}
+	return nil
+}
+
+// Delete hides the data points from queries. They are removed from disk by the next merge.
+func (m *measure) Delete(tr timestamp.TimeRange, keys []DataPointKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var tsdb storage.TSDB[*tsTable, option]
+	if db := m.tsdb.Load(); db != nil {
+		tsdb = db.(storage.TSDB[*tsTable, option])
+	} else {
+		var err error
+		if tsdb, err = m.schemaRepo.loadTSDB(m.group); err != nil {
+			return err
+		}
+		m.tsdb.Store(tsdb)
+	}
+	segments, err := tsdb.SelectSegments(tr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	dataPoints := make(map[common.SeriesID]map[int64]struct{})
+	for _, k := range keys {
+		tss, ok := dataPoints[k.SeriesID]
+		if !ok {
+			tss = make(map[int64]struct{})
+			dataPoints[k.SeriesID] = tss
+		}
+		tss[k.Timestamp] = struct{}{}
+	}
+	for _, segment := range segments {
+		// the caches returned with the tables hold the block metadata of the parts, which the tombstones leave intact
+		tables, _ := segment.Tables()
+		for _, t := range tables {
+			t.deleteDataPoints(tr.Start.UnixNano(), tr.End.UnixNano(), dataPoints)
+		}
+	}
+	return nil
+}
@@ -84,6 +84,7 @@ func (sr *schemaRepo) inFlow(
 			EntityValues: entityValues,
 		}, stm)
 	}
+	sr.continuousAggregations.onMeasureWrite(stm, dp, spec)
 }
 
 func (sr *schemaRepo) getSteamingManager(source *commonv1.Metadata, pipeline queue.Client) (manager *topNProcessorManager) {
@@ -278,6 +279,7 @@ type topNStreamingProcessor struct {
 	stopCh        chan struct{}
 	nodeID        string
 	flow.ComponentState
+	window        topNWindow
 	interval      time.Duration
 	sortDirection modelv1.Sort
 }
@@ -444,8 +446,9 @@ func (t *topNStreamingProcessor) start() *topNStreamingProcessor {
 	if flushInterval > maxFlushInterval {
 		flushInterval = maxFlushInterval
 	}
-	t.errCh = t.streamingFlow.Window(streaming.NewTumblingTimeWindows(t.interval, flushInterval)).
+	t.errCh = t.streamingFlow.Window(t.window.assigner(flushInterval)).
 		AllowedMaxWindows(int(t.topNSchema.GetLruSize())).
+		AllowedLateness(t.window.allowedLateness).
 		TopN(int(t.topNSchema.GetCountersNumber()),
 			streaming.WithKeyExtractor(func(record flow.StreamRecord) uint64 {
 				return record.Data().(flow.Data)[4].(uint64)
@@ -462,6 +465,69 @@ func (t *topNStreamingProcessor) start() *topNStreamingProcessor {
 	return t
 }
 
+// topNWindow is the window a TopNAggregation ranks data points in.
+type topNWindow struct {
+	typ             databasev1.TopNWindow_Type
+	size            time.Duration
+	slide           time.Duration
+	gap             time.Duration
+	allowedLateness time.Duration
+}
+
+// parseTopNWindow parses the window of a TopNAggregation.
+// The data points are split into tumbling windows of the source measure's interval if the spec is absent.
+func parseTopNWindow(spec *databasev1.TopNWindow, interval time.Duration) (topNWindow, error) {
+	w := topNWindow{typ: spec.GetType(), size: interval, slide: interval}
+	var err error
+	if spec.GetAllowedLateness() != "" {
+		if w.allowedLateness, err = timestamp.ParseDuration(spec.GetAllowedLateness()); err != nil {
+			return w, errors.Wrapf(err, "invalid allowed lateness %s", spec.GetAllowedLateness())
+		}
+		if w.allowedLateness < 0 {
+			return w, errors.Errorf("allowed lateness %s is negative", spec.GetAllowedLateness())
+		}
+	}
+	switch w.typ {
+	case databasev1.TopNWindow_TYPE_UNSPECIFIED, databasev1.TopNWindow_TYPE_TUMBLING:
+		w.typ = databasev1.TopNWindow_TYPE_TUMBLING
+	case databasev1.TopNWindow_TYPE_SLIDING:
+		if w.size, err = timestamp.ParseDuration(spec.GetSize()); err != nil {
+			return w, errors.Wrapf(err, "invalid sliding window size %s", spec.GetSize())
+		}
+		if w.slide, err = timestamp.ParseDuration(spec.GetSlide()); err != nil {
+			return w, errors.Wrapf(err, "invalid sliding window slide %s", spec.GetSlide())
+		}
+		// each slide is written to a time bucket of the measure.
+		if w.slide <= 0 || w.slide%interval != 0 {
+			return w, errors.Errorf("slide %s must be a multiple of the measure's interval %s", w.slide, interval)
+		}
+		if w.size < w.slide || w.size%w.slide != 0 {
+			return w, errors.Errorf("sliding window size %s must be a multiple of the slide %s", w.size, w.slide)
+		}
+	case databasev1.TopNWindow_TYPE_SESSION:
+		if w.gap, err = timestamp.ParseDuration(spec.GetGap()); err != nil {
+			return w, errors.Wrapf(err, "invalid session gap %s", spec.GetGap())
+		}
+		if w.gap <= 0 {
+			return w, errors.Errorf("session gap %s must be positive", w.gap)
+		}
+	default:
+		return w, errors.Errorf("unsupported window type %s", w.typ)
+	}
+	return w, nil
+}
+
+func (w topNWindow) assigner(flushInterval time.Duration) flow.WindowAssigner {
+	switch w.typ {
+	case databasev1.TopNWindow_TYPE_SLIDING:
+		return streaming.NewSlidingTimeWindows(w.size, w.slide, flushInterval)
+	case databasev1.TopNWindow_TYPE_SESSION:
+		return streaming.NewSessionWindows(w.gap, flushInterval)
+	default:
+		return streaming.NewTumblingTimeWindows(w.size, flushInterval)
+	}
+}
+
 func orderBy(sort modelv1.Sort) streaming.TopNOption {
 	if sort == modelv1.Sort_SORT_ASC {
 		return streaming.OrderBy(streaming.ASC)
@@ -602,6 +668,10 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 	if err != nil {
 		return errors.Wrapf(err, "invalid interval %s for measure %s", manager.m.Interval, manager.m.GetMetadata().GetName())
 	}
+	window, err := parseTopNWindow(topNSchema.GetWindow(), interval)
+	if err != nil {
+		return errors.Wrapf(err, "invalid window of topN %s", topNSchema.GetMetadata().GetName())
+	}
 	sortDirections := make([]modelv1.Sort, 0, 2)
 	if topNSchema.GetFieldValueSort() == modelv1.Sort_SORT_UNSPECIFIED {
 		sortDirections = append(sortDirections, modelv1.Sort_SORT_ASC, modelv1.Sort_SORT_DESC)
@@ -631,6 +701,7 @@ func (manager *topNProcessorManager) start(topNSchema *databasev1.TopNAggregatio
 			m:             manager.m,
 			l:             manager.l,
 			interval:      interval,
+			window:        window,
 			topNSchema:    topNSchema,
 			sortDirection: sortDirection,
 			src:           srcCh,
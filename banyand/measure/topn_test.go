@@ -20,6 +20,7 @@ package measure
 import (
 	"encoding/base64"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
@@ -838,3 +839,60 @@ func TestNewDataPointWithEntityValues(t *testing.T) {
 	require.Equal(t, 0, result.fieldIndex["field1"])
 	require.NotNil(t, result.tagSpec)
 }
+
+func TestParseTopNWindow(t *testing.T) {
+	tests := []struct {
+		spec    *databasev1.TopNWindow
+		name    string
+		want    topNWindow
+		wantErr bool
+	}{
+		{
+			name: "absent window falls back to tumbling",
+			want: topNWindow{typ: databasev1.TopNWindow_TYPE_TUMBLING, size: time.Minute, slide: time.Minute},
+		},
+		{
+			name:    "size is not a multiple of the slide",
+			spec:    &databasev1.TopNWindow{Type: databasev1.TopNWindow_TYPE_SLIDING, Size: "5m", Slide: "2m"},
+			wantErr: true,
+		},
+		{
+			name: "sliding window updated every minute",
+			spec: &databasev1.TopNWindow{Type: databasev1.TopNWindow_TYPE_SLIDING, Size: "5m", Slide: "1m", AllowedLateness: "30s"},
+			want: topNWindow{
+				typ: databasev1.TopNWindow_TYPE_SLIDING, size: 5 * time.Minute, slide: time.Minute, allowedLateness: 30 * time.Second,
+			},
+		},
+		{
+			name:    "slide is not a multiple of the interval",
+			spec:    &databasev1.TopNWindow{Type: databasev1.TopNWindow_TYPE_SLIDING, Size: "5m", Slide: "30s"},
+			wantErr: true,
+		},
+		{
+			name: "session window",
+			spec: &databasev1.TopNWindow{Type: databasev1.TopNWindow_TYPE_SESSION, Gap: "10m"},
+			want: topNWindow{typ: databasev1.TopNWindow_TYPE_SESSION, size: time.Minute, slide: time.Minute, gap: 10 * time.Minute},
+		},
+		{
+			name:    "session window without gap",
+			spec:    &databasev1.TopNWindow{Type: databasev1.TopNWindow_TYPE_SESSION},
+			wantErr: true,
+		},
+		{
+			name:    "negative lateness",
+			spec:    &databasev1.TopNWindow{AllowedLateness: "-1m"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTopNWindow(tt.spec, time.Minute)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -147,6 +147,9 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	l *logger.Logger, _ timestamp.TimeRange, option option, m any,
 ) (*tsTable, error) {
 	t, epoch := initTSTable(fileSystem, rootPath, p, l, option, m)
+	if err := t.openTombstones(); err != nil {
+		return nil, err
+	}
 	t.startLoop(epoch)
 	return t, nil
 }
@@ -181,8 +184,10 @@ type tsTable struct {
 	option           option
 	curPartID        uint64
 	pendingDataCount atomic.Int64
+	tombstoneMu      sync.Mutex
 	sync.RWMutex
-	shardID common.ShardID
+	shardID       common.ShardID
+	hasTombstones bool
 }
 
 func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) error {
@@ -36,6 +36,9 @@ func newWriteQueue(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	l *logger.Logger, option option, m any, group string, shardID common.ShardID, getNodes func() []string,
 ) (*tsTable, error) {
 	t, epoch := initTSTable(fileSystem, rootPath, p, l, option, m)
+	if err := t.openTombstones(); err != nil {
+		return nil, err
+	}
 	t.getNodes = getNodes
 	t.group = group
 	t.shardID = shardID
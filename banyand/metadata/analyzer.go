@@ -0,0 +1,54 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadata
+
+import (
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/index/analyzer"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+// analyzerEventHandler keeps the custom analyzers of the index package in sync with the schema registry.
+type analyzerEventHandler struct {
+	schema.UnimplementedOnInitHandler
+	l *logger.Logger
+}
+
+func (h *analyzerEventHandler) OnAddOrUpdate(md schema.Metadata) {
+	if md.Kind != schema.KindAnalyzer {
+		return
+	}
+	spec, ok := md.Spec.(*databasev1.Analyzer)
+	if !ok {
+		return
+	}
+	a, err := analyzer.Build(spec)
+	if err != nil {
+		h.l.Error().Err(err).Str("group", md.Group).Str("name", md.Name).Msg("failed to build the analyzer")
+		return
+	}
+	analyzer.Register(md.Name, a)
+}
+
+func (h *analyzerEventHandler) OnDelete(md schema.Metadata) {
+	if md.Kind != schema.KindAnalyzer {
+		return
+	}
+	analyzer.Unregister(md.Name)
+}
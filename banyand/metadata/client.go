@@ -279,6 +279,7 @@ func (s *clientService) PreRun(ctx context.Context) error {
 	if initErr != nil {
 		return initErr
 	}
+	s.schemaRegistry.RegisterHandler("analyzer", schema.KindAnalyzer, &analyzerEventHandler{l: l})
 
 	s.infoCollectorRegistry = schema.NewInfoCollectorRegistry(l, s.schemaRegistry)
 	if s.dataBroadcaster != nil {
@@ -410,6 +411,14 @@ func (s *clientService) PropertyRegistry() schema.Property {
 	return s.schemaRegistry
 }
 
+func (s *clientService) AnalyzerRegistry() schema.Analyzer {
+	return s.schemaRegistry
+}
+
+func (s *clientService) ContinuousAggregationRegistry() schema.ContinuousAggregation {
+	return s.schemaRegistry
+}
+
 func (s *clientService) SetMetricsRegistry(omr observability.MetricsRegistry) {
 	s.omr = omr
 }
@@ -514,6 +523,12 @@ func (s *clientService) DropGroup(ctx context.Context, catalog commonv1.Catalog,
 	return s.infoCollectorRegistry.DropGroup(ctx, catalog, group)
 }
 
+func (s *clientService) BackfillIndex(ctx context.Context, catalog commonv1.Catalog,
+	req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest,
+) (int64, error) {
+	return s.infoCollectorRegistry.BackfillIndex(ctx, catalog, req)
+}
+
 func (s *clientService) RegisterDataCollector(catalog commonv1.Catalog, collector schema.DataInfoCollector) {
 	s.infoCollectorRegistry.RegisterDataCollector(catalog, collector)
 }
@@ -526,6 +541,10 @@ func (s *clientService) RegisterGroupDropHandler(catalog commonv1.Catalog, handl
 	s.infoCollectorRegistry.RegisterGroupDropHandler(catalog, handler)
 }
 
+func (s *clientService) RegisterIndexBackfillHandler(catalog commonv1.Catalog, handler schema.IndexBackfillHandler) {
+	s.infoCollectorRegistry.RegisterIndexBackfillHandler(catalog, handler)
+}
+
 func (s *clientService) SetDataBroadcaster(broadcaster bus.Broadcaster) {
 	s.dataBroadcaster = broadcaster
 }
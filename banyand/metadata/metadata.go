@@ -52,9 +52,12 @@ type Repo interface {
 	RegisterHandler(string, schema.Kind, schema.EventHandler)
 	NodeRegistry() schema.Node
 	PropertyRegistry() schema.Property
+	AnalyzerRegistry() schema.Analyzer
+	ContinuousAggregationRegistry() schema.ContinuousAggregation
 	CollectDataInfo(context.Context, string) ([]*databasev1.DataInfo, error)
 	CollectLiaisonInfo(context.Context, string) ([]*databasev1.LiaisonInfo, error)
 	DropGroup(ctx context.Context, catalog commonv1.Catalog, group string) error
+	BackfillIndex(ctx context.Context, catalog commonv1.Catalog, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error)
 }
 
 // Service is the metadata repository.
@@ -70,4 +73,5 @@ type Service interface {
 	RegisterDataCollector(catalog commonv1.Catalog, collector schema.DataInfoCollector)
 	RegisterLiaisonCollector(catalog commonv1.Catalog, collector schema.LiaisonInfoCollector)
 	RegisterGroupDropHandler(catalog commonv1.Catalog, handler schema.GroupDropHandler)
+	RegisterIndexBackfillHandler(catalog commonv1.Catalog, handler schema.IndexBackfillHandler)
 }
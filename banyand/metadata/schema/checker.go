@@ -18,6 +18,9 @@
 package schema
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -47,6 +50,22 @@ var CheckerMap = map[Kind]EqualityChecker{
 			protocmp.Transform(),
 		)
 	},
+	KindAnalyzer: func(a, b proto.Message) bool {
+		return cmp.Equal(a, b,
+			protocmp.IgnoreUnknown(),
+			protocmp.IgnoreFields(&databasev1.Analyzer{}, "updated_at"),
+			protocmp.IgnoreFields(&commonv1.Metadata{}, "id", "create_revision", "mod_revision"),
+			protocmp.Transform(),
+		)
+	},
+	KindContinuousAggregation: func(a, b proto.Message) bool {
+		return cmp.Equal(a, b,
+			protocmp.IgnoreUnknown(),
+			protocmp.IgnoreFields(&databasev1.ContinuousAggregation{}, "updated_at"),
+			protocmp.IgnoreFields(&commonv1.Metadata{}, "id", "create_revision", "mod_revision"),
+			protocmp.Transform(),
+		)
+	},
 	KindMeasure: func(a, b proto.Message) bool {
 		return cmp.Equal(a, b,
 			protocmp.IgnoreUnknown(),
@@ -100,3 +119,37 @@ var CheckerMap = map[Kind]EqualityChecker{
 		return false
 	},
 }
+
+// CheckAnalyzerInUse rejects removing the analyzer, or changing how it tokenizes texts, while index rules refer to it.
+// The terms indexed by these rules were produced by the analyzer, so they would no longer match the terms of queries.
+// The update is allowed if next is not nil and tokenizes texts in the same way as prev.
+func CheckAnalyzerInUse(prev, next *databasev1.Analyzer, indexRules []*databasev1.IndexRule) error {
+	if next != nil && !analyzerTokenizationChanged(prev, next) {
+		return nil
+	}
+	var refs []string
+	for _, ir := range indexRules {
+		if ir.GetAnalyzer() == prev.GetMetadata().GetName() {
+			refs = append(refs, ir.GetMetadata().GetGroup()+"/"+ir.GetMetadata().GetName())
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	if next == nil {
+		return BadRequest("analyzer", fmt.Sprintf("analyzer %s is referred by the index rules %s", prev.GetMetadata().GetName(), strings.Join(refs, ", ")))
+	}
+	return BadRequest("analyzer", fmt.Sprintf("the tokenization of analyzer %s can't be changed since it's referred by the index rules %s",
+		prev.GetMetadata().GetName(), strings.Join(refs, ", ")))
+}
+
+func analyzerTokenizationChanged(prev, next *databasev1.Analyzer) bool {
+	if prev == nil {
+		return false
+	}
+	return !cmp.Equal(prev, next,
+		protocmp.IgnoreUnknown(),
+		protocmp.IgnoreFields(&databasev1.Analyzer{}, "metadata", "updated_at", "created_at"),
+		protocmp.Transform(),
+	)
+}
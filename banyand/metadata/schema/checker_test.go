@@ -25,9 +25,12 @@ import (
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
 	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/pkg/test/flags"
@@ -244,4 +247,50 @@ var _ = ginkgo.Describe("Utils", func() {
 			gomega.Expect(checker(ir, newIr)).Should(gomega.BeTrue())
 		})
 	})
+
+	ginkgo.Context("Check the index rules referring to an Analyzer", func() {
+		var a *databasev1.Analyzer
+		var ir *databasev1.IndexRule
+
+		newAnalyzer := func() *databasev1.Analyzer {
+			return &databasev1.Analyzer{
+				Metadata:     &commonv1.Metadata{Group: "default", Name: "ngram"},
+				Tokenizer:    &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_NGRAM, MinGram: 2, MaxGram: 3},
+				TokenFilters: []*databasev1.TokenFilter{{Type: databasev1.TokenFilter_TYPE_LOWERCASE}},
+			}
+		}
+
+		ginkgo.BeforeEach(func() {
+			a = newAnalyzer()
+			ir = loadIndexRule()
+			ir.Analyzer = "ngram"
+		})
+
+		ginkgo.It("should reject deleting the referred analyzer", func() {
+			err := schema.CheckAnalyzerInUse(a, nil, []*databasev1.IndexRule{ir})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.InvalidArgument))
+		})
+
+		ginkgo.It("should delete the analyzer no index rule refers to", func() {
+			ir.Analyzer = "keyword"
+			gomega.Expect(schema.CheckAnalyzerInUse(a, nil, []*databasev1.IndexRule{ir})).Should(gomega.Succeed())
+		})
+
+		ginkgo.It("should reject changing the tokenization of the referred analyzer", func() {
+			next := newAnalyzer()
+			next.Tokenizer.MaxGram = 4
+			err := schema.CheckAnalyzerInUse(a, next, []*databasev1.IndexRule{ir})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.InvalidArgument))
+			next = newAnalyzer()
+			next.TokenFilters = nil
+			gomega.Expect(schema.CheckAnalyzerInUse(a, next, []*databasev1.IndexRule{ir})).ShouldNot(gomega.Succeed())
+		})
+
+		ginkgo.It("should update the referred analyzer if the tokenization is kept", func() {
+			next := newAnalyzer()
+			next.Metadata.ModRevision = 100
+			next.UpdatedAt = timestamppb.Now()
+			gomega.Expect(schema.CheckAnalyzerInUse(a, next, []*databasev1.IndexRule{ir})).Should(gomega.Succeed())
+		})
+	})
 })
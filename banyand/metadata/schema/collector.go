@@ -49,12 +49,18 @@ type GroupDropHandler interface {
 	DropGroup(ctx context.Context, group string) error
 }
 
+// IndexBackfillHandler schedules index backfill jobs on the local node.
+type IndexBackfillHandler interface {
+	BackfillIndex(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error)
+}
+
 // InfoCollectorRegistry manages data and liaison info collectors.
 type InfoCollectorRegistry struct {
 	groupGetter        GroupGetter
 	dataCollectors     map[commonv1.Catalog]DataInfoCollector
 	liaisonCollectors  map[commonv1.Catalog]LiaisonInfoCollector
 	dropHandlers       map[commonv1.Catalog]GroupDropHandler
+	backfillHandlers   map[commonv1.Catalog]IndexBackfillHandler
 	dataBroadcaster    bus.Broadcaster
 	liaisonBroadcaster bus.Broadcaster
 	l                  *logger.Logger
@@ -68,6 +74,7 @@ func NewInfoCollectorRegistry(l *logger.Logger, groupGetter GroupGetter) *InfoCo
 		dataCollectors:    make(map[commonv1.Catalog]DataInfoCollector),
 		liaisonCollectors: make(map[commonv1.Catalog]LiaisonInfoCollector),
 		dropHandlers:      make(map[commonv1.Catalog]GroupDropHandler),
+		backfillHandlers:  make(map[commonv1.Catalog]IndexBackfillHandler),
 		l:                 l,
 	}
 }
@@ -280,6 +287,59 @@ func (icr *InfoCollectorRegistry) dropGroupLocal(ctx context.Context, catalog co
 	return nil
 }
 
+// BackfillIndex schedules the index backfill job of a binding on the local node and all data nodes.
+// It returns the number of segment shards scheduled.
+func (icr *InfoCollectorRegistry) BackfillIndex(ctx context.Context, catalog commonv1.Catalog,
+	req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest,
+) (int64, error) {
+	var topic bus.Topic
+	switch catalog {
+	case commonv1.Catalog_CATALOG_MEASURE:
+		topic = data.TopicMeasureIndexBackfill
+	case commonv1.Catalog_CATALOG_STREAM:
+		topic = data.TopicStreamIndexBackfill
+	case commonv1.Catalog_CATALOG_TRACE:
+		topic = data.TopicTraceIndexBackfill
+	default:
+		return 0, fmt.Errorf("unsupported catalog type: %v", catalog)
+	}
+	icr.mux.RLock()
+	handler, hasHandler := icr.backfillHandlers[catalog]
+	dataBroadcaster := icr.dataBroadcaster
+	icr.mux.RUnlock()
+	var total int64
+	if hasHandler && handler != nil {
+		n, localErr := handler.BackfillIndex(ctx, req)
+		if localErr != nil {
+			return 0, fmt.Errorf("failed to schedule index backfill locally: %w", localErr)
+		}
+		total += n
+	}
+	if dataBroadcaster == nil {
+		return total, nil
+	}
+	message := bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req)
+	futures, broadcastErr := dataBroadcaster.Broadcast(inspectBroadcastTimeout, topic, message)
+	if broadcastErr != nil {
+		return 0, fmt.Errorf("failed to broadcast index backfill request: %w", broadcastErr)
+	}
+	var errs []error
+	for _, future := range futures {
+		msg, getErr := future.Get()
+		if getErr != nil {
+			errs = append(errs, getErr)
+			continue
+		}
+		switch d := msg.Data().(type) {
+		case *databasev1.IndexRuleBindingRegistryServiceBackfillResponse:
+			total += d.GetShardCount()
+		case *common.Error:
+			errs = append(errs, fmt.Errorf("node reported error scheduling index backfill: %s", d.Error()))
+		}
+	}
+	return total, multierr.Combine(errs...)
+}
+
 // RegisterDataCollector registers a data info collector for a specific catalog.
 func (icr *InfoCollectorRegistry) RegisterDataCollector(catalog commonv1.Catalog, collector DataInfoCollector) {
 	icr.mux.Lock()
@@ -301,6 +361,13 @@ func (icr *InfoCollectorRegistry) RegisterGroupDropHandler(catalog commonv1.Cata
 	icr.dropHandlers[catalog] = handler
 }
 
+// RegisterIndexBackfillHandler registers an index backfill handler for a specific catalog.
+func (icr *InfoCollectorRegistry) RegisterIndexBackfillHandler(catalog commonv1.Catalog, handler IndexBackfillHandler) {
+	icr.mux.Lock()
+	defer icr.mux.Unlock()
+	icr.backfillHandlers[catalog] = handler
+}
+
 // SetDataBroadcaster sets the broadcaster for data info collection.
 func (icr *InfoCollectorRegistry) SetDataBroadcaster(broadcaster bus.Broadcaster) {
 	icr.mux.Lock()
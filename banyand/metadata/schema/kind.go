@@ -31,10 +31,12 @@ const (
 	KindTopNAggregation
 	KindNode
 	KindProperty
+	KindAnalyzer
+	KindContinuousAggregation
 	KindMask = KindGroup | KindStream | KindMeasure | KindTrace |
 		KindIndexRuleBinding | KindIndexRule |
-		KindTopNAggregation | KindNode | KindProperty
-	KindSize = 9
+		KindTopNAggregation | KindNode | KindProperty | KindAnalyzer | KindContinuousAggregation
+	KindSize = 11
 )
 
 func (k Kind) String() string {
@@ -57,6 +59,10 @@ func (k Kind) String() string {
 		return "node"
 	case KindProperty:
 		return "property"
+	case KindAnalyzer:
+		return "analyzer"
+	case KindContinuousAggregation:
+		return "continuousAggregation"
 	default:
 		return "unknown"
 	}
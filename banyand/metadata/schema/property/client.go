@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,7 +42,9 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/index/analyzer"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
@@ -811,6 +814,112 @@ func (r *SchemaRegistry) TopNAggregations(ctx context.Context, metadata *commonv
 	return result, nil
 }
 
+// GetContinuousAggregation retrieves a continuous aggregation schema.
+func (r *SchemaRegistry) GetContinuousAggregation(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.ContinuousAggregation, error) {
+	return getResource[*databasev1.ContinuousAggregation](ctx, r, schema.KindContinuousAggregation, metadata.GetGroup(), metadata.GetName())
+}
+
+// ListContinuousAggregation lists continuous aggregation schemas in a group.
+func (r *SchemaRegistry) ListContinuousAggregation(ctx context.Context, opt schema.ListOpt) ([]*databasev1.ContinuousAggregation, error) {
+	return listResources[*databasev1.ContinuousAggregation](ctx, r, schema.KindContinuousAggregation, opt.Group, true)
+}
+
+// CreateContinuousAggregation creates a continuous aggregation schema.
+func (r *SchemaRegistry) CreateContinuousAggregation(ctx context.Context, ca *databasev1.ContinuousAggregation) (int64, error) {
+	if validateErr := r.validateContinuousAggregation(ctx, ca); validateErr != nil {
+		return 0, validateErr
+	}
+	now := time.Now().UnixNano()
+	ca.Metadata.ModRevision = now
+	ca.UpdatedAt = timestamppb.Now()
+	return now, createResource(ctx, r, schema.KindContinuousAggregation, ca)
+}
+
+// UpdateContinuousAggregation updates a continuous aggregation schema.
+func (r *SchemaRegistry) UpdateContinuousAggregation(ctx context.Context, ca *databasev1.ContinuousAggregation) (int64, error) {
+	if validateErr := r.validateContinuousAggregation(ctx, ca); validateErr != nil {
+		return 0, validateErr
+	}
+	now := time.Now().UnixNano()
+	ca.Metadata.ModRevision = now
+	ca.UpdatedAt = timestamppb.Now()
+	return now, updateResource(ctx, r, schema.KindContinuousAggregation, ca)
+}
+
+// DeleteContinuousAggregation deletes a continuous aggregation schema.
+func (r *SchemaRegistry) DeleteContinuousAggregation(ctx context.Context, metadata *commonv1.Metadata) (bool, int64, error) {
+	return r.broadcastDelete(ctx, schema.KindContinuousAggregation, metadata.GetGroup(), metadata.GetName())
+}
+
+// validateContinuousAggregation checks the groups are sharded like the source, and the target measure is able to hold the results:
+// it has the group-by tags and numeric fields to write the aggregations to.
+func (r *SchemaRegistry) validateContinuousAggregation(ctx context.Context, ca *databasev1.ContinuousAggregation) error {
+	if validateErr := validate.ContinuousAggregation(ca); validateErr != nil {
+		return validateErr
+	}
+	target, getErr := r.GetMeasure(ctx, ca.GetTarget())
+	if getErr != nil {
+		if errors.Is(getErr, schema.ErrGRPCResourceNotFound) {
+			return schema.BadRequest("continuous_aggregation.target", fmt.Sprintf("measure %s is not found", ca.GetTarget()))
+		}
+		return getErr
+	}
+	shardingTagNames, getErr := r.continuousAggregationShardingTagNames(ctx, ca)
+	if getErr != nil {
+		if errors.Is(getErr, schema.ErrGRPCResourceNotFound) {
+			return schema.BadRequest("continuous_aggregation.source", fmt.Sprintf("%s %s is not found", ca.GetSourceCatalog(), ca.GetSource()))
+		}
+		return getErr
+	}
+	for _, tagName := range shardingTagNames {
+		if !slices.Contains(ca.GetGroupByTagNames(), tagName) {
+			return schema.BadRequest("continuous_aggregation.group_by_tag_names",
+				fmt.Sprintf("tag %s shards the source %s, it must be grouped by so that each group is aggregated by a single node", tagName, ca.GetSource()))
+		}
+	}
+	for _, tagName := range ca.GetGroupByTagNames() {
+		if _, _, tagSpec := pbv1.FindTagByName(target.GetTagFamilies(), tagName); tagSpec == nil {
+			return schema.BadRequest("continuous_aggregation.group_by_tag_names",
+				fmt.Sprintf("tag %s is not found in the target measure %s", tagName, target.GetMetadata().GetName()))
+		}
+	}
+	for _, a := range ca.GetAggregations() {
+		idx := slices.IndexFunc(target.GetFields(), func(f *databasev1.FieldSpec) bool {
+			return f.GetName() == a.GetTargetField()
+		})
+		if idx < 0 {
+			return schema.BadRequest("continuous_aggregation.aggregations",
+				fmt.Sprintf("field %s is not found in the target measure %s", a.GetTargetField(), target.GetMetadata().GetName()))
+		}
+		if ft := target.GetFields()[idx].GetFieldType(); ft != databasev1.FieldType_FIELD_TYPE_INT && ft != databasev1.FieldType_FIELD_TYPE_FLOAT {
+			return schema.BadRequest("continuous_aggregation.aggregations",
+				fmt.Sprintf("field %s of the target measure %s is not numeric", a.GetTargetField(), target.GetMetadata().GetName()))
+		}
+	}
+	return nil
+}
+
+// continuousAggregationShardingTagNames returns the tags sharding the source.
+// The writes are routed to the liaisons by their shards, so a group fed by several shards would be aggregated
+// by several liaisons, and their partial results would overwrite each other.
+func (r *SchemaRegistry) continuousAggregationShardingTagNames(ctx context.Context, ca *databasev1.ContinuousAggregation) ([]string, error) {
+	if ca.GetSourceCatalog() == commonv1.Catalog_CATALOG_STREAM {
+		source, err := r.GetStream(ctx, ca.GetSource())
+		if err != nil {
+			return nil, err
+		}
+		return source.GetEntity().GetTagNames(), nil
+	}
+	source, err := r.GetMeasure(ctx, ca.GetSource())
+	if err != nil {
+		return nil, err
+	}
+	if tagNames := source.GetShardingKey().GetTagNames(); len(tagNames) > 0 {
+		return tagNames, nil
+	}
+	return source.GetEntity().GetTagNames(), nil
+}
+
 // GetTrace retrieves a trace schema.
 func (r *SchemaRegistry) GetTrace(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Trace, error) {
 	return getResource[*databasev1.Trace](ctx, r, schema.KindTrace, metadata.GetGroup(), metadata.GetName())
@@ -939,6 +1048,9 @@ func (r *SchemaRegistry) CreateIndexRule(ctx context.Context, indexRule *databas
 	if validateErr := validate.IndexRule(indexRule); validateErr != nil {
 		return 0, validateErr
 	}
+	if validateErr := r.validateIndexRuleAnalyzer(ctx, indexRule); validateErr != nil {
+		return 0, validateErr
+	}
 	now := time.Now().UnixNano()
 	indexRule.Metadata.ModRevision = now
 	indexRule.UpdatedAt = timestamppb.Now()
@@ -957,6 +1069,9 @@ func (r *SchemaRegistry) UpdateIndexRule(ctx context.Context, indexRule *databas
 	if validateErr := validate.IndexRule(indexRule); validateErr != nil {
 		return 0, validateErr
 	}
+	if validateErr := r.validateIndexRuleAnalyzer(ctx, indexRule); validateErr != nil {
+		return 0, validateErr
+	}
 	now := time.Now().UnixNano()
 	indexRule.Metadata.ModRevision = now
 	indexRule.UpdatedAt = timestamppb.Now()
@@ -968,6 +1083,108 @@ func (r *SchemaRegistry) DeleteIndexRule(ctx context.Context, metadata *commonv1
 	return r.broadcastDelete(ctx, schema.KindIndexRule, metadata.GetGroup(), metadata.GetName())
 }
 
+// GetAnalyzer retrieves a custom analyzer schema.
+func (r *SchemaRegistry) GetAnalyzer(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Analyzer, error) {
+	return getResource[*databasev1.Analyzer](ctx, r, schema.KindAnalyzer, metadata.GetGroup(), metadata.GetName())
+}
+
+// ListAnalyzer lists custom analyzer schemas in a group.
+func (r *SchemaRegistry) ListAnalyzer(ctx context.Context, opt schema.ListOpt) ([]*databasev1.Analyzer, error) {
+	return listResources[*databasev1.Analyzer](ctx, r, schema.KindAnalyzer, opt.Group, true)
+}
+
+// CreateAnalyzer creates a custom analyzer schema.
+func (r *SchemaRegistry) CreateAnalyzer(ctx context.Context, a *databasev1.Analyzer) (int64, error) {
+	if validateErr := r.validateAnalyzer(ctx, a); validateErr != nil {
+		return 0, validateErr
+	}
+	now := time.Now().UnixNano()
+	a.Metadata.ModRevision = now
+	a.UpdatedAt = timestamppb.Now()
+	return now, createResource(ctx, r, schema.KindAnalyzer, a)
+}
+
+// UpdateAnalyzer updates a custom analyzer schema.
+func (r *SchemaRegistry) UpdateAnalyzer(ctx context.Context, a *databasev1.Analyzer) (int64, error) {
+	if validateErr := r.validateAnalyzer(ctx, a); validateErr != nil {
+		return 0, validateErr
+	}
+	if checkErr := r.checkAnalyzerInUse(ctx, a.GetMetadata(), a); checkErr != nil {
+		return 0, checkErr
+	}
+	now := time.Now().UnixNano()
+	a.Metadata.ModRevision = now
+	a.UpdatedAt = timestamppb.Now()
+	return now, updateResource(ctx, r, schema.KindAnalyzer, a)
+}
+
+// DeleteAnalyzer deletes a custom analyzer schema.
+func (r *SchemaRegistry) DeleteAnalyzer(ctx context.Context, metadata *commonv1.Metadata) (bool, int64, error) {
+	if checkErr := r.checkAnalyzerInUse(ctx, metadata, nil); checkErr != nil {
+		return false, 0, checkErr
+	}
+	return r.broadcastDelete(ctx, schema.KindAnalyzer, metadata.GetGroup(), metadata.GetName())
+}
+
+// validateAnalyzer checks the definition of the analyzer could be built,
+// and its name is neither a builtin analyzer nor taken by another group because index rules refer to analyzers by name.
+func (r *SchemaRegistry) validateAnalyzer(ctx context.Context, a *databasev1.Analyzer) error {
+	if validateErr := validate.Analyzer(a); validateErr != nil {
+		return validateErr
+	}
+	if analyzer.IsBuiltin(a.Metadata.Name) {
+		return schema.BadRequest("analyzer.metadata.name", fmt.Sprintf("%s is a builtin analyzer", a.Metadata.Name))
+	}
+	if _, buildErr := analyzer.Build(a); buildErr != nil {
+		return schema.BadRequest("analyzer", buildErr.Error())
+	}
+	existing, listErr := listResources[*databasev1.Analyzer](ctx, r, schema.KindAnalyzer, "", false)
+	if listErr != nil {
+		return listErr
+	}
+	for _, e := range existing {
+		if e.Metadata.Name == a.Metadata.Name && e.Metadata.Group != a.Metadata.Group {
+			return schema.BadRequest("analyzer.metadata.name", fmt.Sprintf("%s is defined in the group %s", a.Metadata.Name, e.Metadata.Group))
+		}
+	}
+	return nil
+}
+
+// checkAnalyzerInUse checks the index rules of all groups, since they refer to analyzers by name.
+// next is nil if the analyzer is being deleted.
+func (r *SchemaRegistry) checkAnalyzerInUse(ctx context.Context, metadata *commonv1.Metadata, next *databasev1.Analyzer) error {
+	prev, getErr := r.GetAnalyzer(ctx, metadata)
+	if getErr != nil {
+		if errors.Is(getErr, schema.ErrGRPCResourceNotFound) {
+			return nil
+		}
+		return getErr
+	}
+	indexRules, listErr := listResources[*databasev1.IndexRule](ctx, r, schema.KindIndexRule, "", false)
+	if listErr != nil {
+		return listErr
+	}
+	return schema.CheckAnalyzerInUse(prev, next, indexRules)
+}
+
+// validateIndexRuleAnalyzer checks the custom analyzer referred by the index rule exists.
+func (r *SchemaRegistry) validateIndexRuleAnalyzer(ctx context.Context, indexRule *databasev1.IndexRule) error {
+	name := indexRule.GetAnalyzer()
+	if name == "" || analyzer.IsBuiltin(name) {
+		return nil
+	}
+	existing, listErr := listResources[*databasev1.Analyzer](ctx, r, schema.KindAnalyzer, "", false)
+	if listErr != nil {
+		return listErr
+	}
+	for _, e := range existing {
+		if e.Metadata.Name == name {
+			return nil
+		}
+	}
+	return schema.BadRequest("index_rule.analyzer", fmt.Sprintf("analyzer %s is not found", name))
+}
+
 // GetIndexRuleBinding retrieves an index rule binding schema.
 func (r *SchemaRegistry) GetIndexRuleBinding(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.IndexRuleBinding, error) {
 	return getResource[*databasev1.IndexRuleBinding](ctx, r, schema.KindIndexRuleBinding, metadata.GetGroup(), metadata.GetName())
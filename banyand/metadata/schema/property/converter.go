@@ -153,7 +153,7 @@ func KindFromString(kindStr string) (schema.Kind, error) {
 // (underscore_case for compound kinds) to the corresponding schema.Kind. The
 // proto schema keeps SchemaKey.kind on a fixed set of values: "stream",
 // "measure", "trace", "property", "index_rule", "index_rule_binding",
-// "group", "top_n_aggregation". An unknown value returns 0 and the caller
+// "group", "top_n_aggregation", "analyzer", "continuous_aggregation". An unknown value returns 0 and the caller
 // should treat the SchemaKey as referring to no live entry.
 func kindFromProtoString(protoKind string) schema.Kind {
 	switch protoKind {
@@ -173,6 +173,10 @@ func kindFromProtoString(protoKind string) schema.Kind {
 		return schema.KindGroup
 	case "top_n_aggregation":
 		return schema.KindTopNAggregation
+	case "analyzer":
+		return schema.KindAnalyzer
+	case "continuous_aggregation":
+		return schema.KindContinuousAggregation
 	default:
 		return 0
 	}
@@ -384,6 +388,14 @@ func getUpdatedAtFromSpec(kind schema.Kind, spec proto.Message) (*timestamppb.Ti
 		if p, ok := spec.(*databasev1.Property); ok {
 			ts = p.GetUpdatedAt()
 		}
+	case schema.KindAnalyzer:
+		if a, ok := spec.(*databasev1.Analyzer); ok {
+			ts = a.GetUpdatedAt()
+		}
+	case schema.KindContinuousAggregation:
+		if c, ok := spec.(*databasev1.ContinuousAggregation); ok {
+			ts = c.GetUpdatedAt()
+		}
 	case schema.KindNode:
 		// Node does not have an UpdatedAt field.
 	default:
@@ -430,6 +442,14 @@ func getCreatedAtFromSpec(kind schema.Kind, spec proto.Message) *timestamppb.Tim
 		if p, ok := spec.(*databasev1.Property); ok {
 			return p.GetCreatedAt()
 		}
+	case schema.KindAnalyzer:
+		if a, ok := spec.(*databasev1.Analyzer); ok {
+			return a.GetCreatedAt()
+		}
+	case schema.KindContinuousAggregation:
+		if c, ok := spec.(*databasev1.ContinuousAggregation); ok {
+			return c.GetCreatedAt()
+		}
 	case schema.KindNode, schema.KindMask:
 		// Node and Mask do not have a CreatedAt field.
 	}
@@ -471,6 +491,14 @@ func setCreatedAtOnSpec(kind schema.Kind, spec proto.Message, ts *timestamppb.Ti
 		if p, ok := spec.(*databasev1.Property); ok {
 			p.CreatedAt = ts
 		}
+	case schema.KindAnalyzer:
+		if a, ok := spec.(*databasev1.Analyzer); ok {
+			a.CreatedAt = ts
+		}
+	case schema.KindContinuousAggregation:
+		if c, ok := spec.(*databasev1.ContinuousAggregation); ok {
+			c.CreatedAt = ts
+		}
 	case schema.KindNode, schema.KindMask:
 		// Node and Mask do not have a CreatedAt field.
 	}
@@ -514,6 +542,14 @@ func getMetadataFromSpec(kind schema.Kind, spec proto.Message) (*commonv1.Metada
 		if p, ok := spec.(*databasev1.Property); ok {
 			return p.GetMetadata(), nil
 		}
+	case schema.KindAnalyzer:
+		if a, ok := spec.(*databasev1.Analyzer); ok {
+			return a.GetMetadata(), nil
+		}
+	case schema.KindContinuousAggregation:
+		if c, ok := spec.(*databasev1.ContinuousAggregation); ok {
+			return c.GetMetadata(), nil
+		}
 	case schema.KindMask:
 		return nil, fmt.Errorf("unsupported kind: %s", kind)
 	}
@@ -540,6 +576,10 @@ func newMessageForKind(kind schema.Kind) (proto.Message, error) {
 		return &databasev1.Node{}, nil
 	case schema.KindProperty:
 		return &databasev1.Property{}, nil
+	case schema.KindAnalyzer:
+		return &databasev1.Analyzer{}, nil
+	case schema.KindContinuousAggregation:
+		return &databasev1.ContinuousAggregation{}, nil
 	default:
 		return nil, schema.ErrUnsupportedEntityType
 	}
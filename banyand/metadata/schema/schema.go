@@ -82,6 +82,8 @@ type Registry interface {
 	Group
 	TopNAggregation
 	Property
+	Analyzer
+	ContinuousAggregation
 	RegisterHandler(string, Kind, EventHandler)
 	Start(context.Context) error
 }
@@ -131,6 +133,24 @@ type IndexRule interface {
 	DeleteIndexRule(ctx context.Context, metadata *commonv1.Metadata) (bool, int64, error)
 }
 
+// Analyzer allows CRUD custom analyzer schemas in a group.
+type Analyzer interface {
+	GetAnalyzer(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.Analyzer, error)
+	ListAnalyzer(ctx context.Context, opt ListOpt) ([]*databasev1.Analyzer, error)
+	CreateAnalyzer(ctx context.Context, analyzer *databasev1.Analyzer) (int64, error)
+	UpdateAnalyzer(ctx context.Context, analyzer *databasev1.Analyzer) (int64, error)
+	DeleteAnalyzer(ctx context.Context, metadata *commonv1.Metadata) (bool, int64, error)
+}
+
+// ContinuousAggregation allows CRUD continuous aggregation schemas in a group.
+type ContinuousAggregation interface {
+	GetContinuousAggregation(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.ContinuousAggregation, error)
+	ListContinuousAggregation(ctx context.Context, opt ListOpt) ([]*databasev1.ContinuousAggregation, error)
+	CreateContinuousAggregation(ctx context.Context, ca *databasev1.ContinuousAggregation) (int64, error)
+	UpdateContinuousAggregation(ctx context.Context, ca *databasev1.ContinuousAggregation) (int64, error)
+	DeleteContinuousAggregation(ctx context.Context, metadata *commonv1.Metadata) (bool, int64, error)
+}
+
 // IndexRuleBinding allows CRUD index rule binding schemas in a group.
 type IndexRuleBinding interface {
 	GetIndexRuleBinding(ctx context.Context, metadata *commonv1.Metadata) (*databasev1.IndexRuleBinding, error)
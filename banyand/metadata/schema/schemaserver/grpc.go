@@ -235,7 +235,7 @@ func (s *schemaManagementServer) DeleteSchema(ctx context.Context, req *schemav1
 	if len(ids) == 0 {
 		return &schemav1.DeleteSchemaResponse{Found: false}, nil
 	}
-	if deleteErr := s.server.db.Delete(ctx, ids, req.UpdateAt.AsTime()); deleteErr != nil {
+	if deleteErr := s.server.db.Delete(ctx, ids, req.UpdateAt.AsTime(), false); deleteErr != nil {
 		s.metrics.totalErr.Inc(1, "delete")
 		s.l.Error().Err(deleteErr).Msg("failed to delete schema")
 		return nil, deleteErr
@@ -55,6 +55,9 @@ const (
 
 var lfs = fs.NewLocalFileSystemWithLogger(logger.GetLogger("property"))
 
+// ErrModRevisionConflict indicates the shard holds a newer property than the expected one.
+var ErrModRevisionConflict = errors.New("mod revision conflict")
+
 // QueriedProperty represents a property returned from a query.
 type QueriedProperty interface {
 	ID() []byte
@@ -68,8 +71,12 @@ type QueriedProperty interface {
 type Database interface {
 	// Update updates or inserts a property into the database.
 	Update(ctx context.Context, shardID common.ShardID, id []byte, property *propertyv1.Property) error
+	// ConditionalUpdate updates or inserts a property if the shard holds no property newer than expectedModRevision.
+	// It returns ErrModRevisionConflict otherwise.
+	ConditionalUpdate(ctx context.Context, shardID common.ShardID, id []byte, property *propertyv1.Property, expectedModRevision int64) error
 	// Delete deletes properties with the given IDs from the database.
-	Delete(ctx context.Context, id [][]byte, delTime time.Time) error
+	// The superseded properties are replaced by newer revisions, whose deletions are not logged for the watches.
+	Delete(ctx context.Context, id [][]byte, delTime time.Time, superseded bool) error
 	// Query queries properties based on the given request.
 	Query(ctx context.Context, request *propertyv1.QueryRequest) ([]QueriedProperty, error)
 	// Repair repairs a property in the database.
@@ -80,6 +87,9 @@ type Database interface {
 	Drop(groupName string) error
 	// RegisterGossip registers the repair scheduler's gossip services with the given messenger.
 	RegisterGossip(messenger gossip.Messenger)
+	// Watch returns the changes logged after the positions of the request, waiting for them if there are none.
+	// It returns ErrWatchDisabled if the database keeps no change log.
+	Watch(ctx context.Context, req *propertyv1.InternalWatchRequest) (*propertyv1.InternalWatchResponse, error)
 	// Close closes the database.
 	Close() error
 }
@@ -121,6 +131,8 @@ type Config struct {
 	Index                  IndexConfig
 	FlushInterval          time.Duration
 	ExpireToDeleteDuration time.Duration
+	// WatchLogSize is the maximum number of changes logged for the watches. Zero disables the change log.
+	WatchLogSize int
 }
 
 type database struct {
@@ -130,6 +142,7 @@ type database struct {
 	lock                fs.File
 	logger              *logger.Logger
 	repairScheduler     *repairScheduler
+	changes             *changeLog
 	groups              sync.Map
 	location            string
 	snapshotDir         string
@@ -165,6 +178,9 @@ func OpenDB(ctx context.Context, cfg Config, omr observability.MetricsRegistry,
 		lfs:                 lfs,
 		indexConfig:         cfg.Index,
 	}
+	if cfg.WatchLogSize > 0 {
+		db.changes = newChangeLog(cfg.WatchLogSize)
+	}
 	var err error
 	// init repair scheduler
 	if cfg.Repair.Enabled {
@@ -226,7 +242,17 @@ func (db *database) Update(ctx context.Context, shardID common.ShardID, id []byt
 	return nil
 }
 
-func (db *database) Delete(ctx context.Context, docIDs [][]byte, delTime time.Time) error {
+func (db *database) ConditionalUpdate(ctx context.Context, shardID common.ShardID, id []byte,
+	property *propertyv1.Property, expectedModRevision int64,
+) error {
+	sd, err := db.loadShard(ctx, property.Metadata.Group, shardID)
+	if err != nil {
+		return err
+	}
+	return sd.conditionalUpdate(ctx, id, property, expectedModRevision)
+}
+
+func (db *database) Delete(ctx context.Context, docIDs [][]byte, delTime time.Time, superseded bool) error {
 	var err error
 	db.groups.Range(func(_, value any) bool {
 		gs := value.(*groupShards)
@@ -235,7 +261,7 @@ func (db *database) Delete(ctx context.Context, docIDs [][]byte, delTime time.Ti
 			return true
 		}
 		for _, s := range *sLst {
-			multierr.AppendInto(&err, s.deleteFromTime(ctx, docIDs, delTime))
+			multierr.AppendInto(&err, s.deleteFromTime(ctx, docIDs, delTime, superseded))
 		}
 		return true
 	})
@@ -466,6 +492,13 @@ func (db *database) Repair(ctx context.Context, id []byte, shardID uint64, prope
 	return err
 }
 
+func (db *database) Watch(ctx context.Context, req *propertyv1.InternalWatchRequest) (*propertyv1.InternalWatchResponse, error) {
+	if db.changes == nil {
+		return nil, ErrWatchDisabled
+	}
+	return db.changes.watch(ctx, req)
+}
+
 func (db *database) TakeSnapShot(ctx context.Context, sn string) *databasev1.Snapshot {
 	var snapshotResult *databasev1.Snapshot
 	db.groups.Range(func(_, value any) bool {
@@ -55,6 +55,7 @@ const (
 	idField        = "_id"
 	timestampField = "_timestamp"
 	shaValueField  = "_sha_value"
+	expireAtField  = "_expire_at"
 )
 
 var (
@@ -66,17 +67,20 @@ var (
 	idFieldKey        = index.FieldKey{TagName: idField}
 	timestampFieldKey = index.FieldKey{TagName: timestampField}
 	shaValueFieldKey  = index.FieldKey{TagName: shaValueField}
-	projection        = []index.FieldKey{idFieldKey, timestampFieldKey, sourceFieldKey, deletedFieldKey}
+	expireAtFieldKey  = index.FieldKey{TagName: expireAtField}
+	projection        = []index.FieldKey{idFieldKey, timestampFieldKey, sourceFieldKey, deletedFieldKey, expireAtFieldKey}
 )
 
 type shard struct {
 	store              index.SeriesStore
 	l                  *logger.Logger
 	repairState        *repair
+	changes            *changeLog
 	location           string
 	group              string
 	expireToDeleteSec  int64
 	id                 common.ShardID
+	conditionalLocker  sync.Mutex
 	waitForPersistence bool
 }
 
@@ -103,6 +107,7 @@ func (db *database) newShard(
 		group:              group,
 		l:                  logger.Fetch(ctx, sName),
 		location:           location,
+		changes:            db.changes,
 		expireToDeleteSec:  deleteExpireSec,
 		waitForPersistence: db.indexConfig.WaitForPersistence,
 	}
@@ -130,7 +135,36 @@ func (s *shard) update(id []byte, property *propertyv1.Property) error {
 	if err != nil {
 		return fmt.Errorf("build update document failure: %w", err)
 	}
-	return s.updateDocuments(index.Documents{*document})
+	if err = s.updateDocuments(index.Documents{*document}); err != nil {
+		return err
+	}
+	s.changes.apply(property)
+	return nil
+}
+
+// conditionalUpdate applies the property unless the shard holds an alive property newer than expectedModRevision.
+// A replica lagging behind the expected revision accepts the update, and the repair brings the others in line.
+func (s *shard) conditionalUpdate(ctx context.Context, id []byte, property *propertyv1.Property, expectedModRevision int64) error {
+	s.conditionalLocker.Lock()
+	defer s.conditionalLocker.Unlock()
+	iq, err := inverted.BuildPropertyQuery(&propertyv1.QueryRequest{
+		Groups: []string{property.Metadata.Group},
+		Name:   property.Metadata.Name,
+		Ids:    []string{property.Id},
+	}, groupField, entityID)
+	if err != nil {
+		return fmt.Errorf("build property query failure: %w", err)
+	}
+	existing, err := s.search(ctx, iq, nil, 100)
+	if err != nil {
+		return fmt.Errorf("query existing properties failed: %w", err)
+	}
+	for _, p := range existing {
+		if p.deleteTime <= 0 && p.timestamp > expectedModRevision {
+			return fmt.Errorf("%w: expected %d, got %d", ErrModRevisionConflict, expectedModRevision, p.timestamp)
+		}
+	}
+	return s.update(id, property)
 }
 
 func (s *shard) buildUpdateDocument(id []byte, property *propertyv1.Property, deleteTime int64) (*index.Document, error) {
@@ -165,6 +199,13 @@ func (s *shard) buildUpdateDocument(id []byte, property *propertyv1.Property, de
 		doc.Fields = append(doc.Fields, tagField)
 	}
 
+	if property.ExpireAt != nil {
+		expireAtField := index.NewBytesField(expireAtFieldKey, convert.Int64ToBytes(property.ExpireAt.AsTime().UnixNano()))
+		expireAtField.Store = true
+		expireAtField.NoSort = true
+		doc.Fields = append(doc.Fields, expireAtField)
+	}
+
 	if deleteTime > 0 {
 		deleteField := index.NewBytesField(deletedFieldKey, convert.Int64ToBytes(deleteTime))
 		deleteField.Store = true
@@ -184,21 +225,32 @@ func (s *shard) buildUpdateDocument(id []byte, property *propertyv1.Property, de
 }
 
 func (s *shard) delete(ctx context.Context, docID [][]byte) error {
-	return s.deleteFromTime(ctx, docID, time.Now())
+	return s.deleteFromTime(ctx, docID, time.Now(), false)
 }
 
-func (s *shard) deleteFromTime(ctx context.Context, docID [][]byte, delTime time.Time) error {
+func (s *shard) deleteFromTime(ctx context.Context, docID [][]byte, delTime time.Time, superseded bool) error {
 	if delTime.IsZero() {
 		delTime = time.Now()
 	}
-	removeDocList, err := s.buildDeleteFromTimeDocuments(ctx, docID, delTime.UnixNano())
+	removeDocList, removed, err := s.buildDeleteFromTimeDocuments(ctx, docID, delTime.UnixNano())
 	if err != nil {
 		return err
 	}
-	return s.updateDocuments(removeDocList)
+	if err = s.updateDocuments(removeDocList); err != nil {
+		return err
+	}
+	if !superseded {
+		for _, p := range removed {
+			s.changes.delete(p, delTime.UnixNano())
+		}
+	}
+	return nil
 }
 
-func (s *shard) buildDeleteFromTimeDocuments(ctx context.Context, docID [][]byte, deleteTime int64) ([]index.Document, error) {
+// buildDeleteFromTimeDocuments builds the documents marking the properties as deleted,
+// and returns the properties which were alive before.
+func (s *shard) buildDeleteFromTimeDocuments(ctx context.Context, docID [][]byte, deleteTime int64,
+) ([]index.Document, []*propertyv1.Property, error) {
 	// search the original documents by docID
 	seriesMatchers := make([]index.SeriesMatcher, 0, len(docID))
 	for _, id := range docID {
@@ -208,30 +260,34 @@ func (s *shard) buildDeleteFromTimeDocuments(ctx context.Context, docID [][]byte
 		})
 	}
 	if len(seriesMatchers) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 	iq, err := s.store.BuildQuery(seriesMatchers, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("build property query failure: %w", err)
+		return nil, nil, fmt.Errorf("build property query failure: %w", err)
 	}
 	exisingDocList, err := s.search(ctx, iq, nil, len(docID))
 	if err != nil {
-		return nil, fmt.Errorf("search existing documents failure: %w", err)
+		return nil, nil, fmt.Errorf("search existing documents failure: %w", err)
 	}
 	removeDocList := make([]index.Document, 0, len(exisingDocList))
+	var removed []*propertyv1.Property
 	for _, property := range exisingDocList {
 		p := &propertyv1.Property{}
 		if err := protojson.Unmarshal(property.source, p); err != nil {
-			return nil, fmt.Errorf("unmarshal property failure: %w", err)
+			return nil, nil, fmt.Errorf("unmarshal property failure: %w", err)
 		}
 		// update the property to mark it as delete
 		document, err := s.buildUpdateDocument(GetPropertyID(p), p, deleteTime)
 		if err != nil {
-			return nil, fmt.Errorf("build delete document failure: %w", err)
+			return nil, nil, fmt.Errorf("build delete document failure: %w", err)
 		}
 		removeDocList = append(removeDocList, *document)
+		if property.deleteTime <= 0 {
+			removed = append(removed, p)
+		}
 	}
-	return removeDocList, nil
+	return removeDocList, removed, nil
 }
 
 func (s *shard) updateDocuments(docs index.Documents) error {
@@ -290,6 +346,7 @@ func (s *shard) search(ctx context.Context, q index.Query, orderBy *propertyv1.Q
 			span.Stop()
 		}()
 	}
+	now := time.Now().UnixNano()
 	if orderBy == nil {
 		ss, searchErr := s.store.Search(ctx, projection, q, limit)
 		if searchErr != nil {
@@ -310,7 +367,7 @@ func (s *shard) search(ctx context.Context, q index.Query, orderBy *propertyv1.Q
 				id:         s.Key.EntityValues,
 				timestamp:  s.Timestamp,
 				source:     bytes,
-				deleteTime: deleteTime,
+				deleteTime: effectiveDeleteTime(deleteTime, s.Fields[expireAtField], now),
 			})
 		}
 		return data, nil
@@ -350,13 +407,17 @@ func (s *shard) search(ctx context.Context, q index.Query, orderBy *propertyv1.Q
 			timestamp:   val.Timestamp,
 			source:      val.Values[sourceField],
 			sortedValue: sortedValue,
-			deleteTime:  deleteTime,
+			deleteTime:  effectiveDeleteTime(deleteTime, val.Values[expireAtField], now),
 		})
 	}
 	return data, nil
 }
 
 func (s *shard) repair(ctx context.Context, id []byte, property *propertyv1.Property, deleteTime int64) (updated bool, selfNewer *queryProperty, err error) {
+	// an expired property is deleted at its expiry time on every replica, so the replicas agree on its delete time.
+	if deleteTime <= 0 && property.ExpireAt != nil && property.ExpireAt.AsTime().UnixNano() <= time.Now().UnixNano() {
+		deleteTime = property.ExpireAt.AsTime().UnixNano()
+	}
 	iq, err := inverted.BuildPropertyQuery(&propertyv1.QueryRequest{
 		Groups: []string{property.Metadata.Group},
 		Name:   property.Metadata.Name,
@@ -372,6 +433,10 @@ func (s *shard) repair(ctx context.Context, id []byte, property *propertyv1.Prop
 	sort.Sort(queryPropertySlice(olderProperties))
 	// if there no older properties, we can insert the latest document.
 	if len(olderProperties) == 0 {
+		// the expired property has been removed from this shard, don't bring it back.
+		if deleteTime > 0 && s.removable(deleteTime) && property.ExpireAt != nil {
+			return false, nil, nil
+		}
 		var doc *index.Document
 		doc, err = s.buildUpdateDocument(id, property, deleteTime)
 		if err != nil {
@@ -381,6 +446,7 @@ func (s *shard) repair(ctx context.Context, id []byte, property *propertyv1.Prop
 		if err != nil {
 			return false, nil, fmt.Errorf("update document failed: %w", err)
 		}
+		s.logRepaired(property, deleteTime)
 		return true, nil, nil
 	}
 
@@ -393,7 +459,8 @@ func (s *shard) repair(ctx context.Context, id []byte, property *propertyv1.Prop
 	}
 
 	docIDList := s.buildNotDeletedDocIDList(olderProperties)
-	deletedDocuments, err := s.buildDeleteFromTimeDocuments(ctx, docIDList, time.Now().UnixNano())
+	// the older revisions are superseded by the repaired property rather than deleted
+	deletedDocuments, _, err := s.buildDeleteFromTimeDocuments(ctx, docIDList, time.Now().UnixNano())
 	if err != nil {
 		return false, nil, fmt.Errorf("build delete older documents failed: %w", err)
 	}
@@ -409,9 +476,19 @@ func (s *shard) repair(ctx context.Context, id []byte, property *propertyv1.Prop
 	if err != nil {
 		return false, nil, fmt.Errorf("update documents failed: %w", err)
 	}
+	s.logRepaired(property, deleteTime)
 	return true, nil, nil
 }
 
+// logRepaired logs the change the repair brings to this replica, which missed it before.
+func (s *shard) logRepaired(property *propertyv1.Property, deleteTime int64) {
+	if deleteTime > 0 {
+		s.changes.delete(property, deleteTime)
+		return
+	}
+	s.changes.apply(property)
+}
+
 func (s *shard) buildNotDeletedDocIDList(properties []*queryProperty) [][]byte {
 	docIDList := make([][]byte, 0, len(properties))
 	for _, p := range properties {
@@ -438,9 +515,13 @@ func (s *shard) prepareForMerge(src []*roaring.Bitmap, segments []segment.Segmen
 		var docID uint64
 		for ; docID < seg.Count(); docID++ {
 			var deleteTime int64
+			var expireAt []byte
 			err = seg.VisitStoredFields(docID, func(field string, value []byte) bool {
-				if field == deleteField {
+				switch field {
+				case deleteField:
 					deleteTime = convert.BytesToInt64(value)
+				case expireAtField:
+					expireAt = value
 				}
 				return true
 			})
@@ -448,7 +529,8 @@ func (s *shard) prepareForMerge(src []*roaring.Bitmap, segments []segment.Segmen
 				return src, fmt.Errorf("visit stored field failure: %w", err)
 			}
 
-			if deleteTime <= 0 || int64(time.Since(time.Unix(0, deleteTime)).Seconds()) < s.expireToDeleteSec {
+			deleteTime = effectiveDeleteTime(deleteTime, expireAt, time.Now().UnixNano())
+			if deleteTime <= 0 || !s.removable(deleteTime) {
 				continue
 			}
 
@@ -467,6 +549,22 @@ func (s *shard) prepareForMerge(src []*roaring.Bitmap, segments []segment.Segmen
 	return src, nil
 }
 
+// removable reports whether the property deleted at deleteTime can be removed from the shard.
+func (s *shard) removable(deleteTime int64) bool {
+	return int64(time.Since(time.Unix(0, deleteTime)).Seconds()) >= s.expireToDeleteSec
+}
+
+// effectiveDeleteTime treats an expired property as deleted at its expiry time.
+func effectiveDeleteTime(deleteTime int64, expireAt []byte, now int64) int64 {
+	if deleteTime > 0 || len(expireAt) == 0 {
+		return deleteTime
+	}
+	if t := convert.BytesToInt64(expireAt); t <= now {
+		return t
+	}
+	return 0
+}
+
 type queryPropertySlice []*queryProperty
 
 func (q queryPropertySlice) Len() int {
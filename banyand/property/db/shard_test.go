@@ -19,12 +19,14 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"testing"
 	"time"
 
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
@@ -148,7 +150,7 @@ func TestMergeDeleted(t *testing.T) {
 				t.Fatal(loadErr)
 			}
 			for _, p := range properties {
-				if err = sd.deleteFromTime(context.Background(), [][]byte{GetPropertyID(p)}, tt.deleteTime); err != nil {
+				if err = sd.deleteFromTime(context.Background(), [][]byte{GetPropertyID(p)}, tt.deleteTime, false); err != nil {
 					t.Fatal(err)
 				}
 			}
@@ -306,6 +308,65 @@ func TestRepair(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name: "expired property is treated as deleted",
+			beforeApply: func() (res []*propertyv1.Property) {
+				property := generateProperty("test-id", now.UnixNano()-1000, 0)
+				property.ExpireAt = timestamppb.New(now.Add(-time.Minute))
+				return []*propertyv1.Property{property}
+			},
+			repair: func(context.Context, Database) error {
+				return nil
+			},
+			verify: func(t *testing.T, ctx context.Context, db Database) error {
+				resp := queryDB(ctx, t, db, "test-id")
+				if len(resp) != 1 {
+					t.Fatal(fmt.Errorf("expect 1 property, got %d", len(resp)))
+				}
+				verifyDeleteTime(t, resp[0], true)
+				if resp[0].DeleteTime() != now.Add(-time.Minute).UnixNano() {
+					t.Fatal(fmt.Errorf("expect deleteTime to be the expiry time, got %d", resp[0].DeleteTime()))
+				}
+				return nil
+			},
+		},
+		{
+			name: "repair expired property with same data",
+			beforeApply: func() (res []*propertyv1.Property) {
+				property := generateProperty("test-id", now.UnixNano()-1000, 0)
+				property.ExpireAt = timestamppb.New(now.Add(-time.Minute))
+				return []*propertyv1.Property{property}
+			},
+			repair: func(ctx context.Context, db Database) error {
+				property := generateProperty("test-id", now.UnixNano()-1000, 0)
+				property.ExpireAt = timestamppb.New(now.Add(-time.Minute))
+				return db.Repair(ctx, GetPropertyID(property), 0, property, 0)
+			},
+			verify: func(t *testing.T, ctx context.Context, db Database) error {
+				resp := queryDB(ctx, t, db, "test-id")
+				// the replicas agree on the delete time, nothing to repair
+				if len(resp) != 1 {
+					t.Fatal(fmt.Errorf("expect 1 property, got %d", len(resp)))
+				}
+				verifyDeleteTime(t, resp[0], true)
+				return nil
+			},
+		},
+		{
+			name: "repair removed expired property with no properties",
+			repair: func(ctx context.Context, db Database) error {
+				property := generateProperty("test-id", now.Add(-3*time.Hour).UnixNano(), 0)
+				property.ExpireAt = timestamppb.New(now.Add(-2 * time.Hour))
+				return db.Repair(ctx, GetPropertyID(property), 0, property, 0)
+			},
+			verify: func(t *testing.T, ctx context.Context, db Database) error {
+				resp := queryDB(ctx, t, db, "")
+				if len(resp) != 0 {
+					t.Fatal(fmt.Errorf("expect no property, got %d", len(resp)))
+				}
+				return nil
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -367,6 +428,67 @@ func TestRepair(t *testing.T) {
 	}
 }
 
+func TestConditionalUpdate(t *testing.T) {
+	dataDir, dataDeferFunc, err := test.NewSpace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataDeferFunc()
+	snapshotDir, snapshotDeferFunc, err := test.NewSpace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshotDeferFunc()
+	db, err := OpenDB(context.Background(), Config{
+		Location:               dataDir,
+		MetricsScopeName:       "property_test",
+		FlushInterval:          3 * time.Second,
+		ExpireToDeleteDuration: time.Hour,
+		Repair: RepairConfig{
+			Enabled:            true,
+			Location:           snapshotDir,
+			BuildTreeCron:      "@every 10m",
+			QuickBuildTreeTime: time.Second * 10,
+			TreeSlotCount:      32,
+		},
+	}, observability.BypassRegistry, fs.NewLocalFileSystem())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx := context.Background()
+	now := time.Now().UnixNano()
+	first := generateProperty("test-id", now, 1)
+	if err = db.ConditionalUpdate(ctx, 0, GetPropertyID(first), first, 0); err != nil {
+		t.Fatal(err)
+	}
+	// two writers both read the first revision, only the first one to arrive wins
+	winner := generateProperty("test-id", now+1, 2)
+	if err = db.ConditionalUpdate(ctx, 0, GetPropertyID(winner), winner, now); err != nil {
+		t.Fatal(err)
+	}
+	loser := generateProperty("test-id", now+2, 3)
+	if err = db.ConditionalUpdate(ctx, 0, GetPropertyID(loser), loser, now); !errors.Is(err, ErrModRevisionConflict) {
+		t.Fatal(fmt.Errorf("expect mod revision conflict, got %v", err))
+	}
+	// a replica lagging behind the expected revision accepts the update
+	ahead := generateProperty("test-id", now+3, 4)
+	if err = db.ConditionalUpdate(ctx, 0, GetPropertyID(ahead), ahead, now+2); err != nil {
+		t.Fatal(err)
+	}
+	resp := queryDB(ctx, t, db, "test-id")
+	if len(resp) != 3 {
+		t.Fatal(fmt.Errorf("expect 3 properties, got %d", len(resp)))
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].Timestamp() < resp[j].Timestamp()
+	})
+	verifyTagIntValue(t, unmarshalProperty(t, resp[2].Source()), 1, 4)
+}
+
 func generateProperty(id string, modReversion int64, val int) *propertyv1.Property {
 	return &propertyv1.Property{
 		Metadata: &commonv1.Metadata{
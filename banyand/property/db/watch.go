@@ -0,0 +1,229 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+// ErrWatchDisabled indicates the database keeps no change log for the watches.
+var ErrWatchDisabled = errors.New("property watch is disabled")
+
+// change is an apply or a delete of a property logged for the watches.
+type change struct {
+	property  *propertyv1.Property
+	revision  int64
+	seq       uint64
+	eventType propertyv1.WatchEventType
+}
+
+// changeLog keeps the latest applies and deletes of the properties in the order they are made on this node.
+// It lives in memory and drops the oldest changes beyond its size, so a watch reading them is told the log is compacted.
+// A restarted node starts a new log, in which every change before the start is regarded as compacted.
+type changeLog struct {
+	notify            chan struct{}
+	id                string
+	ring              []change
+	head              int
+	count             int
+	lastSeq           uint64
+	compactedRevision int64
+	mu                sync.Mutex
+}
+
+func newChangeLog(size int) *changeLog {
+	return &changeLog{
+		id:                uuid.NewString(),
+		ring:              make([]change, size),
+		notify:            make(chan struct{}),
+		compactedRevision: time.Now().UnixNano(),
+	}
+}
+
+func (cl *changeLog) apply(property *propertyv1.Property) {
+	if cl == nil {
+		return
+	}
+	cl.append(change{property: property, revision: property.Metadata.ModRevision, eventType: propertyv1.WatchEventType_WATCH_EVENT_TYPE_APPLY})
+}
+
+func (cl *changeLog) delete(property *propertyv1.Property, deleteTime int64) {
+	if cl == nil {
+		return
+	}
+	cl.append(change{property: property, revision: deleteTime, eventType: propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE})
+}
+
+func (cl *changeLog) append(c change) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.lastSeq++
+	c.seq = cl.lastSeq
+	if cl.count == len(cl.ring) {
+		dropped := cl.ring[cl.head]
+		if dropped.revision > cl.compactedRevision {
+			cl.compactedRevision = dropped.revision
+		}
+		cl.ring[cl.head] = c
+		cl.head = (cl.head + 1) % len(cl.ring)
+	} else {
+		cl.ring[(cl.head+cl.count)%len(cl.ring)] = c
+		cl.count++
+	}
+	close(cl.notify)
+	cl.notify = make(chan struct{})
+}
+
+// read returns the changes matching the filter after the requested position,
+// along with a channel closed when a new change is logged.
+func (cl *changeLog) read(req *propertyv1.InternalWatchRequest, filter *changeFilter) (*propertyv1.InternalWatchResponse, <-chan struct{}) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	resp := &propertyv1.InternalWatchResponse{Position: &propertyv1.WatchLogPosition{Log: cl.id, Seq: cl.lastSeq}}
+	firstSeq := cl.lastSeq - uint64(cl.count) + 1
+	var afterSeq uint64
+	var afterRevision int64
+	switch position := cl.position(req.Positions); {
+	case position != nil:
+		if position.Seq+1 < firstSeq {
+			resp.Compacted = true
+			return resp, cl.notify
+		}
+		afterSeq = position.Seq
+	case req.GetRequest().GetStartRevision() <= 0:
+		// only the changes from now on are watched
+		return resp, cl.notify
+	case req.GetRequest().GetStartRevision() < cl.compactedRevision:
+		resp.Compacted = true
+		return resp, cl.notify
+	default:
+		afterRevision = req.GetRequest().GetStartRevision()
+	}
+	for i := 0; i < cl.count; i++ {
+		c := cl.ring[(cl.head+i)%len(cl.ring)]
+		if c.seq <= afterSeq || c.revision <= afterRevision || !filter.match(c.property) {
+			continue
+		}
+		resp.Events = append(resp.Events, &propertyv1.WatchResponse{
+			EventType: c.eventType,
+			Property:  c.property,
+			Revision:  c.revision,
+		})
+		resp.Seqs = append(resp.Seqs, c.seq)
+	}
+	return resp, cl.notify
+}
+
+func (cl *changeLog) position(positions []*propertyv1.WatchLogPosition) *propertyv1.WatchLogPosition {
+	for _, p := range positions {
+		if p.GetLog() == cl.id {
+			return p
+		}
+	}
+	return nil
+}
+
+// watch returns the changes after the requested position, waiting for them up to the requested duration if there are none.
+func (cl *changeLog) watch(ctx context.Context, req *propertyv1.InternalWatchRequest) (*propertyv1.InternalWatchResponse, error) {
+	filter, err := newChangeFilter(req.GetRequest())
+	if err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(req.GetWait().AsDuration())
+	defer timer.Stop()
+	for {
+		resp, notify := cl.read(req, filter)
+		if len(resp.Events) > 0 || resp.Compacted {
+			return resp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resp, nil
+		case <-timer.C:
+			return resp, nil
+		case <-notify:
+		}
+		// the position skips the changes filtered out
+		req.Positions = []*propertyv1.WatchLogPosition{resp.Position}
+	}
+}
+
+// changeFilter matches the changes of the watched properties.
+type changeFilter struct {
+	tagFilter logical.TagFilter
+	groups    map[string]struct{}
+	ids       map[string]struct{}
+	name      string
+}
+
+func newChangeFilter(req *propertyv1.WatchRequest) (*changeFilter, error) {
+	tagFilter, err := logical.BuildSimpleTagFilter(req.GetCriteria())
+	if err != nil {
+		return nil, err
+	}
+	f := &changeFilter{
+		tagFilter: tagFilter,
+		groups:    make(map[string]struct{}, len(req.GetGroups())),
+		name:      req.GetName(),
+	}
+	for _, g := range req.GetGroups() {
+		f.groups[g] = struct{}{}
+	}
+	if len(req.GetIds()) > 0 {
+		f.ids = make(map[string]struct{}, len(req.GetIds()))
+		for _, id := range req.GetIds() {
+			f.ids[id] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+func (f *changeFilter) match(property *propertyv1.Property) bool {
+	if _, ok := f.groups[property.Metadata.Group]; !ok {
+		return false
+	}
+	if f.name != "" && f.name != property.Metadata.Name {
+		return false
+	}
+	if f.ids != nil {
+		if _, ok := f.ids[property.Id]; !ok {
+			return false
+		}
+	}
+	if f.tagFilter == logical.DummyFilter {
+		return true
+	}
+	tagSpec := logical.TagSpecMap{}
+	values := make([]*modelv1.TagValue, 0, len(property.Tags))
+	for i, t := range property.Tags {
+		tagSpec.RegisterTag(0, i, &databasev1.TagSpec{Name: t.Key})
+		values = append(values, t.Value)
+	}
+	ok, err := f.tagFilter.Match(logical.TagFamiliesForWrite{{Tags: values}}, tagSpec)
+	return err == nil && ok
+}
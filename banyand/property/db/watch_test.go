@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+)
+
+func changedProperty(id string, modRevision int64, value string) *propertyv1.Property {
+	return &propertyv1.Property{
+		Metadata: &commonv1.Metadata{Group: testPropertyGroup, Name: testPropertyName, ModRevision: modRevision},
+		Id:       id,
+		Tags: []*modelv1.Tag{
+			{Key: "t", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}}},
+		},
+	}
+}
+
+func watchRequest(startRevision int64, positions ...*propertyv1.WatchLogPosition) *propertyv1.InternalWatchRequest {
+	return &propertyv1.InternalWatchRequest{
+		Request:   &propertyv1.WatchRequest{Groups: []string{testPropertyGroup}, StartRevision: startRevision},
+		Positions: positions,
+	}
+}
+
+func eventIDs(resp *propertyv1.InternalWatchResponse) []string {
+	ids := make([]string, 0, len(resp.Events))
+	for _, e := range resp.Events {
+		ids = append(ids, e.Property.Id)
+	}
+	return ids
+}
+
+func TestChangeLog_Read(t *testing.T) {
+	cl := newChangeLog(10)
+	base := cl.compactedRevision
+	cl.apply(changedProperty("a", base+10, "v1"))
+	cl.apply(changedProperty("b", base+20, "v1"))
+	cl.delete(changedProperty("a", base+10, "v1"), base+30)
+
+	resp, err := cl.watch(context.Background(), watchRequest(base+10))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, eventIDs(resp), "the changes after the start revision are replayed")
+	assert.Equal(t, []uint64{2, 3}, resp.Seqs)
+	assert.Equal(t, propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE, resp.Events[1].EventType)
+	assert.Equal(t, base+30, resp.Events[1].Revision)
+
+	resp, err = cl.watch(context.Background(), watchRequest(0, &propertyv1.WatchLogPosition{Log: cl.id, Seq: 1}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, eventIDs(resp), "the position takes precedence over the start revision")
+
+	resp, err = cl.watch(context.Background(), watchRequest(0))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Events, "only the changes from now on are watched without a start revision")
+	assert.Equal(t, &propertyv1.WatchLogPosition{Log: cl.id, Seq: 3}, resp.Position)
+}
+
+func TestChangeLog_Compacted(t *testing.T) {
+	cl := newChangeLog(2)
+	base := cl.compactedRevision
+	for i := int64(1); i <= 3; i++ {
+		cl.apply(changedProperty("a", base+i, "v1"))
+	}
+
+	resp, err := cl.watch(context.Background(), watchRequest(0, &propertyv1.WatchLogPosition{Log: cl.id, Seq: 1}))
+	require.NoError(t, err)
+	assert.False(t, resp.Compacted, "the changes after the position are kept")
+	assert.Equal(t, []uint64{2, 3}, resp.Seqs)
+
+	resp, err = cl.watch(context.Background(), watchRequest(0, &propertyv1.WatchLogPosition{Log: cl.id, Seq: 0}))
+	require.NoError(t, err)
+	assert.True(t, resp.Compacted)
+
+	resp, err = cl.watch(context.Background(), watchRequest(base))
+	require.NoError(t, err)
+	assert.True(t, resp.Compacted, "the change right after the start revision has been dropped")
+
+	resp, err = cl.watch(context.Background(), watchRequest(base-1, &propertyv1.WatchLogPosition{Log: "restarted", Seq: 3}))
+	require.NoError(t, err)
+	assert.True(t, resp.Compacted, "the changes before the log is created are unknown")
+}
+
+func TestChangeLog_Filter(t *testing.T) {
+	cl := newChangeLog(10)
+	base := cl.compactedRevision
+	cl.apply(changedProperty("a", base+1, "v1"))
+	cl.apply(changedProperty("b", base+2, "v2"))
+	other := changedProperty("c", base+3, "v1")
+	other.Metadata.Group = "other"
+	cl.apply(other)
+
+	req := watchRequest(base)
+	req.Request.Criteria = &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  "t",
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v1"}}},
+	}}}
+	resp, err := cl.watch(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, eventIDs(resp))
+	assert.Equal(t, uint64(3), resp.Position.Seq, "the position skips the changes filtered out")
+}
+
+func TestChangeLog_Wait(t *testing.T) {
+	cl := newChangeLog(10)
+	base := cl.compactedRevision
+	req := watchRequest(0, &propertyv1.WatchLogPosition{Log: cl.id})
+	req.Wait = durationpb.New(10 * time.Second)
+	done := make(chan *propertyv1.InternalWatchResponse)
+	go func() {
+		resp, err := cl.watch(context.Background(), req)
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	cl.apply(changedProperty("a", base+1, "v1"))
+	select {
+	case resp := <-done:
+		assert.Equal(t, []string{"a"}, eventIDs(resp))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the watch isn't woken up by the change")
+	}
+
+	req = watchRequest(0)
+	req.Wait = durationpb.New(50 * time.Millisecond)
+	resp, err := cl.watch(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Events, "the watch returns nothing when the wait expires")
+}
@@ -19,6 +19,7 @@ package property
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sync"
@@ -33,6 +34,7 @@ import (
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	obsservice "github.com/apache/skywalking-banyandb/banyand/observability/services"
+	"github.com/apache/skywalking-banyandb/banyand/property/db"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/query"
@@ -92,15 +94,21 @@ func (h *updateListener) Rev(ctx context.Context, message bus.Message) (resp bus
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("id is empty"))
 		return
 	}
-	err := h.s.db.Update(ctx, common.ShardID(d.ShardId), d.Id, d.Property)
+	var err error
+	if d.Conditional {
+		err = h.s.db.ConditionalUpdate(ctx, common.ShardID(d.ShardId), d.Id, d.Property, d.ExpectedModRevision)
+	} else {
+		err = h.s.db.Update(ctx, common.ShardID(d.ShardId), d.Id, d.Property)
+	}
+	if errors.Is(err, db.ErrModRevisionConflict) {
+		resp = bus.NewMessage(bus.MessageID(now), &propertyv1.InternalUpdateResponse{ModRevisionConflict: true})
+		return
+	}
 	if err != nil {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to update property: %v", err))
 		return
 	}
-	resp = bus.NewMessage(bus.MessageID(now), &propertyv1.ApplyResponse{
-		Created: true,
-		TagsNum: uint32(len(d.Property.Tags)),
-	})
+	resp = bus.NewMessage(bus.MessageID(now), &propertyv1.InternalUpdateResponse{})
 	return
 }
 
@@ -129,7 +137,11 @@ func (h *deleteListener) Rev(ctx context.Context, message bus.Message) (resp bus
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("id is empty"))
 		return
 	}
-	err := h.s.db.Delete(ctx, d.Ids, time.Now())
+	deleteTime := n
+	if d.DeleteTime > 0 {
+		deleteTime = time.Unix(0, d.DeleteTime)
+	}
+	err := h.s.db.Delete(ctx, d.Ids, deleteTime, d.Superseded)
 	if err != nil {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to delete property: %v", err))
 		return
@@ -276,3 +288,32 @@ func (r *repairListener) Rev(ctx context.Context, message bus.Message) (resp bus
 	resp = bus.NewMessage(bus.MessageID(now), &propertyv1.InternalRepairResponse{})
 	return
 }
+
+type watchListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+func (w *watchListener) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	var protoReq proto.Message
+	defer func() {
+		if err := recover(); err != nil {
+			w.s.l.Error().Interface("err", err).RawJSON("req", logger.Proto(protoReq)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic: %v", err))
+		}
+	}()
+	d := message.Data().(*propertyv1.InternalWatchRequest)
+	if d == nil || d.Request == nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("request is nil"))
+		return
+	}
+	protoReq = d
+	result, err := w.s.db.Watch(ctx, d)
+	if err != nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to watch properties: %v", err))
+		return
+	}
+	resp = bus.NewMessage(bus.MessageID(now), result)
+	return
+}
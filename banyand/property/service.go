@@ -79,6 +79,7 @@ type service struct {
 	expireTimeout            time.Duration
 	flushTimeout             time.Duration
 	repairTreeSlotCount      int
+	watchLogSize             int
 	maxDiskUsagePercent      int
 	maxFileSnapshotNum       int
 	minFileSnapshotAge       time.Duration
@@ -99,6 +100,8 @@ func (s *service) FlagSet() *run.FlagSet {
 		"the duration of the quick build tree after operate the property")
 	flagS.StringVar(&s.repairTriggerCron, "property-repair-trigger-cron", "0 2 * * *", "the cron expression for background repairing the property data")
 	flagS.BoolVar(&s.repairEnabled, "property-repair-enabled", true, "whether to enable the background property repair")
+	flagS.IntVar(&s.watchLogSize, "property-watch-log-size", 100000,
+		"the maximum number of the property changes kept in memory for the watches, the watches resuming from the dropped ones fail")
 	s.gossipMessenger.FlagSet().VisitAll(func(f *pflag.Flag) {
 		flagS.AddFlag(f)
 	})
@@ -112,6 +115,9 @@ func (s *service) Validate() error {
 	if s.maxDiskUsagePercent < 0 {
 		return errors.New("property-max-disk-usage-percent must be greater than or equal to 0")
 	}
+	if s.watchLogSize < 1 {
+		return errors.New("property-watch-log-size must be greater than 0")
+	}
 	if s.maxDiskUsagePercent > 100 {
 		return errors.New("property-max-disk-usage-percent must be less than or equal to 100")
 	}
@@ -158,6 +164,7 @@ func (s *service) PreRun(ctx context.Context) error {
 		MetricsScopeName:       "property",
 		FlushInterval:          s.flushTimeout,
 		ExpireToDeleteDuration: s.expireTimeout,
+		WatchLogSize:           s.watchLogSize,
 		Repair: db.RepairConfig{
 			Enabled:            s.repairEnabled,
 			Location:           s.repairDir,
@@ -210,6 +217,7 @@ func (s *service) PreRun(ctx context.Context) error {
 		s.pipeline.Subscribe(data.TopicPropertyQuery, &queryListener{s: s}),
 		s.pipeline.Subscribe(data.TopicSnapshot, snapshotLis),
 		s.pipeline.Subscribe(data.TopicPropertyRepair, &repairListener{s: s}),
+		s.pipeline.Subscribe(data.TopicPropertyWatch, &watchListener{s: s}),
 	)
 }
 
@@ -0,0 +1,313 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// deleteDataPageSize is the number of rows fetched by each query looking for the rows to delete.
+const deleteDataPageSize = 1000
+
+var (
+	_ bus.MessageListener = (*streamDeleteDataProcessor)(nil)
+	_ bus.MessageListener = (*measureDeleteDataProcessor)(nil)
+	_ bus.MessageListener = (*traceDeleteDataProcessor)(nil)
+)
+
+// deleteTimeRange covers both ends of the requested range.
+// It only selects the segments and parts holding the matched rows, which are deleted by their keys.
+func deleteTimeRange(tr *modelv1.TimeRange) timestamp.TimeRange {
+	return timestamp.NewInclusiveTimeRange(tr.GetBegin().AsTime(), tr.GetEnd().AsTime())
+}
+
+// collectDeleteRows collects the rows matched by a deletion.
+// Paging with an offset rescans the skipped rows for every page, so the time range is split into windows
+// whose rows fit in a page instead. A window filling a page is halved and fetched again, and the page grows
+// if the rows of a single nanosecond don't fit in it. The window doubles after each window fetched.
+func collectDeleteRows[T any](tr *modelv1.TimeRange, fetch func(tr *modelv1.TimeRange, limit uint32) ([]T, *common.Error)) ([]T, *common.Error) {
+	begin, end := tr.GetBegin().AsTime(), tr.GetEnd().AsTime()
+	window := time.Duration(math.MaxInt64)
+	limit := uint32(deleteDataPageSize)
+	var rows []T
+	for !begin.After(end) {
+		// the windows are inclusive at both ends like the queries, so they don't overlap
+		wEnd := end
+		if window <= end.Sub(begin) {
+			wEnd = begin.Add(window - time.Nanosecond)
+		}
+		page, err := fetch(&modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(wEnd)}, limit)
+		if err != nil {
+			return nil, err
+		}
+		span := wEnd.Sub(begin)
+		if len(page) >= int(limit) {
+			if span > 0 {
+				window = span/2 + time.Nanosecond
+			} else {
+				limit *= 2
+			}
+			continue
+		}
+		rows = append(rows, page...)
+		begin = wEnd.Add(time.Nanosecond)
+		limit = deleteDataPageSize
+		if span < math.MaxInt64/2 {
+			window = 2 * (span + time.Nanosecond)
+		}
+	}
+	return rows, nil
+}
+
+func firstTagProjection(families []*databasev1.TagFamilySpec) *modelv1.TagProjection {
+	for _, f := range families {
+		if len(f.GetTags()) > 0 {
+			return &modelv1.TagProjection{
+				TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: f.GetName(), Tags: []string{f.GetTags()[0].GetName()}}},
+			}
+		}
+	}
+	return nil
+}
+
+type streamDeleteDataProcessor struct {
+	streamService stream.Service
+	sqp           *streamQueryProcessor
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *streamDeleteDataProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*streamv1.DeleteDataRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			p.log.Error().Interface("err", err).RawJSON("req", logger.Proto(req)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
+		}
+	}()
+	var keys []uint64
+	for _, g := range req.Groups {
+		meta := &commonv1.Metadata{Name: req.Name, Group: g}
+		ec, err := p.streamService.Stream(meta)
+		if err != nil {
+			return bus.NewMessage(bus.MessageID(now), common.NewError("fail to get execution context for stream %s: %v", meta.GetName(), err))
+		}
+		elementIDs, collectErr := collectDeleteRows(req.TimeRange, func(tr *modelv1.TimeRange, limit uint32) ([]uint64, *common.Error) {
+			qr := &streamv1.QueryRequest{
+				Groups:     []string{g},
+				Name:       req.Name,
+				TimeRange:  tr,
+				Criteria:   req.Criteria,
+				Limit:      limit,
+				Projection: firstTagProjection(ec.GetSchema().GetTagFamilies()),
+			}
+			var elements []*streamv1.Element
+			switch d := p.sqp.Rev(ctx, bus.NewMessage(bus.MessageID(now), qr)).Data().(type) {
+			case *streamv1.QueryResponse:
+				elements = d.Elements
+			case *common.Error:
+				return nil, d
+			}
+			ids := make([]uint64, 0, len(elements))
+			for _, e := range elements {
+				id, decodeErr := hex.DecodeString(e.ElementId)
+				if decodeErr != nil {
+					return nil, common.NewError("invalid element id %s: %v", e.ElementId, decodeErr)
+				}
+				ids = append(ids, convert.BytesToUint64(id))
+			}
+			return ids, nil
+		})
+		if collectErr != nil {
+			return bus.NewMessage(bus.MessageID(now), collectErr)
+		}
+		if !req.DryRun {
+			if err = ec.Delete(deleteTimeRange(req.TimeRange), elementIDs); err != nil {
+				return bus.NewMessage(bus.MessageID(now), common.NewError("fail to delete elements of stream %s: %v", meta.GetName(), err))
+			}
+		}
+		keys = append(keys, elementIDs...)
+	}
+	return bus.NewMessage(bus.MessageID(now), &streamv1.InternalDeleteDataResponse{Keys: keys})
+}
+
+type measureDeleteDataProcessor struct {
+	measureService measure.Service
+	mqp            *measureQueryProcessor
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *measureDeleteDataProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*measurev1.DeleteDataRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			p.log.Error().Interface("err", err).RawJSON("req", logger.Proto(req)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
+		}
+	}()
+	var keys []uint64
+	for _, g := range req.Groups {
+		meta := &commonv1.Metadata{Name: req.Name, Group: g}
+		ec, err := p.measureService.Measure(meta)
+		if err != nil {
+			return bus.NewMessage(bus.MessageID(now), common.NewError("fail to get execution context for measure %s: %v", meta.GetName(), err))
+		}
+		if ec.GetSchema().GetIndexMode() {
+			return bus.NewMessage(bus.MessageID(now), common.NewError("measure %s in the index mode doesn't support deleting data", meta.GetName()))
+		}
+		dataPoints, collectErr := collectDeleteRows(req.TimeRange, func(tr *modelv1.TimeRange, limit uint32) ([]measure.DataPointKey, *common.Error) {
+			qr := &measurev1.QueryRequest{
+				Groups:        []string{g},
+				Name:          req.Name,
+				TimeRange:     tr,
+				Criteria:      req.Criteria,
+				Limit:         limit,
+				TagProjection: firstTagProjection(ec.GetSchema().GetTagFamilies()),
+			}
+			var result []*measurev1.DataPoint
+			switch d := p.mqp.executeQuery(ctx, qr).Data().(type) {
+			case *measurev1.QueryResponse:
+				result = d.DataPoints
+			case *common.Error:
+				return nil, d
+			}
+			dps := make([]measure.DataPointKey, 0, len(result))
+			for _, dp := range result {
+				dps = append(dps, measure.DataPointKey{
+					SeriesID:  common.SeriesID(dp.Sid),
+					Timestamp: dp.Timestamp.AsTime().UnixNano(),
+				})
+			}
+			return dps, nil
+		})
+		if collectErr != nil {
+			return bus.NewMessage(bus.MessageID(now), collectErr)
+		}
+		if !req.DryRun {
+			if err = ec.Delete(deleteTimeRange(req.TimeRange), dataPoints); err != nil {
+				return bus.NewMessage(bus.MessageID(now), common.NewError("fail to delete data points of measure %s: %v", meta.GetName(), err))
+			}
+		}
+		for _, dp := range dataPoints {
+			keys = append(keys, convert.Hash(append(convert.Uint64ToBytes(uint64(dp.SeriesID)), convert.Int64ToBytes(dp.Timestamp)...)))
+		}
+	}
+	return bus.NewMessage(bus.MessageID(now), &measurev1.InternalDeleteDataResponse{Keys: keys})
+}
+
+type traceDeleteDataProcessor struct {
+	traceService trace.Service
+	tqp          *traceQueryProcessor
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *traceDeleteDataProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*tracev1.DeleteDataRequest)
+	if !ok {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			p.log.Error().Interface("err", err).RawJSON("req", logger.Proto(req)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
+		}
+	}()
+	var keys []uint64
+	for _, g := range req.Groups {
+		meta := &commonv1.Metadata{Name: req.Name, Group: g}
+		ec, err := p.traceService.Trace(meta)
+		if err != nil {
+			return bus.NewMessage(bus.MessageID(now), common.NewError("fail to get execution context for trace %s: %v", meta.GetName(), err))
+		}
+		matched, collectErr := collectDeleteRows(req.TimeRange, func(tr *modelv1.TimeRange, limit uint32) ([]string, *common.Error) {
+			qr := &tracev1.QueryRequest{
+				Groups:    []string{g},
+				Name:      req.Name,
+				TimeRange: tr,
+				Criteria:  req.Criteria,
+				Limit:     limit,
+			}
+			var traces []*tracev1.InternalTrace
+			switch d := p.tqp.executeQuery(ctx, qr).Data().(type) {
+			case *tracev1.InternalQueryResponse:
+				traces = d.InternalTraces
+			case *common.Error:
+				return nil, d
+			}
+			ids := make([]string, 0, len(traces))
+			for _, t := range traces {
+				ids = append(ids, t.TraceId)
+			}
+			return ids, nil
+		})
+		if collectErr != nil {
+			return bus.NewMessage(bus.MessageID(now), collectErr)
+		}
+		// the spans of a trace may fall into several windows
+		traceIDs := make([]string, 0, len(matched))
+		seen := make(map[string]struct{}, len(matched))
+		for _, id := range matched {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			traceIDs = append(traceIDs, id)
+		}
+		if !req.DryRun {
+			if err = ec.Delete(deleteTimeRange(req.TimeRange), traceIDs); err != nil {
+				return bus.NewMessage(bus.MessageID(now), common.NewError("fail to delete traces of trace %s: %v", meta.GetName(), err))
+			}
+		}
+		for _, id := range traceIDs {
+			keys = append(keys, convert.HashStr(id))
+		}
+	}
+	return bus.NewMessage(bus.MessageID(now), &tracev1.InternalDeleteDataResponse{Keys: keys})
+}
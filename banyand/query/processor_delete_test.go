@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestCollectDeleteRows(t *testing.T) {
+	begin := time.Unix(0, 0)
+	tests := []struct {
+		name      string
+		rows      []int64
+		maxFetch  int
+		wantCount int
+	}{
+		{name: "empty", maxFetch: 1, wantCount: 0},
+		{name: "fit in a page", rows: spread(10, time.Second), maxFetch: 1, wantCount: 10},
+		{name: "many pages", rows: spread(10*deleteDataPageSize, 100*time.Millisecond), maxFetch: 100, wantCount: 10 * deleteDataPageSize},
+		{name: "a nanosecond beyond a page", rows: append(make([]int64, 3*deleteDataPageSize), spread(10, time.Second)...), maxFetch: 100,
+			wantCount: 3*deleteDataPageSize + 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetched := 0
+			got, err := collectDeleteRows(&modelv1.TimeRange{
+				Begin: timestamppb.New(begin),
+				End:   timestamppb.New(begin.Add(time.Hour)),
+			}, func(tr *modelv1.TimeRange, limit uint32) ([]int64, *common.Error) {
+				fetched++
+				var page []int64
+				for _, ts := range tt.rows {
+					if ts >= tr.Begin.AsTime().UnixNano() && ts <= tr.End.AsTime().UnixNano() && len(page) < int(limit) {
+						page = append(page, ts)
+					}
+				}
+				return page, nil
+			})
+			require.Nil(t, err)
+			assert.Len(t, got, tt.wantCount)
+			assert.LessOrEqual(t, fetched, tt.maxFetch)
+		})
+	}
+}
+
+func TestCollectDeleteRows_Error(t *testing.T) {
+	_, err := collectDeleteRows(&modelv1.TimeRange{Begin: timestamppb.New(time.Unix(0, 0)), End: timestamppb.New(time.Unix(60, 0))},
+		func(*modelv1.TimeRange, uint32) ([]int64, *common.Error) {
+			return nil, common.NewError("failed")
+		})
+	assert.NotNil(t, err)
+}
+
+// spread returns n timestamps in nanoseconds separated by the step.
+func spread(n int, step time.Duration) []int64 {
+	tss := make([]int64, n)
+	for i := range tss {
+		tss[i] = int64(i) * int64(step)
+	}
+	return tss
+}
@@ -46,6 +46,9 @@ type queryService struct {
 	imqp        *measureInternalQueryProcessor
 	nqp         *topNQueryProcessor
 	tqp         *traceQueryProcessor
+	sdp         *streamDeleteDataProcessor
+	mdp         *measureDeleteDataProcessor
+	tdp         *traceDeleteDataProcessor
 	nodeID      string
 	slowQuery   time.Duration
 }
@@ -84,6 +87,22 @@ func NewService(_ context.Context, streamService stream.Service, measureService
 		traceService: traceService,
 		queryService: svc,
 	}
+	// delete data processors find the rows to delete with the query processors
+	svc.sdp = &streamDeleteDataProcessor{
+		streamService: streamService,
+		sqp:           svc.sqp,
+		queryService:  svc,
+	}
+	svc.mdp = &measureDeleteDataProcessor{
+		measureService: measureService,
+		mqp:            svc.mqp,
+		queryService:   svc,
+	}
+	svc.tdp = &traceDeleteDataProcessor{
+		traceService: traceService,
+		tqp:          svc.tqp,
+		queryService: svc,
+	}
 	return svc, nil
 }
 
@@ -105,6 +124,9 @@ func (q *queryService) PreRun(ctx context.Context) error {
 		q.pipeline.Subscribe(data.TopicInternalMeasureQuery, q.imqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.nqp),
 		q.pipeline.Subscribe(data.TopicTraceQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicStreamDeleteData, q.sdp),
+		q.pipeline.Subscribe(data.TopicMeasureDeleteData, q.mdp),
+		q.pipeline.Subscribe(data.TopicTraceDeleteData, q.tdp),
 	)
 }
 
@@ -0,0 +1,272 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// BackfillIndex schedules the index backfill job of a binding in all shards of the group.
+func (s *standalone) BackfillIndex(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	n, err := s.schemaRepo.scheduleIndexBackfill(ctx, req)
+	if err != nil {
+		return n, err
+	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Trigger()
+	}
+	return n, nil
+}
+
+func (sr *schemaRepo) scheduleIndexBackfill(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	binding, err := sr.metadata.IndexRuleBindingRegistry().GetIndexRuleBinding(ctx, req.GetMetadata())
+	if err != nil {
+		return 0, err
+	}
+	if binding.GetSubject().GetCatalog() != commonv1.Catalog_CATALOG_STREAM {
+		return 0, fmt.Errorf("index rule binding %s is not bound to a stream", req.GetMetadata().GetName())
+	}
+	tsdb, err := sr.loadTSDB(req.GetMetadata().GetGroup())
+	if err != nil {
+		return 0, err
+	}
+	return storage.ScheduleIndexBackfill(tsdb, binding, req.GetRateLimit(), backfillShardRoot)
+}
+
+func (sr *schemaRepo) runIndexBackfill(ctx context.Context, group string) {
+	tsdb, err := sr.loadTSDB(group)
+	if err != nil {
+		// The group isn't loaded yet, retry later.
+		return
+	}
+	storage.RunIndexBackfill(ctx, sr.l, tsdb, backfillShardRoot,
+		func(ctx context.Context, segment storage.Segment[*tsTable, option], tst *tsTable, task *storage.IndexBackfillTask) error {
+			return sr.backfillTable(ctx, group, segment, tst, task)
+		})
+}
+
+func backfillShardRoot(tst *tsTable) (fs.FileSystem, string) {
+	return tst.fileSystem, tst.root
+}
+
+// backfillTable rebuilds the element index documents of the parts which haven't been processed by the task.
+// A document is rebuilt from all inverted index rules of the stream, so that replacing it keeps the existing entries.
+func (sr *schemaRepo) backfillTable(ctx context.Context, group string, segment storage.Segment[*tsTable, option], tst *tsTable,
+	task *storage.IndexBackfillTask,
+) error {
+	stm, ok := sr.loadStream(&commonv1.Metadata{Group: group, Name: task.Subject})
+	if !ok {
+		return fmt.Errorf("stream %s: %w", task.Subject, storage.ErrIndexBackfillNotReady)
+	}
+	is := stm.indexSchema.Load().(indexSchema)
+	if !containsIndexRules(is.indexRules, task.Rules) {
+		return storage.ErrIndexBackfillNotReady
+	}
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+		return storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task)
+	}
+	defer snp.decRef()
+	var pending []*partWrapper
+	for _, pw := range snp.parts {
+		if !task.IsProcessed(pw.ID()) {
+			pending = append(pending, pw)
+		}
+	}
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_IN_PROGRESS
+	task.Message = ""
+	task.TotalParts = int64(len(task.ProcessedParts) + len(pending))
+	if err := storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task); err != nil {
+		return err
+	}
+	entities, err := lookupBackfillEntities(ctx, segment, stm, is)
+	if err != nil {
+		return err
+	}
+	limiter := task.Limiter()
+	for _, pw := range pending {
+		n, indexErr := backfillPart(ctx, tst, stm, is, entities, pw.p, limiter)
+		if indexErr != nil {
+			return indexErr
+		}
+		task.MarkProcessed(pw.ID(), n)
+		if err = storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task); err != nil {
+			return err
+		}
+	}
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+	return storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task)
+}
+
+func containsIndexRules(indexRules []*databasev1.IndexRule, names []string) bool {
+	for _, n := range names {
+		found := false
+		for _, r := range indexRules {
+			if r.GetMetadata().GetName() == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupBackfillEntities returns the entity values of the stream's series in the segment
+// if any inverted index rule covers an entity tag, since entity tags aren't stored in parts.
+func lookupBackfillEntities(ctx context.Context, segment storage.Segment[*tsTable, option], stm *stream,
+	is indexSchema,
+) (map[common.SeriesID][]*modelv1.TagValue, error) {
+	needed := false
+	for _, tfr := range is.indexRuleLocators.TagFamilyTRule {
+		for name, r := range tfr {
+			if _, isEntity := is.indexRuleLocators.EntitySet[name]; isEntity && r.GetType() == databasev1.IndexRule_TYPE_INVERTED {
+				needed = true
+			}
+		}
+	}
+	if !needed {
+		return nil, nil
+	}
+	entityValues := make([]*modelv1.TagValue, len(stm.schema.GetEntity().GetTagNames()))
+	for i := range entityValues {
+		entityValues[i] = pbv1.AnyTagValue
+	}
+	sl, err := segment.Lookup(ctx, []*pbv1.Series{{Subject: stm.name, EntityValues: entityValues}})
+	if err != nil {
+		return nil, err
+	}
+	entities := make(map[common.SeriesID][]*modelv1.TagValue, len(sl))
+	for _, s := range sl {
+		entities[s.ID] = s.EntityValues
+	}
+	return entities, nil
+}
+
+func backfillPart(ctx context.Context, tst *tsTable, stm *stream, is indexSchema,
+	entities map[common.SeriesID][]*modelv1.TagValue, p *part, limiter *storage.IndexBackfillLimiter,
+) (int, error) {
+	pmi := generatePartMergeIter()
+	defer releasePartMergeIter(pmi)
+	pmi.mustInitFromPart(p)
+	pmi.tombstone = p.tombstone.Load()
+	br := generateBlockReader()
+	defer releaseBlockReader(br)
+	br.init([]*partMergeIter{pmi})
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	entityIndex := make(map[string]int, len(stm.schema.GetEntity().GetTagNames()))
+	for i, name := range stm.schema.GetEntity().GetTagNames() {
+		entityIndex[name] = i
+	}
+	var indexed int
+	for br.nextBlockMetadata() {
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+		br.loadBlockData(decoder)
+		b := br.block
+		if len(b.timestamps) == 0 {
+			continue
+		}
+		if err := limiter.Wait(ctx, len(b.timestamps)); err != nil {
+			return indexed, err
+		}
+		docs := make(index.Documents, 0, len(b.timestamps))
+		for i := range b.timestamps {
+			fields := backfillFields(stm, is, b.bm.seriesID, entities[b.bm.seriesID], entityIndex, &b.block, i)
+			if len(fields) == 0 {
+				continue
+			}
+			docs = append(docs, index.Document{
+				DocID:     b.elementIDs[i],
+				Fields:    fields,
+				Timestamp: b.timestamps[i],
+			})
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		if err := tst.Index().Rewrite(docs); err != nil {
+			return indexed, err
+		}
+		indexed += len(docs)
+	}
+	return indexed, br.error()
+}
+
+func backfillFields(stm *stream, is indexSchema, seriesID common.SeriesID, entityValues []*modelv1.TagValue,
+	entityIndex map[string]int, b *block, idx int,
+) []index.Field {
+	var fields []index.Field
+	for i, tagFamilySpec := range stm.schema.GetTagFamilies() {
+		tfr := is.indexRuleLocators.TagFamilyTRule[i]
+		for _, t := range tagFamilySpec.GetTags() {
+			r, ok := tfr[t.GetName()]
+			if !ok || r.GetType() != databasev1.IndexRule_TYPE_INVERTED {
+				continue
+			}
+			var tagValue *modelv1.TagValue
+			if ei, isEntity := entityIndex[t.GetName()]; isEntity {
+				if ei < len(entityValues) {
+					tagValue = entityValues[ei]
+				}
+			} else {
+				tagValue = lookupBlockTagValue(b, tagFamilySpec.GetName(), t.GetName(), idx)
+			}
+			if tagValue == nil || tagValue == pbv1.NullTagValue {
+				continue
+			}
+			fields = appendField(fields, index.FieldKey{
+				IndexRuleID: r.GetMetadata().GetId(),
+				Analyzer:    r.Analyzer,
+				SeriesID:    seriesID,
+			}, t.GetType(), tagValue, r.GetNoSort())
+		}
+	}
+	return fields
+}
+
+func lookupBlockTagValue(b *block, tagFamily, tagName string, idx int) *modelv1.TagValue {
+	for i := range b.tagFamilies {
+		if b.tagFamilies[i].name != tagFamily {
+			continue
+		}
+		for j := range b.tagFamilies[i].tags {
+			t := &b.tagFamilies[i].tags[j]
+			if t.name != tagName || idx >= len(t.values) {
+				continue
+			}
+			return mustDecodeTagValue(t.valueType, t.values[idx])
+		}
+	}
+	return nil
+}
@@ -574,9 +574,10 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 
 	idxList := make([]int, 0)
 	var start, end int
+	ts := bc.p.tombstone.Load()
 	if bc.elementFilter != nil {
 		for i := range tmpBlock.elementIDs {
-			if bc.elementFilter.Contains(tmpBlock.elementIDs[i]) {
+			if bc.elementFilter.Contains(tmpBlock.elementIDs[i]) && !ts.contains(tmpBlock.elementIDs[i]) {
 				idxList = append(idxList, i)
 				bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[i])
 				bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[i])
@@ -591,8 +592,21 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 		if !ok {
 			return false
 		}
-		bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[s:e+1]...)
-		bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[s:e+1]...)
+		if ts == nil {
+			bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[s:e+1]...)
+			bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[s:e+1]...)
+		} else {
+			for i := s; i <= e; i++ {
+				if !ts.contains(tmpBlock.elementIDs[i]) {
+					idxList = append(idxList, i)
+					bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[i])
+					bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[i])
+				}
+			}
+			if len(bc.timestamps) == 0 {
+				return false
+			}
+		}
 	}
 
 	for _, cf := range tmpBlock.tagFamilies {
@@ -0,0 +1,227 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/idgen"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const bulkLoadBatchSize = 100_000
+
+// BulkLoader writes the elements of a stream into the data directory of a stopped data node.
+// The elements are encoded into parts and the indexes in the same way as the write path,
+// and the parts are flushed to the disk directly instead of staying in memory.
+type BulkLoader struct {
+	tsdb          storage.TSDB[*tsTable, option]
+	stream        *stream
+	idGen         *idgen.Generator
+	l             *logger.Logger
+	eg            *elementsInGroup
+	entityLocator partition.Locator
+	pending       int
+	shardNum      uint32
+}
+
+// NewBulkLoader opens the storage of the group under the data directory root for loading the elements of the stream.
+func NewBulkLoader(root string, group *commonv1.Group, schema *databasev1.Stream, indexRules []*databasev1.IndexRule) (*BulkLoader, error) {
+	if group.GetResourceOpts() == nil {
+		return nil, fmt.Errorf("no resource opts in group %s", group.GetMetadata().GetName())
+	}
+	if schema.GetMetadata().GetGroup() != group.GetMetadata().GetName() {
+		return nil, fmt.Errorf("stream %s doesn't belong to group %s", schema.GetMetadata().GetName(), group.GetMetadata().GetName())
+	}
+	l := logger.GetLogger("stream-bulk-load")
+	pm := protector.Nop{}
+	s := &supplier{
+		path: root,
+		// The zero flush timeouts persist the batches of the indexes before they are closed.
+		option: option{
+			protector:          pm,
+			mergePolicy:        newDefaultMergePolicy(),
+			seriesCacheMaxSize: run.Bytes(32 << 20),
+		},
+		omr: observability.NewBypassRegistry(),
+		pm:  pm,
+		l:   l,
+	}
+	stm := openStream(streamSpec{schema: schema}, l, pm, nil)
+	stm.OnIndexUpdate(indexRules)
+	db, err := s.OpenDB(group)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the storage of group %s: %w", group.GetMetadata().GetName(), err)
+	}
+	return &BulkLoader{
+		tsdb:          db.(storage.TSDB[*tsTable, option]),
+		stream:        stm,
+		idGen:         idgen.NewGenerator("bulk-load", l),
+		l:             l,
+		entityLocator: partition.NewEntityLocator(schema.GetTagFamilies(), schema.GetEntity(), 0),
+		shardNum:      group.GetResourceOpts().GetShardNum(),
+	}, nil
+}
+
+// Write writes an element, whose tag families are in the order of the stream schema.
+// A zero elementID derives the ID from the element ID string as the write path does,
+// while a non-zero one is stored as it is, which keeps the IDs of the exported elements.
+func (bl *BulkLoader) Write(element *streamv1.ElementValue, elementID uint64) error {
+	t := element.GetTimestamp().AsTime().Local()
+	if err := timestamp.Check(t); err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	metadata := bl.stream.schema.GetMetadata()
+	entityValues, shardID, err := bl.entityLocator.Locate(metadata.GetName(), element.GetTagFamilies(), bl.shardNum)
+	if err != nil {
+		return fmt.Errorf("cannot locate the series: %w", err)
+	}
+	if elementID == 0 {
+		if element.GetElementId() != "" {
+			elementID = convert.HashStr(metadata.Group + "|" + metadata.Name + "|" + element.GetElementId())
+		} else {
+			elementID = bl.idGen.NextID()
+		}
+	}
+	writeEvent := &streamv1.InternalWriteRequest{
+		Request:      &streamv1.WriteRequest{Metadata: metadata, Element: element},
+		ShardId:      uint32(shardID),
+		EntityValues: entityValues[1:].Encode(),
+	}
+	et, err := bl.table(t, shardID)
+	if err != nil {
+		return err
+	}
+	ts := t.UnixNano()
+	if bl.eg.latestTS < ts {
+		bl.eg.latestTS = ts
+	}
+	if err = appendElement(bl.stream, et.elements, writeEvent, elementID, ts, &et.docs, &et.seriesDocs, metadata, nil); err != nil {
+		return err
+	}
+	bl.pending++
+	if bl.pending >= bulkLoadBatchSize {
+		return bl.Flush()
+	}
+	return nil
+}
+
+func (bl *BulkLoader) table(t time.Time, shardID common.ShardID) (*elementsInTable, error) {
+	if bl.eg == nil {
+		bl.eg = &elementsInGroup{tsdb: bl.tsdb}
+	}
+	ts := t.UnixNano()
+	for _, et := range bl.eg.tables {
+		if et.timeRange.Contains(ts) && et.shardID == shardID {
+			return et, nil
+		}
+	}
+	var segment storage.Segment[*tsTable, option]
+	for _, seg := range bl.eg.segments {
+		if seg.GetTimeRange().Contains(ts) {
+			segment = seg
+			break
+		}
+	}
+	if segment == nil {
+		var err error
+		if segment, err = bl.tsdb.CreateSegmentIfNotExist(t); err != nil {
+			return nil, fmt.Errorf("cannot create segment: %w", err)
+		}
+		bl.eg.segments = append(bl.eg.segments, segment)
+	}
+	tst, err := segment.CreateTSTableIfNotExist(shardID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create ts table: %w", err)
+	}
+	et := &elementsInTable{
+		shardID:   shardID,
+		timeRange: segment.GetTimeRange(),
+		tsTable:   tst,
+		elements:  generateElements(),
+		segment:   segment,
+		seriesDocs: seriesDoc{
+			docs:        make(index.Documents, 0),
+			docIDsAdded: make(map[uint64]struct{}),
+		},
+	}
+	et.elements.reset()
+	bl.eg.tables = append(bl.eg.tables, et)
+	return et, nil
+}
+
+// Flush writes the buffered elements into parts on the disk, and their indexes into the element and the series indexes.
+func (bl *BulkLoader) Flush() error {
+	if bl.eg == nil {
+		return nil
+	}
+	eg := bl.eg
+	bl.eg, bl.pending = nil, 0
+	defer func() {
+		for _, segment := range eg.segments {
+			segment.DecRef()
+		}
+	}()
+	for _, et := range eg.tables {
+		if len(et.elements.seriesIDs) > 0 {
+			tst := et.tsTable
+			mp := generateMemPart()
+			mp.mustInitFromElements(et.elements)
+			partID := atomic.AddUint64(&tst.curPartID, 1)
+			mp.mustFlush(tst.fileSystem, partPath(tst.root, partID))
+			releaseMemPart(mp)
+			tst.mustAddFilePartWithProjections(partID, bl.stream.projections)
+		}
+		releaseElements(et.elements)
+		if len(et.docs) > 0 {
+			if err := et.tsTable.Index().Write(et.docs); err != nil {
+				return fmt.Errorf("cannot write the element index: %w", err)
+			}
+		}
+		if len(et.seriesDocs.docs) > 0 {
+			if err := et.segment.IndexDB().Insert(et.seriesDocs.docs); err != nil {
+				return fmt.Errorf("cannot write the series index: %w", err)
+			}
+		}
+	}
+	bl.l.Debug().Int("tables", len(eg.tables)).Int64("latest", eg.latestTS).Msg("flushed elements")
+	return nil
+}
+
+// Close flushes the buffered elements and closes the storage.
+func (bl *BulkLoader) Close() error {
+	err := bl.Flush()
+	if closeErr := bl.tsdb.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
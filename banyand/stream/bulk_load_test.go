@@ -0,0 +1,107 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func TestBulkLoader(t *testing.T) {
+	root := t.TempDir()
+	group := &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "bulk"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        2,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		},
+	}
+	schema := &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "logs", Group: "bulk"},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "level", Type: databasev1.TagType_TAG_TYPE_STRING},
+			},
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{"service"}},
+	}
+
+	bl, err := NewBulkLoader(root, group, schema, nil)
+	require.NoError(t, err)
+	now := time.Now().Truncate(time.Millisecond)
+	const total = 1000
+	for i := 0; i < total; i++ {
+		e := &streamv1.ElementValue{
+			Timestamp: timestamppb.New(now.Add(-time.Duration(i) * time.Second)),
+			TagFamilies: []*modelv1.TagFamilyForWrite{{
+				Tags: []*modelv1.TagValue{
+					{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: fmt.Sprintf("svc-%d", i%3)}}},
+					{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "INFO"}}},
+				},
+			}},
+		}
+		// The elements keep their IDs, or get the IDs derived from the element ID strings.
+		var id uint64
+		if i%2 == 0 {
+			id = uint64(i + 1)
+		} else {
+			e.ElementId = fmt.Sprint(i)
+		}
+		require.NoError(t, bl.Write(e, id))
+	}
+	require.NoError(t, bl.Close())
+
+	bl, err = NewBulkLoader(root, group, schema, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, bl.Close())
+	}()
+	segments, err := bl.tsdb.SelectSegments(timestamp.NewInclusiveTimeRange(now.Add(-time.Hour), now))
+	require.NoError(t, err)
+	var count uint64
+	for _, segment := range segments {
+		tables, _ := segment.Tables()
+		for _, tst := range tables {
+			s := tst.currentSnapshot()
+			if s == nil {
+				continue
+			}
+			for _, pw := range s.parts {
+				require.Nil(t, pw.mp)
+				count += pw.p.partMetadata.TotalCount
+			}
+			s.decRef()
+		}
+		segment.DecRef()
+	}
+	require.Equal(t, uint64(total), count)
+}
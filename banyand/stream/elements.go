@@ -19,6 +19,7 @@ package stream
 
 import (
 	"bytes"
+	"slices"
 
 	"github.com/pkg/errors"
 
@@ -201,13 +202,22 @@ func releaseElements(e *elements) {
 var elementsPool = pool.Register[*elements]("stream-elements")
 
 type elementsInTable struct {
-	seriesDocs seriesDoc
-	segment    storage.Segment[*tsTable, option]
-	tsTable    *tsTable
-	elements   *elements
-	timeRange  timestamp.TimeRange
-	docs       index.Documents
-	shardID    common.ShardID
+	seriesDocs  seriesDoc
+	segment     storage.Segment[*tsTable, option]
+	tsTable     *tsTable
+	elements    *elements
+	timeRange   timestamp.TimeRange
+	docs        index.Documents
+	projections []projectionSpec
+	shardID     common.ShardID
+}
+
+func (et *elementsInTable) addProjections(specs []projectionSpec) {
+	for _, spec := range specs {
+		if !slices.ContainsFunc(et.projections, func(p projectionSpec) bool { return p.key == spec.key }) {
+			et.projections = append(et.projections, spec)
+		}
+	}
 }
 
 type elementsInGroup struct {
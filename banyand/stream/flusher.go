@@ -32,6 +32,9 @@ func (tst *tsTable) flusherLoop(flushCh chan *flusherIntroduction, mergeCh chan
 		return
 	}
 	var flusherWatchers watcher.Epochs
+	if tst.indexStats.Load() == nil {
+		tst.refreshIndexStats(true)
+	}
 
 	for {
 		select {
@@ -56,6 +59,8 @@ func (tst *tsTable) flusherLoop(flushCh chan *flusherIntroduction, mergeCh chan
 					if !ok {
 						return false
 					}
+					tst.flushProjections()
+					tst.refreshIndexStats(false)
 					if !merged {
 						tst.flush(curSnapshot, flushCh)
 					}
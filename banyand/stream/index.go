@@ -26,6 +26,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/common"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/index/posting"
@@ -73,6 +74,23 @@ func (e *elementIndex) Write(docs index.Documents) error {
 	})
 }
 
+// Rewrite replaces the indexed documents of the elements.
+func (e *elementIndex) Rewrite(docs index.Documents) error {
+	return e.store.Batch(index.Batch{
+		Documents: docs,
+		Replace:   true,
+	})
+}
+
+// Delete removes the elements from the index.
+func (e *elementIndex) Delete(elementIDs map[uint64]struct{}) error {
+	docIDs := make([][]byte, 0, len(elementIDs))
+	for id := range elementIDs {
+		docIDs = append(docIDs, convert.Uint64ToBytes(id))
+	}
+	return e.store.Delete(docIDs)
+}
+
 func (e *elementIndex) Search(ctx context.Context, seriesList []uint64, filter index.Filter, tr *index.RangeOpts) (posting.List, posting.List, error) {
 	var result, resultTS posting.List
 	for i, id := range seriesList {
@@ -108,6 +126,15 @@ func (e *elementIndex) Search(ctx context.Context, seriesList []uint64, filter i
 	return result, resultTS, nil
 }
 
+// CollectStats collects the term statistics of the indexed tags.
+func (e *elementIndex) CollectStats(topN int) (*index.Stats, error) {
+	sc, ok := e.store.(index.StatsCollector)
+	if !ok {
+		return nil, nil
+	}
+	return sc.CollectStats(topN)
+}
+
 func (e *elementIndex) EnableExternalSegments() (index.ExternalSegmentStreamer, error) {
 	return e.store.EnableExternalSegments()
 }
@@ -0,0 +1,132 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	indexStatsFilename        = "index-stats.json"
+	indexStatsTopTerms        = 16
+	indexStatsRefreshInterval = time.Minute
+)
+
+// IndexStats returns the statistics of the element indexes of the segments overlapping the time range.
+// It returns nil if any table hasn't collected its statistics yet.
+func (s *stream) IndexStats(tr timestamp.TimeRange) *index.Stats {
+	tsdb, err := s.getTSDB()
+	if err != nil {
+		return nil
+	}
+	segments, err := tsdb.SelectSegments(tr)
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	stats := &index.Stats{}
+	for i := range segments {
+		tables, _ := segments[i].Tables()
+		for _, tst := range tables {
+			st := tst.indexStats.Load()
+			if st == nil {
+				return nil
+			}
+			stats.Merge(st, indexStatsTopTerms)
+		}
+	}
+	return stats
+}
+
+func (tst *tsTable) loadIndexStats() {
+	statsPath := filepath.Join(tst.root, indexStatsFilename)
+	if !tst.fileSystem.IsExist(statsPath) {
+		return
+	}
+	data, err := tst.fileSystem.Read(statsPath)
+	if err != nil {
+		tst.l.Warn().Err(err).Str("path", statsPath).Msg("cannot read index stats")
+		return
+	}
+	var stats index.Stats
+	if err = json.Unmarshal(data, &stats); err != nil {
+		tst.l.Warn().Err(err).Str("path", statsPath).Msg("cannot parse index stats")
+		return
+	}
+	tst.indexStats.Store(&stats)
+}
+
+// refreshIndexStats collects the statistics of the element index if they're missing or out of date.
+func (tst *tsTable) refreshIndexStats(force bool) {
+	if tst.index == nil {
+		return
+	}
+	if cur := tst.indexStats.Load(); !force && cur != nil && time.Since(time.Unix(0, cur.UpdatedAt)) < indexStatsRefreshInterval {
+		return
+	}
+	stats, err := tst.index.CollectStats(indexStatsTopTerms)
+	if err != nil {
+		tst.l.Warn().Err(err).Msg("cannot collect index stats")
+		return
+	}
+	if stats == nil {
+		return
+	}
+	tst.mustPersistIndexStats(stats)
+	tst.indexStats.Store(stats)
+}
+
+func (tst *tsTable) mustPersistIndexStats(stats *index.Stats) {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		logger.Panicf("cannot marshal index stats to JSON: %s", err)
+	}
+	statsPath := filepath.Join(tst.root, indexStatsFilename)
+	statsTempPath := statsPath + ".tmp"
+	lf, err := tst.fileSystem.CreateLockFile(statsTempPath, storage.FilePerm)
+	if err != nil {
+		logger.Panicf("cannot create lock file %s: %s", statsTempPath, err)
+	}
+	n, err := lf.Write(data)
+	if err != nil {
+		_ = lf.Close()
+		logger.Panicf("cannot write index stats %s: %s", statsTempPath, err)
+	}
+	if n != len(data) {
+		_ = lf.Close()
+		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", statsTempPath, n, len(data))
+	}
+	if closeErr := lf.Close(); closeErr != nil {
+		logger.Panicf("cannot close index stats temp file %s: %s", statsTempPath, closeErr)
+	}
+	if renameErr := tst.fileSystem.Rename(statsTempPath, statsPath); renameErr != nil {
+		logger.Panicf("cannot rename index stats %s to %s: %s", statsTempPath, statsPath, renameErr)
+	}
+	tst.fileSystem.SyncPath(tst.root)
+}
@@ -79,16 +79,18 @@ func releaseFlusherIntroduction(i *flusherIntroduction) {
 }
 
 type mergerIntroduction struct {
-	merged  map[uint64]struct{}
-	newPart *partWrapper
-	applied chan struct{}
-	creator snapshotCreator
+	merged     map[uint64]struct{}
+	tombstones map[uint64]*tombstone
+	newPart    *partWrapper
+	applied    chan struct{}
+	creator    snapshotCreator
 }
 
 func (i *mergerIntroduction) reset() {
 	for k := range i.merged {
 		delete(i.merged, k)
 	}
+	i.tombstones = nil
 	i.newPart = nil
 	i.applied = nil
 	i.creator = 0
@@ -279,6 +281,13 @@ func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoc
 		tst.l.Panic().Msg("current snapshot is nil")
 	}
 	defer cur.decRef()
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	for _, pw := range cur.parts {
+		if flushed, ok := nextIntroduction.flushed[pw.ID()]; ok {
+			flushed.p.tombstone.Store(pw.p.tombstone.Load())
+		}
+	}
 	nextSnp := cur.merge(epoch, nextIntroduction.flushed)
 	nextSnp.creator = snapshotCreatorFlusher
 	tst.replaceSnapshot(&nextSnp)
@@ -295,11 +304,17 @@ func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch
 		return
 	}
 	defer cur.decRef()
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	carryTombstones(cur, nextIntroduction.newPart, nextIntroduction.merged, nextIntroduction.tombstones)
 	nextSnp := cur.remove(epoch, nextIntroduction.merged)
 	nextSnp.parts = append(nextSnp.parts, nextIntroduction.newPart)
 	nextSnp.creator = nextIntroduction.creator
 	tst.replaceSnapshot(&nextSnp)
 	tst.persistSnapshot(&nextSnp)
+	if tst.hasTombstones {
+		tst.mustPersistTombstones(&nextSnp)
+	}
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
@@ -53,22 +53,26 @@ type SchemaService interface {
 }
 type schemaRepo struct {
 	resourceSchema.Repository
-	l        *logger.Logger
-	metadata metadata.Repo
-	idGen    *idgen.Generator
-	path     string
-	nodeID   string
-	role     databasev1.Role
+	l           *logger.Logger
+	metadata    metadata.Repo
+	idGen       *idgen.Generator
+	projections *projectionRegistry
+	observers   *writeObservers
+	path        string
+	nodeID      string
+	role        databasev1.Role
 }
 
 func newSchemaRepo(path string, svc *standalone, nodeLabels map[string]string, nodeID string) schemaRepo {
 	sr := schemaRepo{
-		l:        svc.l,
-		path:     path,
-		metadata: svc.metadata,
-		nodeID:   nodeID,
-		idGen:    idgen.NewGenerator(nodeID, svc.l),
-		role:     databasev1.Role_ROLE_DATA,
+		l:           svc.l,
+		path:        path,
+		metadata:    svc.metadata,
+		nodeID:      nodeID,
+		idGen:       idgen.NewGenerator(nodeID, svc.l),
+		projections: newProjectionRegistry(),
+		observers:   &svc.observers,
+		role:        databasev1.Role_ROLE_DATA,
 		Repository: resourceSchema.NewRepository(
 			svc.metadata,
 			svc.l,
@@ -82,12 +86,13 @@ func newSchemaRepo(path string, svc *standalone, nodeLabels map[string]string, n
 
 func newLiaisonSchemaRepo(path string, svc *liaison, streamDataNodeRegistry grpc.NodeRegistry, nodeID string) schemaRepo {
 	sr := schemaRepo{
-		l:        svc.l,
-		path:     path,
-		metadata: svc.metadata,
-		nodeID:   nodeID,
-		idGen:    idgen.NewGenerator(nodeID, svc.l),
-		role:     databasev1.Role_ROLE_LIAISON,
+		l:         svc.l,
+		path:      path,
+		metadata:  svc.metadata,
+		nodeID:    nodeID,
+		idGen:     idgen.NewGenerator(nodeID, svc.l),
+		observers: &svc.observers,
+		role:      databasev1.Role_ROLE_LIAISON,
 		Repository: resourceSchema.NewRepository(
 			svc.metadata,
 			svc.l,
@@ -205,6 +210,7 @@ func (sr *schemaRepo) OnDelete(metadata schema.Metadata) {
 		})
 	case schema.KindStream:
 		streamSpec := metadata.Spec.(*databasev1.Stream)
+		sr.projections.remove(streamSpec.GetMetadata().GetGroup(), streamSpec.GetMetadata().GetName())
 		sr.SendMetadataEvent(resourceSchema.MetadataEvent{
 			Typ:            resourceSchema.EventDelete,
 			Kind:           resourceSchema.EventKindResource,
@@ -370,9 +376,19 @@ func (sr *schemaRepo) collectShardInfo(table any, shardID uint32) *databasev1.Sh
 		InvertedIndexInfo: invertedIndexInfo,
 		SidxInfo:          &databasev1.SIDXInfo{},
 		FilePartCount:     int64(filePartCount),
+		IndexBackfillInfo: sr.collectIndexBackfillInfo(tst),
 	}
 }
 
+func (sr *schemaRepo) collectIndexBackfillInfo(tst *tsTable) []*databasev1.IndexBackfillInfo {
+	progress, err := storage.LoadIndexBackfillProgress(tst.fileSystem, tst.root)
+	if err != nil {
+		sr.l.Warn().Err(err).Str("shard", tst.root).Msg("cannot load the index backfill progress")
+		return nil
+	}
+	return progress.Infos()
+}
+
 func (sr *schemaRepo) collectInvertedIndexInfo(tst *tsTable) *databasev1.InvertedIndexInfo {
 	if tst.index == nil {
 		return &databasev1.InvertedIndexInfo{
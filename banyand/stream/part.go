@@ -63,6 +63,7 @@ type part struct {
 	tagFamilies          map[string]fs.Reader
 	tagFamilyFilter      map[string]fs.Reader
 	seriesMetadata       fs.Reader // Optional: series metadata reader
+	tombstone            atomic.Pointer[tombstone]
 	path                 string
 	primaryBlockMetadata []primaryBlockMetadata
 	partMetadata         partMetadata
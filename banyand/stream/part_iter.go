@@ -265,6 +265,7 @@ func (pi *partIter) findBlock() bool {
 type partMergeIter struct {
 	seqReaders           seqReaders
 	err                  error
+	tombstone            *tombstone
 	primaryBlockMetadata []primaryBlockMetadata
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
@@ -279,6 +280,7 @@ func (pmi *partMergeIter) reset() {
 	pmi.primaryMetadataIdx = 0
 	pmi.primaryBuf = pmi.primaryBuf[:0]
 	pmi.compressedPrimaryBuf = pmi.compressedPrimaryBuf[:0]
+	pmi.tombstone = nil
 	pmi.block.reset()
 }
 
@@ -342,6 +344,9 @@ func (pmi *partMergeIter) loadBlockMetadata() error {
 
 func (pmi *partMergeIter) mustLoadBlockData(decoder *encoding.BytesBlockDecoder, block *blockPointer) {
 	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm)
+	if pmi.tombstone.removeFrom(&block.block) {
+		block.updateMetadata()
+	}
 }
 
 func generatePartMergeIter() *partMergeIter {
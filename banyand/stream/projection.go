@@ -0,0 +1,630 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+const (
+	projectionDirName      = "projection"
+	projectionMetaFilename = "projection.json"
+	// projectionMaxFileParts is the number of file parts which triggers merging the smaller half of them.
+	projectionMaxFileParts = 8
+	// projectionRowHeaderSize is the size of the timestamp and the element ID leading a row.
+	projectionRowHeaderSize = 16
+)
+
+// projectionSpec is the storage form of a stream projection.
+type projectionSpec struct {
+	// key identifies the projection's directory in a table.
+	// It changes with the sort tag or the stored tags, so that a redefined projection starts from scratch.
+	key        string
+	name       string
+	sortFamily string
+	sortTag    string
+	tags       []projectionTag
+}
+
+type projectionTag struct {
+	family string
+	name   string
+}
+
+func parseProjectionSpecs(schema *databasev1.Stream) []projectionSpec {
+	if len(schema.GetProjections()) == 0 {
+		return nil
+	}
+	tagFamilies := make(map[string]string)
+	for _, tf := range schema.GetTagFamilies() {
+		for _, t := range tf.GetTags() {
+			tagFamilies[t.GetName()] = tf.GetName()
+		}
+	}
+	specs := make([]projectionSpec, 0, len(schema.GetProjections()))
+	for _, p := range schema.GetProjections() {
+		spec := projectionSpec{
+			name:       p.GetName(),
+			sortFamily: tagFamilies[p.GetSortTagName()],
+			sortTag:    p.GetSortTagName(),
+		}
+		var fingerprint strings.Builder
+		fingerprint.WriteString(spec.sortFamily + ":" + spec.sortTag)
+		for _, name := range p.GetTagNames() {
+			t := projectionTag{family: tagFamilies[name], name: name}
+			spec.tags = append(spec.tags, t)
+			fingerprint.WriteString("," + t.family + ":" + t.name)
+		}
+		spec.key = filepath.Join(schema.GetMetadata().GetName(),
+			fmt.Sprintf("%s-%016x", spec.name, convert.HashStr(fingerprint.String())))
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// projectionRegistry tracks the projections of the streams in each group.
+// Synced parts carry elements of any stream in the group, so they're projected by all of them.
+type projectionRegistry struct {
+	groups map[string]map[string][]projectionSpec
+	mu     sync.RWMutex
+}
+
+func newProjectionRegistry() *projectionRegistry {
+	return &projectionRegistry{groups: make(map[string]map[string][]projectionSpec)}
+}
+
+func (pr *projectionRegistry) store(group, stream string, specs []projectionSpec) {
+	if pr == nil {
+		return
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if len(specs) == 0 {
+		pr.removeLocked(group, stream)
+		return
+	}
+	streams, ok := pr.groups[group]
+	if !ok {
+		streams = make(map[string][]projectionSpec)
+		pr.groups[group] = streams
+	}
+	streams[stream] = specs
+}
+
+func (pr *projectionRegistry) remove(group, stream string) {
+	if pr == nil {
+		return
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.removeLocked(group, stream)
+}
+
+func (pr *projectionRegistry) removeLocked(group, stream string) {
+	streams, ok := pr.groups[group]
+	if !ok {
+		return
+	}
+	delete(streams, stream)
+	if len(streams) == 0 {
+		delete(pr.groups, group)
+	}
+}
+
+func (pr *projectionRegistry) load(group string) []projectionSpec {
+	if pr == nil {
+		return nil
+	}
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+	var specs []projectionSpec
+	for _, ss := range pr.groups[group] {
+		specs = append(specs, ss...)
+	}
+	return specs
+}
+
+type projectionMeta struct {
+	Parts      map[string]uint64 `json:"parts,omitempty"`
+	Tombstones []uint64          `json:"tombstones,omitempty"`
+	Since      int64             `json:"since"`
+}
+
+// projection is a pre-sorted view of a table, stored in a sidx instance keyed by the sort tag.
+// A row carries the element's timestamp, its ID and the stored tags.
+// The view covers the elements written after it was created, which is recorded in since.
+type projection struct {
+	sidx       sidx.SIDX
+	fileSystem fs.FileSystem
+	tombstones map[uint64]struct{}
+	memParts   map[uint64]uint64
+	fileParts  map[uint64]uint64
+	l          *logger.Logger
+	root       string
+	since      int64
+	curPartID  uint64
+	mu         sync.RWMutex
+}
+
+func openProjection(fileSystem fs.FileSystem, root string, tst *tsTable, since int64, load bool) (*projection, error) {
+	proj := &projection{
+		fileSystem: fileSystem,
+		root:       root,
+		since:      since,
+		l:          tst.l,
+		tombstones: make(map[uint64]struct{}),
+		memParts:   make(map[uint64]uint64),
+		fileParts:  make(map[uint64]uint64),
+	}
+	fileSystem.MkdirIfNotExist(root, storage.DirPerm)
+	var partIDs []uint64
+	if load {
+		meta, err := readProjectionMeta(fileSystem, root)
+		if err != nil {
+			tst.l.Warn().Err(err).Str("path", root).Msg("cannot read projection metadata, it won't serve queries")
+			proj.since = math.MaxInt64
+		} else {
+			proj.since = meta.Since
+			for _, id := range meta.Tombstones {
+				proj.tombstones[id] = struct{}{}
+			}
+		}
+		for _, e := range fileSystem.ReadDir(root) {
+			if !e.IsDir() {
+				continue
+			}
+			id, parseErr := parseEpoch(e.Name())
+			if parseErr != nil {
+				continue
+			}
+			partIDs = append(partIDs, id)
+			proj.fileParts[id] = meta.Parts[e.Name()]
+			if proj.curPartID < id {
+				proj.curPartID = id
+			}
+		}
+	}
+	opts, err := sidx.NewOptions(root, tst.option.protector)
+	if err != nil {
+		return nil, err
+	}
+	opts.AvailablePartIDs = partIDs
+	proj.sidx, err = sidx.NewSIDX(fileSystem, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !load {
+		proj.mustPersist()
+	}
+	return proj, nil
+}
+
+func readProjectionMeta(fileSystem fs.FileSystem, root string) (projectionMeta, error) {
+	var meta projectionMeta
+	data, err := fileSystem.Read(filepath.Join(root, projectionMetaFilename))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// mustPersist writes the projection's metadata. The caller must hold mu unless the projection isn't shared yet.
+func (proj *projection) mustPersist() {
+	meta := projectionMeta{
+		Since: proj.since,
+		Parts: make(map[string]uint64, len(proj.fileParts)),
+	}
+	for id, rows := range proj.fileParts {
+		meta.Parts[partName(id)] = rows
+	}
+	for id := range proj.tombstones {
+		meta.Tombstones = append(meta.Tombstones, id)
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		logger.Panicf("cannot marshal projection metadata to JSON: %s", err)
+	}
+	metaPath := filepath.Join(proj.root, projectionMetaFilename)
+	metaTempPath := metaPath + ".tmp"
+	lf, err := proj.fileSystem.CreateLockFile(metaTempPath, storage.FilePerm)
+	if err != nil {
+		logger.Panicf("cannot create lock file %s: %s", metaTempPath, err)
+	}
+	n, err := lf.Write(data)
+	if err != nil {
+		_ = lf.Close()
+		logger.Panicf("cannot write projection metadata %s: %s", metaTempPath, err)
+	}
+	if n != len(data) {
+		_ = lf.Close()
+		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", metaTempPath, n, len(data))
+	}
+	if closeErr := lf.Close(); closeErr != nil {
+		logger.Panicf("cannot close projection metadata temp file %s: %s", metaTempPath, closeErr)
+	}
+	if renameErr := proj.fileSystem.Rename(metaTempPath, metaPath); renameErr != nil {
+		logger.Panicf("cannot rename projection metadata %s to %s: %s", metaTempPath, metaPath, renameErr)
+	}
+	proj.fileSystem.SyncPath(proj.root)
+}
+
+// covers reports whether the projection holds all the elements from minTimestamp on.
+func (proj *projection) covers(minTimestamp int64) bool {
+	proj.mu.RLock()
+	defer proj.mu.RUnlock()
+	return proj.since <= minTimestamp
+}
+
+// invalidate stops the projection from serving queries after it missed some elements.
+func (proj *projection) invalidate() {
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+	if proj.since == math.MaxInt64 {
+		return
+	}
+	proj.since = math.MaxInt64
+	proj.mustPersist()
+}
+
+func (proj *projection) isDeleted(elementID uint64) bool {
+	proj.mu.RLock()
+	defer proj.mu.RUnlock()
+	_, ok := proj.tombstones[elementID]
+	return ok
+}
+
+func (proj *projection) addTombstones(elementIDs map[uint64]struct{}) {
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+	for id := range elementIDs {
+		proj.tombstones[id] = struct{}{}
+	}
+	proj.mustPersist()
+}
+
+func (proj *projection) introduceRows(reqs []sidx.WriteRequest, minTimestamp, maxTimestamp int64) error {
+	mp, err := proj.sidx.ConvertToMemPart(reqs, 0, &minTimestamp, &maxTimestamp)
+	if err != nil {
+		return err
+	}
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+	proj.curPartID++
+	proj.sidx.IntroduceMemPart(proj.curPartID, mp)
+	proj.memParts[proj.curPartID] = uint64(len(reqs))
+	return nil
+}
+
+// flush writes all the memory parts to a single file part, then merges the smaller half of
+// the file parts once there are too many of them.
+func (proj *projection) flush(closeCh <-chan struct{}) {
+	proj.mu.Lock()
+	memParts := make(map[uint64]struct{}, len(proj.memParts))
+	var rows uint64
+	for id, n := range proj.memParts {
+		memParts[id] = struct{}{}
+		rows += n
+	}
+	proj.mu.Unlock()
+	if len(memParts) > 0 {
+		proj.merge(closeCh, memParts, rows, proj.memParts)
+	}
+
+	proj.mu.RLock()
+	fileIDs := make([]uint64, 0, len(proj.fileParts))
+	for id := range proj.fileParts {
+		fileIDs = append(fileIDs, id)
+	}
+	proj.mu.RUnlock()
+	if len(fileIDs) < projectionMaxFileParts {
+		return
+	}
+	proj.mu.RLock()
+	sort.Slice(fileIDs, func(i, j int) bool {
+		return proj.fileParts[fileIDs[i]] < proj.fileParts[fileIDs[j]]
+	})
+	fileParts := make(map[uint64]struct{}, len(fileIDs)/2)
+	rows = 0
+	for _, id := range fileIDs[:len(fileIDs)/2] {
+		fileParts[id] = struct{}{}
+		rows += proj.fileParts[id]
+	}
+	proj.mu.RUnlock()
+	proj.merge(closeCh, fileParts, rows, proj.fileParts)
+}
+
+func (proj *projection) merge(closeCh <-chan struct{}, partIDs map[uint64]struct{}, rows uint64, from map[uint64]uint64) {
+	proj.mu.Lock()
+	proj.curPartID++
+	newPartID := proj.curPartID
+	proj.mu.Unlock()
+	intro, err := proj.sidx.Merge(closeCh, partIDs, newPartID)
+	if err != nil {
+		proj.fileSystem.MustRMAll(partPath(proj.root, newPartID))
+		select {
+		case <-closeCh:
+		default:
+			proj.l.Warn().Err(err).Str("path", proj.root).Msg("cannot merge projection parts")
+		}
+		return
+	}
+	if intro == nil {
+		return
+	}
+	defer intro.Release()
+	proj.mu.Lock()
+	release := proj.sidx.IntroduceMerged(intro)
+	for id := range partIDs {
+		delete(from, id)
+	}
+	proj.fileParts[newPartID] = rows
+	proj.mustPersist()
+	proj.mu.Unlock()
+	release()
+}
+
+func (proj *projection) close() error {
+	return proj.sidx.Close()
+}
+
+func projectionRoot(tstRoot, key string) string {
+	return filepath.Join(tstRoot, projectionDirName, key)
+}
+
+// loadProjections opens the projections persisted in the table.
+// A projection directory is laid out as <stream>/<name>-<fingerprint>.
+func (tst *tsTable) loadProjections() {
+	root := filepath.Join(tst.root, projectionDirName)
+	if !tst.fileSystem.IsExist(root) {
+		return
+	}
+	for _, streamDir := range tst.fileSystem.ReadDir(root) {
+		if !streamDir.IsDir() {
+			continue
+		}
+		for _, projDir := range tst.fileSystem.ReadDir(filepath.Join(root, streamDir.Name())) {
+			if !projDir.IsDir() {
+				continue
+			}
+			key := filepath.Join(streamDir.Name(), projDir.Name())
+			proj, err := openProjection(tst.fileSystem, projectionRoot(tst.root, key), tst, 0, true)
+			if err != nil {
+				tst.l.Warn().Err(err).Str("projection", key).Msg("cannot open projection, delete it")
+				tst.fileSystem.MustRMAll(projectionRoot(tst.root, key))
+				continue
+			}
+			if tst.projections == nil {
+				tst.projections = make(map[string]*projection)
+			}
+			tst.projections[key] = proj
+		}
+	}
+}
+
+// dropProjections removes the projections of a table whose parts couldn't be loaded.
+func (tst *tsTable) dropProjections() {
+	root := filepath.Join(tst.root, projectionDirName)
+	if tst.fileSystem.IsExist(root) {
+		tst.l.Info().Str("path", root).Msg("delete projections without parts")
+		tst.fileSystem.MustRMAll(root)
+	}
+}
+
+func (tst *tsTable) getProjection(key string) *projection {
+	tst.RLock()
+	defer tst.RUnlock()
+	return tst.projections[key]
+}
+
+func (tst *tsTable) getOrCreateProjection(key string) (*projection, error) {
+	if proj := tst.getProjection(key); proj != nil {
+		return proj, nil
+	}
+	tst.Lock()
+	defer tst.Unlock()
+	if proj, ok := tst.projections[key]; ok {
+		return proj, nil
+	}
+	// A projection created before the first part covers the table entirely.
+	since := int64(math.MinInt64)
+	if tst.snapshot != nil && len(tst.snapshot.parts) > 0 {
+		since = time.Now().UnixNano()
+	}
+	proj, err := openProjection(tst.fileSystem, projectionRoot(tst.root, key), tst, since, false)
+	if err != nil {
+		return nil, err
+	}
+	if tst.projections == nil {
+		tst.projections = make(map[string]*projection)
+	}
+	tst.projections[key] = proj
+	return proj, nil
+}
+
+func (tst *tsTable) allProjections() []*projection {
+	tst.RLock()
+	defer tst.RUnlock()
+	result := make([]*projection, 0, len(tst.projections))
+	for _, proj := range tst.projections {
+		result = append(result, proj)
+	}
+	return result
+}
+
+func (tst *tsTable) flushProjections() {
+	for _, proj := range tst.allProjections() {
+		proj.flush(tst.loopCloser.CloseNotify())
+	}
+}
+
+func (tst *tsTable) deleteProjectedElements(elementIDs map[uint64]struct{}) {
+	for _, proj := range tst.allProjections() {
+		proj.addTombstones(elementIDs)
+	}
+}
+
+func (tst *tsTable) closeProjections() {
+	for key, proj := range tst.projections {
+		if err := proj.close(); err != nil {
+			tst.l.Warn().Err(err).Str("projection", key).Msg("cannot close projection")
+		}
+	}
+	tst.projections = nil
+}
+
+func (tst *tsTable) mustAddElementsWithProjections(es *elements, specs []projectionSpec) {
+	if len(es.seriesIDs) == 0 {
+		return
+	}
+	mp := generateMemPart()
+	mp.mustInitFromElements(es)
+	tst.projectPart(specs, openMemPart(mp))
+	tst.mustAddMemPart(mp)
+}
+
+func (tst *tsTable) mustAddFilePartWithProjections(partID uint64, specs []projectionSpec) {
+	if len(specs) > 0 {
+		p := mustOpenFilePart(partID, tst.root, tst.fileSystem)
+		tst.projectPart(specs, p)
+		p.close()
+	}
+	tst.mustAddFilePart(partID)
+}
+
+// projectPart adds the elements of a part to the projections.
+// The part isn't introduced yet, so the view never lags behind the table.
+func (tst *tsTable) projectPart(specs []projectionSpec, p *part) {
+	if len(specs) == 0 {
+		return
+	}
+	projections := make([]*projection, len(specs))
+	for i := range specs {
+		proj, err := tst.getOrCreateProjection(specs[i].key)
+		if err != nil {
+			tst.l.Error().Err(err).Str("projection", specs[i].key).Msg("cannot create projection")
+			continue
+		}
+		projections[i] = proj
+	}
+	reqs := make([][]sidx.WriteRequest, len(specs))
+	minTimestamps := make([]int64, len(specs))
+	maxTimestamps := make([]int64, len(specs))
+	for i := range specs {
+		minTimestamps[i], maxTimestamps[i] = math.MaxInt64, math.MinInt64
+	}
+	pmi := generatePartMergeIter()
+	defer releasePartMergeIter(pmi)
+	pmi.mustInitFromPart(p)
+	br := generateBlockReader()
+	defer releaseBlockReader(br)
+	br.init([]*partMergeIter{pmi})
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	for br.nextBlockMetadata() {
+		br.loadBlockData(decoder)
+		b := br.block
+		for i := range b.timestamps {
+			for j := range specs {
+				if projections[j] == nil {
+					continue
+				}
+				sortValue := lookupBlockTagValue(&b.block, specs[j].sortFamily, specs[j].sortTag, i)
+				if sortValue.GetInt() == nil {
+					continue
+				}
+				reqs[j] = append(reqs[j], sidx.WriteRequest{
+					SeriesID: b.bm.seriesID,
+					Key:      sortValue.GetInt().GetValue(),
+					Data:     encodeProjectionRow(&b.block, specs[j], b.timestamps[i], b.elementIDs[i], i),
+				})
+				minTimestamps[j] = min(minTimestamps[j], b.timestamps[i])
+				maxTimestamps[j] = max(maxTimestamps[j], b.timestamps[i])
+			}
+		}
+	}
+	readErr := br.error()
+	for i := range specs {
+		if projections[i] == nil {
+			continue
+		}
+		if readErr != nil {
+			tst.l.Error().Err(readErr).Str("projection", specs[i].key).Msg("cannot read part for projection")
+			projections[i].invalidate()
+			continue
+		}
+		if len(reqs[i]) == 0 {
+			continue
+		}
+		if err := projections[i].introduceRows(reqs[i], minTimestamps[i], maxTimestamps[i]); err != nil {
+			tst.l.Error().Err(err).Str("projection", specs[i].key).Msg("cannot write projection rows")
+			projections[i].invalidate()
+		}
+	}
+}
+
+func encodeProjectionRow(b *block, spec projectionSpec, ts int64, elementID uint64, idx int) []byte {
+	tf := &modelv1.TagFamilyForWrite{Tags: make([]*modelv1.TagValue, len(spec.tags))}
+	for i, t := range spec.tags {
+		v := lookupBlockTagValue(b, t.family, t.name, idx)
+		if v == nil {
+			v = pbv1.NullTagValue
+		}
+		tf.Tags[i] = v
+	}
+	data := make([]byte, 0, projectionRowHeaderSize+proto.Size(tf))
+	data = encoding.Int64ToBytes(data, ts)
+	data = encoding.Uint64ToBytes(data, elementID)
+	data, err := proto.MarshalOptions{}.MarshalAppend(data, tf)
+	if err != nil {
+		logger.Panicf("cannot marshal projection row: %s", err)
+	}
+	return data
+}
+
+func decodeProjectionRow(data []byte) (int64, uint64, []*modelv1.TagValue, error) {
+	if len(data) < projectionRowHeaderSize {
+		return 0, 0, nil, fmt.Errorf("projection row is too short: %d bytes", len(data))
+	}
+	ts := encoding.BytesToInt64(data[:8])
+	elementID := encoding.BytesToUint64(data[8:projectionRowHeaderSize])
+	tf := &modelv1.TagFamilyForWrite{}
+	if err := proto.Unmarshal(data[projectionRowHeaderSize:], tf); err != nil {
+		return 0, 0, nil, fmt.Errorf("cannot unmarshal projection row: %w", err)
+	}
+	return ts, elementID, tf.GetTags(), nil
+}
@@ -0,0 +1,128 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func Test_parseProjectionSpecs(t *testing.T) {
+	s := &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Group: "default", Name: "sw"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "searchable", Tags: []*databasev1.TagSpec{
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				{Name: "endpoint", Type: databasev1.TagType_TAG_TYPE_STRING},
+			}},
+			{Name: "data", Tags: []*databasev1.TagSpec{{Name: "raw", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}}},
+		},
+		Projections: []*databasev1.StreamProjection{
+			{Name: "by_duration", SortTagName: "duration", TagNames: []string{"endpoint", "raw"}},
+		},
+	}
+	specs := parseProjectionSpecs(s)
+	require.Len(t, specs, 1)
+	require.Equal(t, "searchable", specs[0].sortFamily)
+	require.Equal(t, []projectionTag{{family: "searchable", name: "endpoint"}, {family: "data", name: "raw"}}, specs[0].tags)
+	require.Equal(t, specs[0].key, parseProjectionSpecs(s)[0].key)
+
+	s.Projections[0].TagNames = []string{"endpoint"}
+	require.NotEqual(t, specs[0].key, parseProjectionSpecs(s)[0].key)
+}
+
+func TestProjection(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: 0, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}}
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	specs := []projectionSpec{{
+		key:        "sw/by_int-0000000000000001",
+		name:       "by_int",
+		sortFamily: "singleTag",
+		sortTag:    "intTag",
+		tags:       []projectionTag{{family: "singleTag", name: "strTag"}},
+	}}
+	tst.mustAddElementsWithProjections(esTS1, specs)
+	tst.mustAddElementsWithProjections(esTS2, specs)
+
+	proj := tst.getProjection(specs[0].key)
+	require.NotNil(t, proj)
+	require.True(t, proj.covers(0), "a projection created with the table covers it entirely")
+	assertProjectionRows(t, proj, []uint64{12, 11}, []string{"value3", "value1"})
+
+	require.NoError(t, tst.deleteElements(0, math.MaxInt64, map[uint64]struct{}{11: {}}))
+	require.True(t, proj.isDeleted(11))
+
+	require.Eventually(t, func() bool {
+		proj.mu.RLock()
+		defer proj.mu.RUnlock()
+		return len(proj.memParts) == 0 && len(proj.fileParts) > 0
+	}, 30*time.Second, 100*time.Millisecond, "projection mem parts not flushed in time")
+	require.Eventually(t, allPartsFlushed(tst), 30*time.Second, 100*time.Millisecond)
+	require.NoError(t, tst.Close())
+
+	tst, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	defer tst.Close()
+	proj = tst.getProjection(specs[0].key)
+	require.NotNil(t, proj, "the projection is loaded with the table")
+	require.True(t, proj.covers(0))
+	require.True(t, proj.isDeleted(11))
+	assertProjectionRows(t, proj, []uint64{12, 11}, []string{"value3", "value1"})
+}
+
+func assertProjectionRows(t *testing.T, proj *projection, wantIDs []uint64, wantTags []string) {
+	respCh, errCh := proj.sidx.StreamingQuery(context.Background(), sidx.QueryRequest{
+		SeriesIDs:    []common.SeriesID{1, 2, 3},
+		Order:        &index.OrderBy{Sort: modelv1.Sort_SORT_DESC},
+		MaxBatchSize: 10,
+	})
+	var gotIDs []uint64
+	var gotTags []string
+	for resp := range respCh {
+		require.NoError(t, resp.Error)
+		for _, data := range resp.Data {
+			_, elementID, values, decodeErr := decodeProjectionRow(data)
+			require.NoError(t, decodeErr)
+			gotIDs = append(gotIDs, elementID)
+			gotTags = append(gotTags, values[0].GetStr().GetValue())
+		}
+	}
+	require.NoError(t, <-errCh)
+	require.Equal(t, wantIDs, gotIDs)
+	require.Equal(t, wantTags, gotTags)
+}
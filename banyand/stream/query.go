@@ -94,6 +94,18 @@ func (s *stream) Query(ctx context.Context, sqo model.StreamQueryOptions) (sqr m
 		return sqr, nil
 	}
 
+	if sqo.Projection != "" {
+		var handled bool
+		sqr, handled, err = s.executeProjectionQuery(ctx, segments, series, sqo, schemaTagTypes, &tr)
+		if err != nil {
+			return nil, err
+		}
+		if handled {
+			segmentsNeedRelease = false
+			return sqr, nil
+		}
+	}
+
 	sqr, err = s.executeIndexedQuery(ctx, segments, series, sqo, schemaTagTypes, &tr)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,289 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// projectionCovers reports whether the projection holds all the elements of the tables in the time range.
+// Tables without any part don't need the projection.
+func projectionCovers(segments []storage.Segment[*tsTable, option], key string, minTimestamp int64) bool {
+	for i := range segments {
+		tables, _ := segments[i].Tables()
+		for _, tst := range tables {
+			proj := tst.getProjection(key)
+			if proj != nil {
+				if !proj.covers(minTimestamp) {
+					return false
+				}
+				continue
+			}
+			snp := tst.currentSnapshot()
+			if snp == nil {
+				continue
+			}
+			empty := len(snp.parts) == 0
+			snp.decRef()
+			if !empty {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// executeProjectionQuery reads the elements from the projection in the order of its sort tag.
+// It returns false if the projection can't serve the query, and the caller falls back to the index.
+func (s *stream) executeProjectionQuery(
+	ctx context.Context,
+	segments []storage.Segment[*tsTable, option],
+	series []*pbv1.Series,
+	sqo model.StreamQueryOptions,
+	schemaTagTypes map[string]pbv1.ValueType,
+	tr *index.RangeOpts,
+) (model.StreamQueryResult, bool, error) {
+	spec, ok := s.projectionSpec(sqo.Projection)
+	if !ok || len(sqo.Order.Index.Tags) != 1 || sqo.Order.Index.Tags[0] != spec.sortTag {
+		return nil, false, nil
+	}
+	minTimestamp, maxTimestamp := sqo.TimeRange.Start.UnixNano(), sqo.TimeRange.End.UnixNano()
+	if !projectionCovers(segments, spec.key, minTimestamp) {
+		return nil, false, nil
+	}
+	idx, seriesFilter, _, err := s.processSegmentsAndBuildFilters(ctx, segments, series, sqo, schemaTagTypes, tr)
+	if err != nil {
+		return nil, true, err
+	}
+	if seriesFilter.IsEmpty() {
+		idx.Release()
+		return nil, true, nil
+	}
+	sids := make([]common.SeriesID, 0, seriesFilter.Len())
+	for _, id := range seriesFilter.ToSlice() {
+		sids = append(sids, common.SeriesID(id))
+	}
+	queryCtx, cancel := context.WithCancel(ctx)
+	result := &projectionResult{
+		spec:           spec,
+		segments:       segments,
+		seriesToEntity: idx.qo.seriesToEntity,
+		elementFilter:  idx.qo.elementFilter,
+		tagProjection:  sqo.TagProjection,
+		entityIndex:    make(map[string]int, len(s.schema.GetEntity().GetTagNames())),
+		minTimestamp:   minTimestamp,
+		maxTimestamp:   maxTimestamp,
+		maxElementSize: sqo.MaxElementSize,
+		asc:            sqo.Order.Sort != modelv1.Sort_SORT_DESC,
+		seen:           make(map[uint64]struct{}),
+		cancel:         cancel,
+	}
+	for i, name := range s.schema.GetEntity().GetTagNames() {
+		result.entityIndex[name] = i
+	}
+	for _, tst := range idx.tabs {
+		proj := tst.getProjection(spec.key)
+		if proj == nil {
+			continue
+		}
+		respCh, errCh := proj.sidx.StreamingQuery(queryCtx, sidx.QueryRequest{
+			SeriesIDs:    sids,
+			Order:        sqo.Order,
+			MinTimestamp: &minTimestamp,
+			MaxTimestamp: &maxTimestamp,
+			MaxBatchSize: sqo.MaxElementSize,
+		})
+		result.cursors = append(result.cursors, &projectionCursor{proj: proj, respCh: respCh, errCh: errCh})
+	}
+	streamQueryResultTracker.Acquire(result)
+	return result, true, nil
+}
+
+type projectionCursor struct {
+	proj   *projection
+	respCh <-chan *sidx.QueryResponse
+	errCh  <-chan error
+	resp   *sidx.QueryResponse
+	idx    int
+}
+
+// next moves to the next row, and returns false once the cursor is exhausted.
+func (pc *projectionCursor) next() (bool, error) {
+	if pc.resp != nil {
+		pc.idx++
+		if pc.idx < pc.resp.Len() {
+			return true, nil
+		}
+	}
+	for {
+		resp, ok := <-pc.respCh
+		if !ok {
+			pc.resp = nil
+			return false, <-pc.errCh
+		}
+		if resp.Error != nil {
+			return false, resp.Error
+		}
+		if resp.Len() > 0 {
+			pc.resp, pc.idx = resp, 0
+			return true, nil
+		}
+	}
+}
+
+func (pc *projectionCursor) key() int64 {
+	return pc.resp.Keys[pc.idx]
+}
+
+type projectionResult struct {
+	elementFilter  posting.List
+	seriesToEntity map[common.SeriesID][]*modelv1.TagValue
+	entityIndex    map[string]int
+	seen           map[uint64]struct{}
+	cancel         context.CancelFunc
+	spec           projectionSpec
+	segments       []storage.Segment[*tsTable, option]
+	tagProjection  []model.TagProjection
+	cursors        []*projectionCursor
+	minTimestamp   int64
+	maxTimestamp   int64
+	maxElementSize int
+	started        bool
+	asc            bool
+}
+
+func (pr *projectionResult) Pull(ctx context.Context) *model.StreamResult {
+	if !pr.started {
+		pr.started = true
+		live := pr.cursors[:0]
+		for _, c := range pr.cursors {
+			ok, err := c.next()
+			if err != nil {
+				return &model.StreamResult{Error: err}
+			}
+			if ok {
+				live = append(live, c)
+			}
+		}
+		pr.cursors = live
+	}
+	r := &model.StreamResult{TagFamilies: make([]model.TagFamily, len(pr.tagProjection))}
+	for i, tp := range pr.tagProjection {
+		r.TagFamilies[i] = model.TagFamily{Name: tp.Family, Tags: make([]model.Tag, len(tp.Names))}
+		for j, n := range tp.Names {
+			r.TagFamilies[i].Tags[j] = model.Tag{Name: n}
+		}
+	}
+	for len(pr.cursors) > 0 && len(r.ElementIDs) < pr.maxElementSize {
+		if err := ctx.Err(); err != nil {
+			return &model.StreamResult{Error: err}
+		}
+		top := 0
+		for i := 1; i < len(pr.cursors); i++ {
+			k, topKey := pr.cursors[i].key(), pr.cursors[top].key()
+			if (pr.asc && k < topKey) || (!pr.asc && k > topKey) {
+				top = i
+			}
+		}
+		c := pr.cursors[top]
+		if err := pr.appendRow(r, c); err != nil {
+			return &model.StreamResult{Error: err}
+		}
+		ok, err := c.next()
+		if err != nil {
+			return &model.StreamResult{Error: err}
+		}
+		if !ok {
+			pr.cursors = append(pr.cursors[:top], pr.cursors[top+1:]...)
+		}
+	}
+	if len(r.ElementIDs) == 0 {
+		return nil
+	}
+	return r
+}
+
+func (pr *projectionResult) appendRow(r *model.StreamResult, c *projectionCursor) error {
+	ts, elementID, values, err := decodeProjectionRow(c.resp.Data[c.idx])
+	if err != nil {
+		return err
+	}
+	if ts < pr.minTimestamp || ts > pr.maxTimestamp {
+		return nil
+	}
+	if _, ok := pr.seen[elementID]; ok {
+		return nil
+	}
+	if pr.elementFilter != nil && !pr.elementFilter.Contains(elementID) {
+		return nil
+	}
+	if c.proj.isDeleted(elementID) {
+		return nil
+	}
+	pr.seen[elementID] = struct{}{}
+	sid := c.resp.SIDs[c.idx]
+	r.Timestamps = append(r.Timestamps, ts)
+	r.ElementIDs = append(r.ElementIDs, elementID)
+	r.SIDs = append(r.SIDs, sid)
+	for i, tp := range pr.tagProjection {
+		for j, name := range tp.Names {
+			r.TagFamilies[i].Tags[j].Values = append(r.TagFamilies[i].Tags[j].Values, pr.tagValue(name, sid, c.key(), values))
+		}
+	}
+	return nil
+}
+
+func (pr *projectionResult) tagValue(name string, sid common.SeriesID, key int64, values []*modelv1.TagValue) *modelv1.TagValue {
+	if i, ok := pr.entityIndex[name]; ok {
+		if entity := pr.seriesToEntity[sid]; i < len(entity) {
+			return entity[i]
+		}
+		return pbv1.NullTagValue
+	}
+	if name == pr.spec.sortTag {
+		return int64TagValue(key)
+	}
+	for i, t := range pr.spec.tags {
+		if t.name == name && i < len(values) {
+			return values[i]
+		}
+	}
+	return pbv1.NullTagValue
+}
+
+func (pr *projectionResult) Release() {
+	streamQueryResultTracker.Release(pr)
+	pr.cancel()
+	for _, c := range pr.cursors {
+		for range c.respCh {
+		}
+	}
+	pr.cursors = nil
+	for i := range pr.segments {
+		pr.segments[i].DecRef()
+	}
+}
@@ -28,6 +28,7 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -69,6 +70,8 @@ type Stream interface {
 	GetSchema() *databasev1.Stream
 	GetIndexRules() []*databasev1.IndexRule
 	Query(ctx context.Context, opts model.StreamQueryOptions) (model.StreamQueryResult, error)
+	Delete(tr timestamp.TimeRange, elementIDs []uint64) error
+	IndexStats(tr timestamp.TimeRange) *index.Stats
 }
 
 type indexSchema struct {
@@ -98,6 +101,7 @@ type stream struct {
 	schemaRepo  *schemaRepo
 	name        string
 	group       string
+	projections []projectionSpec
 }
 
 func (s *stream) GetSchema() *databasev1.Stream {
@@ -124,6 +128,19 @@ func (s *stream) parseSpec() {
 	var is indexSchema
 	is.parse(s.schema)
 	s.indexSchema.Store(is)
+	s.projections = parseProjectionSpecs(s.schema)
+	if s.schemaRepo != nil {
+		s.schemaRepo.projections.store(s.group, s.name, s.projections)
+	}
+}
+
+func (s *stream) projectionSpec(name string) (projectionSpec, bool) {
+	for _, spec := range s.projections {
+		if spec.name == name {
+			return spec, true
+		}
+	}
+	return projectionSpec{}, false
 }
 
 type streamSpec struct {
@@ -57,6 +57,7 @@ type liaison struct {
 	dataNodeSelector          node.Selector
 	l                         *logger.Logger
 	schemaRepo                schemaRepo
+	observers                 writeObservers
 	dataPath                  string
 	root                      string
 	option                    option
@@ -72,6 +73,10 @@ func (s *liaison) Stream(metadata *commonv1.Metadata) (Stream, error) {
 	return sm, nil
 }
 
+func (s *liaison) RegisterWriteObserver(o WriteObserver) {
+	s.observers.register(o)
+}
+
 func (s *liaison) LoadGroup(name string) (resourceSchema.Group, bool) {
 	return s.schemaRepo.LoadGroup(name)
 }
@@ -61,6 +61,8 @@ type Service interface {
 	Query
 	CollectDataInfo(context.Context, string) (*databasev1.DataInfo, error)
 	CollectLiaisonInfo(context.Context, string) (*databasev1.LiaisonInfo, error)
+	// RegisterWriteObserver adds an observer of the written elements. It must be called before the service runs.
+	RegisterWriteObserver(WriteObserver)
 }
 
 var _ Service = (*standalone)(nil)
@@ -74,8 +76,10 @@ type standalone struct {
 	metadata              metadata.Repo
 	pm                    protector.Memory
 	diskMonitor           *storage.DiskMonitor
+	backfillRunner        *storage.IndexBackfillRunner
 	l                     *logger.Logger
 	schemaRepo            schemaRepo
+	observers             writeObservers
 	root                  string
 	dataPath              string
 	snapshotDir           string
@@ -93,6 +97,10 @@ func (s *standalone) Stream(metadata *commonv1.Metadata) (Stream, error) {
 	return sm, nil
 }
 
+func (s *standalone) RegisterWriteObserver(o WriteObserver) {
+	s.observers.register(o)
+}
+
 func (s *standalone) LoadGroup(name string) (resourceSchema.Group, bool) {
 	return s.schemaRepo.LoadGroup(name)
 }
@@ -260,6 +268,7 @@ func (s *standalone) PreRun(ctx context.Context) error {
 		metaSvc.RegisterDataCollector(commonv1.Catalog_CATALOG_STREAM, &s.schemaRepo)
 		metaSvc.RegisterLiaisonCollector(commonv1.Catalog_CATALOG_STREAM, s)
 		metaSvc.RegisterGroupDropHandler(commonv1.Catalog_CATALOG_STREAM, s)
+		metaSvc.RegisterIndexBackfillHandler(commonv1.Catalog_CATALOG_STREAM, s)
 	}
 	if s.pipeline == nil {
 		return nil
@@ -272,6 +281,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	if dropGroupErr := s.pipeline.Subscribe(data.TopicStreamDropGroup, &dropGroupDataListener{s: s}); dropGroupErr != nil {
 		return fmt.Errorf("failed to subscribe to drop group topic: %w", dropGroupErr)
 	}
+	if backfillErr := s.pipeline.Subscribe(data.TopicStreamIndexBackfill, &indexBackfillListener{s: s}); backfillErr != nil {
+		return fmt.Errorf("failed to subscribe to index backfill topic: %w", backfillErr)
+	}
 
 	s.localPipeline = queue.Local()
 	if err = s.pipeline.Subscribe(data.TopicSnapshot, &snapshotListener{s: s}); err != nil {
@@ -285,6 +297,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	s.diskMonitor = storage.NewDiskMonitor(s, s.retentionConfig, s.omr)
 	s.diskMonitor.Start()
 
+	s.backfillRunner = storage.NewIndexBackfillRunner(s.l, s.lfs, s.dataPath, s.schemaRepo.runIndexBackfill)
+	s.backfillRunner.Start()
+
 	// For now, keep the original write throttling behavior based on high watermark
 	writeListener := setUpWriteCallback(s.l, &s.schemaRepo, int(s.retentionConfig.HighWatermark))
 	err = s.pipeline.Subscribe(data.TopicStreamWrite, writeListener)
@@ -325,6 +340,9 @@ func (s *standalone) GracefulStop() {
 	if s.diskMonitor != nil {
 		s.diskMonitor.Stop()
 	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Stop()
+	}
 
 	s.schemaRepo.Close()
 	if s.localPipeline != nil {
@@ -424,3 +442,20 @@ func (l *dropGroupDataListener) Rev(ctx context.Context, message bus.Message) bu
 	}
 	return bus.NewMessage(message.ID(), req)
 }
+
+type indexBackfillListener struct {
+	*bus.UnImplementedHealthyListener
+	s *standalone
+}
+
+func (l *indexBackfillListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	req, ok := message.Data().(*databasev1.IndexRuleBindingRegistryServiceBackfillRequest)
+	if !ok {
+		return bus.NewMessage(message.ID(), common.NewError("invalid data type for index backfill request"))
+	}
+	n, err := l.s.BackfillIndex(ctx, req)
+	if err != nil {
+		return bus.NewMessage(message.ID(), common.NewError("failed to schedule index backfill: %v", err))
+	}
+	return bus.NewMessage(message.ID(), &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{ShardCount: n})
+}
@@ -0,0 +1,283 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const tombstoneFilename = "tombstone.json"
+
+// tombstone is the set of elements deleted from a part but not yet removed from its files.
+// It's never modified after creation. Deleting more elements replaces the part's tombstone.
+type tombstone struct {
+	elementIDs map[uint64]struct{}
+}
+
+func (t *tombstone) contains(elementID uint64) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.elementIDs[elementID]
+	return ok
+}
+
+func (t *tombstone) union(elementIDs map[uint64]struct{}) *tombstone {
+	if t == nil {
+		return &tombstone{elementIDs: elementIDs}
+	}
+	merged := make(map[uint64]struct{}, len(t.elementIDs)+len(elementIDs))
+	for id := range t.elementIDs {
+		merged[id] = struct{}{}
+	}
+	for id := range elementIDs {
+		merged[id] = struct{}{}
+	}
+	return &tombstone{elementIDs: merged}
+}
+
+// removeFrom drops the deleted elements from the block and reports whether any was dropped.
+func (t *tombstone) removeFrom(b *block) bool {
+	if t == nil {
+		return false
+	}
+	var kept []int
+	for i, id := range b.elementIDs {
+		if !t.contains(id) {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == len(b.elementIDs) {
+		return false
+	}
+	for i, k := range kept {
+		b.timestamps[i] = b.timestamps[k]
+		b.elementIDs[i] = b.elementIDs[k]
+	}
+	b.timestamps = b.timestamps[:len(kept)]
+	b.elementIDs = b.elementIDs[:len(kept)]
+	for i := range b.tagFamilies {
+		for j := range b.tagFamilies[i].tags {
+			values := b.tagFamilies[i].tags[j].values
+			if len(values) == 0 {
+				continue
+			}
+			for n, k := range kept {
+				values[n] = values[k]
+			}
+			b.tagFamilies[i].tags[j].values = values[:len(kept)]
+		}
+	}
+	return true
+}
+
+// deleteElements hides the elements from the parts overlapping the time range.
+// The elements are removed from disk when these parts are merged.
+func (tst *tsTable) deleteElements(minTimestamp, maxTimestamp int64, elementIDs map[uint64]struct{}) error {
+	if len(elementIDs) == 0 {
+		return nil
+	}
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	tst.deleteProjectedElements(elementIDs)
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return nil
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		pm := pw.p.partMetadata
+		if maxTimestamp < pm.MinTimestamp || minTimestamp > pm.MaxTimestamp {
+			continue
+		}
+		pw.p.tombstone.Store(pw.p.tombstone.Load().union(elementIDs))
+	}
+	tst.mustPersistTombstones(snp)
+	if tst.index == nil {
+		return nil
+	}
+	return tst.index.Delete(elementIDs)
+}
+
+// carryTombstones copies the tombstones of the replaced parts to the new ones.
+// The merger captured the tombstones of its source parts in applied before reading them.
+// Elements deleted after that are still in the merged part, so they have to be hidden there.
+func carryTombstones(cur *snapshot, newPart *partWrapper, merged map[uint64]struct{}, applied map[uint64]*tombstone) {
+	for _, pw := range cur.parts {
+		if _, ok := merged[pw.ID()]; !ok {
+			continue
+		}
+		t := pw.p.tombstone.Load()
+		if t == nil || t == applied[pw.ID()] {
+			continue
+		}
+		newPart.p.tombstone.Store(newPart.p.tombstone.Load().union(t.elementIDs))
+	}
+}
+
+func captureTombstones(parts []*partWrapper) map[uint64]*tombstone {
+	var applied map[uint64]*tombstone
+	for _, pw := range parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		if applied == nil {
+			applied = make(map[uint64]*tombstone)
+		}
+		applied[pw.ID()] = t
+	}
+	return applied
+}
+
+// mustPersistTombstones writes the tombstones of the snapshot's parts to the table's root.
+// The file is removed once merges have dropped all the deleted elements.
+func (tst *tsTable) mustPersistTombstones(snp *snapshot) {
+	entries := make(map[string][]uint64)
+	for _, pw := range snp.parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		ids := make([]uint64, 0, len(t.elementIDs))
+		for id := range t.elementIDs {
+			ids = append(ids, id)
+		}
+		entries[partName(pw.ID())] = ids
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if len(entries) == 0 {
+		if tst.hasTombstones {
+			if err := tst.fileSystem.DeleteFile(tombstonePath); err != nil {
+				logger.Panicf("cannot delete tombstone file %s: %s", tombstonePath, err)
+			}
+			tst.fileSystem.SyncPath(tst.root)
+			tst.hasTombstones = false
+		}
+		return
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		logger.Panicf("cannot marshal tombstones to JSON: %s", err)
+	}
+	tombstoneTempPath := tombstonePath + ".tmp"
+	lf, err := tst.fileSystem.CreateLockFile(tombstoneTempPath, storage.FilePerm)
+	if err != nil {
+		logger.Panicf("cannot create lock file %s: %s", tombstoneTempPath, err)
+	}
+	n, err := lf.Write(data)
+	if err != nil {
+		_ = lf.Close()
+		logger.Panicf("cannot write tombstones %s: %s", tombstoneTempPath, err)
+	}
+	if n != len(data) {
+		_ = lf.Close()
+		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", tombstoneTempPath, n, len(data))
+	}
+	if closeErr := lf.Close(); closeErr != nil {
+		logger.Panicf("cannot close tombstone temp file %s: %s", tombstoneTempPath, closeErr)
+	}
+	if renameErr := tst.fileSystem.Rename(tombstoneTempPath, tombstonePath); renameErr != nil {
+		logger.Panicf("cannot rename tombstones %s to %s: %s", tombstoneTempPath, tombstonePath, renameErr)
+	}
+	tst.fileSystem.SyncPath(tst.root)
+	tst.hasTombstones = true
+}
+
+// openTombstones loads the tombstones of a table opened from disk.
+// The table is closed if they can't be loaded, since serving its parts without them brings the deleted data back.
+func (tst *tsTable) openTombstones() error {
+	if err := tst.loadTombstones(tst.snapshot); err != nil {
+		_ = tst.Close()
+		return fmt.Errorf("cannot open the table %s: %w", tst.root, err)
+	}
+	return nil
+}
+
+// loadTombstones attaches the persisted tombstones to the loaded parts.
+// Tombstones of parts that no longer exist are dropped.
+func (tst *tsTable) loadTombstones(snp *snapshot) error {
+	if snp == nil {
+		snp = &snapshot{}
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if !tst.fileSystem.IsExist(tombstonePath) {
+		return nil
+	}
+	tst.hasTombstones = true
+	data, err := tst.fileSystem.Read(tombstonePath)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", tombstonePath, err)
+	}
+	var entries map[string][]uint64
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", tombstonePath, err)
+	}
+	for _, pw := range snp.parts {
+		ids, ok := entries[partName(pw.ID())]
+		if !ok {
+			continue
+		}
+		elementIDs := make(map[uint64]struct{}, len(ids))
+		for _, id := range ids {
+			elementIDs[id] = struct{}{}
+		}
+		pw.p.tombstone.Store(&tombstone{elementIDs: elementIDs})
+	}
+	return nil
+}
+
+// Delete hides the elements from queries. They are removed from disk by the next merge.
+func (s *stream) Delete(tr timestamp.TimeRange, elementIDs []uint64) error {
+	if len(elementIDs) == 0 {
+		return nil
+	}
+	tsdb, err := s.getTSDB()
+	if err != nil {
+		return err
+	}
+	segments, err := tsdb.SelectSegments(tr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	ids := make(map[uint64]struct{}, len(elementIDs))
+	for _, id := range elementIDs {
+		ids[id] = struct{}{}
+	}
+	for _, segment := range segments {
+		// the caches returned with the tables hold the block metadata of the parts, which the tombstones leave intact
+		tables, _ := segment.Tables()
+		for _, t := range tables {
+			if err = t.deleteElements(tr.Start.UnixNano(), tr.End.UnixNano(), ids); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -53,8 +53,10 @@ type tsTable struct {
 	pm               protector.Memory
 	metrics          *metrics
 	index            *elementIndex
+	indexStats       atomic.Pointer[index.Stats]
 	snapshot         *snapshot
 	loopCloser       *run.Closer
+	projections      map[string]*projection
 	getNodes         func() []string
 	l                *logger.Logger
 	introductions    chan *introduction
@@ -65,8 +67,10 @@ type tsTable struct {
 	option           option
 	curPartID        uint64
 	pendingDataCount atomic.Int64
+	tombstoneMu      sync.Mutex
 	sync.RWMutex
-	shardID common.ShardID
+	shardID       common.ShardID
+	hasTombstones bool
 }
 
 func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) error {
@@ -236,6 +240,7 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 			return nil, 0, err
 		}
 		tst.index = index
+		tst.loadIndexStats()
 	}
 	tst.gc.init(&tst)
 	ee := fileSystem.ReadDir(rootPath)
@@ -256,6 +261,9 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 			if ee[i].Name() == storage.FailedPartsDirName {
 				continue
 			}
+			if ee[i].Name() == projectionDirName {
+				continue
+			}
 			p, err := parseEpoch(ee[i].Name())
 			if err != nil {
 				l.Info().Err(err).Msg("cannot parse part file name. skip and delete it")
@@ -294,6 +302,7 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 			l.Info().Str("path", partPath(rootPath, id)).Msg("delete orphaned part without snapshot")
 			fileSystem.MustRMAll(partPath(rootPath, id))
 		}
+		tst.dropProjections()
 		return &tst, uint64(time.Now().UnixNano()), nil
 	}
 	sort.Slice(loadedSnapshots, func(i, j int) bool {
@@ -311,12 +320,14 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 			tst.l.Info().Str("path", filepath.Join(rootPath, snapshotName(id))).Msg("delete unreadable snapshot file")
 			fileSystem.MustRMAll(filepath.Join(rootPath, snapshotName(id)))
 		}
+		tst.loadProjections()
 		return &tst, epoch, nil
 	}
 	for _, id := range loadedParts {
 		l.Info().Str("path", partPath(rootPath, id)).Msg("delete orphaned part after all snapshots failed to load")
 		fileSystem.MustRMAll(partPath(rootPath, id))
 	}
+	tst.dropProjections()
 	return &tst, uint64(time.Now().UnixNano()), nil
 }
 
@@ -327,6 +338,9 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	if err != nil {
 		return nil, err
 	}
+	if err = t.openTombstones(); err != nil {
+		return nil, err
+	}
 	t.startLoop(epoch)
 	return t, nil
 }
@@ -346,6 +360,7 @@ func (tst *tsTable) Close() error {
 	tst.Lock()
 	defer tst.Unlock()
 	tst.deleteMetrics()
+	tst.closeProjections()
 	if tst.snapshot == nil {
 		if tst.index != nil {
 			return tst.index.Close()
@@ -403,7 +418,7 @@ func (tst *tsTable) mustAddMemPart(mp *memPart) {
 }
 
 func (tst *tsTable) mustAddElements(es *elements) {
-	tst.mustAddElementsWithSegmentID(es, 0, nil)
+	tst.mustAddElementsWithProjections(es, nil)
 }
 
 func (tst *tsTable) mustAddElementsWithSegmentID(es *elements, segmentID int64, seriesMetadata []byte) {
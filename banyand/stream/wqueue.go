@@ -39,6 +39,9 @@ func newWriteQueue(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	if err != nil {
 		return nil, err
 	}
+	if err = t.openTombstones(); err != nil {
+		return nil, err
+	}
 	t.getNodes = getNodes
 	t.group = group
 	t.shardID = shardID
@@ -42,8 +42,10 @@ type syncPartContext struct {
 	fileSystem fs.FileSystem
 	writers    *writers
 	partPath   string
-	partMeta   partMetadata
-	partID     uint64
+	// projections are the stream projections of the group which the part is added to.
+	projections []projectionSpec
+	partMeta    partMetadata
+	partID      uint64
 }
 
 func (s *syncPartContext) NewPartType(_ *queue.ChunkedSyncPartContext) error {
@@ -58,7 +60,7 @@ func (s *syncPartContext) FinishSync() error {
 	s.partMeta.mustWriteMetadata(s.fileSystem, s.partPath)
 	s.fileSystem.SyncPath(s.partPath)
 
-	s.tsTable.mustAddFilePart(s.partID)
+	s.tsTable.mustAddFilePartWithProjections(s.partID, s.projections)
 	s.partPath = ""
 	return s.Close()
 }
@@ -138,12 +140,13 @@ func (s *syncCallback) CreatePartHandler(ctx *queue.ChunkedSyncPartContext) (que
 	w.mustInitForFilePart(fileSystem, pp, tsTable.pm.ShouldCache(int64(ctx.CompressedSizeBytes)))
 
 	partCtx := &syncPartContext{
-		tsTable:    tsTable,
-		segment:    segment,
-		fileSystem: fileSystem,
-		writers:    w,
-		partPath:   pp,
-		partID:     partID,
+		tsTable:     tsTable,
+		segment:     segment,
+		fileSystem:  fileSystem,
+		writers:     w,
+		partPath:    pp,
+		partID:      partID,
+		projections: s.schemaRepo.projections.load(ctx.Group),
 	}
 	partCtx.partMeta.fillFromSyncContext(ctx)
 	return partCtx, nil
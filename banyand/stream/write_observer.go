@@ -0,0 +1,53 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"sync"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+// WriteObserver is notified of the elements written into streams.
+// It must not block, since it runs in the write path.
+type WriteObserver interface {
+	OnStreamWrite(stm *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec)
+}
+
+type writeObservers struct {
+	list []WriteObserver
+	mu   sync.RWMutex
+}
+
+func (wo *writeObservers) register(o WriteObserver) {
+	wo.mu.Lock()
+	defer wo.mu.Unlock()
+	wo.list = append(wo.list, o)
+}
+
+func (wo *writeObservers) notify(stm *databasev1.Stream, element *streamv1.ElementValue, spec []*streamv1.TagFamilySpec) {
+	if wo == nil {
+		return
+	}
+	wo.mu.RLock()
+	defer wo.mu.RUnlock()
+	for _, o := range wo.list {
+		o.OnStreamWrite(stm, element, spec)
+	}
+}
@@ -89,6 +89,9 @@ func (w *writeCallback) handle(dst map[string]*elementsInGroup, writeEvent *stre
 	if err != nil {
 		return nil, err
 	}
+	if stm, ok := w.schemaRepo.loadStream(metadata); ok {
+		et.addProjections(stm.projections)
+	}
 	return dst, nil
 }
 
@@ -167,21 +170,29 @@ func processElements(schemaRepo *schemaRepo, elements *elements, writeEvent *str
 	ts int64, tableDocs *index.Documents, seriesDocs *seriesDoc, metadata *commonv1.Metadata, spec []*streamv1.TagFamilySpec,
 ) error {
 	req := writeEvent.Request
-
-	elements.timestamps = append(elements.timestamps, ts)
 	var eID uint64
 	if req.Element.GetElementId() != "" {
 		eID = convert.HashStr(metadata.Group + "|" + metadata.Name + "|" + req.Element.GetElementId())
 	} else {
 		eID = schemaRepo.idGen.NextID()
 	}
-	elements.elementIDs = append(elements.elementIDs, eID)
 
 	stm, ok := schemaRepo.loadStream(metadata)
 	if !ok {
 		return fmt.Errorf("cannot find stream definition: %s", metadata)
 	}
+	if err := appendElement(stm, elements, writeEvent, eID, ts, tableDocs, seriesDocs, metadata, spec); err != nil {
+		return err
+	}
+	schemaRepo.observers.notify(stm.schema, req.Element, spec)
+	return nil
+}
 
+// appendElement appends an element of the ID to the elements, and its index documents to the docs.
+func appendElement(stm *stream, elements *elements, writeEvent *streamv1.InternalWriteRequest, eID uint64,
+	ts int64, tableDocs *index.Documents, seriesDocs *seriesDoc, metadata *commonv1.Metadata, spec []*streamv1.TagFamilySpec,
+) error {
+	req := writeEvent.Request
 	fLen := len(req.Element.GetTagFamilies())
 	if fLen < 1 {
 		return fmt.Errorf("%s has no tag family", req)
@@ -197,17 +208,18 @@ func processElements(schemaRepo *schemaRepo, elements *elements, writeEvent *str
 	if err := series.Marshal(); err != nil {
 		return fmt.Errorf("cannot marshal series: %w", err)
 	}
-	elements.seriesIDs = append(elements.seriesIDs, series.ID)
 
 	is := stm.indexSchema.Load().(indexSchema)
-	tagFamilies := make([]tagValues, 0, len(stm.schema.TagFamilies))
-	var fields []index.Field
-
 	if len(is.indexRuleLocators.TagFamilyTRule) != len(stm.GetSchema().GetTagFamilies()) {
 		return fmt.Errorf("metadata crashed, tag family rule length %d, tag family length %d",
 			len(is.indexRuleLocators.TagFamilyTRule), len(stm.GetSchema().GetTagFamilies()))
 	}
+	elements.timestamps = append(elements.timestamps, ts)
+	elements.elementIDs = append(elements.elementIDs, eID)
+	elements.seriesIDs = append(elements.seriesIDs, series.ID)
 
+	tagFamilies := make([]tagValues, 0, len(stm.schema.TagFamilies))
+	var fields []index.Field
 	specFamilyMap, specTagMaps := buildSpecMaps(spec)
 
 	for i := range stm.GetSchema().GetTagFamilies() {
@@ -279,7 +291,6 @@ func processElements(schemaRepo *schemaRepo, elements *elements, writeEvent *str
 		})
 		seriesDocs.docIDsAdded[docID] = struct{}{}
 	}
-
 	return nil
 }
 
@@ -329,7 +340,7 @@ func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Me
 		g := groups[groupName]
 		for j := range g.tables {
 			es := g.tables[j]
-			es.tsTable.mustAddElements(es.elements)
+			es.tsTable.mustAddElementsWithProjections(es.elements, es.projections)
 			releaseElements(es.elements)
 			if len(es.docs) > 0 {
 				index := es.tsTable.Index()
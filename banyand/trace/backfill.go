@@ -0,0 +1,414 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// BackfillIndex schedules the index backfill job of a binding in all shards of the group.
+func (s *standalone) BackfillIndex(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	n, err := s.schemaRepo.scheduleIndexBackfill(ctx, req)
+	if err != nil {
+		return n, err
+	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Trigger()
+	}
+	return n, nil
+}
+
+func (sr *schemaRepo) scheduleIndexBackfill(ctx context.Context, req *databasev1.IndexRuleBindingRegistryServiceBackfillRequest) (int64, error) {
+	binding, err := sr.metadata.IndexRuleBindingRegistry().GetIndexRuleBinding(ctx, req.GetMetadata())
+	if err != nil {
+		return 0, err
+	}
+	if binding.GetSubject().GetCatalog() != commonv1.Catalog_CATALOG_TRACE {
+		return 0, fmt.Errorf("index rule binding %s is not bound to a trace", req.GetMetadata().GetName())
+	}
+	tsdb, err := sr.loadTSDB(req.GetMetadata().GetGroup())
+	if err != nil {
+		return 0, err
+	}
+	n, err := storage.ScheduleIndexBackfill(tsdb, binding, req.GetRateLimit(), backfillShardRoot)
+	// Hold the merges of the parts awaiting the backfill as soon as the tasks are persisted.
+	segments, selectErr := tsdb.SelectSegments(timestamp.TimeRange{
+		Start: time.Unix(0, 0),
+		End:   time.Unix(0, timestamp.MaxNanoTime),
+	})
+	if selectErr != nil {
+		return n, selectErr
+	}
+	for _, s := range segments {
+		tables, _ := s.Tables()
+		for _, tst := range tables {
+			tst.loadIndexBackfill()
+		}
+		s.DecRef()
+	}
+	return n, err
+}
+
+func (sr *schemaRepo) runIndexBackfill(ctx context.Context, group string) {
+	tsdb, err := sr.loadTSDB(group)
+	if err != nil {
+		// The group isn't loaded yet, retry later.
+		return
+	}
+	storage.RunIndexBackfill(ctx, sr.l, tsdb, backfillShardRoot,
+		func(ctx context.Context, segment storage.Segment[*tsTable, option], tst *tsTable, task *storage.IndexBackfillTask) error {
+			return sr.backfillTable(ctx, group, segment, tst, task)
+		})
+}
+
+func backfillShardRoot(tst *tsTable) (fs.FileSystem, string) {
+	return tst.fileSystem, tst.root
+}
+
+// loadIndexBackfill caches the unfinished backfill tasks of the table to hold the merges of the parts awaiting them.
+func (tst *tsTable) loadIndexBackfill() {
+	progress, err := storage.LoadIndexBackfillProgress(tst.fileSystem, tst.root)
+	if err != nil {
+		tst.l.Warn().Err(err).Msg("cannot load the index backfill progress")
+		return
+	}
+	tst.backfillMu.Lock()
+	tst.backfillTasks = progress.Pending()
+	tst.backfillMu.Unlock()
+}
+
+// awaitingIndexBackfill reports whether the part lacks the sidx parts of an unfinished backfill task.
+// Merging such a part with an indexed one would hide its missing entries from the backfill.
+func (tst *tsTable) awaitingIndexBackfill(partID uint64) bool {
+	tst.backfillMu.RLock()
+	defer tst.backfillMu.RUnlock()
+	for _, task := range tst.backfillTasks {
+		if task.IsProcessed(partID) {
+			continue
+		}
+		if len(tst.sidxWithoutPart(task.Rules, partID)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (tst *tsTable) sidxWithoutPart(names []string, partID uint64) []string {
+	var missing []string
+	for _, name := range names {
+		sidxInstance, ok := tst.getSidx(name)
+		if !ok || len(sidxInstance.PartPaths(map[uint64]struct{}{partID: {}})) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// backfillTable builds the missing sidx parts of the bound index rules for the file parts of the table.
+// The memory parts lacking the sidx parts are held from merging, and the task waits for them to be flushed.
+func (sr *schemaRepo) backfillTable(ctx context.Context, group string, segment storage.Segment[*tsTable, option], tst *tsTable,
+	task *storage.IndexBackfillTask,
+) error {
+	t, ok := sr.loadTrace(&commonv1.Metadata{Group: group, Name: task.Subject})
+	if !ok {
+		return fmt.Errorf("trace %s: %w", task.Subject, storage.ErrIndexBackfillNotReady)
+	}
+	rules := make([]*databasev1.IndexRule, 0, len(task.Rules))
+	for _, name := range task.Rules {
+		for _, r := range t.GetIndexRules() {
+			if r.GetMetadata().GetName() == name {
+				rules = append(rules, r)
+				break
+			}
+		}
+	}
+	if len(rules) < len(task.Rules) {
+		return storage.ErrIndexBackfillNotReady
+	}
+	save := func() error {
+		defer tst.loadIndexBackfill()
+		return storage.SaveIndexBackfillTask(tst.fileSystem, tst.root, task)
+	}
+	flushInterval := tst.option.flushTimeout
+	if flushInterval < time.Second {
+		flushInterval = time.Second
+	}
+	// The parts introduced later are written after the rules had been applied.
+	lastPartID := atomic.LoadUint64(&tst.curPartID)
+	limiter := task.Limiter()
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_IN_PROGRESS
+	task.Message = ""
+	for {
+		pending, memParts, snp := collectBackfillParts(tst, task, lastPartID)
+		task.TotalParts = int64(len(task.ProcessedParts) + len(pending) + memParts)
+		err := save()
+		for i := 0; err == nil && i < len(pending); i++ {
+			var n int
+			if n, err = sr.backfillPart(ctx, segment, tst, t, rules, pending[i], limiter); err == nil {
+				task.MarkProcessed(pending[i].ID(), n)
+				err = save()
+			}
+		}
+		if snp != nil {
+			snp.decRef()
+		}
+		if err != nil {
+			return err
+		}
+		if memParts == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(flushInterval):
+		}
+	}
+	task.Phase = databasev1.IndexBackfillInfo_PHASE_COMPLETED
+	return save()
+}
+
+// collectBackfillParts returns the file parts to backfill and the number of memory parts awaiting the flush.
+// The parts having all sidx parts are marked as processed.
+func collectBackfillParts(tst *tsTable, task *storage.IndexBackfillTask, lastPartID uint64) ([]*partWrapper, int, *snapshot) {
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return nil, 0, nil
+	}
+	var pending []*partWrapper
+	var memParts int
+	for _, pw := range snp.parts {
+		if pw.ID() > lastPartID || task.IsProcessed(pw.ID()) {
+			continue
+		}
+		if len(tst.sidxWithoutPart(task.Rules, pw.ID())) == 0 {
+			task.MarkProcessed(pw.ID(), 0)
+			continue
+		}
+		if pw.mp != nil {
+			memParts++
+			continue
+		}
+		pending = append(pending, pw)
+	}
+	return pending, memParts, snp
+}
+
+func (sr *schemaRepo) backfillPart(ctx context.Context, segment storage.Segment[*tsTable, option], tst *tsTable, t *trace,
+	rules []*databasev1.IndexRule, pw *partWrapper, limiter *storage.IndexBackfillLimiter,
+) (int, error) {
+	missing := make(map[string]struct{})
+	for _, name := range tst.sidxWithoutPart(ruleNames(rules), pw.ID()) {
+		missing[name] = struct{}{}
+	}
+	tagSpecs := make([]*databasev1.TraceTagSpec, 0, len(t.schema.GetTags()))
+	for _, tagSpec := range t.schema.GetTags() {
+		if tagSpec.GetName() == t.schema.GetTraceIdTagName() || tagSpec.GetName() == t.schema.GetSpanIdTagName() {
+			continue
+		}
+		tagSpecs = append(tagSpecs, tagSpec)
+	}
+
+	pmi := generatePartMergeIter()
+	defer releasePartMergeIter(pmi)
+	pmi.mustInitFromPart(pw.p)
+	pmi.tombstone = pw.p.tombstone.Load()
+	br := generateBlockReader()
+	defer releaseBlockReader(br)
+	br.init([]*partMergeIter{pmi})
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+
+	sidxReqsMap := make(map[string][]sidx.WriteRequest)
+	seriesDocs := make(map[uint64]index.Document)
+	for br.nextBlockMetadata() {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		br.loadBlockData(decoder)
+		b := br.block
+		if len(b.spans) == 0 {
+			continue
+		}
+		if err := limiter.Wait(ctx, len(b.spans)); err != nil {
+			return 0, err
+		}
+		blockTags := make(map[string]*tag, len(b.tags))
+		for i := range b.tags {
+			blockTags[b.tags[i].name] = &b.tags[i]
+		}
+		data := make([]byte, len(b.bm.traceID)+1)
+		data[0] = byte(idFormatV1)
+		copy(data[1:], b.bm.traceID)
+		for i := range b.spans {
+			values := make(map[string]*modelv1.TagValue, len(tagSpecs))
+			tags := make([]*tagValue, 0, len(tagSpecs))
+			tagMap := make(map[string]*tagValue, len(tagSpecs))
+			for _, tagSpec := range tagSpecs {
+				value := pbv1.NullTagValue
+				if bt, found := blockTags[tagSpec.GetName()]; found && i < len(bt.values) {
+					value = mustDecodeTagValue(bt.valueType, bt.values[i])
+				}
+				values[tagSpec.GetName()] = value
+				tv := encodeTagValue(tagSpec.GetName(), tagSpec.GetType(), value)
+				tags = append(tags, tv)
+				tagMap[tagSpec.GetName()] = tv
+			}
+			sidxTags := buildSidxTags(tags)
+			var buildErr error
+			for _, r := range rules {
+				if _, ok := missing[r.GetMetadata().GetName()]; !ok {
+					continue
+				}
+				req, ok, err := buildBackfillSidxRequest(t.name, r, data, values, tagMap, sidxTags)
+				if err != nil {
+					buildErr = err
+					break
+				}
+				if !ok {
+					continue
+				}
+				sidxReqsMap[r.GetMetadata().GetName()] = append(sidxReqsMap[r.GetMetadata().GetName()], req.WriteRequest)
+				seriesDocs[uint64(req.WriteRequest.SeriesID)] = req.seriesDoc
+			}
+			for _, tv := range tags {
+				releaseTagValue(tv)
+			}
+			if buildErr != nil {
+				return 0, buildErr
+			}
+		}
+	}
+	if err := br.error(); err != nil {
+		return 0, err
+	}
+	if len(sidxReqsMap) == 0 {
+		return 0, nil
+	}
+	if len(seriesDocs) > 0 {
+		docs := make(index.Documents, 0, len(seriesDocs))
+		for _, d := range seriesDocs {
+			docs = append(docs, d)
+		}
+		if err := segment.IndexDB().Update(docs); err != nil {
+			return 0, err
+		}
+	}
+	timeRange := segment.GetTimeRange()
+	sidxFilePartsMap := make(map[string]string, len(sidxReqsMap))
+	var indexed int
+	for name, reqs := range sidxReqsMap {
+		// Create the sidx before writing its part, so that loading it doesn't remove the unknown part.
+		sidxInstance, err := tst.getOrCreateSidx(name)
+		if err != nil {
+			return 0, err
+		}
+		minTS := timeRange.Start.UnixNano()
+		maxTS := timeRange.End.UnixNano()
+		mp, err := sidxInstance.ConvertToMemPart(reqs, timeRange.Start.UnixNano(), &minTS, &maxTS)
+		if err != nil {
+			return 0, err
+		}
+		partPath := sidxPartPath(tst.root, name, pw.ID())
+		mp.MustFlush(tst.fileSystem, partPath)
+		sidx.ReleaseMemPart(mp)
+		sidxFilePartsMap[name] = partPath
+		indexed += len(reqs)
+	}
+	tst.mustAddSidxFileParts(pw.ID(), sidxFilePartsMap)
+	return indexed, nil
+}
+
+type backfillSidxRequest struct {
+	sidx.WriteRequest
+	seriesDoc index.Document
+}
+
+// buildBackfillSidxRequest mirrors processIndexRules for a span read from a part.
+func buildBackfillSidxRequest(subject string, r *databasev1.IndexRule, data []byte, values map[string]*modelv1.TagValue,
+	tagMap map[string]*tagValue, sidxTags []sidx.Tag,
+) (backfillSidxRequest, bool, error) {
+	keyTag := r.Tags[len(r.Tags)-1]
+	tv := tagMap[keyTag]
+	if tv == nil || tv.value == nil {
+		return backfillSidxRequest{}, false, nil
+	}
+	if tv.valueType != pbv1.ValueTypeInt64 && tv.valueType != pbv1.ValueTypeTimestamp {
+		return backfillSidxRequest{}, false, fmt.Errorf("unsupported tag value type: %s", tv.tag)
+	}
+	entityValues := make([]*modelv1.TagValue, 0, len(r.Tags)-1)
+	for _, name := range r.Tags[:len(r.Tags)-1] {
+		if v, ok := values[name]; ok {
+			entityValues = append(entityValues, v)
+		}
+	}
+	series := &pbv1.Series{
+		Subject:      subject,
+		EntityValues: entityValues,
+	}
+	if err := series.Marshal(); err != nil {
+		return backfillSidxRequest{}, false, fmt.Errorf("cannot marshal series: %w", err)
+	}
+	filteredSidxTags := make([]sidx.Tag, 0, len(sidxTags))
+	for _, sidxTag := range sidxTags {
+		inRule := false
+		for _, ruleTagName := range r.Tags {
+			if sidxTag.Name == ruleTagName {
+				inRule = true
+				break
+			}
+		}
+		if !inRule {
+			filteredSidxTags = append(filteredSidxTags, sidxTag)
+		}
+	}
+	return backfillSidxRequest{
+		WriteRequest: sidx.WriteRequest{
+			Data:     data,
+			Tags:     filteredSidxTags,
+			SeriesID: series.ID,
+			Key:      convert.BytesToInt64(tv.value),
+		},
+		seriesDoc: index.Document{
+			DocID:        uint64(series.ID),
+			EntityValues: series.Buffer,
+		},
+	}, true, nil
+}
+
+func ruleNames(rules []*databasev1.IndexRule) []string {
+	names := make([]string, 0, len(rules))
+	for _, r := range rules {
+		names = append(names, r.GetMetadata().GetName())
+	}
+	return names
+}
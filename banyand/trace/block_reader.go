@@ -258,6 +258,11 @@ func (br *blockReader) peek() *blockPointer {
 	return nil
 }
 
+// deleted reports whether the trace of the current block is deleted from its part.
+func (br *blockReader) deleted() bool {
+	return br.pih[0].tombstone.contains(br.block.bm.traceID)
+}
+
 func (br *blockReader) mustReadRaw(r *rawBlock, bm *blockMetadata) {
 	// Delegate to the current partMergeIter to read raw block
 	br.pih[0].mustReadRaw(r, bm)
@@ -0,0 +1,255 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const bulkLoadBatchSize = 100_000
+
+// BulkLoader writes the spans of a trace into the data directory of a stopped data node.
+// The spans are encoded into parts, the secondary indexes and the series index in the same way as the write path,
+// and the parts are flushed to the disk directly instead of staying in memory.
+type BulkLoader struct {
+	tsdb           storage.TSDB[*tsTable, option]
+	trace          *trace
+	l              *logger.Logger
+	tg             *tracesInGroup
+	traceIDIndex   int
+	timestampIndex int
+	pending        int
+	shardNum       uint32
+}
+
+// NewBulkLoader opens the storage of the group under the data directory root for loading the spans of the trace.
+func NewBulkLoader(root string, group *commonv1.Group, schema *databasev1.Trace, indexRules []*databasev1.IndexRule) (*BulkLoader, error) {
+	if group.GetResourceOpts() == nil {
+		return nil, fmt.Errorf("no resource opts in group %s", group.GetMetadata().GetName())
+	}
+	if schema.GetMetadata().GetGroup() != group.GetMetadata().GetName() {
+		return nil, fmt.Errorf("trace %s doesn't belong to group %s", schema.GetMetadata().GetName(), group.GetMetadata().GetName())
+	}
+	l := logger.GetLogger("trace-bulk-load")
+	pm := protector.Nop{}
+	s := &supplier{
+		path: root,
+		// The zero flush timeout persists the batches of the series index before it's closed.
+		option: option{
+			protector:          pm,
+			mergePolicy:        newDefaultMergePolicy(),
+			seriesCacheMaxSize: run.Bytes(32 << 20),
+		},
+		omr: observability.NewBypassRegistry(),
+		pm:  pm,
+		l:   l,
+	}
+	t := openTrace(schema, l, pm, nil)
+	t.OnIndexUpdate(indexRules)
+	traceIDIndex, err := getTagIndex(t, schema.GetTraceIdTagName(), nil)
+	if err != nil {
+		return nil, err
+	}
+	timestampIndex, err := getTagIndex(t, schema.GetTimestampTagName(), nil)
+	if err != nil {
+		return nil, err
+	}
+	db, err := s.OpenDB(group)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the storage of group %s: %w", group.GetMetadata().GetName(), err)
+	}
+	return &BulkLoader{
+		tsdb:           db.(storage.TSDB[*tsTable, option]),
+		trace:          t,
+		l:              l,
+		traceIDIndex:   traceIDIndex,
+		timestampIndex: timestampIndex,
+		shardNum:       group.GetResourceOpts().GetShardNum(),
+	}, nil
+}
+
+// Write writes a span, whose tags are in the order of the trace schema.
+// The span lands in the shard of its trace ID, the same as the liaison routes it.
+func (bl *BulkLoader) Write(tags []*modelv1.TagValue, span []byte) error {
+	if bl.traceIDIndex >= len(tags) || bl.timestampIndex >= len(tags) {
+		return fmt.Errorf("%s needs %d tags at least", bl.trace.schema.GetMetadata(), max(bl.traceIDIndex, bl.timestampIndex)+1)
+	}
+	traceID := tags[bl.traceIDIndex].GetStr().GetValue()
+	if traceID == "" {
+		return fmt.Errorf("the trace ID tag %s is empty", bl.trace.schema.GetTraceIdTagName())
+	}
+	if tags[bl.timestampIndex].GetTimestamp() == nil {
+		return fmt.Errorf("the timestamp tag %s is empty", bl.trace.schema.GetTimestampTagName())
+	}
+	t := tags[bl.timestampIndex].GetTimestamp().AsTime().Local()
+	if err := timestamp.Check(t); err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	shardID := common.ShardID(convert.Hash([]byte(traceID)) % uint64(bl.shardNum))
+	tt, err := bl.table(t, shardID)
+	if err != nil {
+		return err
+	}
+	ts := t.UnixNano()
+	if bl.tg.latestTS < ts {
+		bl.tg.latestTS = ts
+	}
+	metadata := bl.trace.schema.GetMetadata()
+	req := &tracev1.WriteRequest{Metadata: metadata, Tags: tags, Span: span}
+	if err = appendTrace(bl.trace, tt, req, metadata, nil); err != nil {
+		return err
+	}
+	bl.pending++
+	if bl.pending >= bulkLoadBatchSize {
+		return bl.Flush()
+	}
+	return nil
+}
+
+func (bl *BulkLoader) table(t time.Time, shardID common.ShardID) (*tracesInTable, error) {
+	if bl.tg == nil {
+		bl.tg = &tracesInGroup{tsdb: bl.tsdb}
+	}
+	ts := t.UnixNano()
+	for _, tt := range bl.tg.tables {
+		if tt.timeRange.Contains(ts) && tt.shardID == shardID {
+			return tt, nil
+		}
+	}
+	var segment storage.Segment[*tsTable, option]
+	for _, seg := range bl.tg.segments {
+		if seg.GetTimeRange().Contains(ts) {
+			segment = seg
+		}
+	}
+	if segment == nil {
+		var err error
+		if segment, err = bl.tsdb.CreateSegmentIfNotExist(t); err != nil {
+			return nil, fmt.Errorf("cannot create segment: %w", err)
+		}
+		bl.tg.segments = append(bl.tg.segments, segment)
+	}
+	tst, err := segment.CreateTSTableIfNotExist(shardID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create ts table: %w", err)
+	}
+	tt := &tracesInTable{
+		timeRange:   segment.GetTimeRange(),
+		tsTable:     tst,
+		traces:      generateTraces(),
+		segment:     segment,
+		sidxReqsMap: make(map[string][]sidx.WriteRequest),
+		seriesDocs: seriesDoc{
+			docs:        make(index.Documents, 0),
+			docIDsAdded: make(map[uint64]struct{}),
+		},
+		shardID: shardID,
+	}
+	tt.traces.reset()
+	bl.tg.tables = append(bl.tg.tables, tt)
+	return tt, nil
+}
+
+// Flush writes the buffered spans into parts on the disk along with their secondary index parts,
+// and their series into the series index.
+func (bl *BulkLoader) Flush() error {
+	if bl.tg == nil {
+		return nil
+	}
+	tg := bl.tg
+	bl.tg, bl.pending = nil, 0
+	defer func() {
+		for _, segment := range tg.segments {
+			segment.DecRef()
+		}
+	}()
+	for _, tt := range tg.tables {
+		if err := bl.flushTable(tt); err != nil {
+			return err
+		}
+	}
+	bl.l.Debug().Int("tables", len(tg.tables)).Int64("latest", tg.latestTS).Msg("flushed spans")
+	return nil
+}
+
+func (bl *BulkLoader) flushTable(tt *tracesInTable) error {
+	defer releaseTraces(tt.traces)
+	if len(tt.traces.traceIDs) == 0 {
+		return nil
+	}
+	if len(tt.seriesDocs.docs) > 0 {
+		if err := tt.segment.IndexDB().Update(tt.seriesDocs.docs); err != nil {
+			return fmt.Errorf("cannot write the series index: %w", err)
+		}
+	}
+	tst := tt.tsTable
+	partID := atomic.AddUint64(&tst.curPartID, 1)
+	sidxFilePartsMap := make(map[string]string, len(tt.sidxReqsMap))
+	for name, reqs := range tt.sidxReqsMap {
+		if len(reqs) == 0 {
+			continue
+		}
+		// Create the sidx before writing its part, so that loading it doesn't remove the unknown part.
+		sidxInstance, err := tst.getOrCreateSidx(name)
+		if err != nil {
+			return err
+		}
+		minTS := tt.timeRange.Start.UnixNano()
+		maxTS := tt.timeRange.End.UnixNano()
+		mp, err := sidxInstance.ConvertToMemPart(reqs, tt.timeRange.Start.UnixNano(), &minTS, &maxTS)
+		if err != nil {
+			return fmt.Errorf("cannot write the secondary index %s: %w", name, err)
+		}
+		path := sidxPartPath(tst.root, name, partID)
+		mp.MustFlush(tst.fileSystem, path)
+		sidx.ReleaseMemPart(mp)
+		sidxFilePartsMap[name] = path
+	}
+	mp := generateMemPart()
+	mp.mustInitFromTraces(tt.traces)
+	mp.mustFlush(tst.fileSystem, partPath(tst.root, partID))
+	releaseMemPart(mp)
+	tst.mustAddFilePart(partID, sidxFilePartsMap)
+	return nil
+}
+
+// Close flushes the buffered spans and closes the storage.
+func (bl *BulkLoader) Close() error {
+	err := bl.Flush()
+	if closeErr := bl.tsdb.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
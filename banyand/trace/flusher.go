@@ -165,6 +165,9 @@ func (tst *tsTable) mergeMemParts(snp *snapshot, mergeCh chan *mergerIntroductio
 		if snp.parts[i].mp == nil {
 			continue
 		}
+		if tst.awaitingIndexBackfill(snp.parts[i].ID()) {
+			continue
+		}
 
 		segID := snp.parts[i].mp.segmentID
 
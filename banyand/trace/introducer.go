@@ -29,6 +29,8 @@ type introduction struct {
 	applied          chan struct{}
 	sidxReqsMap      map[string]*sidx.MemPart
 	sidxFilePartsMap map[string]string
+	// sidxPartID is the existing part the sidx file parts belong to when no trace part is introduced.
+	sidxPartID uint64
 }
 
 func (i *introduction) reset() {
@@ -36,6 +38,7 @@ func (i *introduction) reset() {
 	i.applied = nil
 	i.sidxReqsMap = nil
 	i.sidxFilePartsMap = nil
+	i.sidxPartID = 0
 }
 
 var introductionPool = pool.Register[*introduction]("trace-introduction")
@@ -93,6 +96,7 @@ func releaseFlusherIntroduction(i *flusherIntroduction) {
 
 type mergerIntroduction struct {
 	merged               map[uint64]struct{}
+	tombstones           map[uint64]*tombstone
 	newPart              *partWrapper
 	sidxMergerIntroduced map[string]*sidx.MergerIntroduction
 	applied              chan struct{}
@@ -101,6 +105,7 @@ type mergerIntroduction struct {
 
 func (i *mergerIntroduction) reset() {
 	i.merged = nil
+	i.tombstones = nil
 	i.sidxMergerIntroduced = nil
 	i.newPart = nil
 	i.applied = nil
@@ -261,6 +266,10 @@ func (tst *tsTable) introducerLoopWithSync(flushCh chan *flusherIntroduction, me
 }
 
 func (tst *tsTable) introducePart(nextIntroduction *introduction, epoch uint64) {
+	if nextIntroduction.part == nil {
+		tst.introduceSidxParts(nextIntroduction)
+		return
+	}
 	// Create generic transaction
 	txn := snapshotpkg.NewTransaction()
 	defer txn.Release()
@@ -324,7 +333,32 @@ func (tst *tsTable) introducePart(nextIntroduction *introduction, epoch uint64)
 	}
 }
 
+// introduceSidxParts adds the file-backed sidx parts built for an existing trace part.
+func (tst *tsTable) introduceSidxParts(nextIntroduction *introduction) {
+	txn := snapshotpkg.NewTransaction()
+	defer txn.Release()
+	var sidxTransitions []*snapshotpkg.Transition[*sidx.Snapshot]
+	for name, sidxPartPath := range nextIntroduction.sidxFilePartsMap {
+		sidxInstance := tst.mustGetOrCreateSidx(name)
+		prepareFunc := sidxInstance.PrepareFilePart(nextIntroduction.sidxPartID, sidxPartPath)
+		sidxTransition := snapshotpkg.NewTransition(sidxInstance, prepareFunc)
+		sidxTransitions = append(sidxTransitions, sidxTransition)
+		snapshotpkg.AddTransition(txn, sidxTransition)
+	}
+	defer func() {
+		for _, t := range sidxTransitions {
+			t.Release()
+		}
+	}()
+	txn.Commit()
+	if nextIntroduction.applied != nil {
+		close(nextIntroduction.applied)
+	}
+}
+
 func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoch uint64) {
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
 	// Create generic transaction
 	txn := snapshotpkg.NewTransaction()
 	defer txn.Release()
@@ -334,6 +368,11 @@ func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoc
 		if cur == nil {
 			tst.l.Panic().Msg("current snapshot is nil")
 		}
+		for _, pw := range cur.parts {
+			if flushed, ok := nextIntroduction.flushed[pw.ID()]; ok {
+				flushed.p.tombstone.Store(pw.p.tombstone.Load())
+			}
+		}
 		nextSnp := cur.merge(epoch, nextIntroduction.flushed)
 		nextSnp.creator = snapshotCreatorFlusher
 		return &nextSnp
@@ -375,6 +414,8 @@ func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoc
 // The snapshots are updated atomically so the syncer can always find
 // the corresponding index once a flushed trace part becomes visible.
 func (tst *tsTable) introduceFlushedForSync(nextIntroduction *flusherIntroduction, epoch uint64) {
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
 	// Create generic transaction
 	txn := snapshotpkg.NewTransaction()
 	defer txn.Release()
@@ -399,6 +440,11 @@ func (tst *tsTable) introduceFlushedForSync(nextIntroduction *flusherIntroductio
 		if cur == nil {
 			tst.l.Panic().Msg("current snapshot is nil")
 		}
+		for _, pw := range cur.parts {
+			if flushed, ok := nextIntroduction.flushed[pw.ID()]; ok {
+				flushed.p.tombstone.Store(pw.p.tombstone.Load())
+			}
+		}
 		nextSnp := cur.merge(epoch, nextIntroduction.flushed)
 		nextSnp.creator = snapshotCreatorFlusher
 		return &nextSnp
@@ -422,6 +468,8 @@ func (tst *tsTable) introduceFlushedForSync(nextIntroduction *flusherIntroductio
 }
 
 func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch uint64) {
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
 	// Create generic transaction
 	txn := snapshotpkg.NewTransaction()
 	defer txn.Release()
@@ -431,6 +479,7 @@ func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch
 		if cur == nil {
 			tst.l.Panic().Msg("current snapshot is nil")
 		}
+		carryTombstones(cur, nextIntroduction.newPart, nextIntroduction.merged, nextIntroduction.tombstones)
 		nextSnp := cur.remove(epoch, nextIntroduction.merged)
 		nextSnp.parts = append(nextSnp.parts, nextIntroduction.newPart)
 		nextSnp.creator = nextIntroduction.creator
@@ -462,6 +511,9 @@ func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch
 	if cur != nil {
 		defer cur.decRef()
 		tst.persistSnapshot(cur)
+		if tst.hasTombstones {
+			tst.mustPersistTombstones(cur)
+		}
 	}
 
 	if nextIntroduction.applied != nil {
@@ -275,6 +275,7 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	defer releaseDiskSpace(reservedSpace)
 	start := time.Now()
 	newPartID := atomic.AddUint64(&tst.curPartID, 1)
+	tombstones := captureTombstones(parts)
 	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, newPartID, tst.root)
 	if err != nil {
 		return nil, err
@@ -363,6 +364,7 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	mi.creator = creator
 	mi.newPart = newPart
 	mi.merged = merged
+	mi.tombstones = tombstones
 	mi.sidxMergerIntroduced = mergerIntroductionMap
 	mi.applied = make(chan struct{})
 	select {
@@ -414,6 +416,9 @@ func (tst *tsTable) getPartsToMerge(snapshot *snapshot, freeDiskSize uint64, dst
 		if _, inFlight := tst.inFlight[pw.ID()]; inFlight {
 			continue
 		}
+		if tst.awaitingIndexBackfill(pw.ID()) {
+			continue
+		}
 		parts = append(parts, pw)
 	}
 	tst.inFlightMu.RUnlock()
@@ -477,6 +482,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	for i := range parts {
 		pmi := generatePartMergeIter()
 		pmi.mustInitFromPart(parts[i].p)
+		pmi.tombstone = parts[i].p.tombstone.Load()
 		pii = append(pii, pmi)
 		totalSize += int64(parts[i].p.partMetadata.CompressedSizeBytes)
 		traceSize += parts[i].p.partMetadata.BlocksCount
@@ -586,6 +592,11 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader, conf
 		default:
 		}
 		b := br.block
+		if br.deleted() {
+			// the trace is deleted, drop its spans.
+			br.mustReadRaw(&rawBlk, &b.bm)
+			continue
+		}
 		// Fast path: if this is the only block for this traceID AND we have no pending block,
 		// copy it raw without unmarshaling
 		nextB := br.peek()
@@ -379,9 +379,19 @@ func (sr *schemaRepo) collectShardInfo(ctx context.Context, table any, shardID u
 		InvertedIndexInfo: &databasev1.InvertedIndexInfo{},
 		SidxInfo:          sidxInfo,
 		FilePartCount:     int64(filePartCount),
+		IndexBackfillInfo: sr.collectIndexBackfillInfo(tst),
 	}
 }
 
+func (sr *schemaRepo) collectIndexBackfillInfo(tst *tsTable) []*databasev1.IndexBackfillInfo {
+	progress, err := storage.LoadIndexBackfillProgress(tst.fileSystem, tst.root)
+	if err != nil {
+		sr.l.Warn().Err(err).Str("shard", tst.root).Msg("cannot load the index backfill progress")
+		return nil
+	}
+	return progress.Infos()
+}
+
 func (sr *schemaRepo) collectSidxInfo(ctx context.Context, tst *tsTable) *databasev1.SIDXInfo {
 	sidxMap := tst.sidxMap
 	if len(sidxMap) == 0 {
@@ -63,6 +63,7 @@ type part struct {
 	tagType              tagType
 	traceIDFilter        traceIDFilter
 	seriesMetadata       fs.Reader // Optional: series metadata reader
+	tombstone            atomic.Pointer[tombstone]
 	path                 string
 	primaryBlockMetadata []primaryBlockMetadata
 	partMetadata         partMetadata
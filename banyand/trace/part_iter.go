@@ -62,7 +62,7 @@ func (pi *partIter) init(bma *blockMetadataArray, p *part, tids []string) {
 	pi.p = p
 
 	pi.bms = bma.arr
-	pi.tids = tids
+	pi.tids = p.tombstone.Load().filter(tids)
 
 	pi.primaryBlockMetadata = p.primaryBlockMetadata
 
@@ -257,6 +257,7 @@ type partMergeIter struct {
 	seqReaders           seqReaders
 	tagType              map[string]pbv1.ValueType
 	err                  error
+	tombstone            *tombstone
 	primaryBlockMetadata []primaryBlockMetadata
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
@@ -272,6 +273,7 @@ func (pmi *partMergeIter) reset() {
 	pmi.primaryMetadataIdx = 0
 	pmi.primaryBuf = pmi.primaryBuf[:0]
 	pmi.compressedPrimaryBuf = pmi.compressedPrimaryBuf[:0]
+	pmi.tombstone = nil
 	pmi.block.reset()
 }
 
@@ -86,6 +86,7 @@ func (t *trace) Query(ctx context.Context, tqo model.TraceQueryOptions) (model.T
 		ctx:           ctx,
 		segments:      segments,
 		tagProjection: tqo.TagProjection,
+		predicate:     tqo.Predicate,
 	}
 	segmentsNeedRelease = false
 	defer func() {
@@ -279,6 +280,7 @@ type queryResult struct {
 	currentCursorGroups map[string][]*blockCursor
 	currentBatch        *scanBatch
 	tagProjection       *model.TagProjection
+	predicate           model.TracePredicate
 	finishResultSpan    func(int, error)
 	recordResult        func(*model.TraceResult)
 	currentTraceIDs     []string
@@ -339,9 +341,19 @@ func (qr *queryResult) Pull() *model.TraceResult {
 		result.Key = qr.keys[traceID]
 		result.TID = traceID
 
-		qr.hit++
 		qr.currentIndex++
 		delete(qr.currentCursorGroups, traceID)
+		if qr.predicate != nil {
+			matched, matchErr := qr.predicate.Match(result)
+			if matchErr != nil {
+				qr.err = matchErr
+				return &model.TraceResult{Error: matchErr}
+			}
+			if !matched {
+				continue
+			}
+		}
+		qr.hit++
 
 		if qr.recordResult != nil {
 			qr.recordResult(result)
@@ -68,6 +68,7 @@ type standalone struct {
 	metadata           metadata.Repo
 	l                  *logger.Logger
 	diskMonitor        *storage.DiskMonitor
+	backfillRunner     *storage.IndexBackfillRunner
 	schemaRepo         schemaRepo
 	snapshotDir        string
 	root               string
@@ -164,6 +165,7 @@ func (s *standalone) PreRun(ctx context.Context) error {
 		metaSvc.RegisterDataCollector(commonv1.Catalog_CATALOG_TRACE, &s.schemaRepo)
 		metaSvc.RegisterLiaisonCollector(commonv1.Catalog_CATALOG_TRACE, s)
 		metaSvc.RegisterGroupDropHandler(commonv1.Catalog_CATALOG_TRACE, s)
+		metaSvc.RegisterIndexBackfillHandler(commonv1.Catalog_CATALOG_TRACE, s)
 	}
 	subErr := s.pipeline.Subscribe(data.TopicTraceCollectDataInfo, &collectDataInfoListener{s: s})
 	if subErr != nil {
@@ -173,6 +175,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	if dropGroupErr != nil {
 		return fmt.Errorf("failed to subscribe to TopicTraceDropGroup: %w", dropGroupErr)
 	}
+	if backfillErr := s.pipeline.Subscribe(data.TopicTraceIndexBackfill, &indexBackfillListener{s: s}); backfillErr != nil {
+		return fmt.Errorf("failed to subscribe to TopicTraceIndexBackfill: %w", backfillErr)
+	}
 
 	// Initialize snapshot directory
 	s.snapshotDir = filepath.Join(path, "snapshots")
@@ -181,6 +186,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	s.diskMonitor = storage.NewDiskMonitor(s, s.retentionConfig, s.omr)
 	s.diskMonitor.Start()
 
+	s.backfillRunner = storage.NewIndexBackfillRunner(s.l, s.lfs, s.dataPath, s.schemaRepo.runIndexBackfill)
+	s.backfillRunner.Start()
+
 	// Set up write callback handler. For now, keep the original write throttling behavior based on high watermark
 	writeListener := setUpWriteCallback(s.l, &s.schemaRepo, int(s.retentionConfig.HighWatermark))
 	err = s.pipeline.Subscribe(data.TopicTraceWrite, writeListener)
@@ -225,6 +233,9 @@ func (s *standalone) GracefulStop() {
 	if s.diskMonitor != nil {
 		s.diskMonitor.Stop()
 	}
+	if s.backfillRunner != nil {
+		s.backfillRunner.Stop()
+	}
 
 	if s.schemaRepo.Repository != nil {
 		s.schemaRepo.Repository.Close()
@@ -598,3 +609,20 @@ func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability
 		pm:       pm,
 	}, nil
 }
+
+type indexBackfillListener struct {
+	*bus.UnImplementedHealthyListener
+	s *standalone
+}
+
+func (l *indexBackfillListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	req, ok := message.Data().(*databasev1.IndexRuleBindingRegistryServiceBackfillRequest)
+	if !ok {
+		return bus.NewMessage(message.ID(), common.NewError("invalid data type for index backfill request"))
+	}
+	n, err := l.s.BackfillIndex(ctx, req)
+	if err != nil {
+		return bus.NewMessage(message.ID(), common.NewError("failed to schedule index backfill: %v", err))
+	}
+	return bus.NewMessage(message.ID(), &databasev1.IndexRuleBindingRegistryServiceBackfillResponse{ShardCount: n})
+}
@@ -0,0 +1,256 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const tombstoneFilename = "tombstone.json"
+
+// tombstone is the set of traces deleted from a part but not yet removed from its files.
+// It's never modified after creation. Deleting more traces replaces the part's tombstone.
+type tombstone struct {
+	traceIDs map[string]struct{}
+}
+
+func (t *tombstone) contains(traceID string) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.traceIDs[traceID]
+	return ok
+}
+
+func (t *tombstone) union(traceIDs map[string]struct{}) *tombstone {
+	if t == nil {
+		return &tombstone{traceIDs: traceIDs}
+	}
+	merged := make(map[string]struct{}, len(t.traceIDs)+len(traceIDs))
+	for id := range t.traceIDs {
+		merged[id] = struct{}{}
+	}
+	for id := range traceIDs {
+		merged[id] = struct{}{}
+	}
+	return &tombstone{traceIDs: merged}
+}
+
+// filter returns the sorted trace IDs which are not deleted.
+func (t *tombstone) filter(tids []string) []string {
+	if t == nil {
+		return tids
+	}
+	kept := make([]string, 0, len(tids))
+	for _, tid := range tids {
+		if !t.contains(tid) {
+			kept = append(kept, tid)
+		}
+	}
+	return kept
+}
+
+// deleteTraces hides the traces from the parts overlapping the time range.
+// The spans are removed from disk when these parts are merged.
+// The secondary indexes keep the trace IDs, which resolve to no spans afterwards.
+func (tst *tsTable) deleteTraces(minTimestamp, maxTimestamp int64, traceIDs map[string]struct{}) {
+	if len(traceIDs) == 0 {
+		return
+	}
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return
+	}
+	defer snp.decRef()
+	for _, pw := range snp.parts {
+		pm := pw.p.partMetadata
+		if maxTimestamp < pm.MinTimestamp || minTimestamp > pm.MaxTimestamp {
+			continue
+		}
+		pw.p.tombstone.Store(pw.p.tombstone.Load().union(traceIDs))
+	}
+	tst.mustPersistTombstones(snp)
+}
+
+// carryTombstones copies the tombstones of the replaced parts to the new ones.
+// The merger captured the tombstones of its source parts in applied before reading them.
+// Traces deleted after that are still in the merged part, so they have to be hidden there.
+func carryTombstones(cur *snapshot, newPart *partWrapper, merged map[uint64]struct{}, applied map[uint64]*tombstone) {
+	for _, pw := range cur.parts {
+		if _, ok := merged[pw.ID()]; !ok {
+			continue
+		}
+		t := pw.p.tombstone.Load()
+		if t == nil || t == applied[pw.ID()] {
+			continue
+		}
+		newPart.p.tombstone.Store(newPart.p.tombstone.Load().union(t.traceIDs))
+	}
+}
+
+func captureTombstones(parts []*partWrapper) map[uint64]*tombstone {
+	var applied map[uint64]*tombstone
+	for _, pw := range parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		if applied == nil {
+			applied = make(map[uint64]*tombstone)
+		}
+		applied[pw.ID()] = t
+	}
+	return applied
+}
+
+// mustPersistTombstones writes the tombstones of the snapshot's parts to the table's root.
+// The file is removed once merges have dropped all the deleted traces.
+func (tst *tsTable) mustPersistTombstones(snp *snapshot) {
+	entries := make(map[string][]string)
+	for _, pw := range snp.parts {
+		t := pw.p.tombstone.Load()
+		if t == nil {
+			continue
+		}
+		ids := make([]string, 0, len(t.traceIDs))
+		for id := range t.traceIDs {
+			ids = append(ids, id)
+		}
+		entries[partName(pw.ID())] = ids
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if len(entries) == 0 {
+		if tst.hasTombstones {
+			if err := tst.fileSystem.DeleteFile(tombstonePath); err != nil {
+				logger.Panicf("cannot delete tombstone file %s: %s", tombstonePath, err)
+			}
+			tst.fileSystem.SyncPath(tst.root)
+			tst.hasTombstones = false
+		}
+		return
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		logger.Panicf("cannot marshal tombstones to JSON: %s", err)
+	}
+	tombstoneTempPath := tombstonePath + ".tmp"
+	lf, err := tst.fileSystem.CreateLockFile(tombstoneTempPath, storage.FilePerm)
+	if err != nil {
+		logger.Panicf("cannot create lock file %s: %s", tombstoneTempPath, err)
+	}
+	n, err := lf.Write(data)
+	if err != nil {
+		_ = lf.Close()
+		logger.Panicf("cannot write tombstones %s: %s", tombstoneTempPath, err)
+	}
+	if n != len(data) {
+		_ = lf.Close()
+		logger.Panicf("unexpected number of bytes written to %s; got %d; want %d", tombstoneTempPath, n, len(data))
+	}
+	if closeErr := lf.Close(); closeErr != nil {
+		logger.Panicf("cannot close tombstone temp file %s: %s", tombstoneTempPath, closeErr)
+	}
+	if renameErr := tst.fileSystem.Rename(tombstoneTempPath, tombstonePath); renameErr != nil {
+		logger.Panicf("cannot rename tombstones %s to %s: %s", tombstoneTempPath, tombstonePath, renameErr)
+	}
+	tst.fileSystem.SyncPath(tst.root)
+	tst.hasTombstones = true
+}
+
+// openTombstones loads the tombstones of a table opened from disk.
+// The table is closed if they can't be loaded, since serving its parts without them brings the deleted data back.
+func (tst *tsTable) openTombstones() error {
+	if err := tst.loadTombstones(tst.snapshot); err != nil {
+		_ = tst.Close()
+		return fmt.Errorf("cannot open the table %s: %w", tst.root, err)
+	}
+	return nil
+}
+
+// loadTombstones attaches the persisted tombstones to the loaded parts.
+// Tombstones of parts that no longer exist are dropped.
+func (tst *tsTable) loadTombstones(snp *snapshot) error {
+	if snp == nil {
+		snp = &snapshot{}
+	}
+	tombstonePath := filepath.Join(tst.root, tombstoneFilename)
+	if !tst.fileSystem.IsExist(tombstonePath) {
+		return nil
+	}
+	tst.hasTombstones = true
+	data, err := tst.fileSystem.Read(tombstonePath)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", tombstonePath, err)
+	}
+	var entries map[string][]string
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", tombstonePath, err)
+	}
+	for _, pw := range snp.parts {
+		ids, ok := entries[partName(pw.ID())]
+		if !ok {
+			continue
+		}
+		traceIDs := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			traceIDs[id] = struct{}{}
+		}
+		pw.p.tombstone.Store(&tombstone{traceIDs: traceIDs})
+	}
+	return nil
+}
+
+// Delete hides the traces from queries. They are removed from disk by the next merge.
+func (t *trace) Delete(tr timestamp.TimeRange, traceIDs []string) error {
+	if len(traceIDs) == 0 {
+		return nil
+	}
+	tsdb, err := t.ensureTSDB()
+	if err != nil {
+		return err
+	}
+	segments, err := tsdb.SelectSegments(tr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}()
+	ids := make(map[string]struct{}, len(traceIDs))
+	for _, id := range traceIDs {
+		ids[id] = struct{}{}
+	}
+	for _, segment := range segments {
+		// the caches returned with the tables hold the block metadata of the parts, which the tombstones leave intact
+		tables, _ := segment.Tables()
+		for _, tst := range tables {
+			tst.deleteTraces(tr.Start.UnixNano(), tr.End.UnixNano(), ids)
+		}
+	}
+	return nil
+}
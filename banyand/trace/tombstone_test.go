@@ -0,0 +1,121 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func Test_tombstone_filter(t *testing.T) {
+	var empty *tombstone
+	tids := []string{"trace1", "trace2", "trace3"}
+	require.Equal(t, tids, empty.filter(tids))
+	ts := empty.union(map[string]struct{}{"trace2": {}})
+	require.Equal(t, []string{"trace1", "trace3"}, ts.filter(tids))
+	require.Equal(t, []string{"trace1", "trace2", "trace3"}, tids)
+}
+
+func Test_mergeParts_tombstone(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	for i, ts := range []*traces{tsTS1, tsTS2} {
+		mp := generateMemPart()
+		mp.mustInitFromTraces(ts)
+		pw := newPartWrapper(mp, openMemPart(mp))
+		pw.p.partMetadata.ID = uint64(i)
+		pp = append(pp, pw)
+	}
+	pp[0].p.tombstone.Store((*tombstone)(nil).union(map[string]struct{}{"trace2": {}, "trace3": {}}))
+	pp[1].p.tombstone.Store((*tombstone)(nil).union(map[string]struct{}{"trace3": {}}))
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}}
+	p, err := tst.mergeParts(fs.NewLocalFileSystem(), closeCh, pp, 2, tmpPath)
+	require.NoError(t, err)
+	defer p.decRef()
+
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p.p)
+	reader := &blockReader{}
+	reader.init([]*partMergeIter{pmi})
+	got := make(map[string]uint64)
+	for reader.nextBlockMetadata() {
+		got[reader.block.bm.traceID] += reader.block.bm.count
+	}
+	require.NoError(t, reader.error())
+	require.Equal(t, map[string]uint64{"trace1": 2, "trace2": 1}, got)
+}
+
+func TestTombstonePersistence(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	tst := &tsTable{fileSystem: fileSystem, root: tmpPath}
+
+	newSnapshot := func() *snapshot {
+		snp := &snapshot{}
+		for i := uint64(1); i <= 2; i++ {
+			snp.parts = append(snp.parts, newPartWrapper(nil, &part{partMetadata: partMetadata{ID: i}}))
+		}
+		return snp
+	}
+	snp := newSnapshot()
+	snp.parts[0].p.tombstone.Store((*tombstone)(nil).union(map[string]struct{}{"trace1": {}}))
+	tst.mustPersistTombstones(snp)
+	require.True(t, tst.hasTombstones)
+
+	loaded := newSnapshot()
+	reopened := &tsTable{fileSystem: fileSystem, root: tmpPath}
+	require.NoError(t, reopened.loadTombstones(loaded))
+	require.True(t, loaded.parts[0].p.tombstone.Load().contains("trace1"))
+	require.Nil(t, loaded.parts[1].p.tombstone.Load())
+
+	snp.parts[0].p.tombstone.Store(nil)
+	tst.mustPersistTombstones(snp)
+	require.False(t, tst.hasTombstones)
+	require.False(t, fileSystem.IsExist(tmpPath+"/"+tombstoneFilename))
+}
+
+func TestCorruptedTombstonesFailOpen(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	_, err := fileSystem.Write([]byte("{"), filepath.Join(tmpPath, tombstoneFilename), storage.FilePerm)
+	require.NoError(t, err)
+
+	_, err = newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, traceSnapshotOption(), nil)
+	require.ErrorContains(t, err, "cannot parse")
+}
@@ -82,6 +82,7 @@ type Trace interface {
 	GetSchema() *databasev1.Trace
 	GetIndexRules() []*databasev1.IndexRule
 	Query(ctx context.Context, opts model.TraceQueryOptions) (model.TraceQueryResult, error)
+	Delete(tr timestamp.TimeRange, traceIDs []string) error
 }
 
 type indexSchema struct {
@@ -59,6 +59,7 @@ type tsTable struct {
 	l                *logger.Logger
 	sidxMap          map[string]sidx.SIDX
 	introductions    chan *introduction
+	backfillTasks    []*storage.IndexBackfillTask
 	p                common.Position
 	group            string
 	root             string
@@ -67,8 +68,11 @@ type tsTable struct {
 	curPartID        uint64
 	pendingDataCount atomic.Int64
 	inFlightMu       sync.RWMutex
+	backfillMu       sync.RWMutex
+	tombstoneMu      sync.Mutex
 	sync.RWMutex
-	shardID common.ShardID
+	shardID       common.ShardID
+	hasTombstones bool
 }
 
 func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) error {
@@ -315,6 +319,10 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	l *logger.Logger, _ timestamp.TimeRange, option option, m any,
 ) (*tsTable, error) {
 	t, epoch := initTSTable(fileSystem, rootPath, p, l, option, m)
+	if err := t.openTombstones(); err != nil {
+		return nil, err
+	}
+	t.loadIndexBackfill()
 	t.startLoop(epoch)
 	return t, nil
 }
@@ -523,6 +531,22 @@ func (tst *tsTable) mustAddFilePart(partID uint64, sidxFilePartsMap map[string]s
 	<-ind.applied
 }
 
+// mustAddSidxFileParts introduces the sidx file parts built for the existing trace part.
+func (tst *tsTable) mustAddSidxFileParts(partID uint64, sidxFilePartsMap map[string]string) {
+	ind := generateIntroduction()
+	defer releaseIntroduction(ind)
+	ind.applied = make(chan struct{})
+	ind.sidxFilePartsMap = sidxFilePartsMap
+	ind.sidxPartID = partID
+
+	select {
+	case tst.introductions <- ind:
+	case <-tst.loopCloser.CloseNotify():
+		return
+	}
+	<-ind.applied
+}
+
 func (tst *tsTable) mustAddMemPart(mp *memPart, sidxReqsMap map[string]*sidx.MemPart) {
 	p := openMemPart(mp)
 
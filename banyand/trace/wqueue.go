@@ -37,6 +37,9 @@ func newWriteQueue(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	handoffCtrl *handoffController,
 ) (*tsTable, error) {
 	t, epoch := initTSTable(fileSystem, rootPath, p, l, option, m)
+	if err := t.openTombstones(); err != nil {
+		return nil, err
+	}
 	t.getNodes = getNodes
 	t.group = group
 	t.shardID = shardID
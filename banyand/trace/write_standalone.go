@@ -368,6 +368,11 @@ func processTraces(schemaRepo *schemaRepo, tracesInTable *tracesInTable, writeEv
 	if !ok {
 		return fmt.Errorf("cannot find trace definition: %s", metadata)
 	}
+	return appendTrace(stm, tracesInTable, req, metadata, spec)
+}
+
+// appendTrace appends the span of the request to the traces of the table, and its index entries to the sidx requests.
+func appendTrace(stm *trace, tracesInTable *tracesInTable, req *tracev1.WriteRequest, metadata *commonv1.Metadata, spec *tracev1.TagSpec) error {
 	specMap := buildSpecMap(spec)
 	traceID, err := extractTraceSpanInfo(stm, tracesInTable, req, specMap)
 	if err != nil {
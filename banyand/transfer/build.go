@@ -0,0 +1,453 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
+	"github.com/apache/skywalking-banyandb/pkg/archive"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+// The formats of the input files.
+const (
+	formatNDJSON  = "ndjson"
+	formatCSV     = "csv"
+	formatParquet = "parquet"
+)
+
+// maxLineSize is the maximum size of a line of an NDJSON file.
+const maxLineSize = 64 << 20
+
+type buildOptions struct {
+	gRPCAddr  string
+	cert      string
+	catalog   string
+	group     string
+	name      string
+	format    string
+	workDir   string
+	timeout   time.Duration
+	chunkSize run.Bytes
+	enableTLS bool
+	insecure  bool
+}
+
+func newBuildCommand() *cobra.Command {
+	opts := buildOptions{chunkSize: run.Bytes(1024 * 1024)}
+	cmd := &cobra.Command{
+		Use:   "build [flags] FILE...",
+		Short: "Build the parts of a measure, a stream or a trace offline from input files, and load them into a cluster",
+		Long: `Build the parts of a measure, a stream or a trace offline from NDJSON, CSV or Parquet files,
+and load them through the PartLoadService of the liaison, which hands them to the data nodes owning their shards.
+The data nodes introduce each part atomically, as they do for the parts synced by the liaison,
+which keeps the historical imports away from the write path, the flusher and the merger.
+Loading is not idempotent: building the same rows again stores the elements of a stream and the spans of a trace twice.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return buildAction(opts, args)
+		},
+	}
+	cmd.Flags().StringVar(&opts.gRPCAddr, "grpc-addr", "127.0.0.1:17912", "gRPC address of the liaison")
+	cmd.Flags().BoolVar(&opts.enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
+	cmd.Flags().BoolVar(&opts.insecure, "insecure", false, "Skip server certificate verification")
+	cmd.Flags().StringVar(&opts.cert, "cert", "", "Path to the gRPC server certificate")
+	cmd.Flags().StringVar(&opts.catalog, "catalog", catalogMeasure, "Catalog of the resource (measure|stream|trace)")
+	cmd.Flags().StringVarP(&opts.group, "group", "g", "", "Group of the resource")
+	cmd.Flags().StringVarP(&opts.name, "name", "n", "", "Name of the measure, the stream or the trace")
+	cmd.Flags().StringVar(&opts.format, "format", "", "Format of the input files (ndjson|csv|parquet). Defaults to the one of the file extension")
+	cmd.Flags().StringVar(&opts.workDir, "work-dir", os.TempDir(), "Directory holding the built parts until they are synced")
+	cmd.Flags().Var(&opts.chunkSize, "chunk-size", "Size of the chunks sending the parts to the liaison")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", time.Minute, "Timeout of each query of the definitions")
+	return cmd
+}
+
+// target is the resource receiving the rows of the input files.
+type target struct {
+	l          loader
+	csvMapping func(header []string) (*csvMapping, error)
+	rowMapping func(file *parquet.Schema) (*rowMapping, error)
+}
+
+func buildAction(opts buildOptions, files []string) error {
+	if opts.catalog != catalogMeasure && opts.catalog != catalogStream && opts.catalog != catalogTrace {
+		return fmt.Errorf("catalog must be %s, %s or %s, got %q", catalogMeasure, catalogStream, catalogTrace, opts.catalog)
+	}
+	if opts.group == "" || opts.name == "" {
+		return errors.New("group and name are required")
+	}
+	formats := make([]string, 0, len(files))
+	for _, file := range files {
+		format, err := formatOf(opts.format, file)
+		if err != nil {
+			return err
+		}
+		formats = append(formats, format)
+	}
+	_, err := snapshot.Conn(opts.gRPCAddr, opts.enableTLS, opts.insecure, opts.cert, func(conn *grpc.ClientConn) (struct{}, error) {
+		b := &builder{conn: conn, opts: opts}
+		return struct{}{}, b.build(files, formats)
+	})
+	return err
+}
+
+func formatOf(format, file string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".ndjson", ".jsonl", ".json":
+			format = formatNDJSON
+		case ".csv":
+			format = formatCSV
+		case ".parquet":
+			format = formatParquet
+		}
+	}
+	switch format {
+	case formatNDJSON, formatCSV, formatParquet:
+		return format, nil
+	case "":
+		return "", fmt.Errorf("cannot tell the format of %s, which should be given by a flag", file)
+	}
+	return "", fmt.Errorf("format must be %s, %s or %s, got %q", formatNDJSON, formatCSV, formatParquet, format)
+}
+
+type builder struct {
+	conn *grpc.ClientConn
+	opts buildOptions
+}
+
+func (b *builder) build(files, formats []string) (err error) {
+	group, err := call(b.opts.timeout, func(ctx context.Context) (*commonv1.Group, error) {
+		resp, callErr := databasev1.NewGroupRegistryServiceClient(b.conn).Get(ctx, &databasev1.GroupRegistryServiceGetRequest{Group: b.opts.group})
+		return resp.GetGroup(), callErr
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get group %s: %w", b.opts.group, err)
+	}
+	if group.GetCatalog() != catalogOf(b.opts.catalog) {
+		return fmt.Errorf("group %s is a %s group", b.opts.group, group.GetCatalog())
+	}
+	rules, err := indexRules(b.conn, b.opts.timeout, b.opts.group, b.opts.name, group.GetCatalog())
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(b.opts.workDir, "banyandb-build-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, os.RemoveAll(dir))
+	}()
+	t, err := b.target(dir, group, rules)
+	if err != nil {
+		return err
+	}
+	for i, file := range files {
+		if err = loadFile(t, file, formats[i]); err != nil {
+			_ = t.l.Close()
+			return fmt.Errorf("cannot load %s: %w", file, err)
+		}
+	}
+	if err = t.l.Close(); err != nil {
+		return err
+	}
+	return b.load(filepath.Join(dir, b.opts.group))
+}
+
+func (b *builder) target(dir string, group *commonv1.Group, rules []*databasev1.IndexRule) (*target, error) {
+	metadata := &commonv1.Metadata{Group: b.opts.group, Name: b.opts.name}
+	switch group.GetCatalog() {
+	case commonv1.Catalog_CATALOG_STREAM:
+		s, err := call(b.opts.timeout, func(ctx context.Context) (*databasev1.Stream, error) {
+			resp, callErr := databasev1.NewStreamRegistryServiceClient(b.conn).Get(ctx, &databasev1.StreamRegistryServiceGetRequest{Metadata: metadata})
+			return resp.GetStream(), callErr
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get stream %s: %w", b.opts.name, err)
+		}
+		bl, err := stream.NewBulkLoader(dir, group, s, rules)
+		if err != nil {
+			return nil, err
+		}
+		return &target{
+			l:          streamLoader{bl},
+			csvMapping: func(header []string) (*csvMapping, error) { return newCSVStreamMapping(header, s) },
+			rowMapping: func(file *parquet.Schema) (*rowMapping, error) { return newStreamMapping(file, s) },
+		}, nil
+	case commonv1.Catalog_CATALOG_TRACE:
+		t, err := call(b.opts.timeout, func(ctx context.Context) (*databasev1.Trace, error) {
+			resp, callErr := databasev1.NewTraceRegistryServiceClient(b.conn).Get(ctx, &databasev1.TraceRegistryServiceGetRequest{Metadata: metadata})
+			return resp.GetTrace(), callErr
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get trace %s: %w", b.opts.name, err)
+		}
+		bl, err := trace.NewBulkLoader(dir, group, t, rules)
+		if err != nil {
+			return nil, err
+		}
+		return &target{
+			l:          traceLoader{bl},
+			csvMapping: func(header []string) (*csvMapping, error) { return newCSVTraceMapping(header, t) },
+			rowMapping: func(file *parquet.Schema) (*rowMapping, error) { return newTraceMapping(file, t) },
+		}, nil
+	}
+	m, err := call(b.opts.timeout, func(ctx context.Context) (*databasev1.Measure, error) {
+		resp, callErr := databasev1.NewMeasureRegistryServiceClient(b.conn).Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: metadata})
+		return resp.GetMeasure(), callErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get measure %s: %w", b.opts.name, err)
+	}
+	bl, err := measure.NewBulkLoader(dir, group, m, rules)
+	if err != nil {
+		return nil, err
+	}
+	return &target{
+		l:          measureLoader{bl},
+		csvMapping: func(header []string) (*csvMapping, error) { return newCSVMeasureMapping(header, m) },
+		rowMapping: func(file *parquet.Schema) (*rowMapping, error) { return newMeasureMapping(file, m) },
+	}, nil
+}
+
+// load sends the archive of the built parts under the directory root to the liaison,
+// which hands the parts to the data nodes owning their shards.
+func (b *builder) load(root string) error {
+	size, err := archive.Size(root)
+	if err != nil {
+		return err
+	}
+	stream, err := databasev1.NewPartLoadServiceClient(b.conn).Load(context.Background())
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(&partLoadWriter{stream: stream, group: b.opts.group, size: uint64(size)}, int(b.opts.chunkSize))
+	err = archive.Tar(w, root)
+	if err == nil {
+		err = w.Flush()
+	}
+	// The stream is broken if the liaison fails, whose error is received on closing.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("cannot load the parts: %w", err)
+	}
+	logger.Infof("loaded %d parts of %s %s/%s into the data nodes", resp.GetParts(), b.opts.catalog, b.opts.group, b.opts.name)
+	return nil
+}
+
+// partLoadWriter sends a copy of each write as a chunk of the archive, since the buffer is reused.
+// The first chunk carries the group and the size of the files.
+type partLoadWriter struct {
+	stream databasev1.PartLoadService_LoadClient
+	group  string
+	size   uint64
+	sent   bool
+}
+
+func (w *partLoadWriter) Write(p []byte) (int, error) {
+	req := &databasev1.PartLoadRequest{Chunk: bytes.Clone(p)}
+	if !w.sent {
+		req.Group, req.Size, w.sent = w.group, w.size, true
+	}
+	if err := w.stream.Send(req); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type traceLoader struct {
+	*trace.BulkLoader
+}
+
+func (l traceLoader) write(rm *rowMapping, values [][]parquet.Value, row int) error {
+	tags, span := rm.traceSpan(values, row)
+	return l.Write(tags, span)
+}
+
+func (l traceLoader) writeJSON(line []byte) error {
+	req := &tracev1.WriteRequest{}
+	if err := protojson.Unmarshal(line, req); err != nil {
+		return err
+	}
+	return l.Write(req.GetTags(), req.GetSpan())
+}
+
+func (l traceLoader) writeCSV(cm *csvMapping, record []string) error {
+	tags, span, err := cm.traceSpan(record)
+	if err != nil {
+		return err
+	}
+	return l.Write(tags, span)
+}
+
+func (l measureLoader) writeJSON(line []byte) error {
+	dp := &measurev1.DataPointValue{}
+	if err := protojson.Unmarshal(line, dp); err != nil {
+		return err
+	}
+	return l.Write(dp)
+}
+
+func (l measureLoader) writeCSV(cm *csvMapping, record []string) error {
+	dp, err := cm.dataPoint(record)
+	if err != nil {
+		return err
+	}
+	return l.Write(dp)
+}
+
+func (l streamLoader) writeJSON(line []byte) error {
+	e := &streamv1.ElementValue{}
+	if err := protojson.Unmarshal(line, e); err != nil {
+		return err
+	}
+	return l.Write(e, 0)
+}
+
+func (l streamLoader) writeCSV(cm *csvMapping, record []string) error {
+	e, err := cm.element(record)
+	if err != nil {
+		return err
+	}
+	return l.Write(e, 0)
+}
+
+func loadFile(t *target, file, format string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var n int
+	switch format {
+	case formatNDJSON:
+		n, err = loadNDJSON(t.l, f)
+	case formatCSV:
+		n, err = loadCSV(t, f)
+	default:
+		n, err = loadParquet(t, f)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("loaded %d rows from %s", n, file)
+	return nil
+}
+
+// loadNDJSON writes the lines of the reader, each of which is the JSON of a data point, an element or a span.
+func loadNDJSON(l loader, r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<20), maxLineSize)
+	var n, line int
+	for scanner.Scan() {
+		line++
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if err := l.writeJSON(b); err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// loadCSV writes the records of the reader, whose first record is the header naming the columns.
+func loadCSV(t *target, r io.Reader) (int, error) {
+	cr := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return 0, fmt.Errorf("cannot read the header: %w", err)
+	}
+	cm, err := t.csvMapping(append([]string(nil), header...))
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for {
+		record, readErr := cr.Read()
+		if errors.Is(readErr, io.EOF) {
+			return n, nil
+		}
+		if readErr != nil {
+			return n, readErr
+		}
+		if err = t.l.writeCSV(cm, record); err != nil {
+			return n, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		n++
+	}
+}
+
+func loadParquet(t *target, f *os.File) (int, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	r, err := parquet.OpenReader(f, info.Size())
+	if err != nil {
+		return 0, err
+	}
+	rm, err := t.rowMapping(r.Schema())
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < r.NumRowGroups(); i++ {
+		values, readErr := r.ReadRowGroup(i, rm.columns)
+		if readErr != nil {
+			return 0, readErr
+		}
+		for row := range values[0] {
+			if err = t.l.write(rm, values, row); err != nil {
+				return 0, fmt.Errorf("row %d of row group %d: %w", row, i, err)
+			}
+		}
+	}
+	return int(r.NumRows()), nil
+}
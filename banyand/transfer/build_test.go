@@ -0,0 +1,189 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/transfer/partsync"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func TestFormatOf(t *testing.T) {
+	for file, want := range map[string]string{"a.ndjson": formatNDJSON, "a.JSONL": formatNDJSON, "a.csv": formatCSV, "a.parquet": formatParquet} {
+		got, err := formatOf("", file)
+		require.NoError(t, err)
+		require.Equal(t, want, got, file)
+	}
+	got, err := formatOf(formatCSV, "a.txt")
+	require.NoError(t, err)
+	require.Equal(t, formatCSV, got)
+	_, err = formatOf("", "a.txt")
+	require.ErrorContains(t, err, "cannot tell the format")
+	_, err = formatOf("xml", "a.xml")
+	require.ErrorContains(t, err, "format must be")
+}
+
+func TestCSVMeasureMapping(t *testing.T) {
+	header := []string{"timestamp", "default.entity_id", "default.codes", "default.raw", "total", "ratio", "version", "unknown"}
+	cm, err := newCSVMeasureMapping(header, testMeasure)
+	require.NoError(t, err)
+	now := time.Now().Truncate(time.Millisecond)
+	dp, err := cm.dataPoint([]string{now.Format(time.RFC3339Nano), "svc", "[1,2]", "AQI=", "10", "", "3", "x"})
+	require.NoError(t, err)
+	want := &measurev1.DataPointValue{
+		Timestamp: timestamppb.New(now),
+		TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+			strTag("svc"), pbv1.NullTagValue, pbv1.NullTagValue,
+			{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{1, 2}}}},
+			{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte{1, 2}}},
+			pbv1.NullTagValue,
+		}}},
+		Fields: []*modelv1.FieldValue{
+			{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 10}}}, pbv1.NullFieldValue, pbv1.NullFieldValue,
+		},
+		Version: 3,
+	}
+	require.Empty(t, cmp.Diff(want, dp, protocmp.Transform()))
+
+	dp, err = cm.dataPoint([]string{"1700000000000", "svc", "", "", "", "", "", ""})
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000000000), dp.GetTimestamp().AsTime().UnixMilli())
+
+	_, err = cm.dataPoint([]string{"yesterday"})
+	require.ErrorContains(t, err, "invalid timestamp")
+	_, err = newCSVMeasureMapping([]string{"default.entity_id"}, testMeasure)
+	require.ErrorContains(t, err, "no timestamp column")
+}
+
+// chunkedSyncRecorder records the parts synced to a node with the names and the sizes of their files.
+type chunkedSyncRecorder struct {
+	parts map[string][]queue.StreamingPartData
+	sizes map[string]int
+	node  string
+}
+
+func (r *chunkedSyncRecorder) SyncStreamingParts(_ context.Context, parts []queue.StreamingPartData) (*queue.SyncResult, error) {
+	for _, p := range parts {
+		for _, f := range p.Files {
+			b, err := io.ReadAll(f.Reader)
+			if err != nil {
+				return nil, err
+			}
+			r.sizes[f.Name] += len(b)
+		}
+	}
+	r.parts[r.node] = append(r.parts[r.node], parts...)
+	return &queue.SyncResult{Success: true, PartsCount: uint32(len(parts))}, nil
+}
+
+func (r *chunkedSyncRecorder) Close() error {
+	return nil
+}
+
+// selectorLocator locates the nodes by the selector, as the node registry of the liaison does.
+type selectorLocator struct {
+	node.Selector
+}
+
+func (l selectorLocator) Locate(group, name string, shardID, replicaID uint32) (string, error) {
+	return l.Pick(group, name, shardID, replicaID)
+}
+
+func TestBuildAndSyncStream(t *testing.T) {
+	group := &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "default"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        2,
+			Replicas:        1,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		},
+	}
+	dir := t.TempDir()
+	bl, err := stream.NewBulkLoader(dir, group, testStream, nil)
+	require.NoError(t, err)
+	tg := &target{l: streamLoader{bl}, csvMapping: func(header []string) (*csvMapping, error) { return newCSVStreamMapping(header, testStream) }}
+
+	now := time.Now().Truncate(time.Millisecond)
+	var lines, records strings.Builder
+	records.WriteString("timestamp,element_id,searchable.trace_id,searchable.duration\n")
+	for i := 0; i < 100; i++ {
+		ts := now.Add(-time.Duration(i) * time.Second).Format(time.RFC3339Nano)
+		lines.WriteString(`{"elementId":"json-` + strings.Repeat("x", i) + `","timestamp":"` + ts +
+			`","tagFamilies":[{"tags":[{"str":{"value":"trace-` + ts + `"}},{"int":{"value":"1"}}]},{"tags":[{"null":null}]}]}` + "\n\n")
+		records.WriteString(ts + ",csv-" + strings.Repeat("x", i) + ",trace-" + ts + ",2\n")
+	}
+	n, err := loadNDJSON(tg.l, strings.NewReader(lines.String()))
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+	n, err = loadCSV(tg, strings.NewReader(records.String()))
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+	_, err = loadNDJSON(tg.l, strings.NewReader("{}\n"))
+	require.ErrorContains(t, err, "line 1")
+	require.NoError(t, bl.Close())
+
+	sel, err := node.NewPickFirstSelector()
+	require.NoError(t, err)
+	sel.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "data-0"}})
+	rec := &chunkedSyncRecorder{parts: make(map[string][]queue.StreamingPartData), sizes: make(map[string]int)}
+	ctrl := gomock.NewController(t)
+	client := queue.NewMockClient(ctrl)
+	client.EXPECT().NewChunkedSyncClient(gomock.Any(), uint32(1024)).DoAndReturn(func(nodeID string, _ uint32) (queue.ChunkedSyncClient, error) {
+		rec.node = nodeID
+		return rec, nil
+	}).Times(1)
+	synced, err := partsync.Sync(filepath.Join(dir, "default"), group, selectorLocator{sel}, client, 1024)
+	require.NoError(t, err)
+	require.Positive(t, synced)
+
+	parts := rec.parts["data-0"]
+	// Each part is sent to both replicas.
+	require.Len(t, parts, 2*synced)
+	var total uint64
+	for _, p := range parts {
+		require.Equal(t, "default", p.Group)
+		require.NotEmpty(t, p.Files)
+		if p.Topic == data.TopicStreamPartSync.String() {
+			total += p.TotalCount
+		}
+	}
+	require.Equal(t, uint64(2*200), total)
+	require.Positive(t, rec.sizes["primary"])
+}
@@ -0,0 +1,274 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// csvMapping binds the columns of a CSV file to the tags and the fields of a resource by the header.
+// The header names a tag of a measure or a stream as "family.tag", and a tag of a trace by its name.
+// A column missing from the file or an empty cell is null.
+type csvMapping struct {
+	tags       [][]int
+	tagTypes   [][]databasev1.TagType
+	fields     []int
+	fieldTypes []databasev1.FieldType
+	timestamp  int
+	version    int
+	elementID  int
+	span       int
+}
+
+func newCSVMeasureMapping(header []string, m *databasev1.Measure) (*csvMapping, error) {
+	cm, err := newCSVMapping(header, m.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	cm.version = indexOf(header, versionColumn)
+	for _, spec := range m.GetFields() {
+		cm.fields = append(cm.fields, indexOf(header, spec.GetName()))
+		cm.fieldTypes = append(cm.fieldTypes, spec.GetFieldType())
+	}
+	return cm, nil
+}
+
+func newCSVStreamMapping(header []string, s *databasev1.Stream) (*csvMapping, error) {
+	cm, err := newCSVMapping(header, s.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	cm.elementID = indexOf(header, elementIDColumn)
+	return cm, nil
+}
+
+func newCSVTraceMapping(header []string, t *databasev1.Trace) (*csvMapping, error) {
+	cm := &csvMapping{timestamp: -1, version: -1, elementID: -1, span: indexOf(header, spanColumn)}
+	if cm.span < 0 {
+		return nil, fmt.Errorf("file has no %s column", spanColumn)
+	}
+	tags := make([]int, 0, len(t.GetTags()))
+	types := make([]databasev1.TagType, 0, len(t.GetTags()))
+	for _, ts := range t.GetTags() {
+		tags = append(tags, indexOf(header, ts.GetName()))
+		types = append(types, ts.GetType())
+	}
+	cm.tags = [][]int{tags}
+	cm.tagTypes = [][]databasev1.TagType{types}
+	return cm, nil
+}
+
+func newCSVMapping(header []string, families []*databasev1.TagFamilySpec) (*csvMapping, error) {
+	cm := &csvMapping{timestamp: indexOf(header, timestampColumn), version: -1, elementID: -1, span: -1}
+	if cm.timestamp < 0 {
+		return nil, fmt.Errorf("file has no %s column", timestampColumn)
+	}
+	for _, fs := range families {
+		tags := make([]int, 0, len(fs.GetTags()))
+		types := make([]databasev1.TagType, 0, len(fs.GetTags()))
+		for _, ts := range fs.GetTags() {
+			tags = append(tags, indexOf(header, fs.GetName()+"."+ts.GetName()))
+			types = append(types, ts.GetType())
+		}
+		cm.tags = append(cm.tags, tags)
+		cm.tagTypes = append(cm.tagTypes, types)
+	}
+	return cm, nil
+}
+
+func indexOf(header []string, name string) int {
+	for i, h := range header {
+		if strings.TrimSpace(h) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func cell(record []string, pos int) string {
+	if pos < 0 || pos >= len(record) {
+		return ""
+	}
+	return record[pos]
+}
+
+func (cm *csvMapping) tagFamilies(record []string) ([]*modelv1.TagFamilyForWrite, error) {
+	families := make([]*modelv1.TagFamilyForWrite, 0, len(cm.tags))
+	for i, tags := range cm.tags {
+		tf := &modelv1.TagFamilyForWrite{Tags: make([]*modelv1.TagValue, 0, len(tags))}
+		for j, pos := range tags {
+			tv, err := tagFromText(cm.tagTypes[i][j], cell(record, pos))
+			if err != nil {
+				return nil, err
+			}
+			tf.Tags = append(tf.Tags, tv)
+		}
+		families = append(families, tf)
+	}
+	return families, nil
+}
+
+// dataPoint converts a record to a data point in the order of the measure schema.
+func (cm *csvMapping) dataPoint(record []string) (*measurev1.DataPointValue, error) {
+	ts, err := parseTime(cell(record, cm.timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", timestampColumn, err)
+	}
+	dp := &measurev1.DataPointValue{
+		Timestamp: timestamppb.New(ts),
+		Fields:    make([]*modelv1.FieldValue, 0, len(cm.fields)),
+	}
+	if dp.TagFamilies, err = cm.tagFamilies(record); err != nil {
+		return nil, err
+	}
+	if v := cell(record, cm.version); v != "" {
+		if dp.Version, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", versionColumn, err)
+		}
+	}
+	for i, pos := range cm.fields {
+		fv, err := fieldFromText(cm.fieldTypes[i], cell(record, pos))
+		if err != nil {
+			return nil, err
+		}
+		dp.Fields = append(dp.Fields, fv)
+	}
+	return dp, nil
+}
+
+// element converts a record to an element in the order of the stream schema.
+func (cm *csvMapping) element(record []string) (*streamv1.ElementValue, error) {
+	ts, err := parseTime(cell(record, cm.timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", timestampColumn, err)
+	}
+	e := &streamv1.ElementValue{
+		Timestamp: timestamppb.New(ts),
+		ElementId: cell(record, cm.elementID),
+	}
+	if e.TagFamilies, err = cm.tagFamilies(record); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// traceSpan converts a record to the tags in the order of the trace schema and the span.
+func (cm *csvMapping) traceSpan(record []string) ([]*modelv1.TagValue, []byte, error) {
+	families, err := cm.tagFamilies(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	span, err := base64.StdEncoding.DecodeString(cell(record, cm.span))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", spanColumn, err)
+	}
+	return families[0].GetTags(), span, nil
+}
+
+// parseTime parses a time in RFC3339, or an integer of the milliseconds since the epoch.
+func parseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// tagFromText parses a tag. The arrays are in JSON, and the binary data is in base64.
+func tagFromText(t databasev1.TagType, s string) (*modelv1.TagValue, error) {
+	if s == "" {
+		return pbv1.NullTagValue, nil
+	}
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: s}}}, nil
+	case databasev1.TagType_TAG_TYPE_INT:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}, nil
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		var arr []string
+		if err := json.Unmarshal([]byte(s), &arr); err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}, nil
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		var arr []int64
+		if err := json.Unmarshal([]byte(s), &arr); err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}, nil
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: b}}, nil
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		ts, err := parseTime(s)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(ts)}}, nil
+	}
+	return nil, fmt.Errorf("unsupported tag type %s", t)
+}
+
+// fieldFromText parses a field. The binary data is in base64.
+func fieldFromText(t databasev1.FieldType, s string) (*modelv1.FieldValue, error) {
+	if s == "" {
+		return pbv1.NullFieldValue, nil
+	}
+	switch t {
+	case databasev1.FieldType_FIELD_TYPE_STRING:
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Str{Str: &modelv1.Str{Value: s}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v}}}, nil
+	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_BinaryData{BinaryData: b}}, nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", t)
+}
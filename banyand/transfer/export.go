@@ -0,0 +1,363 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+)
+
+type exportOptions struct {
+	gRPCAddr     string
+	cert         string
+	catalog      string
+	group        string
+	name         string
+	output       string
+	codec        string
+	begin        string
+	end          string
+	window       time.Duration
+	timeout      time.Duration
+	rowGroupRows int
+	pageSize     uint32
+	enableTLS    bool
+	insecure     bool
+}
+
+func newExportCommand() *cobra.Command {
+	var opts exportOptions
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the data of a measure or a stream in a time range to a Parquet file",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return exportAction(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.gRPCAddr, "grpc-addr", "127.0.0.1:17912", "gRPC address of the liaison or the standalone server")
+	cmd.Flags().BoolVar(&opts.enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
+	cmd.Flags().BoolVar(&opts.insecure, "insecure", false, "Skip server certificate verification")
+	cmd.Flags().StringVar(&opts.cert, "cert", "", "Path to the gRPC server certificate")
+	cmd.Flags().StringVar(&opts.catalog, "catalog", catalogMeasure, "Catalog of the resource (measure|stream)")
+	cmd.Flags().StringVarP(&opts.group, "group", "g", "", "Group of the resource")
+	cmd.Flags().StringVarP(&opts.name, "name", "n", "", "Name of the measure or the stream")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "Path to the Parquet file")
+	cmd.Flags().StringVar(&opts.begin, "begin", "", "Begin of the time range in RFC3339, inclusive")
+	cmd.Flags().StringVar(&opts.end, "end", "", "End of the time range in RFC3339, exclusive. Defaults to now")
+	cmd.Flags().DurationVar(&opts.window, "window", time.Hour, "Time range of each query")
+	cmd.Flags().Uint32Var(&opts.pageSize, "page-size", 10000, "Maximum number of rows returned by each query")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", time.Minute, "Timeout of each query")
+	cmd.Flags().StringVar(&opts.codec, "codec", parquet.Zstd.String(), "Compression codec of the file (uncompressed|snappy|gzip|zstd)")
+	cmd.Flags().IntVar(&opts.rowGroupRows, "row-group-rows", 64*1024, "Number of rows in a row group")
+	return cmd
+}
+
+func exportAction(opts exportOptions) error {
+	if err := checkCatalog(opts.catalog); err != nil {
+		return err
+	}
+	if opts.group == "" || opts.name == "" {
+		return errors.New("group and name are required")
+	}
+	if opts.output == "" {
+		return errors.New("output is required")
+	}
+	if opts.window <= 0 || opts.pageSize == 0 {
+		return errors.New("window and page-size must be positive")
+	}
+	if opts.begin == "" {
+		return errors.New("begin is required")
+	}
+	begin, err := time.Parse(time.RFC3339, opts.begin)
+	if err != nil {
+		return fmt.Errorf("invalid begin: %w", err)
+	}
+	end := time.Now()
+	if opts.end != "" {
+		if end, err = time.Parse(time.RFC3339, opts.end); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	// The server accepts the time range in milliseconds.
+	begin, end = begin.Truncate(time.Millisecond), end.Truncate(time.Millisecond)
+	if !begin.Before(end) {
+		return errors.New("begin must be before end")
+	}
+	codec, err := parquet.ParseCodec(opts.codec)
+	if err != nil {
+		return err
+	}
+	_, err = snapshot.Conn(opts.gRPCAddr, opts.enableTLS, opts.insecure, opts.cert, func(conn *grpc.ClientConn) (struct{}, error) {
+		e := &exporter{conn: conn, opts: opts, begin: begin, end: end, codec: codec}
+		return struct{}{}, e.export()
+	})
+	return err
+}
+
+type exporter struct {
+	begin time.Time
+	end   time.Time
+	conn  *grpc.ClientConn
+	w     *parquet.Writer
+	opts  exportOptions
+	codec parquet.Codec
+	rows  int
+}
+
+func (e *exporter) export() error {
+	group, err := call(e.opts.timeout, func(ctx context.Context) (*commonv1.Group, error) {
+		resp, callErr := databasev1.NewGroupRegistryServiceClient(e.conn).Get(ctx, &databasev1.GroupRegistryServiceGetRequest{Group: e.opts.group})
+		return resp.GetGroup(), callErr
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get group %s: %w", e.opts.group, err)
+	}
+	rules, err := indexRules(e.conn, e.opts.timeout, e.opts.group, e.opts.name, catalogOf(e.opts.catalog))
+	if err != nil {
+		return err
+	}
+	metadata := &commonv1.Metadata{Group: e.opts.group, Name: e.opts.name}
+	if e.opts.catalog == catalogStream {
+		s, getErr := call(e.opts.timeout, func(ctx context.Context) (*databasev1.Stream, error) {
+			resp, callErr := databasev1.NewStreamRegistryServiceClient(e.conn).Get(ctx, &databasev1.StreamRegistryServiceGetRequest{Metadata: metadata})
+			return resp.GetStream(), callErr
+		})
+		if getErr != nil {
+			return fmt.Errorf("cannot get stream %s: %w", e.opts.name, getErr)
+		}
+		return e.exportStream(group, s, rules)
+	}
+	m, err := call(e.opts.timeout, func(ctx context.Context) (*databasev1.Measure, error) {
+		resp, callErr := databasev1.NewMeasureRegistryServiceClient(e.conn).Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: metadata})
+		return resp.GetMeasure(), callErr
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get measure %s: %w", e.opts.name, err)
+	}
+	return e.exportMeasure(group, m, rules)
+}
+
+// indexRules returns the index rules bound to the resource, which are needed to rebuild the index on import.
+func indexRules(conn *grpc.ClientConn, timeout time.Duration, group, name string, catalog commonv1.Catalog) ([]*databasev1.IndexRule, error) {
+	bindings, err := call(timeout, func(ctx context.Context) ([]*databasev1.IndexRuleBinding, error) {
+		resp, callErr := databasev1.NewIndexRuleBindingRegistryServiceClient(conn).List(ctx,
+			&databasev1.IndexRuleBindingRegistryServiceListRequest{Group: group})
+		return resp.GetIndexRuleBinding(), callErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list index rule bindings: %w", err)
+	}
+	names := make(map[string]struct{})
+	for _, b := range bindings {
+		if b.GetSubject().GetName() == name && b.GetSubject().GetCatalog() == catalog {
+			for _, r := range b.GetRules() {
+				names[r] = struct{}{}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	all, err := call(timeout, func(ctx context.Context) ([]*databasev1.IndexRule, error) {
+		resp, callErr := databasev1.NewIndexRuleRegistryServiceClient(conn).List(ctx, &databasev1.IndexRuleRegistryServiceListRequest{Group: group})
+		return resp.GetIndexRule(), callErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list index rules: %w", err)
+	}
+	rules := make([]*databasev1.IndexRule, 0, len(names))
+	for _, r := range all {
+		if _, ok := names[r.GetMetadata().GetName()]; ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+func (e *exporter) exportMeasure(group *commonv1.Group, m *databasev1.Measure, rules []*databasev1.IndexRule) error {
+	schema, err := measureSchema(m)
+	if err != nil {
+		return err
+	}
+	req := &measurev1.QueryRequest{
+		Groups:          []string{e.opts.group},
+		Name:            e.opts.name,
+		TagProjection:   tagProjection(m.GetTagFamilies()),
+		FieldProjection: &measurev1.QueryRequest_FieldProjection{},
+		OrderBy:         &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_ASC},
+		Limit:           e.opts.pageSize,
+	}
+	for _, f := range m.GetFields() {
+		req.FieldProjection.Names = append(req.FieldProjection.Names, f.GetName())
+	}
+	client := measurev1.NewMeasureServiceClient(e.conn)
+	return e.write(schema, group, m, rules, func(tr *modelv1.TimeRange, offset uint32) (int, error) {
+		req.TimeRange, req.Offset = tr, offset
+		dataPoints, err := call(e.opts.timeout, func(ctx context.Context) ([]*measurev1.DataPoint, error) {
+			resp, callErr := client.Query(ctx, req)
+			return resp.GetDataPoints(), callErr
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, dp := range dataPoints {
+			if err = e.w.Write(measureRow(m, dp)); err != nil {
+				return 0, err
+			}
+		}
+		return len(dataPoints), nil
+	})
+}
+
+func (e *exporter) exportStream(group *commonv1.Group, s *databasev1.Stream, rules []*databasev1.IndexRule) error {
+	schema, err := streamSchema(s)
+	if err != nil {
+		return err
+	}
+	req := &streamv1.QueryRequest{
+		Groups:     []string{e.opts.group},
+		Name:       e.opts.name,
+		Projection: tagProjection(s.GetTagFamilies()),
+		OrderBy:    &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_ASC},
+		Limit:      e.opts.pageSize,
+	}
+	client := streamv1.NewStreamServiceClient(e.conn)
+	return e.write(schema, group, s, rules, func(tr *modelv1.TimeRange, offset uint32) (int, error) {
+		req.TimeRange, req.Offset = tr, offset
+		elements, err := call(e.opts.timeout, func(ctx context.Context) ([]*streamv1.Element, error) {
+			resp, callErr := client.Query(ctx, req)
+			return resp.GetElements(), callErr
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, el := range elements {
+			if err = e.w.Write(streamRow(s, el)); err != nil {
+				return 0, err
+			}
+		}
+		return len(elements), nil
+	})
+}
+
+// write creates the output file holding the definitions in the key-value metadata,
+// and fills it by querying the pages of each time window in turn.
+func (e *exporter) write(schema *parquet.Schema, group *commonv1.Group, resource proto.Message,
+	rules []*databasev1.IndexRule, query func(tr *modelv1.TimeRange, offset uint32) (int, error),
+) (err error) {
+	groupJSON, err := protojson.Marshal(group)
+	if err != nil {
+		return err
+	}
+	schemaJSON, err := protojson.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	rulesJSON, err := marshalIndexRules(rules)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(e.opts.output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, f.Close())
+		if err != nil {
+			_ = os.Remove(e.opts.output)
+		}
+	}()
+	bw := bufio.NewWriterSize(f, 1<<20)
+	e.w, err = parquet.NewWriter(bw, schema,
+		parquet.WithCodec(e.codec),
+		parquet.WithRowGroupRows(e.opts.rowGroupRows),
+		parquet.WithKeyValue(keyCatalog, e.opts.catalog),
+		parquet.WithKeyValue(keyGroup, string(groupJSON)),
+		parquet.WithKeyValue(keySchema, string(schemaJSON)),
+		parquet.WithKeyValue(keyIndexRules, rulesJSON),
+	)
+	if err != nil {
+		return err
+	}
+	for start := e.begin; start.Before(e.end); {
+		next := start.Add(e.opts.window)
+		if next.After(e.end) {
+			next = e.end
+		}
+		// The time range of a query includes both ends.
+		tr := &modelv1.TimeRange{Begin: timestamppb.New(start), End: timestamppb.New(next.Add(-time.Millisecond))}
+		for offset := uint32(0); ; offset += e.opts.pageSize {
+			n, queryErr := query(tr, offset)
+			if queryErr != nil {
+				return fmt.Errorf("cannot query the data from %s to %s: %w", start.Format(time.RFC3339), next.Format(time.RFC3339), queryErr)
+			}
+			e.rows += n
+			if n < int(e.opts.pageSize) {
+				break
+			}
+		}
+		start = next
+	}
+	if err = e.w.Close(); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	logger.Infof("exported %d rows of %s %s/%s to %s", e.rows, e.opts.catalog, e.opts.group, e.opts.name, e.opts.output)
+	return nil
+}
+
+// call invokes a gRPC method with the timeout of a query.
+func call[T any](timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func tagProjection(specs []*databasev1.TagFamilySpec) *modelv1.TagProjection {
+	p := &modelv1.TagProjection{}
+	for _, fs := range specs {
+		tf := &modelv1.TagProjection_TagFamily{Name: fs.GetName()}
+		for _, ts := range fs.GetTags() {
+			tf.Tags = append(tf.Tags, ts.GetName())
+		}
+		p.TagFamilies = append(p.TagFamilies, tf)
+	}
+	return p
+}
@@ -0,0 +1,260 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"google.golang.org/grpc"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/backup/snapshot"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+	banyandbpath "github.com/apache/skywalking-banyandb/pkg/path"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+type importOptions struct {
+	catalog        string
+	measureRoot    string
+	streamRoot     string
+	groupFile      string
+	schemaFile     string
+	indexRulesFile string
+	gRPCAddr       string
+	cert           string
+	workDir        string
+	chunkSize      run.Bytes
+	enableTLS      bool
+	insecure       bool
+}
+
+func newImportCommand() *cobra.Command {
+	opts := importOptions{chunkSize: run.Bytes(1024 * 1024)}
+	cmd := &cobra.Command{
+		Use:   "import [flags] FILE...",
+		Short: "Import Parquet files into a running cluster, or the data directory of a stopped standalone server or data node",
+		Long: `Import Parquet files exported by the transfer tool.
+With --grpc-addr, the parts are built offline and loaded through the PartLoadService of the liaison of a running cluster,
+which hands them to the data nodes owning their shards.
+Otherwise, the parts are written into the data directory of a stopped standalone server or data node.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			var errs error
+			for _, file := range args {
+				if err := importFile(opts, file); err != nil {
+					errs = multierr.Append(errs, fmt.Errorf("cannot import %s: %w", file, err))
+				}
+			}
+			return errs
+		},
+	}
+	cmd.Flags().StringVar(&opts.catalog, "catalog", "", "Catalog of the resource (measure|stream). Defaults to the catalog of the exported file")
+	cmd.Flags().StringVar(&opts.measureRoot, "measure-root-path", "/tmp", "Root directory for measure catalog")
+	cmd.Flags().StringVar(&opts.streamRoot, "stream-root-path", "/tmp", "Root directory for stream catalog")
+	cmd.Flags().StringVar(&opts.groupFile, "group-file", "", "Path to the YAML or JSON definition of the group, overriding the one in the file")
+	cmd.Flags().StringVar(&opts.schemaFile, "schema-file", "", "Path to the YAML or JSON definition of the measure or the stream, overriding the one in the file")
+	cmd.Flags().StringVar(&opts.indexRulesFile, "index-rules-file", "", "Path to the YAML or JSON list of the index rules, overriding the ones in the file")
+	cmd.Flags().StringVar(&opts.gRPCAddr, "grpc-addr", "", "gRPC address of the liaison loading the parts into a running cluster. If empty, the parts are written into the root path")
+	cmd.Flags().BoolVar(&opts.enableTLS, "enable-tls", false, "Enable TLS for gRPC connection")
+	cmd.Flags().BoolVar(&opts.insecure, "insecure", false, "Skip server certificate verification")
+	cmd.Flags().StringVar(&opts.cert, "cert", "", "Path to the gRPC server certificate")
+	cmd.Flags().StringVar(&opts.workDir, "work-dir", os.TempDir(), "Directory holding the built parts until they are loaded into a running cluster")
+	cmd.Flags().Var(&opts.chunkSize, "chunk-size", "Size of the chunks sending the parts to the liaison")
+	return cmd
+}
+
+// loader writes the rows of a file into the data directory.
+type loader interface {
+	write(rm *rowMapping, values [][]parquet.Value, row int) error
+	writeJSON(line []byte) error
+	writeCSV(cm *csvMapping, record []string) error
+	Close() error
+}
+
+type measureLoader struct {
+	*measure.BulkLoader
+}
+
+func (l measureLoader) write(rm *rowMapping, values [][]parquet.Value, row int) error {
+	dp, err := rm.dataPoint(values, row)
+	if err != nil {
+		return err
+	}
+	return l.Write(dp)
+}
+
+type streamLoader struct {
+	*stream.BulkLoader
+}
+
+func (l streamLoader) write(rm *rowMapping, values [][]parquet.Value, row int) error {
+	e, elementID, err := rm.element(values, row)
+	if err != nil {
+		return err
+	}
+	return l.Write(e, elementID)
+}
+
+func importFile(opts importOptions, file string) (err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	r, err := parquet.OpenReader(f, info.Size())
+	if err != nil {
+		return err
+	}
+	kv := r.KeyValueMetadata()
+	catalog := opts.catalog
+	if catalog == "" {
+		catalog = kv[keyCatalog]
+	} else if kv[keyCatalog] != "" && kv[keyCatalog] != catalog && opts.schemaFile == "" {
+		return fmt.Errorf("the file is exported from a %s, which needs a schema file to be imported into a %s", kv[keyCatalog], catalog)
+	}
+	if err = checkCatalog(catalog); err != nil {
+		return err
+	}
+	group := &commonv1.Group{}
+	if err = loadDefinition(opts.groupFile, kv[keyGroup], "group", group); err != nil {
+		return err
+	}
+	rules, err := loadIndexRules(opts.indexRulesFile, kv[keyIndexRules])
+	if err != nil {
+		return err
+	}
+	var (
+		l    loader
+		rm   *rowMapping
+		root string
+		name string
+	)
+	if opts.gRPCAddr != "" {
+		if root, err = os.MkdirTemp(opts.workDir, "banyandb-import-"); err != nil {
+			return err
+		}
+		defer func() {
+			err = multierr.Append(err, os.RemoveAll(root))
+		}()
+	}
+	if catalog == catalogStream {
+		s := &databasev1.Stream{}
+		if err = loadDefinition(opts.schemaFile, kv[keySchema], "stream", s); err != nil {
+			return err
+		}
+		if rm, err = newStreamMapping(r.Schema(), s); err != nil {
+			return err
+		}
+		name = s.GetMetadata().GetName()
+		if opts.gRPCAddr == "" {
+			if root, err = dataDir(opts.streamRoot, commonv1.Catalog_CATALOG_STREAM); err != nil {
+				return err
+			}
+		}
+		bl, openErr := stream.NewBulkLoader(root, group, s, rules)
+		if openErr != nil {
+			return openErr
+		}
+		l = streamLoader{bl}
+	} else {
+		m := &databasev1.Measure{}
+		if err = loadDefinition(opts.schemaFile, kv[keySchema], "measure", m); err != nil {
+			return err
+		}
+		if rm, err = newMeasureMapping(r.Schema(), m); err != nil {
+			return err
+		}
+		name = m.GetMetadata().GetName()
+		if opts.gRPCAddr == "" {
+			if root, err = dataDir(opts.measureRoot, commonv1.Catalog_CATALOG_MEASURE); err != nil {
+				return err
+			}
+		}
+		bl, openErr := measure.NewBulkLoader(root, group, m, rules)
+		if openErr != nil {
+			return openErr
+		}
+		l = measureLoader{bl}
+	}
+	if err = writeRows(l, r, rm); err != nil {
+		_ = l.Close()
+		return err
+	}
+	if err = l.Close(); err != nil {
+		return err
+	}
+	if opts.gRPCAddr == "" {
+		logger.Infof("imported %d rows of %s from %s into %s", r.NumRows(), catalog, file, root)
+		return nil
+	}
+	_, err = snapshot.Conn(opts.gRPCAddr, opts.enableTLS, opts.insecure, opts.cert, func(conn *grpc.ClientConn) (struct{}, error) {
+		b := &builder{conn: conn, opts: buildOptions{
+			catalog:   catalog,
+			group:     group.GetMetadata().GetName(),
+			name:      name,
+			chunkSize: opts.chunkSize,
+		}}
+		return struct{}{}, b.load(filepath.Join(root, group.GetMetadata().GetName()))
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("imported %d rows of %s from %s into %s", r.NumRows(), catalog, file, opts.gRPCAddr)
+	return nil
+}
+
+func writeRows(l loader, r *parquet.Reader, rm *rowMapping) error {
+	for i := 0; i < r.NumRowGroups(); i++ {
+		values, err := r.ReadRowGroup(i, rm.columns)
+		if err != nil {
+			return err
+		}
+		for row := range values[rm.timestamp] {
+			if err = l.write(rm, values, row); err != nil {
+				return fmt.Errorf("row %d of row group %d: %w", row, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func dataDir(rootPath string, catalog commonv1.Catalog) (string, error) {
+	if rootPath == "" {
+		return "", errors.New("root path is required")
+	}
+	p, err := banyandbpath.Get(rootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p, snapshot.CatalogName(catalog), storage.DataDir), nil
+}
@@ -0,0 +1,525 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package transfer implements the tools exporting the data of measures and streams to Parquet files,
+// importing the files back into the data directories of the data nodes,
+// and building the parts offline from the input files for the data nodes.
+package transfer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// The keys of the key-value metadata holding the definitions of the exported resource.
+const (
+	keyCatalog    = "banyandb.catalog"
+	keyGroup      = "banyandb.group"
+	keySchema     = "banyandb.schema"
+	keyIndexRules = "banyandb.index_rules"
+)
+
+// The names of the columns besides the tag families and the fields.
+const (
+	timestampColumn = "timestamp"
+	versionColumn   = "version"
+	elementIDColumn = "element_id"
+	spanColumn      = "span"
+)
+
+// measureSchema maps a measure to the columns: the timestamp, the version, a group of each tag family and a column of each field.
+func measureSchema(m *databasev1.Measure) (*parquet.Schema, error) {
+	fields := []parquet.Field{
+		parquet.Leaf(timestampColumn, parquet.Int64, parquet.TimestampMillis, parquet.Required),
+		parquet.Leaf(versionColumn, parquet.Int64, parquet.NoLogicalType, parquet.Required),
+	}
+	families, err := tagFamilyFields(m.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields, families...)
+	for _, spec := range m.GetFields() {
+		f, err := fieldField(spec)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return parquet.NewSchema(m.GetMetadata().GetName(), fields...)
+}
+
+// streamSchema maps a stream to the columns: the timestamp, the element ID and a group of each tag family.
+func streamSchema(s *databasev1.Stream) (*parquet.Schema, error) {
+	fields := []parquet.Field{
+		parquet.Leaf(timestampColumn, parquet.Int64, parquet.TimestampMillis, parquet.Required),
+		parquet.Leaf(elementIDColumn, parquet.ByteArray, parquet.String, parquet.Required),
+	}
+	families, err := tagFamilyFields(s.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	return parquet.NewSchema(s.GetMetadata().GetName(), append(fields, families...)...)
+}
+
+func tagFamilyFields(specs []*databasev1.TagFamilySpec) ([]parquet.Field, error) {
+	fields := make([]parquet.Field, 0, len(specs))
+	for _, fs := range specs {
+		tags := make([]parquet.Field, 0, len(fs.GetTags()))
+		for _, ts := range fs.GetTags() {
+			f, err := tagField(ts)
+			if err != nil {
+				return nil, fmt.Errorf("tag family %s: %w", fs.GetName(), err)
+			}
+			tags = append(tags, f)
+		}
+		fields = append(fields, parquet.Group(fs.GetName(), parquet.Required, tags...))
+	}
+	return fields, nil
+}
+
+func tagField(spec *databasev1.TagSpec) (parquet.Field, error) {
+	name := spec.GetName()
+	switch spec.GetType() {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		return parquet.Leaf(name, parquet.ByteArray, parquet.String, parquet.Optional), nil
+	case databasev1.TagType_TAG_TYPE_INT:
+		return parquet.Leaf(name, parquet.Int64, parquet.NoLogicalType, parquet.Optional), nil
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		return parquet.ListOf(name, parquet.Optional, parquet.Leaf("", parquet.ByteArray, parquet.String, parquet.Required)), nil
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		return parquet.ListOf(name, parquet.Optional, parquet.Leaf("", parquet.Int64, parquet.NoLogicalType, parquet.Required)), nil
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		return parquet.Leaf(name, parquet.ByteArray, parquet.NoLogicalType, parquet.Optional), nil
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		return parquet.Leaf(name, parquet.Int64, parquet.TimestampNanos, parquet.Optional), nil
+	}
+	return parquet.Field{}, fmt.Errorf("tag %s has an unsupported type %s", name, spec.GetType())
+}
+
+func fieldField(spec *databasev1.FieldSpec) (parquet.Field, error) {
+	name := spec.GetName()
+	switch spec.GetFieldType() {
+	case databasev1.FieldType_FIELD_TYPE_STRING:
+		return parquet.Leaf(name, parquet.ByteArray, parquet.String, parquet.Optional), nil
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		return parquet.Leaf(name, parquet.Int64, parquet.NoLogicalType, parquet.Optional), nil
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		return parquet.Leaf(name, parquet.Double, parquet.NoLogicalType, parquet.Optional), nil
+	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		return parquet.Leaf(name, parquet.ByteArray, parquet.NoLogicalType, parquet.Optional), nil
+	}
+	return parquet.Field{}, fmt.Errorf("field %s has an unsupported type %s", name, spec.GetFieldType())
+}
+
+// measureRow converts a data point of the query result to a row of the measure schema.
+func measureRow(m *databasev1.Measure, dp *measurev1.DataPoint) []parquet.Value {
+	row := make([]parquet.Value, 0, 2+len(m.GetFields()))
+	row = append(row, parquet.IntValue(dp.GetTimestamp().AsTime().UnixMilli()), parquet.IntValue(dp.GetVersion()))
+	row = appendTagValues(row, m.GetTagFamilies(), dp.GetTagFamilies())
+	fields := make(map[string]*modelv1.FieldValue, len(dp.GetFields()))
+	for _, f := range dp.GetFields() {
+		fields[f.GetName()] = f.GetValue()
+	}
+	for _, spec := range m.GetFields() {
+		row = append(row, fieldToParquet(fields[spec.GetName()]))
+	}
+	return row
+}
+
+// streamRow converts an element of the query result to a row of the stream schema.
+func streamRow(s *databasev1.Stream, e *streamv1.Element) []parquet.Value {
+	row := make([]parquet.Value, 0, 2)
+	row = append(row, parquet.IntValue(e.GetTimestamp().AsTime().UnixMilli()), parquet.StringValue(e.GetElementId()))
+	return appendTagValues(row, s.GetTagFamilies(), e.GetTagFamilies())
+}
+
+func appendTagValues(row []parquet.Value, specs []*databasev1.TagFamilySpec, families []*modelv1.TagFamily) []parquet.Value {
+	values := make(map[string]map[string]*modelv1.TagValue, len(families))
+	for _, tf := range families {
+		tags := make(map[string]*modelv1.TagValue, len(tf.GetTags()))
+		for _, t := range tf.GetTags() {
+			tags[t.GetKey()] = t.GetValue()
+		}
+		values[tf.GetName()] = tags
+	}
+	for _, fs := range specs {
+		tags := values[fs.GetName()]
+		for _, ts := range fs.GetTags() {
+			row = append(row, tagToParquet(tags[ts.GetName()]))
+		}
+	}
+	return row
+}
+
+func tagToParquet(v *modelv1.TagValue) parquet.Value {
+	switch x := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return parquet.StringValue(x.Str.GetValue())
+	case *modelv1.TagValue_Int:
+		return parquet.IntValue(x.Int.GetValue())
+	case *modelv1.TagValue_StrArray:
+		list := make([]parquet.Value, 0, len(x.StrArray.GetValue()))
+		for _, s := range x.StrArray.GetValue() {
+			list = append(list, parquet.StringValue(s))
+		}
+		return parquet.ListValue(list)
+	case *modelv1.TagValue_IntArray:
+		list := make([]parquet.Value, 0, len(x.IntArray.GetValue()))
+		for _, i := range x.IntArray.GetValue() {
+			list = append(list, parquet.IntValue(i))
+		}
+		return parquet.ListValue(list)
+	case *modelv1.TagValue_BinaryData:
+		return parquet.BytesValue(x.BinaryData)
+	case *modelv1.TagValue_Timestamp:
+		return parquet.IntValue(x.Timestamp.AsTime().UnixNano())
+	}
+	return parquet.NullValue()
+}
+
+func fieldToParquet(v *modelv1.FieldValue) parquet.Value {
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Str:
+		return parquet.StringValue(x.Str.GetValue())
+	case *modelv1.FieldValue_Int:
+		return parquet.IntValue(x.Int.GetValue())
+	case *modelv1.FieldValue_Float:
+		return parquet.FloatValue(x.Float.GetValue())
+	case *modelv1.FieldValue_BinaryData:
+		return parquet.BytesValue(x.BinaryData)
+	}
+	return parquet.NullValue()
+}
+
+// rowMapping binds the columns of a file to the tags and the fields of a resource.
+// The columns are looked up by the paths of the exported schema, so the files written by other engines
+// are imported as long as they name the columns in the same way. A tag missing from the file is null.
+type rowMapping struct {
+	fileColumns []parquet.Column
+	// columns are the indexes of the file columns to read.
+	columns    []int
+	tags       [][]int
+	tagTypes   [][]databasev1.TagType
+	fields     []int
+	fieldTypes []databasev1.FieldType
+	timestamp  int
+	version    int
+	elementID  int
+	span       int
+}
+
+func newMeasureMapping(file *parquet.Schema, m *databasev1.Measure) (*rowMapping, error) {
+	rm, err := newRowMapping(file, m.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	if rm.version, err = rm.bindOptional(checkInt, versionColumn); err != nil {
+		return nil, err
+	}
+	for _, spec := range m.GetFields() {
+		ft := spec.GetFieldType()
+		idx, err := rm.bindOptional(func(c *parquet.Column) bool { return fieldColumnCompatible(c, ft) }, spec.GetName())
+		if err != nil {
+			return nil, err
+		}
+		rm.fields = append(rm.fields, idx)
+		rm.fieldTypes = append(rm.fieldTypes, ft)
+	}
+	return rm, nil
+}
+
+func newStreamMapping(file *parquet.Schema, s *databasev1.Stream) (*rowMapping, error) {
+	rm, err := newRowMapping(file, s.GetTagFamilies())
+	if err != nil {
+		return nil, err
+	}
+	if rm.elementID, err = rm.bindOptional(checkBytes, elementIDColumn); err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
+
+// newTraceMapping binds the top-level columns to the tags of a trace by their names,
+// since the tags of a trace don't belong to any family. The timestamp is one of the tags.
+func newTraceMapping(file *parquet.Schema, t *databasev1.Trace) (*rowMapping, error) {
+	rm := &rowMapping{fileColumns: file.Columns(), timestamp: -1, version: -1, elementID: -1}
+	tags := make([]int, 0, len(t.GetTags()))
+	types := make([]databasev1.TagType, 0, len(t.GetTags()))
+	for _, ts := range t.GetTags() {
+		tt := ts.GetType()
+		idx, err := rm.bindOptional(func(c *parquet.Column) bool { return tagColumnCompatible(c, tt) }, ts.GetName())
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, idx)
+		types = append(types, tt)
+	}
+	rm.tags = [][]int{tags}
+	rm.tagTypes = [][]databasev1.TagType{types}
+	var err error
+	if rm.span, err = rm.bindOptional(checkBytes, spanColumn); err != nil {
+		return nil, err
+	}
+	if rm.span < 0 {
+		return nil, fmt.Errorf("file has no %s column", spanColumn)
+	}
+	return rm, nil
+}
+
+func newRowMapping(file *parquet.Schema, families []*databasev1.TagFamilySpec) (*rowMapping, error) {
+	rm := &rowMapping{fileColumns: file.Columns(), version: -1, elementID: -1, span: -1}
+	var err error
+	if rm.timestamp, err = rm.bindOptional(checkTimestamp, timestampColumn); err != nil {
+		return nil, err
+	}
+	if rm.timestamp < 0 {
+		return nil, fmt.Errorf("file has no %s column", timestampColumn)
+	}
+	for _, fs := range families {
+		tags := make([]int, 0, len(fs.GetTags()))
+		types := make([]databasev1.TagType, 0, len(fs.GetTags()))
+		for _, ts := range fs.GetTags() {
+			tt := ts.GetType()
+			idx, err := rm.bindOptional(func(c *parquet.Column) bool { return tagColumnCompatible(c, tt) }, fs.GetName(), ts.GetName())
+			if err != nil {
+				return nil, err
+			}
+			tags = append(tags, idx)
+			types = append(types, tt)
+		}
+		rm.tags = append(rm.tags, tags)
+		rm.tagTypes = append(rm.tagTypes, types)
+	}
+	return rm, nil
+}
+
+// bindOptional returns the position of the column among the read columns, or -1 if the file doesn't have it.
+func (rm *rowMapping) bindOptional(compatible func(c *parquet.Column) bool, path ...string) (int, error) {
+	idx := -1
+	for i := range rm.fileColumns {
+		if equalPath(rm.fileColumns[i].FieldPath, path) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return -1, nil
+	}
+	c := &rm.fileColumns[idx]
+	if !compatible(c) {
+		return -1, fmt.Errorf("column %s of type %s(%s) is incompatible with the schema", c.Name(), c.Type, c.Logical)
+	}
+	rm.columns = append(rm.columns, idx)
+	return len(rm.columns) - 1, nil
+}
+
+func (rm *rowMapping) column(pos int) *parquet.Column {
+	return &rm.fileColumns[rm.columns[pos]]
+}
+
+func equalPath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func checkInt(c *parquet.Column) bool {
+	return !c.IsList() && (c.Type == parquet.Int32 || c.Type == parquet.Int64)
+}
+
+func checkBytes(c *parquet.Column) bool {
+	return !c.IsList() && (c.Type == parquet.ByteArray || c.Type == parquet.FixedLenByteArray)
+}
+
+func checkTimestamp(c *parquet.Column) bool {
+	return !c.IsList() && (c.Type == parquet.Int64 || c.Type == parquet.Int96)
+}
+
+func tagColumnCompatible(c *parquet.Column, t databasev1.TagType) bool {
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING, databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		return checkBytes(c)
+	case databasev1.TagType_TAG_TYPE_INT:
+		return checkInt(c)
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		return checkTimestamp(c)
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		return c.IsList() && c.Type == parquet.ByteArray
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		return c.IsList() && (c.Type == parquet.Int32 || c.Type == parquet.Int64)
+	}
+	return false
+}
+
+func fieldColumnCompatible(c *parquet.Column, t databasev1.FieldType) bool {
+	switch t {
+	case databasev1.FieldType_FIELD_TYPE_STRING, databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		return checkBytes(c)
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		return checkInt(c)
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		return !c.IsList() && (c.Type == parquet.Float || c.Type == parquet.Double || c.Type == parquet.Int32 || c.Type == parquet.Int64)
+	}
+	return false
+}
+
+// rowTimestamp returns the timestamp of a row. A timestamp column without a logical type is in milliseconds.
+func (rm *rowMapping) rowTimestamp(values [][]parquet.Value, row int) (*timestamppb.Timestamp, error) {
+	v := values[rm.timestamp][row]
+	if v.IsNull() {
+		return nil, fmt.Errorf("row %d has a null timestamp", row)
+	}
+	c := rm.column(rm.timestamp)
+	if !c.Logical.IsTimestamp() {
+		return timestamppb.New(time.UnixMilli(v.Int())), nil
+	}
+	return timestamppb.New(time.Unix(0, c.Logical.UnixNano(v.Int()))), nil
+}
+
+func (rm *rowMapping) tagFamilies(values [][]parquet.Value, row int) []*modelv1.TagFamilyForWrite {
+	families := make([]*modelv1.TagFamilyForWrite, 0, len(rm.tags))
+	for i, tags := range rm.tags {
+		tf := &modelv1.TagFamilyForWrite{Tags: make([]*modelv1.TagValue, 0, len(tags))}
+		for j, pos := range tags {
+			if pos < 0 {
+				tf.Tags = append(tf.Tags, pbv1.NullTagValue)
+				continue
+			}
+			tf.Tags = append(tf.Tags, tagFromParquet(rm.column(pos), rm.tagTypes[i][j], values[pos][row]))
+		}
+		families = append(families, tf)
+	}
+	return families
+}
+
+// dataPoint converts a row to a data point in the order of the measure schema.
+func (rm *rowMapping) dataPoint(values [][]parquet.Value, row int) (*measurev1.DataPointValue, error) {
+	ts, err := rm.rowTimestamp(values, row)
+	if err != nil {
+		return nil, err
+	}
+	dp := &measurev1.DataPointValue{
+		Timestamp:   ts,
+		TagFamilies: rm.tagFamilies(values, row),
+		Fields:      make([]*modelv1.FieldValue, 0, len(rm.fields)),
+	}
+	if rm.version >= 0 {
+		dp.Version = values[rm.version][row].Int()
+	}
+	for i, pos := range rm.fields {
+		if pos < 0 {
+			dp.Fields = append(dp.Fields, pbv1.NullFieldValue)
+			continue
+		}
+		dp.Fields = append(dp.Fields, fieldFromParquet(rm.fieldTypes[i], values[pos][row]))
+	}
+	return dp, nil
+}
+
+// element converts a row to an element in the order of the stream schema.
+// It returns the ID of an exported element, or zero to derive the ID from the element ID string.
+func (rm *rowMapping) element(values [][]parquet.Value, row int) (*streamv1.ElementValue, uint64, error) {
+	ts, err := rm.rowTimestamp(values, row)
+	if err != nil {
+		return nil, 0, err
+	}
+	e := &streamv1.ElementValue{
+		Timestamp:   ts,
+		TagFamilies: rm.tagFamilies(values, row),
+	}
+	if rm.elementID < 0 {
+		return e, 0, nil
+	}
+	e.ElementId = string(values[rm.elementID][row].Bytes())
+	// The exported elements keep their IDs, which the query results carry in hex.
+	if b, err := hex.DecodeString(e.ElementId); err == nil && len(b) == 8 {
+		return e, convert.BytesToUint64(b), nil
+	}
+	return e, 0, nil
+}
+
+// traceSpan converts a row to the tags in the order of the trace schema and the span.
+func (rm *rowMapping) traceSpan(values [][]parquet.Value, row int) ([]*modelv1.TagValue, []byte) {
+	return rm.tagFamilies(values, row)[0].GetTags(), values[rm.span][row].Bytes()
+}
+
+func tagFromParquet(c *parquet.Column, t databasev1.TagType, v parquet.Value) *modelv1.TagValue {
+	if v.IsNull() {
+		return pbv1.NullTagValue
+	}
+	switch t {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: string(v.Bytes())}}}
+	case databasev1.TagType_TAG_TYPE_INT:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v.Int()}}}
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		arr := make([]string, 0, len(v.List()))
+		for _, e := range v.List() {
+			arr = append(arr, string(e.Bytes()))
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		arr := make([]int64, 0, len(v.List()))
+		for _, e := range v.List() {
+			arr = append(arr, e.Int())
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: v.Bytes()}}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Unix(0, c.Logical.UnixNano(v.Int())))}}
+	}
+	return pbv1.NullTagValue
+}
+
+func fieldFromParquet(t databasev1.FieldType, v parquet.Value) *modelv1.FieldValue {
+	if v.IsNull() {
+		return pbv1.NullFieldValue
+	}
+	switch t {
+	case databasev1.FieldType_FIELD_TYPE_STRING:
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Str{Str: &modelv1.Str{Value: string(v.Bytes())}}}
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v.Int()}}}
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		f := v.Float()
+		if v.Kind() == parquet.KindInt {
+			f = float64(v.Int())
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: f}}}
+	case databasev1.FieldType_FIELD_TYPE_DATA_BINARY:
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_BinaryData{BinaryData: v.Bytes()}}
+	}
+	return pbv1.NullFieldValue
+}
@@ -0,0 +1,338 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/archive"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+var testMeasure = &databasev1.Measure{
+	Metadata: &commonv1.Metadata{Name: "service_cpm_minute", Group: "sw_metric"},
+	TagFamilies: []*databasev1.TagFamilySpec{{
+		Name: "default",
+		Tags: []*databasev1.TagSpec{
+			{Name: "entity_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "layer", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "labels", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+			{Name: "codes", Type: databasev1.TagType_TAG_TYPE_INT_ARRAY},
+			{Name: "raw", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY},
+			{Name: "start", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+		},
+	}},
+	Fields: []*databasev1.FieldSpec{
+		{Name: "total", FieldType: databasev1.FieldType_FIELD_TYPE_INT},
+		{Name: "ratio", FieldType: databasev1.FieldType_FIELD_TYPE_FLOAT},
+		{Name: "desc", FieldType: databasev1.FieldType_FIELD_TYPE_STRING},
+	},
+	Entity:   &databasev1.Entity{TagNames: []string{"entity_id"}},
+	Interval: "1m",
+}
+
+var testStream = &databasev1.Stream{
+	Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+	TagFamilies: []*databasev1.TagFamilySpec{
+		{Name: "searchable", Tags: []*databasev1.TagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+		}},
+		{Name: "data", Tags: []*databasev1.TagSpec{{Name: "data_binary", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}}},
+	},
+	Entity: &databasev1.Entity{TagNames: []string{"trace_id"}},
+}
+
+func strTag(v string) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v}}}
+}
+
+func intTag(v int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func roundTrip(t *testing.T, schema *parquet.Schema, rows [][]parquet.Value) *parquet.Reader {
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, schema)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	r, err := parquet.OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return r
+}
+
+func TestMeasureMapping(t *testing.T) {
+	schema, err := measureSchema(testMeasure)
+	require.NoError(t, err)
+	names := make([]string, 0, len(schema.Columns()))
+	for _, c := range schema.Columns() {
+		names = append(names, c.Name())
+	}
+	require.Equal(t, []string{
+		"timestamp", "version", "default.entity_id", "default.layer", "default.labels",
+		"default.codes", "default.raw", "default.start", "total", "ratio", "desc",
+	}, names)
+
+	ts := time.UnixMilli(1700000000000)
+	start := time.Unix(0, 1700000000123456789)
+	dp := &measurev1.DataPoint{
+		Timestamp: timestamppb.New(ts),
+		Version:   3,
+		TagFamilies: []*modelv1.TagFamily{{Name: "default", Tags: []*modelv1.Tag{
+			{Key: "entity_id", Value: strTag("svc")},
+			{Key: "layer", Value: intTag(2)},
+			{Key: "labels", Value: &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{"a", "b"}}}}},
+			{Key: "codes", Value: &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{}}}}},
+			{Key: "raw", Value: &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte{1, 2}}}},
+			{Key: "start", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(start)}}},
+		}}},
+		Fields: []*measurev1.DataPoint_Field{
+			{Name: "total", Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 10}}}},
+			{Name: "ratio", Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: 0.5}}}},
+		},
+	}
+	r := roundTrip(t, schema, [][]parquet.Value{measureRow(testMeasure, dp)})
+	rm, err := newMeasureMapping(r.Schema(), testMeasure)
+	require.NoError(t, err)
+	values, err := r.ReadRowGroup(0, rm.columns)
+	require.NoError(t, err)
+	got, err := rm.dataPoint(values, 0)
+	require.NoError(t, err)
+	want := &measurev1.DataPointValue{
+		Timestamp: timestamppb.New(ts),
+		Version:   3,
+		TagFamilies: []*modelv1.TagFamilyForWrite{{Tags: []*modelv1.TagValue{
+			strTag("svc"),
+			intTag(2),
+			{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: []string{"a", "b"}}}},
+			{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{}}}},
+			{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte{1, 2}}},
+			{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(start)}},
+		}}},
+		Fields: []*modelv1.FieldValue{
+			{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 10}}},
+			{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: 0.5}}},
+			pbv1.NullFieldValue,
+		},
+	}
+	require.Empty(t, cmp.Diff(want, got, protocmp.Transform()))
+}
+
+func TestStreamMapping(t *testing.T) {
+	schema, err := streamSchema(testStream)
+	require.NoError(t, err)
+	ts := time.UnixMilli(1700000000000)
+	id := uint64(0x0102030405060708)
+	e := &streamv1.Element{
+		ElementId: hex.EncodeToString(convert.Uint64ToBytes(id)),
+		Timestamp: timestamppb.New(ts),
+		TagFamilies: []*modelv1.TagFamily{{Name: "searchable", Tags: []*modelv1.Tag{
+			{Key: "trace_id", Value: strTag("trace-1")},
+		}}},
+	}
+	r := roundTrip(t, schema, [][]parquet.Value{streamRow(testStream, e)})
+	rm, err := newStreamMapping(r.Schema(), testStream)
+	require.NoError(t, err)
+	values, err := r.ReadRowGroup(0, rm.columns)
+	require.NoError(t, err)
+	got, gotID, err := rm.element(values, 0)
+	require.NoError(t, err)
+	require.Equal(t, id, gotID)
+	want := &streamv1.ElementValue{
+		ElementId: e.ElementId,
+		Timestamp: timestamppb.New(ts),
+		TagFamilies: []*modelv1.TagFamilyForWrite{
+			{Tags: []*modelv1.TagValue{strTag("trace-1"), pbv1.NullTagValue}},
+			{Tags: []*modelv1.TagValue{pbv1.NullTagValue}},
+		},
+	}
+	require.Empty(t, cmp.Diff(want, got, protocmp.Transform()))
+}
+
+func TestMappingForeignFile(t *testing.T) {
+	// A file written by another engine has a timestamp in microseconds and a free-form element ID,
+	// and it misses some of the tags.
+	schema, err := parquet.NewSchema("spark",
+		parquet.Leaf("timestamp", parquet.Int64, parquet.TimestampMicros, parquet.Required),
+		parquet.Leaf("element_id", parquet.ByteArray, parquet.String, parquet.Optional),
+		parquet.Group("searchable", parquet.Optional,
+			parquet.Leaf("trace_id", parquet.ByteArray, parquet.String, parquet.Optional),
+			parquet.Leaf("duration", parquet.Int32, parquet.NoLogicalType, parquet.Optional),
+		),
+	)
+	require.NoError(t, err)
+	r := roundTrip(t, schema, [][]parquet.Value{{
+		parquet.IntValue(1700000000000000), parquet.StringValue("span-1"), parquet.StringValue("trace-1"), parquet.IntValue(5),
+	}})
+	rm, err := newStreamMapping(r.Schema(), testStream)
+	require.NoError(t, err)
+	values, err := r.ReadRowGroup(0, rm.columns)
+	require.NoError(t, err)
+	got, gotID, err := rm.element(values, 0)
+	require.NoError(t, err)
+	require.Zero(t, gotID)
+	require.Equal(t, "span-1", got.ElementId)
+	require.Equal(t, time.UnixMilli(1700000000000).UTC(), got.Timestamp.AsTime())
+	require.Equal(t, int64(5), got.TagFamilies[0].Tags[1].GetInt().GetValue())
+	require.Equal(t, pbv1.NullTagValue, got.TagFamilies[1].Tags[0])
+
+	schema, err = parquet.NewSchema("spark",
+		parquet.Leaf("timestamp", parquet.Int64, parquet.TimestampMillis, parquet.Required),
+		parquet.Group("searchable", parquet.Required, parquet.Leaf("duration", parquet.Double, parquet.NoLogicalType, parquet.Optional)),
+	)
+	require.NoError(t, err)
+	_, err = newStreamMapping(schema, testStream)
+	require.ErrorContains(t, err, "searchable.duration")
+
+	schema, err = parquet.NewSchema("spark", parquet.Leaf("ts", parquet.Int64, parquet.TimestampMillis, parquet.Required))
+	require.NoError(t, err)
+	_, err = newStreamMapping(schema, testStream)
+	require.ErrorContains(t, err, "no timestamp column")
+}
+
+func TestImport(t *testing.T) {
+	group := &commonv1.Group{
+		Metadata: &commonv1.Metadata{Name: "default"},
+		Catalog:  commonv1.Catalog_CATALOG_STREAM,
+		ResourceOpts: &commonv1.ResourceOpts{
+			ShardNum:        2,
+			SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+			Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+		},
+	}
+	groupJSON, err := protojson.Marshal(group)
+	require.NoError(t, err)
+	schemaJSON, err := protojson.Marshal(testStream)
+	require.NoError(t, err)
+	schema, err := streamSchema(testStream)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sw.parquet")
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	w, err := parquet.NewWriter(f, schema,
+		parquet.WithKeyValue(keyCatalog, catalogStream),
+		parquet.WithKeyValue(keyGroup, string(groupJSON)),
+		parquet.WithKeyValue(keySchema, string(schemaJSON)),
+	)
+	require.NoError(t, err)
+	now := time.Now().Truncate(time.Millisecond)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.Write(streamRow(testStream, &streamv1.Element{
+			ElementId: "element",
+			Timestamp: timestamppb.New(now.Add(-time.Duration(i) * time.Second)),
+			TagFamilies: []*modelv1.TagFamily{{Name: "searchable", Tags: []*modelv1.Tag{
+				{Key: "trace_id", Value: strTag("trace")}, {Key: "duration", Value: intTag(int64(i))},
+			}}},
+		})))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	root := filepath.Join(dir, "root")
+	cmd := newImportCommand()
+	cmd.SetArgs([]string{"--stream-root-path", root, file})
+	require.NoError(t, cmd.Execute())
+	segments, err := filepath.Glob(filepath.Join(root, "stream", "data", "default", "seg-*", "shard-*", "*.snp"))
+	require.NoError(t, err)
+	require.NotEmpty(t, segments)
+
+	cmd = newImportCommand()
+	cmd.SetArgs([]string{"--measure-root-path", root, "--catalog", catalogMeasure, file})
+	require.ErrorContains(t, cmd.Execute(), "needs a schema file")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	loaded := &partLoadRecorder{dir: filepath.Join(dir, "loaded")}
+	databasev1.RegisterPartLoadServiceServer(srv, loaded)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+	workDir := filepath.Join(dir, "work")
+	require.NoError(t, os.Mkdir(workDir, 0o755))
+	cmd = newImportCommand()
+	cmd.SetArgs([]string{"--grpc-addr", lis.Addr().String(), "--work-dir", workDir, file})
+	require.NoError(t, cmd.Execute())
+	require.Equal(t, "default", loaded.group)
+	segments, err = filepath.Glob(filepath.Join(loaded.dir, "seg-*", "shard-*", "*.snp"))
+	require.NoError(t, err)
+	require.NotEmpty(t, segments)
+	// The built parts are removed once they are loaded.
+	entries, err := os.ReadDir(workDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+// partLoadRecorder extracts the archive it receives into dir.
+type partLoadRecorder struct {
+	databasev1.UnimplementedPartLoadServiceServer
+	dir   string
+	group string
+}
+
+func (r *partLoadRecorder) Load(stream databasev1.PartLoadService_LoadServer) error {
+	var buf bytes.Buffer
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetGroup() != "" {
+			r.group = req.GetGroup()
+		}
+		buf.Write(req.GetChunk())
+	}
+	if err := archive.Untar(&buf, r.dir, archive.Limits{}); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&databasev1.PartLoadResponse{Parts: 1})
+}
@@ -0,0 +1,341 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package partsync hands the parts built offline to the data nodes owning their shards.
+package partsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/sidx"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/measure"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	segmentFileExt = ".seg"
+	sidxDirName    = "sidx"
+)
+
+// Locator locates the data node of a replica of a shard.
+type Locator interface {
+	Locate(group, name string, shardID, replicaID uint32) (string, error)
+}
+
+// Sync hands the parts and the indexes under the data directory root of a group, which the bulk loaders build offline,
+// to the data nodes owning their shards through the chunked sync channel.
+// The data nodes introduce each part atomically as a synced part, into the segment of its time range.
+// It returns the number of the synced parts, counting each replica once.
+func Sync(root string, group *commonv1.Group, locator Locator, client queue.Client, chunkSize uint32) (int, error) {
+	ro := group.GetResourceOpts()
+	if ro == nil {
+		return 0, fmt.Errorf("no resource opts in group %s", group.GetMetadata().GetName())
+	}
+	ps := &partSyncer{
+		locator:        locator,
+		client:         client,
+		chunkedClients: make(map[string]queue.ChunkedSyncClient),
+		l:              logger.GetLogger("part-sync"),
+		lfs:            fs.NewLocalFileSystem(),
+		group:          group.GetMetadata().GetName(),
+		replicas:       ro.GetReplicas(),
+		chunkSize:      chunkSize,
+	}
+	defer ps.close()
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, timestamp.MinNanoTime), time.Unix(0, timestamp.MaxNanoTime))
+	interval := storage.MustToIntervalRule(ro.GetSegmentInterval())
+	var err error
+	switch group.GetCatalog() {
+	case commonv1.Catalog_CATALOG_MEASURE:
+		_, err = measure.VisitMeasuresInTimeRange(root, tr, &measureSyncVisitor{ps}, interval)
+	case commonv1.Catalog_CATALOG_STREAM:
+		_, err = stream.VisitStreamsInTimeRange(root, tr, &streamSyncVisitor{ps}, interval)
+	case commonv1.Catalog_CATALOG_TRACE:
+		_, err = trace.VisitTracesInTimeRange(root, tr, &traceSyncVisitor{ps}, interval)
+	default:
+		err = fmt.Errorf("unsupported catalog %s", group.GetCatalog())
+	}
+	return ps.synced, err
+}
+
+// partSyncer sends the parts of a shard to each replica of the shard.
+type partSyncer struct {
+	locator        Locator
+	client         queue.Client
+	chunkedClients map[string]queue.ChunkedSyncClient
+	l              *logger.Logger
+	lfs            fs.FileSystem
+	group          string
+	synced         int
+	replicas       uint32
+	chunkSize      uint32
+}
+
+// open opens the files of the parts to send, and returns the function releasing them.
+// The files are opened for each replica since a sent reader is drained.
+type openFunc func() ([]queue.StreamingPartData, func(), error)
+
+func (ps *partSyncer) sync(shardID uint32, open openFunc) error {
+	for replicaID := uint32(0); replicaID <= ps.replicas; replicaID++ {
+		nodeID, err := ps.locator.Locate(ps.group, "", shardID, replicaID)
+		if err != nil {
+			return fmt.Errorf("cannot pick the node of shard %d replica %d: %w", shardID, replicaID, err)
+		}
+		parts, release, err := open()
+		if err != nil {
+			return err
+		}
+		err = ps.syncToNode(nodeID, parts)
+		release()
+		if err != nil {
+			return err
+		}
+		if replicaID == 0 {
+			ps.synced += len(parts)
+		}
+	}
+	return nil
+}
+
+func (ps *partSyncer) syncToNode(nodeID string, parts []queue.StreamingPartData) error {
+	if len(parts) == 0 {
+		return nil
+	}
+	c, ok := ps.chunkedClients[nodeID]
+	if !ok {
+		var err error
+		if c, err = ps.client.NewChunkedSyncClient(nodeID, ps.chunkSize); err != nil {
+			return fmt.Errorf("cannot create the chunked sync client of node %s: %w", nodeID, err)
+		}
+		ps.chunkedClients[nodeID] = c
+	}
+	result, err := c.SyncStreamingParts(context.Background(), parts)
+	if err != nil {
+		return fmt.Errorf("cannot sync the parts to node %s: %w", nodeID, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("cannot sync the parts to node %s: %v", nodeID, result.FailedParts)
+	}
+	ps.l.Info().Str("node", nodeID).Str("group", ps.group).Uint32("shard", parts[0].ShardID).Str("topic", parts[0].Topic).
+		Uint32("parts", result.PartsCount).Uint64("bytes", result.TotalBytes).Int64("duration_ms", result.DurationMs).Msg("synced parts")
+	return nil
+}
+
+func (ps *partSyncer) close() {
+	for nodeID, c := range ps.chunkedClients {
+		if err := c.Close(); err != nil {
+			ps.l.Warn().Err(err).Str("node", nodeID).Msg("failed to close the chunked sync client")
+		}
+	}
+}
+
+// syncSegmentFiles sends the index segment files in the directory to the shards, which the data nodes merge into their indexes.
+func (ps *partSyncer) syncSegmentFiles(segmentTR *timestamp.TimeRange, dir string, shardIDs []common.ShardID, topic string) error {
+	var names []string
+	if ps.lfs.IsExist(dir) {
+		for _, entry := range ps.lfs.ReadDir(dir) {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), segmentFileExt) {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	for _, shardID := range shardIDs {
+		open := func() ([]queue.StreamingPartData, func(), error) {
+			files := make([]queue.FileInfo, 0, len(names))
+			opened := make([]fs.File, 0, len(names))
+			release := func() {
+				for _, f := range opened {
+					_ = f.Close()
+				}
+			}
+			for _, name := range names {
+				f, err := ps.lfs.OpenFile(filepath.Join(dir, name))
+				if err != nil {
+					release()
+					return nil, nil, fmt.Errorf("cannot open the segment file %s: %w", name, err)
+				}
+				opened = append(opened, f)
+				files = append(files, queue.FileInfo{Name: name, Reader: f.SequentialRead()})
+			}
+			return []queue.StreamingPartData{{
+				Group:        ps.group,
+				ShardID:      uint32(shardID),
+				Topic:        topic,
+				Files:        files,
+				MinTimestamp: segmentTR.Start.UnixNano(),
+				MaxTimestamp: segmentTR.End.UnixNano(),
+			}}, release, nil
+		}
+		if err := ps.sync(uint32(shardID), open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func partIDOf(partPath string) (uint64, error) {
+	id, err := strconv.ParseUint(filepath.Base(partPath), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid part directory %s: %w", partPath, err)
+	}
+	return id, nil
+}
+
+type measureSyncVisitor struct {
+	*partSyncer
+}
+
+func (v *measureSyncVisitor) VisitSeries(segmentTR *timestamp.TimeRange, seriesIndexPath string, shardIDs []common.ShardID) error {
+	return v.syncSegmentFiles(segmentTR, seriesIndexPath, shardIDs, data.TopicMeasureSeriesSync.String())
+}
+
+func (v *measureSyncVisitor) VisitPart(_ *timestamp.TimeRange, shardID common.ShardID, partPath string) error {
+	return v.sync(uint32(shardID), func() ([]queue.StreamingPartData, func(), error) {
+		pd, err := measure.ParsePartMetadata(v.lfs, partPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pd.ID, err = partIDOf(partPath); err != nil {
+			return nil, nil, err
+		}
+		files, release := measure.CreatePartFileReaderFromPath(partPath, v.lfs)
+		pd.Group, pd.ShardID, pd.Topic, pd.Files = v.group, uint32(shardID), data.TopicMeasurePartSync.String(), files
+		return []queue.StreamingPartData{pd}, release, nil
+	})
+}
+
+type streamSyncVisitor struct {
+	*partSyncer
+}
+
+func (v *streamSyncVisitor) VisitSeries(segmentTR *timestamp.TimeRange, seriesIndexPath string, shardIDs []common.ShardID) error {
+	return v.syncSegmentFiles(segmentTR, seriesIndexPath, shardIDs, data.TopicStreamSeriesSync.String())
+}
+
+func (v *streamSyncVisitor) VisitPart(_ *timestamp.TimeRange, shardID common.ShardID, partPath string) error {
+	return v.sync(uint32(shardID), func() ([]queue.StreamingPartData, func(), error) {
+		pd, err := stream.ParsePartMetadata(v.lfs, partPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pd.ID, err = partIDOf(partPath); err != nil {
+			return nil, nil, err
+		}
+		files, release := stream.CreatePartFileReaderFromPath(partPath, v.lfs)
+		pd.Group, pd.ShardID, pd.Topic, pd.Files = v.group, uint32(shardID), data.TopicStreamPartSync.String(), files
+		return []queue.StreamingPartData{pd}, release, nil
+	})
+}
+
+func (v *streamSyncVisitor) VisitElementIndex(segmentTR *timestamp.TimeRange, shardID common.ShardID, indexPath string) error {
+	return v.syncSegmentFiles(segmentTR, indexPath, []common.ShardID{shardID}, data.TopicStreamElementIndexSync.String())
+}
+
+type traceSyncVisitor struct {
+	*partSyncer
+}
+
+func (v *traceSyncVisitor) VisitSeries(segmentTR *timestamp.TimeRange, seriesIndexPath string, shardIDs []common.ShardID) error {
+	return v.syncSegmentFiles(segmentTR, seriesIndexPath, shardIDs, data.TopicTraceSeriesSync.String())
+}
+
+// VisitShard sends each core part along with the secondary index parts of the same ID,
+// which the data nodes introduce together.
+func (v *traceSyncVisitor) VisitShard(segmentTR *timestamp.TimeRange, shardID common.ShardID, shardPath string) error {
+	var sidxNames []string
+	if sidxPath := filepath.Join(shardPath, sidxDirName); v.lfs.IsExist(sidxPath) {
+		for _, entry := range v.lfs.ReadDir(sidxPath) {
+			if entry.IsDir() {
+				sidxNames = append(sidxNames, entry.Name())
+			}
+		}
+	}
+	for _, entry := range v.lfs.ReadDir(shardPath) {
+		if !entry.IsDir() || len(entry.Name()) != 16 {
+			continue
+		}
+		partID, err := strconv.ParseUint(entry.Name(), 16, 64)
+		if err != nil {
+			continue
+		}
+		partPath := filepath.Join(shardPath, entry.Name())
+		err = v.sync(uint32(shardID), func() ([]queue.StreamingPartData, func(), error) {
+			return v.openTracePart(segmentTR, uint32(shardID), partID, partPath, shardPath, sidxNames)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *traceSyncVisitor) openTracePart(segmentTR *timestamp.TimeRange, shardID uint32, partID uint64,
+	partPath, shardPath string, sidxNames []string,
+) ([]queue.StreamingPartData, func(), error) {
+	var releases []func()
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	pd, err := trace.ParsePartMetadata(v.lfs, partPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, r := trace.CreatePartFileReaderFromPath(partPath, v.lfs)
+	releases = append(releases, r)
+	pd.ID, pd.Group, pd.ShardID, pd.Topic, pd.Files, pd.PartType = partID, v.group, shardID, data.TopicTracePartSync.String(), files, trace.PartTypeCore
+	parts := []queue.StreamingPartData{pd}
+	for _, name := range sidxNames {
+		sidxPartPath := filepath.Join(shardPath, sidxDirName, name, filepath.Base(partPath))
+		if !v.lfs.IsExist(sidxPartPath) {
+			continue
+		}
+		sp, parseErr := sidx.ParsePartMetadata(v.lfs, sidxPartPath)
+		if parseErr != nil {
+			release()
+			return nil, nil, parseErr
+		}
+		files, r = sidx.CreatePartFileReaderFromPath(sidxPartPath, v.lfs)
+		releases = append(releases, r)
+		sp.ID, sp.Group, sp.ShardID, sp.Topic, sp.Files, sp.PartType = partID, v.group, shardID, data.TopicTracePartSync.String(), files, name
+		sp.MinTimestamp, sp.MaxTimestamp = segmentTR.Start.UnixNano(), segmentTR.End.UnixNano()
+		parts = append(parts, *sp)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartType < parts[j].PartType
+	})
+	return parts, release, nil
+}
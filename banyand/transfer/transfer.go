@@ -0,0 +1,156 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/config"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+const (
+	catalogMeasure = "measure"
+	catalogStream  = "stream"
+	catalogTrace   = "trace"
+)
+
+// NewCommand creates the command exporting and importing the data in Parquet files,
+// and building the parts offline from the input files for the data nodes.
+func NewCommand() *cobra.Command {
+	logging := logger.Logging{}
+	rootCmd := &cobra.Command{
+		Use:               "transfer",
+		DisableAutoGenTag: true,
+		Version:           version.Build(),
+		Short:             "Export BanyanDB data to Parquet files, import them back, and build parts offline for data nodes",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := config.Load("logging", cmd.Flags()); err != nil {
+				return err
+			}
+			return logger.Init(logging)
+		},
+	}
+	rootCmd.PersistentFlags().StringVar(&logging.Env, "logging-env", "prod", "the logging environment")
+	rootCmd.PersistentFlags().StringVar(&logging.Level, "logging-level", "info", "the root level of logging")
+	rootCmd.AddCommand(newExportCommand())
+	rootCmd.AddCommand(newImportCommand())
+	rootCmd.AddCommand(newBuildCommand())
+	return rootCmd
+}
+
+func checkCatalog(catalog string) error {
+	if catalog != catalogMeasure && catalog != catalogStream {
+		return fmt.Errorf("catalog must be %s or %s, got %q", catalogMeasure, catalogStream, catalog)
+	}
+	return nil
+}
+
+func catalogOf(catalog string) commonv1.Catalog {
+	switch catalog {
+	case catalogStream:
+		return commonv1.Catalog_CATALOG_STREAM
+	case catalogTrace:
+		return commonv1.Catalog_CATALOG_TRACE
+	}
+	return commonv1.Catalog_CATALOG_MEASURE
+}
+
+func marshalIndexRules(rules []*databasev1.IndexRule) (string, error) {
+	raw := make([]json.RawMessage, 0, len(rules))
+	for _, r := range rules {
+		b, err := protojson.Marshal(r)
+		if err != nil {
+			return "", err
+		}
+		raw = append(raw, b)
+	}
+	b, err := json.Marshal(raw)
+	return string(b), err
+}
+
+func unmarshalIndexRules(data []byte) ([]*databasev1.IndexRule, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	rules := make([]*databasev1.IndexRule, 0, len(raw))
+	for _, b := range raw {
+		r := &databasev1.IndexRule{}
+		if err := protojson.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// loadDefinition reads a message from a YAML or JSON file, or from the value of the key-value metadata if the file isn't given.
+func loadDefinition(file, value, name string, m proto.Message) error {
+	data, err := readDefinition(file, value, name)
+	if err != nil {
+		return err
+	}
+	if err = protojson.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("cannot parse the %s: %w", name, err)
+	}
+	return nil
+}
+
+func loadIndexRules(file, value string) ([]*databasev1.IndexRule, error) {
+	if file == "" && value == "" {
+		return nil, nil
+	}
+	data, err := readDefinition(file, value, "index rules")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := unmarshalIndexRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the index rules: %w", err)
+	}
+	return rules, nil
+}
+
+func readDefinition(file, value, name string) ([]byte, error) {
+	if file == "" {
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("the file has no %s, which should be given by a flag", name)
+		}
+		return []byte(value), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the %s: %w", name, err)
+	}
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		return nil, fmt.Errorf("cannot parse the %s file %s: %w", name, file, err)
+	}
+	return data, nil
+}
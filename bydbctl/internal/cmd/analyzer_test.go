@@ -0,0 +1,215 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/zenizh/go-capturer"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/bydbctl/internal/cmd"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+)
+
+var _ = Describe("AnalyzerSchema Operation", func() {
+	var addr string
+	var deferFunc func()
+	var rootCmd *cobra.Command
+	BeforeEach(func() {
+		_, addr, deferFunc = setup.EmptyStandalone(nil)
+		addr = httpSchema + addr
+		// extracting the operation of creating analyzer schema
+		rootCmd = &cobra.Command{Use: "root"}
+		cmd.RootCmdFlags(rootCmd)
+		rootCmd.SetArgs([]string{"group", "create", "-a", addr, "-f", "-"})
+		createGroup := func() string {
+			rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: group1
+catalog: CATALOG_STREAM
+resource_opts:
+  shard_num: 2
+  segment_interval:
+    unit: UNIT_DAY
+    num: 1
+  ttl:
+    unit: UNIT_DAY
+    num: 7`))
+			return capturer.CaptureStdout(func() {
+				err := rootCmd.Execute()
+				if err != nil {
+					GinkgoWriter.Printf("execution fails:%v", err)
+				}
+			})
+		}
+		Eventually(createGroup, flags.EventuallyTimeout).Should(ContainSubstring("group group1 is created"))
+		rootCmd.SetArgs([]string{"analyzer", "create", "-a", addr, "-f", "-"})
+		createAnalyzer := func() string {
+			rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name1
+  group: group1
+tokenizer:
+  type: TYPE_NGRAM
+  min_gram: 2
+  max_gram: 3
+token_filters:
+- type: TYPE_LOWERCASE`))
+			return capturer.CaptureStdout(func() {
+				err := rootCmd.Execute()
+				if err != nil {
+					GinkgoWriter.Printf("execution fails:%v", err)
+				}
+			})
+		}
+		Eventually(createAnalyzer, flags.EventuallyTimeout).Should(ContainSubstring("analyzer group1.name1 is created"))
+	})
+
+	It("get analyzer schema", func() {
+		rootCmd.SetArgs([]string{"analyzer", "get", "-g", "group1", "-n", "name1"})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		GinkgoWriter.Println(out)
+		resp := new(databasev1.AnalyzerRegistryServiceGetResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.Analyzer.Metadata.Group).To(Equal("group1"))
+		Expect(resp.Analyzer.Metadata.Name).To(Equal("name1"))
+		Expect(resp.Analyzer.Tokenizer.Type).To(Equal(databasev1.Tokenizer_TYPE_NGRAM))
+	})
+
+	It("update analyzer schema", func() {
+		rootCmd.SetArgs([]string{"analyzer", "update", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name1
+  group: group1
+tokenizer:
+  type: TYPE_EDGE_NGRAM
+  min_gram: 1
+  max_gram: 5`))
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("analyzer group1.name1 is updated"))
+		rootCmd.SetArgs([]string{"analyzer", "get", "-g", "group1", "-n", "name1"})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.AnalyzerRegistryServiceGetResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.Analyzer.Tokenizer.Type).To(Equal(databasev1.Tokenizer_TYPE_EDGE_NGRAM))
+		Expect(resp.Analyzer.TokenFilters).To(BeEmpty())
+	})
+
+	It("reject invalid analyzer schema", func() {
+		rootCmd.SetArgs([]string{"analyzer", "create", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: standard
+  group: group1
+tokenizer:
+  type: TYPE_UNICODE`))
+		Expect(rootCmd.Execute()).To(HaveOccurred())
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name2
+  group: group1
+tokenizer:
+  type: TYPE_NGRAM
+  min_gram: 3
+  max_gram: 2`))
+		Expect(rootCmd.Execute()).To(HaveOccurred())
+	})
+
+	It("reference analyzer from index rule", func() {
+		rootCmd.SetArgs([]string{"indexRule", "create", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name1
+  group: group1
+tags: ["endpoint"]
+type: TYPE_INVERTED
+analyzer: name1`))
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("indexRule group1.name1 is created"))
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name2
+  group: group1
+tags: ["endpoint"]
+type: TYPE_INVERTED
+analyzer: unknown`))
+		Expect(rootCmd.Execute()).To(HaveOccurred())
+	})
+
+	It("delete analyzer schema", func() {
+		// delete
+		rootCmd.SetArgs([]string{"analyzer", "delete", "-g", "group1", "-n", "name1"})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("analyzer group1.name1 is deleted"))
+		// get again
+		rootCmd.SetArgs([]string{"analyzer", "get", "-g", "group1", "-n", "name1"})
+		err := rootCmd.Execute()
+		Expect(err).To(MatchError("rpc error: code = NotFound desc = banyandb: resource not found"))
+	})
+
+	It("list analyzer schema", func() {
+		// create another analyzer schema for list operation
+		rootCmd.SetArgs([]string{"analyzer", "create", "-f", "-"})
+		rootCmd.SetIn(strings.NewReader(`
+metadata:
+  name: name2
+  group: group1
+tokenizer:
+  type: TYPE_CJK_BIGRAM`))
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("analyzer group1.name2 is created"))
+		// list
+		rootCmd.SetArgs([]string{"analyzer", "list", "-g", "group1"})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.AnalyzerRegistryServiceListResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.Analyzer).To(HaveLen(2))
+	})
+
+	AfterEach(func() {
+		deferFunc()
+	})
+})
@@ -0,0 +1,268 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/zenizh/go-capturer"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/bydbctl/internal/cmd"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+)
+
+var _ = Describe("Continuous Aggregation Schema Operation", func() {
+	var addr string
+	var deferFunc func()
+	var rootCmd *cobra.Command
+	execute := func(args []string, in string) string {
+		rootCmd.SetArgs(args)
+		rootCmd.SetIn(strings.NewReader(in))
+		return capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			if err != nil {
+				GinkgoWriter.Printf("execution fails:%v", err)
+			}
+		})
+	}
+	BeforeEach(func() {
+		_, addr, deferFunc = setup.EmptyStandalone(nil)
+		addr = httpSchema + addr
+		rootCmd = &cobra.Command{Use: "root"}
+		cmd.RootCmdFlags(rootCmd)
+		Eventually(func() string {
+			return execute([]string{"group", "create", "-a", addr, "-f", "-"}, `
+metadata:
+  name: group1
+catalog: CATALOG_MEASURE
+resource_opts:
+  shard_num: 2
+  segment_interval:
+    unit: UNIT_DAY
+    num: 1
+  ttl:
+    unit: UNIT_DAY
+    num: 7`)
+		}, flags.EventuallyTimeout).Should(ContainSubstring("group group1 is created"))
+		Eventually(func() string {
+			return execute([]string{"measure", "create", "-a", addr, "-f", "-"}, `
+metadata:
+  name: minute
+  group: group1
+interval: 1m
+tag_families:
+  - name: default
+    tags:
+    - name: id
+      type: TAG_TYPE_STRING
+    - name: entity_id
+      type: TAG_TYPE_STRING
+fields:
+  - name: value
+    field_type: FIELD_TYPE_INT
+    encoding_method: ENCODING_METHOD_GORILLA
+    compression_method: COMPRESSION_METHOD_ZSTD
+entity:
+  tag_names:
+  - id`)
+		}, flags.EventuallyTimeout).Should(ContainSubstring("measure group1.minute is created"))
+		Eventually(func() string {
+			return execute([]string{"measure", "create", "-a", addr, "-f", "-"}, `
+metadata:
+  name: hour
+  group: group1
+interval: 1h
+tag_families:
+  - name: default
+    tags:
+    - name: id
+      type: TAG_TYPE_STRING
+    - name: entity_id
+      type: TAG_TYPE_STRING
+fields:
+  - name: total
+    field_type: FIELD_TYPE_INT
+    encoding_method: ENCODING_METHOD_GORILLA
+    compression_method: COMPRESSION_METHOD_ZSTD
+  - name: avg
+    field_type: FIELD_TYPE_FLOAT
+    encoding_method: ENCODING_METHOD_GORILLA
+    compression_method: COMPRESSION_METHOD_ZSTD
+entity:
+  tag_names:
+  - id`)
+		}, flags.EventuallyTimeout).Should(ContainSubstring("measure group1.hour is created"))
+		Eventually(func() string {
+			return execute([]string{"continuous-agg", "create", "-a", addr, "-f", "-"}, `
+metadata:
+  name: hourly
+  group: group1
+source:
+  name: minute
+  group: group1
+source_catalog: CATALOG_MEASURE
+group_by_tag_names:
+  - id
+aggregations:
+  - function: AGGREGATION_FUNCTION_SUM
+    source_name: value
+    target_field: total
+target:
+  name: hour
+  group: group1`)
+		}, flags.EventuallyTimeout).Should(ContainSubstring("continuous aggregation group1.hourly is created"))
+	})
+
+	get := func(name string) *databasev1.ContinuousAggregationRegistryServiceGetResponse {
+		rootCmd.SetArgs([]string{"continuous-agg", "get", "-g", "group1", "-n", name})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.ContinuousAggregationRegistryServiceGetResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		return resp
+	}
+
+	It("get continuous aggregation schema", func() {
+		resp := get("hourly")
+		Expect(resp.ContinuousAggregation.Metadata.Group).To(Equal("group1"))
+		Expect(resp.ContinuousAggregation.Metadata.Name).To(Equal("hourly"))
+		Expect(resp.ContinuousAggregation.Target.Name).To(Equal("hour"))
+	})
+
+	It("update continuous aggregation schema", func() {
+		out := execute([]string{"continuous-agg", "update", "-f", "-"}, `
+metadata:
+  name: hourly
+  group: group1
+source:
+  name: minute
+  group: group1
+source_catalog: CATALOG_MEASURE
+group_by_tag_names:
+  - id
+  - entity_id
+aggregations:
+  - function: AGGREGATION_FUNCTION_SUM
+    source_name: value
+    target_field: total
+  - function: AGGREGATION_FUNCTION_MEAN
+    source_name: value
+    target_field: avg
+target:
+  name: hour
+  group: group1`)
+		Expect(out).To(ContainSubstring("continuous aggregation group1.hourly is updated"))
+		resp := get("hourly")
+		Expect(resp.ContinuousAggregation.GroupByTagNames).To(Equal([]string{"id", "entity_id"}))
+		Expect(resp.ContinuousAggregation.Aggregations).To(HaveLen(2))
+	})
+
+	It("reject the aggregation writing into a missing field", func() {
+		out := execute([]string{"continuous-agg", "create", "-f", "-"}, `
+metadata:
+  name: invalid
+  group: group1
+source:
+  name: minute
+  group: group1
+source_catalog: CATALOG_MEASURE
+group_by_tag_names:
+  - id
+aggregations:
+  - function: AGGREGATION_FUNCTION_MAX
+    source_name: value
+    target_field: max
+target:
+  name: hour
+  group: group1`)
+		Expect(out).NotTo(ContainSubstring("is created"))
+	})
+
+	It("reject the aggregation not grouped by the entity of the source", func() {
+		out := execute([]string{"continuous-agg", "create", "-f", "-"}, `
+metadata:
+  name: invalid
+  group: group1
+source:
+  name: minute
+  group: group1
+source_catalog: CATALOG_MEASURE
+group_by_tag_names:
+  - entity_id
+aggregations:
+  - function: AGGREGATION_FUNCTION_SUM
+    source_name: value
+    target_field: total
+target:
+  name: hour
+  group: group1`)
+		Expect(out).NotTo(ContainSubstring("is created"))
+	})
+
+	It("delete continuous aggregation schema", func() {
+		rootCmd.SetArgs([]string{"continuous-agg", "delete", "-g", "group1", "-n", "hourly"})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		Expect(out).To(ContainSubstring("continuous aggregation group1.hourly is deleted"))
+		rootCmd.SetArgs([]string{"continuous-agg", "get", "-g", "group1", "-n", "hourly"})
+		err := rootCmd.Execute()
+		Expect(err).To(MatchError("rpc error: code = NotFound desc = banyandb: resource not found"))
+	})
+
+	It("list continuous aggregation schema", func() {
+		out := execute([]string{"continuous-agg", "create", "-f", "-"}, `
+metadata:
+  name: hourly_count
+  group: group1
+source:
+  name: minute
+  group: group1
+source_catalog: CATALOG_MEASURE
+group_by_tag_names:
+  - id
+aggregations:
+  - function: AGGREGATION_FUNCTION_COUNT
+    target_field: total
+target:
+  name: hour
+  group: group1`)
+		Expect(out).To(ContainSubstring("continuous aggregation group1.hourly_count is created"))
+		rootCmd.SetArgs([]string{"continuous-agg", "list", "-g", "group1"})
+		out = capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		resp := new(databasev1.ContinuousAggregationRegistryServiceListResponse)
+		helpers.UnmarshalYAML([]byte(out), resp)
+		Expect(resp.ContinuousAggregation).To(HaveLen(2))
+	})
+
+	AfterEach(func() {
+		deferFunc()
+	})
+})
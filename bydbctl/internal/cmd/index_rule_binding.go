@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/pkg/version"
 )
@@ -124,7 +125,33 @@ func newIndexRuleBindingCmd() *cobra.Command {
 			}, enableTLS, insecure, cert)
 		},
 	}
-	bindNameFlag(getCmd, deleteCmd)
+	var rateLimit uint32
+	backfillCmd := &cobra.Command{
+		Use:     "backfill [-g group] -n name [--rate-limit rows]",
+		Version: version.Build(),
+		Short:   "Index the data written before a indexRuleBinding",
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
+			return rest(parseFromFlags, func(request request) (*resty.Response, error) {
+				br := &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{
+					Metadata:  &commonv1.Metadata{Group: request.group, Name: request.name},
+					RateLimit: rateLimit,
+				}
+				b, err := protojson.Marshal(br)
+				if err != nil {
+					return nil, err
+				}
+				return request.req.SetBody(b).
+					SetPathParam("name", request.name).SetPathParam("group", request.group).
+					Post(getPath("/api/v1/index-rule-binding/backfill/{group}/{name}"))
+			}, func(_ int, reqBody reqBody, respBody []byte) error {
+				fmt.Printf("indexRuleBinding %s.%s backfill is scheduled", reqBody.group, reqBody.name)
+				fmt.Println()
+				return yamlPrinter(0, reqBody, respBody)
+			}, enableTLS, insecure, cert)
+		},
+	}
+	backfillCmd.Flags().Uint32VarP(&rateLimit, "rate-limit", "", 0, "The maximum number of rows indexed per second in each shard, 0 uses the server default")
+	bindNameFlag(getCmd, deleteCmd, backfillCmd)
 
 	listCmd := &cobra.Command{
 		Use:     "list [-g group]",
@@ -139,7 +166,7 @@ func newIndexRuleBindingCmd() *cobra.Command {
 
 	bindFileFlag(createCmd, updateCmd)
 
-	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd)
-	indexRuleBindingCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd)
+	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, backfillCmd)
+	indexRuleBindingCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, backfillCmd)
 	return indexRuleBindingCmd
 }
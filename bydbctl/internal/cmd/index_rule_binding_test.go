@@ -181,6 +181,16 @@ expire_at: 2121-04-15T01:30:15.01Z`))
 		Expect(resp.IndexRuleBinding).To(HaveLen(2))
 	})
 
+	It("backfill indexRuleBinding", func() {
+		rootCmd.SetArgs([]string{"indexRuleBinding", "backfill", "-g", "group1", "-n", "name1", "--rate-limit", "100"})
+		out := capturer.CaptureStdout(func() {
+			err := rootCmd.Execute()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		GinkgoWriter.Println(out)
+		Expect(out).To(ContainSubstring("indexRuleBinding group1.name1 backfill is scheduled"))
+	})
+
 	AfterEach(func() {
 		deferFunc()
 	})
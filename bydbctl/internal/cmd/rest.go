@@ -328,30 +328,9 @@ func rest(pfn paramsFn, fn reqFn, printer printer, enableTLS bool, insecure bool
 	}
 
 	for i, r := range requests {
-		client := resty.New()
-		if enableTLS {
-			config := tls.Config{
-				// #nosec G402
-				InsecureSkipVerify: insecure,
-			}
-			if cert != "" {
-				cert, err := os.ReadFile(cert)
-				if err != nil {
-					return err
-				}
-				certPool := x509.NewCertPool()
-				if !certPool.AppendCertsFromPEM(cert) {
-					return errors.New("failed to add server's certificate")
-				}
-				config.RootCAs = certPool
-			}
-			client.SetTLSClientConfig(&config)
-		}
-		req := client.R()
-		// Add req headers.
-		authHeader := getAuthHeader()
-		if authHeader != "" {
-			req.Header.Set("Authorization", authHeader)
+		req, err := newRESTRequest(enableTLS, insecure, cert)
+		if err != nil {
+			return err
 		}
 		resp, err := fn(request{
 			reqBody: r,
@@ -379,6 +358,35 @@ func rest(pfn paramsFn, fn reqFn, printer printer, enableTLS bool, insecure bool
 	return nil
 }
 
+func newRESTRequest(enableTLS bool, insecure bool, cert string) (*resty.Request, error) {
+	client := resty.New()
+	if enableTLS {
+		config := tls.Config{
+			// #nosec G402
+			InsecureSkipVerify: insecure,
+		}
+		if cert != "" {
+			cert, err := os.ReadFile(cert)
+			if err != nil {
+				return nil, err
+			}
+			certPool := x509.NewCertPool()
+			if !certPool.AppendCertsFromPEM(cert) {
+				return nil, errors.New("failed to add server's certificate")
+			}
+			config.RootCAs = certPool
+		}
+		client.SetTLSClientConfig(&config)
+	}
+	req := client.R()
+	// Add req headers.
+	authHeader := getAuthHeader()
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	return req, nil
+}
+
 func getAuthHeader() string {
 	if username != "" {
 		return auth.GenerateBasicAuthHeader(username, password)
@@ -33,6 +33,7 @@ const (
 	streamSchemaPath = "/api/v1/stream/schema"
 	streamQueryPath  = "/api/v1/stream/data"
 	streamListPath   = "/api/v1/stream/schema/lists/{group}"
+	streamTailPath   = "/api/v1/stream/tail"
 )
 
 var streamSchemaPathWithParams = streamSchemaPath + pathTemp
@@ -158,10 +159,19 @@ func newStreamCmd() *cobra.Command {
 				}, yamlPrinter, enableTLS, insecure, cert)
 		},
 	}
-	bindFileFlag(createCmd, updateCmd, queryCmd)
+
+	tailCmd := &cobra.Command{
+		Use:     "tail -f [file|-]",
+		Version: version.Build(),
+		Short:   "Follow the elements written into a stream",
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			return tail(func() ([]reqBody, error) { return parseNameAndGroupFromYAML(cmd.InOrStdin()) }, streamTailPath, enableTLS, insecure, cert)
+		},
+	}
+	bindFileFlag(createCmd, updateCmd, queryCmd, tailCmd)
 	bindTimeRangeFlag(queryCmd)
 
-	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
-	streamCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
+	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd, tailCmd)
+	streamCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd, tailCmd)
 	return streamCmd
 }
@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const tailMaxEventSize = 16 << 20
+
+// tail sends the tail request, and prints the elements or spans pushed by the server-sent events until the tail ends.
+func tail(pfn paramsFn, path string, enableTLS bool, insecure bool, cert string) error {
+	requests, err := pfn()
+	if err != nil {
+		return err
+	}
+	if len(requests) != 1 {
+		return errors.New("please specify a single tail request")
+	}
+	req, err := newRESTRequest(enableTLS, insecure, cert)
+	if err != nil {
+		return err
+	}
+	resp, err := req.SetDoNotParseResponse(true).SetHeader("Accept", "text/event-stream").
+		SetBody(requests[0].data).Post(getPath(path))
+	if err != nil {
+		return err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+	if resp.StatusCode() != http.StatusOK {
+		msg, _ := io.ReadAll(body)
+		return fmt.Errorf("unexpected HTTP status code: %d, %s", resp.StatusCode(), strings.TrimSpace(string(msg)))
+	}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), tailMaxEventSize)
+	var event string
+	index := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			event = ""
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := []byte(strings.TrimPrefix(line, "data: "))
+			if event == "error" {
+				var st struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				}
+				if err = json.Unmarshal(data, &st); err != nil {
+					return err
+				}
+				return fmt.Errorf("the tail ends with %s: %s", st.Code, st.Message)
+			}
+			if err = yamlPrinter(index, requests[0], data); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+	return scanner.Err()
+}
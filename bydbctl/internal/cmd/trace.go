@@ -32,6 +32,7 @@ const (
 	traceSchemaPath = "/api/v1/trace/schema"
 	traceQueryPath  = "/api/v1/trace/data"
 	traceListPath   = "/api/v1/trace/schema/lists/{group}"
+	traceTailPath   = "/api/v1/trace/tail"
 )
 
 var traceSchemaPathWithParams = traceSchemaPath + pathTemp
@@ -154,9 +155,18 @@ func newTraceCmd() *cobra.Command {
 		},
 	}
 
-	bindFileFlag(createCmd, updateCmd, queryCmd)
+	tailCmd := &cobra.Command{
+		Use:     "tail -f [file|-]",
+		Version: version.Build(),
+		Short:   "Follow the spans written into a trace",
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			return tail(func() ([]reqBody, error) { return parseNameAndGroupFromYAML(cmd.InOrStdin()) }, traceTailPath, enableTLS, insecure, cert)
+		},
+	}
+
+	bindFileFlag(createCmd, updateCmd, queryCmd, tailCmd)
 	bindTimeRangeFlag(queryCmd)
-	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
-	traceCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd)
+	bindTLSRelatedFlag(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd, tailCmd)
+	traceCmd.AddCommand(getCmd, createCmd, deleteCmd, updateCmd, listCmd, queryCmd, tailCmd)
 	return traceCmd
 }
@@ -0,0 +1,160 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package archive packs the directories into the archives and unpacks them.
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Tar writes the files under the directory root to w as a tar archive, whose entries are named relative to root.
+func Tar(w io.Writer, root string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return fmt.Errorf("%s is neither a file nor a directory", path)
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// ErrLimitExceeded indicates the archive holds more files or bytes than the limits of Untar.
+var ErrLimitExceeded = errors.New("the archive exceeds the limit")
+
+// Limits bounds the files extracted from an archive. A zero value means no limit.
+type Limits struct {
+	// MaxBytes is the maximum total size of the files.
+	MaxBytes int64
+	// MaxFiles is the maximum number of the files and the directories.
+	MaxFiles int
+}
+
+// Size returns the total size of the files under the directory root, which bounds the bytes that Untar writes for its archive.
+func Size(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return walkErr
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Untar writes the files of the tar archive read from r under the directory dir.
+// It rejects the entries other than the files and the directories, and the ones pointing outside dir.
+// It fails with ErrLimitExceeded once the entries exceed the limits, leaving the files written so far.
+func Untar(r io.Reader, dir string, limits Limits) error {
+	tr := tar.NewReader(r)
+	var files int
+	var written int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("the entry %s is outside the archive", header.Name)
+		}
+		path := filepath.Join(dir, name)
+		if files++; limits.MaxFiles > 0 && files > limits.MaxFiles {
+			return fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, limits.MaxFiles)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			// the reader of the archive reads no more than the size in the header
+			if written += header.Size; limits.MaxBytes > 0 && written > limits.MaxBytes {
+				return fmt.Errorf("%w: more than %d bytes", ErrLimitExceeded, limits.MaxBytes)
+			}
+			if err = untarFile(tr, path); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("the entry %s is neither a file nor a directory", header.Name)
+		}
+	}
+}
+
+func untarFile(r io.Reader, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
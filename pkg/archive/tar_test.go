@@ -0,0 +1,81 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package archive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/archive"
+)
+
+func TestTarUntar(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "a", "b"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "empty"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a", "b", "c"), []byte("c"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "d"), bytes.Repeat([]byte("d"), 1<<16), 0o600))
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Tar(&buf, src))
+	dst := t.TempDir()
+	require.NoError(t, archive.Untar(&buf, dst, archive.Limits{MaxBytes: 1<<16 + 1, MaxFiles: 5}))
+
+	b, err := os.ReadFile(filepath.Join(dst, "a", "b", "c"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("c"), b)
+	b, err = os.ReadFile(filepath.Join(dst, "d"))
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte("d"), 1<<16), b)
+	info, err := os.Stat(filepath.Join(dst, "empty"))
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+	size, err := archive.Size(src)
+	require.NoError(t, err)
+	require.Equal(t, int64(1<<16+1), size)
+}
+
+func TestUntarLimits(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "b"), []byte("bb"), 0o600))
+	for _, limits := range []archive.Limits{{MaxBytes: 2}, {MaxFiles: 1}} {
+		var buf bytes.Buffer
+		require.NoError(t, archive.Tar(&buf, src))
+		require.ErrorIs(t, archive.Untar(&buf, t.TempDir(), limits), archive.ErrLimitExceeded)
+	}
+}
+
+func TestUntarInvalidEntries(t *testing.T) {
+	for _, header := range []*tar.Header{
+		{Name: "../escaped", Typeflag: tar.TypeReg},
+		{Name: "/absolute", Typeflag: tar.TypeReg},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, tw.WriteHeader(header))
+		require.NoError(t, tw.Close())
+		require.Error(t, archive.Untar(&buf, t.TempDir(), archive.Limits{}), header.Name)
+	}
+}
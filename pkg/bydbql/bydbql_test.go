@@ -18,6 +18,7 @@
 package bydbql_test
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -627,6 +628,35 @@ var _ = Describe("Parser", func() {
 					}
 				})
 
+				It("parses MATCH with fuzziness", func() {
+					grammar, err := ParseQuery("SELECT * FROM STREAM sw IN default WHERE message MATCH('conection', 'standard', 'OR', 1)")
+					Expect(err).To(BeNil())
+					match := grammar.Select.Where.Expr.Left.Left.Binary.Tail.Match
+					Expect(match).NotTo(BeNil())
+					Expect(*match.Operator).To(Equal("OR"))
+					Expect(*match.Fuzziness).To(Equal(int64(1)))
+				})
+
+				It("parses MATCH_PHRASE with slop and analyzer", func() {
+					grammar, err := ParseQuery("SELECT * FROM STREAM sw IN default WHERE message MATCH_PHRASE('reset by peer', 2, 'standard') HIGHLIGHT (message)")
+					Expect(err).To(BeNil())
+					stmt := grammar.Select
+					phrase := stmt.Where.Expr.Left.Left.Binary.Tail.MatchPhrase
+					Expect(phrase).NotTo(BeNil())
+					Expect(phrase.Phrase).To(Equal("reset by peer"))
+					Expect(*phrase.Slop).To(Equal(int64(2)))
+					Expect(*phrase.Analyzer).To(Equal("standard"))
+					Expect(stmt.Highlight).NotTo(BeNil())
+					Expect(stmt.Highlight.Tags).To(HaveLen(1))
+				})
+
+				It("rejects HIGHLIGHT in non-stream queries", func() {
+					grammar, err := ParseQuery("SELECT * FROM MEASURE m IN default WHERE message MATCH_PHRASE('reset by peer') HIGHLIGHT")
+					Expect(err).To(BeNil())
+					_, err = NewTransformer(nil).Transform(context.Background(), grammar)
+					Expect(err).To(MatchError(ContainSubstring("HIGHLIGHT clause is only supported in stream query")))
+				})
+
 				It("parses MATCH with integer values", func() {
 					grammar, err := ParseQuery("SELECT * FROM STREAM sw IN default WHERE code MATCH((404, 500, 503))")
 					Expect(err).To(BeNil())
@@ -3635,6 +3665,66 @@ var _ = Describe("Parser", func() {
 				Expect(stmt.Where).NotTo(BeNil())
 				Expect(stmt.Where.Expr).NotTo(BeNil())
 			})
+
+			It("parses Trace query with HAVING trace conditions", func() {
+				grammar, err := ParseQuery("SELECT * FROM TRACE sw_trace IN default TIME > '-30m' WHERE tags HAVING ('http.method=GET') " +
+					"HAVING SPAN_COUNT() > 500 AND duration(duration, microsecond) >= 2000 AND ANY(service_id = 'A' OR service_id = 'B') AND ANY(service_id = 'C') LIMIT 10")
+				Expect(err).To(BeNil())
+				Expect(grammar).NotTo(BeNil())
+
+				stmt := grammar.Select
+				Expect(stmt.Where).NotTo(BeNil())
+				Expect(stmt.Where.Expr.Left.Left.Having).NotTo(BeNil())
+				Expect(stmt.Having).NotTo(BeNil())
+				Expect(stmt.Having.Conditions).To(HaveLen(4))
+
+				spanCount := stmt.Having.Conditions[0]
+				Expect(spanCount.Function).To(Equal("SPAN_COUNT"))
+				Expect(spanCount.Tag).To(BeNil())
+				Expect(spanCount.Compare.Operator).To(Equal(">"))
+				Expect(*spanCount.Compare.Value.Integer).To(Equal(int64(500)))
+
+				duration := stmt.Having.Conditions[1]
+				Expect(duration.Function).To(Equal("duration"))
+				tagName, nameErr := duration.Tag.ToString(false)
+				Expect(nameErr).To(BeNil())
+				Expect(tagName).To(Equal("duration"))
+				Expect(*duration.Unit).To(Equal("microsecond"))
+				Expect(duration.Compare.Operator).To(Equal(">="))
+
+				Expect(stmt.Having.Conditions[2].Criteria).NotTo(BeNil())
+				Expect(stmt.Having.Conditions[2].Criteria.Right).To(HaveLen(1))
+				Expect(stmt.Having.Conditions[3].Criteria).NotTo(BeNil())
+				Expect(stmt.Limit.Value).To(Equal(10))
+			})
+
+			It("parses Trace query with LIKE and REGEXP predicates", func() {
+				grammar, err := ParseQuery("SELECT * FROM TRACE sw_trace IN default TIME > '-30m' " +
+					"WHERE endpoint_name LIKE '/api/v1/%' AND service_id regexp 'svc-[0-9]+' LIMIT 10")
+				Expect(err).To(BeNil())
+				Expect(grammar).NotTo(BeNil())
+
+				stmt := grammar.Select
+				Expect(stmt.Where).NotTo(BeNil())
+				like := stmt.Where.Expr.Left.Left.Binary
+				Expect(like).NotTo(BeNil())
+				Expect(like.Tail.Pattern).NotTo(BeNil())
+				Expect(strings.ToUpper(like.Tail.Pattern.Operator)).To(Equal("LIKE"))
+				Expect(like.Tail.Pattern.Pattern).To(Equal("/api/v1/%"))
+				Expect(stmt.Where.Expr.Left.Right).To(HaveLen(1))
+				regexp := stmt.Where.Expr.Left.Right[0].Right.Binary
+				Expect(regexp).NotTo(BeNil())
+				Expect(regexp.Tail.Pattern).NotTo(BeNil())
+				Expect(strings.ToUpper(regexp.Tail.Pattern.Operator)).To(Equal("REGEXP"))
+				Expect(regexp.Tail.Pattern.Pattern).To(Equal("svc-[0-9]+"))
+			})
+
+			It("rejects HAVING in non-trace queries", func() {
+				grammar, err := ParseQuery("SELECT * FROM STREAM sw IN default HAVING SPAN_COUNT() > 1")
+				Expect(err).To(BeNil())
+				_, err = NewTransformer(nil).Transform(context.Background(), grammar)
+				Expect(err).To(MatchError(ContainSubstring("HAVING clause is only supported in trace query")))
+			})
 		})
 	})
 
@@ -43,8 +43,10 @@ type GrammarSelectStatement struct {
 	From           *GrammarFromClause          `parser:"@@"`
 	Time           *GrammarTimeClause          `parser:"@@?"`
 	Where          *GrammarSelectWhereClause   `parser:"@@?"`
+	Having         *GrammarTraceHavingClause   `parser:"@@?"`
 	GroupBy        *GrammarGroupByClause       `parser:"@@?"`
 	OrderBy        *GrammarSelectOrderByClause `parser:"@@?"`
+	Highlight      *GrammarHighlightClause     `parser:"@@?"`
 	WithQueryTrace *GrammarWithTraceClause     `parser:"@@?"`
 	Limit          *GrammarLimitClause         `parser:"@@?"`
 	Offset         *GrammarOffsetClause        `parser:"@@?"`
@@ -158,6 +160,21 @@ type GrammarTopNWhereClause struct {
 	Expr  *GrammarAndExpr `parser:"@@"`
 }
 
+// GrammarTraceHavingClause represents HAVING clause filtering whole traces.
+type GrammarTraceHavingClause struct {
+	Having     string                   `parser:"@'HAVING'"`
+	Conditions []*GrammarTraceCondition `parser:"@@ ( 'AND' @@ )*"`
+}
+
+// GrammarTraceCondition represents a trace condition, such as SPAN_COUNT() > 500, DURATION(duration, MILLISECOND) > 2000 or ANY(service_id = 'A').
+type GrammarTraceCondition struct {
+	Function string                 `parser:"@Ident '('"`
+	Criteria *GrammarOrExpr         `parser:"( @@"`
+	Tag      *GrammarIdentifierPath `parser:"| @@"`
+	Unit     *string                `parser:"( ',' @Ident )? )? ')'"`
+	Compare  *GrammarCompareTail    `parser:"@@?"`
+}
+
 // GrammarOrExpr represents OR expression.
 type GrammarOrExpr struct {
 	Left  *GrammarAndExpr   `parser:"@@"`
@@ -196,10 +213,18 @@ type GrammarBinaryPredicate struct {
 	Tail       *GrammarBinaryPredicateTail `parser:"@@"`
 }
 
-// GrammarBinaryPredicateTail distinguishes between a MATCH suffix and a standard comparison operator.
+// GrammarBinaryPredicateTail distinguishes between a MATCH suffix, a MATCH_PHRASE suffix, a pattern and a standard comparison operator.
 type GrammarBinaryPredicateTail struct {
-	Match   *GrammarMatchTail   `parser:"  @@"`
-	Compare *GrammarCompareTail `parser:"| @@"`
+	Match       *GrammarMatchTail       `parser:"  @@"`
+	MatchPhrase *GrammarMatchPhraseTail `parser:"| @@"`
+	Pattern     *GrammarPatternTail     `parser:"| @@"`
+	Compare     *GrammarCompareTail     `parser:"| @@"`
+}
+
+// GrammarPatternTail represents the RHS of a LIKE or REGEXP predicate.
+type GrammarPatternTail struct {
+	Operator string `parser:"@( 'LIKE' | 'REGEXP' )"`
+	Pattern  string `parser:"@String"`
 }
 
 // GrammarCompareTail represents traditional binary comparison operators.
@@ -214,10 +239,21 @@ type GrammarMatchTail struct {
 	LParen     string              `parser:"@'('"`
 	Values     *GrammarMatchValues `parser:"@@"`
 	Analyzer   *string             `parser:"( ',' @String"`
-	Operator   *string             `parser:"  ( ',' @String )? )?"`
+	Operator   *string             `parser:"  ( ',' @String"`
+	Fuzziness  *int64              `parser:"    ( ',' @Int )? )? )?"`
 	RParen     string              `parser:"@')'"`
 }
 
+// GrammarMatchPhraseTail represents the RHS of a MATCH_PHRASE predicate.
+type GrammarMatchPhraseTail struct {
+	MatchPhraseToken string  `parser:"@'MATCH_PHRASE'"`
+	LParen           string  `parser:"@'('"`
+	Phrase           string  `parser:"@String"`
+	Slop             *int64  `parser:"( ',' @Int"`
+	Analyzer         *string `parser:"  ( ',' @String )? )?"`
+	RParen           string  `parser:"@')'"`
+}
+
 // GrammarInPredicate represents IN/NOT IN predicate.
 type GrammarInPredicate struct {
 	Identifier *GrammarIdentifierPath `parser:"@@"`
@@ -328,6 +364,12 @@ type GrammarOffsetClause struct {
 	Value  int    `parser:"@Int"`
 }
 
+// GrammarHighlightClause represents HIGHLIGHT clause.
+type GrammarHighlightClause struct {
+	Highlight string                   `parser:"@'HIGHLIGHT'"`
+	Tags      []*GrammarIdentifierPath `parser:"( '(' @@ ( ',' @@ )* ')' )?"`
+}
+
 // GrammarWithTraceClause represents WITH QUERY_TRACE clause.
 type GrammarWithTraceClause struct {
 	With       string `parser:"@'WITH'"`
@@ -31,7 +31,7 @@ var bydbqlKeywords = []string{
 	"IN", "ON", "STAGES", "TIME", "BETWEEN", "AND", "OR", "WHERE", "GROUP", "BY", "ORDER",
 	"ASC", "DESC", "LIMIT", "OFFSET", "WITH", "QUERY_TRACE", "SUM", "MEAN",
 	"AVG", "COUNT", "MAX", "MIN", "TAG", "FIELD", "NOT", "HAVING", "MATCH",
-	"AGGREGATE", "NULL",
+	"AGGREGATE", "NULL", "LIKE", "REGEXP", "MATCH_PHRASE", "HIGHLIGHT",
 }
 
 // Lexer and parser are initialized in init().
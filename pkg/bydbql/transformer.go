@@ -27,6 +27,7 @@ import (
 
 	"github.com/xhit/go-str2duration/v2"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
@@ -104,6 +105,12 @@ func (t *Transformer) Transform(ctx context.Context, grammar *Grammar) (*Transfo
 	if grammar.Select != nil {
 		// Extract resource type from SELECT statement
 		resourceType := grammar.Select.From.ResourceType
+		if grammar.Select.Having != nil && !strings.EqualFold(resourceType, "TRACE") {
+			return nil, fmt.Errorf("HAVING clause is only supported in trace query")
+		}
+		if grammar.Select.Highlight != nil && !strings.EqualFold(resourceType, "STREAM") {
+			return nil, fmt.Errorf("HIGHLIGHT clause is only supported in stream query")
+		}
 		switch strings.ToUpper(resourceType) {
 		case "STREAM":
 			return t.transformStreamQuery(ctx, grammar)
@@ -168,6 +175,12 @@ func (t *Transformer) transformStreamQuery(ctx context.Context, grammar *Grammar
 	// convert order by
 	orderBy := t.convertSelectOrderBy(statement.OrderBy)
 
+	// convert highlight
+	highlight, err := t.convertHighlight(statement.Highlight)
+	if err != nil {
+		return nil, err
+	}
+
 	// convert criteria
 	criteria, err := t.convertSelectCriteria(statement.Where, allTags)
 	if err != nil {
@@ -202,6 +215,7 @@ func (t *Transformer) transformStreamQuery(ctx context.Context, grammar *Grammar
 			Projection: projection,
 			Trace:      statement.WithQueryTrace != nil,
 			Stages:     stages,
+			Highlight:  highlight,
 		},
 	}, nil
 }
@@ -383,6 +397,12 @@ func (t *Transformer) transformTraceQuery(ctx context.Context, grammar *Grammar)
 		return nil, fmt.Errorf("failed to convert criteria: %w", err)
 	}
 
+	// convert trace predicate
+	tracePredicate, err := t.convertTraceHaving(statement.Having, allTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert having: %w", err)
+	}
+
 	// convert order by
 	orderBy := t.convertSelectOrderBy(statement.OrderBy)
 
@@ -404,16 +424,17 @@ func (t *Transformer) transformTraceQuery(ctx context.Context, grammar *Grammar)
 		Type:     QueryTypeTrace,
 		Original: grammar,
 		QueryRequest: &tracev1.QueryRequest{
-			Groups:        groups,
-			Name:          resourceName,
-			TimeRange:     timeRange,
-			Offset:        offset,
-			Limit:         limit,
-			OrderBy:       orderBy,
-			Criteria:      criteria,
-			TagProjection: tagProjection,
-			Trace:         statement.WithQueryTrace != nil,
-			Stages:        stages,
+			Groups:         groups,
+			Name:           resourceName,
+			TimeRange:      timeRange,
+			Offset:         offset,
+			Limit:          limit,
+			OrderBy:        orderBy,
+			Criteria:       criteria,
+			TracePredicate: tracePredicate,
+			TagProjection:  tagProjection,
+			Trace:          statement.WithQueryTrace != nil,
+			Stages:         stages,
 		},
 	}, nil
 }
@@ -600,6 +621,114 @@ func (t *Transformer) convertSelectCriteria(where *GrammarSelectWhereClause, all
 	return t.convertOrExpr(where.Expr, allTags, nil)
 }
 
+// convertTraceHaving converts the trace conditions to a trace predicate.
+// SPAN_COUNT() compares the number of spans, DURATION([tag, unit]) compares the trace duration in milliseconds,
+// and ANY(expr) requires at least one span to match the expression.
+func (t *Transformer) convertTraceHaving(having *GrammarTraceHavingClause, allTags map[string]*tagSpecWithFamily) (*tracev1.TracePredicate, error) {
+	if having == nil {
+		return nil, nil
+	}
+	predicate := &tracev1.TracePredicate{}
+	for _, cond := range having.Conditions {
+		function := strings.ToUpper(cond.Function)
+		if function == "ANY" {
+			if cond.Criteria == nil || cond.Compare != nil {
+				return nil, fmt.Errorf("ANY should only contain a span condition, such as ANY(service_id = 'A')")
+			}
+			criteria, err := t.convertOrExpr(cond.Criteria, allTags, nil)
+			if err != nil {
+				return nil, err
+			}
+			predicate.SpanCriteria = append(predicate.SpanCriteria, criteria)
+			continue
+		}
+		if cond.Criteria != nil || cond.Compare == nil || cond.Compare.Value.Integer == nil {
+			return nil, fmt.Errorf("%s should be compared with an integer", function)
+		}
+		value := *cond.Compare.Value.Integer
+		switch function {
+		case "SPAN_COUNT":
+			if cond.Tag != nil || cond.Unit != nil {
+				return nil, fmt.Errorf("SPAN_COUNT takes no argument")
+			}
+			lower, upper, err := convertTraceBounds(cond.Compare.Operator, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SPAN_COUNT condition: %w", err)
+			}
+			if lower != nil {
+				predicate.MinSpanCount = uint32(*lower)
+			}
+			if upper != nil {
+				if *upper <= 0 {
+					return nil, fmt.Errorf("SPAN_COUNT should be compared with a positive bound")
+				}
+				predicate.MaxSpanCount = uint32(*upper)
+			}
+		case "DURATION":
+			if cond.Tag != nil {
+				tagName, nameErr := cond.Tag.ToString(false)
+				if nameErr != nil {
+					return nil, nameErr
+				}
+				spec, ok := allTags[tagName]
+				if !ok {
+					return nil, fmt.Errorf("tag %s not found in schema", tagName)
+				}
+				if spec.tag.GetType() != databasev1.TagType_TAG_TYPE_INT {
+					return nil, fmt.Errorf("duration tag %s should be an integer", tagName)
+				}
+				if cond.Unit == nil {
+					return nil, fmt.Errorf("DURATION(%s) requires the unit of the tag, such as DURATION(%s, MILLISECOND)", tagName, tagName)
+				}
+				unit, ok := tracev1.DurationUnit_value["DURATION_UNIT_"+strings.ToUpper(*cond.Unit)]
+				if !ok || unit == int32(tracev1.DurationUnit_DURATION_UNIT_UNSPECIFIED) {
+					return nil, fmt.Errorf("unsupported duration unit %s, expected NANOSECOND, MICROSECOND, MILLISECOND or SECOND", *cond.Unit)
+				}
+				predicate.DurationTagName = tagName
+				predicate.DurationUnit = tracev1.DurationUnit(unit)
+			}
+			// the duration is compared in milliseconds
+			lower, upper, err := convertTraceBounds(cond.Compare.Operator, (time.Duration(value) * time.Millisecond).Nanoseconds())
+			if err != nil {
+				return nil, fmt.Errorf("invalid DURATION condition: %w", err)
+			}
+			if lower != nil {
+				predicate.MinDuration = durationpb.New(time.Duration(*lower))
+			}
+			if upper != nil {
+				if *upper <= 0 {
+					return nil, fmt.Errorf("DURATION should be compared with a positive bound")
+				}
+				predicate.MaxDuration = durationpb.New(time.Duration(*upper))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported trace condition %s, expected SPAN_COUNT, DURATION or ANY", cond.Function)
+		}
+	}
+	return predicate, nil
+}
+
+// convertTraceBounds converts a comparison to the inclusive bounds, a nil bound means unbounded.
+func convertTraceBounds(operator string, value int64) (lower, upper *int64, err error) {
+	if value < 0 {
+		return nil, nil, fmt.Errorf("negative value %d", value)
+	}
+	lessOne, plusOne := value-1, value+1
+	switch operator {
+	case "=":
+		return &value, &value, nil
+	case ">":
+		return &plusOne, nil, nil
+	case ">=":
+		return &value, nil, nil
+	case "<":
+		return nil, &lessOne, nil
+	case "<=":
+		return nil, &value, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported operator %s", operator)
+}
+
 func (t *Transformer) convertTopNAndConditions(ctx context.Context, where *GrammarTopNWhereClause, groups []string, resourceName string) ([]*modelv1.Condition, error) {
 	if where == nil || where.Expr == nil {
 		return nil, nil
@@ -917,6 +1046,14 @@ func (t *Transformer) convertBinaryPredicate(
 		return t.convertMatchPredicate(identifierName, pred.Tail.Match, accept)
 	}
 
+	if pred.Tail.MatchPhrase != nil {
+		return t.convertMatchPhrasePredicate(identifierName, pred.Tail.MatchPhrase, accept)
+	}
+
+	if pred.Tail.Pattern != nil {
+		return t.convertPatternPredicate(identifierName, pred.Tail.Pattern, accept)
+	}
+
 	if pred.Tail.Compare != nil {
 		return t.convertComparePredicate(identifierName, pred.Tail.Compare, tagSpec, accept)
 	}
@@ -924,6 +1061,73 @@ func (t *Transformer) convertBinaryPredicate(
 	return nil, errors.New("empty binary predicate tail")
 }
 
+func (t *Transformer) convertPatternPredicate(identifierName string, pattern *GrammarPatternTail, accept func(c *modelv1.Condition)) (*modelv1.Criteria, error) {
+	cond := &modelv1.Condition{
+		Name: identifierName,
+		Op:   modelv1.Condition_BINARY_OP_LIKE,
+		Value: &modelv1.TagValue{
+			Value: &modelv1.TagValue_Str{
+				Str: &modelv1.Str{Value: pattern.Pattern},
+			},
+		},
+	}
+	if strings.EqualFold(pattern.Operator, "REGEXP") {
+		cond.Op = modelv1.Condition_BINARY_OP_REGEXP
+	}
+
+	if accept != nil {
+		accept(cond)
+	}
+
+	return &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{
+			Condition: cond,
+		},
+	}, nil
+}
+
+func (t *Transformer) convertHighlight(clause *GrammarHighlightClause) (*streamv1.HighlightOption, error) {
+	if clause == nil {
+		return nil, nil
+	}
+	highlight := &streamv1.HighlightOption{}
+	for _, tag := range clause.Tags {
+		name, err := tag.ToString(false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse highlight tag: %w", err)
+		}
+		highlight.Tags = append(highlight.Tags, name)
+	}
+	return highlight, nil
+}
+
+func (t *Transformer) convertMatchPhrasePredicate(identifierName string, match *GrammarMatchPhraseTail, accept func(c *modelv1.Condition)) (*modelv1.Criteria, error) {
+	pbMatchOpt := &modelv1.Condition_MatchOption{Phrase: true}
+	if match.Slop != nil {
+		if *match.Slop < 0 {
+			return nil, fmt.Errorf("MATCH_PHRASE slop must not be negative")
+		}
+		pbMatchOpt.Slop = uint32(*match.Slop)
+	}
+	if match.Analyzer != nil {
+		pbMatchOpt.Analyzer = *match.Analyzer
+	}
+	cond := &modelv1.Condition{
+		Name:        identifierName,
+		Op:          modelv1.Condition_BINARY_OP_MATCH,
+		Value:       &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: match.Phrase}}},
+		MatchOption: pbMatchOpt,
+	}
+	if accept != nil {
+		accept(cond)
+	}
+	return &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{
+			Condition: cond,
+		},
+	}, nil
+}
+
 func (t *Transformer) convertMatchPredicate(identifierName string, match *GrammarMatchTail, accept func(c *modelv1.Condition)) (*modelv1.Criteria, error) {
 	if match.Values == nil {
 		return nil, fmt.Errorf("MATCH operator requires values")
@@ -973,7 +1177,7 @@ func (t *Transformer) convertMatchPredicate(identifierName string, match *Gramma
 		}
 	}
 
-	// set MatchOption if analyzer or operator is specified
+	// set MatchOption if analyzer, operator or fuzziness is specified
 	if match.Analyzer != nil || match.Operator != nil {
 		pbMatchOpt := &modelv1.Condition_MatchOption{}
 
@@ -989,6 +1193,13 @@ func (t *Transformer) convertMatchPredicate(identifierName string, match *Gramma
 			}
 		}
 
+		if match.Fuzziness != nil {
+			if *match.Fuzziness < 0 {
+				return nil, fmt.Errorf("MATCH fuzziness must not be negative")
+			}
+			pbMatchOpt.Fuzziness = uint32(*match.Fuzziness)
+		}
+
 		cond.MatchOption = pbMatchOpt
 	}
 
@@ -25,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/dquery"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/grpc"
@@ -40,6 +41,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/queue/sub"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
 	"github.com/apache/skywalking-banyandb/banyand/trace"
+	"github.com/apache/skywalking-banyandb/banyand/transfer/partsync"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/node"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -78,6 +80,7 @@ func newLiaisonCmd(runners ...run.Unit) *cobra.Command {
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate stream liaison service")
 	}
+	streamSVC.RegisterWriteObserver(measureSVC)
 
 	traceLiaisonNodeSel := node.NewRoundRobinSelector(data.TopicTraceWrite.String(), metaSvc)
 	traceDataNodeSel := node.NewRoundRobinSelector(data.TopicTracePartSync.String(), metaSvc)
@@ -103,7 +106,13 @@ func newLiaisonCmd(runners ...run.Unit) *cobra.Command {
 		StreamLiaisonNodeRegistry:  grpc.NewClusterNodeRegistry(data.TopicStreamWrite, tire1Client, streamLiaisonNodeSel),
 		PropertyNodeRegistry:       grpc.NewClusterNodeRegistry(data.TopicPropertyUpdate, tire2Client, propertyNodeSel),
 		TraceLiaisonNodeRegistry:   grpc.NewClusterNodeRegistry(data.TopicTraceWrite, tire1Client, traceLiaisonNodeSel),
+		MeasureDataNodeRegistry:    grpc.NewClusterNodeRegistry(data.TopicMeasurePartSync, tire2Client, measureDataNodeSel),
+		StreamDataNodeRegistry:     grpc.NewClusterNodeRegistry(data.TopicStreamPartSync, tire2Client, streamDataNodeSel),
+		TraceDataNodeRegistry:      grpc.NewClusterNodeRegistry(data.TopicTracePartSync, tire2Client, traceDataNodeSel),
 	}, metricSvc, pm, routeProviders)
+	grpcServer.SetPartSyncer(func(root string, group *commonv1.Group, registry grpc.NodeRegistry, chunkSize uint32) (int, error) {
+		return partsync.Sync(root, group, registry, tire2Client, chunkSize)
+	})
 	internalPipeline.SetMetadataRepo(metaSvc)
 	profSvc := observability.NewProfService()
 	httpServer := http.NewServer(grpcServer.GetAuthReloader())
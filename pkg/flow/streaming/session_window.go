@@ -0,0 +1,278 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package streaming
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/flow"
+)
+
+var (
+	_ flow.Operator       = (*sessionWindows)(nil)
+	_ flow.WindowAssigner = (*sessionWindows)(nil)
+)
+
+// sessionWindows groups elements into sessions. A session closes when no element arrives within the gap.
+// An element bridging several sessions merges them into one.
+type sessionWindows struct {
+	in       chan flow.StreamRecord
+	out      chan flow.StreamRecord
+	sessions []*session
+	windowState
+	flow.ComponentState
+	currentWatermark int64
+	lastFlushTime    int64
+	flushInterval    int64
+	gap              int64
+}
+
+// session is a window whose end is the timestamp of its latest element plus the gap.
+type session struct {
+	aggr  flow.MergeableAggregationOp
+	w     timeWindow
+	fired bool
+}
+
+// NewSessionWindows returns session windows which close after no element arrives within the gap.
+// The aggregation of the windows must implement flow.MergeableAggregationOp.
+func NewSessionWindows(gap time.Duration, maxFlushInterval time.Duration) flow.WindowAssigner {
+	g := gap.Milliseconds()
+	mfi := maxFlushInterval.Milliseconds()
+	it := int64(float64(g) * 0.4)
+	if it > mfi {
+		it = mfi
+	}
+	w := &sessionWindows{
+		gap:           g,
+		flushInterval: it,
+		in:            make(chan flow.StreamRecord),
+		out:           make(chan flow.StreamRecord),
+	}
+	w.initTasks()
+	return w
+}
+
+func (s *sessionWindows) In() chan<- flow.StreamRecord {
+	return s.in
+}
+
+func (s *sessionWindows) Out() <-chan flow.StreamRecord {
+	return s.out
+}
+
+func (s *sessionWindows) Setup(_ context.Context) error {
+	if s.aggregationFactory == nil {
+		return errors.New("aggregation of session windows is not specified")
+	}
+	if _, ok := s.aggregationFactory().(flow.MergeableAggregationOp); !ok {
+		return errors.New("session windows require a mergeable aggregation")
+	}
+	if s.windowCount < defaultCacheSize {
+		s.windowCount = defaultCacheSize
+	}
+	for _, cp := range s.restored {
+		aggr, ok := cp.Aggregation.(flow.MergeableAggregationOp)
+		if !ok {
+			return errors.New("session windows require a mergeable aggregation")
+		}
+		s.sessions = append(s.sessions, &session{
+			w:    timeWindow{start: cp.Start, end: cp.End},
+			aggr: aggr,
+		})
+	}
+	s.restored = nil
+	slices.SortFunc(s.sessions, func(a, b *session) int {
+		return cmp.Compare(a.w.start, b.w.start)
+	})
+	// start processing
+	s.Add(1)
+	go s.receive()
+	return nil
+}
+
+// AssignWindows assigns the window of a session only containing the given timestamp.
+func (s *sessionWindows) AssignWindows(timestamp int64) ([]flow.Window, error) {
+	if timestamp > math.MinInt64 {
+		return []flow.Window{timeWindow{
+			start: timestamp,
+			end:   timestamp + s.gap,
+		}}, nil
+	}
+	return nil, errors.New("invalid timestamp from the element")
+}
+
+// Checkpoint calls fn with the sessions in the order of their starts.
+func (s *sessionWindows) Checkpoint(fn func([]flow.WindowCheckpoint)) error {
+	return s.execute(func() {
+		checkpoints := make([]flow.WindowCheckpoint, 0, len(s.sessions))
+		for _, ss := range s.sessions {
+			checkpoints = append(checkpoints, flow.WindowCheckpoint{
+				Aggregation: ss.aggr,
+				Start:       ss.w.start,
+				End:         ss.w.end,
+			})
+		}
+		fn(checkpoints)
+	})
+}
+
+func (s *sessionWindows) receive() {
+	defer s.Done()
+	defer close(s.stopped)
+
+	for {
+		select {
+		case elem, ok := <-s.in:
+			if !ok {
+				close(s.out)
+				return
+			}
+			s.process(elem)
+		case task := <-s.tasks:
+			task()
+		}
+	}
+}
+
+func (s *sessionWindows) process(elem flow.StreamRecord) {
+	assignedWindows, err := s.AssignWindows(elem.TimestampMillis())
+	if err != nil {
+		s.errorHandler(err)
+		return
+	}
+	s.addElement(elem, assignedWindows[0].(timeWindow))
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if s.lastFlushTime == 0 {
+		s.lastFlushTime = now
+	}
+	if elem.TimestampMillis() > s.currentWatermark {
+		s.currentWatermark = elem.TimestampMillis()
+	}
+	s.fireDueSessions()
+	// flush the open sessions periodically, since a session might last for a long time.
+	if now-s.lastFlushTime > s.flushInterval {
+		s.lastFlushTime = now
+		for _, ss := range s.sessions {
+			if !ss.fired {
+				s.flushSession(ss)
+			}
+		}
+	}
+}
+
+// addElement puts the element into the sessions overlapping its window, and merges them.
+// The element starts a new session if there is no such session.
+func (s *sessionWindows) addElement(elem flow.StreamRecord, w timeWindow) {
+	var merged *session
+	kept := s.sessions[:0]
+	for _, ss := range s.sessions {
+		if ss.w.start >= w.end || w.start >= ss.w.end {
+			kept = append(kept, ss)
+			continue
+		}
+		if merged == nil {
+			merged = ss
+			continue
+		}
+		merged.w.start = min(merged.w.start, ss.w.start)
+		merged.w.end = max(merged.w.end, ss.w.end)
+		merged.aggr.Merge(ss.aggr)
+	}
+	clear(s.sessions[len(kept):])
+	s.sessions = kept
+	if merged == nil {
+		// drop if the session is late
+		if w.MaxTimestamp()+s.allowedLateness < s.currentWatermark {
+			return
+		}
+		merged = &session{
+			w:    w,
+			aggr: s.aggregationFactory().(flow.MergeableAggregationOp),
+		}
+		if s.l != nil {
+			if e := s.l.Debug(); e.Enabled() {
+				e.Stringer("window", w).Msg("create new session")
+			}
+		}
+	} else {
+		merged.w.start = min(merged.w.start, w.start)
+		merged.w.end = max(merged.w.end, w.end)
+	}
+	merged.aggr.Add([]flow.StreamRecord{elem})
+	idx, _ := slices.BinarySearchFunc(s.sessions, merged.w.start, func(ss *session, start int64) int {
+		return int(ss.w.start - start)
+	})
+	s.sessions = slices.Insert(s.sessions, idx, merged)
+	// the session is updated by a late element within the allowed lateness.
+	merged.fired = merged.w.MaxTimestamp() <= s.currentWatermark
+	if merged.fired {
+		s.flushSession(merged)
+	}
+	if len(s.sessions) > s.windowCount {
+		s.flushSession(s.sessions[0])
+		s.sessions = s.sessions[1:]
+	}
+}
+
+// fireDueSessions flushes the sessions closed by the watermark,
+// and purges them once the allowed lateness passes.
+func (s *sessionWindows) fireDueSessions() {
+	kept := s.sessions[:0]
+	for _, ss := range s.sessions {
+		if !ss.fired && ss.w.MaxTimestamp() <= s.currentWatermark {
+			s.flushSession(ss)
+			ss.fired = true
+		}
+		if ss.fired && ss.w.MaxTimestamp()+s.allowedLateness < s.currentWatermark {
+			continue
+		}
+		kept = append(kept, ss)
+	}
+	clear(s.sessions[len(kept):])
+	s.sessions = kept
+}
+
+func (s *sessionWindows) flushSession(ss *session) {
+	if !ss.aggr.Dirty() {
+		return
+	}
+	s.out <- flow.NewStreamRecord(ss.aggr.Snapshot(), ss.w.start)
+	if s.l != nil {
+		if e := s.l.Debug(); e.Enabled() {
+			e.Stringer("window", ss.w).Msg("flush session")
+		}
+	}
+}
+
+func (s *sessionWindows) Teardown(_ context.Context) error {
+	s.Wait()
+	return nil
+}
+
+func (s *sessionWindows) Exec(downstream flow.Inlet) {
+	s.Add(1)
+	go flow.Transmit(&s.ComponentState, downstream, s)
+}
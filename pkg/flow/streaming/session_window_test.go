@@ -0,0 +1,144 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package streaming
+
+import (
+	"context"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	"github.com/apache/skywalking-banyandb/pkg/flow"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+)
+
+var _ = g.Describe("Session Window", func() {
+	var (
+		baseTS   time.Time
+		snk      *slice
+		sessions *sessionWindows
+	)
+
+	at := func(d time.Duration) int64 {
+		return baseTS.Add(d).UnixMilli()
+	}
+
+	g.BeforeEach(func() {
+		baseTS = time.Unix(time.Now().Unix(), 0)
+		snk = newSlice()
+		sessions = NewSessionWindows(10*time.Second, 10*time.Second).(*sessionWindows)
+		sessions.aggregationFactory = func() flow.AggregationOp {
+			return &intSumAggregator{}
+		}
+		sessions.allowedLateness = (30 * time.Second).Milliseconds()
+		sessions.l = logger.GetLogger("sessionWindows")
+		gomega.Expect(sessions.Setup(context.TODO())).Should(gomega.Succeed())
+		gomega.Expect(snk.Setup(context.TODO())).Should(gomega.Succeed())
+		sessions.Exec(snk)
+	})
+
+	g.AfterEach(func() {
+		close(sessions.in)
+		gomega.Expect(sessions.Teardown(context.TODO())).Should(gomega.Succeed())
+	})
+
+	g.It("Should close a session after the gap", func() {
+		for _, r := range []flow.StreamRecord{
+			flow.NewStreamRecord(1, at(0)),
+			flow.NewStreamRecord(2, at(5*time.Second)),
+			flow.NewStreamRecord(4, at(30*time.Second)),
+			flow.NewStreamRecord(8, at(50*time.Second)),
+		} {
+			sessions.In() <- r
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			values := lastValues(snk.Value())
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(0), 3))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(30*time.Second), 4))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+	})
+
+	g.It("Should merge the sessions bridged by an element", func() {
+		for _, r := range []flow.StreamRecord{
+			flow.NewStreamRecord(1, at(0)),
+			flow.NewStreamRecord(2, at(14*time.Second)),
+			flow.NewStreamRecord(4, at(8*time.Second)),
+			flow.NewStreamRecord(8, at(60*time.Second)),
+		} {
+			sessions.In() <- r
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			g.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(at(0), 1+2+4))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+		gomega.Expect(lastValues(snk.Value())).ShouldNot(gomega.HaveKey(at(14 * time.Second)))
+	})
+
+	g.It("Should drop the elements after the allowed lateness", func() {
+		for _, r := range []flow.StreamRecord{
+			flow.NewStreamRecord(1, at(0)),
+			flow.NewStreamRecord(2, at(100*time.Second)),
+			flow.NewStreamRecord(4, at(time.Second)),
+			flow.NewStreamRecord(8, at(200*time.Second)),
+		} {
+			sessions.In() <- r
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			g.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(at(100*time.Second), 2))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+		gomega.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(at(0), 1))
+	})
+
+	g.It("Should resume the checkpointed sessions", func() {
+		for _, r := range []flow.StreamRecord{
+			flow.NewStreamRecord(1, at(0)),
+			flow.NewStreamRecord(2, at(5*time.Second)),
+		} {
+			sessions.In() <- r
+		}
+		var checkpoints []flow.WindowCheckpoint
+		gomega.Expect(sessions.Checkpoint(func(cps []flow.WindowCheckpoint) {
+			checkpoints = cps
+		})).Should(gomega.Succeed())
+		gomega.Expect(checkpoints).Should(gomega.HaveLen(1))
+		gomega.Expect(checkpoints[0].Start).Should(gomega.Equal(at(0)))
+
+		restored := NewSessionWindows(10*time.Second, 10*time.Second).(*sessionWindows)
+		restored.aggregationFactory = sessions.aggregationFactory
+		restored.l = sessions.l
+		restored.Restore(checkpoints)
+		restoredSnk := newSlice()
+		gomega.Expect(restored.Setup(context.TODO())).Should(gomega.Succeed())
+		gomega.Expect(restoredSnk.Setup(context.TODO())).Should(gomega.Succeed())
+		restored.Exec(restoredSnk)
+		defer func() {
+			close(restored.in)
+			gomega.Expect(restored.Teardown(context.TODO())).Should(gomega.Succeed())
+		}()
+		for _, r := range []flow.StreamRecord{
+			flow.NewStreamRecord(4, at(8*time.Second)),
+			flow.NewStreamRecord(8, at(60*time.Second)),
+		} {
+			restored.In() <- r
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			g.Expect(lastValues(restoredSnk.Value())).Should(gomega.HaveKeyWithValue(at(0), 1+2+4))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+	})
+})
@@ -41,18 +41,72 @@ const (
 var (
 	_ flow.Operator       = (*tumblingTimeWindows)(nil)
 	_ flow.WindowAssigner = (*tumblingTimeWindows)(nil)
+	_ flow.Operator       = (*slidingTimeWindows)(nil)
+	_ flow.WindowAssigner = (*slidingTimeWindows)(nil)
 	_ flow.Window         = (*timeWindow)(nil)
 
+	_ flow.CheckpointableWindows = (*tumblingTimeWindows)(nil)
+	_ flow.CheckpointableWindows = (*slidingTimeWindows)(nil)
+	_ flow.CheckpointableWindows = (*sessionWindows)(nil)
+
 	defaultCacheSize = 2
 )
 
+// windowOperator is an operator which aggregates the elements of each window.
+type windowOperator interface {
+	flow.Operator
+	flow.WindowAssigner
+	state() *windowState
+}
+
+// windowState holds the settings shared by all window operators.
+type windowState struct {
+	l                  *logger.Logger
+	aggregationFactory flow.AggregationOpFactory
+	errorHandler       func(error)
+	tasks              chan func()
+	stopped            chan struct{}
+	restored           []flow.WindowCheckpoint
+	windowCount        int
+	allowedLateness    int64
+}
+
+func (s *windowState) state() *windowState {
+	return s
+}
+
+func (s *windowState) initTasks() {
+	s.tasks = make(chan func())
+	s.stopped = make(chan struct{})
+}
+
+// execute runs the task in the goroutine processing elements, and waits for it to finish.
+func (s *windowState) execute(task func()) error {
+	done := make(chan struct{})
+	select {
+	case s.tasks <- func() {
+		defer close(done)
+		task()
+	}:
+	case <-s.stopped:
+		return errors.New("windows are closed")
+	}
+	<-done
+	return nil
+}
+
+// Restore resumes the windows when the operator is set up.
+func (s *windowState) Restore(checkpoints []flow.WindowCheckpoint) {
+	s.restored = append(s.restored, checkpoints...)
+}
+
 func (f *streamingFlow) Window(w flow.WindowAssigner) flow.WindowedFlow {
-	switch v := w.(type) {
-	case *tumblingTimeWindows:
-		v.errorHandler = f.drainErr
-		v.l = f.l
+	if v, ok := w.(windowOperator); ok {
+		st := v.state()
+		st.errorHandler = f.drainErr
+		st.l = f.l
 		f.ops = append(f.ops, v)
-	default:
+	} else {
 		f.drainErr(errors.New("window type is not supported"))
 	}
 
@@ -64,45 +118,97 @@ func (f *streamingFlow) Window(w flow.WindowAssigner) flow.WindowedFlow {
 }
 
 func (s *windowedFlow) AllowedMaxWindows(windowCnt int) flow.WindowedFlow {
-	switch v := s.wa.(type) {
-	case *tumblingTimeWindows:
-		v.windowCount = windowCnt
-	default:
+	if v, ok := s.wa.(windowOperator); ok {
+		v.state().windowCount = windowCnt
+	} else {
 		s.f.drainErr(errors.New("windowCnt is not supported"))
 	}
 	return s
 }
 
+func (s *windowedFlow) AllowedLateness(lateness time.Duration) flow.WindowedFlow {
+	if v, ok := s.wa.(windowOperator); ok {
+		v.state().allowedLateness = lateness.Milliseconds()
+	} else {
+		s.f.drainErr(errors.New("allowed lateness is not supported"))
+	}
+	return s
+}
+
+func (s *windowedFlow) Aggregate(factory flow.AggregationOpFactory) flow.Flow {
+	if v, ok := s.wa.(windowOperator); ok {
+		v.state().aggregationFactory = factory
+	} else {
+		s.f.drainErr(errors.New("aggregation is not supported by the window type"))
+	}
+	return s.f
+}
+
+// tumblingTimeWindows splits elements into fixed-size and non-overlapping windows.
 type tumblingTimeWindows struct {
-	l                  *logger.Logger
-	snapshots          *lru.Cache
-	timerHeap          *flow.DedupPriorityQueue
-	aggregationFactory flow.AggregationOpFactory
-	in                 chan flow.StreamRecord
-	out                chan flow.StreamRecord
-	errorHandler       func(error)
+	timeWindows
+}
+
+// slidingTimeWindows assigns elements to fixed-size windows, and a new window starts every slide.
+// An element belongs to size/slide windows.
+type slidingTimeWindows struct {
+	timeWindows
+}
+
+// timeWindows assigns elements to windows of windowSize which start every slide.
+// The windows are tumbling if the slide equals the size.
+type timeWindows struct {
+	snapshots *lru.Cache
+	timerHeap *flow.DedupPriorityQueue
+	in        chan flow.StreamRecord
+	out       chan flow.StreamRecord
+	windowState
 	flow.ComponentState
-	windowCount      int
 	currentWatermark int64
 	lastFlushTime    int64
 	flushInterval    int64
 	windowSize       int64
+	slide            int64
 	timerMu          sync.Mutex
 }
 
-func (s *tumblingTimeWindows) In() chan<- flow.StreamRecord {
+func (s *timeWindows) init(size, slide, maxFlushInterval time.Duration) {
+	s.windowSize = size.Milliseconds()
+	s.slide = slide.Milliseconds()
+	mfi := maxFlushInterval.Milliseconds()
+	it := int64(float64(s.slide) * 0.4)
+	if it > mfi {
+		it = mfi
+	}
+	s.flushInterval = it
+	s.timerHeap = flow.NewPriorityQueue(func(a, b interface{}) int {
+		return int(a.(*internalTimer).w.MaxTimestamp() - b.(*internalTimer).w.MaxTimestamp())
+	}, false)
+	s.in = make(chan flow.StreamRecord)
+	s.out = make(chan flow.StreamRecord)
+	s.initTasks()
+}
+
+func (s *timeWindows) In() chan<- flow.StreamRecord {
 	return s.in
 }
 
-func (s *tumblingTimeWindows) Out() <-chan flow.StreamRecord {
+func (s *timeWindows) Out() <-chan flow.StreamRecord {
 	return s.out
 }
 
-func (s *tumblingTimeWindows) Setup(_ context.Context) (err error) {
+func (s *timeWindows) Setup(_ context.Context) (err error) {
 	if s.snapshots == nil {
 		if s.windowCount <= 0 {
 			s.windowCount = defaultCacheSize
 		}
+		// keep all the windows which are open or within the allowed lateness,
+		// otherwise an evicted window would be recreated with partial results.
+		if s.slide < s.windowSize || s.allowedLateness > 0 {
+			if minCount := int((s.windowSize+s.allowedLateness+s.slide-1)/s.slide) + 1; s.windowCount < minCount {
+				s.windowCount = minCount
+			}
+		}
 		s.snapshots, err = lru.NewWithEvict(s.windowCount, func(key interface{}, value interface{}) {
 			flushed := s.flushSnapshot(key.(timeWindow), value.(flow.AggregationOp))
 			if s.l != nil {
@@ -115,6 +221,16 @@ func (s *tumblingTimeWindows) Setup(_ context.Context) (err error) {
 			return err
 		}
 	}
+	// the restored windows beyond the capacity would be evicted before the flow starts.
+	if len(s.restored) > s.windowCount {
+		s.restored = s.restored[len(s.restored)-s.windowCount:]
+	}
+	for _, cp := range s.restored {
+		w := timeWindow{start: cp.Start, end: cp.End}
+		s.snapshots.Add(w, cp.Aggregation)
+		s.eventTimeTriggerOnElement(w)
+	}
+	s.restored = nil
 	// start processing
 	s.Add(1)
 	go s.receive()
@@ -122,15 +238,40 @@ func (s *tumblingTimeWindows) Setup(_ context.Context) (err error) {
 	return
 }
 
-func (s *tumblingTimeWindows) flushSnapshot(w timeWindow, snapshot flow.AggregationOp) bool {
+// Checkpoint calls fn with the windows in the LRU cache, from the least recently used one.
+func (s *timeWindows) Checkpoint(fn func([]flow.WindowCheckpoint)) error {
+	return s.execute(func() {
+		keys := s.snapshots.Keys()
+		checkpoints := make([]flow.WindowCheckpoint, 0, len(keys))
+		for _, key := range keys {
+			if aggr, ok := s.snapshots.Peek(key); ok {
+				w := key.(timeWindow)
+				checkpoints = append(checkpoints, flow.WindowCheckpoint{
+					Aggregation: aggr.(flow.AggregationOp),
+					Start:       w.start,
+					End:         w.end,
+				})
+			}
+		}
+		fn(checkpoints)
+	})
+}
+
+// emitTimestamp returns the timestamp of the window's result, which is the start of its last slide.
+// It's the window's start if the windows are tumbling.
+func (s *timeWindows) emitTimestamp(w timeWindow) int64 {
+	return w.end - s.slide
+}
+
+func (s *timeWindows) flushSnapshot(w timeWindow, snapshot flow.AggregationOp) bool {
 	if snapshot.Dirty() {
-		s.out <- flow.NewStreamRecord(snapshot.Snapshot(), w.start)
+		s.out <- flow.NewStreamRecord(snapshot.Snapshot(), s.emitTimestamp(w))
 		return true
 	}
 	return false
 }
 
-func (s *tumblingTimeWindows) flushWindow(w timeWindow) {
+func (s *timeWindows) flushWindow(w timeWindow) {
 	if snapshot, ok := s.snapshots.Get(w); ok {
 		flushed := s.flushSnapshot(w, snapshot.(flow.AggregationOp))
 		if s.l != nil {
@@ -142,7 +283,7 @@ func (s *tumblingTimeWindows) flushWindow(w timeWindow) {
 	}
 }
 
-func (s *tumblingTimeWindows) flushDueWindows() {
+func (s *timeWindows) flushDueWindows() {
 	s.timerMu.Lock()
 	defer s.timerMu.Unlock()
 	for {
@@ -157,26 +298,47 @@ func (s *tumblingTimeWindows) flushDueWindows() {
 	}
 }
 
-func (s *tumblingTimeWindows) flushDirtyWindows() {
+func (s *timeWindows) flushDirtyWindows() {
 	for _, key := range s.snapshots.Keys() {
-		s.flushWindow(key.(timeWindow))
+		w := key.(timeWindow)
+		// a sliding window whose last slide hasn't begun would be written ahead of the data.
+		if s.emitTimestamp(w) > s.currentWatermark {
+			continue
+		}
+		s.flushWindow(w)
 	}
 }
 
-func (s *tumblingTimeWindows) receive() {
+func (s *timeWindows) receive() {
 	defer s.Done()
+	defer close(s.stopped)
 
-	for elem := range s.in {
-		assignedWindow, err := s.AssignWindows(elem.TimestampMillis())
-		if err != nil {
-			s.errorHandler(err)
-			continue
+	for {
+		select {
+		case elem, ok := <-s.in:
+			if !ok {
+				close(s.out)
+				return
+			}
+			s.process(elem)
+		case task := <-s.tasks:
+			task()
 		}
+	}
+}
+
+func (s *timeWindows) process(elem flow.StreamRecord) {
+	assignedWindows, err := s.AssignWindows(elem.TimestampMillis())
+	if err != nil {
+		s.errorHandler(err)
+		return
+	}
+	for _, assignedWindow := range assignedWindows {
+		tw := assignedWindow.(timeWindow)
 		// drop if the window is late
-		if s.isWindowLate(assignedWindow) {
+		if s.isWindowLate(tw) {
 			continue
 		}
-		tw := assignedWindow.(timeWindow)
 		// add elem to the bucket
 		if oldAggr, ok := s.snapshots.Get(tw); ok {
 			oldAggr.(flow.AggregationOp).Add([]flow.StreamRecord{elem})
@@ -196,79 +358,82 @@ func (s *tumblingTimeWindows) receive() {
 		if result == fire {
 			s.flushWindow(tw)
 		}
+	}
 
-		// even if the incoming elements do not follow strict order,
-		// the watermark could increase monotonically.
-		now := time.Now().UnixNano() / int64(time.Millisecond)
-		pastDataDur := elem.TimestampMillis() - s.currentWatermark
-		if s.lastFlushTime == 0 {
-			s.lastFlushTime = now
-		}
-		pastDur := now - s.lastFlushTime
-		if pastDur > 0 || pastDataDur > 0 {
-			previousWaterMark := s.currentWatermark
+	// even if the incoming elements do not follow strict order,
+	// the watermark could increase monotonically.
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	pastDataDur := elem.TimestampMillis() - s.currentWatermark
+	if s.lastFlushTime == 0 {
+		s.lastFlushTime = now
+	}
+	pastDur := now - s.lastFlushTime
+	if pastDur > 0 || pastDataDur > 0 {
+		previousWaterMark := s.currentWatermark
+		// the watermark never goes back if late elements are allowed,
+		// so that the lateness is measured from the latest event time.
+		if s.allowedLateness == 0 || pastDataDur > 0 {
 			s.currentWatermark = elem.TimestampMillis()
+		}
 
-			// Currently, assume the current watermark is t,
-			// then we allow lateness items by not purging the window
-			// of which the flush trigger time is less and equal than t,
-			// i.e. triggerTime <= t
-			s.flushDueWindows()
-
-			// flush dirty windows if the necessary
-			// use 40% of the data point interval as the flush interval,
-			// which means roughly the record located in the same time bucket will be persistent twice.
-			// |---------------------------------|
-			// |    40%     |    40%     |  20%  |
-			// |          flush        flush     |
-			// |---------------------------------|
-			// the max flush interval is 1 minute.
-			if (pastDur > s.flushInterval) || (previousWaterMark > 0 && pastDataDur > s.flushInterval) {
-				s.lastFlushTime = now
-				s.flushDirtyWindows()
-			}
+		// Currently, assume the current watermark is t,
+		// then we allow lateness items by not purging the window
+		// of which the flush trigger time is less and equal than t,
+		// i.e. triggerTime <= t
+		s.flushDueWindows()
+
+		// flush dirty windows if the necessary
+		// use 40% of the data point interval as the flush interval,
+		// which means roughly the record located in the same time bucket will be persistent twice.
+		// |---------------------------------|
+		// |    40%     |    40%     |  20%  |
+		// |          flush        flush     |
+		// |---------------------------------|
+		// the max flush interval is 1 minute.
+		if (pastDur > s.flushInterval) || (previousWaterMark > 0 && pastDataDur > s.flushInterval) {
+			s.lastFlushTime = now
+			s.flushDirtyWindows()
 		}
 	}
-	close(s.out)
 }
 
 // isWindowLate checks whether this window is valid. The window is late if and only if
-// it meets all the following conditions,
-// 1) the max timestamp is before the current watermark
-// 2) the LRU cache is full
-// 3) the LRU cache does not contain the window entry.
-func (s *tumblingTimeWindows) isWindowLate(w flow.Window) bool {
-	return w.MaxTimestamp() <= s.currentWatermark && s.snapshots.Len() >= s.windowCount && !s.snapshots.Contains(w)
+// its max timestamp is before the current watermark, and
+// 1) the allowed lateness has passed if it's set,
+// 2) otherwise, the LRU cache is full and does not contain the window entry.
+func (s *timeWindows) isWindowLate(w timeWindow) bool {
+	if w.MaxTimestamp() > s.currentWatermark {
+		return false
+	}
+	if s.allowedLateness > 0 {
+		return w.MaxTimestamp()+s.allowedLateness < s.currentWatermark
+	}
+	return s.snapshots.Len() >= s.windowCount && !s.snapshots.Contains(w)
 }
 
-func (s *tumblingTimeWindows) Teardown(_ context.Context) error {
+func (s *timeWindows) Teardown(_ context.Context) error {
 	s.Wait()
 	return nil
 }
 
-func (s *tumblingTimeWindows) Exec(downstream flow.Inlet) {
+func (s *timeWindows) Exec(downstream flow.Inlet) {
 	s.Add(1)
 	go flow.Transmit(&s.ComponentState, downstream, s)
 }
 
 // NewTumblingTimeWindows return tumbling-time windows.
 func NewTumblingTimeWindows(size time.Duration, maxFlushInterval time.Duration) flow.WindowAssigner {
-	ws := size.Milliseconds()
-	mfi := maxFlushInterval.Milliseconds()
-	it := int64(float64(ws) * 0.4)
-	if it > mfi {
-		it = mfi
-	}
-	return &tumblingTimeWindows{
-		windowSize: ws,
-		timerHeap: flow.NewPriorityQueue(func(a, b interface{}) int {
-			return int(a.(*internalTimer).w.MaxTimestamp() - b.(*internalTimer).w.MaxTimestamp())
-		}, false),
-		in:               make(chan flow.StreamRecord),
-		out:              make(chan flow.StreamRecord),
-		currentWatermark: 0,
-		flushInterval:    it,
-	}
+	w := &tumblingTimeWindows{}
+	w.init(size, size, maxFlushInterval)
+	return w
+}
+
+// NewSlidingTimeWindows returns sliding-time windows of the size, and a new window starts every slide.
+// The size should be a multiple of the slide.
+func NewSlidingTimeWindows(size, slide time.Duration, maxFlushInterval time.Duration) flow.WindowAssigner {
+	w := &slidingTimeWindows{}
+	w.init(size, slide, maxFlushInterval)
+	return w
 }
 
 type timeWindow struct {
@@ -287,13 +452,21 @@ func (t timeWindow) String() string {
 }
 
 // AssignWindows assigns windows according to the given timestamp.
-func (s *tumblingTimeWindows) AssignWindows(timestamp int64) (flow.Window, error) {
+func (s *timeWindows) AssignWindows(timestamp int64) ([]flow.Window, error) {
 	if timestamp > math.MinInt64 {
-		start := getWindowStart(timestamp, s.windowSize)
-		return timeWindow{
-			start: start,
-			end:   start + s.windowSize,
-		}, nil
+		// the windows are in the order of their starts,
+		// so that the LRU cache evicts the earliest window first.
+		n := s.windowSize / s.slide
+		start := getWindowStart(timestamp, s.slide) - (n-1)*s.slide
+		windows := make([]flow.Window, 0, n)
+		for i := int64(0); i < n; i++ {
+			windows = append(windows, timeWindow{
+				start: start,
+				end:   start + s.windowSize,
+			})
+			start += s.slide
+		}
+		return windows, nil
 	}
 	return nil, errors.New("invalid timestamp from the element")
 }
@@ -305,7 +478,7 @@ func getWindowStart(timestamp, windowSize int64) int64 {
 }
 
 // eventTimeTriggerOnElement processes element(s) with EventTimeTrigger.
-func (s *tumblingTimeWindows) eventTimeTriggerOnElement(window timeWindow) triggerResult {
+func (s *timeWindows) eventTimeTriggerOnElement(window timeWindow) triggerResult {
 	if window.MaxTimestamp() <= s.currentWatermark {
 		// if watermark is already past the window fire immediately
 		return fire
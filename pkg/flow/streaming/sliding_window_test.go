@@ -55,6 +55,21 @@ func (i *intSumAggregator) Dirty() bool {
 	return i.dirty
 }
 
+func (i *intSumAggregator) Merge(other flow.AggregationOp) {
+	i.sum += other.(*intSumAggregator).sum
+	i.dirty = true
+}
+
+// lastValues returns the latest result of each window by its timestamp.
+func lastValues(records []interface{}) map[int64]int {
+	values := make(map[int64]int)
+	for _, r := range records {
+		record := r.(flow.StreamRecord)
+		values[record.TimestampMillis()] = record.Data().(int)
+	}
+	return values
+}
+
 var _ = g.Describe("Sliding Window", func() {
 	var (
 		baseTS         time.Time
@@ -273,4 +288,140 @@ var _ = g.Describe("Sliding Window", func() {
 			gomega.Expect(timerHeap.Len()).Should(gomega.Equal(0))
 		})
 	})
+
+	g.Describe("Allowed Lateness", func() {
+		g.JustBeforeEach(func() {
+			slidingWindows.allowedLateness = (20 * time.Second).Milliseconds()
+		})
+
+		g.BeforeEach(func() {
+			input = nil
+		})
+
+		g.It("Should update the window with late elements within the lateness", func() {
+			base := time.Unix(baseTS.Unix()-baseTS.Unix()%15, 0)
+			for _, r := range []flow.StreamRecord{
+				flow.NewStreamRecord(1, base.Add(time.Second).UnixMilli()),
+				flow.NewStreamRecord(2, base.Add(16*time.Second).UnixMilli()),
+				flow.NewStreamRecord(4, base.Add(2*time.Second).UnixMilli()),
+				flow.NewStreamRecord(8, base.Add(50*time.Second).UnixMilli()),
+				flow.NewStreamRecord(16, base.Add(3*time.Second).UnixMilli()),
+			} {
+				slidingWindows.In() <- r
+			}
+			gomega.Eventually(func(g gomega.Gomega) {
+				g.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(base.UnixMilli(), 5))
+			}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+			gomega.Consistently(func(g gomega.Gomega) {
+				g.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(base.UnixMilli(), 5))
+			}).WithTimeout(time.Second).Should(gomega.Succeed())
+		})
+	})
+})
+
+var _ = g.Describe("Hopping Window", func() {
+	var (
+		baseTS  time.Time
+		snk     *slice
+		windows *slidingTimeWindows
+	)
+
+	g.BeforeEach(func() {
+		now := time.Now().Unix()
+		baseTS = time.Unix(now-now%30, 0)
+		snk = newSlice()
+		windows = NewSlidingTimeWindows(30*time.Second, 10*time.Second, 10*time.Second).(*slidingTimeWindows)
+		windows.aggregationFactory = func() flow.AggregationOp {
+			return &intSumAggregator{}
+		}
+		windows.l = logger.GetLogger("slidingTimeWindows")
+		gomega.Expect(windows.Setup(context.TODO())).Should(gomega.Succeed())
+		gomega.Expect(snk.Setup(context.TODO())).Should(gomega.Succeed())
+		windows.Exec(snk)
+	})
+
+	g.AfterEach(func() {
+		close(windows.in)
+		gomega.Expect(windows.Teardown(context.TODO())).Should(gomega.Succeed())
+	})
+
+	g.It("Should assign an element to overlapping windows", func() {
+		assigned, err := windows.AssignWindows(baseTS.Add(time.Second).UnixMilli())
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(assigned).Should(gomega.HaveLen(3))
+		for i, w := range assigned {
+			start := baseTS.Add(time.Duration(i-2) * 10 * time.Second).UnixMilli()
+			gomega.Expect(w).Should(gomega.Equal(timeWindow{start: start, end: start + 30_000}))
+		}
+	})
+
+	g.It("Should emit each window at its last slide", func() {
+		for i, ts := range []time.Duration{time.Second, 11 * time.Second, 21 * time.Second, 31 * time.Second, 75 * time.Second} {
+			windows.In() <- flow.NewStreamRecord(1<<i, baseTS.Add(ts).UnixMilli())
+		}
+		at := func(d time.Duration) int64 {
+			return baseTS.Add(d).UnixMilli()
+		}
+		gomega.Eventually(func(g gomega.Gomega) {
+			values := lastValues(snk.Value())
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(0), 1))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(10*time.Second), 1+2))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(20*time.Second), 1+2+4))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(30*time.Second), 2+4+8))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(40*time.Second), 4+8))
+			g.Expect(values).Should(gomega.HaveKeyWithValue(at(50*time.Second), 8))
+			// the last slide of the window containing the latest element hasn't begun.
+			g.Expect(values).ShouldNot(gomega.HaveKey(at(90 * time.Second)))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+	})
+})
+
+var _ = g.Describe("Window Checkpoint", func() {
+	newWindows := func() *tumblingTimeWindows {
+		w := NewTumblingTimeWindows(time.Second*15, time.Second*15).(*tumblingTimeWindows)
+		w.aggregationFactory = func() flow.AggregationOp {
+			return &intSumAggregator{}
+		}
+		w.l = logger.GetLogger("tumblingTimeWindows")
+		return w
+	}
+	open := func(w *tumblingTimeWindows) *slice {
+		snk := newSlice()
+		gomega.Expect(w.Setup(context.TODO())).Should(gomega.Succeed())
+		gomega.Expect(snk.Setup(context.TODO())).Should(gomega.Succeed())
+		w.Exec(snk)
+		return snk
+	}
+	closeWindows := func(w *tumblingTimeWindows) {
+		close(w.in)
+		gomega.Expect(w.Teardown(context.TODO())).Should(gomega.Succeed())
+	}
+
+	g.It("Should resume the checkpointed windows", func() {
+		now := time.Now()
+		baseTS := time.Unix(now.Unix()-now.Unix()%15, 0)
+		windows := newWindows()
+		open(windows)
+		windows.In() <- flow.NewStreamRecord(1, baseTS.UnixMilli())
+		windows.In() <- flow.NewStreamRecord(2, baseTS.Add(time.Second*5).UnixMilli())
+		var checkpoints []flow.WindowCheckpoint
+		gomega.Expect(windows.Checkpoint(func(cps []flow.WindowCheckpoint) {
+			checkpoints = cps
+		})).Should(gomega.Succeed())
+		closeWindows(windows)
+		gomega.Expect(windows.Checkpoint(func([]flow.WindowCheckpoint) {})).ShouldNot(gomega.Succeed())
+		gomega.Expect(checkpoints).Should(gomega.HaveLen(1))
+		gomega.Expect(checkpoints[0].Start).Should(gomega.Equal(baseTS.UnixMilli()))
+		gomega.Expect(checkpoints[0].End).Should(gomega.Equal(baseTS.Add(time.Second * 15).UnixMilli()))
+
+		restored := newWindows()
+		restored.Restore(checkpoints)
+		snk := open(restored)
+		defer closeWindows(restored)
+		restored.In() <- flow.NewStreamRecord(4, baseTS.Add(time.Second*10).UnixMilli())
+		restored.In() <- flow.NewStreamRecord(8, baseTS.Add(time.Second*31).UnixMilli())
+		gomega.Eventually(func(g gomega.Gomega) {
+			g.Expect(lastValues(snk.Value())).Should(gomega.HaveKeyWithValue(baseTS.UnixMilli(), 1+2+4))
+		}).WithTimeout(flags.EventuallyTimeout).Should(gomega.Succeed())
+	})
 })
@@ -18,6 +18,8 @@
 package streaming
 
 import (
+	"cmp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -39,6 +41,8 @@ const (
 	ASC
 )
 
+var _ flow.MergeableAggregationOp = (*topNAggregatorGroup)(nil)
+
 type windowedFlow struct {
 	f  *streamingFlow
 	wa flow.WindowAssigner
@@ -46,7 +50,12 @@ type windowedFlow struct {
 }
 
 func (s *windowedFlow) TopN(topNum int, opts ...any) flow.Flow {
-	s.wa.(*tumblingTimeWindows).aggregationFactory = func() flow.AggregationOp {
+	wo, ok := s.wa.(windowOperator)
+	if !ok {
+		s.f.drainErr(errors.New("topN is not supported by the window type"))
+		return s.f
+	}
+	wo.state().aggregationFactory = func() flow.AggregationOp {
 		topNAggrFunc := &topNAggregatorGroup{
 			cacheSize: topNum,
 			sort:      DESC,
@@ -177,6 +186,28 @@ func (t *topNAggregatorGroup) Snapshot() interface{} {
 	return groupRanks
 }
 
+// Merge adds the items ranked by the other aggregator group in the order of their timestamps,
+// so that the latest item of a key stays.
+func (t *topNAggregatorGroup) Merge(other flow.AggregationOp) {
+	o, ok := other.(*topNAggregatorGroup)
+	if !ok {
+		return
+	}
+	var items []flow.StreamRecord
+	for _, aggregator := range o.aggregatorGroup {
+		iter := aggregator.treeMap.Iterator()
+		for iter.Next() {
+			for _, item := range iter.Value().([]interface{}) {
+				items = append(items, item.(flow.StreamRecord))
+			}
+		}
+	}
+	slices.SortStableFunc(items, func(a, b flow.StreamRecord) int {
+		return cmp.Compare(a.TimestampMillis(), b.TimestampMillis())
+	})
+	t.Add(items)
+}
+
 func (t *topNAggregatorGroup) Dirty() bool {
 	for _, aggregator := range t.aggregatorGroup {
 		if aggregator.dirty {
@@ -57,12 +57,17 @@ type Flow interface {
 // The WindowedFlow can be created with a WindowAssigner.
 type WindowedFlow interface {
 	AllowedMaxWindows(windowCnt int) WindowedFlow
+	// AllowedLateness keeps windows open for late elements after the watermark passes their ends.
+	// Late elements within the lateness update the windows' results, and the others are dropped.
+	AllowedLateness(lateness time.Duration) WindowedFlow
 	// TopN applies a TopNAggregation to each Window.
 	TopN(topNum int, opts ...any) Flow
+	// Aggregate applies the AggregationOp created by the factory to each Window.
+	// The snapshots of the AggregationOp are emitted as the results of the Window.
+	Aggregate(factory AggregationOpFactory) Flow
 }
 
 // Window is a bucket of elements with a finite size.
-// timedWindow is the only implementation now.
 type Window interface {
 	// MaxTimestamp returns the upper bound of the Window.
 	// Unit: Millisecond
@@ -73,7 +78,7 @@ type Window interface {
 type WindowAssigner interface {
 	// AssignWindows assigns a slice of Window according to the given timestamp, e.g. eventTime.
 	// The unit of the timestamp here is MilliSecond.
-	AssignWindows(timestamp int64) (Window, error)
+	AssignWindows(timestamp int64) ([]Window, error)
 }
 
 // AggregationOp defines the stateful operation for aggregation.
@@ -87,9 +92,36 @@ type AggregationOp interface {
 	Dirty() bool
 }
 
+// MergeableAggregationOp is an AggregationOp which can absorb the state of another one.
+// Session windows require it to merge the sessions bridged by an element.
+type MergeableAggregationOp interface {
+	AggregationOp
+	// Merge adds the elements kept by the other AggregationOp.
+	Merge(other AggregationOp)
+}
+
 // AggregationOpFactory is a factory to create AggregationOp.
 type AggregationOpFactory func() AggregationOp
 
+// WindowCheckpoint is the state of a Window kept by a window operator.
+type WindowCheckpoint struct {
+	Aggregation AggregationOp
+	// Start and End are the bounds of the Window in milliseconds.
+	Start int64
+	End   int64
+}
+
+// CheckpointableWindows is a WindowAssigner whose Windows could be saved and restored,
+// so that the aggregations survive restarts.
+type CheckpointableWindows interface {
+	// Checkpoint calls fn with the Windows kept by the operator.
+	// fn runs in the goroutine processing elements, so the aggregations are not modified concurrently.
+	// It fails if the flow is closed.
+	Checkpoint(fn func([]WindowCheckpoint)) error
+	// Restore resumes the Windows. It must be called before the flow opens.
+	Restore([]WindowCheckpoint)
+}
+
 // StreamRecord is a container wraps user data and timestamp.
 // It is the underlying transmission medium for the streaming processing.
 type StreamRecord struct {
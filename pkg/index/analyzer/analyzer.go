@@ -20,6 +20,7 @@ package analyzer
 
 import (
 	"bytes"
+	"sync"
 	"unicode"
 
 	"github.com/blugelabs/bluge/analysis"
@@ -29,11 +30,16 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/index"
 )
 
-// Analyzers is a map that associates each IndexRule_Analyzer type with a corresponding Analyzer.
-var Analyzers map[string]*analysis.Analyzer
+var (
+	// builtins is a map that associates each builtin analyzer name with a corresponding Analyzer.
+	builtins map[string]*analysis.Analyzer
+
+	customs   = make(map[string]*analysis.Analyzer)
+	customsMu sync.RWMutex
+)
 
 func init() {
-	Analyzers = map[string]*analysis.Analyzer{
+	builtins = map[string]*analysis.Analyzer{
 		index.AnalyzerKeyword:  analyzer.NewKeywordAnalyzer(),
 		index.AnalyzerSimple:   analyzer.NewSimpleAnalyzer(),
 		index.AnalyzerStandard: analyzer.NewStandardAnalyzer(),
@@ -41,6 +47,37 @@ func init() {
 	}
 }
 
+// Get returns the builtin or custom analyzer with the name.
+// It returns nil if the analyzer doesn't exist.
+func Get(name string) *analysis.Analyzer {
+	if a, ok := builtins[name]; ok {
+		return a
+	}
+	customsMu.RLock()
+	defer customsMu.RUnlock()
+	return customs[name]
+}
+
+// IsBuiltin reports whether the name refers to a builtin analyzer.
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// Register adds or replaces a custom analyzer.
+func Register(name string, a *analysis.Analyzer) {
+	customsMu.Lock()
+	defer customsMu.Unlock()
+	customs[name] = a
+}
+
+// Unregister removes a custom analyzer.
+func Unregister(name string) {
+	customsMu.Lock()
+	defer customsMu.Unlock()
+	delete(customs, name)
+}
+
 // NewURLAnalyzer creates a new URL analyzer.
 func NewURLAnalyzer() *analysis.Analyzer {
 	return &analysis.Analyzer{
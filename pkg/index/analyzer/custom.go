@@ -0,0 +1,164 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package analyzer
+
+import (
+	"math"
+	"regexp"
+
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/char"
+	"github.com/blugelabs/bluge/analysis/lang/cjk"
+	"github.com/blugelabs/bluge/analysis/lang/en"
+	"github.com/blugelabs/bluge/analysis/token"
+	"github.com/blugelabs/bluge/analysis/tokenizer"
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// Build creates an analyzer from the definition of a custom analyzer.
+func Build(spec *databasev1.Analyzer) (*analysis.Analyzer, error) {
+	if spec.GetTokenizer() == nil {
+		return nil, errors.New("tokenizer is absent")
+	}
+	a := &analysis.Analyzer{}
+	for i, cf := range spec.GetCharFilters() {
+		f, err := buildCharFilter(cf)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "char_filters[%d]", i)
+		}
+		a.CharFilters = append(a.CharFilters, f)
+	}
+	var err error
+	if a.Tokenizer, err = buildTokenizer(spec.GetTokenizer()); err != nil {
+		return nil, errors.WithMessage(err, "tokenizer")
+	}
+	for i, tf := range spec.GetTokenFilters() {
+		f, err := buildTokenFilter(tf)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "token_filters[%d]", i)
+		}
+		a.TokenFilters = append(a.TokenFilters, f)
+	}
+	return a, nil
+}
+
+func buildCharFilter(spec *databasev1.CharFilter) (analysis.CharFilter, error) {
+	switch spec.GetType() {
+	case databasev1.CharFilter_TYPE_HTML_STRIP:
+		return char.NewHTMLCharFilter(), nil
+	case databasev1.CharFilter_TYPE_PATTERN_REPLACE:
+		if spec.GetPattern() == "" {
+			return nil, errors.New("pattern is empty")
+		}
+		re, err := regexp.Compile(spec.GetPattern())
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid pattern %q", spec.GetPattern())
+		}
+		return char.NewRegexpCharFilter(re, []byte(spec.GetReplacement())), nil
+	case databasev1.CharFilter_TYPE_ASCII_FOLDING:
+		return char.NewASCIIFoldingFilter(), nil
+	}
+	return nil, errors.Errorf("unsupported char filter type: %s", spec.GetType())
+}
+
+func buildTokenizer(spec *databasev1.Tokenizer) (analysis.Tokenizer, error) {
+	switch spec.GetType() {
+	case databasev1.Tokenizer_TYPE_UNICODE:
+		return tokenizer.NewUnicodeTokenizer(), nil
+	case databasev1.Tokenizer_TYPE_WHITESPACE:
+		return tokenizer.NewWhitespaceTokenizer(), nil
+	case databasev1.Tokenizer_TYPE_LETTER:
+		return tokenizer.NewLetterTokenizer(), nil
+	case databasev1.Tokenizer_TYPE_KEYWORD:
+		return tokenizer.NewSingleTokenTokenizer(), nil
+	case databasev1.Tokenizer_TYPE_NGRAM, databasev1.Tokenizer_TYPE_EDGE_NGRAM:
+		minGram, maxGram := int(spec.GetMinGram()), int(spec.GetMaxGram())
+		if minGram < 1 || maxGram < minGram {
+			return nil, errors.Errorf("invalid gram range [%d, %d]", minGram, maxGram)
+		}
+		var filter analysis.TokenFilter = token.NewNgramFilter(minGram, maxGram)
+		if spec.GetType() == databasev1.Tokenizer_TYPE_EDGE_NGRAM {
+			filter = token.NewEdgeNgramFilter(token.FRONT, minGram, maxGram)
+		}
+		return &filteredTokenizer{tokenizer: tokenizer.NewSingleTokenTokenizer(), filters: []analysis.TokenFilter{filter}}, nil
+	case databasev1.Tokenizer_TYPE_PATTERN:
+		if spec.GetPattern() == "" {
+			return nil, errors.New("pattern is empty")
+		}
+		re, err := regexp.Compile(spec.GetPattern())
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid pattern %q", spec.GetPattern())
+		}
+		return tokenizer.NewRegexpTokenizer(re), nil
+	case databasev1.Tokenizer_TYPE_CJK_BIGRAM:
+		return &filteredTokenizer{
+			tokenizer: tokenizer.NewUnicodeTokenizer(),
+			filters:   []analysis.TokenFilter{cjk.NewWidthFilter(), cjk.NewBigramFilter(false)},
+		}, nil
+	}
+	return nil, errors.Errorf("unsupported tokenizer type: %s", spec.GetType())
+}
+
+func buildTokenFilter(spec *databasev1.TokenFilter) (analysis.TokenFilter, error) {
+	switch spec.GetType() {
+	case databasev1.TokenFilter_TYPE_LOWERCASE:
+		return token.NewLowerCaseFilter(), nil
+	case databasev1.TokenFilter_TYPE_STOP:
+		if len(spec.GetStopWords()) == 0 {
+			return en.StopWordsFilter(), nil
+		}
+		words := analysis.NewTokenMap()
+		for _, w := range spec.GetStopWords() {
+			words.AddToken(w)
+		}
+		return token.NewStopTokensFilter(words), nil
+	case databasev1.TokenFilter_TYPE_STEMMER:
+		return en.StemmerFilter(), nil
+	case databasev1.TokenFilter_TYPE_LENGTH:
+		minLength, maxLength := int(spec.GetMinLength()), int(spec.GetMaxLength())
+		if maxLength == 0 {
+			maxLength = math.MaxInt
+		}
+		if maxLength < minLength {
+			return nil, errors.Errorf("invalid length range [%d, %d]", minLength, maxLength)
+		}
+		return token.NewLengthFilter(minLength, maxLength), nil
+	case databasev1.TokenFilter_TYPE_CAMEL_CASE:
+		return token.NewCamelCaseFilter(), nil
+	case databasev1.TokenFilter_TYPE_UNIQUE:
+		return token.NewUniqueTermFilter(), nil
+	}
+	return nil, errors.Errorf("unsupported token filter type: %s", spec.GetType())
+}
+
+// filteredTokenizer applies the filters to the tokens as a part of the tokenization,
+// so that they are run ahead of the token filters of the analyzer.
+type filteredTokenizer struct {
+	tokenizer analysis.Tokenizer
+	filters   []analysis.TokenFilter
+}
+
+func (t *filteredTokenizer) Tokenize(input []byte) analysis.TokenStream {
+	tokens := t.tokenizer.Tokenize(input)
+	for _, f := range t.filters {
+		tokens = f.Filter(tokens)
+	}
+	return tokens
+}
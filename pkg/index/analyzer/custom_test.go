@@ -0,0 +1,177 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func terms(t *testing.T, spec *databasev1.Analyzer, input string) []string {
+	a, err := Build(spec)
+	require.NoError(t, err)
+	var result []string
+	for _, tk := range a.Analyze([]byte(input)) {
+		result = append(result, string(tk.Term))
+	}
+	return result
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		spec     *databasev1.Analyzer
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "ngram",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_NGRAM, MinGram: 2, MaxGram: 3},
+			},
+			input:    "abcd",
+			expected: []string{"ab", "abc", "bc", "bcd", "cd"},
+		},
+		{
+			name: "edge ngram with lowercase",
+			spec: &databasev1.Analyzer{
+				Tokenizer:    &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_EDGE_NGRAM, MinGram: 1, MaxGram: 3},
+				TokenFilters: []*databasev1.TokenFilter{{Type: databasev1.TokenFilter_TYPE_LOWERCASE}},
+			},
+			input:    "ABCD",
+			expected: []string{"a", "ab", "abc"},
+		},
+		{
+			name: "pattern tokenizer",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_PATTERN, Pattern: "[a-z0-9]+"},
+			},
+			input:    "svc-1.pod_2",
+			expected: []string{"svc", "1", "pod", "2"},
+		},
+		{
+			name: "cjk bigram",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_CJK_BIGRAM},
+			},
+			input:    "服务网格",
+			expected: []string{"服务", "务网", "网格"},
+		},
+		{
+			name: "camel case and lowercase",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_WHITESPACE},
+				TokenFilters: []*databasev1.TokenFilter{
+					{Type: databasev1.TokenFilter_TYPE_CAMEL_CASE},
+					{Type: databasev1.TokenFilter_TYPE_LOWERCASE},
+				},
+			},
+			input:    "getUserName",
+			expected: []string{"get", "user", "name"},
+		},
+		{
+			name: "custom stop words and length",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_UNICODE},
+				TokenFilters: []*databasev1.TokenFilter{
+					{Type: databasev1.TokenFilter_TYPE_STOP, StopWords: []string{"error"}},
+					{Type: databasev1.TokenFilter_TYPE_LENGTH, MinLength: 3},
+				},
+			},
+			input:    "error in db connection",
+			expected: []string{"connection"},
+		},
+		{
+			name: "pattern replace char filter",
+			spec: &databasev1.Analyzer{
+				CharFilters: []*databasev1.CharFilter{{Type: databasev1.CharFilter_TYPE_PATTERN_REPLACE, Pattern: "[0-9]+", Replacement: "N"}},
+				Tokenizer:   &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_KEYWORD},
+			},
+			input:    "/users/42/orders/7",
+			expected: []string{"/users/N/orders/N"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, terms(t, tt.spec, tt.input))
+		})
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	tests := []struct {
+		spec *databasev1.Analyzer
+		name string
+	}{
+		{
+			name: "absent tokenizer",
+			spec: &databasev1.Analyzer{},
+		},
+		{
+			name: "invalid gram range",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_NGRAM, MinGram: 3, MaxGram: 2},
+			},
+		},
+		{
+			name: "invalid pattern",
+			spec: &databasev1.Analyzer{
+				Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_PATTERN, Pattern: "[a-z"},
+			},
+		},
+		{
+			name: "unspecified token filter",
+			spec: &databasev1.Analyzer{
+				Tokenizer:    &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_UNICODE},
+				TokenFilters: []*databasev1.TokenFilter{{}},
+			},
+		},
+		{
+			name: "invalid length range",
+			spec: &databasev1.Analyzer{
+				Tokenizer:    &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_UNICODE},
+				TokenFilters: []*databasev1.TokenFilter{{Type: databasev1.TokenFilter_TYPE_LENGTH, MinLength: 5, MaxLength: 2}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(tt.spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	assert.True(t, IsBuiltin("standard"))
+	assert.False(t, IsBuiltin("path_ngram"))
+	assert.Nil(t, Get("path_ngram"))
+
+	a, err := Build(&databasev1.Analyzer{
+		Tokenizer: &databasev1.Tokenizer{Type: databasev1.Tokenizer_TYPE_NGRAM, MinGram: 1, MaxGram: 2},
+	})
+	require.NoError(t, err)
+	Register("path_ngram", a)
+	assert.Same(t, a, Get("path_ngram"))
+	Unregister("path_ngram")
+	assert.Nil(t, Get("path_ngram"))
+}
@@ -786,6 +786,8 @@ func (fk *FieldKey) UnmarshalAll(data []byte) error {
 type Batch struct {
 	PersistentCallback func(error)
 	Documents          Documents
+	// Replace makes Batch replace the existing documents sharing the same DocID instead of appending new ones.
+	Replace bool
 }
 
 // Writer allows writing fields and docID in a document to an index.
@@ -812,6 +814,8 @@ type Searcher interface {
 	Match(fieldKey FieldKey, match []string, opts *modelv1.Condition_MatchOption) (list posting.List, timestamps posting.List, err error)
 	MatchField(fieldKey FieldKey) (list posting.List, timestamps posting.List, err error)
 	MatchTerms(field Field) (list posting.List, timestamps posting.List, err error)
+	MatchPrefix(fieldKey FieldKey, prefix string) (list posting.List, timestamps posting.List, err error)
+	MatchRegexp(fieldKey FieldKey, regexp string) (list posting.List, timestamps posting.List, err error)
 	Range(fieldKey FieldKey, opts RangeOpts) (list posting.List, timestamps posting.List, err error)
 }
 
@@ -200,7 +200,7 @@ func (s *store) Batch(batch index.Batch) error {
 				tf.StoreValue()
 			}
 			if f.Key.Analyzer != index.AnalyzerUnspecified {
-				tf = tf.WithAnalyzer(analyzer.Analyzers[f.Key.Analyzer])
+				tf = tf.WithAnalyzer(analyzer.Get(f.Key.Analyzer)).SearchTermPositions()
 			}
 			doc.AddField(tf)
 			if i == 0 {
@@ -211,6 +211,10 @@ func (s *store) Batch(batch index.Batch) error {
 		if d.Timestamp > 0 {
 			doc.AddField(bluge.NewDateTimeField(timestampField, time.Unix(0, d.Timestamp)).StoreValue())
 		}
+		if batch.Replace {
+			b.Update(doc.ID(), doc)
+			continue
+		}
 		b.Insert(doc)
 	}
 	return s.writer.Batch(b)
@@ -228,7 +232,7 @@ func NewStore(opts StoreOpts) (index.SeriesStore, error) {
 	}
 	indexConfig.CacheMaxBytes = opts.CacheMaxBytes
 	config := bluge.DefaultConfigWithIndexConfig(indexConfig)
-	config.DefaultSearchAnalyzer = analyzer.Analyzers[index.AnalyzerKeyword]
+	config.DefaultSearchAnalyzer = analyzer.Get(index.AnalyzerKeyword)
 	config.Logger = log.New(opts.Logger, opts.Logger.Module(), 0)
 	config = config.WithPrepareMergeCallback(opts.PrepareMergeCallback)
 	if opts.ExternalSegmentTempDir != "" {
@@ -448,13 +452,11 @@ func (s *store) Match(fieldKey index.FieldKey, matches []string, opts *modelv1.C
 	if err != nil {
 		return nil, nil, err
 	}
-	analyzer, operator := getMatchOptions(fieldKey.Analyzer, opts)
 	fk := fieldKey.Marshal()
 	query := bluge.NewBooleanQuery()
 	query.AddMust(bluge.NewTermQuery(string(fieldKey.SeriesID.Marshal())).SetField(seriesIDField))
 	for _, m := range matches {
-		query.AddMust(bluge.NewMatchQuery(m).SetField(fk).
-			SetAnalyzer(analyzer).SetOperator(operator))
+		query.AddMust(newMatchQuery(fk, m, fieldKey.Analyzer, opts))
 	}
 	_ = appendTimeRangeToQuery(query, fieldKey)
 	documentMatchIterator, err := reader.Search(context.Background(), bluge.NewAllMatches(query))
@@ -473,12 +475,55 @@ func (s *store) Match(fieldKey index.FieldKey, matches []string, opts *modelv1.C
 	return list, timestamps, err
 }
 
+func (s *store) MatchPrefix(fieldKey index.FieldKey, prefix string) (posting.List, posting.List, error) {
+	return s.matchQuery(fieldKey, bluge.NewPrefixQuery(prefix).SetField(fieldKey.Marshal()))
+}
+
+func (s *store) MatchRegexp(fieldKey index.FieldKey, regexp string) (posting.List, posting.List, error) {
+	return s.matchQuery(fieldKey, bluge.NewRegexpQuery(regexp).SetField(fieldKey.Marshal()))
+}
+
+func (s *store) matchQuery(fieldKey index.FieldKey, q bluge.Query) (list posting.List, timestamps posting.List, err error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, nil, err
+	}
+	query := bluge.NewBooleanQuery()
+	query.AddMust(bluge.NewTermQuery(string(fieldKey.SeriesID.Marshal())).SetField(seriesIDField))
+	query.AddMust(q)
+	_ = appendTimeRangeToQuery(query, fieldKey)
+	documentMatchIterator, err := reader.Search(context.Background(), bluge.NewAllMatches(query))
+	if err != nil {
+		return nil, nil, err
+	}
+	iter := newBlugeMatchIterator(documentMatchIterator, reader, defaultProjection)
+	defer func() {
+		err = multierr.Append(err, iter.Close())
+	}()
+	list, timestamps = roaring.NewPostingList(), roaring.NewPostingList()
+	for iter.Next() {
+		list.Insert(iter.Val().DocID)
+		timestamps.Insert(uint64(iter.Val().Timestamp))
+	}
+	return list, timestamps, err
+}
+
+// newMatchQuery creates a phrase query if the phrase option is enabled, otherwise a term query with optional fuzziness.
+func newMatchQuery(field, value, analyzerOnIndexRule string, opts *modelv1.Condition_MatchOption) bluge.Query {
+	analyzer, operator := getMatchOptions(analyzerOnIndexRule, opts)
+	if opts.GetPhrase() {
+		return bluge.NewMatchPhraseQuery(value).SetField(field).SetAnalyzer(analyzer).SetSlop(int(opts.GetSlop()))
+	}
+	return bluge.NewMatchQuery(value).SetField(field).SetAnalyzer(analyzer).SetOperator(operator).
+		SetFuzziness(int(opts.GetFuzziness()))
+}
+
 func getMatchOptions(analyzerOnIndexRule string, opts *modelv1.Condition_MatchOption) (*analysis.Analyzer, bluge.MatchQueryOperator) {
-	a := analyzer.Analyzers[analyzerOnIndexRule]
+	a := analyzer.Get(analyzerOnIndexRule)
 	operator := bluge.MatchQueryOperatorOr
 	if opts != nil {
 		if opts.Analyzer != index.AnalyzerUnspecified {
-			a = analyzer.Analyzers[opts.Analyzer]
+			a = analyzer.Get(opts.Analyzer)
 		}
 		if opts.Operator != modelv1.Condition_MatchOption_OPERATOR_UNSPECIFIED {
 			if opts.Operator == modelv1.Condition_MatchOption_OPERATOR_AND {
@@ -116,7 +116,7 @@ func toDoc(d index.Document, toParseFieldNames bool) (*bluge.Document, []string)
 				tf.Sortable()
 			}
 			if f.Key.Analyzer != index.AnalyzerUnspecified {
-				tf = tf.WithAnalyzer(analyzer.Analyzers[f.Key.Analyzer])
+				tf = tf.WithAnalyzer(analyzer.Get(f.Key.Analyzer)).SearchTermPositions()
 			}
 		} else {
 			tf = bluge.NewStoredOnlyField(k, f.GetBytes())
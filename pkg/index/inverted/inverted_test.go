@@ -354,6 +354,106 @@ func TestStore_NumericMatch(t *testing.T) {
 	tester.True(roaring.NewPostingListWithInitialData(1).Equal(l))
 }
 
+func TestStore_MatchPattern(t *testing.T) {
+	tester := assert.New(t)
+	path, fn := setUp(require.New(t))
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	var batch index.Batch
+	endpointName := index.FieldKey{
+		IndexRuleID: 8,
+	}
+	for i, endpoint := range []string{"/api/v1/users", "/api/v1/orders", "/api/v2/users", "/health"} {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{index.NewStringField(endpointName, endpoint)},
+			DocID:  uint64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	l, _, err := s.MatchPrefix(endpointName, "/api/v1/")
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 2).Equal(l))
+
+	l, _, err = s.MatchRegexp(endpointName, "/api/v[0-9]+/users")
+	tester.NoError(err)
+	tester.True(roaring.NewPostingListWithInitialData(1, 3).Equal(l))
+
+	l, _, err = s.MatchRegexp(endpointName, "users")
+	tester.NoError(err)
+	tester.True(l.IsEmpty(), "the regexp should match the whole value")
+}
+
+func TestStore_MatchPhraseAndFuzzy(t *testing.T) {
+	tester := assert.New(t)
+	path, fn := setUp(require.New(t))
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	var batch index.Batch
+	message := index.FieldKey{
+		IndexRuleID: 9,
+		Analyzer:    index.AnalyzerStandard,
+	}
+	for i, m := range []string{
+		"connection reset by peer",
+		"peer reset the connection",
+		"connection to the peer was reset",
+	} {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{index.NewStringField(message, m)},
+			DocID:  uint64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	tests := []struct {
+		opts  *modelv1.Condition_MatchOption
+		want  posting.List
+		name  string
+		match string
+	}{
+		{
+			name:  "phrase",
+			match: "connection reset",
+			opts:  &modelv1.Condition_MatchOption{Phrase: true},
+			want:  roaring.NewPostingListWithInitialData(1),
+		},
+		{
+			name:  "phrase with slop",
+			match: "connection peer",
+			opts:  &modelv1.Condition_MatchOption{Phrase: true, Slop: 2},
+			want:  roaring.NewPostingListWithInitialData(1, 3),
+		},
+		{
+			name:  "fuzzy",
+			match: "conection",
+			opts:  &modelv1.Condition_MatchOption{Fuzziness: 1},
+			want:  roaring.NewPostingListWithInitialData(1, 2, 3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _, err := s.Match(message, []string{tt.match}, tt.opts)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(l), "want %v, got %v", tt.want.ToSlice(), l.ToSlice())
+		})
+	}
+}
+
 func setUp(t *require.Assertions) (tempDir string, deferFunc func()) {
 	t.NoError(logger.Init(logger.Logging{
 		Env:   "dev",
@@ -295,7 +295,7 @@ func validateMatchCondition(cond *modelv1.Condition) error {
 	if _, ok := cond.Value.Value.(*modelv1.TagValue_Str); !ok {
 		return errors.WithMessagef(logical.ErrUnsupportedConditionValue, "MATCH condition requires string value type: %s", cond)
 	}
-	return nil
+	return logical.ValidateMatchOption(cond.MatchOption)
 }
 
 func validateINCondition(cond *modelv1.Condition) error {
@@ -366,10 +366,18 @@ func parseConditionToQuery(cond *modelv1.Condition, indexRule *databasev1.IndexR
 		if len(bb) != 1 {
 			return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "don't support multiple or null value: %s", cond)
 		}
-		analyzer, operator := getMatchOptions(indexRule.Analyzer, cond.MatchOption)
-		query := bluge.NewMatchQuery(convert.BytesToString(bb[0])).SetField(fieldKey).SetAnalyzer(analyzer).SetOperator(operator)
+		query := newMatchQuery(fieldKey, convert.BytesToString(bb[0]), indexRule.Analyzer, cond.MatchOption)
 		node := newMatchNode(str, indexRule)
 		return &queryNode{query, node}, nil
+	case modelv1.Condition_BINARY_OP_LIKE, modelv1.Condition_BINARY_OP_REGEXP:
+		pattern, err := logical.ParsePattern(cond)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.IsPrefix {
+			return &queryNode{bluge.NewPrefixQuery(pattern.Prefix).SetField(fieldKey), newPrefixNode(pattern.Prefix)}, nil
+		}
+		return &queryNode{bluge.NewRegexpQuery(pattern.Regexp).SetField(fieldKey), newRegexpNode(pattern.Regexp)}, nil
 	case modelv1.Condition_BINARY_OP_NE:
 		bb := expr.Bytes()
 		if len(bb) != 1 {
@@ -664,6 +672,26 @@ func (m *wildcardNode) String() string {
 	return convert.JSONToString(m)
 }
 
+type regexpNode struct {
+	regexp string
+}
+
+func newRegexpNode(regexp string) *regexpNode {
+	return &regexpNode{
+		regexp: regexp,
+	}
+}
+
+func (m *regexpNode) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["regexp"] = m.regexp
+	return json.Marshal(data)
+}
+
+func (m *regexpNode) String() string {
+	return convert.JSONToString(m)
+}
+
 type timeRangeNode struct {
 	timeRange *timestamp.TimeRange
 }
@@ -0,0 +1,176 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/numeric"
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+var _ index.StatsCollector = (*store)(nil)
+
+// CollectStats walks through the term dictionaries of the fields keyed by index rule IDs.
+// A field is numeric if all its terms are prefix coded or formatted numbers, and only the full precision terms of it are counted.
+func (s *store) CollectStats(topN int) (*index.Stats, error) {
+	if !s.closer.AddRunning() {
+		return nil, nil
+	}
+	defer s.closer.Done()
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	count, err := reader.Count()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := reader.Fields()
+	if err != nil {
+		return nil, err
+	}
+	stats := &index.Stats{
+		DocCount:  int64(count),
+		UpdatedAt: time.Now().UnixNano(),
+		Fields:    make(map[uint32]*index.FieldStats),
+	}
+	for _, f := range fields {
+		if f == seriesIDField {
+			var series termCounter
+			if err = countTerms(reader, f, func(_ string, n int64) { series.add("", n, 0) }); err != nil {
+				return nil, err
+			}
+			stats.Series = series.cardinality
+			continue
+		}
+		if len(f) != 4 || strings.HasPrefix(f, "_") {
+			continue
+		}
+		var str, num termCounter
+		isNumeric := true
+		if err = countTerms(reader, f, func(term string, n int64) {
+			str.add(term, n, topN)
+			if !isNumeric {
+				return
+			}
+			valid, shift := numeric.ValidPrefixCodedTerm(term)
+			if !valid {
+				// numeric fields also index the formatted value for term queries
+				if _, parseErr := strconv.ParseFloat(term, 64); parseErr != nil {
+					isNumeric = false
+				}
+				return
+			}
+			if shift != 0 {
+				return
+			}
+			v, decodeErr := numeric.PrefixCoded(term).Int64()
+			if decodeErr != nil {
+				isNumeric = false
+				return
+			}
+			num.addNumeric(v, n, topN)
+		}); err != nil {
+			return nil, err
+		}
+		fs := str.stats()
+		if isNumeric && num.cardinality > 0 {
+			fs = num.stats()
+			fs.Numeric, fs.Min, fs.Max = true, num.min, num.max
+		}
+		stats.Fields[convert.BytesToUint32(convert.StringToBytes(f))] = fs
+	}
+	return stats, nil
+}
+
+func countTerms(reader *bluge.Reader, field string, fn func(term string, n int64)) (err error) {
+	dict, err := reader.DictionaryIterator(field, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, dict.Close())
+	}()
+	for {
+		de, nextErr := dict.Next()
+		if nextErr != nil {
+			return nextErr
+		}
+		if de == nil {
+			return nil
+		}
+		fn(de.Term(), int64(de.Count()))
+	}
+}
+
+type termCounter struct {
+	top         map[string]int64
+	postings    int64
+	cardinality int64
+	min         int64
+	max         int64
+}
+
+func (tc *termCounter) add(term string, n int64, topN int) {
+	tc.postings += n
+	tc.cardinality++
+	if topN < 1 {
+		return
+	}
+	if tc.top == nil {
+		tc.top = make(map[string]int64, topN+1)
+	}
+	tc.top[term] = n
+	if len(tc.top) <= topN {
+		return
+	}
+	var minTerm string
+	minCount := int64(-1)
+	for t, c := range tc.top {
+		if minCount < 0 || c < minCount || (c == minCount && t > minTerm) {
+			minTerm, minCount = t, c
+		}
+	}
+	delete(tc.top, minTerm)
+}
+
+func (tc *termCounter) addNumeric(v int64, n int64, topN int) {
+	if tc.cardinality == 0 || v < tc.min {
+		tc.min = v
+	}
+	if tc.cardinality == 0 || v > tc.max {
+		tc.max = v
+	}
+	tc.add(strconv.FormatInt(v, 10), n, topN)
+}
+
+func (tc *termCounter) stats() *index.FieldStats {
+	return &index.FieldStats{
+		TopTerms:    tc.top,
+		Postings:    tc.postings,
+		Cardinality: tc.cardinality,
+	}
+}
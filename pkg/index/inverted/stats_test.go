@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestStore_CollectStats(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	method := index.FieldKey{IndexRuleID: 1, SeriesID: common.SeriesID(11)}
+	duration := index.FieldKey{IndexRuleID: 2, SeriesID: common.SeriesID(12)}
+	var docs index.Documents
+	for i := 0; i < 10; i++ {
+		m := "GET"
+		if i%5 == 0 {
+			m = "POST"
+		}
+		docs = append(docs, index.Document{
+			DocID: uint64(i + 1),
+			Fields: []index.Field{
+				index.NewStringField(method, m),
+				index.NewIntField(duration, int64(100+i*10)),
+			},
+			Timestamp: int64(i + 1),
+		})
+	}
+	docs = append(docs, index.Document{
+		DocID:     11,
+		Fields:    []index.Field{index.NewStringField(index.FieldKey{IndexRuleID: 1, SeriesID: common.SeriesID(13)}, "PUT")},
+		Timestamp: 11,
+	})
+	tester.NoError(s.Batch(index.Batch{Documents: docs}))
+
+	stats, err := s.(index.StatsCollector).CollectStats(2)
+	tester.NoError(err)
+	tester.EqualValues(11, stats.DocCount)
+	tester.EqualValues(2, stats.Series)
+
+	m := stats.Field(1)
+	tester.NotNil(m)
+	tester.False(m.Numeric)
+	tester.EqualValues(3, m.Cardinality)
+	tester.EqualValues(11, m.Postings)
+	tester.Equal(map[string]int64{"GET": 8, "POST": 2}, m.TopTerms)
+	tester.InDelta(1, m.EstimateTerm("PUT"), 0.001)
+
+	d := stats.Field(2)
+	tester.NotNil(d)
+	tester.True(d.Numeric)
+	tester.EqualValues(10, d.Cardinality)
+	tester.EqualValues(10, d.Postings)
+	tester.EqualValues(100, d.Min)
+	tester.EqualValues(190, d.Max)
+	fraction, ok := d.EstimateRange(index.NewIntRangeOpts(100, 145, true, true))
+	tester.True(ok)
+	tester.InDelta(0.5, fraction, 0.001)
+}
@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"math"
+	"sort"
+
+	"github.com/blugelabs/bluge/numeric"
+)
+
+// StatsCollector collects the term statistics of the indexed fields.
+type StatsCollector interface {
+	CollectStats(topN int) (*Stats, error)
+}
+
+// Stats holds the statistics of the indexed fields which are keyed by the index rule ID.
+type Stats struct {
+	Fields    map[uint32]*FieldStats `json:"fields,omitempty"`
+	DocCount  int64                  `json:"doc_count"`
+	Series    int64                  `json:"series"`
+	UpdatedAt int64                  `json:"updated_at"`
+}
+
+// FieldStats describes the distribution of the terms of an indexed field.
+type FieldStats struct {
+	TopTerms    map[string]int64 `json:"top_terms,omitempty"`
+	Min         int64            `json:"min,omitempty"`
+	Max         int64            `json:"max,omitempty"`
+	Postings    int64            `json:"postings"`
+	Cardinality int64            `json:"cardinality"`
+	Numeric     bool             `json:"numeric,omitempty"`
+}
+
+// Merge adds the statistics of another index to s, and keeps the topN most frequent terms of each field.
+// The cardinality of the merged field is the larger one, since the indexes share most of their terms.
+func (s *Stats) Merge(other *Stats, topN int) {
+	if other == nil {
+		return
+	}
+	s.DocCount += other.DocCount
+	s.Series += other.Series
+	if s.UpdatedAt < other.UpdatedAt {
+		s.UpdatedAt = other.UpdatedAt
+	}
+	if len(other.Fields) > 0 && s.Fields == nil {
+		s.Fields = make(map[uint32]*FieldStats, len(other.Fields))
+	}
+	for id, of := range other.Fields {
+		f, ok := s.Fields[id]
+		if !ok {
+			f = &FieldStats{Numeric: of.Numeric, Min: of.Min, Max: of.Max}
+			s.Fields[id] = f
+		}
+		f.Postings += of.Postings
+		f.Cardinality = max(f.Cardinality, of.Cardinality)
+		if f.Numeric && of.Numeric {
+			f.Min = min(f.Min, of.Min)
+			f.Max = max(f.Max, of.Max)
+		}
+		for term, n := range of.TopTerms {
+			if f.TopTerms == nil {
+				f.TopTerms = make(map[string]int64, len(of.TopTerms))
+			}
+			f.TopTerms[term] += n
+		}
+		f.TopTerms = topTerms(f.TopTerms, topN)
+	}
+}
+
+// Field returns the statistics of the field indexed by the rule.
+func (s *Stats) Field(indexRuleID uint32) *FieldStats {
+	if s == nil {
+		return nil
+	}
+	return s.Fields[indexRuleID]
+}
+
+// EstimateTerm returns the estimated number of documents containing the term.
+// The frequent terms are known, and the others share the rest of the postings evenly.
+func (fs *FieldStats) EstimateTerm(term string) float64 {
+	if n, ok := fs.TopTerms[term]; ok {
+		return float64(n)
+	}
+	rest, restTerms := fs.Postings, fs.Cardinality
+	for _, n := range fs.TopTerms {
+		rest -= n
+		restTerms--
+	}
+	if rest <= 0 || restTerms <= 0 {
+		return 0
+	}
+	return float64(rest) / float64(restTerms)
+}
+
+// EstimateRange returns the fraction of the numeric field's postings falling into the range,
+// assuming that the values are uniformly distributed between the min and max values.
+// It returns false if the field or the range isn't numeric.
+func (fs *FieldStats) EstimateRange(opts RangeOpts) (float64, bool) {
+	if !fs.Numeric {
+		return 0, false
+	}
+	lower, lowerOK := opts.Lower.(*FloatTermValue)
+	upper, upperOK := opts.Upper.(*FloatTermValue)
+	if !lowerOK || !upperOK {
+		return 0, false
+	}
+	lo := math.Max(float64(numeric.Float64ToInt64(lower.Value)), float64(fs.Min))
+	hi := math.Min(float64(numeric.Float64ToInt64(upper.Value)), float64(fs.Max))
+	if lo > hi {
+		return 0, true
+	}
+	if fs.Max == fs.Min {
+		return 1, true
+	}
+	return (hi - lo) / (float64(fs.Max) - float64(fs.Min)), true
+}
+
+func topTerms(terms map[string]int64, topN int) map[string]int64 {
+	if len(terms) <= topN {
+		return terms
+	}
+	keys := make([]string, 0, len(terms))
+	for k := range terms {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if terms[keys[i]] == terms[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return terms[keys[i]] > terms[keys[j]]
+	})
+	result := make(map[string]int64, topN)
+	for _, k := range keys[:topN] {
+		result[k] = terms[k]
+	}
+	return result
+}
@@ -0,0 +1,466 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package parquet reads and writes the Apache Parquet files to exchange data with the analytics engines.
+//
+// It models the files whose schemas consist of groups, primitive columns and lists of primitives,
+// whose values are read and written column by column. The format itself is handled by github.com/parquet-go/parquet-go.
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// Type is the physical type of a column.
+type Type int32
+
+// The physical types.
+const (
+	Boolean           Type = 0
+	Int32             Type = 1
+	Int64             Type = 2
+	Int96             Type = 3
+	Float             Type = 4
+	Double            Type = 5
+	ByteArray         Type = 6
+	FixedLenByteArray Type = 7
+)
+
+func (t Type) String() string {
+	switch t {
+	case Boolean:
+		return "BOOLEAN"
+	case Int32:
+		return "INT32"
+	case Int64:
+		return "INT64"
+	case Int96:
+		return "INT96"
+	case Float:
+		return "FLOAT"
+	case Double:
+		return "DOUBLE"
+	case ByteArray:
+		return "BYTE_ARRAY"
+	case FixedLenByteArray:
+		return "FIXED_LEN_BYTE_ARRAY"
+	}
+	return fmt.Sprintf("TYPE(%d)", int32(t))
+}
+
+// Repetition is the repetition of a field.
+type Repetition int32
+
+// The repetitions.
+const (
+	Required Repetition = 0
+	Optional Repetition = 1
+	Repeated Repetition = 2
+)
+
+// LogicalType annotates how to interpret the physical type of a field.
+type LogicalType int8
+
+// The logical types.
+const (
+	NoLogicalType LogicalType = iota
+	String
+	JSON
+	List
+	TimestampMillis
+	TimestampMicros
+	TimestampNanos
+)
+
+func (t LogicalType) String() string {
+	switch t {
+	case NoLogicalType:
+		return "NONE"
+	case String:
+		return "STRING"
+	case JSON:
+		return "JSON"
+	case List:
+		return "LIST"
+	case TimestampMillis:
+		return "TIMESTAMP(MILLIS)"
+	case TimestampMicros:
+		return "TIMESTAMP(MICROS)"
+	case TimestampNanos:
+		return "TIMESTAMP(NANOS)"
+	}
+	return fmt.Sprintf("LOGICAL(%d)", int8(t))
+}
+
+// IsTimestamp returns true if the type is a timestamp.
+func (t LogicalType) IsTimestamp() bool {
+	return t == TimestampMillis || t == TimestampMicros || t == TimestampNanos
+}
+
+// UnixNano converts a timestamp in the unit of the type to nanoseconds.
+func (t LogicalType) UnixNano(v int64) int64 {
+	switch t {
+	case TimestampMillis:
+		return v * 1e6
+	case TimestampMicros:
+		return v * 1e3
+	}
+	return v
+}
+
+// Codec is the compression codec of the pages.
+type Codec int32
+
+// The compression codecs.
+const (
+	Uncompressed Codec = 0
+	Snappy       Codec = 1
+	Gzip         Codec = 2
+	Zstd         Codec = 6
+)
+
+func (c Codec) String() string {
+	switch c {
+	case Uncompressed:
+		return "uncompressed"
+	case Snappy:
+		return "snappy"
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("codec(%d)", int32(c))
+}
+
+func (c Codec) compressCodec() (compress.Codec, error) {
+	switch c {
+	case Uncompressed:
+		return &pq.Uncompressed, nil
+	case Snappy:
+		return &pq.Snappy, nil
+	case Gzip:
+		return &pq.Gzip, nil
+	case Zstd:
+		return &pq.Zstd, nil
+	}
+	return nil, fmt.Errorf("unsupported compression codec %s", c)
+}
+
+// ParseCodec parses the name of a compression codec.
+func ParseCodec(name string) (Codec, error) {
+	for _, c := range []Codec{Uncompressed, Snappy, Gzip, Zstd} {
+		if strings.EqualFold(name, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compression codec %q", name)
+}
+
+// Field is a field of a schema. A field having children is a group, otherwise it's a primitive column.
+type Field struct {
+	Name       string
+	Fields     []Field
+	Type       Type
+	Repetition Repetition
+	Logical    LogicalType
+	// TypeLength is the length of a FIXED_LEN_BYTE_ARRAY.
+	TypeLength int32
+}
+
+// Leaf returns a primitive field.
+func Leaf(name string, t Type, logical LogicalType, repetition Repetition) Field {
+	return Field{Name: name, Type: t, Logical: logical, Repetition: repetition}
+}
+
+// Group returns a group of fields.
+func Group(name string, repetition Repetition, fields ...Field) Field {
+	return Field{Name: name, Repetition: repetition, Fields: fields}
+}
+
+// ListOf returns a list field in the three-level structure of the LIST logical type.
+func ListOf(name string, repetition Repetition, element Field) Field {
+	element.Name = "element"
+	return Field{
+		Name:       name,
+		Repetition: repetition,
+		Logical:    List,
+		Fields:     []Field{{Name: "list", Repetition: Repeated, Fields: []Field{element}}},
+	}
+}
+
+func (f *Field) isGroup() bool {
+	return len(f.Fields) > 0
+}
+
+// Column is a primitive column of a schema.
+type Column struct {
+	// Path is the path of the column from the root, including the levels of a list.
+	Path []string
+	// FieldPath is the path of the field the column belongs to. It's the path to the list field for a list column.
+	FieldPath []string
+	// MaxDefinitionLevel is the number of optional and repeated fields in the path.
+	MaxDefinitionLevel int
+	// MaxRepetitionLevel is the number of repeated fields in the path, which is 1 for a list column.
+	MaxRepetitionLevel int
+	// listDefinitionLevel is the definition level of an empty list.
+	listDefinitionLevel int
+	Type                Type
+	TypeLength          int32
+	Logical             LogicalType
+	// nullable is true if the primitive field, or the element of a list, is optional.
+	nullable bool
+	// listNullable is true if the list field is optional.
+	listNullable bool
+}
+
+// Name returns the dotted path of the field the column belongs to.
+func (c *Column) Name() string {
+	return strings.Join(c.FieldPath, ".")
+}
+
+// IsList returns true if the column is a list of values.
+func (c *Column) IsList() bool {
+	return c.MaxRepetitionLevel > 0
+}
+
+// Schema is the schema of a file.
+type Schema struct {
+	Name    string
+	Fields  []Field
+	columns []Column
+}
+
+// NewSchema returns a schema with the fields.
+func NewSchema(name string, fields ...Field) (*Schema, error) {
+	s := &Schema{Name: name, Fields: fields}
+	if len(fields) == 0 {
+		return nil, errors.New("schema has no fields")
+	}
+	if err := s.walk(fields, nil, nil, 0, 0, -1, NoLogicalType, false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) walk(fields []Field, path, fieldPath []string, def, rep, listDef int, parentLogical LogicalType, listNullable bool) error {
+	names := make(map[string]struct{}, len(fields))
+	for i := range fields {
+		f := &fields[i]
+		if f.Name == "" {
+			return fmt.Errorf("field of %s has no name", strings.Join(path, "."))
+		}
+		if _, ok := names[f.Name]; ok {
+			return fmt.Errorf("field %s is duplicated", strings.Join(append(path, f.Name), "."))
+		}
+		names[f.Name] = struct{}{}
+		p := append(append([]string(nil), path...), f.Name)
+		fp := fieldPath
+		if parentLogical != List && rep == 0 {
+			fp = append(append([]string(nil), fieldPath...), f.Name)
+		}
+		d, r, ld := def, rep, listDef
+		switch f.Repetition {
+		case Required:
+		case Optional:
+			d++
+		case Repeated:
+			if rep > 0 {
+				return fmt.Errorf("field %s is nested in a repeated field", strings.Join(p, "."))
+			}
+			ld = def
+			d++
+			r++
+		default:
+			return fmt.Errorf("field %s has an unknown repetition %d", strings.Join(p, "."), f.Repetition)
+		}
+		if !f.isGroup() {
+			if f.Logical == List {
+				return fmt.Errorf("list %s has no element", strings.Join(p, "."))
+			}
+			if f.Type == FixedLenByteArray && f.TypeLength <= 0 {
+				return fmt.Errorf("field %s has no type length", strings.Join(p, "."))
+			}
+			s.columns = append(s.columns, Column{
+				Path:                p,
+				FieldPath:           fp,
+				Type:                f.Type,
+				Logical:             f.Logical,
+				TypeLength:          f.TypeLength,
+				MaxDefinitionLevel:  d,
+				MaxRepetitionLevel:  r,
+				listDefinitionLevel: ld,
+				nullable:            f.Repetition == Optional,
+				listNullable:        listNullable,
+			})
+			continue
+		}
+		ln := listNullable
+		if f.Logical == List {
+			ln = f.Repetition == Optional
+		}
+		if err := s.walk(f.Fields, p, fp, d, r, ld, f.Logical, ln); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Columns returns the primitive columns in the order of the fields.
+func (s *Schema) Columns() []Column {
+	return s.columns
+}
+
+// Lookup returns the index of the column of a field.
+func (s *Schema) Lookup(fieldPath ...string) (int, bool) {
+	for i := range s.columns {
+		if equalPath(s.columns[i].FieldPath, fieldPath) || equalPath(s.columns[i].Path, fieldPath) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func equalPath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Kind is the kind of a value.
+type Kind int8
+
+// The kinds of values.
+const (
+	KindNull Kind = iota
+	KindBoolean
+	KindInt
+	KindFloat
+	KindBytes
+	KindList
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBoolean:
+		return "boolean"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindBytes:
+		return "bytes"
+	case KindList:
+		return "list"
+	}
+	return fmt.Sprintf("kind(%d)", int8(k))
+}
+
+// Value is the value of a column in a row. The value of a list column is a list of the element values.
+type Value struct {
+	bytes []byte
+	list  []Value
+	num   uint64
+	kind  Kind
+}
+
+// NullValue returns a null value.
+func NullValue() Value {
+	return Value{}
+}
+
+// BooleanValue returns a boolean value.
+func BooleanValue(v bool) Value {
+	if v {
+		return Value{kind: KindBoolean, num: 1}
+	}
+	return Value{kind: KindBoolean}
+}
+
+// IntValue returns an integer value, which is written to an INT32 or an INT64 column.
+func IntValue(v int64) Value {
+	return Value{kind: KindInt, num: uint64(v)}
+}
+
+// FloatValue returns a floating-point value, which is written to a FLOAT or a DOUBLE column.
+func FloatValue(v float64) Value {
+	return Value{kind: KindFloat, num: math.Float64bits(v)}
+}
+
+// BytesValue returns a byte array value.
+func BytesValue(v []byte) Value {
+	return Value{kind: KindBytes, bytes: v}
+}
+
+// StringValue returns a byte array value holding the string.
+func StringValue(v string) Value {
+	return Value{kind: KindBytes, bytes: []byte(v)}
+}
+
+// ListValue returns a list of values.
+func ListValue(v []Value) Value {
+	return Value{kind: KindList, list: v}
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// IsNull returns true if the value is null.
+func (v Value) IsNull() bool {
+	return v.kind == KindNull
+}
+
+// Boolean returns the boolean value.
+func (v Value) Boolean() bool {
+	return v.num != 0
+}
+
+// Int returns the integer value.
+func (v Value) Int() int64 {
+	return int64(v.num)
+}
+
+// Float returns the floating-point value.
+func (v Value) Float() float64 {
+	return math.Float64frombits(v.num)
+}
+
+// Bytes returns the byte array value.
+func (v Value) Bytes() []byte {
+	return v.bytes
+}
+
+// List returns the values of a list.
+func (v Value) List() []Value {
+	return v.list
+}
@@ -0,0 +1,178 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parquet_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/parquet"
+)
+
+func testSchema(t *testing.T) *parquet.Schema {
+	s, err := parquet.NewSchema("test",
+		parquet.Leaf("timestamp", parquet.Int64, parquet.TimestampMillis, parquet.Required),
+		parquet.Group("default", parquet.Required,
+			parquet.Leaf("service", parquet.ByteArray, parquet.String, parquet.Optional),
+			parquet.ListOf("labels", parquet.Optional, parquet.Leaf("", parquet.ByteArray, parquet.String, parquet.Optional)),
+			parquet.ListOf("codes", parquet.Optional, parquet.Leaf("", parquet.Int64, parquet.NoLogicalType, parquet.Required)),
+			parquet.Leaf("success", parquet.Boolean, parquet.NoLogicalType, parquet.Optional),
+		),
+		parquet.Leaf("value", parquet.Double, parquet.NoLogicalType, parquet.Optional),
+		parquet.Leaf("count", parquet.Int32, parquet.NoLogicalType, parquet.Optional),
+		parquet.Leaf("raw", parquet.ByteArray, parquet.NoLogicalType, parquet.Optional),
+	)
+	require.NoError(t, err)
+	return s
+}
+
+func testRow(i int) []parquet.Value {
+	row := []parquet.Value{
+		parquet.IntValue(int64(1_700_000_000_000 + i)),
+		parquet.StringValue(fmt.Sprintf("service-%d", i%7)),
+		parquet.NullValue(),
+		parquet.ListValue([]parquet.Value{}),
+		parquet.BooleanValue(i%3 == 0),
+		parquet.FloatValue(float64(i) / 4),
+		parquet.IntValue(int64(-i)),
+		parquet.BytesValue([]byte(fmt.Sprintf("raw-%d", i))),
+	}
+	if i%2 == 0 {
+		row[2] = parquet.ListValue([]parquet.Value{parquet.StringValue("a"), parquet.NullValue(), parquet.StringValue(fmt.Sprint(i))})
+	}
+	if i%5 != 0 {
+		row[3] = parquet.ListValue([]parquet.Value{parquet.IntValue(int64(i)), parquet.IntValue(int64(i * 2))})
+	}
+	if i%11 == 0 {
+		row[1], row[4], row[5], row[6], row[7] = parquet.NullValue(), parquet.NullValue(), parquet.NullValue(), parquet.NullValue(), parquet.NullValue()
+	}
+	return row
+}
+
+func TestRoundTrip(t *testing.T) {
+	const rows = 20000
+	for _, codec := range []parquet.Codec{parquet.Uncompressed, parquet.Snappy, parquet.Gzip, parquet.Zstd} {
+		t.Run(codec.String(), func(t *testing.T) {
+			schema := testSchema(t)
+			var buf bytes.Buffer
+			w, err := parquet.NewWriter(&buf, schema,
+				parquet.WithCodec(codec), parquet.WithRowGroupRows(7000), parquet.WithKeyValue("k", "v"))
+			require.NoError(t, err)
+			for i := 0; i < rows; i++ {
+				require.NoError(t, w.Write(testRow(i)))
+			}
+			require.NoError(t, w.Close())
+
+			r, err := parquet.OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err)
+			require.Equal(t, int64(rows), r.NumRows())
+			require.Equal(t, 3, r.NumRowGroups())
+			require.Equal(t, map[string]string{"k": "v"}, r.KeyValueMetadata())
+			require.Equal(t, schema.Columns(), r.Schema().Columns())
+
+			i := 0
+			for g := 0; g < r.NumRowGroups(); g++ {
+				columns, err := r.ReadRowGroup(g, nil)
+				require.NoError(t, err)
+				for j := range columns[0] {
+					want := testRow(i)
+					for c := range columns {
+						require.Equal(t, want[c], columns[c][j], "row %d column %d", i, c)
+					}
+					i++
+				}
+			}
+			require.Equal(t, rows, i)
+		})
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, testSchema(t))
+	require.NoError(t, err)
+	require.NoError(t, w.Write(testRow(0)))
+	require.NoError(t, w.Close())
+
+	f, err := pq.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, c := range f.Root().Columns() {
+		names = append(names, c.Name())
+	}
+	require.Equal(t, []string{"timestamp", "default", "value", "count", "raw"}, names, "the fields keep their order")
+}
+
+func TestReadColumns(t *testing.T) {
+	schema := testSchema(t)
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, schema)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Write(testRow(i)))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := parquet.OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	idx, ok := r.Schema().Lookup("default", "labels")
+	require.True(t, ok)
+	require.True(t, r.Schema().Columns()[idx].IsList())
+	columns, err := r.ReadRowGroup(0, []int{idx})
+	require.NoError(t, err)
+	require.Len(t, columns, 1)
+	require.Equal(t, testRow(2)[2], columns[0][2])
+}
+
+func TestWriteInvalidRows(t *testing.T) {
+	schema := testSchema(t)
+	w, err := parquet.NewWriter(&bytes.Buffer{}, schema)
+	require.NoError(t, err)
+	row := testRow(1)
+	require.Error(t, w.Write(row[:3]))
+
+	row[0] = parquet.NullValue()
+	require.ErrorContains(t, w.Write(row), "required")
+
+	row = testRow(1)
+	row[5] = parquet.StringValue("not a double")
+	require.Error(t, w.Write(row))
+
+	row = testRow(1)
+	row[3] = parquet.ListValue([]parquet.Value{parquet.NullValue()})
+	require.ErrorContains(t, w.Write(row), "required")
+
+	row = testRow(1)
+	row[6] = parquet.IntValue(1 << 40)
+	require.ErrorContains(t, w.Write(row), "overflows")
+}
+
+func TestOpenInvalidFile(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("PAR1"),
+		[]byte("PAR1\x00\x00\x00\x00\x00\x00\x00\x00PAR0"),
+		[]byte("PAR1\x00\x00\x00\x00\xff\x00\x00\x00PAR1"),
+	} {
+		_, err := parquet.OpenReader(bytes.NewReader(data), int64(len(data)))
+		require.Error(t, err)
+	}
+}
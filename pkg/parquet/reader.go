@@ -0,0 +1,344 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// readBatchSize is the number of the values read from a page at a time.
+const readBatchSize = 1024
+
+// Reader reads the rows of a file.
+type Reader struct {
+	file      *pq.File
+	schema    *Schema
+	keyValues map[string]string
+}
+
+// OpenReader reads the footer of a file of the size.
+func OpenReader(r io.ReaderAt, size int64) (*Reader, error) {
+	f, err := pq.OpenFile(r, size, pq.SkipPageIndex(true), pq.SkipBloomFilters(true))
+	if err != nil {
+		return nil, err
+	}
+	pr := &Reader{file: f}
+	if pr.schema, err = schemaFromElements(f.Metadata().Schema); err != nil {
+		return nil, err
+	}
+	for i, g := range f.RowGroups() {
+		if len(g.ColumnChunks()) != len(pr.schema.columns) {
+			return nil, fmt.Errorf("row group %d has %d columns, expected %d", i, len(g.ColumnChunks()), len(pr.schema.columns))
+		}
+	}
+	pr.keyValues = make(map[string]string, len(f.Metadata().KeyValueMetadata))
+	for _, kv := range f.Metadata().KeyValueMetadata {
+		pr.keyValues[kv.Key] = kv.Value
+	}
+	return pr, nil
+}
+
+// Schema returns the schema of the file.
+func (r *Reader) Schema() *Schema {
+	return r.schema
+}
+
+// NumRows returns the number of rows in the file.
+func (r *Reader) NumRows() int64 {
+	return r.file.NumRows()
+}
+
+// NumRowGroups returns the number of row groups in the file.
+func (r *Reader) NumRowGroups() int {
+	return len(r.file.RowGroups())
+}
+
+// KeyValueMetadata returns the key-value metadata of the file.
+func (r *Reader) KeyValueMetadata() map[string]string {
+	return r.keyValues
+}
+
+// ReadRowGroup reads the columns of a row group, and returns the values of each column in the rows.
+// The nil columns read all the columns of the schema.
+func (r *Reader) ReadRowGroup(i int, columns []int) ([][]Value, error) {
+	groups := r.file.RowGroups()
+	if i < 0 || i >= len(groups) {
+		return nil, fmt.Errorf("row group %d is out of range", i)
+	}
+	g := groups[i]
+	if columns == nil {
+		columns = make([]int, len(r.schema.columns))
+		for c := range columns {
+			columns[c] = c
+		}
+	}
+	result := make([][]Value, len(columns))
+	for j, c := range columns {
+		if c < 0 || c >= len(r.schema.columns) {
+			return nil, fmt.Errorf("column %d is out of range", c)
+		}
+		col := &r.schema.columns[c]
+		p := &columnPages{col: col}
+		if err := p.read(g.ColumnChunks()[c]); err != nil {
+			return nil, fmt.Errorf("cannot read column %s of row group %d: %w", col.Name(), i, err)
+		}
+		values, err := p.assemble(g.NumRows())
+		if err != nil {
+			return nil, fmt.Errorf("cannot read column %s of row group %d: %w", col.Name(), i, err)
+		}
+		result[j] = values
+	}
+	return result, nil
+}
+
+// columnPages holds the levels and the non-null values read from the pages of a column chunk.
+type columnPages struct {
+	col    *Column
+	defs   []int32
+	reps   []int32
+	values []Value
+}
+
+func (p *columnPages) read(chunk pq.ColumnChunk) error {
+	pages := chunk.Pages()
+	defer pages.Close()
+	buf := make([]pq.Value, readBatchSize)
+	for {
+		page, err := pages.ReadPage()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = p.readPage(page, buf)
+		pq.Release(page)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *columnPages) readPage(page pq.Page, buf []pq.Value) error {
+	values := page.Values()
+	for {
+		n, err := values.ReadValues(buf)
+		for _, v := range buf[:n] {
+			p.defs = append(p.defs, int32(v.DefinitionLevel()))
+			if p.col.IsList() {
+				p.reps = append(p.reps, int32(v.RepetitionLevel()))
+			}
+			if !v.IsNull() {
+				p.values = append(p.values, valueOf(v))
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// valueOf converts a non-null value, whose byte array is copied since the page is released after reading.
+func valueOf(v pq.Value) Value {
+	switch v.Kind() {
+	case pq.Boolean:
+		return BooleanValue(v.Boolean())
+	case pq.Int32:
+		return IntValue(int64(v.Int32()))
+	case pq.Int64:
+		return IntValue(v.Int64())
+	case pq.Int96:
+		return IntValue(int96ToUnixNano(v.Int96()))
+	case pq.Float:
+		return FloatValue(float64(v.Float()))
+	case pq.Double:
+		return FloatValue(v.Double())
+	}
+	return BytesValue(bytes.Clone(v.ByteArray()))
+}
+
+const (
+	julianUnixEpoch = 2440588
+	nanosPerDay     = 86400 * 1e9
+)
+
+// int96ToUnixNano converts an INT96 timestamp, which holds the nanoseconds of the day and the Julian day, to nanoseconds.
+func int96ToUnixNano(v deprecated.Int96) int64 {
+	nanos := int64(uint64(v[0]) | uint64(v[1])<<32)
+	return (int64(v[2])-julianUnixEpoch)*nanosPerDay + nanos
+}
+
+// assemble builds the values of the rows from the levels.
+func (p *columnPages) assemble(numRows int64) ([]Value, error) {
+	col := p.col
+	if col.IsList() && len(p.reps) != len(p.defs) {
+		return nil, fmt.Errorf("%d repetition levels and %d definition levels", len(p.reps), len(p.defs))
+	}
+	rows := make([]Value, 0, numRows)
+	next := 0
+	value := func(def int32) (Value, error) {
+		if int(def) != col.MaxDefinitionLevel {
+			return NullValue(), nil
+		}
+		if next >= len(p.values) {
+			return Value{}, errors.New("values are fewer than the levels")
+		}
+		next++
+		return p.values[next-1], nil
+	}
+	for i, d := range p.defs {
+		if !col.IsList() {
+			v, err := value(d)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, v)
+			continue
+		}
+		if p.reps[i] == 0 {
+			switch {
+			case int(d) < col.listDefinitionLevel:
+				rows = append(rows, NullValue())
+				continue
+			case int(d) == col.listDefinitionLevel:
+				rows = append(rows, ListValue([]Value{}))
+				continue
+			}
+			rows = append(rows, ListValue(nil))
+		} else if len(rows) == 0 || rows[len(rows)-1].kind != KindList || int(d) <= col.listDefinitionLevel {
+			return nil, fmt.Errorf("invalid levels of a list element at %d", i)
+		}
+		v, err := value(d)
+		if err != nil {
+			return nil, err
+		}
+		last := &rows[len(rows)-1]
+		last.list = append(last.list, v)
+	}
+	if next != len(p.values) {
+		return nil, fmt.Errorf("%d values, expected %d", len(p.values), next)
+	}
+	if int64(len(rows)) != numRows {
+		return nil, fmt.Errorf("%d rows, expected %d", len(rows), numRows)
+	}
+	return rows, nil
+}
+
+func schemaFromElements(elements []format.SchemaElement) (*Schema, error) {
+	if len(elements) == 0 {
+		return nil, errors.New("file has no schema")
+	}
+	numChildren, _ := elements[0].NumChildren.Get()
+	fields, rest, err := fieldsFromElements(elements[1:], int(numChildren))
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("schema has %d dangling elements", len(rest))
+	}
+	s, err := NewSchema(elements[0].Name, fields...)
+	if err != nil {
+		return nil, err
+	}
+	// INT96 is the legacy timestamp in nanoseconds, which is still written by Spark by default.
+	for i := range s.columns {
+		if s.columns[i].Type == Int96 {
+			s.columns[i].Logical = TimestampNanos
+		}
+	}
+	return s, nil
+}
+
+func fieldsFromElements(elements []format.SchemaElement, n int) ([]Field, []format.SchemaElement, error) {
+	if n < 0 || n > len(elements) {
+		return nil, nil, fmt.Errorf("invalid number of children %d", n)
+	}
+	fields := make([]Field, 0, n)
+	for i := 0; i < n; i++ {
+		e := &elements[0]
+		elements = elements[1:]
+		f := Field{Name: e.Name, Repetition: Required, Logical: logicalType(e)}
+		if repetition, ok := e.RepetitionType.Get(); ok {
+			f.Repetition = Repetition(repetition)
+		}
+		numChildren, _ := e.NumChildren.Get()
+		typ, hasType := e.Type.Get()
+		switch {
+		case numChildren > 0:
+			var err error
+			if f.Fields, elements, err = fieldsFromElements(elements, int(numChildren)); err != nil {
+				return nil, nil, err
+			}
+		case !hasType:
+			return nil, nil, fmt.Errorf("field %s has neither type nor children", e.Name)
+		default:
+			f.Type = Type(typ)
+			if f.Type == FixedLenByteArray {
+				f.TypeLength, _ = e.TypeLength.Get()
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, elements, nil
+}
+
+func logicalType(e *format.SchemaElement) LogicalType {
+	switch t := e.LogicalType.Value.(type) {
+	case *format.StringType, *format.EnumType:
+		return String
+	case *format.JsonType:
+		return JSON
+	case *format.ListType:
+		return List
+	case *format.TimestampType:
+		switch t.Unit.Value.(type) {
+		case *format.MilliSeconds:
+			return TimestampMillis
+		case *format.MicroSeconds:
+			return TimestampMicros
+		case *format.NanoSeconds:
+			return TimestampNanos
+		}
+	}
+	convertedType, ok := e.ConvertedType.Get()
+	if !ok {
+		return NoLogicalType
+	}
+	switch convertedType {
+	case deprecated.UTF8, deprecated.Enum:
+		return String
+	case deprecated.Json:
+		return JSON
+	case deprecated.List:
+		return List
+	case deprecated.TimestampMillis:
+		return TimestampMillis
+	case deprecated.TimestampMicros:
+		return TimestampMicros
+	}
+	return NoLogicalType
+}
@@ -0,0 +1,363 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+const (
+	defaultRowGroupRows = 64 * 1024
+	maxDictionarySize   = 1 << 20
+	defaultCreatedBy    = "skywalking-banyandb"
+)
+
+// WriterOption configures a Writer.
+type WriterOption func(*Writer)
+
+// WithCodec sets the compression codec of the pages, which is ZSTD by default.
+func WithCodec(codec Codec) WriterOption {
+	return func(w *Writer) {
+		w.codec = codec
+	}
+}
+
+// WithRowGroupRows sets the number of rows in a row group.
+func WithRowGroupRows(rows int) WriterOption {
+	return func(w *Writer) {
+		if rows > 0 {
+			w.rowGroupRows = rows
+		}
+	}
+}
+
+// WithKeyValue adds a key-value pair to the metadata of the file.
+func WithKeyValue(key, value string) WriterOption {
+	return func(w *Writer) {
+		w.options = append(w.options, pq.KeyValueMetadata(key, value))
+	}
+}
+
+// WithCreatedBy sets the application writing the file.
+func WithCreatedBy(createdBy string) WriterOption {
+	return func(w *Writer) {
+		w.createdBy = createdBy
+	}
+}
+
+// Writer writes the rows into a file. The rows of a row group are buffered in memory until the group is full.
+type Writer struct {
+	w            *pq.Writer
+	schema       *Schema
+	createdBy    string
+	options      []pq.WriterOption
+	row          pq.Row
+	rowGroupRows int
+	codec        Codec
+	closed       bool
+}
+
+// NewWriter returns a writer writing the rows of the schema into w.
+func NewWriter(w io.Writer, schema *Schema, opts ...WriterOption) (*Writer, error) {
+	pw := &Writer{
+		schema:       schema,
+		createdBy:    defaultCreatedBy,
+		rowGroupRows: defaultRowGroupRows,
+		codec:        Zstd,
+	}
+	for _, opt := range opts {
+		opt(pw)
+	}
+	codec, err := pw.codec.compressCodec()
+	if err != nil {
+		return nil, err
+	}
+	root, err := groupOf(schema.Fields)
+	if err != nil {
+		return nil, err
+	}
+	pw.w = pq.NewWriter(w, append([]pq.WriterOption{
+		pq.NewSchema(schema.Name, root),
+		pq.Compression(codec),
+		pq.MaxRowsPerRowGroup(int64(pw.rowGroupRows)),
+		pq.CreatedBy(pw.createdBy, "", ""),
+		pq.DefaultEncodingFor(pq.ByteArray, &pq.RLEDictionary),
+		pq.DictionaryMaxBytes(maxDictionarySize),
+	}, pw.options...)...)
+	return pw, nil
+}
+
+// Write writes a row, which holds a value for each column of the schema.
+// The value of a list column is a list value or a null.
+func (w *Writer) Write(row []Value) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	if len(row) != len(w.schema.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(row), len(w.schema.columns))
+	}
+	for i := range row {
+		if err := checkColumnValue(&w.schema.columns[i], row[i]); err != nil {
+			return err
+		}
+	}
+	w.row = w.row[:0]
+	for i := range row {
+		w.row = appendColumnValue(w.row, &w.schema.columns[i], i, row[i])
+	}
+	_, err := w.w.WriteRows([]pq.Row{w.row})
+	return err
+}
+
+// Close flushes the buffered rows and writes the footer of the file. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.w.Close()
+}
+
+func checkColumnValue(c *Column, v Value) error {
+	if !c.IsList() {
+		if v.IsNull() {
+			if !c.nullable {
+				return fmt.Errorf("column %s is required", c.Name())
+			}
+			return nil
+		}
+		return checkValue(c, v)
+	}
+	if v.IsNull() {
+		return nil
+	}
+	if v.kind != KindList {
+		return fmt.Errorf("column %s expects a list, got kind %s", c.Name(), v.kind)
+	}
+	for _, e := range v.list {
+		if e.IsNull() {
+			if !c.nullable {
+				return fmt.Errorf("elements of column %s are required", c.Name())
+			}
+			continue
+		}
+		if err := checkValue(c, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValue checks whether the non-null value fits the type of the column.
+func checkValue(c *Column, v Value) error {
+	var kind Kind
+	switch c.Type {
+	case Boolean:
+		kind = KindBoolean
+	case Int32:
+		if v.kind == KindInt && (v.Int() < math.MinInt32 || v.Int() > math.MaxInt32) {
+			return fmt.Errorf("column %s: %d overflows INT32", c.Name(), v.Int())
+		}
+		kind = KindInt
+	case Int64:
+		kind = KindInt
+	case Float, Double:
+		kind = KindFloat
+	case ByteArray:
+		kind = KindBytes
+	case Int96, FixedLenByteArray:
+		size := int(c.TypeLength)
+		if c.Type == Int96 {
+			size = 12
+		}
+		if v.kind != KindBytes || len(v.bytes) != size {
+			return fmt.Errorf("column %s expects a byte array of %d bytes", c.Name(), size)
+		}
+		return nil
+	default:
+		return fmt.Errorf("column %s has an unsupported type %s", c.Name(), c.Type)
+	}
+	if v.kind != kind {
+		return fmt.Errorf("column %s of %s can't hold a value of kind %s", c.Name(), c.Type, v.kind)
+	}
+	return nil
+}
+
+// appendColumnValue appends the leveled values of the column, which are checked by checkColumnValue, to the row.
+func appendColumnValue(row pq.Row, c *Column, index int, v Value) pq.Row {
+	if !c.IsList() {
+		if v.IsNull() {
+			return append(row, pq.Value{}.Level(0, c.MaxDefinitionLevel-1, index))
+		}
+		return append(row, primitiveValue(c, v).Level(0, c.MaxDefinitionLevel, index))
+	}
+	if len(v.list) == 0 {
+		// A null list of a required list field is written as an empty list.
+		def := c.listDefinitionLevel
+		if v.IsNull() && c.listNullable {
+			def--
+		}
+		return append(row, pq.Value{}.Level(0, def, index))
+	}
+	for i, e := range v.list {
+		rep := 1
+		if i == 0 {
+			rep = 0
+		}
+		if e.IsNull() {
+			row = append(row, pq.Value{}.Level(rep, c.MaxDefinitionLevel-1, index))
+			continue
+		}
+		row = append(row, primitiveValue(c, e).Level(rep, c.MaxDefinitionLevel, index))
+	}
+	return row
+}
+
+func primitiveValue(c *Column, v Value) pq.Value {
+	switch c.Type {
+	case Boolean:
+		return pq.BooleanValue(v.Boolean())
+	case Int32:
+		return pq.Int32Value(int32(v.Int()))
+	case Int64:
+		return pq.Int64Value(v.Int())
+	case Int96:
+		return pq.Int96Value(deprecated.Int96{
+			binary.LittleEndian.Uint32(v.bytes), binary.LittleEndian.Uint32(v.bytes[4:]), binary.LittleEndian.Uint32(v.bytes[8:]),
+		})
+	case Float:
+		return pq.FloatValue(float32(v.Float()))
+	case Double:
+		return pq.DoubleValue(v.Float())
+	case FixedLenByteArray:
+		return pq.FixedLenByteArrayValue(v.bytes)
+	}
+	return pq.ByteArrayValue(v.bytes)
+}
+
+// groupOf returns the node of a group holding the fields in their order, which parquet.Group sorts by the names.
+func groupOf(fields []Field) (pq.Node, error) {
+	g := &orderedGroup{Group: make(pq.Group, len(fields)), fields: make([]pq.Field, 0, len(fields))}
+	for i := range fields {
+		n, err := nodeOf(&fields[i])
+		if err != nil {
+			return nil, err
+		}
+		g.Group[fields[i].Name] = n
+		g.fields = append(g.fields, &orderedField{Node: n, name: fields[i].Name})
+	}
+	return g, nil
+}
+
+func nodeOf(f *Field) (pq.Node, error) {
+	var n pq.Node
+	switch {
+	case f.Logical == List && len(f.Fields) == 1 && f.Fields[0].Repetition == Repeated && len(f.Fields[0].Fields) == 1:
+		element, err := nodeOf(&f.Fields[0].Fields[0])
+		if err != nil {
+			return nil, err
+		}
+		n = pq.List(element)
+	case f.isGroup():
+		g, err := groupOf(f.Fields)
+		if err != nil {
+			return nil, err
+		}
+		n = g
+	default:
+		leaf, err := leafOf(f)
+		if err != nil {
+			return nil, err
+		}
+		n = leaf
+	}
+	switch f.Repetition {
+	case Optional:
+		return pq.Optional(n), nil
+	case Repeated:
+		return pq.Repeated(n), nil
+	}
+	return pq.Required(n), nil
+}
+
+func leafOf(f *Field) (pq.Node, error) {
+	switch f.Type {
+	case Boolean:
+		return pq.Leaf(pq.BooleanType), nil
+	case Int32:
+		return pq.Leaf(pq.Int32Type), nil
+	case Int64:
+		switch f.Logical {
+		case TimestampMillis:
+			return pq.Timestamp(pq.Millisecond), nil
+		case TimestampMicros:
+			return pq.Timestamp(pq.Microsecond), nil
+		case TimestampNanos:
+			return pq.Timestamp(pq.Nanosecond), nil
+		}
+		return pq.Leaf(pq.Int64Type), nil
+	case Int96:
+		return pq.Leaf(pq.Int96Type), nil
+	case Float:
+		return pq.Leaf(pq.FloatType), nil
+	case Double:
+		return pq.Leaf(pq.DoubleType), nil
+	case ByteArray:
+		switch f.Logical {
+		case String:
+			return pq.String(), nil
+		case JSON:
+			return pq.JSON(), nil
+		}
+		return pq.Leaf(pq.ByteArrayType), nil
+	case FixedLenByteArray:
+		return pq.Leaf(pq.FixedLenByteArrayType(int(f.TypeLength))), nil
+	}
+	return nil, fmt.Errorf("field %s has an unsupported type %s", f.Name, f.Type)
+}
+
+// orderedGroup is a group keeping the order of its fields.
+type orderedGroup struct {
+	pq.Group
+	fields []pq.Field
+}
+
+func (g *orderedGroup) Fields() []pq.Field {
+	return g.fields
+}
+
+// orderedField is a field of an orderedGroup. The rows are written as parquet.Row, so it has no Go value.
+type orderedField struct {
+	pq.Node
+	name string
+}
+
+func (f *orderedField) Name() string {
+	return f.name
+}
+
+func (f *orderedField) Value(reflect.Value) reflect.Value {
+	return reflect.Value{}
+}
@@ -40,8 +40,10 @@ type Partial[N Number] struct {
 
 // Map accumulates raw values and produces aggregation results.
 // It serves as the local accumulator for raw data points.
+// Combine merges the partial results pre-aggregated by the storage, such as the block stats.
 type Map[N Number] interface {
 	In(N)
+	Combine(Partial[N])
 	Val() N
 	Partial() Partial[N]
 	Reset()
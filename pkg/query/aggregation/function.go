@@ -28,6 +28,11 @@ func (m *meanFunc[N]) In(val N) {
 	m.count++
 }
 
+func (m *meanFunc[N]) Combine(p Partial[N]) {
+	m.sum += p.Value
+	m.count += p.Count
+}
+
 func (m meanFunc[N]) Val() N {
 	if m.count == m.zero {
 		return m.zero
@@ -84,6 +89,10 @@ func (c *countFunc[N]) In(_ N) {
 	c.count++
 }
 
+func (c *countFunc[N]) Combine(p Partial[N]) {
+	c.count += p.Value
+}
+
 func (c countFunc[N]) Val() N {
 	return c.count
 }
@@ -122,6 +131,10 @@ func (s *sumFunc[N]) In(val N) {
 	s.sum += val
 }
 
+func (s *sumFunc[N]) Combine(p Partial[N]) {
+	s.sum += p.Value
+}
+
 func (s sumFunc[N]) Val() N {
 	return s.sum
 }
@@ -162,6 +175,10 @@ func (m *maxFunc[N]) In(val N) {
 	}
 }
 
+func (m *maxFunc[N]) Combine(p Partial[N]) {
+	m.In(p.Value)
+}
+
 func (m maxFunc[N]) Val() N {
 	return m.val
 }
@@ -204,6 +221,10 @@ func (m *minFunc[N]) In(val N) {
 	}
 }
 
+func (m *minFunc[N]) Combine(p Partial[N]) {
+	m.In(p.Value)
+}
+
 func (m minFunc[N]) Val() N {
 	return m.val
 }
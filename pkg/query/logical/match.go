@@ -0,0 +1,338 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/pkg/errors"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/analyzer"
+)
+
+const (
+	// MaxFuzziness is the maximum edit distance supported by a fuzzy match.
+	MaxFuzziness = 2
+	// MaxSlop is the maximum slop of a phrase match.
+	// The phrase searcher of the inverted index walks through the paths of the phrase within the slop, which grow quickly with it.
+	MaxSlop = 10
+)
+
+// ValidateMatchOption checks whether the phrase, slop and fuzziness of a match option are compatible.
+func ValidateMatchOption(opts *modelv1.Condition_MatchOption) error {
+	if opts.GetFuzziness() > MaxFuzziness {
+		return errors.WithMessagef(ErrUnsupportedConditionValue, "fuzziness %d exceeds the maximum %d", opts.GetFuzziness(), MaxFuzziness)
+	}
+	if opts.GetPhrase() && opts.GetFuzziness() > 0 {
+		return errors.WithMessage(ErrUnsupportedConditionValue, "fuzziness can't be used together with phrase")
+	}
+	if opts.GetSlop() > MaxSlop {
+		return errors.WithMessagef(ErrUnsupportedConditionValue, "slop %d exceeds the maximum %d", opts.GetSlop(), MaxSlop)
+	}
+	if !opts.GetPhrase() && opts.GetSlop() > 0 {
+		return errors.WithMessage(ErrUnsupportedConditionValue, "slop is only applicable to phrase")
+	}
+	return nil
+}
+
+// Span is the byte range [Start, End) of a matched term in a tag value.
+type Span struct {
+	Start int
+	End   int
+}
+
+type matchTerm struct {
+	term string
+	pos  int
+}
+
+// Matcher evaluates a match condition against a string in the same way as the inverted index.
+type Matcher struct {
+	valueAnalyzer *analysis.Analyzer
+	terms         []matchTerm
+	slop          int
+	fuzziness     int
+	phrase        bool
+	and           bool
+}
+
+// NewMatcher creates a Matcher for the match condition on a tag indexed by the analyzer.
+// The whole value is treated as a single term if the tag isn't analyzed.
+func NewMatcher(cond *modelv1.Condition, indexAnalyzer string) (*Matcher, error) {
+	v, ok := cond.GetValue().GetValue().(*modelv1.TagValue_Str)
+	if !ok {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "MATCH condition requires string value type: %s", cond)
+	}
+	opts := cond.GetMatchOption()
+	if err := ValidateMatchOption(opts); err != nil {
+		return nil, err
+	}
+	if indexAnalyzer == index.AnalyzerUnspecified {
+		indexAnalyzer = index.AnalyzerKeyword
+	}
+	valueAnalyzer := analyzer.Get(indexAnalyzer)
+	if valueAnalyzer == nil {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "analyzer %q is not found for tag %q", indexAnalyzer, cond.Name)
+	}
+	queryAnalyzer := valueAnalyzer
+	if opts.GetAnalyzer() != index.AnalyzerUnspecified {
+		if queryAnalyzer = analyzer.Get(opts.GetAnalyzer()); queryAnalyzer == nil {
+			return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "analyzer %q is not found", opts.GetAnalyzer())
+		}
+	}
+	m := &Matcher{
+		valueAnalyzer: valueAnalyzer,
+		phrase:        opts.GetPhrase(),
+		and:           opts.GetOperator() == modelv1.Condition_MatchOption_OPERATOR_AND,
+		slop:          int(opts.GetSlop()),
+		fuzziness:     int(opts.GetFuzziness()),
+	}
+	pos := 0
+	for _, t := range queryAnalyzer.Analyze([]byte(v.Str.GetValue())) {
+		pos += t.PositionIncr
+		m.terms = append(m.terms, matchTerm{term: string(t.Term), pos: pos})
+	}
+	return m, nil
+}
+
+// Match reports whether the value is hit by the match condition.
+func (m *Matcher) Match(value string) bool {
+	return len(m.Locate(value)) > 0
+}
+
+// Locate returns the sorted spans of the terms in the value hit by the match condition.
+// It returns nil if the value isn't hit.
+func (m *Matcher) Locate(value string) []Span {
+	if len(m.terms) == 0 {
+		return nil
+	}
+	tokens := m.valueAnalyzer.Analyze([]byte(value))
+	var spans []Span
+	if m.phrase {
+		spans = m.locatePhrase(tokens)
+	} else {
+		spans = m.locateTerms(tokens)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return spans
+}
+
+func (m *Matcher) locateTerms(tokens analysis.TokenStream) []Span {
+	hit := make([]bool, len(m.terms))
+	var spans []Span
+	for _, t := range tokens {
+		matched := false
+		for i := range m.terms {
+			if m.termMatches(string(t.Term), m.terms[i].term) {
+				hit[i] = true
+				matched = true
+			}
+		}
+		if matched {
+			spans = append(spans, Span{Start: t.Start, End: t.End})
+		}
+	}
+	for _, h := range hit {
+		if m.and && !h {
+			return nil
+		}
+		if !m.and && h {
+			return spans
+		}
+	}
+	if m.and {
+		return spans
+	}
+	return nil
+}
+
+func (m *Matcher) termMatches(term, target string) bool {
+	if m.fuzziness == 0 {
+		return term == target
+	}
+	return editDistance([]rune(term), []rune(target)) <= m.fuzziness
+}
+
+type phraseLoc struct {
+	pos   int
+	token int
+}
+
+type phraseState struct {
+	used string
+	slot int
+	prev int
+	slop int
+}
+
+// phraseWalker finds the paths of a phrase through the locations of its terms.
+type phraseWalker struct {
+	memo map[phraseState]bool
+	hit  map[int]struct{}
+	// slots are the locations of the terms at each position of the phrase
+	slots [][]phraseLoc
+	// gaps are the positions between each slot and the previous one
+	gaps []int
+	// laterTerms are the terms of the slots from each slot to the end
+	laterTerms []map[string]struct{}
+	terms      []string
+	slop       int
+}
+
+// locatePhrase finds the tokens on the paths of the phrase with the same slop rule as the phrase searcher of the inverted index.
+func (m *Matcher) locatePhrase(tokens analysis.TokenStream) []Span {
+	locations := make(map[string][]phraseLoc)
+	terms := make([]string, len(tokens))
+	pos := 0
+	for i, t := range tokens {
+		pos += t.PositionIncr
+		terms[i] = string(t.Term)
+		locations[terms[i]] = append(locations[terms[i]], phraseLoc{pos: pos, token: i})
+	}
+	w := &phraseWalker{
+		memo:  make(map[phraseState]bool),
+		hit:   make(map[int]struct{}),
+		terms: terms,
+		slop:  m.slop,
+	}
+	var slotTerms [][]string
+	for i, t := range m.terms {
+		if i > 0 && t.pos == m.terms[i-1].pos {
+			w.slots[len(w.slots)-1] = append(w.slots[len(w.slots)-1], locations[t.term]...)
+			slotTerms[len(slotTerms)-1] = append(slotTerms[len(slotTerms)-1], t.term)
+			continue
+		}
+		gap := 0
+		if i > 0 {
+			gap = t.pos - m.terms[i-1].pos
+		}
+		w.gaps = append(w.gaps, gap)
+		w.slots = append(w.slots, slices.Clone(locations[t.term]))
+		slotTerms = append(slotTerms, []string{t.term})
+	}
+	w.laterTerms = make([]map[string]struct{}, len(w.slots))
+	later := make(map[string]struct{})
+	for i := len(w.slots) - 1; i >= 0; i-- {
+		for _, t := range slotTerms[i] {
+			later[t] = struct{}{}
+		}
+		w.laterTerms[i] = make(map[string]struct{}, len(later))
+		for t := range later {
+			w.laterTerms[i][t] = struct{}{}
+		}
+	}
+	if !w.walk(0, phraseLoc{}, m.slop, nil) {
+		return nil
+	}
+	spans := make([]Span, 0, len(w.hit))
+	for i := range w.hit {
+		spans = append(spans, Span{Start: tokens[i].Start, End: tokens[i].End})
+	}
+	return spans
+}
+
+// walk reports whether there are paths from the slot to the end of the phrase, and marks the tokens on them as hit.
+// A path can't use a location twice, so the paths depend on the used locations which the rest of the phrase could reach.
+// They are empty unless the phrase repeats a term, which keeps the walk polynomial for the other phrases.
+// The tokens on the paths from a state are marked the first time it's walked through, so a memoized state is skipped.
+func (w *phraseWalker) walk(slot int, prev phraseLoc, slop int, used []phraseLoc) bool {
+	if slot == len(w.slots) {
+		return true
+	}
+	state := phraseState{slot: slot, prev: prev.token, slop: slop, used: w.reachableUsed(slot, prev, slop, used)}
+	if found, ok := w.memo[state]; ok {
+		return found
+	}
+	found := false
+next:
+	for _, loc := range w.slots[slot] {
+		dist := 0
+		if slot > 0 {
+			dist = abs(prev.pos + w.gaps[slot] - loc.pos)
+		}
+		if dist > slop {
+			continue
+		}
+		for _, u := range used {
+			if u == loc {
+				continue next
+			}
+		}
+		if w.walk(slot+1, loc, slop-dist, append(used, loc)) {
+			w.hit[loc.token] = struct{}{}
+			found = true
+		}
+	}
+	w.memo[state] = found
+	return found
+}
+
+// reachableUsed encodes the used locations which the terms from the slot to the end of the phrase could move to.
+func (w *phraseWalker) reachableUsed(slot int, prev phraseLoc, slop int, used []phraseLoc) string {
+	var b strings.Builder
+	for _, u := range used {
+		if _, ok := w.laterTerms[slot][w.terms[u.token]]; !ok {
+			continue
+		}
+		// the rest of the phrase moves forward from the previous location, and the slop left limits how far it could step back
+		if u.pos < prev.pos+w.gaps[slot]-slop {
+			continue
+		}
+		b.WriteString(strconv.Itoa(u.token))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// editDistance is the optimal string alignment distance, which counts a transposition as a single edit.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
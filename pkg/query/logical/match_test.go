@@ -0,0 +1,200 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func matchCondition(value string, opts *modelv1.Condition_MatchOption) *modelv1.Condition {
+	return &modelv1.Condition{
+		Name:        "message",
+		Op:          modelv1.Condition_BINARY_OP_MATCH,
+		Value:       &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+		MatchOption: opts,
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	const message = "Connection reset by peer while reading response"
+	tests := []struct {
+		opts     *modelv1.Condition_MatchOption
+		name     string
+		value    string
+		expected []Span
+	}{
+		{
+			name:     "any term",
+			value:    "reset timeout",
+			expected: []Span{{Start: 11, End: 16}},
+		},
+		{
+			name:  "all terms",
+			value: "reset timeout",
+			opts:  &modelv1.Condition_MatchOption{Operator: modelv1.Condition_MatchOption_OPERATOR_AND},
+		},
+		{
+			name:     "phrase",
+			value:    "reset by peer",
+			opts:     &modelv1.Condition_MatchOption{Phrase: true},
+			expected: []Span{{Start: 11, End: 16}, {Start: 17, End: 19}, {Start: 20, End: 24}},
+		},
+		{
+			name:  "phrase out of order",
+			value: "peer by reset",
+			opts:  &modelv1.Condition_MatchOption{Phrase: true},
+		},
+		{
+			name:  "terms too far apart",
+			value: "connection peer",
+			opts:  &modelv1.Condition_MatchOption{Phrase: true, Slop: 1},
+		},
+		{
+			name:     "terms within slop",
+			value:    "connection peer",
+			opts:     &modelv1.Condition_MatchOption{Phrase: true, Slop: 2},
+			expected: []Span{{Start: 0, End: 10}, {Start: 20, End: 24}},
+		},
+		{
+			name:     "fuzzy",
+			value:    "conection raeding",
+			opts:     &modelv1.Condition_MatchOption{Fuzziness: 1, Operator: modelv1.Condition_MatchOption_OPERATOR_AND},
+			expected: []Span{{Start: 0, End: 10}, {Start: 31, End: 38}},
+		},
+		{
+			name:  "beyond fuzziness",
+			value: "conecton",
+			opts:  &modelv1.Condition_MatchOption{Fuzziness: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(matchCondition(tt.value, tt.opts), "standard")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.Locate(message))
+			assert.Equal(t, len(tt.expected) > 0, m.Match(message))
+		})
+	}
+}
+
+func TestMatcherWithoutAnalyzer(t *testing.T) {
+	m, err := NewMatcher(matchCondition("GET /api", nil), "")
+	require.NoError(t, err)
+	assert.True(t, m.Match("GET /api"))
+	assert.False(t, m.Match("GET /api/users"))
+}
+
+func TestValidateMatchOption(t *testing.T) {
+	assert.NoError(t, ValidateMatchOption(nil))
+	assert.NoError(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Phrase: true, Slop: 3}))
+	assert.ErrorIs(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Fuzziness: 3}), ErrUnsupportedConditionValue)
+	assert.ErrorIs(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Phrase: true, Fuzziness: 1}), ErrUnsupportedConditionValue)
+	assert.ErrorIs(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Slop: 1}), ErrUnsupportedConditionValue)
+	assert.NoError(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Phrase: true, Slop: MaxSlop}))
+	assert.ErrorIs(t, ValidateMatchOption(&modelv1.Condition_MatchOption{Phrase: true, Slop: MaxSlop + 1}), ErrUnsupportedConditionValue)
+}
+
+func TestMatcherRepeatedPhrase(t *testing.T) {
+	m, err := NewMatcher(matchCondition(strings.Repeat("a ", 8), &modelv1.Condition_MatchOption{Phrase: true, Slop: MaxSlop}), "standard")
+	require.NoError(t, err)
+	assert.Len(t, m.Locate(strings.Repeat("a ", 100)), 100)
+	assert.False(t, m.Match(strings.Repeat("a ", 7)))
+
+	m, err = NewMatcher(matchCondition("a b a", &modelv1.Condition_MatchOption{Phrase: true, Slop: 2}), "standard")
+	require.NoError(t, err)
+	assert.Equal(t, []Span{{Start: 0, End: 1}, {Start: 4, End: 5}, {Start: 6, End: 7}}, m.Locate("a x b a"))
+	assert.Nil(t, m.Locate("b a"))
+}
+
+// enumeratePhrase walks through every path of the phrase as the phrase searcher of the inverted index.
+func enumeratePhrase(m *Matcher, value string) []Span {
+	tokens := m.valueAnalyzer.Analyze([]byte(value))
+	var locs []phraseLoc
+	pos := 0
+	for i, t := range tokens {
+		pos += t.PositionIncr
+		locs = append(locs, phraseLoc{pos: pos, token: i})
+	}
+	hit := make(map[int]struct{})
+	var path []phraseLoc
+	var find func(i, prevPos, slop int)
+	find = func(i, prevPos, slop int) {
+		if i == len(m.terms) {
+			for _, l := range path {
+				hit[l.token] = struct{}{}
+			}
+			return
+		}
+	next:
+		for _, loc := range locs {
+			if string(tokens[loc.token].Term) != m.terms[i].term {
+				continue
+			}
+			dist := 0
+			if i > 0 {
+				dist = abs(prevPos + m.terms[i].pos - m.terms[i-1].pos - loc.pos)
+			}
+			if dist > slop || slices.Contains(path, loc) {
+				continue next
+			}
+			path = append(path, loc)
+			find(i+1, loc.pos, slop-dist)
+			path = path[:len(path)-1]
+		}
+	}
+	find(0, 0, m.slop)
+	var spans []Span
+	for i := range hit {
+		spans = append(spans, Span{Start: tokens[i].Start, End: tokens[i].End})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return spans
+}
+
+func TestLocatePhraseAsEnumeration(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := func(n int) string {
+		w := make([]string, n)
+		for i := range w {
+			w[i] = string(rune('a' + r.Intn(3)))
+		}
+		return strings.Join(w, " ")
+	}
+	for i := 0; i < 2000; i++ {
+		phrase, value := words(1+r.Intn(4)), words(r.Intn(10))
+		m, err := NewMatcher(matchCondition(phrase, &modelv1.Condition_MatchOption{Phrase: true, Slop: uint32(r.Intn(4))}), "standard")
+		require.NoError(t, err)
+		require.Equal(t, enumeratePhrase(m, value), m.Locate(value), "phrase %q, value %q, slop %d", phrase, value, m.slop)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance([]rune("peer"), []rune("peer")))
+	assert.Equal(t, 1, editDistance([]rune("reading"), []rune("raeding")))
+	assert.Equal(t, 1, editDistance([]rune("connection"), []rune("conection")))
+	assert.Equal(t, 3, editDistance([]rune("kitten"), []rune("sitting")))
+}
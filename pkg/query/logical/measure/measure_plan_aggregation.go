@@ -30,6 +30,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
 )
 
 const aggCountFieldName = "__agg_count"
@@ -56,10 +57,20 @@ type mapAccumulator[N aggregation.Number] struct {
 }
 
 func (a *mapAccumulator[N]) Feed(dp *measurev1.DataPoint, fieldIdx int) error {
-	v, parseErr := aggregation.FromFieldValue[N](dp.GetFields()[fieldIdx].GetValue())
+	fields := dp.GetFields()
+	v, parseErr := aggregation.FromFieldValue[N](fields[fieldIdx].GetValue())
 	if parseErr != nil {
 		return parseErr
 	}
+	// The data points answered from the block stats carry the partial value and its count.
+	if last := fields[len(fields)-1]; last.GetName() == aggCountFieldName {
+		c, countErr := aggregation.FromFieldValue[N](last.GetValue())
+		if countErr != nil {
+			return countErr
+		}
+		a.mapFunc.Combine(aggregation.Partial[N]{Value: v, Count: c})
+		return nil
+	}
 	a.mapFunc.In(v)
 	return nil
 }
@@ -195,6 +206,13 @@ func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPl
 			return nil, mapErr
 		}
 		acc = &mapAccumulator[N]{mapFunc: mapFunc, aggrType: gba.aggrFunc, emitPartial: gba.emitPartial}
+		// Let the storage answer the aggregation from the block stats if it scans the data points directly.
+		if scan, ok := prevPlan.(*localIndexScan); ok && !gba.isGroup {
+			scan.aggregation = &model.MeasureAggregation{
+				FieldName: fieldRef.Field.Name,
+				Function:  gba.aggrFunc,
+			}
+		}
 	}
 	return &aggregationPlan[N]{
 		Parent: &logical.Parent{
@@ -180,6 +180,7 @@ type localIndexScan struct {
 	query                index.Query
 	uis                  *unresolvedIndexScan
 	order                *logical.OrderBy
+	aggregation          *model.MeasureAggregation
 	metadata             *commonv1.Metadata
 	l                    *logger.Logger
 	hiddenTags           logical.HiddenTagSet
@@ -224,6 +225,7 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 		Entities:        i.entities,
 		Query:           i.query,
 		Order:           orderBy,
+		Aggregation:     i.aggregation,
 		TagProjection:   i.projectionTags,
 		FieldProjection: i.projectionFields,
 	})
@@ -365,6 +367,12 @@ func (ei *resultMIterator) Next() bool {
 				})
 			}
 		}
+		if len(r.AggregatedCounts) > i {
+			dp.Fields = append(dp.Fields, &measurev1.DataPoint_Field{
+				Name:  aggCountFieldName,
+				Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: r.AggregatedCounts[i]}}},
+			})
+		}
 		var shardID common.ShardID
 		if len(r.ShardIDs) > i {
 			shardID = r.ShardIDs[i]
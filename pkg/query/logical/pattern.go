@@ -0,0 +1,111 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+// IsPatternOp reports whether the operation matches the tag values against a pattern.
+func IsPatternOp(op modelv1.Condition_BinaryOp) bool {
+	return op == modelv1.Condition_BINARY_OP_LIKE || op == modelv1.Condition_BINARY_OP_REGEXP
+}
+
+// Pattern is a compiled LIKE or REGEXP pattern which matches a whole string.
+type Pattern struct {
+	re *regexp.Regexp
+	// Prefix is the literal prefix if the pattern only matches by the prefix, such as LIKE 'abc%'.
+	Prefix string
+	// Regexp is the pattern in RE2 syntax without anchors, which is what the inverted index expects.
+	Regexp string
+	// IsPrefix reports whether the pattern is a prefix pattern.
+	IsPrefix bool
+}
+
+// ParsePattern compiles the pattern of a LIKE or REGEXP condition.
+func ParsePattern(cond *modelv1.Condition) (*Pattern, error) {
+	v, ok := cond.GetValue().GetValue().(*modelv1.TagValue_Str)
+	if !ok {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "%s requires a string pattern: %s", cond.Op, cond)
+	}
+	p := &Pattern{}
+	switch cond.Op {
+	case modelv1.Condition_BINARY_OP_LIKE:
+		p.Regexp, p.Prefix, p.IsPrefix = likeToRegexp(v.Str.GetValue())
+	case modelv1.Condition_BINARY_OP_REGEXP:
+		p.Regexp = v.Str.GetValue()
+	default:
+		return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "%s is not a pattern operation", cond.Op)
+	}
+	var err error
+	if p.re, err = regexp.Compile("^(?:" + p.Regexp + ")$"); err != nil {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "invalid pattern %q: %v", v.Str.GetValue(), err)
+	}
+	return p, nil
+}
+
+// MatchString reports whether the whole string matches the pattern.
+func (p *Pattern) MatchString(s string) bool {
+	return p.re.MatchString(s)
+}
+
+func (p *Pattern) String() string {
+	return p.Regexp
+}
+
+// likeToRegexp converts a LIKE pattern to a regular expression.
+// It also returns the literal prefix if the only wildcard is a trailing "%".
+func likeToRegexp(like string) (expr, prefix string, isPrefix bool) {
+	var re, literal strings.Builder
+	wildcards := 0
+	trailingAny := false
+	runes := []rune(like)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			re.WriteString(regexp.QuoteMeta(string(runes[i])))
+			if wildcards == 0 {
+				literal.WriteRune(runes[i])
+			}
+			continue
+		case r == '%':
+			re.WriteString(".*")
+		case r == '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+			if wildcards == 0 {
+				literal.WriteRune(r)
+			}
+			continue
+		}
+		wildcards++
+		trailingAny = r == '%' && i == len(runes)-1
+	}
+	if wildcards == 1 && trailingAny {
+		return re.String(), literal.String(), true
+	}
+	return re.String(), "", false
+}
@@ -0,0 +1,116 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func patternCondition(op modelv1.Condition_BinaryOp, pattern string) *modelv1.Condition {
+	return &modelv1.Condition{
+		Name:  "endpoint",
+		Op:    op,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: pattern}}},
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		prefix   string
+		match    []string
+		notMatch []string
+		op       modelv1.Condition_BinaryOp
+		isPrefix bool
+	}{
+		{
+			name:     "like prefix",
+			op:       modelv1.Condition_BINARY_OP_LIKE,
+			pattern:  "/api/v1/%",
+			prefix:   "/api/v1/",
+			isPrefix: true,
+			match:    []string{"/api/v1/", "/api/v1/users"},
+			notMatch: []string{"/api/v2/users", "api/v1/"},
+		},
+		{
+			name:     "like wildcards",
+			op:       modelv1.Condition_BINARY_OP_LIKE,
+			pattern:  "%/users/_",
+			match:    []string{"/api/users/1", "/users/a"},
+			notMatch: []string{"/api/users/12", "/api/users/"},
+		},
+		{
+			name:     "like escapes wildcards and regexp meta characters",
+			op:       modelv1.Condition_BINARY_OP_LIKE,
+			pattern:  `100\%.(a)%`,
+			prefix:   "100%.(a)",
+			isPrefix: true,
+			match:    []string{"100%.(a)", "100%.(a) done"},
+			notMatch: []string{"1000.(a)", "100%x(a)"},
+		},
+		{
+			name:     "regexp matches the whole value",
+			op:       modelv1.Condition_BINARY_OP_REGEXP,
+			pattern:  "svc-[0-9]+",
+			match:    []string{"svc-1", "svc-42"},
+			notMatch: []string{"svc-", "my-svc-1", "svc-1a"},
+		},
+		{
+			name:     "regexp alternation is anchored",
+			op:       modelv1.Condition_BINARY_OP_REGEXP,
+			pattern:  "a|b",
+			match:    []string{"a", "b"},
+			notMatch: []string{"ab", "xa"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePattern(patternCondition(tt.op, tt.pattern))
+			require.NoError(t, err)
+			assert.Equal(t, tt.isPrefix, p.IsPrefix)
+			assert.Equal(t, tt.prefix, p.Prefix)
+			for _, s := range tt.match {
+				assert.True(t, p.MatchString(s), "%q should match %q", tt.pattern, s)
+			}
+			for _, s := range tt.notMatch {
+				assert.False(t, p.MatchString(s), "%q should not match %q", tt.pattern, s)
+			}
+		})
+	}
+}
+
+func TestParsePatternError(t *testing.T) {
+	_, err := ParsePattern(patternCondition(modelv1.Condition_BINARY_OP_REGEXP, "svc-[0-9"))
+	assert.ErrorIs(t, err, ErrUnsupportedConditionValue)
+
+	_, err = ParsePattern(&modelv1.Condition{
+		Name:  "endpoint",
+		Op:    modelv1.Condition_BINARY_OP_LIKE,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: 1}}},
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedConditionValue)
+
+	_, err = ParsePattern(patternCondition(modelv1.Condition_BINARY_OP_EQ, "a"))
+	assert.ErrorIs(t, err, ErrUnsupportedConditionOp)
+}
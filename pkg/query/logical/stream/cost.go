@@ -0,0 +1,331 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// scanStrategy is the way the data node reads the elements of a query.
+type scanStrategy int
+
+const (
+	// strategyBlockScan reads the blocks of all series.
+	strategyBlockScan scanStrategy = iota
+	// strategySeriesScan reads the blocks of the series matched by the entity.
+	strategySeriesScan
+	// strategySkippingScan reads the blocks which aren't pruned by the skipping indexes.
+	strategySkippingScan
+	// strategyIndexScan reads the elements matched by the inverted indexes.
+	strategyIndexScan
+)
+
+func (s scanStrategy) String() string {
+	switch s {
+	case strategySeriesScan:
+		return "series_scan"
+	case strategySkippingScan:
+		return "skipping_scan"
+	case strategyIndexScan:
+		return "index_scan"
+	default:
+		return "block_scan"
+	}
+}
+
+// The cost of reading a row sequentially is the unit of the other costs.
+const (
+	seqRowCost       = 1.0
+	randomRowCost    = 4.0
+	postingCost      = 0.05
+	seriesLookupCost = 16.0
+	blockProbeCost   = 1.0
+	rowsPerBlock     = 256.0
+
+	defaultEqSelectivity      = 0.1
+	defaultRangeSelectivity   = 1.0 / 3
+	defaultMatchSelectivity   = 0.1
+	defaultPatternSelectivity = 0.25
+)
+
+// costEstimate is the estimated cost of a strategy. The cost is negative if there're no statistics to estimate it.
+type costEstimate struct {
+	strategy scanStrategy
+	cost     float64
+	rows     float64
+}
+
+func (ce costEstimate) known() bool {
+	return ce.cost >= 0
+}
+
+func (ce costEstimate) String() string {
+	if !ce.known() {
+		return fmt.Sprintf("strategy=%s,cost=unknown", ce.strategy)
+	}
+	return fmt.Sprintf("strategy=%s,cost=%.1f,rows=%.0f", ce.strategy, ce.cost, ce.rows)
+}
+
+// chooseStrategy estimates the cost of each available strategy and picks the cheapest one,
+// preferring the indexes on a tie.
+// Without statistics, the inverted index is preferred over the skipping index, then the scans.
+// The inverted index can't be skipped if its conditions aren't evaluated again by a tag filter.
+func chooseStrategy(stats *index.Stats, inverted, skipping index.Filter, entities [][]*modelv1.TagValue, invertedRequired bool) costEstimate {
+	hasInverted := inverted != nil && inverted != ENode
+	hasSkipping := skipping != nil && skipping != ENode
+	scan := strategyBlockScan
+	if entitySpecified(entities) {
+		scan = strategySeriesScan
+	}
+	if stats == nil || stats.DocCount == 0 {
+		switch {
+		case hasInverted:
+			return costEstimate{strategy: strategyIndexScan, cost: -1}
+		case hasSkipping:
+			return costEstimate{strategy: strategySkippingScan, cost: -1}
+		}
+		return costEstimate{strategy: scan, cost: -1}
+	}
+	se := selectivityEstimator{stats: stats}
+	seriesFraction := estimateSeriesFraction(entities, stats.Series)
+	rows := float64(stats.DocCount) * seriesFraction
+	candidates := []costEstimate{{strategy: scan, cost: rows * seqRowCost, rows: rows}}
+	if hasSkipping {
+		sel, _ := se.selectivity(skipping)
+		read := rows * (1 - math.Pow(1-sel, rowsPerBlock))
+		// Pruning never reads more blocks than scanning them.
+		candidates = append(candidates, costEstimate{
+			strategy: strategySkippingScan,
+			cost:     math.Min(read*seqRowCost+rows/rowsPerBlock*blockProbeCost, rows*seqRowCost),
+			rows:     read,
+		})
+	}
+	if hasInverted {
+		sel, postings := se.selectivity(inverted)
+		series := math.Max(1, float64(stats.Series)*seriesFraction)
+		matched := rows * sel
+		indexScan := costEstimate{
+			strategy: strategyIndexScan,
+			cost:     series*seriesLookupCost + postings*seriesFraction*postingCost + matched*randomRowCost,
+			rows:     matched,
+		}
+		if invertedRequired {
+			return indexScan
+		}
+		candidates = append(candidates, indexScan)
+	}
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.cost <= best.cost {
+			best = c
+		}
+	}
+	return best
+}
+
+func entitySpecified(entities [][]*modelv1.TagValue) bool {
+	for _, entity := range entities {
+		for _, v := range entity {
+			if v != pbv1.AnyTagValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// estimateSeriesFraction estimates the fraction of series matched by the entities.
+// An entity specifying all its tags matches a single series, and one specifying none matches all of them.
+func estimateSeriesFraction(entities [][]*modelv1.TagValue, series int64) float64 {
+	if series <= 1 || len(entities) == 0 {
+		return 1
+	}
+	var fraction float64
+	for _, entity := range entities {
+		var specified int
+		for _, v := range entity {
+			if v != pbv1.AnyTagValue {
+				specified++
+			}
+		}
+		if specified == 0 {
+			return 1
+		}
+		fraction += math.Pow(1/float64(series), float64(specified)/float64(len(entity)))
+	}
+	return math.Min(1, fraction)
+}
+
+type selectivityEstimator struct {
+	stats *index.Stats
+}
+
+// selectivity returns the estimated fraction of the elements matched by the filter,
+// and the number of postings read to evaluate it. Conditions are assumed to be independent.
+func (se selectivityEstimator) selectivity(f index.Filter) (float64, float64) {
+	switch n := f.(type) {
+	case nil, *emptyNode:
+		return 1, 0
+	case *andNode:
+		sel, postings := 1.0, 0.0
+		for _, sub := range n.SubNodes {
+			s, p := se.selectivity(sub)
+			sel *= s
+			postings += p
+		}
+		return sel, postings
+	case *orNode:
+		unmatched, postings := 1.0, 0.0
+		for _, sub := range n.SubNodes {
+			s, p := se.selectivity(sub)
+			unmatched *= 1 - s
+			postings += p
+		}
+		return 1 - unmatched, postings
+	case *not:
+		s, p := se.selectivity(n.Inner)
+		if fs := se.field(n.Key); fs != nil {
+			p += float64(fs.Postings)
+		}
+		return 1 - s, p
+	case *eq:
+		return se.eq(n)
+	case *rangeOp:
+		fs := se.field(n.Key)
+		if fs == nil {
+			return defaultRangeSelectivity, 0
+		}
+		fraction, ok := fs.EstimateRange(n.Opts)
+		if !ok {
+			fraction = defaultRangeSelectivity
+		}
+		postings := fraction * float64(fs.Postings)
+		return se.fraction(postings), postings
+	case *match:
+		fs := se.field(n.Key)
+		if fs == nil || fs.Cardinality == 0 {
+			return defaultMatchSelectivity, 0
+		}
+		postings := float64(fs.Postings) / float64(fs.Cardinality) * float64(len(n.Expr.Bytes()))
+		return se.fraction(postings), postings
+	case *patternFilter:
+		fs := se.field(n.Key)
+		if fs == nil {
+			return defaultPatternSelectivity, 0
+		}
+		return defaultPatternSelectivity, float64(fs.Postings)
+	}
+	return 1, 0
+}
+
+func (se selectivityEstimator) eq(n *eq) (float64, float64) {
+	fs := se.field(n.Key)
+	if fs == nil {
+		return defaultEqSelectivity, 0
+	}
+	postings := -1.0
+	for _, e := range n.Expr.SubExprs() {
+		field := e.Field(index.FieldKey{})
+		p := fs.EstimateTerm(field.GetTerm().String())
+		if postings < 0 || p < postings {
+			postings = p
+		}
+	}
+	if postings < 0 {
+		return defaultEqSelectivity, 0
+	}
+	return se.fraction(postings), postings
+}
+
+// field returns the statistics of the inverted index field. The skipping indexes don't have any.
+func (se selectivityEstimator) field(key fieldKey) *index.FieldStats {
+	if key.IndexRule == nil || key.Metadata == nil || key.Type != databasev1.IndexRule_TYPE_INVERTED {
+		return nil
+	}
+	return se.stats.Field(key.Metadata.Id)
+}
+
+func (se selectivityEstimator) fraction(postings float64) float64 {
+	if se.stats.DocCount <= 0 {
+		return 1
+	}
+	return math.Min(1, postings/float64(se.stats.DocCount))
+}
+
+// orderConjunctions sorts the conditions of AND nodes by their selectivity,
+// so that the most selective one is evaluated first and the empty result short-circuits the others.
+func orderConjunctions(f index.Filter, stats *index.Stats) {
+	if stats == nil {
+		return
+	}
+	se := selectivityEstimator{stats: stats}
+	var walk func(index.Filter)
+	walk = func(f index.Filter) {
+		switch n := f.(type) {
+		case *andNode:
+			for _, sub := range n.SubNodes {
+				walk(sub)
+			}
+			sels := make(map[index.Filter]float64, len(n.SubNodes))
+			for _, sub := range n.SubNodes {
+				sels[sub], _ = se.selectivity(sub)
+			}
+			sort.SliceStable(n.SubNodes, func(i, j int) bool {
+				return sels[n.SubNodes[i]] < sels[n.SubNodes[j]]
+			})
+		case *orNode:
+			for _, sub := range n.SubNodes {
+				walk(sub)
+			}
+		case *not:
+			walk(n.Inner)
+		}
+	}
+	walk(f)
+}
+
+// requiresIndex reports whether the filter has conditions which can't be evaluated without the inverted index.
+func requiresIndex(f index.Filter) bool {
+	switch n := f.(type) {
+	case *andNode:
+		return anyRequiresIndex(n.SubNodes)
+	case *orNode:
+		return anyRequiresIndex(n.SubNodes)
+	case *not:
+		return requiresIndex(n.Inner)
+	case *match, *patternFilter:
+		return true
+	}
+	return false
+}
+
+func anyRequiresIndex(ff []index.Filter) bool {
+	for _, f := range ff {
+		if requiresIndex(f) {
+			return true
+		}
+	}
+	return false
+}
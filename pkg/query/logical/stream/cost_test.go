@@ -0,0 +1,249 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+type statsContext struct {
+	stats *index.Stats
+}
+
+func (sc statsContext) Query(context.Context, model.StreamQueryOptions) (model.StreamQueryResult, error) {
+	return nil, nil
+}
+
+func (sc statsContext) IndexStats(timestamp.TimeRange) *index.Stats {
+	return sc.stats
+}
+
+func mustBuildCostTestSchema(t *testing.T) logical.Schema {
+	t.Helper()
+	stream := &databasev1.Stream{
+		Entity: &databasev1.Entity{TagNames: []string{"service_id"}},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{
+				Name: "default",
+				Tags: []*databasev1.TagSpec{
+					{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "http_method", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+					{Name: "endpoint", Type: databasev1.TagType_TAG_TYPE_STRING},
+				},
+			},
+		},
+	}
+	rule := func(id uint32, tag string, typ databasev1.IndexRule_Type) *databasev1.IndexRule {
+		return &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Id: id, Name: tag, Group: "default"},
+			Tags:     []string{tag},
+			Type:     typ,
+		}
+	}
+	s, err := BuildSchema(stream, []*databasev1.IndexRule{
+		rule(1, "http_method", databasev1.IndexRule_TYPE_INVERTED),
+		rule(2, "trace_id", databasev1.IndexRule_TYPE_INVERTED),
+		rule(3, "duration", databasev1.IndexRule_TYPE_INVERTED),
+		rule(4, "endpoint", databasev1.IndexRule_TYPE_SKIPPING),
+	})
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	return s
+}
+
+func costTestStats() *index.Stats {
+	return &index.Stats{
+		DocCount: 100000,
+		Series:   100,
+		Fields: map[uint32]*index.FieldStats{
+			1: {Postings: 100000, Cardinality: 3, TopTerms: map[string]int64{"GET": 90000, "POST": 9990, "PUT": 10}},
+			2: {Postings: 100000, Cardinality: 100000, TopTerms: map[string]int64{"hot": 5}},
+			3: {Postings: 100000, Cardinality: 1000, Numeric: true, Min: 0, Max: 1000},
+		},
+	}
+}
+
+func condition(name string, op modelv1.Condition_BinaryOp, value *modelv1.TagValue) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{Name: name, Op: op, Value: value}}}
+}
+
+func and(left, right *modelv1.Criteria) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op: modelv1.LogicalExpression_LOGICAL_OP_AND, Left: left, Right: right,
+	}}}
+}
+
+func intValue(v int64) *modelv1.TagValue {
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v}}}
+}
+
+func TestOptimizeStrategy(t *testing.T) {
+	schema := mustBuildCostTestSchema(t)
+	tests := []struct {
+		stats        *index.Stats
+		criteria     *modelv1.Criteria
+		name         string
+		wantStrategy scanStrategy
+		wantIndex    bool
+	}{
+		{
+			name:         "no statistics",
+			criteria:     buildEqualityCriteria("http_method", "GET"),
+			wantStrategy: strategyIndexScan,
+			wantIndex:    true,
+		},
+		{
+			name:         "frequent term",
+			stats:        costTestStats(),
+			criteria:     buildEqualityCriteria("http_method", "GET"),
+			wantStrategy: strategyBlockScan,
+		},
+		{
+			name:         "rare term",
+			stats:        costTestStats(),
+			criteria:     buildEqualityCriteria("http_method", "PUT"),
+			wantStrategy: strategyIndexScan,
+			wantIndex:    true,
+		},
+		{
+			name:         "unknown term of a high cardinality tag",
+			stats:        costTestStats(),
+			criteria:     buildEqualityCriteria("trace_id", "abc"),
+			wantStrategy: strategyIndexScan,
+			wantIndex:    true,
+		},
+		{
+			name:         "frequent term in a series",
+			stats:        costTestStats(),
+			criteria:     and(buildEqualityCriteria("service_id", "svc"), buildEqualityCriteria("http_method", "GET")),
+			wantStrategy: strategySeriesScan,
+		},
+		{
+			name:         "narrow range",
+			stats:        costTestStats(),
+			criteria:     condition("duration", modelv1.Condition_BINARY_OP_GT, intValue(999)),
+			wantStrategy: strategyIndexScan,
+			wantIndex:    true,
+		},
+		{
+			name:         "wide range",
+			stats:        costTestStats(),
+			criteria:     condition("duration", modelv1.Condition_BINARY_OP_GT, intValue(10)),
+			wantStrategy: strategyBlockScan,
+		},
+		{
+			name:         "skipping index",
+			stats:        costTestStats(),
+			criteria:     and(buildEqualityCriteria("endpoint", "/home"), buildEqualityCriteria("http_method", "GET")),
+			wantStrategy: strategySkippingScan,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := tagFilter(time.Unix(0, 0), time.Unix(1, 0), &commonv1.Metadata{Name: "sw", Group: "default"},
+				tt.criteria, nil, [][]*logical.Tag{logical.NewTags("default", "trace_id")}, statsContext{stats: tt.stats})
+			resolved, err := plan.Analyze(schema)
+			if err != nil {
+				t.Fatalf("analyze: %v", err)
+			}
+			tfPlan, ok := resolved.(*tagFilterPlan)
+			if !ok {
+				t.Fatalf("expected tagFilterPlan, got %T", resolved)
+			}
+			scan := tfPlan.parent.(*localIndexScan)
+			if scan.estimate.strategy != tt.wantStrategy {
+				t.Fatalf("want strategy %s, got %s", tt.wantStrategy, scan.estimate)
+			}
+			if hasIndex := scan.invertedFilter != nil && scan.invertedFilter != ENode; hasIndex != tt.wantIndex {
+				t.Fatalf("want inverted filter %v, got %v", tt.wantIndex, scan.invertedFilter)
+			}
+			if tt.stats != nil != scan.estimate.known() {
+				t.Fatalf("unexpected estimate %s", scan.estimate)
+			}
+			if !strings.Contains(scan.String(), "strategy="+tt.wantStrategy.String()) {
+				t.Fatalf("the strategy is missing in the plan %s", scan)
+			}
+		})
+	}
+}
+
+func TestOptimizeKeepsRequiredIndex(t *testing.T) {
+	schema := mustBuildCostTestSchema(t)
+	criteria := buildEqualityCriteria("http_method", "GET")
+	criteria.GetCondition().Op = modelv1.Condition_BINARY_OP_MATCH
+	plan := tagFilter(time.Unix(0, 0), time.Unix(1, 0), &commonv1.Metadata{Name: "sw", Group: "default"},
+		criteria, nil, [][]*logical.Tag{logical.NewTags("default", "trace_id")}, statsContext{stats: costTestStats()})
+	resolved, err := plan.Analyze(schema)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	scan := resolved.(*tagFilterPlan).parent.(*localIndexScan)
+	if scan.estimate.strategy != strategyIndexScan || scan.invertedFilter == ENode {
+		t.Fatalf("a match condition must be served by the index, got %s", scan.estimate)
+	}
+}
+
+func TestOrderConjunctions(t *testing.T) {
+	schema := mustBuildCostTestSchema(t)
+	entityDict := map[string]int{"service_id": 0}
+	criteria := and(
+		and(buildEqualityCriteria("http_method", "GET"), condition("duration", modelv1.Condition_BINARY_OP_GT, intValue(500))),
+		buildEqualityCriteria("trace_id", "hot"),
+	)
+	filter, _, err := buildLocalFilter(criteria, schema, entityDict, []*modelv1.TagValue{nil}, databasev1.IndexRule_TYPE_INVERTED)
+	if err != nil {
+		t.Fatalf("build filter: %v", err)
+	}
+	orderConjunctions(filter, costTestStats())
+	root := filter.(*andNode)
+	if _, ok := root.SubNodes[0].(*eq); !ok {
+		t.Fatalf("the trace_id condition should be evaluated first, got %s", root)
+	}
+	nested := root.SubNodes[1].(*andNode)
+	if _, ok := nested.SubNodes[0].(*rangeOp); !ok {
+		t.Fatalf("the duration range should be evaluated before http_method, got %s", nested)
+	}
+}
+
+func TestEstimateSeriesFraction(t *testing.T) {
+	specified := &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}}
+	if f := estimateSeriesFraction([][]*modelv1.TagValue{{pbv1.AnyTagValue}}, 100); f != 1 {
+		t.Fatalf("any entity should match all series, got %f", f)
+	}
+	if f := estimateSeriesFraction([][]*modelv1.TagValue{{specified}}, 100); f != 0.01 {
+		t.Fatalf("a specified entity should match a single series, got %f", f)
+	}
+	if f := estimateSeriesFraction([][]*modelv1.TagValue{{specified, pbv1.AnyTagValue}}, 100); f != 0.1 {
+		t.Fatalf("a half specified entity should match the square root of series, got %f", f)
+	}
+}
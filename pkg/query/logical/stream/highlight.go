@@ -0,0 +1,179 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const (
+	defaultHighlightPreTag  = "<em>"
+	defaultHighlightPostTag = "</em>"
+)
+
+// highlighter marks the terms of the tag values hit by the match conditions.
+type highlighter struct {
+	opt      *streamv1.HighlightOption
+	matchers map[string][]*logical.Matcher
+	tags     []string
+}
+
+func newHighlighter(opt *streamv1.HighlightOption, criteria *modelv1.Criteria, s logical.Schema) (*highlighter, error) {
+	h := &highlighter{
+		opt:      opt,
+		matchers: make(map[string][]*logical.Matcher),
+	}
+	requested := make(map[string]struct{}, len(opt.GetTags()))
+	for _, t := range opt.GetTags() {
+		requested[t] = struct{}{}
+	}
+	for _, cond := range collectMatchConditions(criteria, nil) {
+		if _, ok := requested[cond.Name]; len(requested) > 0 && !ok {
+			continue
+		}
+		_, indexRule := s.IndexRuleDefined(cond.Name)
+		m, err := logical.NewMatcher(cond, indexRule.GetAnalyzer())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := h.matchers[cond.Name]; !ok {
+			h.tags = append(h.tags, cond.Name)
+		}
+		h.matchers[cond.Name] = append(h.matchers[cond.Name], m)
+	}
+	if len(h.tags) == 0 {
+		return nil, nil
+	}
+	return h, nil
+}
+
+func collectMatchConditions(criteria *modelv1.Criteria, conds []*modelv1.Condition) []*modelv1.Condition {
+	switch exp := criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		if exp.Condition.GetOp() == modelv1.Condition_BINARY_OP_MATCH {
+			conds = append(conds, exp.Condition)
+		}
+	case *modelv1.Criteria_Le:
+		conds = collectMatchConditions(exp.Le.GetLeft(), conds)
+		conds = collectMatchConditions(exp.Le.GetRight(), conds)
+	}
+	return conds
+}
+
+func (h *highlighter) highlight(tagFamilies []*modelv1.TagFamily, s logical.Schema) []*streamv1.Highlight {
+	var result []*streamv1.Highlight
+	for _, tag := range h.tags {
+		tagSpec := s.FindTagSpecByName(tag)
+		if tagSpec == nil {
+			continue
+		}
+		v, ok := logical.TagFamilies(tagFamilies).GetTagValue(tagSpec.TagFamilyIdx, tagSpec.TagIdx).GetValue().(*modelv1.TagValue_Str)
+		if !ok {
+			continue
+		}
+		value := v.Str.GetValue()
+		var spans []logical.Span
+		for _, m := range h.matchers[tag] {
+			spans = append(spans, m.Locate(value)...)
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		result = append(result, &streamv1.Highlight{
+			TagName:   tag,
+			Fragments: fragments(value, spans, h.opt),
+		})
+	}
+	return result
+}
+
+// fragments cuts the value into the fragments around the spans, and wraps each span with the pre and post tags.
+func fragments(value string, spans []logical.Span, opt *streamv1.HighlightOption) []string {
+	spans = mergeSpans(spans)
+	windows := []logical.Span{{Start: 0, End: len(value)}}
+	if size := int(opt.GetFragmentSize()); size > 0 {
+		windows = windows[:0]
+		for _, sp := range spans {
+			start := max(0, sp.Start-max(0, (size-(sp.End-sp.Start))/2))
+			end := min(len(value), max(sp.End, start+size))
+			if end == len(value) {
+				start = min(start, max(0, end-size))
+			}
+			for start > 0 && !utf8.RuneStart(value[start]) {
+				start--
+			}
+			for end < len(value) && !utf8.RuneStart(value[end]) {
+				end++
+			}
+			if n := len(windows); n > 0 && start <= windows[n-1].End {
+				windows[n-1].End = max(windows[n-1].End, end)
+				continue
+			}
+			windows = append(windows, logical.Span{Start: start, End: end})
+		}
+	}
+	if n := int(opt.GetNumberOfFragments()); n > 0 && len(windows) > n {
+		windows = windows[:n]
+	}
+	preTag, postTag := opt.GetPreTag(), opt.GetPostTag()
+	if preTag == "" {
+		preTag = defaultHighlightPreTag
+	}
+	if postTag == "" {
+		postTag = defaultHighlightPostTag
+	}
+	result := make([]string, 0, len(windows))
+	for _, w := range windows {
+		var sb strings.Builder
+		cursor := w.Start
+		for _, sp := range spans {
+			if sp.Start < w.Start || sp.End > w.End {
+				continue
+			}
+			sb.WriteString(value[cursor:sp.Start])
+			sb.WriteString(preTag)
+			sb.WriteString(value[sp.Start:sp.End])
+			sb.WriteString(postTag)
+			cursor = sp.End
+		}
+		sb.WriteString(value[cursor:w.End])
+		result = append(result, sb.String())
+	}
+	return result
+}
+
+// mergeSpans sorts the spans by the start and merges the overlapping ones.
+func mergeSpans(spans []logical.Span) []logical.Span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp.Start <= last.End {
+			last.End = max(last.End, sp.End)
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+func TestFragments(t *testing.T) {
+	const value = "connection reset by peer, retrying the connection to peer"
+	spans := []logical.Span{{Start: 53, End: 57}, {Start: 20, End: 24}, {Start: 0, End: 10}, {Start: 0, End: 10}}
+	tests := []struct {
+		opt      *streamv1.HighlightOption
+		name     string
+		expected []string
+	}{
+		{
+			name:     "whole value",
+			opt:      &streamv1.HighlightOption{},
+			expected: []string{"<em>connection</em> reset by <em>peer</em>, retrying the connection to <em>peer</em>"},
+		},
+		{
+			name: "fragments",
+			opt:  &streamv1.HighlightOption{FragmentSize: 12, PreTag: "[", PostTag: "]"},
+			expected: []string{
+				"[connection] r",
+				" by [peer], re",
+				"tion to [peer]",
+			},
+		},
+		{
+			name:     "limited fragments",
+			opt:      &streamv1.HighlightOption{FragmentSize: 12, NumberOfFragments: 1},
+			expected: []string{"<em>connection</em> r"},
+		},
+		{
+			name:     "overlapping fragments are merged",
+			opt:      &streamv1.HighlightOption{FragmentSize: 16},
+			expected: []string{"<em>connection</em> reset by <em>peer</em>, retr", "nnection to <em>peer</em>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := append([]logical.Span(nil), spans...)
+			assert.Equal(t, tt.expected, fragments(value, in, tt.opt))
+		})
+	}
+}
+
+func TestFragmentsRuneBoundary(t *testing.T) {
+	const value = "数据库连接被重置"
+	// "连接" starts at byte 9 and ends at byte 15.
+	assert.Equal(t, []string{"库<em>连接</em>被"}, fragments(value, []logical.Span{{Start: 9, End: 15}}, &streamv1.HighlightOption{FragmentSize: 10}))
+}
@@ -128,6 +128,16 @@ func parseConditionToFilter(cond *modelv1.Condition, indexRule *databasev1.Index
 			return newMatch(indexRule, expr, cond.MatchOption), [][]*modelv1.TagValue{entity}, nil
 		}
 		return nil, nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v for skipping index", cond)
+	case modelv1.Condition_BINARY_OP_LIKE, modelv1.Condition_BINARY_OP_REGEXP:
+		pattern, err := logical.ParsePattern(cond)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the analyzed terms can't match the pattern of the whole value, leave it to the tag filter
+		if indexRule.Type != databasev1.IndexRule_TYPE_INVERTED || indexRule.Analyzer != index.AnalyzerUnspecified {
+			return ENode, [][]*modelv1.TagValue{entity}, nil
+		}
+		return newPatternFilter(indexRule, pattern), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_NE:
 		return newNot(indexRule, newEq(indexRule, expr)), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_HAVING:
@@ -247,6 +257,10 @@ func execute(searcher index.GetSearcher, seriesID common.SeriesID, n *node, lp l
 		if resultTS, err = merge(resultTS, rt, lp); err != nil {
 			return nil, nil, err
 		}
+		// The conjunction can't match anything once an operand matches nothing.
+		if _, isAnd := lp.(*andNode); isAnd && result != nil && result.IsEmpty() {
+			break
+		}
 	}
 	return result, resultTS, nil
 }
@@ -516,6 +530,46 @@ func (match *match) String() string {
 	return convert.JSONToString(match)
 }
 
+type patternFilter struct {
+	*leaf
+	pattern *logical.Pattern
+}
+
+func newPatternFilter(indexRule *databasev1.IndexRule, pattern *logical.Pattern) *patternFilter {
+	return &patternFilter{
+		leaf: &leaf{
+			Key: newFieldKeyWithIndexRule(indexRule),
+		},
+		pattern: pattern,
+	}
+}
+
+func (p *patternFilter) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	s, err := searcher(p.Key.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.pattern.IsPrefix {
+		return s.MatchPrefix(p.Key.toIndex(seriesID, tr), p.pattern.Prefix)
+	}
+	return s.MatchRegexp(p.Key.toIndex(seriesID, tr), p.pattern.Regexp)
+}
+
+func (p *patternFilter) ShouldSkip(_ index.FilterOp) (bool, error) {
+	return false, nil
+}
+
+func (p *patternFilter) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["index"] = p.Key.IndexRule.Metadata.Name + ":" + p.Key.IndexRule.Metadata.Group
+	data["pattern"] = p.pattern.String()
+	return json.Marshal(data)
+}
+
+func (p *patternFilter) String() string {
+	return convert.JSONToString(p)
+}
+
 type rangeOp struct {
 	*leaf
 	Opts index.RangeOpts
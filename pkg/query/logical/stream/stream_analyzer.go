@@ -214,5 +214,5 @@ func parseTags(criteria *streamv1.QueryRequest, metadata *commonv1.Metadata,
 ) logical.UnresolvedPlan {
 	timeRange := criteria.GetTimeRange()
 	return tagFilter(timeRange.GetBegin().AsTime(), timeRange.GetEnd().AsTime(), metadata,
-		criteria.Criteria, tagProjection, ec)
+		criteria.Criteria, criteria.Highlight, tagProjection, ec)
 }
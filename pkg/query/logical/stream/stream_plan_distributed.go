@@ -76,6 +76,7 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 		Criteria:   ud.originalQuery.Criteria,
 		Limit:      limit + ud.originalQuery.Offset,
 		OrderBy:    ud.originalQuery.OrderBy,
+		Highlight:  ud.originalQuery.Highlight,
 	}
 	if ud.originalQuery.OrderBy == nil {
 		return &distributedPlan{
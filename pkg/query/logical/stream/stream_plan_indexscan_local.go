@@ -32,6 +32,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -58,6 +59,7 @@ type localIndexScan struct {
 	projectionTagRefs [][]*logical.TagRef
 	projectionTags    []model.TagProjection
 	entities          [][]*modelv1.TagValue
+	estimate          costEstimate
 	maxElementSize    int
 }
 
@@ -92,7 +94,8 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 		}
 	}
 	var err error
-	if i.result, err = i.ec.Query(ctx, model.StreamQueryOptions{
+	queryCtx, stop := i.startSpan(ctx, query.GetTracer(ctx))
+	i.result, err = i.ec.Query(queryCtx, model.StreamQueryOptions{
 		Name:           i.metadata.GetName(),
 		TimeRange:      &i.timeRange,
 		Entities:       i.entities,
@@ -100,8 +103,11 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 		SkippingFilter: i.skippingFilter,
 		Order:          orderBy,
 		TagProjection:  i.projectionTags,
+		Projection:     i.selectProjection(),
 		MaxElementSize: i.maxElementSize,
-	}); err != nil {
+	})
+	stop(err)
+	if err != nil {
 		return nil, err
 	}
 	if i.result == nil {
@@ -111,9 +117,84 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 }
 
 func (i *localIndexScan) String() string {
-	return fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; orderBy=%s; limit=%d",
+	str := fmt.Sprintf("IndexScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; orderBy=%s; limit=%d; %s",
 		i.timeRange.Start.Unix(), i.timeRange.End.Unix(), i.metadata.GetGroup(), i.metadata.GetName(),
-		i.invertedFilter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxElementSize)
+		i.invertedFilter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxElementSize, i.estimate)
+	if p := i.selectProjection(); p != "" {
+		str += "; streamProjection=" + p
+	}
+	return str
+}
+
+func (i *localIndexScan) startSpan(ctx context.Context, tracer *query.Tracer) (context.Context, func(error)) {
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	span, ctx := tracer.StartSpan(ctx, "indexScan-%s", i.metadata)
+	span.Tag("strategy", i.estimate.strategy.String())
+	if i.estimate.known() {
+		span.Tagf("estimated_cost", "%.1f", i.estimate.cost)
+		span.Tagf("estimated_rows", "%.0f", i.estimate.rows)
+	}
+	span.Tag("details", i.String())
+	return ctx, func(err error) {
+		if err != nil {
+			span.Error(err)
+		}
+		span.Stop()
+	}
+}
+
+// selectProjection picks the stream projection sorted by the order's tag which covers all the projected tags.
+// The one storing the fewest tags wins. Skipping filters are evaluated on blocks, so they rule projections out.
+func (i *localIndexScan) selectProjection() string {
+	if i.order == nil || i.order.Index == nil || len(i.order.Index.GetTags()) != 1 {
+		return ""
+	}
+	if i.skippingFilter != nil && i.skippingFilter != ENode {
+		return ""
+	}
+	s, ok := i.schema.(*schema)
+	if !ok {
+		return ""
+	}
+	entity := make(map[string]struct{}, len(s.stream.GetEntity().GetTagNames()))
+	for _, name := range s.stream.GetEntity().GetTagNames() {
+		entity[name] = struct{}{}
+	}
+	sortTag := i.order.Index.GetTags()[0]
+	selected, fewest := "", -1
+	for _, p := range s.stream.GetProjections() {
+		if p.GetSortTagName() != sortTag {
+			continue
+		}
+		stored := make(map[string]struct{}, len(p.GetTagNames())+1)
+		stored[p.GetSortTagName()] = struct{}{}
+		for _, name := range p.GetTagNames() {
+			stored[name] = struct{}{}
+		}
+		if !coversProjection(i.projectionTags, entity, stored) {
+			continue
+		}
+		if fewest < 0 || len(p.GetTagNames()) < fewest {
+			selected, fewest = p.GetName(), len(p.GetTagNames())
+		}
+	}
+	return selected
+}
+
+func coversProjection(projectionTags []model.TagProjection, entity, stored map[string]struct{}) bool {
+	for _, tp := range projectionTags {
+		for _, name := range tp.Names {
+			if _, ok := entity[name]; ok {
+				continue
+			}
+			if _, ok := stored[name]; !ok {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 func (i *localIndexScan) Children() []logical.Plan {
@@ -0,0 +1,98 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/index/posting"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func TestSelectProjection(t *testing.T) {
+	stream := &databasev1.Stream{
+		Entity: &databasev1.Entity{TagNames: []string{"service_id"}},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{
+				Name: "searchable",
+				Tags: []*databasev1.TagSpec{
+					{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+					{Name: "start_time", Type: databasev1.TagType_TAG_TYPE_INT},
+					{Name: "endpoint_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				},
+			},
+		},
+		Projections: []*databasev1.StreamProjection{
+			{Name: "wide", SortTagName: "duration", TagNames: []string{"trace_id", "endpoint_id"}},
+			{Name: "narrow", SortTagName: "duration", TagNames: []string{"trace_id"}},
+			{Name: "by_start", SortTagName: "start_time", TagNames: []string{"trace_id", "endpoint_id"}},
+		},
+	}
+	s, err := BuildSchema(stream, nil)
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	orderBy := func(tag string) *logical.OrderBy {
+		return &logical.OrderBy{Index: &databasev1.IndexRule{Tags: []string{tag}}, Sort: modelv1.Sort_SORT_DESC}
+	}
+	tests := []struct {
+		order          *logical.OrderBy
+		skippingFilter index.Filter
+		name           string
+		want           string
+		tags           []string
+	}{
+		{name: "fewest stored tags", order: orderBy("duration"), tags: []string{"service_id", "trace_id", "duration"}, want: "narrow"},
+		{name: "wider tag projection", order: orderBy("duration"), tags: []string{"trace_id", "endpoint_id"}, want: "wide"},
+		{name: "another sort tag", order: orderBy("start_time"), tags: []string{"trace_id"}, want: "by_start"},
+		{name: "uncovered tag", order: orderBy("start_time"), tags: []string{"duration"}},
+		{name: "no order", tags: []string{"trace_id"}},
+		{name: "match-all skipping filter", order: orderBy("duration"), tags: []string{"trace_id"}, skippingFilter: ENode, want: "narrow"},
+		{name: "skipping filter", order: orderBy("duration"), tags: []string{"trace_id"}, skippingFilter: skippingFilterStub{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := &localIndexScan{
+				schema:         s,
+				order:          tt.order,
+				skippingFilter: tt.skippingFilter,
+				projectionTags: []model.TagProjection{{Family: "searchable", Names: tt.tags}},
+			}
+			if got := scan.selectProjection(); got != tt.want {
+				t.Fatalf("want projection %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+type skippingFilterStub struct{}
+
+func (skippingFilterStub) String() string { return "skipping" }
+
+func (skippingFilterStub) Execute(index.GetSearcher, common.SeriesID, *index.RangeOpts) (posting.List, posting.List, error) {
+	return nil, nil, nil
+}
+
+func (skippingFilterStub) ShouldSkip(index.FilterOp) (bool, error) { return false, nil }
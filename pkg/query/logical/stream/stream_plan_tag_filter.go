@@ -43,6 +43,7 @@ type unresolvedTagFilter struct {
 	ec             executor.StreamExecutionContext
 	metadata       *commonv1.Metadata
 	criteria       *modelv1.Criteria
+	highlight      *streamv1.HighlightOption
 	projectionTags [][]*logical.Tag
 }
 
@@ -103,19 +104,41 @@ func (uis *unresolvedTagFilter) Analyze(s logical.Schema) (logical.Plan, error)
 		}
 	}
 
+	tagFiltered := uis.criteria != nil && tagFilter != nil && tagFilter != logical.DummyFilter
+	uis.optimize(ctx, !tagFiltered || requiresIndex(ctx.invertedFilter))
 	plan := uis.selectIndexScanner(ctx, uis.ec)
-	if uis.criteria != nil && tagFilter != nil && tagFilter != logical.DummyFilter {
+	if tagFiltered {
 		projSchema := s
 		if len(ctx.projTagsRefs) > 0 {
 			if projected := s.ProjTags(ctx.projTagsRefs...); projected != nil {
 				projSchema = projected
 			}
 		}
-		plan = newTagFilter(projSchema, plan, tagFilter, hiddenTags)
+		var h *highlighter
+		if uis.highlight != nil {
+			if h, err = newHighlighter(uis.highlight, uis.criteria, s); err != nil {
+				return nil, err
+			}
+		}
+		plan = newTagFilter(projSchema, plan, tagFilter, hiddenTags, h)
 	}
 	return plan, err
 }
 
+// optimize picks the cheapest strategy estimated from the index statistics.
+// The inverted filter is dropped if the index isn't used, and the tag filter evaluates its conditions instead.
+func (uis *unresolvedTagFilter) optimize(ctx *analyzeContext, invertedRequired bool) {
+	var stats *index.Stats
+	if sp, ok := uis.ec.(executor.IndexStatsProvider); ok {
+		stats = sp.IndexStats(timestamp.NewInclusiveTimeRange(uis.startTime, uis.endTime))
+	}
+	orderConjunctions(ctx.invertedFilter, stats)
+	ctx.estimate = chooseStrategy(stats, ctx.invertedFilter, ctx.skippingFilter, ctx.entities, invertedRequired)
+	if ctx.estimate.strategy != strategyIndexScan && ctx.invertedFilter != nil {
+		ctx.invertedFilter = ENode
+	}
+}
+
 func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext, ec executor.StreamExecutionContext) logical.Plan {
 	return &localIndexScan{
 		timeRange:         timestamp.NewInclusiveTimeRange(uis.startTime, uis.endTime),
@@ -125,6 +148,7 @@ func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext, ec execu
 		metadata:          uis.metadata,
 		invertedFilter:    ctx.invertedFilter,
 		skippingFilter:    ctx.skippingFilter,
+		estimate:          ctx.estimate,
 		entities:          ctx.entities,
 		l:                 logger.GetLogger("query", "stream", "local-index"),
 		ec:                ec,
@@ -132,13 +156,14 @@ func (uis *unresolvedTagFilter) selectIndexScanner(ctx *analyzeContext, ec execu
 }
 
 func tagFilter(startTime, endTime time.Time, metadata *commonv1.Metadata, criteria *modelv1.Criteria,
-	projection [][]*logical.Tag, ec executor.StreamExecutionContext,
+	highlight *streamv1.HighlightOption, projection [][]*logical.Tag, ec executor.StreamExecutionContext,
 ) logical.UnresolvedPlan {
 	return &unresolvedTagFilter{
 		startTime:      startTime,
 		endTime:        endTime,
 		metadata:       metadata,
 		criteria:       criteria,
+		highlight:      highlight,
 		projectionTags: projection,
 		ec:             ec,
 	}
@@ -151,6 +176,7 @@ type analyzeContext struct {
 	entities       [][]*modelv1.TagValue
 	projectionTags []model.TagProjection
 	projTagsRefs   [][]*logical.TagRef
+	estimate       costEstimate
 }
 
 func newAnalyzerContext(s logical.Schema) *analyzeContext {
@@ -165,22 +191,26 @@ var (
 )
 
 type tagFilterPlan struct {
-	s          logical.Schema
-	parent     logical.Plan
-	tagFilter  logical.TagFilter
-	hiddenTags logical.HiddenTagSet
+	s           logical.Schema
+	parent      logical.Plan
+	tagFilter   logical.TagFilter
+	hiddenTags  logical.HiddenTagSet
+	highlighter *highlighter
 }
 
 func (t *tagFilterPlan) Close() {
 	t.parent.(executor.StreamExecutable).Close()
 }
 
-func newTagFilter(s logical.Schema, parent logical.Plan, tagFilter logical.TagFilter, hiddenTags logical.HiddenTagSet) logical.Plan {
+func newTagFilter(s logical.Schema, parent logical.Plan, tagFilter logical.TagFilter,
+	hiddenTags logical.HiddenTagSet, highlighter *highlighter,
+) logical.Plan {
 	return &tagFilterPlan{
-		s:          s,
-		parent:     parent,
-		tagFilter:  tagFilter,
-		hiddenTags: hiddenTags,
+		s:           s,
+		parent:      parent,
+		tagFilter:   tagFilter,
+		hiddenTags:  hiddenTags,
+		highlighter: highlighter,
 	}
 }
 
@@ -201,6 +231,9 @@ func (t *tagFilterPlan) Execute(ec context.Context) ([]*streamv1.Element, error)
 				return nil, err
 			}
 			if ok {
+				if t.highlighter != nil {
+					e.Highlights = t.highlighter.highlight(e.TagFamilies, t.s)
+				}
 				// Strip hidden tags using shared utility
 				e.TagFamilies = t.hiddenTags.StripHiddenTags(e.TagFamilies)
 				filteredElements = append(filteredElements, e)
@@ -36,6 +36,7 @@ func TestAnalyzeAddsHiddenCriteriaTags(t *testing.T) {
 		time.Unix(1, 0),
 		metadata,
 		buildEqualityCriteria("filter_tag", "match"),
+		nil,
 		[][]*logical.Tag{logical.NewTags("default", "projected_tag")},
 		nil,
 	)
@@ -95,10 +95,13 @@ func BuildTagFilter(criteria *modelv1.Criteria, entityDict map[string]int, schem
 				return nil, errors.WithMessagef(ErrTagNotDefined, "tag %q does not exist in the current schema", cond.Name)
 			}
 		}
+		if IsPatternOp(cond.Op) {
+			return buildPatternTagFilter(cond, entityDict, schema, hasGlobalIndex, skippedTagNames)
+		}
 		var expr ComparableExpr
 		var err error
 		_, indexRule := indexChecker.IndexRuleDefined(cond.Name)
-		expr, err = parseExpr(cond.Value, analyzer.Analyzers[indexRule.GetAnalyzer()])
+		expr, err = parseExpr(cond.Value, analyzer.Get(indexRule.GetAnalyzer()))
 		if err != nil {
 			return nil, err
 		}
@@ -164,7 +167,7 @@ func parseFilter(cond *modelv1.Condition, expr ComparableExpr, schema Schema, in
 	case modelv1.Condition_BINARY_OP_EQ:
 		return newEqTag(cond.Name, expr), nil
 	case modelv1.Condition_BINARY_OP_MATCH:
-		return newMatchTag(cond.Name, expr, indexChecker), nil
+		return newMatchTag(cond, expr, indexChecker)
 	case modelv1.Condition_BINARY_OP_NE:
 		return newNotTag(newEqTag(cond.Name, expr)), nil
 	case modelv1.Condition_BINARY_OP_HAVING:
@@ -192,6 +195,32 @@ func parseFilter(cond *modelv1.Condition, expr ComparableExpr, schema Schema, in
 	}
 }
 
+func buildPatternTagFilter(cond *modelv1.Condition, entityDict map[string]int, schema Schema,
+	hasGlobalIndex bool, skippedTagNames []string,
+) (TagFilter, error) {
+	// entity tags and the skipped tags are not filtered by the tag values, so the pattern would be ignored silently
+	if _, ok := entityDict[cond.Name]; ok && !hasGlobalIndex {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "%s is not supported on the entity tag %q", cond.Op, cond.Name)
+	}
+	for _, skippedTagName := range skippedTagNames {
+		if cond.Name == skippedTagName {
+			return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "%s is not supported on the tag %q", cond.Op, cond.Name)
+		}
+	}
+	if schema != nil {
+		switch schema.FindTagSpecByName(cond.Name).Spec.GetType() {
+		case databasev1.TagType_TAG_TYPE_STRING, databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		default:
+			return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "%s only supports string tags, but %q is not", cond.Op, cond.Name)
+		}
+	}
+	pattern, err := ParsePattern(cond)
+	if err != nil {
+		return nil, err
+	}
+	return newPatternTag(cond.Name, cond.Op, pattern), nil
+}
+
 func parseExpr(value *modelv1.TagValue, analyzer *analysis.Analyzer) (ComparableExpr, error) {
 	if analyzer != nil {
 		if _, ok := value.Value.(*modelv1.TagValue_Str); ok {
@@ -545,26 +574,38 @@ func tagExpr(accessor TagValueIndexAccessor, registry TagSpecRegistry, tagName s
 
 type matchTag struct {
 	*tagLeaf
-	indexChecker IndexChecker
+	matcher *Matcher
 }
 
-func newMatchTag(tagName string, values LiteralExpr, indexChecker IndexChecker) *matchTag {
+func newMatchTag(cond *modelv1.Condition, values LiteralExpr, indexChecker IndexChecker) (*matchTag, error) {
+	_, indexRule := indexChecker.IndexRuleDefined(cond.Name)
+	matcher, err := NewMatcher(cond, indexRule.GetAnalyzer())
+	if err != nil {
+		return nil, err
+	}
 	return &matchTag{
 		tagLeaf: &tagLeaf{
-			Name: tagName,
+			Name: cond.Name,
 			Expr: values,
 		},
-		indexChecker: indexChecker,
-	}
+		matcher: matcher,
+	}, nil
 }
 
 func (m *matchTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
-	_, indexRule := m.indexChecker.IndexRuleDefined(m.Name)
-	expr, err := tagExpr(accessor, registry, m.Name, analyzer.Analyzers[indexRule.GetAnalyzer()])
-	if err != nil {
-		return false, err
+	tagSpec := registry.FindTagSpecByName(m.Name)
+	if tagSpec == nil {
+		return false, errors.WithMessagef(ErrTagNotDefined, "tag %q does not exist in the current schema", m.Name)
+	}
+	tagVal := accessor.GetTagValue(tagSpec.TagFamilyIdx, tagSpec.TagIdx)
+	if tagVal == nil {
+		return false, errors.WithMessagef(ErrTagNotDefined, "tag value is nil for tag %q, tagSpec: %+v", m.Name, tagSpec)
+	}
+	v, ok := tagVal.GetValue().(*modelv1.TagValue_Str)
+	if !ok {
+		return false, errors.WithMessagef(ErrUnsupportedConditionValue, "tag filter parses %v", tagVal)
 	}
-	return expr.Contains(m.Expr), nil
+	return m.matcher.Match(v.Str.GetValue()), nil
 }
 
 func (m *matchTag) MarshalJSON() ([]byte, error) {
@@ -577,6 +618,48 @@ func (m *matchTag) String() string {
 	return convert.JSONToString(m)
 }
 
+type patternTag struct {
+	Pattern *Pattern
+	Name    string
+	Op      string
+}
+
+func newPatternTag(tagName string, op modelv1.Condition_BinaryOp, pattern *Pattern) *patternTag {
+	return &patternTag{
+		Name:    tagName,
+		Op:      strings.ToLower(strings.TrimPrefix(op.String(), "BINARY_OP_")),
+		Pattern: pattern,
+	}
+}
+
+func (p *patternTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	tagSpec := registry.FindTagSpecByName(p.Name)
+	if tagSpec == nil {
+		return false, errors.WithMessagef(ErrTagNotDefined, "tag %q does not exist in the current schema", p.Name)
+	}
+	switch v := accessor.GetTagValue(tagSpec.TagFamilyIdx, tagSpec.TagIdx).GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return p.Pattern.MatchString(v.Str.GetValue()), nil
+	case *modelv1.TagValue_StrArray:
+		for _, item := range v.StrArray.GetValue() {
+			if p.Pattern.MatchString(item) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (p *patternTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data[p.Op] = map[string]string{"name": p.Name, "pattern": p.Pattern.String()}
+	return json.Marshal(data)
+}
+
+func (p *patternTag) String() string {
+	return convert.JSONToString(p)
+}
+
 type havingTag struct {
 	*tagLeaf
 }
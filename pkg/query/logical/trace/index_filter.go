@@ -80,6 +80,10 @@ func parseConditionToFilter(cond *modelv1.Condition, schema logical.Schema,
 		return &traceHavingFilter{op: "having", tagName: cond.Name, expr: expr}, [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_NOT_HAVING:
 		return &traceFilter{op: "not_having", tagName: cond.Name}, [][]*modelv1.TagValue{entity}, nil
+	case modelv1.Condition_BINARY_OP_LIKE:
+		return &traceFilter{op: "like", tagName: cond.Name}, [][]*modelv1.TagValue{entity}, nil
+	case modelv1.Condition_BINARY_OP_REGEXP:
+		return &traceFilter{op: "regexp", tagName: cond.Name}, [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_IN:
 		if schema != nil {
 			tagSpec := schema.FindTagSpecByName(cond.Name)
@@ -317,7 +321,8 @@ func extractIDsFromCondition(cond *modelv1.Condition) []string {
 		}
 	case modelv1.Condition_BINARY_OP_NE, modelv1.Condition_BINARY_OP_LT, modelv1.Condition_BINARY_OP_GT,
 		modelv1.Condition_BINARY_OP_LE, modelv1.Condition_BINARY_OP_GE, modelv1.Condition_BINARY_OP_HAVING,
-		modelv1.Condition_BINARY_OP_NOT_HAVING, modelv1.Condition_BINARY_OP_NOT_IN, modelv1.Condition_BINARY_OP_MATCH:
+		modelv1.Condition_BINARY_OP_NOT_HAVING, modelv1.Condition_BINARY_OP_NOT_IN, modelv1.Condition_BINARY_OP_MATCH,
+		modelv1.Condition_BINARY_OP_LIKE, modelv1.Condition_BINARY_OP_REGEXP:
 		// These operations don't support ID extraction
 	}
 
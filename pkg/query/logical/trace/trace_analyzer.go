@@ -240,6 +240,7 @@ func parseTraceTags(criteria *tracev1.QueryRequest, metadata *commonv1.Metadata,
 		endTime:          timeRange.GetEnd().AsTime(),
 		metadata:         metadata,
 		criteria:         criteria.Criteria,
+		predicate:        criteria.TracePredicate,
 		projectionTags:   tagProjection,
 		ec:               ec,
 		traceIDTagName:   traceIDTagName,
@@ -70,13 +70,15 @@ func (t *unresolvedTraceDistributed) Analyze(s logical.Schema) (logical.Plan, er
 	if limit == 0 {
 		limit = defaultLimit
 	}
+	// spans are sharded by the trace id, so data nodes hold whole traces to evaluate the trace predicate
 	temp := &tracev1.QueryRequest{
-		TagProjection: t.originalQuery.TagProjection,
-		Name:          t.originalQuery.Name,
-		Groups:        t.originalQuery.Groups,
-		Criteria:      t.originalQuery.Criteria,
-		Limit:         limit + t.originalQuery.Offset,
-		OrderBy:       t.originalQuery.OrderBy,
+		TagProjection:  t.originalQuery.TagProjection,
+		Name:           t.originalQuery.Name,
+		Groups:         t.originalQuery.Groups,
+		Criteria:       t.originalQuery.Criteria,
+		Limit:          limit + t.originalQuery.Offset,
+		OrderBy:        t.originalQuery.OrderBy,
+		TracePredicate: t.originalQuery.TracePredicate,
 	}
 	if t.originalQuery.OrderBy == nil {
 		return &distributedPlan{
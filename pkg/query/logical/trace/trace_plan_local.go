@@ -44,6 +44,7 @@ type localScan struct {
 	schema            logical.Schema
 	skippingFilter    index.Filter
 	tagFilterMatcher  model.TagFilterMatcher
+	predicate         model.TracePredicate
 	result            model.TraceQueryResult
 	ec                executor.TraceExecutionContext
 	order             *logical.OrderBy
@@ -103,6 +104,7 @@ func (i *localScan) Execute(ctx context.Context) (iter.Iterator[model.TraceResul
 			TraceIDs:       i.traceIDs,
 			MinVal:         i.minVal,
 			MaxVal:         i.maxVal,
+			Predicate:      i.predicate,
 		}); err != nil {
 			return iter.Empty[model.TraceResult](), err
 		}
@@ -156,9 +158,9 @@ func (tri *traceResultIterator) Next() (model.TraceResult, bool) {
 }
 
 func (i *localScan) String() string {
-	return fmt.Sprintf("TraceScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; projection=%s; orderBy=%s; limit=%d",
+	return fmt.Sprintf("TraceScan: startTime=%d,endTime=%d,Metadata{group=%s,name=%s},conditions=%s; predicate=%v; projection=%s; orderBy=%s; limit=%d",
 		i.timeRange.Start.Unix(), i.timeRange.End.Unix(), i.metadata.GetGroup(), i.metadata.GetName(),
-		i.skippingFilter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxTraceSize)
+		i.skippingFilter, i.predicate, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxTraceSize)
 }
 
 func (i *localScan) Children() []logical.Plan {
@@ -25,6 +25,7 @@ import (
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/iter"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -43,6 +44,7 @@ type unresolvedTraceTagFilter struct {
 	ec               executor.TraceExecutionContext
 	metadata         *commonv1.Metadata
 	criteria         *modelv1.Criteria
+	predicate        *tracev1.TracePredicate
 	traceIDTagName   string
 	spanIDTagName    string
 	orderByTag       string
@@ -117,6 +119,15 @@ func (uis *unresolvedTraceTagFilter) Analyze(s logical.Schema) (logical.Plan, er
 		conditionSchema = s.ProjTags(conditionTagRefs...)
 	}
 
+	// Add tag names required by the trace predicate to projection
+	ctx.predicate, err = buildTracePredicate(uis.predicate, s, uis.timestampTagName)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.predicate != nil {
+		ctx.projectionTags.Names = append(ctx.projectionTags.Names, ctx.predicate.tagNames()...)
+	}
+
 	// Deduplicate tag names
 	ctx.projectionTags.Names = deduplicateStrings(ctx.projectionTags.Names)
 
@@ -167,7 +178,7 @@ func (uis *unresolvedTraceTagFilter) Analyze(s logical.Schema) (logical.Plan, er
 func (uis *unresolvedTraceTagFilter) selectTraceScanner(ctx *traceAnalyzeContext,
 	ec executor.TraceExecutionContext, traceIDs []string, minVal, maxVal int64, tagFilterMatcher model.TagFilterMatcher,
 ) logical.Plan {
-	return &localScan{
+	scan := &localScan{
 		timeRange:         timestamp.NewInclusiveTimeRange(uis.startTime, uis.endTime),
 		schema:            ctx.s,
 		projectionTagRefs: ctx.projTagsRefs,
@@ -183,11 +194,17 @@ func (uis *unresolvedTraceTagFilter) selectTraceScanner(ctx *traceAnalyzeContext
 		maxVal:            maxVal,
 		groupIndex:        uis.groupIndex,
 	}
+	// avoid a typed nil predicate
+	if ctx.predicate != nil {
+		scan.predicate = ctx.predicate
+	}
+	return scan
 }
 
 type traceAnalyzeContext struct {
 	s              logical.Schema
 	skippingFilter index.Filter
+	predicate      *tracePredicate
 	projectionTags *model.TagProjection
 	projTagsRefs   [][]*logical.TagRef
 	entities       [][]*modelv1.TagValue
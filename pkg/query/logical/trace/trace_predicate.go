@@ -0,0 +1,253 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+var _ model.TracePredicate = (*tracePredicate)(nil)
+
+// tracePredicate evaluates a tracev1.TracePredicate against the spans of a trace.
+type tracePredicate struct {
+	registry     *predicateTagRegistry
+	spanFilters  []logical.TagFilter
+	minSpanCount int
+	maxSpanCount int
+	minDuration  int64
+	maxDuration  int64
+	durationUnit time.Duration
+	timestampIdx int
+	durationIdx  int
+}
+
+var durationUnits = map[tracev1.DurationUnit]time.Duration{
+	tracev1.DurationUnit_DURATION_UNIT_NANOSECOND:  time.Nanosecond,
+	tracev1.DurationUnit_DURATION_UNIT_MICROSECOND: time.Microsecond,
+	tracev1.DurationUnit_DURATION_UNIT_MILLISECOND: time.Millisecond,
+	tracev1.DurationUnit_DURATION_UNIT_SECOND:      time.Second,
+}
+
+// buildTracePredicate returns nil if the predicate has no condition.
+// The tags required by the predicate are registered in the returned predicate, which should be projected by the scan.
+func buildTracePredicate(predicate *tracev1.TracePredicate, s logical.Schema, timestampTagName string) (*tracePredicate, error) {
+	if predicate == nil {
+		return nil, nil
+	}
+	if predicate.MaxSpanCount > 0 && predicate.MinSpanCount > predicate.MaxSpanCount {
+		return nil, errors.Errorf("min_span_count %d is greater than max_span_count %d", predicate.MinSpanCount, predicate.MaxSpanCount)
+	}
+	tp := &tracePredicate{
+		registry:     &predicateTagRegistry{s: s, indexes: make(map[string]int)},
+		minSpanCount: int(predicate.MinSpanCount),
+		maxSpanCount: int(predicate.MaxSpanCount),
+		timestampIdx: -1,
+		durationIdx:  -1,
+	}
+	if predicate.MinDuration != nil {
+		tp.minDuration = predicate.MinDuration.AsDuration().Nanoseconds()
+	}
+	if predicate.MaxDuration != nil {
+		tp.maxDuration = predicate.MaxDuration.AsDuration().Nanoseconds()
+	}
+	if tp.maxDuration > 0 && tp.minDuration > tp.maxDuration {
+		return nil, errors.Errorf("min_duration %s is greater than max_duration %s", predicate.MinDuration.AsDuration(), predicate.MaxDuration.AsDuration())
+	}
+	var err error
+	if tp.minDuration > 0 || tp.maxDuration > 0 {
+		if tp.timestampIdx, err = tp.registry.register(timestampTagName); err != nil {
+			return nil, err
+		}
+		if predicate.DurationTagName != "" {
+			if tp.durationIdx, err = tp.registry.register(predicate.DurationTagName); err != nil {
+				return nil, err
+			}
+			if tagType := tp.registry.tagType(tp.durationIdx); tagType != databasev1.TagType_TAG_TYPE_INT {
+				return nil, errors.Errorf("duration tag %q should be an integer rather than %s", predicate.DurationTagName, tagType)
+			}
+			var ok bool
+			if tp.durationUnit, ok = durationUnits[predicate.DurationUnit]; !ok {
+				return nil, errors.Errorf("duration tag %q requires a duration unit", predicate.DurationTagName)
+			}
+		}
+	}
+	for _, criteria := range predicate.SpanCriteria {
+		for _, name := range collectCriteriaTagNames(criteria) {
+			if _, err = tp.registry.register(name); err != nil {
+				return nil, err
+			}
+		}
+		var filter logical.TagFilter
+		if filter, err = logical.BuildTagFilter(criteria, nil, s, s, false); err != nil {
+			return nil, err
+		}
+		tp.spanFilters = append(tp.spanFilters, filter)
+	}
+	if tp.minSpanCount == 0 && tp.maxSpanCount == 0 && tp.minDuration == 0 && tp.maxDuration == 0 && len(tp.spanFilters) == 0 {
+		return nil, nil
+	}
+	return tp, nil
+}
+
+// tagNames returns the tags required by the predicate.
+func (tp *tracePredicate) tagNames() []string {
+	return tp.registry.names
+}
+
+func (tp *tracePredicate) Match(result *model.TraceResult) (bool, error) {
+	spanCount := len(result.Spans)
+	if spanCount < tp.minSpanCount || tp.maxSpanCount > 0 && spanCount > tp.maxSpanCount {
+		return false, nil
+	}
+	columns := make([][]*modelv1.TagValue, len(tp.registry.names))
+	for _, tag := range result.Tags {
+		if idx, ok := tp.registry.indexes[tag.Name]; ok {
+			columns[idx] = tag.Values
+		}
+	}
+	if tp.minDuration > 0 || tp.maxDuration > 0 {
+		duration, ok := tp.duration(columns, spanCount)
+		if !ok || duration < tp.minDuration || tp.maxDuration > 0 && duration > tp.maxDuration {
+			return false, nil
+		}
+	}
+	row := &predicateRow{columns: columns}
+	for _, filter := range tp.spanFilters {
+		matched := false
+		for row.idx = 0; row.idx < spanCount; row.idx++ {
+			ok, err := filter.Match(row, tp.registry)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// duration returns the gap between the earliest start and the latest end of the spans.
+func (tp *tracePredicate) duration(columns [][]*modelv1.TagValue, spanCount int) (int64, bool) {
+	start, end := int64(math.MaxInt64), int64(math.MinInt64)
+	for i := 0; i < spanCount; i++ {
+		ts, ok := timestampOf(columnValue(columns, tp.timestampIdx, i))
+		if !ok {
+			continue
+		}
+		spanEnd := ts
+		if d := columnValue(columns, tp.durationIdx, i).GetInt(); d != nil {
+			spanEnd += (time.Duration(d.Value) * tp.durationUnit).Nanoseconds()
+		}
+		start = min(start, ts)
+		end = max(end, spanEnd)
+	}
+	if start > end {
+		return 0, false
+	}
+	return end - start, true
+}
+
+func (tp *tracePredicate) String() string {
+	return fmt.Sprintf("span_count:[%d,%d],duration:[%d,%d],span_filters:%v",
+		tp.minSpanCount, tp.maxSpanCount, tp.minDuration, tp.maxDuration, tp.spanFilters)
+}
+
+func columnValue(columns [][]*modelv1.TagValue, idx, row int) *modelv1.TagValue {
+	if idx < 0 || row >= len(columns[idx]) {
+		return nil
+	}
+	return columns[idx][row]
+}
+
+func timestampOf(value *modelv1.TagValue) (int64, bool) {
+	switch v := value.GetValue().(type) {
+	case *modelv1.TagValue_Timestamp:
+		return v.Timestamp.AsTime().UnixNano(), true
+	case *modelv1.TagValue_Int:
+		return v.Int.Value, true
+	}
+	return 0, false
+}
+
+func collectCriteriaTagNames(criteria *modelv1.Criteria) []string {
+	switch c := criteria.GetExp().(type) {
+	case *modelv1.Criteria_Condition:
+		return []string{c.Condition.Name}
+	case *modelv1.Criteria_Le:
+		return append(collectCriteriaTagNames(c.Le.Left), collectCriteriaTagNames(c.Le.Right)...)
+	}
+	return nil
+}
+
+// predicateTagRegistry locates the tags required by a predicate in the columns of a trace.
+type predicateTagRegistry struct {
+	s       logical.Schema
+	indexes map[string]int
+	names   []string
+}
+
+func (r *predicateTagRegistry) register(name string) (int, error) {
+	if idx, ok := r.indexes[name]; ok {
+		return idx, nil
+	}
+	if r.s.FindTagSpecByName(name) == nil {
+		return -1, errors.WithMessagef(logical.ErrTagNotDefined, "tag %q does not exist in the current schema", name)
+	}
+	idx := len(r.names)
+	r.indexes[name] = idx
+	r.names = append(r.names, name)
+	return idx, nil
+}
+
+func (r *predicateTagRegistry) tagType(idx int) databasev1.TagType {
+	return r.s.FindTagSpecByName(r.names[idx]).Spec.GetType()
+}
+
+func (r *predicateTagRegistry) FindTagSpecByName(name string) *logical.TagSpec {
+	idx, ok := r.indexes[name]
+	if !ok {
+		return nil
+	}
+	spec := r.s.FindTagSpecByName(name)
+	return &logical.TagSpec{Spec: spec.Spec, TagIdx: idx}
+}
+
+// predicateRow exposes a span of a trace to the tag filters.
+type predicateRow struct {
+	columns [][]*modelv1.TagValue
+	idx     int
+}
+
+func (r *predicateRow) GetTagValue(_, tagIdx int) *modelv1.TagValue {
+	return columnValue(r.columns, tagIdx, r.idx)
+}
@@ -0,0 +1,205 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+func predicateTestSchema(t *testing.T) logical.Schema {
+	trace := &databasev1.Trace{
+		Metadata: &commonv1.Metadata{Name: "test", Group: "default"},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+		},
+		TraceIdTagName:   "trace_id",
+		SpanIdTagName:    "span_id",
+		TimestampTagName: "timestamp",
+	}
+	s, err := BuildSchema(trace, nil)
+	require.NoError(t, err)
+	return s
+}
+
+func serviceCondition(service string) *modelv1.Criteria {
+	return &modelv1.Criteria{
+		Exp: &modelv1.Criteria_Condition{
+			Condition: &modelv1.Condition{
+				Name:  "service_id",
+				Op:    modelv1.Condition_BINARY_OP_EQ,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: service}}},
+			},
+		},
+	}
+}
+
+// predicateTestTrace returns a trace with three spans of service a, b and c.
+// It lasts from its first span start to its last span end, that is 2s + 1.5s = 3.5s.
+func predicateTestTrace() *model.TraceResult {
+	begin := time.Unix(1700000000, 0)
+	timestamps := []time.Time{begin, begin.Add(time.Second), begin.Add(2 * time.Second)}
+	durations := []int64{500, 500, 1500}
+	services := []string{"a", "b", "c"}
+	result := &model.TraceResult{
+		TID:  "trace-1",
+		Tags: []model.Tag{{Name: "service_id"}, {Name: "duration"}, {Name: "timestamp"}},
+	}
+	for i := range services {
+		result.Spans = append(result.Spans, []byte(services[i]))
+		result.Tags[0].Values = append(result.Tags[0].Values,
+			&modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: services[i]}}})
+		result.Tags[1].Values = append(result.Tags[1].Values,
+			&modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: durations[i]}}})
+		result.Tags[2].Values = append(result.Tags[2].Values,
+			&modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(timestamps[i])}})
+	}
+	return result
+}
+
+func TestTracePredicateMatch(t *testing.T) {
+	tests := []struct {
+		predicate *tracev1.TracePredicate
+		name      string
+		want      bool
+	}{
+		{
+			name:      "min span count",
+			predicate: &tracev1.TracePredicate{MinSpanCount: 3},
+			want:      true,
+		},
+		{
+			name:      "more spans than max span count",
+			predicate: &tracev1.TracePredicate{MaxSpanCount: 2},
+			want:      false,
+		},
+		{
+			name: "longer than min duration",
+			predicate: &tracev1.TracePredicate{
+				MinDuration:     durationpb.New(3 * time.Second),
+				DurationTagName: "duration",
+				DurationUnit:    tracev1.DurationUnit_DURATION_UNIT_MILLISECOND,
+			},
+			want: true,
+		},
+		{
+			name: "shorter than min duration",
+			predicate: &tracev1.TracePredicate{
+				MinDuration:     durationpb.New(4 * time.Second),
+				DurationTagName: "duration",
+				DurationUnit:    tracev1.DurationUnit_DURATION_UNIT_MILLISECOND,
+			},
+			want: false,
+		},
+		{
+			name: "durations in microseconds",
+			predicate: &tracev1.TracePredicate{
+				MinDuration:     durationpb.New(3 * time.Second),
+				DurationTagName: "duration",
+				DurationUnit:    tracev1.DurationUnit_DURATION_UNIT_MICROSECOND,
+			},
+			want: false,
+		},
+		{
+			name:      "duration without the duration tag",
+			predicate: &tracev1.TracePredicate{MaxDuration: durationpb.New(2 * time.Second)},
+			want:      true,
+		},
+		{
+			name: "touch both services",
+			predicate: &tracev1.TracePredicate{
+				SpanCriteria: []*modelv1.Criteria{serviceCondition("a"), serviceCondition("c")},
+			},
+			want: true,
+		},
+		{
+			name: "miss one of the services",
+			predicate: &tracev1.TracePredicate{
+				SpanCriteria: []*modelv1.Criteria{serviceCondition("a"), serviceCondition("d")},
+			},
+			want: false,
+		},
+	}
+	s := predicateTestSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := buildTracePredicate(tt.predicate, s, "timestamp")
+			require.NoError(t, err)
+			require.NotNil(t, tp)
+			matched, err := tp.Match(predicateTestTrace())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
+
+func TestBuildTracePredicate(t *testing.T) {
+	s := predicateTestSchema(t)
+
+	tp, err := buildTracePredicate(&tracev1.TracePredicate{}, s, "timestamp")
+	require.NoError(t, err)
+	assert.Nil(t, tp, "an empty predicate should be ignored")
+
+	tp, err = buildTracePredicate(&tracev1.TracePredicate{
+		MinDuration:     durationpb.New(time.Second),
+		DurationTagName: "duration",
+		DurationUnit:    tracev1.DurationUnit_DURATION_UNIT_MILLISECOND,
+		SpanCriteria:    []*modelv1.Criteria{serviceCondition("a")},
+	}, s, "timestamp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"timestamp", "duration", "service_id"}, tp.tagNames())
+
+	_, err = buildTracePredicate(&tracev1.TracePredicate{MinSpanCount: 3, MaxSpanCount: 2}, s, "timestamp")
+	assert.Error(t, err)
+	_, err = buildTracePredicate(&tracev1.TracePredicate{
+		MinDuration:     durationpb.New(time.Second),
+		DurationTagName: "service_id",
+	}, s, "timestamp")
+	assert.Error(t, err, "the duration tag should be an integer")
+	_, err = buildTracePredicate(&tracev1.TracePredicate{
+		MinDuration:     durationpb.New(time.Second),
+		DurationTagName: "duration",
+	}, s, "timestamp")
+	assert.Error(t, err, "the duration tag requires a unit")
+	_, err = buildTracePredicate(&tracev1.TracePredicate{
+		SpanCriteria: []*modelv1.Criteria{{
+			Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+				Name:  "unknown",
+				Op:    modelv1.Condition_BINARY_OP_EQ,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "x"}}},
+			}},
+		}},
+	}, s, "timestamp")
+	assert.ErrorIs(t, err, logical.ErrTagNotDefined)
+}
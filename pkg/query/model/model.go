@@ -60,6 +60,7 @@ type MeasureQueryOptions struct {
 	Query           index.Query
 	TimeRange       *timestamp.TimeRange
 	Order           *index.OrderBy
+	Aggregation     *MeasureAggregation
 	Name            string
 	Entities        [][]*modelv1.TagValue
 	TagProjection   []TagProjection
@@ -68,6 +69,12 @@ type MeasureQueryOptions struct {
 	Number          int32
 }
 
+// MeasureAggregation is the aggregation of a field which the storage can answer from the block stats.
+type MeasureAggregation struct {
+	FieldName string
+	Function  modelv1.AggregationFunction
+}
+
 // MeasureResult is the result of a query.
 type MeasureResult struct {
 	Error       error
@@ -76,7 +83,10 @@ type MeasureResult struct {
 	ShardIDs    []common.ShardID
 	TagFamilies []TagFamily
 	Fields      []Field
-	SID         common.SeriesID
+	// AggregatedCounts is set if the data points are the partial aggregations answered from the block stats.
+	// The aggregated field holds the partial value and AggregatedCounts holds the number of the aggregated values.
+	AggregatedCounts []int64
+	SID              common.SeriesID
 }
 
 // MeasureQueryResult is the result of a measure query.
@@ -93,6 +103,9 @@ type StreamQueryOptions struct {
 	InvertedFilter index.Filter
 	SkippingFilter index.Filter
 	Order          *index.OrderBy
+	// Projection is the name of the stream projection covering the order and the tag projection.
+	// The storage falls back to the index if the projection isn't available in the time range.
+	Projection     string
 	TagProjection  []TagProjection
 	MaxElementSize int
 }
@@ -106,6 +119,7 @@ func (s *StreamQueryOptions) Reset() {
 	s.SkippingFilter = nil
 	s.Order = nil
 	s.TagProjection = nil
+	s.Projection = ""
 	s.MaxElementSize = 0
 }
 
@@ -134,6 +148,7 @@ func (s *StreamQueryOptions) CopyFrom(other *StreamQueryOptions) {
 		s.TagProjection = nil
 	}
 
+	s.Projection = other.Projection
 	s.MaxElementSize = other.MaxElementSize
 }
 
@@ -329,10 +344,16 @@ type StreamQueryResult interface {
 	Release()
 }
 
+// TracePredicate matches a trace after its spans are grouped by the trace ID.
+type TracePredicate interface {
+	Match(result *TraceResult) (bool, error)
+}
+
 // TraceQueryOptions is the options of a trace query.
 type TraceQueryOptions struct {
 	SkippingFilter index.Filter
 	TagFilter      TagFilterMatcher
+	Predicate      TracePredicate
 	TimeRange      *timestamp.TimeRange
 	Order          *index.OrderBy
 	TagProjection  *TagProjection
@@ -350,6 +371,7 @@ func (t *TraceQueryOptions) Reset() {
 	t.TimeRange = nil
 	t.SkippingFilter = nil
 	t.TagFilter = nil
+	t.Predicate = nil
 	t.Order = nil
 	t.TagProjection = nil
 	t.Entities = nil
@@ -363,6 +385,7 @@ func (t *TraceQueryOptions) CopyFrom(other *TraceQueryOptions) {
 	t.TimeRange = other.TimeRange
 	t.SkippingFilter = other.SkippingFilter
 	t.TagFilter = other.TagFilter
+	t.Predicate = other.Predicate
 	t.Order = other.Order
 	t.TagProjection = other.TagProjection
 	t.Entities = other.Entities
@@ -557,9 +557,9 @@ func (sr *schemaRepo) ResourceRevision(kind schema.Kind, groupName, name string)
 			}
 		}
 	case schema.KindIndexRuleBinding, schema.KindTopNAggregation,
-		schema.KindNode, schema.KindProperty, schema.KindMask:
+		schema.KindNode, schema.KindProperty, schema.KindAnalyzer, schema.KindContinuousAggregation, schema.KindMask:
 		// schemaRepo only caches resources, index rules, and groups; other kinds
-		// (bindings, top-n aggregations, nodes, properties, masks) have no entry here.
+		// (bindings, top-n and continuous aggregations, nodes, properties, analyzers, masks) have no entry here.
 	}
 	return 0, false
 }
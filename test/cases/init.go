@@ -45,6 +45,7 @@ func Initialize(addr string, now time.Time) {
 	// stream
 	casesstreamdata.Write(conn, "sw", now, interval)
 	casesstreamdata.Write(conn, "duplicated", now, 0)
+	casesstreamdata.WriteToGroup(conn, "sw_projection", "default", "sw", now, interval)
 	casesstreamdata.WriteDeduplicationTest(conn, "deduplication_test", now, time.Millisecond)
 	casesstreamdata.WriteToGroup(conn, "sw", "updated", "sw_updated", now.Add(time.Minute), interval)
 	casesstreamdata.WriteMixed(conn, now.Add(2*time.Minute), interval,
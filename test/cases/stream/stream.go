@@ -59,6 +59,7 @@ var streamEntries = []any{
 		End:   timestamppb.New(time.Unix(0, math.MaxInt64).Truncate(time.Millisecond)),
 	}),
 	g.Entry("sort desc", helpers.Args{Input: "sort_desc", Duration: 1 * time.Hour}),
+	g.Entry("sort desc by projection", helpers.Args{Input: "projection_sort_desc", Want: "sort_desc", Duration: 1 * time.Hour, IgnoreElementID: true}),
 	g.Entry("sort with filter", helpers.Args{Input: "sort_filter", Duration: 1 * time.Hour}),
 	g.Entry("sort with empty result", helpers.Args{Input: "sort_empty", Duration: 1 * time.Hour, WantEmpty: true}),
 	g.Entry("global index", helpers.Args{Input: "global_index", Duration: 1 * time.Hour}),
@@ -75,6 +76,10 @@ var streamEntries = []any{
 	g.Entry("having non indexed", helpers.Args{Input: "having_non_indexed", Duration: 1 * time.Hour}),
 	g.Entry("having non indexed array", helpers.Args{Input: "having_non_indexed_arr", Duration: 1 * time.Hour}),
 	g.Entry("full text searching", helpers.Args{Input: "search", Duration: 1 * time.Hour}),
+	g.Entry("phrase searching with highlights", helpers.Args{Input: "search_phrase_highlight", Duration: 1 * time.Hour}),
+	g.Entry("fuzzy searching", helpers.Args{Input: "search_fuzzy", Want: "search", Duration: 1 * time.Hour}),
+	g.Entry("like on indexed tag", helpers.Args{Input: "like_indexed", Duration: 1 * time.Hour}),
+	g.Entry("regexp on non-indexed tag", helpers.Args{Input: "regexp_non_indexed", Duration: 1 * time.Hour}),
 	g.Entry("filter by non-indexed tag with or", helpers.Args{Input: "filter_no_indexed_or", Duration: 1 * time.Hour}),
 	g.Entry("filter with desc order", helpers.Args{Input: "filter_order_desc", Duration: 1 * time.Hour}),
 	g.Entry("duplicated all elements", helpers.Args{Input: "duplicated_all", Duration: 1 * time.Hour, DisOrder: true}),
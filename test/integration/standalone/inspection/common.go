@@ -55,6 +55,8 @@ var (
 	measureRegClient   databasev1.MeasureRegistryServiceClient
 	streamRegClient    databasev1.StreamRegistryServiceClient
 	traceRegClient     databasev1.TraceRegistryServiceClient
+	indexRuleClient    databasev1.IndexRuleRegistryServiceClient
+	bindingClient      databasev1.IndexRuleBindingRegistryServiceClient
 	measureWriteClient measurev1.MeasureServiceClient
 	streamWriteClient  streamv1.StreamServiceClient
 	traceWriteClient   tracev1.TraceServiceClient
@@ -87,6 +89,8 @@ var _ = ginkgo.SynchronizedBeforeSuite(func() []byte {
 	measureRegClient = databasev1.NewMeasureRegistryServiceClient(connection)
 	streamRegClient = databasev1.NewStreamRegistryServiceClient(connection)
 	traceRegClient = databasev1.NewTraceRegistryServiceClient(connection)
+	indexRuleClient = databasev1.NewIndexRuleRegistryServiceClient(connection)
+	bindingClient = databasev1.NewIndexRuleBindingRegistryServiceClient(connection)
 	measureWriteClient = measurev1.NewMeasureServiceClient(connection)
 	streamWriteClient = streamv1.NewStreamServiceClient(connection)
 	traceWriteClient = tracev1.NewTraceServiceClient(connection)
@@ -557,3 +561,479 @@ var _ = ginkgo.Describe("Inspect trace in standalone mode", ginkgo.Ordered, func
 			len(resp.SchemaInfo.IndexRules))
 	})
 })
+
+var _ = ginkgo.Describe("Backfill the index of stream in standalone mode", ginkgo.Ordered, func() {
+	var groupName string
+	var ctx context.Context
+	const (
+		streamName = "test_stream"
+		dataCount  = 10
+	)
+
+	ginkgo.BeforeAll(func() {
+		ctx = context.TODO()
+		groupName = fmt.Sprintf("backfill-stream-test-%d", time.Now().UnixNano())
+
+		ginkgo.By("Creating stream group")
+		_, createErr := groupClient.Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{
+					Name: groupName,
+				},
+				Catalog: commonv1.Catalog_CATALOG_STREAM,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum: 2,
+					SegmentInterval: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  1,
+					},
+					Ttl: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  7,
+					},
+				},
+			},
+		})
+		gomega.Expect(createErr).ShouldNot(gomega.HaveOccurred())
+
+		ginkgo.By("Creating stream schema")
+		_, streamErr := streamRegClient.Create(ctx, &databasev1.StreamRegistryServiceCreateRequest{
+			Stream: &databasev1.Stream{
+				Metadata: &commonv1.Metadata{
+					Name:  streamName,
+					Group: groupName,
+				},
+				Entity: &databasev1.Entity{
+					TagNames: []string{"svc"},
+				},
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{
+						{Name: "svc", Type: databasev1.TagType_TAG_TYPE_STRING},
+						{Name: "status", Type: databasev1.TagType_TAG_TYPE_STRING},
+					},
+				}},
+			},
+		})
+		gomega.Expect(streamErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(time.Second)
+
+		ginkgo.By("Writing stream data before the binding")
+		writeClient, writeErr := streamWriteClient.Write(ctx)
+		gomega.Expect(writeErr).ShouldNot(gomega.HaveOccurred())
+		baseTime := time.Now().Truncate(time.Millisecond)
+		for idx := 0; idx < dataCount; idx++ {
+			status := "ok"
+			if idx%2 == 0 {
+				status = "error"
+			}
+			gomega.Expect(writeClient.Send(&streamv1.WriteRequest{
+				Metadata: &commonv1.Metadata{Name: streamName, Group: groupName},
+				Element: &streamv1.ElementValue{
+					ElementId: strconv.Itoa(idx),
+					Timestamp: timestamppb.New(baseTime.Add(time.Duration(idx) * time.Second)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{
+							{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc_" + strconv.Itoa(idx%3)}}},
+							{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: status}}},
+						},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).Should(gomega.Succeed())
+		}
+		gomega.Expect(writeClient.CloseSend()).To(gomega.Succeed())
+		gomega.Eventually(func() error {
+			_, recvErr := writeClient.Recv()
+			return recvErr
+		}, flags.EventuallyTimeout).Should(gomega.Equal(io.EOF))
+
+		ginkgo.By("Binding an index rule to the written tag")
+		_, ruleErr := indexRuleClient.Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{
+			IndexRule: &databasev1.IndexRule{
+				Metadata: &commonv1.Metadata{Name: "status", Group: groupName},
+				Tags:     []string{"status"},
+				Type:     databasev1.IndexRule_TYPE_INVERTED,
+			},
+		})
+		gomega.Expect(ruleErr).ShouldNot(gomega.HaveOccurred())
+		_, bindingErr := bindingClient.Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{
+			IndexRuleBinding: &databasev1.IndexRuleBinding{
+				Metadata: &commonv1.Metadata{Name: "status_binding", Group: groupName},
+				Rules:    []string{"status"},
+				Subject: &databasev1.Subject{
+					Catalog: commonv1.Catalog_CATALOG_STREAM,
+					Name:    streamName,
+				},
+				BeginAt:  timestamppb.New(time.Now().Add(-time.Hour)),
+				ExpireAt: timestamppb.New(time.Now().Add(24 * time.Hour)),
+			},
+		})
+		gomega.Expect(bindingErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(2 * time.Second)
+	})
+
+	ginkgo.AfterAll(func() {
+		_, _ = groupClient.Delete(ctx, &databasev1.GroupRegistryServiceDeleteRequest{Group: groupName})
+	})
+
+	queryErrors := func() (int, error) {
+		now := time.Now().Truncate(time.Millisecond)
+		resp, err := streamWriteClient.Query(ctx, &streamv1.QueryRequest{
+			Groups: []string{groupName},
+			Name:   streamName,
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(now.Add(-time.Hour)),
+				End:   timestamppb.New(now.Add(time.Hour)),
+			},
+			Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+				Name:  "status",
+				Op:    modelv1.Condition_BINARY_OP_EQ,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "error"}}},
+			}}},
+			Projection: &modelv1.TagProjection{
+				TagFamilies: []*modelv1.TagProjection_TagFamily{
+					{Name: "default", Tags: []string{"svc", "status"}},
+				},
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(resp.Elements), nil
+	}
+
+	ginkgo.It("should index the data written before the binding", func() {
+		ginkgo.By("Missing the data written before the binding")
+		gomega.Expect(queryErrors()).To(gomega.Equal(0))
+
+		ginkgo.By("Scheduling the backfill")
+		resp, err := bindingClient.Backfill(ctx, &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{
+			Metadata:  &commonv1.Metadata{Name: "status_binding", Group: groupName},
+			RateLimit: 100,
+		})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(resp.ShardCount).Should(gomega.BeNumerically(">=", 1))
+
+		ginkgo.By("Waiting for the backfill to complete in all shards")
+		gomega.Eventually(func(g gomega.Gomega) {
+			inspectResp, inspectErr := groupClient.Inspect(ctx, &databasev1.GroupRegistryServiceInspectRequest{Group: groupName})
+			g.Expect(inspectErr).ShouldNot(gomega.HaveOccurred())
+			var scheduled int64
+			for _, dataInfo := range inspectResp.DataInfo {
+				for _, segmentInfo := range dataInfo.SegmentInfo {
+					for _, shardInfo := range segmentInfo.ShardInfo {
+						for _, info := range shardInfo.IndexBackfillInfo {
+							g.Expect(info.Binding).To(gomega.Equal("status_binding"))
+							g.Expect(info.CurrentPhase).To(gomega.Equal(databasev1.IndexBackfillInfo_PHASE_COMPLETED), info.Message)
+							scheduled++
+						}
+					}
+				}
+			}
+			g.Expect(scheduled).To(gomega.Equal(resp.ShardCount))
+		}, flags.EventuallyTimeout).Should(gomega.Succeed())
+
+		ginkgo.By("Querying by the backfilled index")
+		gomega.Eventually(queryErrors, flags.EventuallyTimeout).Should(gomega.Equal(dataCount / 2))
+	})
+})
+
+var _ = ginkgo.Describe("Backfill the index of measure in standalone mode", ginkgo.Ordered, func() {
+	var groupName string
+	var ctx context.Context
+	const (
+		measureName = "test_measure"
+		dataCount   = 10
+	)
+
+	ginkgo.BeforeAll(func() {
+		ctx = context.TODO()
+		groupName = fmt.Sprintf("backfill-measure-test-%d", time.Now().UnixNano())
+
+		ginkgo.By("Creating measure group")
+		_, createErr := groupClient.Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{
+					Name: groupName,
+				},
+				Catalog: commonv1.Catalog_CATALOG_MEASURE,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum: 2,
+					SegmentInterval: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  1,
+					},
+					Ttl: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  7,
+					},
+				},
+			},
+		})
+		gomega.Expect(createErr).ShouldNot(gomega.HaveOccurred())
+
+		ginkgo.By("Creating measure schema")
+		_, measureErr := measureRegClient.Create(ctx, &databasev1.MeasureRegistryServiceCreateRequest{
+			Measure: &databasev1.Measure{
+				Metadata: &commonv1.Metadata{
+					Name:  measureName,
+					Group: groupName,
+				},
+				Entity: &databasev1.Entity{
+					TagNames: []string{"id"},
+				},
+				TagFamilies: []*databasev1.TagFamilySpec{{
+					Name: "default",
+					Tags: []*databasev1.TagSpec{
+						{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+						{Name: "region", Type: databasev1.TagType_TAG_TYPE_STRING},
+					},
+				}},
+				Fields: []*databasev1.FieldSpec{{
+					Name:              "value",
+					FieldType:         databasev1.FieldType_FIELD_TYPE_INT,
+					EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+					CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+				}},
+			},
+		})
+		gomega.Expect(measureErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(time.Second)
+
+		ginkgo.By("Writing measure data before the binding")
+		writeClient, writeErr := measureWriteClient.Write(ctx)
+		gomega.Expect(writeErr).ShouldNot(gomega.HaveOccurred())
+		baseTime := time.Now().Truncate(time.Millisecond)
+		for idx := 0; idx < dataCount; idx++ {
+			region := "east"
+			if idx%2 == 0 {
+				region = "west"
+			}
+			gomega.Expect(writeClient.Send(&measurev1.WriteRequest{
+				Metadata: &commonv1.Metadata{Name: measureName, Group: groupName},
+				DataPoint: &measurev1.DataPointValue{
+					Timestamp: timestamppb.New(baseTime.Add(time.Duration(idx) * time.Second)),
+					TagFamilies: []*modelv1.TagFamilyForWrite{{
+						Tags: []*modelv1.TagValue{
+							{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "id_" + strconv.Itoa(idx)}}},
+							{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: region}}},
+						},
+					}},
+					Fields: []*modelv1.FieldValue{{
+						Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: int64(idx)}},
+					}},
+				},
+				MessageId: uint64(time.Now().UnixNano()),
+			})).Should(gomega.Succeed())
+		}
+		gomega.Expect(writeClient.CloseSend()).To(gomega.Succeed())
+		gomega.Eventually(func() error {
+			_, recvErr := writeClient.Recv()
+			return recvErr
+		}, flags.EventuallyTimeout).Should(gomega.Equal(io.EOF))
+
+		ginkgo.By("Binding an index rule to the written tag")
+		_, ruleErr := indexRuleClient.Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{
+			IndexRule: &databasev1.IndexRule{
+				Metadata: &commonv1.Metadata{Name: "region", Group: groupName},
+				Tags:     []string{"region"},
+				Type:     databasev1.IndexRule_TYPE_INVERTED,
+			},
+		})
+		gomega.Expect(ruleErr).ShouldNot(gomega.HaveOccurred())
+		_, bindingErr := bindingClient.Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{
+			IndexRuleBinding: &databasev1.IndexRuleBinding{
+				Metadata: &commonv1.Metadata{Name: "region_binding", Group: groupName},
+				Rules:    []string{"region"},
+				Subject: &databasev1.Subject{
+					Catalog: commonv1.Catalog_CATALOG_MEASURE,
+					Name:    measureName,
+				},
+				BeginAt:  timestamppb.New(time.Now().Add(-time.Hour)),
+				ExpireAt: timestamppb.New(time.Now().Add(24 * time.Hour)),
+			},
+		})
+		gomega.Expect(bindingErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(2 * time.Second)
+	})
+
+	ginkgo.AfterAll(func() {
+		_, _ = groupClient.Delete(ctx, &databasev1.GroupRegistryServiceDeleteRequest{Group: groupName})
+	})
+
+	queryWest := func() (int, error) {
+		now := time.Now().Truncate(time.Millisecond)
+		resp, err := measureWriteClient.Query(ctx, &measurev1.QueryRequest{
+			Groups: []string{groupName},
+			Name:   measureName,
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(now.Add(-time.Hour)),
+				End:   timestamppb.New(now.Add(time.Hour)),
+			},
+			Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+				Name:  "region",
+				Op:    modelv1.Condition_BINARY_OP_EQ,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "west"}}},
+			}}},
+			TagProjection: &modelv1.TagProjection{
+				TagFamilies: []*modelv1.TagProjection_TagFamily{
+					{Name: "default", Tags: []string{"id", "region"}},
+				},
+			},
+			FieldProjection: &measurev1.QueryRequest_FieldProjection{
+				Names: []string{"value"},
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(resp.DataPoints), nil
+	}
+
+	ginkgo.It("should index the data written before the binding", func() {
+		ginkgo.By("Missing the data written before the binding")
+		gomega.Expect(queryWest()).To(gomega.Equal(0))
+
+		ginkgo.By("Scheduling the backfill")
+		resp, err := bindingClient.Backfill(ctx, &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{
+			Metadata: &commonv1.Metadata{Name: "region_binding", Group: groupName},
+		})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(resp.ShardCount).Should(gomega.BeNumerically(">=", 1))
+
+		ginkgo.By("Querying by the backfilled index")
+		gomega.Eventually(queryWest, flags.EventuallyTimeout).Should(gomega.Equal(dataCount / 2))
+	})
+})
+
+var _ = ginkgo.Describe("Backfill the index of trace in standalone mode", ginkgo.Ordered, func() {
+	var groupName string
+	var ctx context.Context
+	const (
+		traceName = "test_trace"
+		dataCount = 10
+	)
+
+	ginkgo.BeforeAll(func() {
+		ctx = context.TODO()
+		groupName = fmt.Sprintf("backfill-trace-test-%d", time.Now().UnixNano())
+
+		ginkgo.By("Creating trace group")
+		_, createErr := groupClient.Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{
+					Name: groupName,
+				},
+				Catalog: commonv1.Catalog_CATALOG_TRACE,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum: 2,
+					SegmentInterval: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  1,
+					},
+					Ttl: &commonv1.IntervalRule{
+						Unit: commonv1.IntervalRule_UNIT_DAY,
+						Num:  7,
+					},
+				},
+			},
+		})
+		gomega.Expect(createErr).ShouldNot(gomega.HaveOccurred())
+
+		ginkgo.By("Creating trace schema")
+		_, traceErr := traceRegClient.Create(ctx, &databasev1.TraceRegistryServiceCreateRequest{
+			Trace: &databasev1.Trace{
+				Metadata: &commonv1.Metadata{
+					Name:  traceName,
+					Group: groupName,
+				},
+				Tags: []*databasev1.TraceTagSpec{
+					{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+					{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+				},
+				TraceIdTagName:   "trace_id",
+				SpanIdTagName:    "span_id",
+				TimestampTagName: "timestamp",
+			},
+		})
+		gomega.Expect(traceErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(time.Second)
+
+		ginkgo.By("Writing trace data before the binding")
+		writeTraceData(ctx, groupName, traceName, dataCount)
+
+		ginkgo.By("Binding an index rule to the written tags")
+		_, ruleErr := indexRuleClient.Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{
+			IndexRule: &databasev1.IndexRule{
+				Metadata: &commonv1.Metadata{Name: "duration", Group: groupName},
+				Tags:     []string{"service_id", "duration"},
+				Type:     databasev1.IndexRule_TYPE_TREE,
+			},
+		})
+		gomega.Expect(ruleErr).ShouldNot(gomega.HaveOccurred())
+		_, bindingErr := bindingClient.Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{
+			IndexRuleBinding: &databasev1.IndexRuleBinding{
+				Metadata: &commonv1.Metadata{Name: "duration_binding", Group: groupName},
+				Rules:    []string{"duration"},
+				Subject: &databasev1.Subject{
+					Catalog: commonv1.Catalog_CATALOG_TRACE,
+					Name:    traceName,
+				},
+				BeginAt:  timestamppb.New(time.Now().Add(-time.Hour)),
+				ExpireAt: timestamppb.New(time.Now().Add(24 * time.Hour)),
+			},
+		})
+		gomega.Expect(bindingErr).ShouldNot(gomega.HaveOccurred())
+		time.Sleep(2 * time.Second)
+	})
+
+	ginkgo.AfterAll(func() {
+		_, _ = groupClient.Delete(ctx, &databasev1.GroupRegistryServiceDeleteRequest{Group: groupName})
+	})
+
+	queryTraces := func() (int, error) {
+		now := time.Now().Truncate(time.Millisecond)
+		resp, err := traceWriteClient.Query(ctx, &tracev1.QueryRequest{
+			Groups: []string{groupName},
+			Name:   traceName,
+			TimeRange: &modelv1.TimeRange{
+				Begin: timestamppb.New(now.Add(-time.Hour)),
+				End:   timestamppb.New(now.Add(time.Hour)),
+			},
+			Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+				Name:  "service_id",
+				Op:    modelv1.Condition_BINARY_OP_EQ,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "test_service"}}},
+			}}},
+			OrderBy: &modelv1.QueryOrder{
+				IndexRuleName: "duration",
+				Sort:          modelv1.Sort_SORT_ASC,
+			},
+			TagProjection: []string{"trace_id", "duration"},
+			Limit:         dataCount,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(resp.Traces), nil
+	}
+
+	ginkgo.It("should index the data written before the binding", func() {
+		ginkgo.By("Missing the data written before the binding")
+		gomega.Expect(queryTraces()).To(gomega.Equal(0))
+
+		ginkgo.By("Scheduling the backfill")
+		resp, err := bindingClient.Backfill(ctx, &databasev1.IndexRuleBindingRegistryServiceBackfillRequest{
+			Metadata: &commonv1.Metadata{Name: "duration_binding", Group: groupName},
+		})
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(resp.ShardCount).Should(gomega.BeNumerically(">=", 1))
+
+		ginkgo.By("Querying by the backfilled index")
+		gomega.Eventually(queryTraces, flags.EventuallyTimeout).Should(gomega.Equal(dataCount))
+	})
+})
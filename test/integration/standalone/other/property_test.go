@@ -25,7 +25,9 @@ import (
 	gm "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
@@ -214,6 +216,112 @@ var _ = g.Describe("Property application", func() {
 			{Key: "t2", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v22"}}}},
 		}))
 	})
+	g.It("applies properties conditionally", func() {
+		newProperty := func(val string) *propertyv1.Property {
+			return &propertyv1.Property{
+				Metadata: &commonv1.Metadata{Name: "p", Group: "g"},
+				Id:       "1",
+				Tags: []*modelv1.Tag{
+					{Key: "t1", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: val}}}},
+				},
+			}
+		}
+		resp, err := client.Apply(context.Background(), &propertyv1.ApplyRequest{
+			Property:     newProperty("v1"),
+			Precondition: &propertyv1.ApplyRequest_MustNotExist{MustNotExist: true},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.Created).To(gm.BeTrue())
+		gm.Expect(resp.ModRevision).To(gm.BeNumerically(">", 0))
+		revision := resp.ModRevision
+		_, err = client.Apply(context.Background(), &propertyv1.ApplyRequest{
+			Property:     newProperty("v2"),
+			Precondition: &propertyv1.ApplyRequest_MustNotExist{MustNotExist: true},
+		})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.FailedPrecondition))
+		_, err = client.Apply(context.Background(), &propertyv1.ApplyRequest{
+			Property:     newProperty("v2"),
+			Precondition: &propertyv1.ApplyRequest_ExpectedModRevision{ExpectedModRevision: revision - 1},
+		})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.FailedPrecondition))
+		resp, err = client.Apply(context.Background(), &propertyv1.ApplyRequest{
+			Property:     newProperty("v3"),
+			Precondition: &propertyv1.ApplyRequest_ExpectedModRevision{ExpectedModRevision: revision},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.Created).To(gm.BeFalse())
+		gm.Expect(resp.ModRevision).To(gm.BeNumerically(">", revision))
+		// the stale revision can't be applied twice
+		_, err = client.Apply(context.Background(), &propertyv1.ApplyRequest{
+			Property:     newProperty("v4"),
+			Precondition: &propertyv1.ApplyRequest_ExpectedModRevision{ExpectedModRevision: revision},
+		})
+		gm.Expect(status.Code(err)).To(gm.Equal(codes.FailedPrecondition))
+		got, err := client.Query(context.Background(), &propertyv1.QueryRequest{
+			Groups: []string{"g"},
+			Name:   "p",
+			Ids:    []string{"1"},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(got.Properties).To(gm.HaveLen(1))
+		gm.Expect(got.Properties[0].Tags[0].Value.GetStr().Value).To(gm.Equal("v3"))
+	})
+	g.It("watches property changes", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := client.Watch(ctx, &propertyv1.WatchRequest{Groups: []string{"g"}, Name: "p"})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		events := make(chan *propertyv1.WatchResponse, 10)
+		go func() {
+			defer close(events)
+			for {
+				event, recvErr := stream.Recv()
+				if recvErr != nil {
+					return
+				}
+				events <- event
+			}
+		}()
+		md := &commonv1.Metadata{Name: "p", Group: "g"}
+		var resp *propertyv1.ApplyResponse
+		// the watch may start after the first apply, keep applying until an event arrives
+		var event *propertyv1.WatchResponse
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, err = client.Apply(context.Background(), &propertyv1.ApplyRequest{Property: &propertyv1.Property{
+				Metadata: md,
+				Id:       "1",
+				Tags: []*modelv1.Tag{
+					{Key: "t1", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "v1"}}}},
+				},
+			}})
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			innerGm.Eventually(events, 3*time.Second).Should(gm.Receive(&event))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+		gm.Expect(event.EventType).To(gm.Equal(propertyv1.WatchEventType_WATCH_EVENT_TYPE_APPLY))
+		gm.Expect(event.Property.Id).To(gm.Equal("1"))
+		applied := resp.ModRevision
+
+		_, err = client.Delete(context.Background(), &propertyv1.DeleteRequest{Group: "g", Name: "p", Id: "1"})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Eventually(events, flags.EventuallyTimeout).Should(gm.Receive(&event))
+		gm.Expect(event.EventType).To(gm.Equal(propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE))
+		gm.Expect(event.Revision).To(gm.BeNumerically(">", applied))
+		cancel()
+
+		// resume from the apply, the deletion is replayed
+		resumeCtx, resumeCancel := context.WithCancel(context.Background())
+		defer resumeCancel()
+		resumed, err := client.Watch(resumeCtx, &propertyv1.WatchRequest{
+			Groups:        []string{"g"},
+			Name:          "p",
+			StartRevision: applied,
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		event, err = resumed.Recv()
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		gm.Expect(event.EventType).To(gm.Equal(propertyv1.WatchEventType_WATCH_EVENT_TYPE_DELETE))
+		gm.Expect(event.Property.Id).To(gm.Equal("1"))
+	})
 })
 
 var _ = g.Describe("Property application", func() {